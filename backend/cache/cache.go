@@ -0,0 +1,179 @@
+// Package cache provides a Redis-backed cache for hot catalog reads. It is configured
+// with REDIS_URL; without it, or while Redis is unreachable, every lookup is a miss
+// and callers fall back to the database.
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Cache keys shared by readers and the handlers that invalidate them
+const (
+	KeyCategoryNames = "categories:names"
+	KeyCategoryTree  = "categories:tree"
+)
+
+// ProductKey returns the cache key of a single product
+func ProductKey(productID string) string {
+	return "product:" + productID
+}
+
+// TrendingKey returns the cache key of a trending products list
+func TrendingKey(days, limit int, category string) string {
+	return "products:trending:" + strconv.Itoa(days) + ":" + strconv.Itoa(limit) + ":" + category
+}
+
+// BoughtTogetherKey returns the cache key of the products bought together with a product
+func BoughtTogetherKey(productID string) string {
+	return "product:" + productID + ":bought-together"
+}
+
+var (
+	client *redisClient
+	hits   atomic.Uint64
+	misses atomic.Uint64
+	errs   atomic.Uint64
+)
+
+// Init connects to Redis if REDIS_URL is set. A failed connection is logged and
+// retried lazily, so the app keeps working against the database alone.
+func Init() {
+	rawURL := os.Getenv("REDIS_URL")
+	if rawURL == "" {
+		log.Println("REDIS_URL not set, catalog cache disabled")
+		return
+	}
+
+	rc, err := newRedisClient(rawURL, commandTimeout())
+	if err != nil {
+		log.Printf("Warning: Invalid REDIS_URL, catalog cache disabled: %v", err)
+		return
+	}
+	client = rc
+
+	if _, err := client.do("PING"); err != nil {
+		log.Printf("Warning: Redis unavailable, falling back to the database: %v", err)
+		return
+	}
+	log.Println("Connected to Redis catalog cache")
+}
+
+// Close closes the Redis connection
+func Close() {
+	if client != nil {
+		client.close()
+	}
+}
+
+// Enabled reports whether a Redis URL is configured
+func Enabled() bool {
+	return client != nil
+}
+
+// TTL returns how long catalog entries are cached
+func TTL() time.Duration {
+	if value := os.Getenv("CACHE_TTL_SECONDS"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return 5 * time.Minute
+}
+
+// Get decodes the value stored under key into dest. It reports false on a miss,
+// when Redis is unavailable or when the stored value cannot be decoded.
+func Get(key string, dest interface{}) bool {
+	if client == nil {
+		return false
+	}
+
+	reply, err := client.do("GET", key)
+	if err != nil {
+		if errors.Is(err, errNil) {
+			misses.Add(1)
+		} else {
+			errs.Add(1)
+		}
+		return false
+	}
+
+	value, ok := reply.(string)
+	if !ok || json.Unmarshal([]byte(value), dest) != nil {
+		errs.Add(1)
+		return false
+	}
+
+	hits.Add(1)
+	return true
+}
+
+// Set stores value under key for the given TTL. Failures are ignored; the next read
+// simply misses.
+func Set(key string, value interface{}, ttl time.Duration) {
+	if client == nil {
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("Failed to encode cache entry %s: %v", key, err)
+		return
+	}
+
+	seconds := int(ttl.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	if _, err := client.do("SET", key, string(data), "EX", strconv.Itoa(seconds)); err != nil {
+		errs.Add(1)
+	}
+}
+
+// Invalidate deletes the given keys
+func Invalidate(keys ...string) {
+	if client == nil || len(keys) == 0 {
+		return
+	}
+
+	args := append([]string{"DEL"}, keys...)
+	if _, err := client.do(args...); err != nil {
+		errs.Add(1)
+		log.Printf("Failed to invalidate cache keys %v: %v", keys, err)
+	}
+}
+
+// Stats returns cache hit/miss statistics
+func Stats() map[string]interface{} {
+	h := hits.Load()
+	m := misses.Load()
+
+	hitRate := 0.0
+	if total := h + m; total > 0 {
+		hitRate = float64(h) / float64(total) * 100
+	}
+
+	return map[string]interface{}{
+		"enabled":     Enabled(),
+		"hits":        h,
+		"misses":      m,
+		"errors":      errs.Load(),
+		"hit_rate":    hitRate,
+		"ttl_seconds": TTL().Seconds(),
+	}
+}
+
+// commandTimeout bounds each Redis round trip so a slow server cannot stall requests
+func commandTimeout() time.Duration {
+	if value := os.Getenv("REDIS_TIMEOUT_MS"); value != "" {
+		if ms, err := strconv.Atoi(value); err == nil && ms > 0 {
+			return time.Duration(ms) * time.Millisecond
+		}
+	}
+	return 200 * time.Millisecond
+}
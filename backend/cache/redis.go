@@ -0,0 +1,223 @@
+package cache
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errNil is returned for a nil bulk reply, i.e. a missing key
+var errNil = errors.New("redis: nil")
+
+// errUnavailable is returned while the client waits before redialing a failed server
+var errUnavailable = errors.New("redis: unavailable")
+
+// redialBackoff is how long the client waits before redialing after a failed connect,
+// so requests are not slowed down by a dial timeout each while Redis is down
+const redialBackoff = 30 * time.Second
+
+// redisClient is a minimal Redis client speaking RESP over a single connection.
+// Commands are serialized; a failed connection is dropped and redialed on the next command.
+type redisClient struct {
+	addr     string
+	useTLS   bool
+	password string
+	username string
+	db       int
+	timeout  time.Duration
+
+	mu      sync.Mutex
+	conn    net.Conn
+	reader  *bufio.Reader
+	retryAt time.Time
+}
+
+// newRedisClient parses a redis:// or rediss:// URL
+func newRedisClient(rawURL string, timeout time.Duration) (*redisClient, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	client := &redisClient{
+		addr:    u.Host,
+		useTLS:  u.Scheme == "rediss",
+		timeout: timeout,
+	}
+	if u.Port() == "" {
+		client.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			client.username = u.User.Username()
+			client.password = password
+		} else {
+			client.password = u.User.Username()
+		}
+	}
+	if path := strings.TrimPrefix(u.Path, "/"); path != "" {
+		if client.db, err = strconv.Atoi(path); err != nil {
+			return nil, fmt.Errorf("invalid database %q", path)
+		}
+	}
+	return client, nil
+}
+
+// do runs a command and returns its reply
+func (rc *redisClient) do(args ...string) (interface{}, error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.conn == nil {
+		if time.Now().Before(rc.retryAt) {
+			return nil, errUnavailable
+		}
+		if err := rc.connect(); err != nil {
+			rc.retryAt = time.Now().Add(redialBackoff)
+			return nil, err
+		}
+	}
+
+	reply, err := rc.roundTrip(args)
+	var redisErr redisError
+	if err != nil && !errors.Is(err, errNil) && !errors.As(err, &redisErr) {
+		// The connection is in an unknown state after a network error
+		rc.conn.Close()
+		rc.conn = nil
+	}
+	return reply, err
+}
+
+// connect dials the server, authenticating and selecting the database if configured
+func (rc *redisClient) connect() error {
+	dialer := &net.Dialer{Timeout: rc.timeout}
+	var conn net.Conn
+	var err error
+	if rc.useTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", rc.addr, &tls.Config{MinVersion: tls.VersionTLS12})
+	} else {
+		conn, err = dialer.Dial("tcp", rc.addr)
+	}
+	if err != nil {
+		return err
+	}
+	rc.conn = conn
+	rc.reader = bufio.NewReader(conn)
+
+	if rc.password != "" {
+		args := []string{"AUTH", rc.password}
+		if rc.username != "" {
+			args = []string{"AUTH", rc.username, rc.password}
+		}
+		if _, err := rc.roundTrip(args); err != nil {
+			conn.Close()
+			rc.conn = nil
+			return err
+		}
+	}
+	if rc.db != 0 {
+		if _, err := rc.roundTrip([]string{"SELECT", strconv.Itoa(rc.db)}); err != nil {
+			conn.Close()
+			rc.conn = nil
+			return err
+		}
+	}
+	return nil
+}
+
+// roundTrip writes a command and reads its reply within the client timeout
+func (rc *redisClient) roundTrip(args []string) (interface{}, error) {
+	if err := rc.conn.SetDeadline(time.Now().Add(rc.timeout)); err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := rc.conn.Write([]byte(b.String())); err != nil {
+		return nil, err
+	}
+	return rc.readReply()
+}
+
+// redisError is an error reply sent by the server
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// readReply reads a single RESP reply
+func (rc *redisClient) readReply() (interface{}, error) {
+	line, err := rc.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, errNil
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(rc.reader, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, errNil
+		}
+		items := make([]interface{}, 0, count)
+		for i := 0; i < count; i++ {
+			item, err := rc.readReply()
+			if err != nil && !errors.Is(err, errNil) {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
+
+// close closes the connection if open
+func (rc *redisClient) close() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.conn != nil {
+		rc.conn.Close()
+		rc.conn = nil
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/services"
+)
+
+// runCommand runs a one-off maintenance command named by the first argument instead
+// of starting the server, returning the process exit code
+func runCommand(args []string) int {
+	switch args[0] {
+	case "backfill-daily-stats":
+		return backfillDailyStats(args[1:])
+	default:
+		log.Printf("Unknown command %q. Available commands: backfill-daily-stats", args[0])
+		return 2
+	}
+}
+
+// backfillDailyStats populates daily_user_stats for past days from the raw tables.
+// Usage: main backfill-daily-stats [-days 365] [-from 2024-01-01] [-to 2024-12-31]
+func backfillDailyStats(args []string) int {
+	flags := flag.NewFlagSet("backfill-daily-stats", flag.ContinueOnError)
+	days := flags.Int("days", 365, "Number of days before today to backfill, ignored when -from is set")
+	fromFlag := flags.String("from", "", "First day to backfill (YYYY-MM-DD)")
+	toFlag := flags.String("to", "", "Last day to backfill (YYYY-MM-DD), defaults to yesterday")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	to := services.StartOfDayUTC(time.Now()).AddDate(0, 0, -1)
+	if *toFlag != "" {
+		parsed, err := time.Parse("2006-01-02", *toFlag)
+		if err != nil {
+			log.Printf("Invalid -to date %q, expected YYYY-MM-DD", *toFlag)
+			return 2
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, 1-*days)
+	if *fromFlag != "" {
+		parsed, err := time.Parse("2006-01-02", *fromFlag)
+		if err != nil {
+			log.Printf("Invalid -from date %q, expected YYYY-MM-DD", *fromFlag)
+			return 2
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		log.Printf("Nothing to backfill: %s is after %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+		return 2
+	}
+
+	log.Printf("Backfilling daily user stats from %s to %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	rows, err := services.BackfillDailyUserStats(database.DB, from, to)
+	if err != nil {
+		log.Printf("Backfill failed after %d user-days: %v", rows, err)
+		return 1
+	}
+
+	log.Printf("Backfill completed: %d user-days written", rows)
+	return 0
+}
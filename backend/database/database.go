@@ -1,13 +1,17 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"bachelor_backend/models"
+	"bachelor_backend/pkg/metrics"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -61,6 +65,8 @@ func Connect() {
 	// Open database connection with enhanced configuration
 	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logLevel),
+		// Map driver errors such as unique violations to gorm.ErrDuplicatedKey
+		TranslateError: true,
 		NowFunc: func() time.Time {
 			return time.Now().UTC()
 		},
@@ -73,6 +79,10 @@ func Connect() {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
+	if err := registerMetricsCallbacks(DB); err != nil {
+		log.Fatal("Failed to register database metrics callbacks:", err)
+	}
+
 	// Configure connection pool for better performance and reliability
 	sqlDB, err := DB.DB()
 	if err != nil {
@@ -108,14 +118,23 @@ func Connect() {
 
 // AutoMigrate runs auto-migration for all models
 func AutoMigrate() error {
+	// Convert decimal amounts to cents before the models declare them as bigint
+	if err := migrateMoneyColumns(); err != nil {
+		log.Printf("Warning: Failed to convert monetary columns to cents: %v", err)
+	}
+
 	// Migrate models one by one to handle potential constraint issues
 	models := []interface{}{
 		&models.User{},
+		&models.Category{},
 		&models.Product{},
+		&models.ProductImage{},
+		&models.ProductVariant{},
 		&models.Order{},
 		&models.OrderItem{},
 		&models.ShoppingCart{},
 		&models.CartItem{},
+		&models.SavedItem{},
 		&models.UserInteraction{},
 		&models.SearchQuery{},
 		&models.Recommendation{},
@@ -123,6 +142,8 @@ func AutoMigrate() error {
 		&models.UserSession{},
 		&models.ProductView{},
 		&models.SearchAnalytics{},
+		&models.SearchClick{},
+		&models.SavedSearch{},
 		&models.MLModelPerformance{},
 		&models.Favorite{},
 		&models.Upvote{},
@@ -133,6 +154,34 @@ func AutoMigrate() error {
 		&models.RequestLog{},
 		&models.AnomalyAlert{},
 		&models.SecurityMetrics{},
+		&models.NotificationPreference{},
+		&models.UserPreference{},
+		&models.StockMovement{},
+		&models.OrderStatusHistory{},
+		&models.Refund{},
+		&models.RefundItem{},
+		&models.Notification{},
+		&models.NotificationSuppression{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.PasswordResetToken{},
+		&models.Address{},
+		&models.StockReservation{},
+		&models.WishlistShare{},
+		&models.PriceHistory{},
+		&models.PriceAlert{},
+		&models.SentimentSnapshot{},
+		&models.TrainingJob{},
+		&models.SearchConfig{},
+		&models.IPBlock{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.DailyUserStats{},
+		&models.AnalyticsSchedule{},
+		&models.GeneratedReport{},
+		&models.Experiment{},
+		&models.ExperimentAssignment{},
+		&models.RecommendationImpression{},
 	}
 
 	var migrationErrors []error
@@ -152,12 +201,23 @@ func AutoMigrate() error {
 		log.Printf("Warning: Failed to create uuid-ossp extension: %v", err)
 	}
 
+	// Trigram similarity for "did you mean" search suggestions
+	if err := DB.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		log.Printf("Warning: Failed to create pg_trgm extension: %v", err)
+	}
+
 	// Add custom constraints and indexes
 	if err := addCustomConstraints(); err != nil {
 		log.Printf("Warning: Failed to add custom constraints: %v", err)
 		migrationErrors = append(migrationErrors, err)
 	}
 
+	// Backfill data for columns added after rows already existed
+	if err := backfillData(); err != nil {
+		log.Printf("Warning: Failed to backfill data: %v", err)
+		migrationErrors = append(migrationErrors, err)
+	}
+
 	// If there were migration errors but some models succeeded, log them but don't fail
 	if len(migrationErrors) > 0 {
 		log.Printf("Migration completed with %d warnings/errors", len(migrationErrors))
@@ -169,16 +229,105 @@ func AutoMigrate() error {
 	return nil
 }
 
+// moneyColumns are the columns holding monetary amounts, stored as bigint cents
+var moneyColumns = []struct{ table, column string }{
+	{"products", "price"},
+	{"product_variants", "price_override"},
+	{"orders", "total"},
+	{"orders", "subtotal"},
+	{"orders", "discount_total"},
+	{"orders", "coupon_discount"},
+	{"orders", "tax_total"},
+	{"orders", "shipping_cost"},
+	{"order_items", "price"},
+	{"refunds", "amount"},
+	{"user_preferences", "min_price"},
+	{"user_preferences", "max_price"},
+	{"price_histories", "old_price"},
+	{"price_histories", "new_price"},
+	{"price_alerts", "old_price"},
+	{"price_alerts", "new_price"},
+	{"daily_user_stats", "spend"},
+	{"discounts", "min_order_amount"},
+	{"discounts", "max_discount_amount"},
+}
+
+// migrateMoneyColumns converts monetary columns created as decimal amounts in
+// currency units to bigint cents. Columns that are already bigint, and tables that
+// do not exist yet, are left alone.
+func migrateMoneyColumns() error {
+	for _, mc := range moneyColumns {
+		var dataType string
+		if err := DB.Raw(`
+			SELECT data_type FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?
+		`, mc.table, mc.column).Scan(&dataType).Error; err != nil {
+			return fmt.Errorf("failed to inspect %s.%s: %w", mc.table, mc.column, err)
+		}
+		if dataType == "" || dataType == "bigint" {
+			continue
+		}
+
+		if err := DB.Exec(`ALTER TABLE ` + mc.table + ` ALTER COLUMN ` + mc.column +
+			` TYPE bigint USING ROUND(` + mc.column + ` * 100)::bigint`).Error; err != nil {
+			return fmt.Errorf("failed to convert %s.%s to cents: %w", mc.table, mc.column, err)
+		}
+		log.Printf("Converted %s.%s to cents", mc.table, mc.column)
+	}
+	return nil
+}
+
 // addCustomConstraints adds custom database constraints and indexes
 func addCustomConstraints() error {
-	// Add unique constraint for cart_id + product_id combination in cart_items
+	// Add unique constraint for cart_id + product_id + variant_id combination in cart_items;
+	// a product without variants has a single line per cart
 	if err := DB.Exec(`
-		CREATE UNIQUE INDEX IF NOT EXISTS idx_cart_items_cart_product 
-		ON cart_items(cart_id, product_id)
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_cart_items_cart_product_variant
+		ON cart_items(cart_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'::uuid))
 	`).Error; err != nil {
 		return fmt.Errorf("failed to create unique index on cart_items: %w", err)
 	}
 
+	// Superseded by idx_cart_items_cart_product_variant
+	if err := DB.Exec(`DROP INDEX IF EXISTS idx_cart_items_cart_product`).Error; err != nil {
+		return fmt.Errorf("failed to drop old unique index on cart_items: %w", err)
+	}
+
+	// A user favorites and upvotes a product at most once; drop duplicates left by
+	// concurrent requests from before the indexes existed, keeping the earliest
+	for _, table := range []string{"favorites", "upvotes"} {
+		if err := DB.Exec(`
+			DELETE FROM ` + table + ` a USING ` + table + ` b
+			WHERE a.user_id = b.user_id AND a.product_id = b.product_id
+			AND (a.created_at, a.id) > (b.created_at, b.id)
+		`).Error; err != nil {
+			return fmt.Errorf("failed to remove duplicate %s: %w", table, err)
+		}
+
+		if err := DB.Exec(`
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_` + table + `_user_product
+			ON ` + table + `(user_id, product_id)
+		`).Error; err != nil {
+			return fmt.Errorf("failed to create unique index on %s: %w", table, err)
+		}
+	}
+
+	// A tag is attached to a product at most once; drop duplicate associations first
+	if err := DB.Exec(`
+		DELETE FROM product_tags a USING product_tags b
+		WHERE a.product_id = b.product_id AND a.tag_id = b.tag_id
+		AND (a.created_at, a.ctid) > (b.created_at, b.ctid)
+	`).Error; err != nil {
+		return fmt.Errorf("failed to remove duplicate product tags: %w", err)
+	}
+
+	if err := DB.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_product_tags_product_tag
+		ON product_tags(product_id, tag_id)
+	`).Error; err != nil {
+		return fmt.Errorf("failed to create unique index on product_tags: %w", err)
+	}
+
 	// Add unique constraint for user_id + product_id + algorithm_type in recommendations
 	if err := DB.Exec(`
 		CREATE UNIQUE INDEX IF NOT EXISTS idx_recommendations_user_product_algorithm 
@@ -187,15 +336,133 @@ func addCustomConstraints() error {
 		return fmt.Errorf("failed to create unique index on recommendations: %w", err)
 	}
 
-	// Add check constraints for valid order statuses
+	// Full-text search vector over product name, category and description
+	if err := DB.Exec(`
+		ALTER TABLE products 
+		ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+			setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+			setweight(to_tsvector('english', coalesce(category, '')), 'B') ||
+			setweight(to_tsvector('english', coalesce(description, '')), 'C')
+		) STORED
+	`).Error; err != nil {
+		return fmt.Errorf("failed to add search_vector column to products: %w", err)
+	}
+
+	if err := DB.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_products_search_vector 
+		ON products USING GIN(search_vector)
+	`).Error; err != nil {
+		return fmt.Errorf("failed to create search_vector index on products: %w", err)
+	}
+
+	// One analytics row per search text
+	if err := DB.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_search_analytics_query_unique 
+		ON search_analytics(query)
+	`).Error; err != nil {
+		return fmt.Errorf("failed to create unique index on search_analytics: %w", err)
+	}
+
+	// Prefix lookups for search suggestions
+	if err := DB.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_search_queries_query_prefix 
+		ON search_queries (LOWER(query) text_pattern_ops)
+	`).Error; err != nil {
+		return fmt.Errorf("failed to create prefix index on search_queries: %w", err)
+	}
+
+	if err := DB.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_products_name_prefix 
+		ON products (LOWER(name) text_pattern_ops)
+	`).Error; err != nil {
+		return fmt.Errorf("failed to create prefix index on products: %w", err)
+	}
+
+	// Trigram lookups for "did you mean" suggestions; optional when pg_trgm is unavailable
+	if err := DB.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_products_name_trgm 
+		ON products USING GIN (LOWER(name) gin_trgm_ops)
+	`).Error; err != nil {
+		log.Printf("Warning: Failed to create trigram index on products: %v", err)
+	}
+
+	if err := DB.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_search_queries_query_trgm 
+		ON search_queries USING GIN (LOWER(TRIM(query)) gin_trgm_ops)
+	`).Error; err != nil {
+		log.Printf("Warning: Failed to create trigram index on search_queries: %v", err)
+	}
+
+	// At most one primary image per product
+	if err := DB.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_product_images_primary 
+		ON product_images(product_id) WHERE is_primary
+	`).Error; err != nil {
+		return fmt.Errorf("failed to create primary image index on product_images: %w", err)
+	}
+
+	// Tag slugs are unique once set; tags from before slugs existed are backfilled
+	if err := DB.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_slug 
+		ON tags(slug) WHERE slug <> ''
+	`).Error; err != nil {
+		return fmt.Errorf("failed to create slug index on tags: %w", err)
+	}
+
+	// At most one ML training job runs at a time
 	if err := DB.Exec(`
-		ALTER TABLE orders 
-		ADD CONSTRAINT IF NOT EXISTS check_order_status 
-		CHECK (status IN ('pending', 'processing', 'shipped', 'delivered', 'cancelled'))
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_training_jobs_running 
+		ON training_jobs((true)) WHERE status = 'running'
+	`).Error; err != nil {
+		return fmt.Errorf("failed to create running job index on training_jobs: %w", err)
+	}
+
+	// At most one default address per user
+	if err := DB.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_addresses_default 
+		ON addresses(user_id) WHERE is_default
+	`).Error; err != nil {
+		return fmt.Errorf("failed to create default address index on addresses: %w", err)
+	}
+
+	// Add check constraints for valid order statuses. Databases created before the
+	// refund statuses existed have a constraint without them, which is replaced.
+	if err := DB.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_constraint
+				WHERE conname = 'check_order_status' AND conrelid = 'orders'::regclass
+					AND pg_get_constraintdef(oid) LIKE '%''partially_refunded''%'
+					AND pg_get_constraintdef(oid) LIKE '%''refunded''%'
+			) THEN
+				ALTER TABLE orders DROP CONSTRAINT IF EXISTS check_order_status;
+				ALTER TABLE orders 
+				ADD CONSTRAINT check_order_status 
+				CHECK (status IN ('pending', 'processing', 'shipped', 'delivered', 'cancelled', 'partially_refunded', 'refunded'));
+			END IF;
+		END $$
 	`).Error; err != nil {
 		log.Printf("Warning: Failed to add order status check constraint: %v", err)
 	}
 
+	// Every cart belongs to either a user or a guest session
+	if err := DB.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_constraint
+				WHERE conname = 'check_shopping_cart_owner' AND conrelid = 'shopping_carts'::regclass
+			) THEN
+				ALTER TABLE shopping_carts 
+				ADD CONSTRAINT check_shopping_cart_owner 
+				CHECK (user_id IS NOT NULL OR session_id IS NOT NULL);
+			END IF;
+		END $$
+	`).Error; err != nil {
+		log.Printf("Warning: Failed to add shopping cart owner check constraint: %v", err)
+	}
+
 	// Add check constraints for valid interaction types
 	if err := DB.Exec(`
 		ALTER TABLE user_interactions 
@@ -218,6 +485,149 @@ func addCustomConstraints() error {
 	return nil
 }
 
+// backfillData fills in values for columns introduced after rows already existed
+func backfillData() error {
+	// Existing users become customers
+	if err := DB.Exec(`
+		UPDATE users SET role = 'customer' WHERE role IS NULL OR role = ''
+	`).Error; err != nil {
+		return fmt.Errorf("failed to backfill user roles: %w", err)
+	}
+
+	// Fixed discounts were stored in discount_value in currency units before amount_off
+	if err := DB.Exec(`
+		UPDATE discounts SET amount_off = ROUND(discount_value * 100)::bigint, discount_value = 0
+		WHERE discount_type = 'fixed_amount' AND amount_off = 0 AND discount_value > 0
+	`).Error; err != nil {
+		return fmt.Errorf("failed to backfill discount amounts: %w", err)
+	}
+
+	// Error counts were only kept as a rate before they were counted directly
+	if err := DB.Exec(`
+		UPDATE security_metrics SET error_requests = ROUND(error_rate * total_requests)
+		WHERE error_requests = 0 AND error_rate > 0
+	`).Error; err != nil {
+		return fmt.Errorf("failed to backfill security metric error counts: %w", err)
+	}
+
+	// Orders placed before discounts were applied were charged their subtotal
+	if err := DB.Exec(`
+		UPDATE orders SET subtotal = total WHERE subtotal = 0 AND discount_total = 0
+	`).Error; err != nil {
+		return fmt.Errorf("failed to backfill order subtotals: %w", err)
+	}
+
+	// Create categories from the free-text product categories; names that only differ
+	// in case or punctuation share one category
+	if err := DB.Exec(`
+		INSERT INTO categories (name, slug, created_at, updated_at)
+		SELECT DISTINCT ON (slug) name, slug, NOW(), NOW() FROM (
+			SELECT TRIM(category) AS name,
+				TRIM(BOTH '-' FROM LOWER(REGEXP_REPLACE(TRIM(category), '[^a-zA-Z0-9]+', '-', 'g'))) AS slug
+			FROM products
+		) names
+		WHERE slug <> ''
+		ORDER BY slug, name
+		ON CONFLICT (slug) DO NOTHING
+	`).Error; err != nil {
+		return fmt.Errorf("failed to backfill categories: %w", err)
+	}
+
+	if err := DB.Exec(`
+		UPDATE products SET category_id = categories.id
+		FROM categories
+		WHERE products.category_id IS NULL
+		AND categories.slug = TRIM(BOTH '-' FROM LOWER(REGEXP_REPLACE(TRIM(products.category), '[^a-zA-Z0-9]+', '-', 'g')))
+	`).Error; err != nil {
+		return fmt.Errorf("failed to link products to categories: %w", err)
+	}
+
+	// Flag existing comments by users who received the product
+	if err := DB.Exec(`
+		UPDATE comments SET verified_purchase = true
+		WHERE verified_purchase = false AND EXISTS (
+			SELECT 1 FROM order_items oi JOIN orders o ON o.id = oi.order_id
+			WHERE o.user_id = comments.user_id AND oi.product_id = comments.product_id
+			AND o.status IN ('delivered', 'completed')
+		)
+	`).Error; err != nil {
+		return fmt.Errorf("failed to backfill verified purchases: %w", err)
+	}
+
+	// Recommendations stored before generation times were tracked count as generated when created
+	if err := DB.Exec(`
+		UPDATE recommendations SET generated_at = created_at WHERE generated_at IS NULL
+	`).Error; err != nil {
+		return fmt.Errorf("failed to backfill recommendation generation times: %w", err)
+	}
+
+	// Derive slugs for existing tags; names that share a slug are numbered in creation order
+	if err := DB.Exec(`
+		UPDATE tags SET slug = numbered.slug
+		FROM (
+			SELECT id, base || CASE WHEN ROW_NUMBER() OVER w > 1 THEN '-' || ROW_NUMBER() OVER w ELSE '' END AS slug
+			FROM (
+				SELECT id, created_at, COALESCE(NULLIF(TRIM(BOTH '-' FROM LOWER(REGEXP_REPLACE(TRIM(name), '[^a-zA-Z0-9]+', '-', 'g'))), ''), 'tag') AS base
+				FROM tags WHERE slug = ''
+			) bases
+			WINDOW w AS (PARTITION BY base ORDER BY created_at, id)
+		) numbered
+		WHERE tags.id = numbered.id
+	`).Error; err != nil {
+		return fmt.Errorf("failed to backfill tag slugs: %w", err)
+	}
+
+	// Promote bootstrap administrators listed in ADMIN_EMAILS
+	if adminEmails := getEnv("ADMIN_EMAILS", ""); adminEmails != "" {
+		var emails []string
+		for _, email := range strings.Split(adminEmails, ",") {
+			if email = strings.TrimSpace(email); email != "" {
+				emails = append(emails, email)
+			}
+		}
+		if len(emails) > 0 {
+			if err := DB.Exec("UPDATE users SET role = 'admin' WHERE email IN ?", emails).Error; err != nil {
+				return fmt.Errorf("failed to promote admin users: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// registerMetricsCallbacks counts every statement GORM executes, by operation
+func registerMetricsCallbacks(db *gorm.DB) error {
+	count := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			outcome := "success"
+			if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+				outcome = "error"
+			}
+			metrics.DBQueriesTotal.Inc(operation, outcome)
+		}
+	}
+
+	callbacks := db.Callback()
+	registrations := []error{
+		callbacks.Create().After("gorm:create").Register("metrics:create", count("create")),
+		callbacks.Query().After("gorm:query").Register("metrics:query", count("query")),
+		callbacks.Update().After("gorm:update").Register("metrics:update", count("update")),
+		callbacks.Delete().After("gorm:delete").Register("metrics:delete", count("delete")),
+		callbacks.Row().After("gorm:row").Register("metrics:row", count("row")),
+		callbacks.Raw().After("gorm:raw").Register("metrics:raw", count("raw")),
+	}
+	return errors.Join(registrations...)
+}
+
+// Ping checks that the database is reachable
+func Ping(ctx context.Context) error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
 // GetDB returns the database instance
 func GetDB() *gorm.DB {
 	return DB
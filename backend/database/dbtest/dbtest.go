@@ -0,0 +1,142 @@
+// Package dbtest connects tests to a PostgreSQL database and creates fixtures in it.
+//
+// Tests using it are skipped unless TEST_DATABASE_URL is set, e.g.
+// TEST_DATABASE_URL="host=localhost user=postgres password=postgres dbname=bachelor_test sslmode=disable".
+// The database must be a disposable one: it is migrated and tests write to it. Every
+// test package migrates it on first use, so run them one at a time with go test -p 1.
+package dbtest
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/models"
+	"bachelor_backend/pkg/money"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	migrateOnce sync.Once
+	migrateErr  error
+)
+
+// Open points database.DB at the test database, migrating it on first use,
+// and skips the test when TEST_DATABASE_URL is not set
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	migrateOnce.Do(func() {
+		database.DB, migrateErr = gorm.Open(postgres.Open(dsn), &gorm.Config{
+			Logger:         logger.Default.LogMode(logger.Silent),
+			TranslateError: true,
+			NowFunc: func() time.Time {
+				return time.Now().UTC()
+			},
+		})
+		if migrateErr == nil {
+			migrateErr = database.AutoMigrate()
+		}
+	})
+	if migrateErr != nil {
+		t.Fatalf("failed to prepare test database: %v", migrateErr)
+	}
+
+	return database.DB
+}
+
+// CreateUser creates a customer that is deleted, with its cart, after the test. Orders
+// keep a user from being deleted, so create them with CreateOrder.
+func CreateUser(t testing.TB, db *gorm.DB) models.User {
+	t.Helper()
+
+	id := uuid.New()
+	user := models.User{
+		ID:           id,
+		Email:        fmt.Sprintf("test-%s@example.com", id),
+		Name:         "Test User",
+		PasswordHash: "not-a-real-hash",
+		Role:         "customer",
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Delete(&models.User{}, "id = ?", user.ID).Error; err != nil {
+			t.Errorf("failed to delete user %s: %v", user.ID, err)
+		}
+	})
+	return user
+}
+
+// CreateProduct creates a product with the given price and stock that is deleted after
+// the test. Create it before the users and orders that reference it, so they are
+// cleaned up first.
+func CreateProduct(t testing.TB, db *gorm.DB, price money.Cents, stock int) models.Product {
+	t.Helper()
+
+	product := models.Product{
+		ID:       uuid.New(),
+		Name:     "Test Product",
+		Price:    price,
+		Category: "Test",
+		Stock:    stock,
+	}
+	if err := db.Create(&product).Error; err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Unscoped().Delete(&models.Product{}, "id = ?", product.ID).Error; err != nil {
+			t.Errorf("failed to delete product %s: %v", product.ID, err)
+		}
+	})
+	return product
+}
+
+// CreateOrder creates a pending order for one line of the product that is deleted after
+// the test. The product's stock is left as it is.
+func CreateOrder(t testing.TB, db *gorm.DB, user models.User, product models.Product, quantity int) models.Order {
+	t.Helper()
+
+	subtotal := product.Price.Mul(quantity)
+	order := models.Order{
+		ID:       uuid.New(),
+		UserID:   user.ID,
+		Status:   "pending",
+		Subtotal: subtotal,
+		Total:    subtotal,
+	}
+	if err := db.Create(&order).Error; err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Delete(&models.Order{}, "id = ?", order.ID).Error; err != nil {
+			t.Errorf("failed to delete order %s: %v", order.ID, err)
+		}
+	})
+
+	item := models.OrderItem{
+		ID:        uuid.New(),
+		OrderID:   order.ID,
+		ProductID: product.ID,
+		Quantity:  quantity,
+		Price:     product.Price,
+	}
+	if err := db.Create(&item).Error; err != nil {
+		t.Fatalf("failed to create order item: %v", err)
+	}
+	order.OrderItems = []models.OrderItem{item}
+	return order
+}
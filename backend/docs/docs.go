@@ -24,14 +24,14 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/analytics/dashboard": {
+        "/addresses": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get comprehensive dashboard analytics including user statistics and recent interactions",
+                "description": "Get the current user's saved shipping addresses, default address first",
                 "consumes": [
                     "application/json"
                 ],
@@ -39,12 +39,12 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Analytics"
+                    "Addresses"
                 ],
-                "summary": "Get dashboard analytics",
+                "summary": "Get saved addresses",
                 "responses": {
                     "200": {
-                        "description": "Dashboard analytics retrieved successfully",
+                        "description": "Addresses retrieved successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -56,55 +56,54 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
-            }
-        },
-        "/analytics/export": {
-            "get": {
+            },
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Export user analytics data in JSON or CSV format",
+                "description": "Save a shipping address to the current user's address book. The first address becomes the default automatically.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json",
-                    "text/csv"
+                    "application/json"
                 ],
                 "tags": [
-                    "Analytics"
+                    "Addresses"
                 ],
-                "summary": "Export analytics data",
+                "summary": "Save address",
                 "parameters": [
                     {
-                        "type": "string",
-                        "default": "\"json\"",
-                        "description": "Export format (json, csv)",
-                        "name": "format",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 30,
-                        "description": "Number of days to export",
-                        "name": "days",
-                        "in": "query"
+                        "description": "Address details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateAddressRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Analytics data exported successfully",
+                    "201": {
+                        "description": "Address saved successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Unsupported format",
+                        "description": "Invalid request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -116,18 +115,25 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
             }
         },
-        "/analytics/products": {
+        "/addresses/{id}": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get product performance analytics including top selling products, most viewed, and category performance",
+                "description": "Get one of the current user's saved shipping addresses",
                 "consumes": [
                     "application/json"
                 ],
@@ -135,28 +141,27 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Analytics"
+                    "Addresses"
                 ],
-                "summary": "Get product analytics",
+                "summary": "Get saved address",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "default": 30,
-                        "description": "Number of days to analyze",
-                        "name": "days",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 20,
-                        "description": "Number of items to return",
-                        "name": "limit",
-                        "in": "query"
+                        "type": "string",
+                        "description": "Address ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Product analytics retrieved successfully",
+                        "description": "Address retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Address"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid address ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -169,24 +174,22 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "404": {
+                        "description": "Address not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
                 }
-            }
-        },
-        "/analytics/recommendations/metrics": {
-            "get": {
+            },
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get recommendation system performance metrics",
+                "description": "Update a saved shipping address. Orders already placed keep the address they were shipped to.",
                 "consumes": [
                     "application/json"
                 ],
@@ -194,12 +197,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Analytics"
+                    "Addresses"
+                ],
+                "summary": "Update address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Address ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateAddressRequest"
+                        }
+                    }
                 ],
-                "summary": "Get recommendation metrics",
                 "responses": {
                     "200": {
-                        "description": "Recommendation metrics retrieved successfully",
+                        "description": "Address updated successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -212,6 +240,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "404": {
+                        "description": "Address not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -220,16 +255,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/analytics/search": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get search analytics and query performance metrics",
+                "description": "Remove a saved shipping address. Deleting the default address makes the oldest remaining address the default.",
                 "consumes": [
                     "application/json"
                 ],
@@ -237,21 +270,28 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Analytics"
+                    "Addresses"
                 ],
-                "summary": "Get search analytics",
+                "summary": "Delete address",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "default": 30,
-                        "description": "Number of days to analyze",
-                        "name": "days",
-                        "in": "query"
+                        "type": "string",
+                        "description": "Address ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Search analytics retrieved successfully",
+                        "description": "Address deleted successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid address ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -264,6 +304,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "404": {
+                        "description": "Address not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -274,14 +321,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/analytics/trends": {
+        "/admin/search-config": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get ML-powered trend analysis and predictions",
+                "description": "Get the ranking weights of product search (admin only): how much a match in the name, category or description counts, each between 0 and 1. Source is 'database' once an admin has set the weights and 'environment' before that.",
                 "consumes": [
                     "application/json"
                 ],
@@ -289,21 +336,12 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Analytics"
-                ],
-                "summary": "Get ML trends",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "default": 30,
-                        "description": "Number of days to analyze",
-                        "name": "days",
-                        "in": "query"
-                    }
+                    "Admin"
                 ],
+                "summary": "Get search configuration",
                 "responses": {
                     "200": {
-                        "description": "ML trends retrieved successfully",
+                        "description": "Search configuration retrieved successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -316,6 +354,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -324,16 +369,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/analytics/user": {
-            "get": {
+            },
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get detailed user analytics including interaction stats, category preferences, and spending patterns",
+                "description": "Set the ranking weights of product search (admin only). Each weight is between 0 and 1; searches use the new weights right away.",
                 "consumes": [
                     "application/json"
                 ],
@@ -341,21 +384,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Analytics"
+                    "Admin"
                 ],
-                "summary": "Get user analytics",
+                "summary": "Update search configuration",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "default": 30,
-                        "description": "Number of days to analyze",
-                        "name": "days",
-                        "in": "query"
+                        "description": "Ranking weights",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.SearchWeights"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User analytics retrieved successfully",
+                        "description": "Search configuration updated successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid weights",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -368,6 +420,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -378,9 +437,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/login": {
-            "post": {
-                "description": "Authenticate user with email and password",
+        "/analytics/dashboard": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get comprehensive dashboard analytics including user statistics and recent interactions. Cached per user for ANALYTICS_CACHE_TTL_SECONDS (default 5 minutes), so figures can lag by up to that long; the X-Cache header reports HIT, MISS or BYPASS. Placing an order clears the caller's cached analytics.",
                 "consumes": [
                     "application/json"
                 ],
@@ -388,56 +452,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Authentication"
+                    "Analytics"
                 ],
-                "summary": "User login",
+                "summary": "Get dashboard analytics",
                 "parameters": [
                     {
-                        "description": "Login credentials",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.LoginRequest"
-                        }
+                        "type": "boolean",
+                        "description": "Bypass the analytics cache",
+                        "name": "nocache",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Login successful",
-                        "schema": {
-                            "$ref": "#/definitions/handlers.AuthResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid request body or validation error",
+                        "description": "Dashboard analytics retrieved successfully",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "401": {
-                        "description": "Invalid email or password",
-                        "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal server error",
+                        "description": "User not authenticated",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/auth/profile": {
+        "/analytics/experiments": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get the authenticated user's comprehensive profile information including statistics and recent activity",
+                "description": "Get all recommendation algorithm experiments, newest first. Admin only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -445,37 +496,47 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Authentication"
+                    "Analytics"
                 ],
-                "summary": "Get user profile",
+                "summary": "Get experiments",
                 "responses": {
                     "200": {
-                        "description": "User profile retrieved successfully",
+                        "description": "Experiments retrieved successfully",
                         "schema": {
-                            "$ref": "#/definitions/handlers.UserProfileResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "401": {
                         "description": "User not authenticated",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "User not found",
+                    "403": {
+                        "description": "Admin role required",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             },
-            "put": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update the authenticated user's profile information",
+                "description": "Start an experiment that buckets users into recommendation algorithm variants (collaborative, content_based, hybrid; all three by default). Users are assigned deterministically from a hash of their ID the first time they request recommendations, and keep their variant for the life of the experiment. Only one experiment can be active at a time. Admin only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -483,57 +544,74 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Authentication"
+                    "Analytics"
                 ],
-                "summary": "Update user profile",
+                "summary": "Create experiment",
                 "parameters": [
                     {
-                        "description": "Profile update data",
+                        "description": "Experiment details",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.UpdateProfileRequest"
+                            "$ref": "#/definitions/handlers.CreateExperimentRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Profile updated successfully",
+                    "201": {
+                        "description": "Experiment created successfully",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid request body or validation error",
+                        "description": "Invalid request",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "401": {
                         "description": "User not authenticated",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "User not found",
+                    "403": {
+                        "description": "Admin role required",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Another experiment is active or the name is taken",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/auth/register": {
-            "post": {
-                "description": "Create a new user account with email, name, and password",
+        "/analytics/experiments/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get an experiment with, for each variant, the number of users assigned, the recommendations generated for them and the impressions actually shown, clicks and purchases recorded as recommendation feedback, and the resulting click-through and conversion rates per impression. Only activity after each user's assignment and before the experiment ended counts. Admin only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -541,76 +619,49 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Authentication"
+                    "Analytics"
                 ],
-                "summary": "Register a new user",
+                "summary": "Get experiment results",
                 "parameters": [
                     {
-                        "description": "Registration details",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.RegisterRequest"
-                        }
+                        "type": "string",
+                        "description": "Experiment ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "User registered successfully",
+                    "200": {
+                        "description": "Experiment results",
                         "schema": {
-                            "$ref": "#/definitions/handlers.AuthResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid request body or validation error",
+                        "description": "Invalid experiment ID",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
-                    "409": {
-                        "description": "User with this email already exists",
+                    "401": {
+                        "description": "User not authenticated",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
-                        "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/cart": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Get the current user's shopping cart with all items and total",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Cart"
-                ],
-                "summary": "Get shopping cart",
-                "responses": {
-                    "200": {
-                        "description": "Cart retrieved successfully",
+                    "403": {
+                        "description": "Admin role required",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "401": {
-                        "description": "User not authenticated",
+                    "404": {
+                        "description": "Experiment not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -626,14 +677,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/cart/add": {
-            "post": {
+        "/analytics/experiments/{id}/status": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Add a product to the user's shopping cart with specified quantity",
+                "description": "Pause, resume or complete an experiment. While no experiment is active every user gets the default hybrid recommendations; assignments are kept, so resuming restores each user's variant. Completing an experiment is final and freezes its results window. Admin only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -641,30 +692,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Cart"
+                    "Analytics"
                 ],
-                "summary": "Add item to cart",
+                "summary": "Update experiment status",
                 "parameters": [
                     {
-                        "description": "Item to add to cart",
+                        "type": "string",
+                        "description": "Experiment ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New status",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.AddToCartRequest"
+                            "$ref": "#/definitions/handlers.UpdateExperimentStatusRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Item added to cart successfully",
+                        "description": "Experiment updated successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid request or insufficient stock",
+                        "description": "Invalid request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -677,8 +735,22 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "404": {
-                        "description": "Product not found",
+                        "description": "Experiment not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Another experiment is active or the experiment is completed",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -694,48 +766,58 @@ const docTemplate = `{
                 }
             }
         },
-        "/cart/clear": {
-            "delete": {
+        "/analytics/export": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Remove all items from the user's shopping cart",
+                "description": "Export user analytics data in JSON or CSV format",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv"
                 ],
                 "tags": [
-                    "Cart"
+                    "Analytics"
+                ],
+                "summary": "Export analytics data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "\"json\"",
+                        "description": "Export format (json, csv)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 30,
+                        "description": "Number of days to export",
+                        "name": "days",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Clear shopping cart",
                 "responses": {
                     "200": {
-                        "description": "Cart cleared successfully",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "401": {
-                        "description": "User not authenticated",
+                        "description": "Analytics data exported successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Cart not found",
+                    "400": {
+                        "description": "Unsupported format",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "401": {
+                        "description": "User not authenticated",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -744,14 +826,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/cart/item/{id}": {
-            "put": {
+        "/analytics/forecast": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update the quantity of a specific item in the user's cart",
+                "description": "Project daily revenue for the next days from the daily revenue history (FORECAST_HISTORY_DAYS, default 90 days). The projection comes from the ML service when it is available (source \"ml_service\") and otherwise from a linear trend fitted in Go (source \"linear_trend\"); each day has lower and upper confidence bounds. ML forecasts are cached for the rest of the UTC day. Admin only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -759,37 +841,27 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Cart"
+                    "Analytics"
                 ],
-                "summary": "Update cart item quantity",
+                "summary": "Get revenue forecast",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Cart Item ID (UUID)",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "New quantity (0 to remove item)",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.UpdateCartItemRequest"
-                        }
+                        "type": "integer",
+                        "default": 30,
+                        "description": "Number of days to forecast (1-365)",
+                        "name": "days",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Cart item updated successfully",
+                        "description": "Revenue forecast",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/services.RevenueForecast"
                         }
                     },
                     "400": {
-                        "description": "Invalid request or insufficient stock",
+                        "description": "Invalid number of days",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -802,8 +874,8 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Cart item not found",
+                    "403": {
+                        "description": "Admin role required",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -817,14 +889,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/analytics/funnel": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Remove a specific item from the user's shopping cart",
+                "description": "Get the view → cart_add → purchase conversion funnel over the period, counted as distinct user-product pairs. Each stage reports its count, the conversion from the previous stage and from the first stage, and the drop-off. A later stage implies the earlier ones, so a purchase without a recorded cart_add still counts at the cart stage; such pairs are reported in purchased_without_cart_add. The overall funnel is always returned; pass product_id to also get that product's funnel. Cached per user for ANALYTICS_CACHE_TTL_SECONDS (default 5 minutes); the X-Cache header reports HIT, MISS or BYPASS. Admin only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -832,28 +906,40 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Cart"
+                    "Analytics"
                 ],
-                "summary": "Remove item from cart",
+                "summary": "Get conversion funnel",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Cart Item ID (UUID)",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Product ID (UUID) to compute a product funnel for",
+                        "name": "product_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 30,
+                        "description": "Number of days to analyze (1-365)",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Bypass the analytics cache",
+                        "name": "nocache",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Item removed from cart successfully",
+                        "description": "Funnel retrieved successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid item ID",
+                        "description": "Invalid product ID or number of days",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -866,8 +952,15 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Cart item not found",
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -883,14 +976,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/comments": {
-            "post": {
+        "/analytics/products": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Add a comment and rating to a product",
+                "description": "Get product performance analytics including top selling products, most viewed, and category performance. Cached per user for ANALYTICS_CACHE_TTL_SECONDS (default 5 minutes), so figures can lag by up to that long; the X-Cache header reports HIT, MISS or BYPASS.",
                 "consumes": [
                     "application/json"
                 ],
@@ -898,30 +991,34 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Comments"
+                    "Analytics"
                 ],
-                "summary": "Add product comment",
+                "summary": "Get product analytics",
                 "parameters": [
                     {
-                        "description": "Comment to add",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.AddCommentRequest"
-                        }
+                        "type": "integer",
+                        "default": 30,
+                        "description": "Number of days to analyze",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Number of items to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Bypass the analytics cache",
+                        "name": "nocache",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Comment added successfully",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid request",
+                    "200": {
+                        "description": "Product analytics retrieved successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -934,8 +1031,8 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Product not found",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -944,52 +1041,57 @@ const docTemplate = `{
                 }
             }
         },
-        "/comments/{comment_id}": {
-            "put": {
+        "/analytics/products/export": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update a user's own comment",
+                "description": "Export top selling products, category performance and most viewed products as CSV (one block per section, separated by a blank line) or XLSX (one sheet per section). Admin only.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
                 ],
                 "tags": [
-                    "Comments"
+                    "Analytics"
                 ],
-                "summary": "Update comment",
+                "summary": "Export product analytics",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Comment ID (UUID)",
-                        "name": "comment_id",
-                        "in": "path",
-                        "required": true
+                        "default": "\"csv\"",
+                        "description": "Export format (csv, xlsx)",
+                        "name": "format",
+                        "in": "query"
                     },
                     {
-                        "description": "Updated comment data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.UpdateCommentRequest"
-                        }
+                        "type": "integer",
+                        "default": 30,
+                        "description": "Number of days to analyze",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Number of products per section",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Comment updated successfully",
+                        "description": "Product analytics export",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "file"
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Unsupported format",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1003,28 +1105,30 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "Not authorized to update this comment",
+                        "description": "Admin role required",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Comment not found",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/analytics/recommendations/metrics": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete a user's own comment",
+                "description": "Get recommendation system performance metrics. Click-through and conversion rates are computed against impressions (recommendations actually returned to the user), counting only clicks tied to an impression.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1032,28 +1136,12 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Comments"
-                ],
-                "summary": "Delete comment",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Comment ID (UUID)",
-                        "name": "comment_id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Analytics"
                 ],
+                "summary": "Get recommendation metrics",
                 "responses": {
                     "200": {
-                        "description": "Comment deleted successfully",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid comment ID",
+                        "description": "Recommendation metrics retrieved successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1066,8 +1154,8 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Comment not found",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1076,9 +1164,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/comments/{product_id}": {
+        "/analytics/reports": {
             "get": {
-                "description": "Get paginated list of comments for a product",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the analytics reports generated for the current user by their schedules, newest first, with download links",
                 "consumes": [
                     "application/json"
                 ],
@@ -1086,42 +1179,26 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Comments"
-                ],
-                "summary": "Get product comments",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Product ID (UUID)",
-                        "name": "product_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 20,
-                        "description": "Items per page",
-                        "name": "limit",
-                        "in": "query"
-                    }
+                    "Analytics"
                 ],
+                "summary": "Get generated analytics reports",
                 "responses": {
                     "200": {
-                        "description": "Comments retrieved successfully",
+                        "description": "Reports retrieved successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Invalid product ID",
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1130,45 +1207,40 @@ const docTemplate = `{
                 }
             }
         },
-        "/discounts": {
-            "post": {
+        "/analytics/reports/{id}/download": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new product or category discount (admin only)",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Download one of the current user's generated analytics reports as CSV or XLSX",
                 "produces": [
-                    "application/json"
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
                 ],
                 "tags": [
-                    "Discounts"
+                    "Analytics"
                 ],
-                "summary": "Create discount",
+                "summary": "Download generated analytics report",
                 "parameters": [
                     {
-                        "description": "Discount data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.CreateDiscountRequest"
-                        }
+                        "type": "string",
+                        "description": "Report ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Discount created successfully",
+                    "200": {
+                        "description": "Report file",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "file"
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Invalid report ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1180,13 +1252,32 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "404": {
+                        "description": "Report not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
             }
         },
-        "/discounts/active": {
+        "/analytics/schedules": {
             "get": {
-                "description": "Get list of currently active discounts",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the recurring analytics reports the current user is subscribed to",
                 "consumes": [
                     "application/json"
                 ],
@@ -1194,42 +1285,40 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Discounts"
-                ],
-                "summary": "Get active discounts",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Filter by product ID",
-                        "name": "product_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by category",
-                        "name": "category",
-                        "in": "query"
-                    }
+                    "Analytics"
                 ],
+                "summary": "Get analytics report schedules",
                 "responses": {
                     "200": {
-                        "description": "Active discounts retrieved successfully",
+                        "description": "Schedules retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
                 }
-            }
-        },
-        "/favorites": {
-            "get": {
+            },
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get paginated list of user's favorite products",
+                "description": "Subscribe to a weekly or monthly analytics export. The \"user\" report covers the caller's own activity and orders; the \"products\" report covers store-wide product analytics and requires the admin role. Each run stores the export as a downloadable report and sends an analytics_report notification with its link. The first report is generated one period after subscribing.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1237,80 +1326,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Favorites"
-                ],
-                "summary": "Get user favorites",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 20,
-                        "description": "Items per page",
-                        "name": "limit",
-                        "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Favorites retrieved successfully",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "401": {
-                        "description": "User not authenticated",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    }
-                }
-            },
-            "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Add a product to the user's favorite list",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Favorites"
+                    "Analytics"
                 ],
-                "summary": "Add product to favorites",
+                "summary": "Schedule analytics report",
                 "parameters": [
                     {
-                        "description": "Product to add to favorites",
+                        "description": "Schedule details",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.AddFavoriteRequest"
+                            "$ref": "#/definitions/handlers.CreateAnalyticsScheduleRequest"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Product added to favorites successfully",
+                        "description": "Schedule created successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid request or product already in favorites",
+                        "description": "Invalid request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1323,8 +1362,22 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Product not found",
+                    "403": {
+                        "description": "Admin role required for product reports",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Already subscribed to this report",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1333,14 +1386,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/favorites/{product_id}": {
+        "/analytics/schedules/{id}": {
             "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Remove a product from the user's favorite list",
+                "description": "Cancel a recurring analytics report. Reports already generated remain available for download.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1348,28 +1401,28 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Favorites"
+                    "Analytics"
                 ],
-                "summary": "Remove product from favorites",
+                "summary": "Cancel analytics report schedule",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Product ID (UUID)",
-                        "name": "product_id",
+                        "description": "Schedule ID (UUID)",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Product removed from favorites successfully",
+                        "description": "Schedule cancelled successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid product ID",
+                        "description": "Invalid schedule ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1383,7 +1436,14 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Favorite not found",
+                        "description": "Schedule not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1392,14 +1452,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/ml/auto-tagging/auto-tag": {
-            "post": {
+        "/analytics/search": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Automatically assign tags to products that don't have sufficient tags",
+                "description": "Get search analytics and query performance metrics, including the share of searches that had a result opened (click_through_rate)",
                 "consumes": [
                     "application/json"
                 ],
@@ -1407,21 +1467,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "ML"
+                    "Analytics"
                 ],
-                "summary": "Auto-tag products",
+                "summary": "Get search analytics",
                 "parameters": [
                     {
                         "type": "integer",
-                        "default": 50,
-                        "description": "Maximum number of products to tag",
-                        "name": "limit",
+                        "default": 30,
+                        "description": "Number of days to analyze",
+                        "name": "days",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Products auto-tagged successfully",
+                        "description": "Search analytics retrieved successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1444,14 +1504,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/ml/auto-tagging/insights": {
+        "/analytics/trends": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get insights about the auto-tagging system performance and coverage",
+                "description": "Get ML-powered trend analysis and predictions. Cached per user for ANALYTICS_CACHE_TTL_SECONDS (default 5 minutes), so figures can lag by up to that long; the X-Cache header reports HIT, MISS or BYPASS.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1459,12 +1519,27 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "ML"
+                    "Analytics"
+                ],
+                "summary": "Get ML trends",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 30,
+                        "description": "Number of days to analyze",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Bypass the analytics cache",
+                        "name": "nocache",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get tagging insights",
                 "responses": {
                     "200": {
-                        "description": "Tagging insights retrieved successfully",
+                        "description": "ML trends retrieved successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1487,14 +1562,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/ml/auto-tagging/suggest/{id}": {
+        "/analytics/user": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get AI-suggested tags for a specific product based on content and user behavior",
+                "description": "Get detailed user analytics including interaction stats, category preferences, and spending patterns. Daily order counts and spend come from the nightly daily_user_stats rollup, with only today computed live. Cached per user for ANALYTICS_CACHE_TTL_SECONDS (default 5 minutes), so figures can lag by up to that long; the X-Cache header reports HIT, MISS or BYPASS.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1502,28 +1577,27 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "ML"
+                    "Analytics"
                 ],
-                "summary": "Suggest product tags",
+                "summary": "Get user analytics",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Product ID (UUID)",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "default": 30,
+                        "description": "Number of days to analyze",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Bypass the analytics cache",
+                        "name": "nocache",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Product tag suggestions retrieved successfully",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid product ID",
+                        "description": "User analytics retrieved successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1546,14 +1620,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/ml/initialize-services": {
+        "/auth/change-password": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Initialize or retrain all new ML services (sentiment, auto-tagging, smart discounts)",
+                "description": "Change the password after verifying the current one. All refresh tokens are revoked so other sessions must sign in again.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1561,42 +1635,58 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "ML"
+                    "Authentication"
+                ],
+                "summary": "Change password",
+                "parameters": [
+                    {
+                        "description": "Current and new password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ChangePasswordRequest"
+                        }
+                    }
                 ],
-                "summary": "Initialize ML services",
                 "responses": {
                     "200": {
-                        "description": "ML services initialized successfully",
+                        "description": "Password changed successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
+                    "400": {
+                        "description": "Invalid request body, validation error or new password equals the current one",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    },
                     "401": {
-                        "description": "User not authenticated",
+                        "description": "User not authenticated or current password is wrong",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/ml/sentiment/category/{category}": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Get sentiment analysis for a product category based on user comments",
+        "/auth/forgot-password": {
+            "post": {
+                "description": "Generate a single-use password reset token valid for one hour. Always returns 200 so account existence is not revealed.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1604,51 +1694,40 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "ML"
+                    "Authentication"
                 ],
-                "summary": "Analyze category sentiment",
+                "summary": "Request password reset",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Product Category",
-                        "name": "category",
-                        "in": "path",
-                        "required": true
+                        "description": "Account email",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ForgotPasswordRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Category sentiment analysis retrieved successfully",
+                        "description": "Reset instructions sent if the account exists",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "401": {
-                        "description": "User not authenticated",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "500": {
-                        "description": "Internal server error",
+                    "400": {
+                        "description": "Invalid request body or validation error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/ml/sentiment/insights": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Get actionable sentiment insights across all products and categories",
+        "/auth/login": {
+            "post": {
+                "description": "Authenticate user with email and password",
                 "consumes": [
                     "application/json"
                 ],
@@ -1656,42 +1735,56 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "ML"
+                    "Authentication"
+                ],
+                "summary": "User login",
+                "parameters": [
+                    {
+                        "description": "Login credentials",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LoginRequest"
+                        }
+                    }
                 ],
-                "summary": "Get sentiment insights",
                 "responses": {
                     "200": {
-                        "description": "Sentiment insights retrieved successfully",
+                        "description": "Login successful",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or validation error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     },
                     "401": {
-                        "description": "User not authenticated",
+                        "description": "Invalid email or password",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/ml/sentiment/product/{id}": {
-            "get": {
+        "/auth/logout": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get sentiment analysis for a specific product based on user comments",
+                "description": "Revoke the current access token so it can no longer be used. If a refresh token is supplied it is revoked as well.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1699,58 +1792,56 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "ML"
+                    "Authentication"
                 ],
-                "summary": "Analyze product sentiment",
+                "summary": "User logout",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Product ID (UUID)",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Refresh token to revoke",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LogoutRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Product sentiment analysis retrieved successfully",
+                        "description": "Logged out successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid product ID",
+                        "description": "Invalid request body or token cannot be revoked",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     },
                     "401": {
                         "description": "User not authenticated",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/ml/smart-discounts/insights": {
+        "/auth/notifications": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get insights about discount opportunities and performance across the platform",
+                "description": "Get the authenticated user's notification opt-ins (price drop, back in stock, order status, digest)",
                 "consumes": [
                     "application/json"
                 ],
@@ -1758,42 +1849,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "ML"
+                    "Authentication"
                 ],
-                "summary": "Get discount insights",
+                "summary": "Get notification preferences",
                 "responses": {
                     "200": {
-                        "description": "Discount insights retrieved successfully",
+                        "description": "Notification preferences retrieved successfully",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.NotificationPreference"
                         }
                     },
                     "401": {
                         "description": "User not authenticated",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/ml/smart-discounts/suggest/category/{category}": {
-            "get": {
+            },
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get AI-suggested discounts for all products in a specific category",
+                "description": "Update the authenticated user's notification opt-ins. Omitted fields keep their current value.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1801,51 +1887,56 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "ML"
+                    "Authentication"
                 ],
-                "summary": "Suggest category discounts",
+                "summary": "Update notification preferences",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Product Category",
-                        "name": "category",
-                        "in": "path",
-                        "required": true
+                        "description": "Notification preferences",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateNotificationPreferencesRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Category discount suggestions retrieved successfully",
+                        "description": "Notification preferences updated successfully",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.NotificationPreference"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or validation error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     },
                     "401": {
                         "description": "User not authenticated",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/ml/smart-discounts/suggest/product/{id}": {
+        "/auth/profile": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get AI-suggested optimal discount for a specific product based on performance metrics",
+                "description": "Get the authenticated user's comprehensive profile information including statistics and recent activity",
                 "consumes": [
                     "application/json"
                 ],
@@ -1853,58 +1944,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "ML"
-                ],
-                "summary": "Suggest product discount",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Product ID (UUID)",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Authentication"
                 ],
+                "summary": "Get user profile",
                 "responses": {
                     "200": {
-                        "description": "Product discount suggestion retrieved successfully",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid product ID",
+                        "description": "User profile retrieved successfully",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.UserProfileResponse"
                         }
                     },
                     "401": {
                         "description": "User not authenticated",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "404": {
+                        "description": "User not found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/orders": {
-            "get": {
+            },
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get paginated list of user's order history with order items",
+                "description": "Update the authenticated user's name and phone number (max 20 characters)",
                 "consumes": [
                     "application/json"
                 ],
@@ -1912,56 +1982,57 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Orders"
+                    "Authentication"
                 ],
-                "summary": "Get user orders",
+                "summary": "Update user profile",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 10,
-                        "description": "Items per page",
-                        "name": "limit",
-                        "in": "query"
+                        "description": "Profile update data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateProfileRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Orders retrieved successfully",
+                        "description": "Profile updated successfully",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or validation error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     },
                     "401": {
                         "description": "User not authenticated",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/auth/refresh": {
             "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Create a new order from the user's current cart items or specific cart items with atomic stock management",
+                "description": "Exchange a refresh token for a new short-lived access token",
                 "consumes": [
                     "application/json"
                 ],
@@ -1969,67 +2040,51 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Orders"
+                    "Authentication"
                 ],
-                "summary": "Create order from cart",
+                "summary": "Refresh access token",
                 "parameters": [
                     {
-                        "description": "Order creation data. cart_item_ids is optional - if not provided, orders all cart items. Example: {\\",
+                        "description": "Refresh token",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.CreateOrderRequest"
+                            "$ref": "#/definitions/handlers.RefreshTokenRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Order created successfully",
+                    "200": {
+                        "description": "Access token refreshed",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.RefreshResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid request, empty cart, or insufficient stock",
+                        "description": "Invalid request body or validation error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     },
                     "401": {
-                        "description": "User not authenticated",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "404": {
-                        "description": "Cart not found",
+                        "description": "Refresh token is invalid, revoked or expired",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/orders/stats": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Get comprehensive order statistics for the authenticated user",
+        "/auth/register": {
+            "post": {
+                "description": "Create a new user account with email, name, and password",
                 "consumes": [
                     "application/json"
                 ],
@@ -2037,35 +2092,51 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Orders"
+                    "Authentication"
+                ],
+                "summary": "Register a new user",
+                "parameters": [
+                    {
+                        "description": "Registration details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RegisterRequest"
+                        }
+                    }
                 ],
-                "summary": "Get order statistics",
                 "responses": {
-                    "200": {
-                        "description": "Order statistics retrieved successfully",
+                    "201": {
+                        "description": "User registered successfully (access token valid for 15 minutes, refresh token for 7 days)",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.AuthResponse"
                         }
                     },
-                    "401": {
-                        "description": "User not authenticated",
+                    "400": {
+                        "description": "Invalid request body or validation error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "User with this email already exists",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/orders/{id}": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Get detailed information about a specific order",
+        "/auth/reset-password": {
+            "post": {
+                "description": "Set a new password using a token from forgot-password. Tokens expire after one hour and can only be used once.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2073,57 +2144,51 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Orders"
+                    "Authentication"
                 ],
-                "summary": "Get order by ID",
+                "summary": "Reset password",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Order ID (UUID)",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Reset token and new password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ResetPasswordRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Order retrieved successfully",
-                        "schema": {
-                            "$ref": "#/definitions/models.Order"
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid order ID",
+                        "description": "Password reset successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "401": {
-                        "description": "User not authenticated",
+                    "400": {
+                        "description": "Invalid request, or invalid or expired token",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Order not found",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/orders/{id}/cancel": {
-            "put": {
+        "/cart": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Cancel a pending order and restore product stock",
+                "description": "Get the current user's shopping cart with all items and total. Guests without a token get the cart for their X-Session-ID header.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2131,42 +2196,27 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Orders"
+                    "Cart"
                 ],
-                "summary": "Cancel order",
+                "summary": "Get shopping cart",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Order ID (UUID)",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Guest session ID, used when not signed in",
+                        "name": "X-Session-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Order cancelled successfully",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid order ID or order cannot be cancelled",
+                        "description": "Cart retrieved successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "401": {
-                        "description": "User not authenticated",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "404": {
-                        "description": "Order not found",
+                        "description": "Neither signed in nor a session ID provided",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -2182,14 +2232,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/orders/{id}/status": {
-            "put": {
+        "/cart/add": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update the status of an order with validation for status transitions (admin access required)",
+                "description": "Add a product to the user's shopping cart with specified quantity. Products with variants require a variant_id, and each variant is a separate cart line. Guests without a token add to the cart for their X-Session-ID header.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2197,44 +2247,50 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Orders"
+                    "Cart"
                 ],
-                "summary": "Update order status",
+                "summary": "Add item to cart",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Order ID (UUID)",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Guest session ID, used when not signed in",
+                        "name": "X-Session-ID",
+                        "in": "header"
                     },
                     {
-                        "description": "New order status",
+                        "description": "Item to add to cart",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.UpdateOrderStatusRequest"
+                            "$ref": "#/definitions/handlers.AddToCartRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Order status updated successfully",
+                        "description": "Item added to cart successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid request or status transition",
+                        "description": "Invalid request or insufficient stock",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Neither signed in nor a session ID provided",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "404": {
-                        "description": "Order not found",
+                        "description": "Product not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -2250,9 +2306,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/products": {
-            "get": {
-                "description": "Get a paginated list of products with optional filtering and sorting",
+        "/cart/apply-coupon": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Validate a coupon code against its active dates, usage limit and minimum order amount using the current cart, and store it on the cart. The coupon is redeemed when an order is placed.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2260,54 +2321,44 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Products"
+                    "Cart"
                 ],
-                "summary": "Get products",
+                "summary": "Apply coupon to cart",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 20,
-                        "description": "Items per page",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by category",
-                        "name": "category",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Search in name and description",
-                        "name": "search",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "default": "\"created_at\"",
-                        "description": "Sort field (price, name, created_at)",
-                        "name": "sort",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "default": "\"desc\"",
-                        "description": "Sort order (asc, desc)",
-                        "name": "order",
-                        "in": "query"
+                        "description": "Coupon code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ApplyCouponRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Products retrieved successfully",
+                        "description": "Coupon applied successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or coupon cannot be applied",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Cart or coupon not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -2321,14 +2372,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/cart/clear": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new product in the catalog (admin access required)",
+                "description": "Remove all items from the user's shopping cart",
                 "consumes": [
                     "application/json"
                 ],
@@ -2336,37 +2389,34 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Products"
+                    "Cart"
                 ],
-                "summary": "Create a new product",
+                "summary": "Clear shopping cart",
                 "parameters": [
                     {
-                        "description": "Product creation data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.CreateProductRequest"
-                        }
+                        "type": "string",
+                        "description": "Guest session ID, used when not signed in",
+                        "name": "X-Session-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Product created successfully",
+                    "200": {
+                        "description": "Cart cleared successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Invalid request body or validation error",
+                    "401": {
+                        "description": "Neither signed in nor a session ID provided",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "401": {
-                        "description": "Authentication required",
+                    "404": {
+                        "description": "Cart not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -2382,14 +2432,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/products/recommendations": {
-            "get": {
+        "/cart/coupon": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get personalized product recommendations using ML algorithms with reasoning",
+                "description": "Remove the coupon currently applied to the user's cart",
                 "consumes": [
                     "application/json"
                 ],
@@ -2397,28 +2447,19 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Products"
-                ],
-                "summary": "Get product recommendations",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "default": 10,
-                        "description": "Number of recommendations",
-                        "name": "limit",
-                        "in": "query"
-                    }
+                    "Cart"
                 ],
+                "summary": "Remove coupon from cart",
                 "responses": {
                     "200": {
-                        "description": "Recommendations retrieved successfully",
+                        "description": "Coupon removed successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "401": {
-                        "description": "Authentication required for recommendations",
+                        "description": "User not authenticated",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -2434,9 +2475,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/products/search": {
-            "get": {
-                "description": "Search products using enhanced search with relevance scoring and intelligent filtering",
+        "/cart/item/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update the quantity of a specific item in the user's cart",
                 "consumes": [
                     "application/json"
                 ],
@@ -2444,113 +2490,64 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Products"
+                    "Cart"
                 ],
-                "summary": "Search products",
+                "summary": "Update cart item quantity",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Search query",
-                        "name": "q",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 20,
-                        "description": "Items per page",
-                        "name": "limit",
-                        "in": "query"
+                        "description": "Guest session ID, used when not signed in",
+                        "name": "X-Session-ID",
+                        "in": "header"
                     },
                     {
                         "type": "string",
-                        "description": "Filter by category",
-                        "name": "category",
-                        "in": "query"
-                    },
-                    {
-                        "type": "number",
-                        "description": "Minimum price filter",
-                        "name": "min_price",
-                        "in": "query"
+                        "description": "Cart Item ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "number",
-                        "description": "Maximum price filter",
-                        "name": "max_price",
-                        "in": "query"
+                        "description": "New quantity (0 to remove item)",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateCartItemRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Search results retrieved successfully",
+                        "description": "Cart item updated successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Search query is required",
+                        "description": "Invalid request or insufficient stock",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "401": {
+                        "description": "Neither signed in nor a session ID provided",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
-                    }
-                }
-            }
-        },
-        "/products/{id}": {
-            "get": {
-                "description": "Get detailed information about a specific product",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Products"
-                ],
-                "summary": "Get product by ID",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Product ID (UUID)",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Product retrieved successfully",
-                        "schema": {
-                            "$ref": "#/definitions/models.Product"
-                        }
                     },
-                    "400": {
-                        "description": "Invalid product ID",
+                    "404": {
+                        "description": "Cart item not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Product not found",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -2558,13 +2555,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "put": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update an existing product in the catalog (admin access required)",
+                "description": "Remove a specific item from the user's shopping cart",
                 "consumes": [
                     "application/json"
                 ],
@@ -2572,51 +2569,48 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Products"
+                    "Cart"
                 ],
-                "summary": "Update a product",
+                "summary": "Remove item from cart",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Product ID (UUID)",
+                        "description": "Guest session ID, used when not signed in",
+                        "name": "X-Session-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cart Item ID (UUID)",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Product update data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.UpdateProductRequest"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Product updated successfully",
+                        "description": "Item removed from cart successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid request body or product ID",
+                        "description": "Invalid item ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "401": {
-                        "description": "Authentication required",
+                        "description": "Neither signed in nor a session ID provided",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "404": {
-                        "description": "Product not found",
+                        "description": "Cart item not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -2630,14 +2624,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/cart/item/{id}/save-for-later": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete a product from the catalog (admin access required)",
+                "description": "Move an item out of the cart into the saved-for-later list, keeping its quantity and variant. Saving a product that is already saved replaces the saved quantity and variant.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2645,13 +2641,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Products"
+                    "Cart"
                 ],
-                "summary": "Delete a product",
+                "summary": "Save cart item for later",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Product ID (UUID)",
+                        "description": "Cart Item ID (UUID)",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -2659,28 +2655,28 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Product deleted successfully",
+                        "description": "Item saved for later successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid product ID or product has order history",
+                        "description": "Invalid item ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "401": {
-                        "description": "Authentication required",
+                        "description": "User not authenticated",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "404": {
-                        "description": "Product not found",
+                        "description": "Cart item not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -2696,14 +2692,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/security/alerts": {
-            "get": {
+        "/cart/merge": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get recent security alerts with filtering options",
+                "description": "Merge the cart built as a guest into the signed-in user's cart, typically right after login. Quantities of products in both carts are summed and capped at the per-item maximum of 100 and at available stock. The guest cart is removed afterwards.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2711,69 +2707,65 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Security"
+                    "Cart"
                 ],
-                "summary": "Get Security Alerts",
+                "summary": "Merge guest cart",
                 "parameters": [
                     {
-                        "maximum": 1000,
-                        "minimum": 1,
-                        "type": "integer",
-                        "default": 50,
-                        "description": "Number of alerts to return",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "low",
-                            "medium",
-                            "high",
-                            "critical"
-                        ],
                         "type": "string",
-                        "description": "Filter by risk level",
-                        "name": "risk_level",
-                        "in": "query"
+                        "description": "Guest session ID, used when not given in the body",
+                        "name": "X-Session-ID",
+                        "in": "header"
                     },
                     {
-                        "type": "boolean",
-                        "description": "Filter by resolution status",
-                        "name": "resolved",
-                        "in": "query"
+                        "description": "Guest session to merge",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MergeGuestCartRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Security alerts data",
+                        "description": "Guest cart merged successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or no session ID provided",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "401": {
-                        "description": "Unauthorized",
+                        "description": "User not authenticated",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/security/alerts/{alert_id}/resolve": {
+        "/cart/reserve": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Mark a security alert as resolved with optional notes",
+                "description": "Reserve the quantities currently in the cart so they cannot be sold to anyone else while the user checks out. Reservations expire after a short window (STOCK_RESERVATION_MINUTES, 10 minutes by default) and calling this again renews them. Either every item is reserved or none is; reservations for products no longer in the cart are released. Placing an order consumes the reservations.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2781,71 +2773,63 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Security"
-                ],
-                "summary": "Resolve Security Alert",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "format": "uuid",
-                        "description": "Alert ID",
-                        "name": "alert_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Resolution details",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.ResolveAlertRequest"
-                        }
-                    }
+                    "Cart"
                 ],
+                "summary": "Reserve cart stock",
                 "responses": {
                     "200": {
-                        "description": "Alert resolved successfully",
+                        "description": "Cart reserved successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Bad request - invalid alert ID or request body",
+                        "description": "Cart is empty",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "401": {
-                        "description": "Unauthorized",
+                        "description": "User not authenticated",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "404": {
-                        "description": "Alert not found",
+                        "description": "Cart not found",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Not enough stock to reserve some items",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/security/dashboard": {
+        "/cart/summary": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get comprehensive security dashboard with anomaly detection data and real-time threat monitoring",
+                "description": "Get the cart's subtotal, automatic and coupon discounts, estimated tax (applied per line), estimated shipping and the final total, as charged at checkout. Guests without a token get the summary for their X-Session-ID header.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2853,39 +2837,44 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Security"
+                    "Cart"
+                ],
+                "summary": "Get cart summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Guest session ID, used when not signed in",
+                        "name": "X-Session-ID",
+                        "in": "header"
+                    }
                 ],
-                "summary": "Get Security Dashboard",
                 "responses": {
                     "200": {
-                        "description": "Security dashboard data",
+                        "description": "Cart summary retrieved successfully",
                         "schema": {
-                            "$ref": "#/definitions/handlers.SecurityDashboardResponse"
+                            "$ref": "#/definitions/handlers.CartTotals"
                         }
                     },
                     "401": {
-                        "description": "Unauthorized",
+                        "description": "Neither signed in nor a session ID provided",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/security/insights": {
+        "/categories": {
             "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Get detailed insights and analysis about detected anomalies and attack patterns",
+                "description": "Get the product categories as a tree of top-level categories and their subcategories, sorted by name, with the number of products directly in each",
                 "consumes": [
                     "application/json"
                 ],
@@ -2893,40 +2882,33 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Security"
+                    "Categories"
                 ],
-                "summary": "Get Anomaly Insights",
+                "summary": "Get category tree",
                 "responses": {
                     "200": {
-                        "description": "Anomaly insights data",
+                        "description": "Category tree retrieved successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
-                        }
-                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
-            }
-        },
-        "/security/metrics": {
-            "get": {
+            },
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get security metrics and statistics for a specified time period",
+                "description": "Create a category, optionally below a parent category (admin access required). The slug is derived from the name and must be unique.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2934,92 +2916,73 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Security"
+                    "Categories"
                 ],
-                "summary": "Get Security Metrics",
+                "summary": "Create category",
                 "parameters": [
                     {
-                        "maximum": 365,
-                        "minimum": 1,
-                        "type": "integer",
-                        "default": 30,
-                        "description": "Number of days to include in metrics",
-                        "name": "days",
-                        "in": "query"
+                        "description": "Category details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateCategoryRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Security metrics data",
+                    "201": {
+                        "description": "Category created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Category"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or parent not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "401": {
-                        "description": "Unauthorized",
+                        "description": "Authentication required",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
-                        "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/security/patterns": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Get information about detectable attack patterns and security threats",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Security"
-                ],
-                "summary": "Get Attack Patterns",
-                "responses": {
-                    "200": {
-                        "description": "Attack patterns information",
+                    "403": {
+                        "description": "Admin role required",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "409": {
+                        "description": "Category with this slug already exists",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/security/simulate": {
-            "get": {
+        "/categories/{id}": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Simulate different types of attacks for demonstration and testing purposes",
+                "description": "Rename a category or move it below another parent (admin access required). Renaming updates the category name stored on its products. A category cannot be moved below itself or one of its subcategories.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3027,57 +2990,82 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Security"
+                    "Categories"
                 ],
-                "summary": "Simulate Attack",
+                "summary": "Update category",
                 "parameters": [
                     {
-                        "enum": [
-                            "sql_injection",
-                            "xss",
-                            "brute_force",
-                            "ddos"
-                        ],
                         "type": "string",
-                        "example": "sql_injection",
-                        "description": "Attack type",
-                        "name": "attack_type",
-                        "in": "query",
+                        "description": "Category ID (UUID)",
+                        "name": "id",
+                        "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateCategoryRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Attack simulation results",
+                        "description": "Category updated successfully",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.Category"
                         }
                     },
                     "400": {
-                        "description": "Bad request - invalid attack type",
+                        "description": "Invalid request, parent not found or move would create a cycle",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "401": {
-                        "description": "Unauthorized",
+                        "description": "Authentication required",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Category not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Category with this slug already exists",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/tags": {
+        "/categories/{slug}/products": {
             "get": {
-                "description": "Get list of all available product tags",
+                "description": "Get a paginated list of products in a category, including the products of all its subcategories",
                 "consumes": [
                     "application/json"
                 ],
@@ -3085,26 +3073,65 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Tags"
+                    "Categories"
+                ],
+                "summary": "Get category products",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get all tags",
                 "responses": {
                     "200": {
-                        "description": "Tags retrieved successfully",
+                        "description": "Products retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Category not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
                 }
-            },
+            }
+        },
+        "/comments": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new product tag (admin only)",
+                "description": "Add a comment and rating to a product, or reply to a top-level comment with parent_id. Replies carry no rating and cannot themselves be replied to.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3112,30 +3139,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Tags"
+                    "Comments"
                 ],
-                "summary": "Create tag",
+                "summary": "Add product comment",
                 "parameters": [
                     {
-                        "description": "Tag data",
+                        "description": "Comment to add",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.CreateTagRequest"
+                            "$ref": "#/definitions/handlers.AddCommentRequest"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Tag created successfully",
+                        "description": "Comment added successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid request or tag already exists",
+                        "description": "Invalid request, rating rules violated or reply to a reply",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -3147,18 +3174,25 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "404": {
+                        "description": "Product or parent comment not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
             }
         },
-        "/tags/products": {
-            "post": {
+        "/comments/{comment_id}": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Add a tag to a product (admin only)",
+                "description": "Update a user's own comment",
                 "consumes": [
                     "application/json"
                 ],
@@ -3166,30 +3200,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Tags"
+                    "Comments"
                 ],
-                "summary": "Add tag to product",
+                "summary": "Update comment",
                 "parameters": [
                     {
-                        "description": "Product and tag IDs",
+                        "type": "string",
+                        "description": "Comment ID (UUID)",
+                        "name": "comment_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated comment data",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.AddProductTagRequest"
+                            "$ref": "#/definitions/handlers.UpdateCommentRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Tag added to product successfully",
+                    "200": {
+                        "description": "Comment updated successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid request or tag already added",
+                        "description": "Invalid request or rating on a reply",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -3202,19 +3243,29 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "403": {
+                        "description": "Not authorized to update this comment",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "404": {
-                        "description": "Product or tag not found",
+                        "description": "Comment not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
                 }
-            }
-        },
-        "/tags/products/{product_id}": {
-            "get": {
-                "description": "Get all tags associated with a product",
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a user's own comment together with its replies",
                 "consumes": [
                     "application/json"
                 ],
@@ -3222,21 +3273,96 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Tags"
+                    "Comments"
                 ],
-                "summary": "Get product tags",
+                "summary": "Delete comment",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Product ID (UUID)",
-                        "name": "product_id",
+                        "description": "Comment ID (UUID)",
+                        "name": "comment_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Product tags retrieved successfully",
+                        "description": "Comment deleted successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid comment ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Comment not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/comments/{product_id}": {
+            "get": {
+                "description": "Get paginated list of top-level comments for a product, each with its replies nested oldest first. Comments by users who received the product are flagged as verified purchases, and ratings are summarized separately for verified and unverified reviewers.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Comments"
+                ],
+                "summary": "Get product comments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Only return comments from verified purchases",
+                        "name": "verified_only",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Comments retrieved successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -3252,14 +3378,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/upvotes": {
+        "/discounts": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Add an upvote to a product",
+                "description": "Create a new product or category discount (admin only). Discounts with a code are coupons that customers apply to their cart; a coupon without product_id or category applies to the whole cart. Percentage discounts take discount_value, fixed_amount discounts take amount_off.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3267,30 +3393,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Upvotes"
+                    "Discounts"
                 ],
-                "summary": "Upvote product",
+                "summary": "Create discount",
                 "parameters": [
                     {
-                        "description": "Product to upvote",
+                        "description": "Discount data",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.AddUpvoteRequest"
+                            "$ref": "#/definitions/handlers.CreateDiscountRequest"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Product upvoted successfully",
+                        "description": "Discount created successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid request or already upvoted",
+                        "description": "Invalid request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -3303,8 +3429,15 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Product not found",
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Coupon code already exists",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -3313,14 +3446,52 @@ const docTemplate = `{
                 }
             }
         },
-        "/upvotes/{product_id}": {
+        "/discounts/active": {
+            "get": {
+                "description": "Get list of automatic discounts whose date window contains the current time",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Discounts"
+                ],
+                "summary": "Get active discounts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by product ID",
+                        "name": "product_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by category",
+                        "name": "category",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Active discounts retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/discounts/expired": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get upvote count and user's upvote status for a product",
+                "description": "Get discounts and coupons whose end date has passed, most recently ended first (admin only)",
                 "consumes": [
                     "application/json"
                 ],
@@ -3328,42 +3499,65 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Upvotes"
+                    "Discounts"
                 ],
-                "summary": "Get product upvotes",
+                "summary": "Get expired discounts",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Product ID (UUID)",
-                        "name": "product_id",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Upvotes retrieved successfully",
+                        "description": "Expired discounts retrieved successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Invalid product ID",
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/discounts/upcoming": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Remove an upvote from a product",
+                "description": "Get discounts and coupons whose start date is still in the future, soonest first (admin only)",
                 "consumes": [
                     "application/json"
                 ],
@@ -3371,42 +3565,49 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Upvotes"
+                    "Discounts"
                 ],
-                "summary": "Remove upvote",
+                "summary": "Get upcoming discounts",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Product ID (UUID)",
-                        "name": "product_id",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Upvote removed successfully",
+                        "description": "Upcoming discounts retrieved successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Invalid product ID",
+                    "401": {
+                        "description": "User not authenticated",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "401": {
-                        "description": "User not authenticated",
+                    "403": {
+                        "description": "Admin role required",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Upvote not found",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -3414,55 +3615,6998 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.AddCommentRequest": {
-            "type": "object",
-            "required": [
-                "content",
-                "product_id",
-                "rating"
-            ],
-            "properties": {
-                "content": {
-                    "type": "string",
-                    "maxLength": 1000,
-                    "minLength": 1,
-                    "example": "Great product!"
-                },
-                "product_id": {
-                    "type": "string",
-                    "example": "123e4567-e89b-12d3-a456-426614174000"
-                },
-                "rating": {
-                    "type": "integer",
-                    "maximum": 5,
-                    "minimum": 1,
-                    "example": 5
-                }
-            }
-        },
-        "handlers.AddFavoriteRequest": {
-            "type": "object",
-            "required": [
-                "product_id"
-            ],
-            "properties": {
-                "product_id": {
-                    "type": "string",
-                    "example": "123e4567-e89b-12d3-a456-426614174000"
-                }
-            }
         },
-        "handlers.AddProductTagRequest": {
-            "type": "object",
-            "required": [
-                "product_id",
-                "tag_id"
-            ],
-            "properties": {
-                "product_id": {
+        "/favorites": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get paginated list of user's favorite products",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Favorites"
+                ],
+                "summary": "Get user favorites",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Favorites retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a product to the user's favorite list",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Favorites"
+                ],
+                "summary": "Add product to favorites",
+                "parameters": [
+                    {
+                        "description": "Product to add to favorites",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AddFavoriteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Product added to favorites successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Product already in favorites",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/favorites/recommendations": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get products in the same categories as the user's favorites that are not favorited yet, ordered by upvotes and comments",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Favorites"
+                ],
+                "summary": "Get recommendations based on favorites",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of products",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recommendations retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/favorites/share": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Generate a public read-only link to the user's favorites. Returns the existing link if the favorites are already shared.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Favorites"
+                ],
+                "summary": "Share favorites",
+                "responses": {
+                    "200": {
+                        "description": "Wishlist already shared",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "201": {
+                        "description": "Wishlist shared successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke the public link to the user's favorites. The old link stops working immediately.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Favorites"
+                ],
+                "summary": "Revoke favorites share",
+                "responses": {
+                    "200": {
+                        "description": "Wishlist share revoked successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Wishlist is not shared",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/favorites/{product_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a product from the user's favorite list",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Favorites"
+                ],
+                "summary": "Remove product from favorites",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Product removed from favorites successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Favorite not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/health/ready": {
+            "get": {
+                "description": "Ping the database and the ML service status endpoint, concurrently and with a 3 second timeout each. Returns 200 when both are up and 503 with the status of each dependency otherwise. Use /health for liveness; it does not touch dependencies.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Readiness check",
+                "responses": {
+                    "200": {
+                        "description": "All dependencies are up",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "At least one dependency is down",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/metrics": {
+            "get": {
+                "description": "Metrics in the Prometheus text exposition format: request counts by method, route and status (http_requests_total), request duration (http_request_duration_seconds), database statements (db_queries_total), ML service call latency (ml_request_duration_seconds) and orders not yet delivered or cancelled by status (orders_active).",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Prometheus metrics",
+                "responses": {
+                    "200": {
+                        "description": "Metrics in Prometheus text format",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/ml/auto-tagging/apply/{id}": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Attach the accepted tags from an auto-tagging suggestion to a product (admin only). Tags that do not exist yet are created. Tags the product already has are left as they are; the rest are recorded with source 'ml'.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ML"
+                ],
+                "summary": "Apply suggested tags",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Accepted tag names",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ApplySuggestedTagsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Suggested tags applied successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID or tag names",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/ml/auto-tagging/auto-tag": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Automatically assign tags to products that don't have sufficient tags",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ML"
+                ],
+                "summary": "Auto-tag products",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum number of products to tag",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Products auto-tagged successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/ml/auto-tagging/insights": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get insights about the auto-tagging system performance and coverage",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ML"
+                ],
+                "summary": "Get tagging insights",
+                "responses": {
+                    "200": {
+                        "description": "Tagging insights retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/ml/auto-tagging/suggest/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get AI-suggested tags for a specific product based on content and user behavior",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ML"
+                ],
+                "summary": "Suggest product tags",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Product tag suggestions retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/ml/initialize-services": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Initialize or retrain all new ML services (sentiment, auto-tagging, smart discounts)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ML"
+                ],
+                "summary": "Initialize ML services",
+                "responses": {
+                    "200": {
+                        "description": "ML services initialized successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/ml/preferences": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the authenticated user's explicit recommendation preferences",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ML"
+                ],
+                "summary": "Get recommendation preferences",
+                "responses": {
+                    "200": {
+                        "description": "Preferences retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Set preferred and avoided categories and a price range that recommendations must respect",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ML"
+                ],
+                "summary": "Update recommendation preferences",
+                "parameters": [
+                    {
+                        "description": "Recommendation preferences",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateUserPreferencesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Preferences updated successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/ml/recommendations/preview": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Call the ML service directly to preview recommendations for any user and algorithm without storing the results (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ML"
+                ],
+                "summary": "Preview recommendations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Target user ID (UUID), defaults to the caller",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "hybrid",
+                        "description": "Algorithm (collaborative, content_based, hybrid, popular)",
+                        "name": "algorithm",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of recommendations",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recommendation preview generated successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin access required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "ML service unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/ml/sentiment/batch": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the sentiment of up to 100 products at once, keyed by product ID. Stored snapshots are served while fresh and the rest are analyzed by the ML service in one batch. Products that cannot be analyzed and have no stored snapshot are listed in unavailable.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ML"
+                ],
+                "summary": "Analyze sentiment of several products",
+                "parameters": [
+                    {
+                        "description": "Product IDs",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BatchSentimentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Product sentiments retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/ml/sentiment/category/{category}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get sentiment analysis for a product category based on user comments",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ML"
+                ],
+                "summary": "Analyze category sentiment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product Category",
+                        "name": "category",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Category sentiment analysis retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/ml/sentiment/compare": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Analyze sentiment for several categories in parallel and rank them from most to least positive. Categories that fail are reported separately.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ML"
+                ],
+                "summary": "Compare category sentiment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma-separated list of categories (max 10)",
+                        "name": "categories",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Category sentiment comparison retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid categories parameter",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "All category lookups failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/ml/sentiment/insights": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get actionable sentiment insights across all products and categories",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ML"
+                ],
+                "summary": "Get sentiment insights",
+                "responses": {
+                    "200": {
+                        "description": "Sentiment insights retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/ml/sentiment/product/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the sentiment of a product's user comments. The stored snapshot is served while it is younger than SENTIMENT_SNAPSHOT_TTL_MINUTES and recomputed by the ML service otherwise; if that fails, the expired snapshot is served with stale set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ML"
+                ],
+                "summary": "Analyze product sentiment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Product sentiment analysis retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/ml/smart-discounts/insights": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get insights about discount opportunities and performance across the platform",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ML"
+                ],
+                "summary": "Get discount insights",
+                "responses": {
+                    "200": {
+                        "description": "Discount insights retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/ml/smart-discounts/suggest/category/{category}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get AI-suggested discounts for all products in a specific category",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ML"
+                ],
+                "summary": "Suggest category discounts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product Category",
+                        "name": "category",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Category discount suggestions retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/ml/smart-discounts/suggest/product/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get AI-suggested optimal discount for a specific product based on performance metrics",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ML"
+                ],
+                "summary": "Suggest product discount",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Product discount suggestion retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/ml/taste-profile": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Summarize the user's preferences from their interaction history: top categories, price sensitivity, preferred tags and brand affinity",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ML"
+                ],
+                "summary": "Get taste profile",
+                "responses": {
+                    "200": {
+                        "description": "Taste profile retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/ml/train": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Start training the ML models in the background (admin only). Returns the training job, whose status is available at /ml/training/{jobId}; the admin is notified when training finishes. Only one job runs at a time.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ML"
+                ],
+                "summary": "Train ML models",
+                "responses": {
+                    "202": {
+                        "description": "Training started",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Training is already running",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/ml/training/{jobId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the status of an ML training job (admin only): running, completed or failed, with the error of a failed job",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ML"
+                ],
+                "summary": "Get training job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Training job ID (UUID)",
+                        "name": "jobId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Training job retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.TrainingJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid job ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Training job not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the authenticated user's in-app notifications, newest first. The unread count is returned in the body and the X-Unread-Count header for badge display.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Get notifications",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only return unread notifications",
+                        "name": "unread",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Notifications retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/dispatch/stats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get queue length, delivery counters and configuration of the notification dispatcher",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Get notification dispatch stats",
+                "responses": {
+                    "200": {
+                        "description": "Dispatch statistics",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/read-all": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Mark every unread notification of the authenticated user as read",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Mark all notifications as read",
+                "responses": {
+                    "200": {
+                        "description": "Notifications marked as read",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/suppressions": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Stop delivering notifications to a user, e.g. after a bounce or unsubscribe",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Suppress notifications for a user",
+                "parameters": [
+                    {
+                        "description": "Suppression details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.NotificationSuppressionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "User suppressed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/suppressions/{user_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Resume delivering notifications to a previously suppressed user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Remove notification suppression",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Suppression removed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User is not suppressed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/{id}/read": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Mark one of the authenticated user's notifications as read",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Mark notification as read",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Notification ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Notification marked as read",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid notification ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Notification not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/orders": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get paginated list of user's order history with order items. Pass cursor (empty for the first page) to use cursor pagination instead of page numbers; it requires sort=date and returns next_cursor instead of totals.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Get user orders",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from pagination.next_cursor; empty for the first page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "date",
+                        "description": "Sort field (date, total)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Sort order (asc, desc)",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "items.product",
+                        "description": "Related data to include (items.product, items, none)",
+                        "name": "expand",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Orders retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid sort, order, expand or cursor parameter",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new order from the user's current cart items or specific cart items with atomic stock management. Active discounts are applied to each item under the discount stacking policy, and a coupon applied to the cart is re-validated and redeemed. Estimated tax and shipping are added as in the cart summary. Units reserved with /cart/reserve are consumed; units reserved by other users are not available. The order ships to the inline shipping_address or the saved address_id (the default address otherwise), stored on the order as a snapshot. The display currency (the base currency unless currency is given) and its exchange rate at the time of purchase are stored on the order too.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Create order from cart",
+                "parameters": [
+                    {
+                        "description": "Order creation data. cart_item_ids is optional - if not provided, orders all cart items. Example: {\\",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateOrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Order created successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, empty cart, or insufficient stock",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Cart not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Exchange rates are unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/stats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get comprehensive order statistics for the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Get order statistics",
+                "responses": {
+                    "200": {
+                        "description": "Order statistics retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get detailed information about a specific order",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Get order by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Order retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid order ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/cancel": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Cancel a pending order, or a processing order within the cancellation grace period (ORDER_CANCEL_GRACE_MINUTES, default 30). Pass items to cancel only part of the order; stock is restored for the cancelled quantities and the order total is adjusted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Cancel order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Items to cancel (omit to cancel the whole order)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CancelOrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Order cancelled successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid order ID or order cannot be cancelled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Order changed by a concurrent request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the status transitions of an order, oldest first. Customers can see their own orders; admins can see any order.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Get order status history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Order history retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid order ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/invoice": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the invoice for an order with line items, discounts, grand total and the shipping address. Returns JSON by default, or a PDF with format=pdf. Customers can fetch their own orders; admins can fetch any order.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Get order invoice",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "pdf"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "Invoice format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Invoice",
+                        "schema": {
+                            "$ref": "#/definitions/services.Invoice"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid order ID or format",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/items/{itemId}/cancel": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Cancel one line item of a pending order, or of a processing order within the cancellation grace period. The item's stock is restored and the order total recomputed; the order is cancelled once all of its items are.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Cancel order item",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Order item ID (UUID)",
+                        "name": "itemId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Order item cancelled successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID or order cannot be cancelled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Order or order item not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Order changed by a concurrent request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/refund": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Refund a delivered order, in full or by order item (admin access required). Stock is restored for the refunded quantities and the order moves to refunded or partially_refunded. The refunded amount defaults to the items' share of the paid total and can never exceed what remains of it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Refund order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Refund details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RefundOrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Order refunded successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, order not refundable or amount exceeds paid total",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/status": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update the status of an order with validation for status transitions (admin access required)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Update order status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New order status",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateOrderStatusRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Order status updated successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or status transition",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin access required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products": {
+            "get": {
+                "description": "Get a paginated list of products with optional filtering and sorting. Pass cursor (empty for the first page) to use cursor pagination instead of page numbers; it requires sorting by created_at and returns next_cursor instead of totals. Pass currency to also get each price converted at the current exchange rate (converted_price) and the rate used; stored prices stay in the base currency.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Get products",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from pagination.next_cursor; empty for the first page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search in name and description",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "\"created_at\"",
+                        "description": "Sort field (price, name, created_at)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "\"desc\"",
+                        "description": "Sort order (asc, desc)",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ISO 4217 code to convert prices to for display, e.g. EUR",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Set to true to skip the listing cache (admins only)",
+                        "name": "X-Cache-Bypass",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Products retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid cursor or sort for cursor pagination, or unsupported currency",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Exchange rates are unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new product in the catalog (admin access required)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Create a new product",
+                "parameters": [
+                    {
+                        "description": "Product creation data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateProductRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Product created successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or validation error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Product with this SKU already exists",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/bulk": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create products from a JSON array of CreateProductRequest objects, or from a CSV file (multipart field \"file\" or a text/csv body) with a header row using the same field names. Every row is validated and reported individually. With atomic=true nothing is inserted if any row fails.",
+                "consumes": [
+                    "application/json",
+                    "multipart/form-data",
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Bulk import products",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Roll back the whole import if any row fails",
+                        "name": "atomic",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Products to import (JSON)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.CreateProductRequest"
+                            }
+                        }
+                    },
+                    {
+                        "type": "file",
+                        "description": "CSV file with products",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Import finished; see per-row results",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input, or atomic import with failing rows",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/cache/stats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get hit, miss and hit-rate statistics for the in-memory product listing cache and the Redis catalog cache",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Get product cache statistics",
+                "responses": {
+                    "200": {
+                        "description": "Cache statistics retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/low-stock": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get products at or below the stock threshold, lowest stock first, with units sold over the last days and the estimated days until each runs out. Cancelled orders do not count as sales.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Get low-stock products",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Stock threshold (default LOW_STOCK_THRESHOLD, 10)",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 365,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 30,
+                        "description": "Sales window in days",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 500,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Maximum number of products",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Low-stock products",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid threshold or window",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/receive-shipment": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Increase a product's stock from an inbound shipment, identified by product ID or SKU. Records a stock movement with the shipment reference and notifies users waiting for the product to come back in stock.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Receive shipment",
+                "parameters": [
+                    {
+                        "description": "Shipment data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReceiveShipmentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Shipment received successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or validation error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/recently-viewed": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the distinct products the current user (or, for guests, the X-Session-ID session) most recently opened, newest first. Each product appears once, at its latest view. Only product page views count, not listings, and products already in the cart or removed from the catalog are left out.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Get recently viewed products",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Guest session ID, used when not signed in",
+                        "name": "X-Session-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of products (max 50)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recently viewed products retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Neither signed in nor a session ID provided",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/recommendations": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get personalized product recommendations using ML algorithms with reasoning. Products the user dismissed within RECOMMENDATION_DISMISS_DAYS (default 30) days are left out. Stored recommendations are regenerated in the background once older than RECOMMENDATION_STALE_HOURS (default 6).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Get product recommendations",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of recommendations",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recommendations retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required for recommendations",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/recommendations/refresh": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Regenerate the current user's recommendations from the ML service now, regardless of how fresh the stored ones are. Regeneration runs in the background; fetch GET /products/recommendations afterwards. Recommendations are otherwise regenerated automatically once older than RECOMMENDATION_STALE_HOURS (default 6).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Refresh recommendations",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of recommendations to generate",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Regeneration started",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required for recommendations",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/search": {
+            "get": {
+                "description": "Search products with PostgreSQL full-text search (stemming and prefix matching) over name, category and description, ordered by ts_rank relevance with the ranking weights from /admin/search-config. When nothing matches, suggestions lists similar product names and popular past queries (\"did you mean\").",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Search products",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search query",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum price filter",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Maximum price filter",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Include category, price and tag facets for the result set",
+                        "name": "facets",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Search results retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Search query is required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/search/click": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Record that a product was opened from a search, using the search_query_id returned by /products/search. Each product counts once per search towards the search's results_clicked and the query's click-through rate. Only the user or guest session (X-Session-ID) that made the search can record clicks on it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Record search result click",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Guest session ID, used when not signed in",
+                        "name": "X-Session-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Search and clicked product",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RecordSearchClickRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Click recorded",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Search query or product not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/search/suggestions": {
+            "get": {
+                "description": "Get up to 10 completions for a search prefix, combining popular past queries and product names, ranked by query frequency",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Get search suggestions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search prefix",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Suggestions retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Search prefix is required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/sku/{sku}": {
+            "get": {
+                "description": "Look up a product by SKU, e.g. from a barcode scanner",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Get product by SKU",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product SKU",
+                        "name": "sku",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Product retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/social-counts": {
+            "post": {
+                "description": "Get upvote and favorite counts for up to 100 products in one request, plus the current user's upvote/favorite status when authenticated",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Upvotes"
+                ],
+                "summary": "Get batch social counts",
+                "parameters": [
+                    {
+                        "description": "Product IDs",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SocialCountsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Social counts retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/stock-adjust": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Apply relative stock changes to several products in one transaction. The whole batch is rejected if any product is missing, listed twice, or would end up with negative stock. Each change is recorded as a stock movement.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Adjust stock in bulk",
+                "parameters": [
+                    {
+                        "description": "Stock adjustments",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StockAdjustRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stock adjusted successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or validation error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Adjustment would make stock negative",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/trending": {
+            "get": {
+                "description": "Get the products trending now. Each interaction in the window counts by type (purchase 5, cart_add 3, favorite and wishlist 2, upvote and comment 1.5, view 1) and halves in weight every TRENDING_HALF_LIFE_HOURS (default 48), so products with rising interest outrank ones that were popular weeks ago. Results are cached for CACHE_TTL_SECONDS when Redis is configured.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Get trending products",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 30,
+                        "description": "Window of interactions considered, in days (max 90)",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of products (max 50)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only products in this category",
+                        "name": "category",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Trending products retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}": {
+            "get": {
+                "description": "Get detailed information about a specific product, including its ordered image gallery, its variants and the last computed sentiment of its comments",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Get product by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Product retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "410": {
+                        "description": "Product has been removed from the catalog",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update an existing product in the catalog (admin access required)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Update a product",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Product update data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateProductRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Product updated successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or product ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Product with this SKU already exists",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Soft-delete a product from the catalog (admin access required). Order history keeps referencing it and GET /products/{id} returns 410 afterwards.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Delete a product",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Product deleted successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/frequently-bought-together": {
+            "get": {
+                "description": "Get the products that most often appear in the same orders as the given product, ranked by the number of shared orders. The product itself, out-of-stock products and products removed from the catalog are left out; cancelled and refunded orders do not count. Rankings are cached for an hour.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Get frequently bought together products",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "description": "Number of products (max 20)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Frequently bought together products retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/images": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add an image to a product's gallery (admin access required). The first image becomes primary automatically.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Add product image",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Image details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AddProductImageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Image added successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/images/{imageId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove an image from a product's gallery (admin access required). Deleting the primary image promotes the next image by position.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Delete product image",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Image ID (UUID)",
+                        "name": "imageId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Image deleted successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product or image ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Image not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/images/{imageId}/primary": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Make an image the product's primary image (admin access required). Any previous primary image is demoted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Set primary product image",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Image ID (UUID)",
+                        "name": "imageId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Primary image updated successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product or image ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Image not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/price-history": {
+            "get": {
+                "description": "Get the price changes of a product, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Get product price history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum number of entries",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Price history retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/quote": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the effective unit and total price for a quantity, including any applied discounts and the reasons they apply. Overlapping discounts are combined under the stacking policy reported in stacking_policy (DISCOUNT_STACKING_POLICY: best, stack or exclusive). For signed-in users the minimum order amounts of discounts are checked against their cart as it would be with this quantity of the product, the way checkout prices it; order_subtotal reports that amount.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Get product price quote",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Quantity",
+                        "name": "quantity",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Price quote calculated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/services.PriceQuote"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID or quantity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/similar": {
+            "get": {
+                "description": "Get products in the same category as the given product, scored by the share of its tags they have (weight 0.6) and how close their price is (weight 0.4; 1 at the same price, 0 at double or free). Returns the top matches with their score, highest first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Get similar products",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "description": "Number of products (max 20)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Similar products retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/variants": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a purchasable variant such as a size or color to a product (admin access required). Once a product has variants, customers must pick one and its stock becomes the total of its variants' stock.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Add product variant",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Variant details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateProductVariantRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Variant added successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "SKU already in use",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/variants/{variantId}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change a variant's SKU, attributes, price override or stock (admin access required). The product's stock is recalculated from its variants.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Update product variant",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Variant ID (UUID)",
+                        "name": "variantId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateProductVariantRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Variant updated successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Variant not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "SKU already in use",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a variant from a product (admin access required). Variants that have been ordered cannot be deleted; set their stock to 0 instead.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Delete product variant",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Variant ID (UUID)",
+                        "name": "variantId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Variant deleted successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product or variant ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Authentication required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Variant not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Variant has been ordered",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/recommendations/{productId}/click": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Record that the current user clicked a product they were recommended. The click is tied to an impression: the one given by impression_id (as returned by GET /products/recommendations), or otherwise the user's latest impression of the product from the last 24 hours. Each impression counts at most one click; repeated clicks return the existing feedback.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Record recommendation click",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "productId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Impression the click came from",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RecommendationClickRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Click already recorded for this impression",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "201": {
+                        "description": "Click recorded",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product or impression ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "No recommendation impression found for this product",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/recommendations/{productId}/dismiss": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Record that the current user is not interested in a recommended product. The product is left out of their recommendations for RECOMMENDATION_DISMISS_DAYS (default 30) days and the dismissal is kept as negative feedback for the recommender. Dismissing again restarts the period.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Dismiss recommendation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "productId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Recommendation dismissed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/saved-items": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the products the user saved for later, most recent first. Products no longer in the catalog are left out.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cart"
+                ],
+                "summary": "Get saved items",
+                "responses": {
+                    "200": {
+                        "description": "Saved items retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/saved-items/{productId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a product from the user's saved-for-later list",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cart"
+                ],
+                "summary": "Remove saved item",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "productId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Saved item removed successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Saved item not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/saved-items/{productId}/move-to-cart": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move a saved-for-later product back into the cart with its saved quantity, subject to the same stock checks as adding to the cart",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cart"
+                ],
+                "summary": "Move saved item to cart",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "productId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Item moved to cart successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID or insufficient stock",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Saved item or product not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/saved-searches": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the searches the current user saved, oldest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Saved Searches"
+                ],
+                "summary": "Get saved searches",
+                "responses": {
+                    "200": {
+                        "description": "Saved searches retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Save a search query with optional category and price filters. The saved search is re-run periodically and a saved_search notification is sent when products added since the last check match it. Only products created after saving are reported.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Saved Searches"
+                ],
+                "summary": "Save search",
+                "parameters": [
+                    {
+                        "description": "Search to save",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SavedSearchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Search saved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or saved search limit reached",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/saved-searches/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a saved search of the current user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Saved Searches"
+                ],
+                "summary": "Get saved search",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Saved search ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Saved search retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid saved search ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Saved search not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the query and filters of a saved search. Products added before the update are not reported for the new criteria.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Saved Searches"
+                ],
+                "summary": "Update saved search",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Saved search ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New search criteria",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SavedSearchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Saved search updated successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Saved search not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a saved search and stop its alerts. Notifications already sent remain.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Saved Searches"
+                ],
+                "summary": "Delete saved search",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Saved search ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Saved search deleted successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid saved search ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Saved search not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/alerts": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get recent security alerts with filtering options",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Security"
+                ],
+                "summary": "Get Security Alerts",
+                "parameters": [
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Number of alerts to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "low",
+                            "medium",
+                            "high",
+                            "critical"
+                        ],
+                        "type": "string",
+                        "description": "Filter by risk level",
+                        "name": "risk_level",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by resolution status",
+                        "name": "resolved",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Security alerts data",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/security/alerts/{alert_id}/resolve": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Mark a security alert as resolved with optional notes. Set block_ip to also block the IP address that raised the alert.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Security"
+                ],
+                "summary": "Resolve Security Alert",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Alert ID",
+                        "name": "alert_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Resolution details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ResolveAlertRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Alert resolved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid alert ID or request body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Alert not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/security/blocks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List blocked IP addresses (admin only). Expired blocks are omitted unless include_expired is set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Security"
+                ],
+                "summary": "List IP blocks",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Include expired blocks",
+                        "name": "include_expired",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "IP blocks",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deny an IP address access to the API (admin only). Blocking an already blocked IP replaces its reason and expiry.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Security"
+                ],
+                "summary": "Block an IP address",
+                "parameters": [
+                    {
+                        "description": "Block details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateIPBlockRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "IP blocked successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid IP address or duration",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/security/blocks/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove an IP block (admin only). The IP regains access immediately.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Security"
+                ],
+                "summary": "Unblock an IP address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Block ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "IP unblocked successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid block ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Block not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/security/dashboard": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get comprehensive security dashboard with anomaly detection data and real-time threat monitoring",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Security"
+                ],
+                "summary": "Get Security Dashboard",
+                "responses": {
+                    "200": {
+                        "description": "Security dashboard data",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SecurityDashboardResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/security/insights": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get detailed insights and analysis about detected anomalies and attack patterns",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Security"
+                ],
+                "summary": "Get Anomaly Insights",
+                "responses": {
+                    "200": {
+                        "description": "Anomaly insights data",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/security/metrics": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get security metrics and statistics for a specified time period",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Security"
+                ],
+                "summary": "Get Security Metrics",
+                "parameters": [
+                    {
+                        "maximum": 365,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 30,
+                        "description": "Number of days to include in metrics",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Security metrics data",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/security/patterns": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get information about detectable attack patterns and security threats. Falls back to a built-in catalog (source: local) when the ML service is unavailable.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Security"
+                ],
+                "summary": "Get Attack Patterns",
+                "responses": {
+                    "200": {
+                        "description": "Attack patterns information",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/security/requests": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Search logged requests for incident investigation (admin only). Without from, only the last 24 hours are searched. Aggregates cover every matching request, not just the current page.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Security"
+                ],
+                "summary": "Get request logs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Client IP address",
+                        "name": "ip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "/api/orders",
+                        "description": "Request path prefix",
+                        "name": "path_prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "HTTP method",
+                        "name": "method",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 599,
+                        "minimum": 100,
+                        "type": "integer",
+                        "description": "Minimum status code",
+                        "name": "status_min",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 599,
+                        "minimum": 100,
+                        "type": "integer",
+                        "description": "Maximum status code",
+                        "name": "status_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the time window (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the time window (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Request logs with aggregates",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/security/simulate": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Simulate different types of attacks for demonstration and testing purposes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Security"
+                ],
+                "summary": "Simulate Attack",
+                "parameters": [
+                    {
+                        "enum": [
+                            "sql_injection",
+                            "xss",
+                            "brute_force",
+                            "ddos"
+                        ],
+                        "type": "string",
+                        "example": "sql_injection",
+                        "description": "Attack type",
+                        "name": "attack_type",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Attack simulation results",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid attack type",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StandardErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tags": {
+            "get": {
+                "description": "Get list of all available product tags, each with the number of products carrying it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tags"
+                ],
+                "summary": "Get all tags",
+                "responses": {
+                    "200": {
+                        "description": "Tags retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new product tag (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tags"
+                ],
+                "summary": "Create tag",
+                "parameters": [
+                    {
+                        "description": "Tag data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateTagRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Tag created successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or tag already exists",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/tags/bulk-assign": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Attach a tag to all products in a category (including its subcategories) or to a list of products, in one transaction (admin only). Products that already have the tag are skipped. Provide either category or product_ids.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tags"
+                ],
+                "summary": "Bulk assign tag",
+                "parameters": [
+                    {
+                        "description": "Tag and the products to attach it to",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BulkAssignTagRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tag assigned",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Tag or category not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/tags/products": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a tag to a product (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tags"
+                ],
+                "summary": "Add tag to product",
+                "parameters": [
+                    {
+                        "description": "Product and tag IDs",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AddProductTagRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Tag added to product successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or tag already added",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Product or tag not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a tag from a product (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tags"
+                ],
+                "summary": "Remove tag from product",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "product_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tag ID (UUID)",
+                        "name": "tag_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tag removed from product successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product or tag ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Product does not have the tag",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/tags/products/{product_id}": {
+            "get": {
+                "description": "Get all tags associated with a product",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tags"
+                ],
+                "summary": "Get product tags",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Product tags retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/tags/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a tag (admin only). A tag still attached to products is only deleted with force=true, which also removes it from those products.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tags"
+                ],
+                "summary": "Delete tag",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tag ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Remove the tag from its products as well",
+                        "name": "force",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tag deleted successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid tag ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Tag not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Tag is still attached to products",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/tags/{id}/products": {
+            "get": {
+                "description": "Get a paginated list of the products carrying a tag, looked up by ID or slug",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tags"
+                ],
+                "summary": "Get tag products",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tag ID (UUID) or slug",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Products retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Tag not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/upvotes": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add an upvote to a product",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Upvotes"
+                ],
+                "summary": "Upvote product",
+                "parameters": [
+                    {
+                        "description": "Product to upvote",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AddUpvoteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Product upvoted successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Product already upvoted",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/upvotes/{product_id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get upvote count and user's upvote status for a product",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Upvotes"
+                ],
+                "summary": "Get product upvotes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Upvotes retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove an upvote from a product",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Upvotes"
+                ],
+                "summary": "Remove upvote",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID (UUID)",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Upvote removed successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid product ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Upvote not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List registered webhooks (admin only). Secrets are never returned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "List webhooks",
+                "responses": {
+                    "200": {
+                        "description": "Webhooks",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Register an endpoint to receive signed event payloads (admin only). Each POST carries an X-Signature header with the hex HMAC-SHA256 of the body, prefixed with \"sha256=\". The secret is only returned in this response.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Register a webhook",
+                "parameters": [
+                    {
+                        "description": "Webhook details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Webhook created successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL, secret or event types",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change a webhook's URL, secret, event types or active flag (admin only). Pending deliveries to an inactive webhook fail on their next attempt.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Update a webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Webhook updated successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a webhook and its delivery history (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Delete a webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Webhook deleted successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid webhook ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the delivery attempts for a webhook, newest first (admin only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "List webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "succeeded",
+                            "failed"
+                        ],
+                        "type": "string",
+                        "description": "Filter by delivery status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Webhook deliveries",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid webhook ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Admin role required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/wishlists/{slug}": {
+            "get": {
+                "description": "Get a user's favorite products through a public share link. The response is read-only and does not include the owner's email or ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Favorites"
+                ],
+                "summary": "Get shared wishlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Share slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Shared wishlist retrieved successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Wishlist not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handlers.AddCommentRequest": {
+            "type": "object",
+            "required": [
+                "content",
+                "product_id"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "maxLength": 1000,
+                    "minLength": 1,
+                    "example": "Great product!"
+                },
+                "parent_id": {
+                    "description": "Comment being replied to",
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174001"
+                },
+                "product_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "rating": {
+                    "description": "Required for top-level comments, not allowed on replies",
+                    "type": "integer",
+                    "maximum": 5,
+                    "minimum": 1,
+                    "example": 5
+                }
+            }
+        },
+        "handlers.AddFavoriteRequest": {
+            "type": "object",
+            "required": [
+                "product_id"
+            ],
+            "properties": {
+                "product_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                }
+            }
+        },
+        "handlers.AddProductImageRequest": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "is_primary": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "position": {
+                    "description": "Defaults to the end of the gallery",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 1
+                },
+                "url": {
+                    "type": "string",
+                    "maxLength": 2048,
+                    "example": "https://example.com/image-2.jpg"
+                }
+            }
+        },
+        "handlers.AddProductTagRequest": {
+            "type": "object",
+            "required": [
+                "product_id",
+                "tag_id"
+            ],
+            "properties": {
+                "product_id": {
                     "type": "string",
                     "example": "123e4567-e89b-12d3-a456-426614174000"
                 },
@@ -3488,6 +10632,11 @@ const docTemplate = `{
                     "maximum": 100,
                     "minimum": 1,
                     "example": 2
+                },
+                "variant_id": {
+                    "description": "Required for products with variants",
+                    "type": "string",
+                    "example": "5f0c2a9e-4b7d-4c1e-9a3f-2d8e6b1c7a40"
                 }
             }
         },
@@ -3499,19 +10648,363 @@ const docTemplate = `{
             "properties": {
                 "product_id": {
                     "type": "string",
-                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                }
+            }
+        },
+        "handlers.ApplyCouponRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 3,
+                    "example": "SPRING20"
+                }
+            }
+        },
+        "handlers.ApplySuggestedTagsRequest": {
+            "type": "object",
+            "required": [
+                "tags"
+            ],
+            "properties": {
+                "tags": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "wireless"
+                    ]
+                }
+            }
+        },
+        "handlers.AuthResponse": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "description": "Access token lifetime in seconds",
+                    "type": "integer",
+                    "example": 900
+                },
+                "refresh_token": {
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                }
+            }
+        },
+        "handlers.BatchSentimentRequest": {
+            "type": "object",
+            "required": [
+                "product_ids"
+            ],
+            "properties": {
+                "product_ids": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "123e4567-e89b-12d3-a456-426614174000"
+                    ]
+                }
+            }
+        },
+        "handlers.BulkAssignTagRequest": {
+            "type": "object",
+            "required": [
+                "tag_id"
+            ],
+            "properties": {
+                "category": {
+                    "description": "Category name or slug; its subcategories are included",
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1,
+                    "example": "electronics"
+                },
+                "product_ids": {
+                    "type": "array",
+                    "maxItems": 1000,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "123e4567-e89b-12d3-a456-426614174000"
+                    ]
+                },
+                "tag_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                }
+            }
+        },
+        "handlers.CancelOrderItemRequest": {
+            "type": "object",
+            "required": [
+                "order_item_id"
+            ],
+            "properties": {
+                "order_item_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "quantity": {
+                    "description": "Defaults to the full item quantity",
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 1
+                }
+            }
+        },
+        "handlers.CancelOrderRequest": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.CancelOrderItemRequest"
+                    }
+                }
+            }
+        },
+        "handlers.CartTotals": {
+            "type": "object",
+            "properties": {
+                "coupon": {
+                    "$ref": "#/definitions/services.CouponQuote"
+                },
+                "coupon_discount": {
+                    "type": "integer"
+                },
+                "coupon_error": {
+                    "type": "string"
+                },
+                "discount_policy": {
+                    "description": "How overlapping automatic discounts are combined",
+                    "type": "string"
+                },
+                "discount_total": {
+                    "description": "Automatic discounts and the coupon",
+                    "type": "integer"
+                },
+                "item_count": {
+                    "description": "Total number of units",
+                    "type": "integer"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.CartTotalsLine"
+                    }
+                },
+                "shipping": {
+                    "type": "integer"
+                },
+                "subtotal": {
+                    "type": "integer"
+                },
+                "tax": {
+                    "type": "integer"
+                },
+                "tax_rate": {
+                    "type": "number"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.CartTotalsLine": {
+            "type": "object",
+            "properties": {
+                "applied_discounts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.AppliedDiscount"
+                    }
+                },
+                "cart_item_id": {
+                    "type": "string"
+                },
+                "coupon_discount": {
+                    "description": "Line's share of the coupon",
+                    "type": "integer"
+                },
+                "discount": {
+                    "description": "Automatic discounts for the line under the stacking policy",
+                    "type": "integer"
+                },
+                "product_id": {
+                    "type": "string"
+                },
+                "product_name": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "skipped_discounts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.SkippedDiscount"
+                    }
+                },
+                "subtotal": {
+                    "type": "integer"
+                },
+                "tax": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "unit_price": {
+                    "type": "integer"
+                },
+                "variant_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ChangePasswordRequest": {
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 1,
+                    "example": "password123"
+                },
+                "new_password": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 8,
+                    "example": "newpassword123"
+                }
+            }
+        },
+        "handlers.CreateAddressRequest": {
+            "type": "object",
+            "required": [
+                "city",
+                "country",
+                "line1",
+                "zip"
+            ],
+            "properties": {
+                "city": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Springfield"
+                },
+                "country": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 2,
+                    "example": "US"
+                },
+                "is_default": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "label": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "Home"
+                },
+                "line1": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 3,
+                    "example": "123 Main St"
+                },
+                "line2": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Apt 4B"
+                },
+                "state": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "IL"
+                },
+                "zip": {
+                    "type": "string",
+                    "maxLength": 20,
+                    "example": "62701"
+                }
+            }
+        },
+        "handlers.CreateAnalyticsScheduleRequest": {
+            "type": "object",
+            "required": [
+                "format",
+                "frequency",
+                "report_type"
+            ],
+            "properties": {
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "csv",
+                        "xlsx"
+                    ],
+                    "example": "csv"
+                },
+                "frequency": {
+                    "type": "string",
+                    "enum": [
+                        "weekly",
+                        "monthly"
+                    ],
+                    "example": "weekly"
+                },
+                "report_type": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "products"
+                    ],
+                    "example": "user"
                 }
             }
         },
-        "handlers.AuthResponse": {
+        "handlers.CreateCategoryRequest": {
             "type": "object",
+            "required": [
+                "name"
+            ],
             "properties": {
-                "token": {
+                "name": {
                     "type": "string",
-                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                    "maxLength": 100,
+                    "minLength": 1,
+                    "example": "Smartphones"
                 },
-                "user": {
-                    "$ref": "#/definitions/models.User"
+                "parent_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
                 }
             }
         },
@@ -3519,17 +11012,29 @@ const docTemplate = `{
             "type": "object",
             "required": [
                 "discount_type",
-                "discount_value",
                 "end_date",
                 "start_date"
             ],
             "properties": {
+                "amount_off": {
+                    "description": "Amount off, required for fixed_amount discounts",
+                    "type": "string",
+                    "minLength": 0,
+                    "example": "15.00"
+                },
                 "category": {
                     "type": "string",
                     "maxLength": 100,
                     "minLength": 1,
                     "example": "Electronics"
                 },
+                "code": {
+                    "description": "Coupon code; coupons may omit product_id and category to apply to the whole cart",
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 3,
+                    "example": "SPRING20"
+                },
                 "discount_type": {
                     "type": "string",
                     "enum": [
@@ -3539,8 +11044,9 @@ const docTemplate = `{
                     "example": "percentage"
                 },
                 "discount_value": {
+                    "description": "Percentage off, required for percentage discounts",
                     "type": "number",
-                    "minimum": 0,
+                    "maximum": 100,
                     "example": 20
                 },
                 "end_date": {
@@ -3548,14 +11054,14 @@ const docTemplate = `{
                     "example": "2024-12-31T23:59:59Z"
                 },
                 "max_discount_amount": {
-                    "type": "number",
-                    "minimum": 0,
-                    "example": 50
+                    "type": "string",
+                    "minLength": 0,
+                    "example": "50.00"
                 },
                 "min_order_amount": {
-                    "type": "number",
-                    "minimum": 0,
-                    "example": 100
+                    "type": "string",
+                    "minLength": 0,
+                    "example": "100.00"
                 },
                 "product_id": {
                     "type": "string",
@@ -3572,13 +11078,65 @@ const docTemplate = `{
                 }
             }
         },
+        "handlers.CreateExperimentRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 1000,
+                    "example": "Does collaborative filtering beat hybrid on CTR?"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 3,
+                    "example": "collab-vs-hybrid"
+                },
+                "variants": {
+                    "type": "array",
+                    "minItems": 2,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "collaborative",
+                        "hybrid"
+                    ]
+                }
+            }
+        },
+        "handlers.CreateIPBlockRequest": {
+            "type": "object",
+            "properties": {
+                "duration_hours": {
+                    "description": "0 blocks permanently",
+                    "type": "integer",
+                    "example": 24
+                },
+                "ip_address": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Credential stuffing"
+                }
+            }
+        },
         "handlers.CreateOrderRequest": {
             "type": "object",
             "required": [
-                "payment_method",
-                "shipping_address"
+                "payment_method"
             ],
             "properties": {
+                "address_id": {
+                    "description": "Saved address; defaults to the user's default address when neither is given",
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
                 "cart_item_ids": {
                     "description": "Optional: specific cart items to order (as string UUIDs)",
                     "type": "array",
@@ -3590,6 +11148,11 @@ const docTemplate = `{
                         "456e7890-e89b-12d3-a456-426614174001"
                     ]
                 },
+                "currency": {
+                    "description": "Display currency shown at checkout; amounts are charged in the base currency",
+                    "type": "string",
+                    "example": "EUR"
+                },
                 "payment_method": {
                     "type": "string",
                     "enum": [
@@ -3611,19 +11174,36 @@ const docTemplate = `{
         "handlers.CreateProductRequest": {
             "type": "object",
             "required": [
-                "category",
                 "description",
                 "name",
                 "price",
+                "sku",
                 "stock"
             ],
             "properties": {
+                "barcode": {
+                    "type": "string",
+                    "maxLength": 14,
+                    "minLength": 8,
+                    "example": "0194253401234"
+                },
+                "brand": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Apple"
+                },
                 "category": {
+                    "description": "Matched to a category by slug, created if missing",
                     "type": "string",
                     "maxLength": 100,
                     "minLength": 1,
                     "example": "Electronics"
                 },
+                "category_id": {
+                    "description": "Takes precedence over category",
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
                 "description": {
                     "type": "string",
                     "maxLength": 1000,
@@ -3641,9 +11221,14 @@ const docTemplate = `{
                     "example": "iPhone 15 Pro"
                 },
                 "price": {
-                    "type": "number",
-                    "minimum": 0.01,
-                    "example": 999.99
+                    "type": "string",
+                    "minLength": 1,
+                    "example": "999.99"
+                },
+                "sku": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "IPH15PRO256"
                 },
                 "stock": {
                     "type": "integer",
@@ -3652,6 +11237,40 @@ const docTemplate = `{
                 }
             }
         },
+        "handlers.CreateProductVariantRequest": {
+            "type": "object",
+            "required": [
+                "attributes",
+                "sku"
+            ],
+            "properties": {
+                "attributes": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "color": "red",
+                        "size": "M"
+                    }
+                },
+                "price_override": {
+                    "description": "Defaults to the product price",
+                    "type": "string",
+                    "example": "24.99"
+                },
+                "sku": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "TSHIRTMRED"
+                },
+                "stock": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 25
+                }
+            }
+        },
         "handlers.CreateTagRequest": {
             "type": "object",
             "required": [
@@ -3659,6 +11278,7 @@ const docTemplate = `{
             ],
             "properties": {
                 "color": {
+                    "description": "#RRGGBB",
                     "type": "string",
                     "example": "#FF5733"
                 },
@@ -3675,6 +11295,42 @@ const docTemplate = `{
                 }
             }
         },
+        "handlers.CreateWebhookRequest": {
+            "type": "object",
+            "properties": {
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "anomaly.critical",
+                        "order.created"
+                    ]
+                },
+                "secret": {
+                    "description": "Generated when omitted",
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://ops.example.com/hooks/shop"
+                }
+            }
+        },
+        "handlers.ForgotPasswordRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "user@example.com"
+                }
+            }
+        },
         "handlers.LoginRequest": {
             "type": "object",
             "required": [
@@ -3695,6 +11351,182 @@ const docTemplate = `{
                 }
             }
         },
+        "handlers.LogoutRequest": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "description": "Optional: also revoke this refresh token",
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+                }
+            }
+        },
+        "handlers.MergeGuestCartRequest": {
+            "type": "object",
+            "properties": {
+                "session_id": {
+                    "description": "Defaults to the X-Session-ID header",
+                    "type": "string",
+                    "maxLength": 128,
+                    "example": "b3f1c2d4-5e6f-4a7b-8c9d-0e1f2a3b4c5d"
+                }
+            }
+        },
+        "handlers.NotificationSuppressionRequest": {
+            "type": "object",
+            "required": [
+                "reason",
+                "user_id"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "enum": [
+                        "bounce",
+                        "unsubscribe",
+                        "complaint",
+                        "manual"
+                    ],
+                    "example": "bounce"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                }
+            }
+        },
+        "handlers.ReceiveShipmentRequest": {
+            "type": "object",
+            "required": [
+                "quantity",
+                "reference"
+            ],
+            "properties": {
+                "product_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "quantity": {
+                    "type": "integer",
+                    "maximum": 100000,
+                    "minimum": 1,
+                    "example": 50
+                },
+                "reference": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1,
+                    "example": "SHIP-2024-0042"
+                },
+                "sku": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "IPH15PRO256"
+                }
+            }
+        },
+        "handlers.RecommendationClickRequest": {
+            "type": "object",
+            "properties": {
+                "impression_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                }
+            }
+        },
+        "handlers.RecordSearchClickRequest": {
+            "type": "object",
+            "required": [
+                "product_id",
+                "search_query_id"
+            ],
+            "properties": {
+                "position": {
+                    "description": "1-based rank of the result on the page",
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 3
+                },
+                "product_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174001"
+                },
+                "search_query_id": {
+                    "description": "search_query_id from the search response",
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                }
+            }
+        },
+        "handlers.RefreshResponse": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "description": "Access token lifetime in seconds",
+                    "type": "integer",
+                    "example": 900
+                },
+                "token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                }
+            }
+        },
+        "handlers.RefreshTokenRequest": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+                }
+            }
+        },
+        "handlers.RefundOrderItemRequest": {
+            "type": "object",
+            "required": [
+                "order_item_id",
+                "quantity"
+            ],
+            "properties": {
+                "order_item_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 1
+                }
+            }
+        },
+        "handlers.RefundOrderRequest": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "amount": {
+                    "description": "Overrides the amount calculated from the items",
+                    "type": "string",
+                    "example": "19.99"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.RefundOrderItemRequest"
+                    }
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "minLength": 3,
+                    "example": "Item arrived damaged"
+                }
+            }
+        },
         "handlers.RegisterRequest": {
             "type": "object",
             "required": [
@@ -3718,19 +11550,78 @@ const docTemplate = `{
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 8,
-                    "example": "password123"
+                    "example": "password123"
+                }
+            }
+        },
+        "handlers.ResetPasswordRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "token"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 8,
+                    "example": "newpassword123"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
                 }
             }
         },
         "handlers.ResolveAlertRequest": {
             "type": "object",
             "properties": {
+                "block_duration_hours": {
+                    "description": "0 blocks permanently",
+                    "type": "integer",
+                    "example": 24
+                },
+                "block_ip": {
+                    "description": "Also block the alert's IP address",
+                    "type": "boolean",
+                    "example": false
+                },
                 "notes": {
                     "type": "string",
                     "example": "False positive - legitimate admin access"
                 }
             }
         },
+        "handlers.SavedSearchRequest": {
+            "type": "object",
+            "required": [
+                "query"
+            ],
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Electronics"
+                },
+                "max_price": {
+                    "description": "Omit for no upper bound",
+                    "type": "string",
+                    "minLength": 0,
+                    "example": "150.00"
+                },
+                "min_price": {
+                    "type": "string",
+                    "minLength": 0,
+                    "example": "50.00"
+                },
+                "query": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "minLength": 1,
+                    "example": "mechanical keyboard"
+                }
+            }
+        },
         "handlers.SecurityDashboardResponse": {
             "type": "object",
             "properties": {
@@ -3756,6 +11647,25 @@ const docTemplate = `{
                 }
             }
         },
+        "handlers.SocialCountsRequest": {
+            "type": "object",
+            "required": [
+                "product_ids"
+            ],
+            "properties": {
+                "product_ids": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "123e4567-e89b-12d3-a456-426614174000"
+                    ]
+                }
+            }
+        },
         "handlers.StandardErrorResponse": {
             "type": "object",
             "properties": {
@@ -3769,6 +11679,104 @@ const docTemplate = `{
                 }
             }
         },
+        "handlers.StockAdjustRequest": {
+            "type": "object",
+            "required": [
+                "adjustments"
+            ],
+            "properties": {
+                "adjustments": {
+                    "type": "array",
+                    "maxItems": 500,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/handlers.StockAdjustment"
+                    }
+                },
+                "reason": {
+                    "description": "Defaults to 'adjustment'",
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "stock_count"
+                },
+                "reference": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "COUNT-2024-03"
+                }
+            }
+        },
+        "handlers.StockAdjustment": {
+            "type": "object",
+            "required": [
+                "delta",
+                "product_id"
+            ],
+            "properties": {
+                "delta": {
+                    "type": "integer",
+                    "maximum": 100000,
+                    "minimum": -100000,
+                    "example": -3
+                },
+                "product_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "reason": {
+                    "description": "Defaults to the batch reason",
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "damaged"
+                }
+            }
+        },
+        "handlers.UpdateAddressRequest": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Springfield"
+                },
+                "country": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 2,
+                    "example": "US"
+                },
+                "is_default": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "label": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "Work"
+                },
+                "line1": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 3,
+                    "example": "1 Office Park"
+                },
+                "line2": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Suite 200"
+                },
+                "state": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "IL"
+                },
+                "zip": {
+                    "type": "string",
+                    "maxLength": 20,
+                    "example": "62702"
+                }
+            }
+        },
         "handlers.UpdateCartItemRequest": {
             "type": "object",
             "required": [
@@ -3783,6 +11791,23 @@ const docTemplate = `{
                 }
             }
         },
+        "handlers.UpdateCategoryRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1,
+                    "example": "Mobile Phones"
+                },
+                "parent_id": {
+                    "description": "Empty string moves the category to the top level",
+                    "type": "string",
+                    "maxLength": 36,
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                }
+            }
+        },
         "handlers.UpdateCommentRequest": {
             "type": "object",
             "properties": {
@@ -3800,12 +11825,60 @@ const docTemplate = `{
                 }
             }
         },
+        "handlers.UpdateExperimentStatusRequest": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "paused",
+                        "completed"
+                    ],
+                    "example": "completed"
+                }
+            }
+        },
+        "handlers.UpdateNotificationPreferencesRequest": {
+            "type": "object",
+            "properties": {
+                "back_in_stock": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "digest_frequency": {
+                    "type": "string",
+                    "enum": [
+                        "none",
+                        "daily",
+                        "weekly"
+                    ],
+                    "example": "weekly"
+                },
+                "order_status": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "price_drop": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
         "handlers.UpdateOrderStatusRequest": {
             "type": "object",
             "required": [
                 "status"
             ],
             "properties": {
+                "note": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "Handed over to the courier"
+                },
                 "status": {
                     "type": "string",
                     "enum": [
@@ -3822,12 +11895,27 @@ const docTemplate = `{
         "handlers.UpdateProductRequest": {
             "type": "object",
             "properties": {
+                "barcode": {
+                    "type": "string",
+                    "maxLength": 14,
+                    "minLength": 8,
+                    "example": "0194253401234"
+                },
+                "brand": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Apple"
+                },
                 "category": {
                     "type": "string",
                     "maxLength": 100,
                     "minLength": 1,
                     "example": "Electronics"
                 },
+                "category_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
                 "description": {
                     "type": "string",
                     "maxLength": 1000,
@@ -3845,9 +11933,14 @@ const docTemplate = `{
                     "example": "iPhone 15 Pro"
                 },
                 "price": {
-                    "type": "number",
-                    "minimum": 0.01,
-                    "example": 999.99
+                    "type": "string",
+                    "minLength": 1,
+                    "example": "999.99"
+                },
+                "sku": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "IPH15PRO256"
                 },
                 "stock": {
                     "type": "integer",
@@ -3856,6 +11949,32 @@ const docTemplate = `{
                 }
             }
         },
+        "handlers.UpdateProductVariantRequest": {
+            "type": "object",
+            "properties": {
+                "attributes": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "clear_price_override": {
+                    "description": "Sell at the product price again",
+                    "type": "boolean"
+                },
+                "price_override": {
+                    "type": "string"
+                },
+                "sku": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "stock": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
         "handlers.UpdateProfileRequest": {
             "type": "object",
             "properties": {
@@ -3872,6 +11991,62 @@ const docTemplate = `{
                 }
             }
         },
+        "handlers.UpdateUserPreferencesRequest": {
+            "type": "object",
+            "properties": {
+                "avoided_categories": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Clothing"
+                    ]
+                },
+                "max_price": {
+                    "type": "string",
+                    "minLength": 0,
+                    "example": "500.00"
+                },
+                "min_price": {
+                    "type": "string",
+                    "minLength": 0,
+                    "example": "10.00"
+                },
+                "preferred_categories": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Electronics",
+                        "Books"
+                    ]
+                }
+            }
+        },
+        "handlers.UpdateWebhookRequest": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
         "handlers.UserProfileResponse": {
             "type": "object",
             "properties": {
@@ -3906,6 +12081,9 @@ const docTemplate = `{
                         "$ref": "#/definitions/models.Order"
                     }
                 },
+                "phone": {
+                    "type": "string"
+                },
                 "recent_activity": {
                     "$ref": "#/definitions/handlers.UserRecentActivity"
                 },
@@ -3915,6 +12093,10 @@ const docTemplate = `{
                         "$ref": "#/definitions/models.Recommendation"
                     }
                 },
+                "role": {
+                    "description": "'customer', 'admin'",
+                    "type": "string"
+                },
                 "shopping_cart": {
                     "$ref": "#/definitions/models.ShoppingCart"
                 },
@@ -3942,13 +12124,13 @@ const docTemplate = `{
             "type": "object",
             "properties": {
                 "average_order_value": {
-                    "type": "number"
+                    "type": "integer"
                 },
                 "cart_items_count": {
                     "type": "integer"
                 },
                 "cart_total_value": {
-                    "type": "number"
+                    "type": "integer"
                 },
                 "favorite_category": {
                     "type": "string"
@@ -3966,7 +12148,7 @@ const docTemplate = `{
                     "type": "integer"
                 },
                 "total_spent": {
-                    "type": "number"
+                    "type": "integer"
                 }
             }
         },
@@ -3979,23 +12161,66 @@ const docTemplate = `{
                         "$ref": "#/definitions/models.UserInteraction"
                     }
                 },
-                "recent_orders": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.Order"
-                    }
+                "recent_orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Order"
+                    }
+                },
+                "recent_searches": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SearchQuery"
+                    }
+                },
+                "recent_views": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProductView"
+                    }
+                }
+            }
+        },
+        "models.Address": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "type": "string"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_default": {
+                    "description": "At most one per user",
+                    "type": "boolean"
                 },
-                "recent_searches": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.SearchQuery"
-                    }
+                "label": {
+                    "description": "e.g. 'Home', 'Work'",
+                    "type": "string"
                 },
-                "recent_views": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.ProductView"
-                    }
+                "line1": {
+                    "type": "string"
+                },
+                "line2": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "zip": {
+                    "type": "string"
                 }
             }
         },
@@ -4024,6 +12249,10 @@ const docTemplate = `{
                 "notes": {
                     "type": "string"
                 },
+                "request_id": {
+                    "description": "X-Request-ID of the originating request",
+                    "type": "string"
+                },
                 "request_log": {
                     "description": "Relationships",
                     "allOf": [
@@ -4088,6 +12317,45 @@ const docTemplate = `{
                 "quantity": {
                     "type": "integer"
                 },
+                "updated_at": {
+                    "type": "string"
+                },
+                "variant": {
+                    "$ref": "#/definitions/models.ProductVariant"
+                },
+                "variant_id": {
+                    "description": "Required for products with variants",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Category": {
+            "type": "object",
+            "properties": {
+                "children": {
+                    "description": "Relationships",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Category"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "description": "Nil for top-level categories",
+                    "type": "string"
+                },
+                "slug": {
+                    "description": "URL-safe form of the name, unique across the tree",
+                    "type": "string"
+                },
                 "updated_at": {
                     "type": "string"
                 }
@@ -4105,6 +12373,10 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
+                "parent_id": {
+                    "description": "Set for replies; replies cannot be replied to",
+                    "type": "string"
+                },
                 "product": {
                     "$ref": "#/definitions/models.Product"
                 },
@@ -4112,9 +12384,15 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "rating": {
-                    "description": "1-5 star rating",
+                    "description": "1-5 star rating; nil for replies",
                     "type": "integer"
                 },
+                "replies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Comment"
+                    }
+                },
                 "updated_at": {
                     "type": "string"
                 },
@@ -4128,16 +12406,28 @@ const docTemplate = `{
                 },
                 "user_id": {
                     "type": "string"
+                },
+                "verified_purchase": {
+                    "description": "Author received an order containing the product",
+                    "type": "boolean"
                 }
             }
         },
         "models.Discount": {
             "type": "object",
             "properties": {
+                "amount_off": {
+                    "description": "Fixed amount taken off; 0 for percentages",
+                    "type": "string"
+                },
                 "category": {
                     "description": "Nullable for product-specific discounts",
                     "type": "string"
                 },
+                "code": {
+                    "description": "Coupon code; nil for automatic discounts",
+                    "type": "string"
+                },
                 "created_at": {
                     "type": "string"
                 },
@@ -4146,7 +12436,7 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "discount_value": {
-                    "description": "Percentage (0-100) or fixed amount",
+                    "description": "Percentage (0-100); 0 for fixed amounts",
                     "type": "number"
                 },
                 "end_date": {
@@ -4156,15 +12446,16 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "is_active": {
+                    "description": "Kept in step with the date window by the discount scheduler",
                     "type": "boolean"
                 },
                 "max_discount_amount": {
                     "description": "Maximum discount amount (for percentage)",
-                    "type": "number"
+                    "type": "string"
                 },
                 "min_order_amount": {
                     "description": "Minimum order amount to apply discount",
-                    "type": "number"
+                    "type": "string"
                 },
                 "product": {
                     "description": "Relationships",
@@ -4221,12 +12512,65 @@ const docTemplate = `{
                 }
             }
         },
+        "models.NotificationPreference": {
+            "type": "object",
+            "properties": {
+                "back_in_stock": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "digest_frequency": {
+                    "description": "'none', 'daily', 'weekly'",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "order_status": {
+                    "type": "boolean"
+                },
+                "price_drop": {
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
         "models.Order": {
             "type": "object",
             "properties": {
+                "coupon_discount": {
+                    "type": "string"
+                },
+                "coupon_id": {
+                    "type": "string"
+                },
                 "created_at": {
                     "type": "string"
                 },
+                "currency": {
+                    "description": "Currency the customer saw at checkout and the rate from the base currency at that\ntime; the amounts above stay in the base currency",
+                    "type": "string"
+                },
+                "discount_ids": {
+                    "description": "Automatic discounts redeemed at checkout",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "discount_total": {
+                    "type": "string"
+                },
+                "exchange_rate": {
+                    "type": "number"
+                },
                 "id": {
                     "type": "string"
                 },
@@ -4236,11 +12580,36 @@ const docTemplate = `{
                         "$ref": "#/definitions/models.OrderItem"
                     }
                 },
+                "refunds": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Refund"
+                    }
+                },
+                "shipping_address": {
+                    "description": "Snapshot of the shipping address at checkout; editing the saved address does not change it",
+                    "type": "string"
+                },
+                "shipping_address_id": {
+                    "description": "Saved address the snapshot was taken from",
+                    "type": "string"
+                },
+                "shipping_cost": {
+                    "type": "string"
+                },
                 "status": {
                     "type": "string"
                 },
+                "subtotal": {
+                    "description": "Pricing breakdown; DiscountTotal includes automatic discounts and the coupon",
+                    "type": "string"
+                },
+                "tax_total": {
+                    "type": "string"
+                },
                 "total": {
-                    "type": "number"
+                    "description": "Subtotal - DiscountTotal + TaxTotal + ShippingCost",
+                    "type": "string"
                 },
                 "updated_at": {
                     "type": "string"
@@ -4279,7 +12648,7 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "price": {
-                    "type": "number"
+                    "type": "string"
                 },
                 "product": {
                     "$ref": "#/definitions/models.Product"
@@ -4289,12 +12658,25 @@ const docTemplate = `{
                 },
                 "quantity": {
                     "type": "integer"
+                },
+                "variant": {
+                    "$ref": "#/definitions/models.ProductVariant"
+                },
+                "variant_id": {
+                    "description": "Set for products with variants",
+                    "type": "string"
                 }
             }
         },
         "models.Product": {
             "type": "object",
             "properties": {
+                "barcode": {
+                    "type": "string"
+                },
+                "brand": {
+                    "type": "string"
+                },
                 "cart_items": {
                     "type": "array",
                     "items": {
@@ -4302,8 +12684,16 @@ const docTemplate = `{
                     }
                 },
                 "category": {
+                    "description": "Category name, kept in sync with CategoryID for older clients",
+                    "type": "string"
+                },
+                "category_id": {
+                    "description": "Node in the category tree",
                     "type": "string"
                 },
+                "category_node": {
+                    "$ref": "#/definitions/models.Category"
+                },
                 "comments": {
                     "type": "array",
                     "items": {
@@ -4313,6 +12703,13 @@ const docTemplate = `{
                 "created_at": {
                     "type": "string"
                 },
+                "currency": {
+                    "description": "Prices are stored in this currency; others are converted for display only",
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
                 "description": {
                     "type": "string"
                 },
@@ -4334,18 +12731,25 @@ const docTemplate = `{
                 "image_url": {
                     "type": "string"
                 },
+                "images": {
+                    "description": "Relationships",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProductImage"
+                    }
+                },
                 "name": {
                     "type": "string"
                 },
                 "order_items": {
-                    "description": "Relationships",
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/models.OrderItem"
                     }
                 },
                 "price": {
-                    "type": "number"
+                    "type": "string",
+                    "example": "999.99"
                 },
                 "product_views": {
                     "type": "array",
@@ -4359,7 +12763,19 @@ const docTemplate = `{
                         "$ref": "#/definitions/models.Recommendation"
                     }
                 },
+                "reserved": {
+                    "description": "Units held by checkout reservations; Stock - Reserved is available",
+                    "type": "integer"
+                },
+                "sentiment": {
+                    "$ref": "#/definitions/models.SentimentSnapshot"
+                },
+                "sku": {
+                    "description": "NULL only for products created before SKUs were required",
+                    "type": "string"
+                },
                 "stock": {
+                    "description": "Total of the variants' stock for products with variants",
                     "type": "integer"
                 },
                 "tags": {
@@ -4382,6 +12798,69 @@ const docTemplate = `{
                     "items": {
                         "$ref": "#/definitions/models.UserInteraction"
                     }
+                },
+                "variants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProductVariant"
+                    }
+                }
+            }
+        },
+        "models.ProductImage": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_primary": {
+                    "type": "boolean"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "product_id": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ProductVariant": {
+            "type": "object",
+            "properties": {
+                "attributes": {
+                    "description": "e.g. {\"size\": \"M\", \"color\": \"red\"}",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "price_override": {
+                    "description": "Nil sells at the product price",
+                    "type": "string"
+                },
+                "product_id": {
+                    "type": "string"
+                },
+                "sku": {
+                    "type": "string"
+                },
+                "stock": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
@@ -4404,6 +12883,10 @@ const docTemplate = `{
                 "session_id": {
                     "type": "string"
                 },
+                "source": {
+                    "description": "'detail' for the product page, 'list' for listings",
+                    "type": "string"
+                },
                 "user": {
                     "description": "Relationships",
                     "allOf": [
@@ -4427,6 +12910,10 @@ const docTemplate = `{
                 "created_at": {
                     "type": "string"
                 },
+                "generated_at": {
+                    "description": "When the recommendation was last (re)generated",
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
@@ -4447,7 +12934,54 @@ const docTemplate = `{
                         }
                     ]
                 },
-                "user_id": {
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Refund": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "items": {
+                    "description": "Relationships",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RefundItem"
+                    }
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RefundItem": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "order_item_id": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "refund_id": {
                     "type": "string"
                 }
             }
@@ -4470,6 +13004,10 @@ const docTemplate = `{
                 "query_params": {
                     "type": "string"
                 },
+                "request_id": {
+                    "description": "X-Request-ID of the request",
+                    "type": "string"
+                },
                 "request_size": {
                     "type": "integer"
                 },
@@ -4519,6 +13057,7 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "results_clicked": {
+                    "description": "Distinct results opened from this search",
                     "type": "integer"
                 },
                 "results_count": {
@@ -4558,6 +13097,10 @@ const docTemplate = `{
                 "error_rate": {
                     "type": "number"
                 },
+                "error_requests": {
+                    "description": "Requests answered with a 4xx or 5xx status",
+                    "type": "integer"
+                },
                 "high_risk_requests": {
                     "type": "integer"
                 },
@@ -4575,6 +13118,28 @@ const docTemplate = `{
                 }
             }
         },
+        "models.SentimentSnapshot": {
+            "type": "object",
+            "properties": {
+                "comment_count": {
+                    "type": "integer"
+                },
+                "computed_at": {
+                    "type": "string"
+                },
+                "label": {
+                    "description": "'positive', 'negative', 'neutral' or 'no_data'",
+                    "type": "string"
+                },
+                "product_id": {
+                    "type": "string"
+                },
+                "score": {
+                    "description": "Average comment sentiment, from -1 to 1",
+                    "type": "number"
+                }
+            }
+        },
         "models.ShoppingCart": {
             "type": "object",
             "properties": {
@@ -4584,12 +13149,23 @@ const docTemplate = `{
                         "$ref": "#/definitions/models.CartItem"
                     }
                 },
+                "coupon": {
+                    "$ref": "#/definitions/models.Discount"
+                },
+                "coupon_id": {
+                    "description": "Applied coupon, redeemed when the order is placed",
+                    "type": "string"
+                },
                 "created_at": {
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
+                "session_id": {
+                    "description": "Set for guest carts only",
+                    "type": "string"
+                },
                 "updated_at": {
                     "type": "string"
                 },
@@ -4602,6 +13178,7 @@ const docTemplate = `{
                     ]
                 },
                 "user_id": {
+                    "description": "Nil for guest carts",
                     "type": "string"
                 }
             }
@@ -4610,7 +13187,7 @@ const docTemplate = `{
             "type": "object",
             "properties": {
                 "color": {
-                    "description": "Hex color code",
+                    "description": "Hex color code, #RRGGBB",
                     "type": "string"
                 },
                 "created_at": {
@@ -4631,6 +13208,34 @@ const docTemplate = `{
                     "items": {
                         "$ref": "#/definitions/models.Product"
                     }
+                },
+                "slug": {
+                    "description": "URL-safe form of the name, unique across tags",
+                    "type": "string"
+                }
+            }
+        },
+        "models.TrainingJob": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "started_by": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "'running', 'completed' or 'failed'",
+                    "type": "string"
                 }
             }
         },
@@ -4696,12 +13301,19 @@ const docTemplate = `{
                         "$ref": "#/definitions/models.Order"
                     }
                 },
+                "phone": {
+                    "type": "string"
+                },
                 "recommendations": {
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/models.Recommendation"
                     }
                 },
+                "role": {
+                    "description": "'customer', 'admin'",
+                    "type": "string"
+                },
                 "shopping_cart": {
                     "$ref": "#/definitions/models.ShoppingCart"
                 },
@@ -4757,6 +13369,281 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "services.AppliedDiscount": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "amount_off": {
+                    "description": "Fixed amount, for fixed amount discounts",
+                    "type": "integer"
+                },
+                "discount_id": {
+                    "type": "string"
+                },
+                "discount_type": {
+                    "type": "string"
+                },
+                "discount_value": {
+                    "description": "Percentage, for percentage discounts",
+                    "type": "number"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "scope": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.CouponQuote": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "discount_id": {
+                    "type": "string"
+                },
+                "eligible_subtotal": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "scope": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.DailyRevenue": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "description": "YYYY-MM-DD",
+                    "type": "string"
+                },
+                "revenue": {
+                    "type": "number"
+                }
+            }
+        },
+        "services.Invoice": {
+            "type": "object",
+            "properties": {
+                "coupon_code": {
+                    "type": "string"
+                },
+                "coupon_discount": {
+                    "type": "integer"
+                },
+                "customer_email": {
+                    "type": "string"
+                },
+                "customer_name": {
+                    "type": "string"
+                },
+                "discount_total": {
+                    "type": "integer"
+                },
+                "grand_total": {
+                    "type": "integer"
+                },
+                "invoice_number": {
+                    "type": "string"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.InvoiceLine"
+                    }
+                },
+                "order_date": {
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "refunded": {
+                    "type": "integer"
+                },
+                "shipping": {
+                    "type": "integer"
+                },
+                "shipping_address": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "subtotal": {
+                    "type": "integer"
+                },
+                "tax": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.InvoiceLine": {
+            "type": "object",
+            "properties": {
+                "discount": {
+                    "description": "Share of the order's discounts",
+                    "type": "integer"
+                },
+                "product_id": {
+                    "type": "string"
+                },
+                "product_name": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "sku": {
+                    "type": "string"
+                },
+                "subtotal": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "unit_price": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.PriceQuote": {
+            "type": "object",
+            "properties": {
+                "applied_discounts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.AppliedDiscount"
+                    }
+                },
+                "base_unit_price": {
+                    "type": "integer"
+                },
+                "discount_total": {
+                    "type": "integer"
+                },
+                "product_id": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "skipped_discounts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.SkippedDiscount"
+                    }
+                },
+                "stacking_policy": {
+                    "description": "How overlapping discounts were combined",
+                    "type": "string"
+                },
+                "subtotal": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "unit_price": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.RevenueForecast": {
+            "type": "object",
+            "properties": {
+                "confidence_level": {
+                    "type": "number"
+                },
+                "forecast": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.RevenueForecastPoint"
+                    }
+                },
+                "generated_at": {
+                    "type": "string"
+                },
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.DailyRevenue"
+                    }
+                },
+                "model": {
+                    "type": "string"
+                },
+                "source": {
+                    "description": "'ml_service' or 'linear_trend'",
+                    "type": "string"
+                },
+                "total_forecast": {
+                    "type": "number"
+                }
+            }
+        },
+        "services.RevenueForecastPoint": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "lower_bound": {
+                    "type": "number"
+                },
+                "revenue": {
+                    "type": "number"
+                },
+                "upper_bound": {
+                    "type": "number"
+                }
+            }
+        },
+        "services.SearchWeights": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "number",
+                    "maximum": 1,
+                    "minimum": 0,
+                    "example": 0.4
+                },
+                "description": {
+                    "type": "number",
+                    "maximum": 1,
+                    "minimum": 0,
+                    "example": 0.2
+                },
+                "name": {
+                    "type": "number",
+                    "maximum": 1,
+                    "minimum": 0,
+                    "example": 1
+                }
+            }
+        },
+        "services.SkippedDiscount": {
+            "type": "object",
+            "properties": {
+                "discount_id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
@@ -4776,6 +13663,10 @@ const docTemplate = `{
             "description": "Product catalog management and search",
             "name": "Products"
         },
+        {
+            "description": "Product category tree",
+            "name": "Categories"
+        },
         {
             "description": "Shopping cart operations",
             "name": "Cart"
@@ -4784,6 +13675,10 @@ const docTemplate = `{
             "description": "Order management and tracking",
             "name": "Orders"
         },
+        {
+            "description": "Saved shipping addresses",
+            "name": "Addresses"
+        },
         {
             "description": "Business analytics and reporting",
             "name": "Analytics"
@@ -4802,7 +13697,7 @@ const docTemplate = `{
 // SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = &swag.Spec{
 	Version:          "1.0",
-	Host:             "localhost:8081",
+	Host:             "localhost:8080",
 	BasePath:         "/api/v1",
 	Schemes:          []string{"http", "https"},
 	Title:            "Bachelor E-commerce API",
@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"bachelor_backend/database"
+	"bachelor_backend/middleware"
+	"bachelor_backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreateAddressRequest represents the request to save a shipping address
+type CreateAddressRequest struct {
+	Label     string `json:"label" validate:"max=50" example:"Home"`
+	Line1     string `json:"line1" validate:"required,min=3,max=255" example:"123 Main St"`
+	Line2     string `json:"line2" validate:"max=255" example:"Apt 4B"`
+	City      string `json:"city" validate:"required,max=100" example:"Springfield"`
+	State     string `json:"state" validate:"max=100" example:"IL"`
+	Zip       string `json:"zip" validate:"required,max=20" example:"62701"`
+	Country   string `json:"country" validate:"required,min=2,max=100" example:"US"`
+	IsDefault bool   `json:"is_default" example:"true"`
+}
+
+// UpdateAddressRequest represents the request to update a saved address.
+// Only the provided fields are changed.
+type UpdateAddressRequest struct {
+	Label     *string `json:"label,omitempty" validate:"omitempty,max=50" example:"Work"`
+	Line1     *string `json:"line1,omitempty" validate:"omitempty,min=3,max=255" example:"1 Office Park"`
+	Line2     *string `json:"line2,omitempty" validate:"omitempty,max=255" example:"Suite 200"`
+	City      *string `json:"city,omitempty" validate:"omitempty,max=100" example:"Springfield"`
+	State     *string `json:"state,omitempty" validate:"omitempty,max=100" example:"IL"`
+	Zip       *string `json:"zip,omitempty" validate:"omitempty,max=20" example:"62702"`
+	Country   *string `json:"country,omitempty" validate:"omitempty,min=2,max=100" example:"US"`
+	IsDefault *bool   `json:"is_default,omitempty" example:"true"`
+}
+
+// formatAddress renders a saved address as the single text block stored on orders
+func formatAddress(address models.Address) string {
+	lines := []string{address.Line1}
+	if address.Line2 != "" {
+		lines = append(lines, address.Line2)
+	}
+
+	cityLine := address.City
+	if address.State != "" {
+		cityLine += ", " + address.State
+	}
+	cityLine += " " + address.Zip
+	lines = append(lines, cityLine, address.Country)
+
+	return strings.Join(lines, "\n")
+}
+
+// setDefaultAddress makes the given address the user's only default address
+func setDefaultAddress(tx *gorm.DB, userID, addressID uuid.UUID) error {
+	if err := tx.Model(&models.Address{}).
+		Where("user_id = ? AND id <> ? AND is_default = ?", userID, addressID, true).
+		Update("is_default", false).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&models.Address{}).
+		Where("id = ?", addressID).
+		Update("is_default", true).Error
+}
+
+// loadUserAddress fetches one of the current user's addresses from the route parameters
+func loadUserAddress(c *fiber.Ctx, userID uuid.UUID) (models.Address, error) {
+	var address models.Address
+
+	addressID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return address, fiber.NewError(fiber.StatusBadRequest, "Invalid address ID")
+	}
+
+	if err := database.DB.Where("id = ? AND user_id = ?", addressID, userID).First(&address).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return address, fiber.NewError(fiber.StatusNotFound, "Address not found")
+		}
+		return address, err
+	}
+
+	return address, nil
+}
+
+// GetAddresses returns the user's saved addresses
+// @Summary Get saved addresses
+// @Description Get the current user's saved shipping addresses, default address first
+// @Tags Addresses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Addresses retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /addresses [get]
+func GetAddresses(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var addresses []models.Address
+	if err := database.DB.Where("user_id = ?", userID).
+		Order("is_default DESC, created_at ASC").
+		Find(&addresses).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch addresses",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"addresses": addresses,
+	})
+}
+
+// GetAddress returns a single saved address
+// @Summary Get saved address
+// @Description Get one of the current user's saved shipping addresses
+// @Tags Addresses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Address ID (UUID)"
+// @Success 200 {object} models.Address "Address retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid address ID"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 404 {object} map[string]interface{} "Address not found"
+// @Router /addresses/{id} [get]
+func GetAddress(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	address, err := loadUserAddress(c, userID)
+	if err != nil {
+		return fiberErrorResponse(c, err)
+	}
+
+	return c.JSON(address)
+}
+
+// CreateAddress saves a new shipping address
+// @Summary Save address
+// @Description Save a shipping address to the current user's address book. The first address becomes the default automatically.
+// @Tags Addresses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateAddressRequest true "Address details"
+// @Success 201 {object} map[string]interface{} "Address saved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /addresses [post]
+func CreateAddress(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var req CreateAddressRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	address := models.Address{
+		UserID:  userID,
+		Label:   strings.TrimSpace(req.Label),
+		Line1:   strings.TrimSpace(req.Line1),
+		Line2:   strings.TrimSpace(req.Line2),
+		City:    strings.TrimSpace(req.City),
+		State:   strings.TrimSpace(req.State),
+		Zip:     strings.TrimSpace(req.Zip),
+		Country: strings.TrimSpace(req.Country),
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Address{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(&address).Error; err != nil {
+			return err
+		}
+
+		// The first address is always the default
+		if req.IsDefault || count == 0 {
+			address.IsDefault = true
+			return setDefaultAddress(tx, userID, address.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save address",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Address saved successfully",
+		"address": address,
+	})
+}
+
+// UpdateAddress updates a saved address
+// @Summary Update address
+// @Description Update a saved shipping address. Orders already placed keep the address they were shipped to.
+// @Tags Addresses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Address ID (UUID)"
+// @Param request body UpdateAddressRequest true "Fields to update"
+// @Success 200 {object} map[string]interface{} "Address updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 404 {object} map[string]interface{} "Address not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /addresses/{id} [put]
+func UpdateAddress(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	address, err := loadUserAddress(c, userID)
+	if err != nil {
+		return fiberErrorResponse(c, err)
+	}
+
+	var req UpdateAddressRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	updates := map[string]interface{}{}
+	fields := map[string]*string{
+		"label":   req.Label,
+		"line1":   req.Line1,
+		"line2":   req.Line2,
+		"city":    req.City,
+		"state":   req.State,
+		"zip":     req.Zip,
+		"country": req.Country,
+	}
+	for column, value := range fields {
+		if value != nil {
+			updates[column] = strings.TrimSpace(*value)
+		}
+	}
+
+	// Required fields cannot be cleared
+	for _, column := range []string{"line1", "city", "zip", "country"} {
+		if value, set := updates[column]; set && value == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": column + " cannot be empty",
+			})
+		}
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if len(updates) > 0 {
+			if err := tx.Model(&address).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		// Unsetting the default is done by making another address the default
+		if req.IsDefault != nil && *req.IsDefault && !address.IsDefault {
+			if err := setDefaultAddress(tx, userID, address.ID); err != nil {
+				return err
+			}
+		}
+
+		return tx.First(&address, "id = ?", address.ID).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update address",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Address updated successfully",
+		"address": address,
+	})
+}
+
+// DeleteAddress removes a saved address
+// @Summary Delete address
+// @Description Remove a saved shipping address. Deleting the default address makes the oldest remaining address the default.
+// @Tags Addresses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Address ID (UUID)"
+// @Success 200 {object} map[string]interface{} "Address deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid address ID"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 404 {object} map[string]interface{} "Address not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /addresses/{id} [delete]
+func DeleteAddress(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	address, err := loadUserAddress(c, userID)
+	if err != nil {
+		return fiberErrorResponse(c, err)
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&address).Error; err != nil {
+			return err
+		}
+
+		if !address.IsDefault {
+			return nil
+		}
+
+		// Promote the oldest remaining address
+		var next models.Address
+		err := tx.Where("user_id = ?", userID).Order("created_at ASC").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return setDefaultAddress(tx, userID, next.ID)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete address",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Address deleted successfully",
+	})
+}
@@ -1,13 +1,19 @@
 package handlers
 
 import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"net/url"
 	"strconv"
 	"time"
 
 	"bachelor_backend/database"
 	"bachelor_backend/middleware"
 	"bachelor_backend/models"
+	"bachelor_backend/pkg/money"
+	"bachelor_backend/services"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -15,11 +21,12 @@ import (
 
 // GetDashboard returns comprehensive dashboard analytics
 // @Summary Get dashboard analytics
-// @Description Get comprehensive dashboard analytics including user statistics and recent interactions
+// @Description Get comprehensive dashboard analytics including user statistics and recent interactions. Cached per user for ANALYTICS_CACHE_TTL_SECONDS (default 5 minutes), so figures can lag by up to that long; the X-Cache header reports HIT, MISS or BYPASS. Placing an order clears the caller's cached analytics.
 // @Tags Analytics
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param nocache query bool false "Bypass the analytics cache"
 // @Success 200 {object} map[string]interface{} "Dashboard analytics retrieved successfully"
 // @Failure 401 {object} map[string]interface{} "User not authenticated"
 // @Router /analytics/dashboard [get]
@@ -31,12 +38,17 @@ func GetDashboard(c *fiber.Ctx) error {
 		})
 	}
 
+	cacheKey := analyticsCacheKey(c, userID, "dashboard")
+	if body, found := cachedAnalytics(c, cacheKey); found {
+		return sendAnalytics(c, body, "HIT")
+	}
+
 	// Get user statistics
 	var userStats struct {
-		TotalOrders   int64   `json:"total_orders"`
-		TotalSpent    float64 `json:"total_spent"`
-		TotalProducts int64   `json:"total_products"`
-		RecentOrders  int64   `json:"recent_orders"`
+		TotalOrders   int64       `json:"total_orders"`
+		TotalSpent    money.Cents `json:"total_spent"`
+		TotalProducts int64       `json:"total_products"`
+		RecentOrders  int64       `json:"recent_orders"`
 	}
 
 	// Total orders and spent
@@ -124,13 +136,13 @@ func GetDashboard(c *fiber.Ctx) error {
 		quickInsights.ActivityLevel = "Inactive"
 	}
 
-	// Recommendation score (based on engagement with recommendations)
-	var totalRecs, clickedRecs int64
-	database.DB.Model(&models.Recommendation{}).Where("user_id = ?", userID).Count(&totalRecs)
-	database.DB.Model(&models.RecommendationFeedback{}).Where("user_id = ? AND feedback_type = ?", userID, "clicked").Count(&clickedRecs)
+	// Recommendation score (click-through rate on recommendations shown)
+	var impressions, clickedRecs int64
+	database.DB.Model(&models.RecommendationImpression{}).Where("user_id = ?", userID).Count(&impressions)
+	database.DB.Model(&models.RecommendationFeedback{}).Where("user_id = ? AND feedback_type = ? AND impression_id IS NOT NULL", userID, "clicked").Count(&clickedRecs)
 
-	if totalRecs > 0 {
-		quickInsights.RecommendationScore = float64(clickedRecs) / float64(totalRecs) * 100
+	if impressions > 0 {
+		quickInsights.RecommendationScore = float64(clickedRecs) / float64(impressions) * 100
 	}
 
 	// Alerts and notifications
@@ -157,12 +169,13 @@ func GetDashboard(c *fiber.Ctx) error {
 		})
 	}
 
-	// Price drop alerts (mock implementation)
-	if len(recentInteractions) > 0 {
+	// Price drop alerts for products viewed recently
+	for _, drop := range viewedPriceDrops(userID, thirtyDaysAgo) {
 		alerts = append(alerts, DashboardAlert{
-			Type:    "info",
-			Title:   "Price Drop Alert",
-			Message: "Some products you viewed recently have price drops!",
+			Type:  "info",
+			Title: "Price Drop Alert",
+			Message: fmt.Sprintf("'%s' you viewed recently dropped from $%s to $%s",
+				drop.ProductName, drop.PreviousPrice, drop.CurrentPrice),
 		})
 	}
 
@@ -189,7 +202,7 @@ func GetDashboard(c *fiber.Ctx) error {
 	recommendationSummary.TopRecommendations = topRecs
 	recommendationSummary.LastUpdated = time.Now()
 
-	return c.JSON(fiber.Map{
+	return cacheAnalytics(c, cacheKey, fiber.Map{
 		"user_stats":             userStats,
 		"recent_interactions":    recentInteractions,
 		"quick_insights":         quickInsights,
@@ -207,22 +220,23 @@ type DashboardAlert struct {
 }
 
 type RecommendationSummary struct {
-	ID            uuid.UUID `json:"id"`
-	Name          string    `json:"name"`
-	Category      string    `json:"category"`
-	Price         float64   `json:"price"`
-	Score         float64   `json:"score"`
-	AlgorithmType string    `json:"algorithm_type"`
+	ID            uuid.UUID   `json:"id"`
+	Name          string      `json:"name"`
+	Category      string      `json:"category"`
+	Price         money.Cents `json:"price"`
+	Score         float64     `json:"score"`
+	AlgorithmType string      `json:"algorithm_type"`
 }
 
 // GetUserAnalytics returns detailed user analytics
 // @Summary Get user analytics
-// @Description Get detailed user analytics including interaction stats, category preferences, and spending patterns
+// @Description Get detailed user analytics including interaction stats, category preferences, and spending patterns. Daily order counts and spend come from the nightly daily_user_stats rollup, with only today computed live. Cached per user for ANALYTICS_CACHE_TTL_SECONDS (default 5 minutes), so figures can lag by up to that long; the X-Cache header reports HIT, MISS or BYPASS.
 // @Tags Analytics
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param days query int false "Number of days to analyze" default(30)
+// @Param nocache query bool false "Bypass the analytics cache"
 // @Success 200 {object} map[string]interface{} "User analytics retrieved successfully"
 // @Failure 401 {object} map[string]interface{} "User not authenticated"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
@@ -235,6 +249,11 @@ func GetUserAnalytics(c *fiber.Ctx) error {
 		})
 	}
 
+	cacheKey := analyticsCacheKey(c, userID, "user")
+	if body, found := cachedAnalytics(c, cacheKey); found {
+		return sendAnalytics(c, body, "HIT")
+	}
+
 	days, _ := strconv.Atoi(c.Query("days", "30"))
 	cutoffDate := time.Now().AddDate(0, 0, -days)
 
@@ -252,9 +271,9 @@ func GetUserAnalytics(c *fiber.Ctx) error {
 
 	// Category preferences
 	var categoryStats []struct {
-		Category string  `json:"category"`
-		Count    int64   `json:"count"`
-		Revenue  float64 `json:"revenue"`
+		Category string      `json:"category"`
+		Count    int64       `json:"count"`
+		Revenue  money.Cents `json:"revenue"`
 	}
 
 	database.DB.Table("user_interactions ui").
@@ -264,19 +283,21 @@ func GetUserAnalytics(c *fiber.Ctx) error {
 		Group("p.category").
 		Scan(&categoryStats)
 
-	// Spending over time
-	var spendingOverTime []struct {
-		Date   time.Time `json:"date"`
-		Amount float64   `json:"amount"`
+	// Spending over time, from the daily rollups plus today
+	type dailySpend struct {
+		Date   time.Time   `json:"date"`
+		Amount money.Cents `json:"amount"`
+	}
+	spendingOverTime := []dailySpend{}
+	var totalOrders int64
+	var totalSpent money.Cents
+	for _, day := range loadUserDailyActivity(userID, cutoffDate) {
+		totalOrders += day.Orders
+		totalSpent += day.Spend
+		if day.Spend > 0 {
+			spendingOverTime = append(spendingOverTime, dailySpend{Date: day.Date, Amount: day.Spend})
+		}
 	}
-
-	database.DB.Model(&models.Order{}).
-		Select("DATE(created_at) as date, SUM(total) as amount").
-		Where("user_id = ? AND created_at >= ? AND status IN ?",
-			userID, cutoffDate, []string{"delivered", "completed"}).
-		Group("DATE(created_at)").
-		Order("date").
-		Scan(&spendingOverTime)
 
 	// Enhanced analytics - Behavioral patterns
 	var behaviorAnalytics struct {
@@ -318,11 +339,6 @@ func GetUserAnalytics(c *fiber.Ctx) error {
 		Count(&behaviorAnalytics.TotalSessions)
 
 	// Conversion rate (orders / sessions)
-	var totalOrders int64
-	database.DB.Model(&models.Order{}).
-		Where("user_id = ? AND created_at >= ?", userID, cutoffDate).
-		Count(&totalOrders)
-
 	if behaviorAnalytics.TotalSessions > 0 {
 		behaviorAnalytics.ConversionRate = float64(totalOrders) / float64(behaviorAnalytics.TotalSessions) * 100
 	}
@@ -368,12 +384,16 @@ func GetUserAnalytics(c *fiber.Ctx) error {
 		Where("user_id = ?", userID).
 		Count(&recStats.TotalRecommendations)
 
+	database.DB.Model(&models.RecommendationImpression{}).
+		Where("user_id = ?", userID).
+		Count(&recStats.Impressions)
+
 	database.DB.Model(&models.RecommendationFeedback{}).
-		Where("user_id = ? AND feedback_type = ?", userID, "clicked").
+		Where("user_id = ? AND feedback_type = ? AND impression_id IS NOT NULL", userID, "clicked").
 		Count(&recStats.ClickedRecommendations)
 
-	if recStats.TotalRecommendations > 0 {
-		recStats.ClickThroughRate = float64(recStats.ClickedRecommendations) / float64(recStats.TotalRecommendations) * 100
+	if recStats.Impressions > 0 {
+		recStats.ClickThroughRate = float64(recStats.ClickedRecommendations) / float64(recStats.Impressions) * 100
 	}
 
 	productInsights.RecommendationStats = recStats
@@ -392,21 +412,18 @@ func GetUserAnalytics(c *fiber.Ctx) error {
 
 	// Financial insights
 	var financialInsights struct {
-		TotalSpent            float64 `json:"total_spent"`
-		AvgOrderValue         float64 `json:"avg_order_value"`
-		LargestOrder          float64 `json:"largest_order"`
-		MostExpensiveCategory string  `json:"most_expensive_category"`
-		SavingsOpportunity    float64 `json:"potential_savings"`
+		TotalSpent            money.Cents `json:"total_spent"`
+		AvgOrderValue         money.Cents `json:"avg_order_value"`
+		LargestOrder          money.Cents `json:"largest_order"`
+		MostExpensiveCategory string      `json:"most_expensive_category"`
+		SavingsOpportunity    money.Cents `json:"potential_savings"`
 	}
 
-	database.DB.Model(&models.Order{}).
-		Where("user_id = ? AND created_at >= ? AND status IN ?", userID, cutoffDate, []string{"delivered", "completed"}).
-		Select("COALESCE(SUM(total), 0)").
-		Scan(&financialInsights.TotalSpent)
+	financialInsights.TotalSpent = totalSpent
 
 	database.DB.Model(&models.Order{}).
 		Where("user_id = ? AND created_at >= ? AND status IN ?", userID, cutoffDate, []string{"delivered", "completed"}).
-		Select("COALESCE(AVG(total), 0)").
+		Select("COALESCE(ROUND(AVG(total)), 0)").
 		Scan(&financialInsights.AvgOrderValue)
 
 	database.DB.Model(&models.Order{}).
@@ -425,7 +442,7 @@ func GetUserAnalytics(c *fiber.Ctx) error {
 		Limit(1).
 		Scan(&financialInsights.MostExpensiveCategory)
 
-	return c.JSON(fiber.Map{
+	return cacheAnalytics(c, cacheKey, fiber.Map{
 		"interaction_stats":  interactionStats,
 		"category_stats":     categoryStats,
 		"spending_over_time": spendingOverTime,
@@ -438,19 +455,52 @@ func GetUserAnalytics(c *fiber.Ctx) error {
 	})
 }
 
+// loadUserDailyActivity returns a user's activity per day since cutoff, oldest first.
+// Finished days are read from the daily_user_stats rollup; only today is computed
+// from the raw tables.
+func loadUserDailyActivity(userID uuid.UUID, cutoff time.Time) []models.DailyUserStats {
+	today := services.StartOfDayUTC(time.Now())
+
+	var activity []models.DailyUserStats
+	database.DB.Where("user_id = ? AND date >= ? AND date < ?", userID, services.StartOfDayUTC(cutoff), today).
+		Order("date ASC").
+		Find(&activity)
+
+	live := models.DailyUserStats{UserID: userID, Date: today}
+	database.DB.Model(&models.Order{}).
+		Where("user_id = ? AND created_at >= ?", userID, today).
+		Count(&live.Orders)
+	database.DB.Model(&models.Order{}).
+		Where("user_id = ? AND created_at >= ? AND status IN ?", userID, today, []string{"delivered", "completed"}).
+		Select("COALESCE(SUM(total), 0)").
+		Scan(&live.Spend)
+	database.DB.Model(&models.UserInteraction{}).
+		Where("user_id = ? AND created_at >= ?", userID, today).
+		Count(&live.Interactions)
+	database.DB.Model(&models.SearchQuery{}).
+		Where("user_id = ? AND created_at >= ?", userID, today).
+		Count(&live.Searches)
+
+	if live.Orders > 0 || live.Interactions > 0 || live.Searches > 0 {
+		activity = append(activity, live)
+	}
+	return activity
+}
+
 // Supporting structs for enhanced analytics
 type ProductInsight struct {
-	ID               uuid.UUID `json:"id"`
-	Name             string    `json:"name"`
-	Category         string    `json:"category"`
-	Price            float64   `json:"price"`
-	InteractionCount int64     `json:"interaction_count"`
+	ID               uuid.UUID   `json:"id"`
+	Name             string      `json:"name"`
+	Category         string      `json:"category"`
+	Price            money.Cents `json:"price"`
+	InteractionCount int64       `json:"interaction_count"`
 }
 
 type RecommendationInsight struct {
 	TotalRecommendations   int64   `json:"total_recommendations"`
+	Impressions            int64   `json:"impressions"`
 	ClickedRecommendations int64   `json:"clicked_recommendations"`
-	ClickThroughRate       float64 `json:"click_through_rate"`
+	ClickThroughRate       float64 `json:"click_through_rate"` // Clicks per impression, in percent
 }
 
 type SearchPattern struct {
@@ -460,13 +510,14 @@ type SearchPattern struct {
 
 // GetProductAnalytics returns product performance analytics
 // @Summary Get product analytics
-// @Description Get product performance analytics including top selling products, most viewed, and category performance
+// @Description Get product performance analytics including top selling products, most viewed, and category performance. Cached per user for ANALYTICS_CACHE_TTL_SECONDS (default 5 minutes), so figures can lag by up to that long; the X-Cache header reports HIT, MISS or BYPASS.
 // @Tags Analytics
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param days query int false "Number of days to analyze" default(30)
 // @Param limit query int false "Number of items to return" default(20)
+// @Param nocache query bool false "Bypass the analytics cache"
 // @Success 200 {object} map[string]interface{} "Product analytics retrieved successfully"
 // @Failure 401 {object} map[string]interface{} "User not authenticated"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
@@ -479,19 +530,66 @@ func GetProductAnalytics(c *fiber.Ctx) error {
 		})
 	}
 
+	cacheKey := analyticsCacheKey(c, userID, "products")
+	if body, found := cachedAnalytics(c, cacheKey); found {
+		return sendAnalytics(c, body, "HIT")
+	}
+
 	days, _ := strconv.Atoi(c.Query("days", "30"))
 	limit, _ := strconv.Atoi(c.Query("limit", "20"))
 	cutoffDate := time.Now().AddDate(0, 0, -days)
+	report := loadProductAnalytics(cutoffDate, limit)
 
-	// Top selling products
-	var topProducts []struct {
-		ProductID   uuid.UUID `json:"product_id"`
-		ProductName string    `json:"product_name"`
-		Category    string    `json:"category"`
-		UnitsSold   int64     `json:"units_sold"`
-		Revenue     float64   `json:"revenue"`
-	}
+	return cacheAnalytics(c, cacheKey, fiber.Map{
+		"top_products":         report.TopProducts,
+		"most_viewed":          report.MostViewed,
+		"category_performance": report.CategoryPerformance,
+		"user_analytics": fiber.Map{
+			"user_id":                   userID,
+			"personal_top_categories":   getUserTopCategories(userID, cutoffDate),
+			"personal_purchase_history": getUserPurchaseStats(userID, cutoffDate),
+		},
+		"period_days":  days,
+		"generated_at": time.Now(),
+	})
+}
+
+// TopSellingProduct is a product ranked by revenue from delivered and completed orders
+type TopSellingProduct struct {
+	ProductID   uuid.UUID   `json:"product_id"`
+	ProductName string      `json:"product_name"`
+	Category    string      `json:"category"`
+	UnitsSold   int64       `json:"units_sold"`
+	Revenue     money.Cents `json:"revenue"`
+}
+
+// MostViewedProduct is a product ranked by views
+type MostViewedProduct struct {
+	ProductID   uuid.UUID `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	ViewCount   int64     `json:"view_count"`
+}
+
+// CategoryPerformance is the sales and views of one category
+type CategoryPerformance struct {
+	Category  string      `json:"category"`
+	UnitsSold int64       `json:"units_sold"`
+	Revenue   money.Cents `json:"revenue"`
+	ViewCount int64       `json:"view_count"`
+}
+
+// productAnalyticsReport holds the store-wide sections of the product analytics
+type productAnalyticsReport struct {
+	TopProducts         []TopSellingProduct
+	MostViewed          []MostViewedProduct
+	CategoryPerformance []CategoryPerformance
+}
+
+// loadProductAnalytics computes the store-wide product analytics since cutoffDate
+func loadProductAnalytics(cutoffDate time.Time, limit int) productAnalyticsReport {
+	var report productAnalyticsReport
 
+	// Top selling products
 	database.DB.Table("order_items oi").
 		Select("p.id as product_id, p.name as product_name, p.category, SUM(oi.quantity) as units_sold, SUM(oi.quantity * oi.price) as revenue").
 		Joins("JOIN products p ON oi.product_id = p.id").
@@ -500,15 +598,9 @@ func GetProductAnalytics(c *fiber.Ctx) error {
 		Group("p.id, p.name, p.category").
 		Order("revenue DESC").
 		Limit(limit).
-		Scan(&topProducts)
+		Scan(&report.TopProducts)
 
 	// Most viewed products
-	var mostViewed []struct {
-		ProductID   uuid.UUID `json:"product_id"`
-		ProductName string    `json:"product_name"`
-		ViewCount   int64     `json:"view_count"`
-	}
-
 	database.DB.Table("user_interactions ui").
 		Select("p.id as product_id, p.name as product_name, COUNT(*) as view_count").
 		Joins("JOIN products p ON ui.product_id = p.id").
@@ -516,16 +608,9 @@ func GetProductAnalytics(c *fiber.Ctx) error {
 		Group("p.id, p.name").
 		Order("view_count DESC").
 		Limit(limit).
-		Scan(&mostViewed)
+		Scan(&report.MostViewed)
 
 	// Category performance
-	var categoryPerformance []struct {
-		Category  string  `json:"category"`
-		UnitsSold int64   `json:"units_sold"`
-		Revenue   float64 `json:"revenue"`
-		ViewCount int64   `json:"view_count"`
-	}
-
 	database.DB.Table("products p").
 		Select(`p.category, 
 			COALESCE(SUM(oi.quantity), 0) as units_sold,
@@ -536,32 +621,21 @@ func GetProductAnalytics(c *fiber.Ctx) error {
 		Joins("LEFT JOIN user_interactions ui ON p.id = ui.product_id AND ui.interaction_type = ? AND ui.created_at >= ?", "view", cutoffDate).
 		Group("p.category").
 		Order("revenue DESC").
-		Scan(&categoryPerformance)
+		Scan(&report.CategoryPerformance)
 
-	return c.JSON(fiber.Map{
-		"top_products":         topProducts,
-		"most_viewed":          mostViewed,
-		"category_performance": categoryPerformance,
-		"user_analytics": fiber.Map{
-			"user_id":                   userID,
-			"personal_top_categories":   getUserTopCategories(userID, cutoffDate),
-			"personal_purchase_history": getUserPurchaseStats(userID, cutoffDate),
-		},
-		"period_days":  days,
-		"generated_at": time.Now(),
-	})
+	return report
 }
 
 // Helper function to get user's top categories
 func getUserTopCategories(userID uuid.UUID, cutoffDate time.Time) []struct {
-	Category string  `json:"category"`
-	Count    int64   `json:"interaction_count"`
-	Revenue  float64 `json:"total_spent"`
+	Category string      `json:"category"`
+	Count    int64       `json:"interaction_count"`
+	Revenue  money.Cents `json:"total_spent"`
 } {
 	var userCategories []struct {
-		Category string  `json:"category"`
-		Count    int64   `json:"interaction_count"`
-		Revenue  float64 `json:"total_spent"`
+		Category string      `json:"category"`
+		Count    int64       `json:"interaction_count"`
+		Revenue  money.Cents `json:"total_spent"`
 	}
 
 	database.DB.Table("user_interactions ui").
@@ -578,14 +652,14 @@ func getUserTopCategories(userID uuid.UUID, cutoffDate time.Time) []struct {
 
 // Helper function to get user's purchase statistics
 func getUserPurchaseStats(userID uuid.UUID, cutoffDate time.Time) struct {
-	TotalOrders   int64   `json:"total_orders"`
-	TotalSpent    float64 `json:"total_spent"`
-	AvgOrderValue float64 `json:"avg_order_value"`
+	TotalOrders   int64       `json:"total_orders"`
+	TotalSpent    money.Cents `json:"total_spent"`
+	AvgOrderValue money.Cents `json:"avg_order_value"`
 } {
 	var stats struct {
-		TotalOrders   int64   `json:"total_orders"`
-		TotalSpent    float64 `json:"total_spent"`
-		AvgOrderValue float64 `json:"avg_order_value"`
+		TotalOrders   int64       `json:"total_orders"`
+		TotalSpent    money.Cents `json:"total_spent"`
+		AvgOrderValue money.Cents `json:"avg_order_value"`
 	}
 
 	database.DB.Model(&models.Order{}).
@@ -598,7 +672,7 @@ func getUserPurchaseStats(userID uuid.UUID, cutoffDate time.Time) struct {
 		Scan(&stats.TotalSpent)
 
 	if stats.TotalOrders > 0 {
-		stats.AvgOrderValue = stats.TotalSpent / float64(stats.TotalOrders)
+		stats.AvgOrderValue = stats.TotalSpent.Div(int(stats.TotalOrders))
 	}
 
 	return stats
@@ -606,12 +680,13 @@ func getUserPurchaseStats(userID uuid.UUID, cutoffDate time.Time) struct {
 
 // GetMLTrends returns ML-powered trend analysis
 // @Summary Get ML trends
-// @Description Get ML-powered trend analysis and predictions
+// @Description Get ML-powered trend analysis and predictions. Cached per user for ANALYTICS_CACHE_TTL_SECONDS (default 5 minutes), so figures can lag by up to that long; the X-Cache header reports HIT, MISS or BYPASS.
 // @Tags Analytics
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param days query int false "Number of days to analyze" default(30)
+// @Param nocache query bool false "Bypass the analytics cache"
 // @Success 200 {object} map[string]interface{} "ML trends retrieved successfully"
 // @Failure 401 {object} map[string]interface{} "User not authenticated"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
@@ -624,6 +699,11 @@ func GetMLTrends(c *fiber.Ctx) error {
 		})
 	}
 
+	cacheKey := analyticsCacheKey(c, userID, "trends")
+	if body, found := cachedAnalytics(c, cacheKey); found {
+		return sendAnalytics(c, body, "HIT")
+	}
+
 	days, _ := strconv.Atoi(c.Query("days", "30"))
 	cutoffDate := time.Now().AddDate(0, 0, -days)
 
@@ -730,7 +810,7 @@ func GetMLTrends(c *fiber.Ctx) error {
 		userTrends.TrendingInterest = "stable"
 	}
 
-	return c.JSON(fiber.Map{
+	return cacheAnalytics(c, cacheKey, fiber.Map{
 		"trending_products": trendingProducts,
 		"category_trends":   categoryTrends,
 		"user_trends":       userTrends,
@@ -740,9 +820,41 @@ func GetMLTrends(c *fiber.Ctx) error {
 	})
 }
 
+// GetRevenueForecast returns a forward projection of daily store revenue
+// @Summary Get revenue forecast
+// @Description Project daily revenue for the next days from the daily revenue history (FORECAST_HISTORY_DAYS, default 90 days). The projection comes from the ML service when it is available (source "ml_service") and otherwise from a linear trend fitted in Go (source "linear_trend"); each day has lower and upper confidence bounds. ML forecasts are cached for the rest of the UTC day. Admin only.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param days query int false "Number of days to forecast (1-365)" default(30)
+// @Success 200 {object} services.RevenueForecast "Revenue forecast"
+// @Failure 400 {object} map[string]interface{} "Invalid number of days"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /analytics/forecast [get]
+func GetRevenueForecast(c *fiber.Ctx) error {
+	days, err := strconv.Atoi(c.Query("days", "30"))
+	if err != nil || days < 1 || days > 365 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "days must be between 1 and 365",
+		})
+	}
+
+	forecast, err := services.ForecastRevenue(days)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to forecast revenue",
+		})
+	}
+
+	return c.JSON(forecast)
+}
+
 // GetSearchAnalytics returns search analytics
 // @Summary Get search analytics
-// @Description Get search analytics and query performance metrics
+// @Description Get search analytics and query performance metrics, including the share of searches that had a result opened (click_through_rate)
 // @Tags Analytics
 // @Accept json
 // @Produce json
@@ -804,6 +916,13 @@ func GetSearchAnalytics(c *fiber.Ctx) error {
 		Limit(10).
 		Scan(&zeroResultQueries)
 
+	// Share of searches that had a result opened
+	var clickThroughRate float64
+	database.DB.Model(&models.SearchQuery{}).
+		Select("COALESCE(AVG(CASE WHEN results_clicked > 0 THEN 1.0 ELSE 0 END), 0)").
+		Where("created_at >= ?", cutoffDate).
+		Scan(&clickThroughRate)
+
 	// User's personal search stats
 	var userSearchStats struct {
 		TotalSearches   int64 `json:"total_searches"`
@@ -829,6 +948,7 @@ func GetSearchAnalytics(c *fiber.Ctx) error {
 		"top_queries":         topQueries,
 		"search_volume":       searchVolume,
 		"zero_result_queries": zeroResultQueries,
+		"click_through_rate":  clickThroughRate,
 		"user_search_stats":   userSearchStats,
 		"period_days":         days,
 		"generated_at":        time.Now(),
@@ -837,7 +957,7 @@ func GetSearchAnalytics(c *fiber.Ctx) error {
 
 // GetRecommendationMetrics returns recommendation system metrics
 // @Summary Get recommendation metrics
-// @Description Get recommendation system performance metrics
+// @Description Get recommendation system performance metrics. Click-through and conversion rates are computed against impressions (recommendations actually returned to the user), counting only clicks tied to an impression.
 // @Tags Analytics
 // @Accept json
 // @Produce json
@@ -857,6 +977,7 @@ func GetRecommendationMetrics(c *fiber.Ctx) error {
 	// User's recommendation stats
 	var userRecommendationStats struct {
 		TotalRecommendations     int64   `json:"total_recommendations"`
+		Impressions              int64   `json:"impressions"`
 		ClickedRecommendations   int64   `json:"clicked_recommendations"`
 		PurchasedRecommendations int64   `json:"purchased_recommendations"`
 		ClickThroughRate         float64 `json:"click_through_rate"`
@@ -868,9 +989,14 @@ func GetRecommendationMetrics(c *fiber.Ctx) error {
 		Where("user_id = ?", userID).
 		Count(&userRecommendationStats.TotalRecommendations)
 
-	// Clicked recommendations
+	// Recommendations actually shown to the user
+	database.DB.Model(&models.RecommendationImpression{}).
+		Where("user_id = ?", userID).
+		Count(&userRecommendationStats.Impressions)
+
+	// Clicks tied to an impression
 	database.DB.Model(&models.RecommendationFeedback{}).
-		Where("user_id = ? AND feedback_type = ?", userID, "clicked").
+		Where("user_id = ? AND feedback_type = ? AND impression_id IS NOT NULL", userID, "clicked").
 		Count(&userRecommendationStats.ClickedRecommendations)
 
 	// Purchased recommendations
@@ -879,9 +1005,9 @@ func GetRecommendationMetrics(c *fiber.Ctx) error {
 		Count(&userRecommendationStats.PurchasedRecommendations)
 
 	// Calculate rates
-	if userRecommendationStats.TotalRecommendations > 0 {
-		userRecommendationStats.ClickThroughRate = float64(userRecommendationStats.ClickedRecommendations) / float64(userRecommendationStats.TotalRecommendations) * 100
-		userRecommendationStats.ConversionRate = float64(userRecommendationStats.PurchasedRecommendations) / float64(userRecommendationStats.TotalRecommendations) * 100
+	if userRecommendationStats.Impressions > 0 {
+		userRecommendationStats.ClickThroughRate = float64(userRecommendationStats.ClickedRecommendations) / float64(userRecommendationStats.Impressions) * 100
+		userRecommendationStats.ConversionRate = float64(userRecommendationStats.PurchasedRecommendations) / float64(userRecommendationStats.Impressions) * 100
 	}
 
 	// Algorithm performance breakdown
@@ -961,7 +1087,7 @@ func ExportAnalytics(c *fiber.Ctx) error {
 	// Get user's data
 	var orders []models.Order
 	database.DB.Where("user_id = ? AND created_at >= ?", userID, cutoffDate).
-		Preload("OrderItems.Product").
+		Preload("OrderItems.Product", includeDeletedProducts).
 		Find(&orders)
 
 	var interactions []models.UserInteraction
@@ -980,33 +1106,90 @@ func ExportAnalytics(c *fiber.Ctx) error {
 	if format == "json" {
 		return c.JSON(exportData)
 	} else if format == "csv" {
-		// For CSV, we'll return a simplified format
-		c.Set("Content-Type", "text/csv")
-		c.Set("Content-Disposition", "attachment; filename=analytics_export.csv")
-
-		csvData := "Type,Date,Product,Category,Amount\n"
+		// For CSV, we'll return a simplified format. encoding/csv quotes names containing
+		// commas, quotes or newlines; names that look like formulas are escaped.
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"Type", "Date", "Product", "Category", "Amount"})
 
 		for _, order := range orders {
 			for _, item := range order.OrderItems {
-				csvData += fmt.Sprintf("Order,%s,%s,%s,%.2f\n",
+				w.Write([]string{
+					"Order",
 					order.CreatedAt.Format("2006-01-02"),
-					item.Product.Name,
-					item.Product.Category,
-					item.Price*float64(item.Quantity))
+					escapeCSVFormula(item.Product.Name),
+					escapeCSVFormula(item.Product.Category),
+					item.Price.Mul(item.Quantity).String(),
+				})
 			}
 		}
 
 		for _, interaction := range interactions {
-			csvData += fmt.Sprintf("Interaction,%s,%s,%s,0\n",
+			w.Write([]string{
+				"Interaction",
 				interaction.CreatedAt.Format("2006-01-02"),
-				interaction.Product.Name,
-				interaction.Product.Category)
+				escapeCSVFormula(interaction.Product.Name),
+				escapeCSVFormula(interaction.Product.Category),
+				"0",
+			})
 		}
 
-		return c.SendString(csvData)
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to generate export",
+			})
+		}
+
+		c.Set("Content-Type", "text/csv; charset=utf-8")
+		c.Set("Content-Disposition", "attachment; filename=analytics_export.csv")
+		return c.Send(buf.Bytes())
 	}
 
 	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 		"error": "Unsupported format. Use 'json' or 'csv'",
 	})
 }
+
+// analyticsCacheKey builds the analytics cache key of the request for an endpoint,
+// from the caller and the query parameters other than nocache
+func analyticsCacheKey(c *fiber.Ctx, userID uuid.UUID, endpoint string) string {
+	params := url.Values{}
+	for key, value := range c.Queries() {
+		if key != "nocache" {
+			params.Set(key, value)
+		}
+	}
+	return services.AnalyticsCacheKey(userID, endpoint, params.Encode(), time.Now())
+}
+
+// cachedAnalytics returns the cached response for a key unless the caller passed nocache=true
+func cachedAnalytics(c *fiber.Ctx, cacheKey string) ([]byte, bool) {
+	if c.QueryBool("nocache") {
+		return nil, false
+	}
+	return services.AnalyticsCacheInstance.Get(cacheKey)
+}
+
+// cacheAnalytics encodes an analytics response, caches it and sends it
+func cacheAnalytics(c *fiber.Ctx, cacheKey string, response fiber.Map) error {
+	body, err := json.Marshal(response)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to encode analytics",
+		})
+	}
+
+	services.AnalyticsCacheInstance.Set(cacheKey, body)
+	if c.QueryBool("nocache") {
+		return sendAnalytics(c, body, "BYPASS")
+	}
+	return sendAnalytics(c, body, "MISS")
+}
+
+// sendAnalytics writes an encoded analytics response with its cache status
+func sendAnalytics(c *fiber.Ctx, body []byte, cacheStatus string) error {
+	c.Set("X-Cache", cacheStatus)
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(body)
+}
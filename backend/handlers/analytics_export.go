@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"bachelor_backend/pkg/xlsx"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// exportSection is one table of an analytics export: a sheet in XLSX, a block in CSV
+type exportSection struct {
+	Name   string
+	Header []string
+	Rows   [][]interface{}
+}
+
+// ExportProductAnalytics exports the store-wide product analytics
+// @Summary Export product analytics
+// @Description Export top selling products, category performance and most viewed products as CSV (one block per section, separated by a blank line) or XLSX (one sheet per section). Admin only.
+// @Tags Analytics
+// @Accept json
+// @Produce text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security BearerAuth
+// @Param format query string false "Export format (csv, xlsx)" default("csv")
+// @Param days query int false "Number of days to analyze" default(30)
+// @Param limit query int false "Number of products per section" default(20)
+// @Success 200 {file} file "Product analytics export"
+// @Failure 400 {object} map[string]interface{} "Unsupported format"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /analytics/products/export [get]
+func ExportProductAnalytics(c *fiber.Ctx) error {
+	format := c.Query("format", "csv")
+	if format != "csv" && format != "xlsx" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Unsupported format. Use 'csv' or 'xlsx'",
+		})
+	}
+
+	days, _ := strconv.Atoi(c.Query("days", "30"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	sections := productAnalyticsSections(loadProductAnalytics(time.Now().AddDate(0, 0, -days), limit))
+
+	filename := fmt.Sprintf("product_analytics_%s.%s", time.Now().Format("2006-01-02"), format)
+	return sendExport(c, format, filename, sections)
+}
+
+// productAnalyticsSections lays out a product analytics report as export sections
+func productAnalyticsSections(report productAnalyticsReport) []exportSection {
+	topProducts := exportSection{
+		Name:   "Top Products",
+		Header: []string{"Product ID", "Product", "Category", "Units Sold", "Revenue"},
+	}
+	for _, p := range report.TopProducts {
+		topProducts.Rows = append(topProducts.Rows, []interface{}{p.ProductID.String(), p.ProductName, p.Category, p.UnitsSold, p.Revenue})
+	}
+
+	categories := exportSection{
+		Name:   "Category Performance",
+		Header: []string{"Category", "Units Sold", "Revenue", "Views"},
+	}
+	for _, category := range report.CategoryPerformance {
+		categories.Rows = append(categories.Rows, []interface{}{category.Category, category.UnitsSold, category.Revenue, category.ViewCount})
+	}
+
+	mostViewed := exportSection{
+		Name:   "Most Viewed",
+		Header: []string{"Product ID", "Product", "Views"},
+	}
+	for _, p := range report.MostViewed {
+		mostViewed.Rows = append(mostViewed.Rows, []interface{}{p.ProductID.String(), p.ProductName, p.ViewCount})
+	}
+
+	return []exportSection{topProducts, categories, mostViewed}
+}
+
+// sendExport encodes sections as CSV or XLSX and sends them as a file download
+func sendExport(c *fiber.Ctx, format, filename string, sections []exportSection) error {
+	content, contentType, err := encodeExport(format, sections)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate export",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	return c.Send(content)
+}
+
+// encodeExport encodes sections as CSV or XLSX, returning the file and its content type
+func encodeExport(format string, sections []exportSection) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if format == "xlsx" {
+		if err := writeXLSXSections(&buf, sections); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), xlsx.ContentType, nil
+	}
+
+	if err := writeCSVSections(&buf, sections); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "text/csv; charset=utf-8", nil
+}
+
+// writeCSVSections writes each section as a title row, a header row and its rows,
+// with a blank line between sections. Fields are quoted as needed by encoding/csv
+// and text that could be read as a formula is escaped.
+func writeCSVSections(buf *bytes.Buffer, sections []exportSection) error {
+	w := csv.NewWriter(buf)
+	for i, section := range sections {
+		if i > 0 {
+			if err := w.Write([]string{}); err != nil {
+				return err
+			}
+		}
+		if err := w.Write([]string{section.Name}); err != nil {
+			return err
+		}
+		if err := w.Write(section.Header); err != nil {
+			return err
+		}
+		for _, row := range section.Rows {
+			if err := w.Write(csvRecord(row)); err != nil {
+				return err
+			}
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// csvRecord formats a row of cells as CSV fields
+func csvRecord(row []interface{}) []string {
+	record := make([]string, len(row))
+	for i, value := range row {
+		switch v := value.(type) {
+		case string:
+			record[i] = escapeCSVFormula(v)
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'f', 2, 64)
+		case time.Time:
+			record[i] = v.Format(time.RFC3339)
+		case nil:
+			record[i] = ""
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return record
+}
+
+// escapeCSVFormula prefixes text starting with a character spreadsheets treat as the
+// start of a formula with a single quote, so exported data cannot inject formulas
+func escapeCSVFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// writeXLSXSections writes each section to its own sheet, header row first
+func writeXLSXSections(buf *bytes.Buffer, sections []exportSection) error {
+	var workbook xlsx.Workbook
+	for _, section := range sections {
+		rows := make([][]interface{}, 0, len(section.Rows)+1)
+		header := make([]interface{}, len(section.Header))
+		for i, title := range section.Header {
+			header[i] = title
+		}
+		rows = append(rows, header)
+		rows = append(rows, section.Rows...)
+		workbook.AddSheet(section.Name, rows)
+	}
+	return workbook.Write(buf)
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEscapeCSVFormula(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"empty", "", ""},
+		{"plain", "Wireless Mouse", "Wireless Mouse"},
+		{"equals", "=HYPERLINK(\"http://evil.example\",\"click\")", "'=HYPERLINK(\"http://evil.example\",\"click\")"},
+		{"plus", "+1+1", "'+1+1"},
+		{"minus", "-2+3", "'-2+3"},
+		{"at", "@SUM(A1:A2)", "'@SUM(A1:A2)"},
+		{"tab", "\t=1", "'\t=1"},
+		{"carriage return", "\r=1", "'\r=1"},
+		{"formula character inside", "USB-C cable = fast", "USB-C cable = fast"},
+		{"leading space", " =1", " =1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := escapeCSVFormula(tt.value); got != tt.want {
+				t.Errorf("escapeCSVFormula(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVRecord(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	row := []interface{}{"=cmd|' /C calc'!A0", 12.5, 3, at, nil, "Desk, \"oak\"\nlarge"}
+
+	got := csvRecord(row)
+	want := []string{"'=cmd|' /C calc'!A0", "12.50", "3", "2024-03-01T12:30:00Z", "", "Desk, \"oak\"\nlarge"}
+	if len(got) != len(want) {
+		t.Fatalf("csvRecord returned %d fields, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("field %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestWriteCSVSectionsAdversarialNames(t *testing.T) {
+	names := []string{
+		"Chair, black",
+		"The \"Best\" Lamp",
+		"Two\nline name",
+		"Comma, \"quote\" and\r\nnewline",
+		"=1+1",
+		"+SUM(A1:A9)",
+		"-10",
+		"@import",
+	}
+
+	rows := make([][]interface{}, 0, len(names))
+	for _, name := range names {
+		rows = append(rows, []interface{}{name, 9.99})
+	}
+	sections := []exportSection{{Name: "Products", Header: []string{"name", "price"}, Rows: rows}}
+
+	var buf bytes.Buffer
+	if err := writeCSVSections(&buf, sections); err != nil {
+		t.Fatalf("writeCSVSections: %v", err)
+	}
+
+	// The title row has a single field
+	reader := csv.NewReader(&buf)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("exported CSV does not parse: %v", err)
+	}
+	// Title row, header row, then one row per product
+	if len(records) != len(names)+2 {
+		t.Fatalf("got %d records, want %d", len(records), len(names)+2)
+	}
+
+	for i, name := range names {
+		record := records[i+2]
+		if len(record) != 2 {
+			t.Fatalf("row %d has %d fields, want 2: %q", i, len(record), record)
+		}
+		// encoding/csv turns \r\n inside quoted fields into \n
+		want := escapeCSVFormula(strings.ReplaceAll(name, "\r\n", "\n"))
+		if record[0] != want {
+			t.Errorf("row %d name = %q, want %q", i, record[0], want)
+		}
+		if record[1] != "9.99" {
+			t.Errorf("row %d price = %q, want 9.99", i, record[1])
+		}
+	}
+}
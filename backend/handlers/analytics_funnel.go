@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/middleware"
+	"bachelor_backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// funnelSQL counts the user-product pairs reaching each funnel stage. A later stage
+// implies the earlier ones, so a purchase without a recorded cart_add or view still
+// counts as having been carted and viewed; those gaps are reported separately.
+const funnelSQL = `
+WITH pairs AS (
+	SELECT user_id, product_id,
+		BOOL_OR(interaction_type = 'view') AS viewed,
+		BOOL_OR(interaction_type = 'cart_add') AS carted,
+		BOOL_OR(interaction_type = 'purchase') AS purchased
+	FROM user_interactions
+	WHERE created_at >= @cutoff
+		AND interaction_type IN ('view', 'cart_add', 'purchase')
+		AND (CAST(@product_id AS uuid) IS NULL OR product_id = CAST(@product_id AS uuid))
+	GROUP BY user_id, product_id
+)
+SELECT
+	COUNT(*) AS viewed,
+	COUNT(*) FILTER (WHERE carted OR purchased) AS carted,
+	COUNT(*) FILTER (WHERE purchased) AS purchased,
+	COUNT(*) FILTER (WHERE purchased AND NOT carted) AS purchased_without_cart_add,
+	COUNT(*) FILTER (WHERE (carted OR purchased) AND NOT viewed) AS without_view,
+	COUNT(DISTINCT user_id) AS users
+FROM pairs`
+
+// FunnelStage is one step of a conversion funnel
+type FunnelStage struct {
+	Stage             string  `json:"stage"`
+	Count             int64   `json:"count"`
+	ConversionRate    float64 `json:"conversion_rate"`    // Percent of the previous stage reaching this one
+	OverallConversion float64 `json:"overall_conversion"` // Percent of the first stage reaching this one
+	DropOff           int64   `json:"drop_off"`           // Pairs of the previous stage that did not reach this one
+}
+
+// ConversionFunnel is the view → cart → purchase funnel over user-product pairs
+type ConversionFunnel struct {
+	Stages                  []FunnelStage `json:"stages"`
+	Users                   int64         `json:"users"`
+	PurchasedWithoutCartAdd int64         `json:"purchased_without_cart_add"` // e.g. buy-now or interactions recorded before cart tracking
+	WithoutView             int64         `json:"without_view"`               // Carted or purchased without a recorded view
+}
+
+// loadConversionFunnel computes the funnel since cutoff, store-wide or for one product
+func loadConversionFunnel(cutoff time.Time, productID *uuid.UUID) (ConversionFunnel, error) {
+	var counts struct {
+		Viewed                  int64
+		Carted                  int64
+		Purchased               int64
+		PurchasedWithoutCartAdd int64
+		WithoutView             int64
+		Users                   int64
+	}
+
+	params := map[string]interface{}{
+		"cutoff":     cutoff,
+		"product_id": productID,
+	}
+	if err := database.DB.Raw(funnelSQL, params).Scan(&counts).Error; err != nil {
+		return ConversionFunnel{}, err
+	}
+
+	funnel := ConversionFunnel{
+		Users:                   counts.Users,
+		PurchasedWithoutCartAdd: counts.PurchasedWithoutCartAdd,
+		WithoutView:             counts.WithoutView,
+	}
+
+	stages := []struct {
+		name  string
+		count int64
+	}{
+		{"view", counts.Viewed},
+		{"cart_add", counts.Carted},
+		{"purchase", counts.Purchased},
+	}
+	for i, stage := range stages {
+		entry := FunnelStage{Stage: stage.name, Count: stage.count}
+		if i == 0 {
+			if stage.count > 0 {
+				entry.ConversionRate = 100
+				entry.OverallConversion = 100
+			}
+		} else {
+			previous := stages[i-1].count
+			entry.DropOff = previous - stage.count
+			if previous > 0 {
+				entry.ConversionRate = float64(stage.count) / float64(previous) * 100
+			}
+			if stages[0].count > 0 {
+				entry.OverallConversion = float64(stage.count) / float64(stages[0].count) * 100
+			}
+		}
+		funnel.Stages = append(funnel.Stages, entry)
+	}
+
+	return funnel, nil
+}
+
+// GetFunnelAnalytics returns view → cart → purchase conversion funnels
+// @Summary Get conversion funnel
+// @Description Get the view → cart_add → purchase conversion funnel over the period, counted as distinct user-product pairs. Each stage reports its count, the conversion from the previous stage and from the first stage, and the drop-off. A later stage implies the earlier ones, so a purchase without a recorded cart_add still counts at the cart stage; such pairs are reported in purchased_without_cart_add. The overall funnel is always returned; pass product_id to also get that product's funnel. Cached per user for ANALYTICS_CACHE_TTL_SECONDS (default 5 minutes); the X-Cache header reports HIT, MISS or BYPASS. Admin only.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param product_id query string false "Product ID (UUID) to compute a product funnel for"
+// @Param days query int false "Number of days to analyze (1-365)" default(30)
+// @Param nocache query bool false "Bypass the analytics cache"
+// @Success 200 {object} map[string]interface{} "Funnel retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid product ID or number of days"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /analytics/funnel [get]
+func GetFunnelAnalytics(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	days, err := strconv.Atoi(c.Query("days", "30"))
+	if err != nil || days < 1 || days > 365 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "days must be between 1 and 365",
+		})
+	}
+
+	var product *models.Product
+	if value := c.Query("product_id"); value != "" {
+		productID, err := uuid.Parse(value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid product ID",
+			})
+		}
+
+		product = &models.Product{}
+		if err := database.DB.Scopes(includeDeletedProducts).Where("id = ?", productID).First(product).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Product not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch product",
+			})
+		}
+	}
+
+	cacheKey := analyticsCacheKey(c, userID, "funnel")
+	if body, found := cachedAnalytics(c, cacheKey); found {
+		return sendAnalytics(c, body, "HIT")
+	}
+
+	cutoffDate := time.Now().AddDate(0, 0, -days)
+
+	overall, err := loadConversionFunnel(cutoffDate, nil)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to compute funnel",
+		})
+	}
+
+	response := fiber.Map{
+		"overall":      overall,
+		"period_days":  days,
+		"generated_at": time.Now(),
+	}
+
+	if product != nil {
+		productFunnel, err := loadConversionFunnel(cutoffDate, &product.ID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to compute funnel",
+			})
+		}
+		response["product"] = fiber.Map{
+			"product_id":   product.ID,
+			"product_name": product.Name,
+			"funnel":       productFunnel,
+		}
+	}
+
+	return cacheAnalytics(c, cacheKey, response)
+}
@@ -0,0 +1,330 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/middleware"
+	"bachelor_backend/models"
+	"bachelor_backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreateAnalyticsScheduleRequest represents the request to subscribe to a recurring analytics report
+type CreateAnalyticsScheduleRequest struct {
+	ReportType string `json:"report_type" validate:"required,oneof=user products" example:"user"`
+	Frequency  string `json:"frequency" validate:"required,oneof=weekly monthly" example:"weekly"`
+	Format     string `json:"format" validate:"required,oneof=csv xlsx" example:"csv"`
+}
+
+// GenerateAnalyticsReport builds the export a schedule subscribes to for the given period.
+// It is registered with the report scheduler, which stores the result.
+func GenerateAnalyticsReport(schedule models.AnalyticsSchedule, from, to time.Time) (*models.GeneratedReport, error) {
+	var sections []exportSection
+	switch schedule.ReportType {
+	case "user":
+		sections = userAnalyticsSections(schedule.UserID, from, to)
+	case "products":
+		sections = productAnalyticsSections(loadProductAnalytics(from, 20))
+	default:
+		return nil, fmt.Errorf("unknown report type %q", schedule.ReportType)
+	}
+
+	content, contentType, err := encodeExport(schedule.Format, sections)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.GeneratedReport{
+		Filename:    fmt.Sprintf("%s_analytics_%s.%s", schedule.ReportType, to.Format("2006-01-02"), schedule.Format),
+		ContentType: contentType,
+		Content:     content,
+	}, nil
+}
+
+// userAnalyticsSections lays out a user's activity, orders and top categories for a period as export sections
+func userAnalyticsSections(userID uuid.UUID, from, to time.Time) []exportSection {
+	activity := exportSection{
+		Name:   "Daily Activity",
+		Header: []string{"Date", "Orders", "Spend", "Interactions", "Searches"},
+	}
+	for _, day := range loadUserDailyActivity(userID, from) {
+		if day.Date.After(to) {
+			continue
+		}
+		activity.Rows = append(activity.Rows, []interface{}{day.Date.Format("2006-01-02"), day.Orders, day.Spend, day.Interactions, day.Searches})
+	}
+
+	var orders []models.Order
+	database.DB.Where("user_id = ? AND created_at >= ? AND created_at < ?", userID, from, to).
+		Preload("OrderItems.Product", includeDeletedProducts).
+		Order("created_at ASC").
+		Find(&orders)
+
+	orderLines := exportSection{
+		Name:   "Orders",
+		Header: []string{"Order ID", "Date", "Status", "Product", "Category", "Quantity", "Amount"},
+	}
+	for _, order := range orders {
+		for _, item := range order.OrderItems {
+			orderLines.Rows = append(orderLines.Rows, []interface{}{
+				order.ID.String(),
+				order.CreatedAt.Format("2006-01-02"),
+				order.Status,
+				item.Product.Name,
+				item.Product.Category,
+				item.Quantity,
+				item.Price.Mul(item.Quantity).Float64(),
+			})
+		}
+	}
+
+	categories := exportSection{
+		Name:   "Top Categories",
+		Header: []string{"Category", "Interactions", "Value Viewed"},
+	}
+	for _, category := range getUserTopCategories(userID, from) {
+		categories.Rows = append(categories.Rows, []interface{}{category.Category, category.Count, category.Revenue})
+	}
+
+	return []exportSection{activity, orderLines, categories}
+}
+
+// GetAnalyticsSchedules returns the current user's report schedules
+// @Summary Get analytics report schedules
+// @Description Get the recurring analytics reports the current user is subscribed to
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Schedules retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /analytics/schedules [get]
+func GetAnalyticsSchedules(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var schedules []models.AnalyticsSchedule
+	if err := database.DB.Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&schedules).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch schedules",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"schedules": schedules,
+	})
+}
+
+// CreateAnalyticsSchedule subscribes the current user to a recurring analytics report
+// @Summary Schedule analytics report
+// @Description Subscribe to a weekly or monthly analytics export. The "user" report covers the caller's own activity and orders; the "products" report covers store-wide product analytics and requires the admin role. Each run stores the export as a downloadable report and sends an analytics_report notification with its link. The first report is generated one period after subscribing.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateAnalyticsScheduleRequest true "Schedule details"
+// @Success 201 {object} map[string]interface{} "Schedule created successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required for product reports"
+// @Failure 409 {object} map[string]interface{} "Already subscribed to this report"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /analytics/schedules [post]
+func CreateAnalyticsSchedule(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var req CreateAnalyticsScheduleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if req.ReportType == "products" && !middleware.IsAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Admin role required for product reports",
+		})
+	}
+
+	var existing int64
+	if err := database.DB.Model(&models.AnalyticsSchedule{}).
+		Where("user_id = ? AND report_type = ? AND frequency = ? AND format = ?", userID, req.ReportType, req.Frequency, req.Format).
+		Count(&existing).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create schedule",
+		})
+	}
+	if existing > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Already subscribed to this report",
+		})
+	}
+
+	schedule := models.AnalyticsSchedule{
+		UserID:     userID,
+		ReportType: req.ReportType,
+		Frequency:  req.Frequency,
+		Format:     req.Format,
+		NextRunAt:  services.NextReportRun(req.Frequency, time.Now()),
+	}
+	if err := database.DB.Create(&schedule).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create schedule",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":  "Report scheduled successfully",
+		"schedule": schedule,
+	})
+}
+
+// DeleteAnalyticsSchedule cancels one of the current user's report schedules
+// @Summary Cancel analytics report schedule
+// @Description Cancel a recurring analytics report. Reports already generated remain available for download.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Schedule ID (UUID)"
+// @Success 200 {object} map[string]interface{} "Schedule cancelled successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid schedule ID"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 404 {object} map[string]interface{} "Schedule not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /analytics/schedules/{id} [delete]
+func DeleteAnalyticsSchedule(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	scheduleID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid schedule ID",
+		})
+	}
+
+	result := database.DB.Where("id = ? AND user_id = ?", scheduleID, userID).Delete(&models.AnalyticsSchedule{})
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to cancel schedule",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Schedule not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Schedule cancelled successfully",
+	})
+}
+
+// GetGeneratedReports returns the current user's generated reports, newest first
+// @Summary Get generated analytics reports
+// @Description Get the analytics reports generated for the current user by their schedules, newest first, with download links
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Reports retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /analytics/reports [get]
+func GetGeneratedReports(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var reports []models.GeneratedReport
+	if err := database.DB.Omit("content").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(100).
+		Find(&reports).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch reports",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"reports": reports,
+	})
+}
+
+// DownloadGeneratedReport sends a generated report file
+// @Summary Download generated analytics report
+// @Description Download one of the current user's generated analytics reports as CSV or XLSX
+// @Tags Analytics
+// @Produce text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security BearerAuth
+// @Param id path string true "Report ID (UUID)"
+// @Success 200 {file} file "Report file"
+// @Failure 400 {object} map[string]interface{} "Invalid report ID"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 404 {object} map[string]interface{} "Report not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /analytics/reports/{id}/download [get]
+func DownloadGeneratedReport(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	reportID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid report ID",
+		})
+	}
+
+	var report models.GeneratedReport
+	if err := database.DB.Where("id = ? AND user_id = ?", reportID, userID).First(&report).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Report not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch report",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, report.ContentType)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+report.Filename+`"`)
+	return c.Send(report.Content)
+}
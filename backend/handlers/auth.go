@@ -1,16 +1,27 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"os"
+	"strings"
 	"time"
 
 	"bachelor_backend/database"
 	"bachelor_backend/middleware"
 	"bachelor_backend/models"
+	"bachelor_backend/pkg/money"
+	"bachelor_backend/services"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // RegisterRequest represents the registration request payload
@@ -32,12 +43,61 @@ type UpdateProfileRequest struct {
 	Phone string `json:"phone" validate:"omitempty,max=20" example:"+1234567890"`
 }
 
+// UpdateNotificationPreferencesRequest represents the notification preferences update payload
+type UpdateNotificationPreferencesRequest struct {
+	PriceDrop       *bool   `json:"price_drop,omitempty" example:"true"`
+	BackInStock     *bool   `json:"back_in_stock,omitempty" example:"true"`
+	OrderStatus     *bool   `json:"order_status,omitempty" example:"true"`
+	DigestFrequency *string `json:"digest_frequency,omitempty" validate:"omitempty,oneof=none daily weekly" example:"weekly"`
+}
+
+// RefreshTokenRequest represents the token refresh request payload
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+// LogoutRequest represents the logout request payload
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"` // Optional: also revoke this refresh token
+}
+
+// ForgotPasswordRequest represents the forgot-password request payload
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email,max=255" example:"user@example.com"`
+}
+
+// ResetPasswordRequest represents the reset-password request payload
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	Password string `json:"password" validate:"required,min=8,max=128" example:"newpassword123"`
+}
+
+// ChangePasswordRequest represents the change-password request payload
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required,min=1,max=128" example:"password123"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,max=128" example:"newpassword123"`
+}
+
 // AuthResponse represents the authentication response
 type AuthResponse struct {
-	Token string      `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
-	User  models.User `json:"user"`
+	Token        string      `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string      `json:"refresh_token" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	ExpiresIn    int         `json:"expires_in" example:"900"` // Access token lifetime in seconds
+	User         models.User `json:"user"`
+}
+
+// RefreshResponse represents the token refresh response
+type RefreshResponse struct {
+	Token     string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	ExpiresIn int    `json:"expires_in" example:"900"` // Access token lifetime in seconds
 }
 
+// Token lifetimes; the access token lifetime is configured by middleware.AccessTokenTTL
+const (
+	refreshTokenTTL       = 7 * 24 * time.Hour
+	passwordResetTokenTTL = time.Hour
+)
+
 // StandardErrorResponse represents a standard error response
 type StandardErrorResponse struct {
 	Success bool   `json:"success" example:"false"`
@@ -53,15 +113,15 @@ type UserProfileResponse struct {
 
 // UserProfileStatistics represents user statistics
 type UserProfileStatistics struct {
-	TotalOrders             int64   `json:"total_orders"`
-	TotalSpent              float64 `json:"total_spent"`
-	AverageOrderValue       float64 `json:"average_order_value"`
-	CartItemsCount          int64   `json:"cart_items_count"`
-	CartTotalValue          float64 `json:"cart_total_value"`
-	TotalInteractions       int64   `json:"total_interactions"`
-	FavoriteCategory        string  `json:"favorite_category"`
-	RecommendationsReceived int64   `json:"recommendations_received"`
-	RecommendationsClicked  int64   `json:"recommendations_clicked"`
+	TotalOrders             int64       `json:"total_orders"`
+	TotalSpent              money.Cents `json:"total_spent"`
+	AverageOrderValue       money.Cents `json:"average_order_value"`
+	CartItemsCount          int64       `json:"cart_items_count"`
+	CartTotalValue          money.Cents `json:"cart_total_value"`
+	TotalInteractions       int64       `json:"total_interactions"`
+	FavoriteCategory        string      `json:"favorite_category"`
+	RecommendationsReceived int64       `json:"recommendations_received"`
+	RecommendationsClicked  int64       `json:"recommendations_clicked"`
 }
 
 // UserRecentActivity represents recent user activity
@@ -79,7 +139,7 @@ type UserRecentActivity struct {
 // @Accept json
 // @Produce json
 // @Param request body RegisterRequest true "Registration details"
-// @Success 201 {object} AuthResponse "User registered successfully"
+// @Success 201 {object} AuthResponse "User registered successfully (access token valid for 15 minutes, refresh token for 7 days)"
 // @Failure 400 {object} StandardErrorResponse "Invalid request body or validation error"
 // @Failure 409 {object} StandardErrorResponse "User with this email already exists"
 // @Failure 500 {object} StandardErrorResponse "Internal server error"
@@ -126,6 +186,7 @@ func Register(c *fiber.Ctx) error {
 		Email:        req.Email,
 		Name:         req.Name,
 		PasswordHash: string(hashedPassword),
+		Role:         "customer",
 	}
 
 	if err := database.DB.Create(&user).Error; err != nil {
@@ -135,7 +196,7 @@ func Register(c *fiber.Ctx) error {
 		})
 	}
 
-	// Generate JWT token
+	// Generate access and refresh tokens
 	token, err := generateJWTToken(user)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(StandardErrorResponse{
@@ -144,15 +205,25 @@ func Register(c *fiber.Ctx) error {
 		})
 	}
 
+	refreshToken, err := generateRefreshToken(user)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Failed to generate refresh token",
+		})
+	}
+
 	// Create shopping cart for the user
 	cart := models.ShoppingCart{
-		UserID: user.ID,
+		UserID: &user.ID,
 	}
 	database.DB.Create(&cart)
 
 	return c.Status(fiber.StatusCreated).JSON(AuthResponse{
-		Token: token,
-		User:  user,
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(middleware.AccessTokenTTL().Seconds()),
+		User:         user,
 	})
 }
 
@@ -204,7 +275,7 @@ func Login(c *fiber.Ctx) error {
 		})
 	}
 
-	// Generate JWT token
+	// Generate access and refresh tokens
 	token, err := generateJWTToken(user)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(StandardErrorResponse{
@@ -213,12 +284,409 @@ func Login(c *fiber.Ctx) error {
 		})
 	}
 
+	refreshToken, err := generateRefreshToken(user)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Failed to generate refresh token",
+		})
+	}
+
 	return c.JSON(AuthResponse{
-		Token: token,
-		User:  user,
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(middleware.AccessTokenTTL().Seconds()),
+		User:         user,
+	})
+}
+
+// RefreshAccessToken issues a new access token for a valid refresh token
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new short-lived access token
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} RefreshResponse "Access token refreshed"
+// @Failure 400 {object} StandardErrorResponse "Invalid request body or validation error"
+// @Failure 401 {object} StandardErrorResponse "Refresh token is invalid, revoked or expired"
+// @Failure 500 {object} StandardErrorResponse "Internal server error"
+// @Router /auth/refresh [post]
+func RefreshAccessToken(c *fiber.Ctx) error {
+	var req RefreshTokenRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Invalid request body: " + err.Error(),
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	}
+
+	var stored models.RefreshToken
+	if err := database.DB.Where("token_hash = ?", hashToken(req.RefreshToken)).
+		First(&stored).Error; err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Invalid refresh token",
+		})
+	}
+
+	if stored.Revoked {
+		return c.Status(fiber.StatusUnauthorized).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Refresh token has been revoked",
+		})
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return c.Status(fiber.StatusUnauthorized).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Refresh token has expired",
+		})
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", stored.UserID).Error; err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Invalid refresh token",
+		})
+	}
+
+	token, err := generateJWTToken(user)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Failed to generate token",
+		})
+	}
+
+	return c.JSON(RefreshResponse{
+		Token:     token,
+		ExpiresIn: int(middleware.AccessTokenTTL().Seconds()),
+	})
+}
+
+// Logout revokes the current access token
+// @Summary User logout
+// @Description Revoke the current access token so it can no longer be used. If a refresh token is supplied it is revoked as well.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body LogoutRequest false "Refresh token to revoke"
+// @Success 200 {object} map[string]interface{} "Logged out successfully"
+// @Failure 400 {object} StandardErrorResponse "Invalid request body or token cannot be revoked"
+// @Failure 401 {object} StandardErrorResponse "User not authenticated"
+// @Failure 500 {object} StandardErrorResponse "Internal server error"
+// @Router /auth/logout [post]
+func Logout(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+
+	var req LogoutRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(StandardErrorResponse{
+				Success: false,
+				Error:   "Invalid request body: " + err.Error(),
+			})
+		}
+	}
+
+	jti, expiresAt, ok := middleware.GetTokenID(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Token cannot be revoked, please sign in again",
+		})
+	}
+
+	revoked := models.RevokedToken{
+		JTI:       jti,
+		ExpiresAt: expiresAt,
+	}
+	if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&revoked).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Failed to revoke token",
+		})
+	}
+
+	if req.RefreshToken != "" {
+		if err := database.DB.Model(&models.RefreshToken{}).
+			Where("token_hash = ? AND user_id = ?", hashToken(req.RefreshToken), userID).
+			Update("revoked", true).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(StandardErrorResponse{
+				Success: false,
+				Error:   "Failed to revoke refresh token",
+			})
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Logged out successfully",
+	})
+}
+
+// ForgotPassword starts the password reset flow
+// @Summary Request password reset
+// @Description Generate a single-use password reset token valid for one hour. Always returns 200 so account existence is not revealed.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body ForgotPasswordRequest true "Account email"
+// @Success 200 {object} map[string]interface{} "Reset instructions sent if the account exists"
+// @Failure 400 {object} StandardErrorResponse "Invalid request body or validation error"
+// @Router /auth/forgot-password [post]
+func ForgotPassword(c *fiber.Ctx) error {
+	var req ForgotPasswordRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Invalid request body: " + err.Error(),
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	}
+
+	response := fiber.Map{
+		"success": true,
+		"message": "If an account with that email exists, password reset instructions have been sent",
+	}
+
+	var user models.User
+	if err := database.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
+		return c.JSON(response)
+	}
+
+	token, err := generateRandomToken()
+	if err != nil {
+		log.Printf("Failed to generate password reset token: %v", err)
+		return c.JSON(response)
+	}
+
+	// Only the most recent reset token stays usable
+	database.DB.Where("user_id = ? AND used_at IS NULL", user.ID).Delete(&models.PasswordResetToken{})
+
+	resetToken := models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(passwordResetTokenTTL),
+	}
+	if err := database.DB.Create(&resetToken).Error; err != nil {
+		log.Printf("Failed to store password reset token: %v", err)
+		return c.JSON(response)
+	}
+
+	// There is no mail delivery yet, so the token is only logged outside production
+	if os.Getenv("GO_ENV") != "production" {
+		log.Printf("Password reset token for %s: %s", user.Email, token)
+	}
+
+	return c.JSON(response)
+}
+
+// ResetPassword sets a new password using a reset token
+// @Summary Reset password
+// @Description Set a new password using a token from forgot-password. Tokens expire after one hour and can only be used once.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} map[string]interface{} "Password reset successfully"
+// @Failure 400 {object} StandardErrorResponse "Invalid request, or invalid or expired token"
+// @Failure 500 {object} StandardErrorResponse "Internal server error"
+// @Router /auth/reset-password [post]
+func ResetPassword(c *fiber.Ctx) error {
+	var req ResetPasswordRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Invalid request body: " + err.Error(),
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	}
+
+	var resetToken models.PasswordResetToken
+	if err := database.DB.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hashToken(req.Token), time.Now()).
+		First(&resetToken).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Invalid or expired reset token",
+		})
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Failed to hash password",
+		})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Mark the token used first so a concurrent request cannot reuse it
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", resetToken.ID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errResetTokenUsed
+		}
+
+		if err := tx.Model(&models.User{}).Where("id = ?", resetToken.UserID).
+			Update("password_hash", string(hashedPassword)).Error; err != nil {
+			return err
+		}
+
+		// Sign out other sessions
+		return tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked = ?", resetToken.UserID, false).
+			Update("revoked", true).Error
+	})
+	if errors.Is(err, errResetTokenUsed) {
+		return c.Status(fiber.StatusBadRequest).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Invalid or expired reset token",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Failed to reset password",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Password reset successfully",
 	})
 }
 
+// ChangePassword changes the authenticated user's password
+// @Summary Change password
+// @Description Change the password after verifying the current one. All refresh tokens are revoked so other sessions must sign in again.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} map[string]interface{} "Password changed successfully"
+// @Failure 400 {object} StandardErrorResponse "Invalid request body, validation error or new password equals the current one"
+// @Failure 401 {object} StandardErrorResponse "User not authenticated or current password is wrong"
+// @Failure 404 {object} StandardErrorResponse "User not found"
+// @Failure 500 {object} StandardErrorResponse "Internal server error"
+// @Router /auth/change-password [post]
+func ChangePassword(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+
+	var req ChangePasswordRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Invalid request body: " + err.Error(),
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "User not found",
+		})
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)); err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Current password is incorrect",
+		})
+	}
+
+	if req.NewPassword == req.CurrentPassword {
+		return c.Status(fiber.StatusBadRequest).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "New password must be different from the current password",
+		})
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Failed to hash password",
+		})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("password_hash", string(hashedPassword)).Error; err != nil {
+			return err
+		}
+
+		// Force other sessions to re-authenticate
+		return tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked = ?", user.ID, false).
+			Update("revoked", true).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Failed to change password",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Password changed successfully",
+	})
+}
+
+// errResetTokenUsed signals that a reset token was consumed by another request
+var errResetTokenUsed = errors.New("reset token already used")
+
 // GetProfile returns the current user's profile
 // @Summary Get user profile
 // @Description Get the authenticated user's comprehensive profile information including statistics and recent activity
@@ -240,7 +708,7 @@ func GetProfile(c *fiber.Ctx) error {
 	}
 
 	var user models.User
-	if err := database.DB.Preload("Orders.OrderItems.Product").
+	if err := database.DB.Preload("Orders.OrderItems.Product", includeDeletedProducts).
 		Preload("ShoppingCart.CartItems.Product").
 		Preload("UserInteractions.Product").
 		Preload("Recommendations.Product").
@@ -285,7 +753,7 @@ func calculateUserStatistics(userID uuid.UUID) (UserProfileStatistics, error) {
 	// Calculate order statistics
 	var orderStats struct {
 		TotalOrders int64
-		TotalSpent  float64
+		TotalSpent  money.Cents
 	}
 
 	if err := database.DB.Model(&models.Order{}).
@@ -299,13 +767,13 @@ func calculateUserStatistics(userID uuid.UUID) (UserProfileStatistics, error) {
 	stats.TotalSpent = orderStats.TotalSpent
 
 	if stats.TotalOrders > 0 {
-		stats.AverageOrderValue = stats.TotalSpent / float64(stats.TotalOrders)
+		stats.AverageOrderValue = stats.TotalSpent.Div(int(stats.TotalOrders))
 	}
 
 	// Calculate cart statistics
 	var cartStats struct {
 		ItemCount  int64
-		TotalValue float64
+		TotalValue money.Cents
 	}
 
 	if err := database.DB.Table("cart_items").
@@ -366,7 +834,7 @@ func getUserRecentActivity(userID uuid.UUID) (UserRecentActivity, error) {
 
 	// Get recent orders (last 5)
 	if err := database.DB.Where("user_id = ?", userID).
-		Preload("OrderItems.Product").
+		Preload("OrderItems.Product", includeDeletedProducts).
 		Order("created_at DESC").
 		Limit(5).
 		Find(&activity.RecentOrders).Error; err != nil {
@@ -404,7 +872,7 @@ func getUserRecentActivity(userID uuid.UUID) (UserRecentActivity, error) {
 
 // UpdateProfile updates the current user's profile
 // @Summary Update user profile
-// @Description Update the authenticated user's profile information
+// @Description Update the authenticated user's name and phone number (max 20 characters)
 // @Tags Authentication
 // @Accept json
 // @Produce json
@@ -456,6 +924,9 @@ func UpdateProfile(c *fiber.Ctx) error {
 	if req.Name != "" {
 		user.Name = req.Name
 	}
+	if req.Phone != "" {
+		user.Phone = strings.TrimSpace(req.Phone)
+	}
 
 	if err := database.DB.Save(&user).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(StandardErrorResponse{
@@ -467,14 +938,118 @@ func UpdateProfile(c *fiber.Ctx) error {
 	return c.JSON(user)
 }
 
+// GetNotificationPreferences returns the current user's notification preferences
+// @Summary Get notification preferences
+// @Description Get the authenticated user's notification opt-ins (price drop, back in stock, order status, digest)
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.NotificationPreference "Notification preferences retrieved successfully"
+// @Failure 401 {object} StandardErrorResponse "User not authenticated"
+// @Failure 500 {object} StandardErrorResponse "Internal server error"
+// @Router /auth/notifications [get]
+func GetNotificationPreferences(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+
+	preference, err := services.GetNotificationPreference(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Failed to load notification preferences",
+		})
+	}
+
+	return c.JSON(preference)
+}
+
+// UpdateNotificationPreferences updates the current user's notification preferences
+// @Summary Update notification preferences
+// @Description Update the authenticated user's notification opt-ins. Omitted fields keep their current value.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body UpdateNotificationPreferencesRequest true "Notification preferences"
+// @Success 200 {object} models.NotificationPreference "Notification preferences updated successfully"
+// @Failure 400 {object} StandardErrorResponse "Invalid request body or validation error"
+// @Failure 401 {object} StandardErrorResponse "User not authenticated"
+// @Failure 500 {object} StandardErrorResponse "Internal server error"
+// @Router /auth/notifications [put]
+func UpdateNotificationPreferences(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+
+	var req UpdateNotificationPreferencesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Invalid request body: " + err.Error(),
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	}
+
+	preference, err := services.GetNotificationPreference(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Failed to load notification preferences",
+		})
+	}
+
+	// Update fields if provided
+	if req.PriceDrop != nil {
+		preference.PriceDrop = *req.PriceDrop
+	}
+	if req.BackInStock != nil {
+		preference.BackInStock = *req.BackInStock
+	}
+	if req.OrderStatus != nil {
+		preference.OrderStatus = *req.OrderStatus
+	}
+	if req.DigestFrequency != nil {
+		preference.DigestFrequency = *req.DigestFrequency
+	}
+
+	if err := database.DB.Save(&preference).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(StandardErrorResponse{
+			Success: false,
+			Error:   "Failed to update notification preferences",
+		})
+	}
+
+	return c.JSON(preference)
+}
+
 // generateJWTToken generates a JWT token for the user
 func generateJWTToken(user models.User) (string, error) {
 	claims := middleware.JWTClaims{
 		UserID: user.ID,
 		Email:  user.Email,
 		Name:   user.Name,
+		Role:   user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ID:        uuid.New().String(),
+			Issuer:    middleware.JWTIssuer(),
+			Audience:  jwt.ClaimStrings{middleware.JWTAudience()},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(middleware.AccessTokenTTL())),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -485,6 +1060,41 @@ func generateJWTToken(user models.User) (string, error) {
 	return token.SignedString([]byte(secret))
 }
 
+// generateRefreshToken creates and stores a new refresh token for the user.
+// Only a hash of the token is persisted.
+func generateRefreshToken(user models.User) (string, error) {
+	token, err := generateRandomToken()
+	if err != nil {
+		return "", err
+	}
+
+	refreshToken := models.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	}
+	if err := database.DB.Create(&refreshToken).Error; err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// generateRandomToken returns a random 256-bit token encoded as hex
+func generateRandomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashToken returns the SHA-256 hex digest under which refresh and reset tokens are stored
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // getJWTSecret gets JWT secret from environment
 func getJWTSecret() string {
 	// This should match the one in middleware/auth.go
@@ -4,18 +4,28 @@ import (
 	"bachelor_backend/database"
 	"bachelor_backend/middleware"
 	"bachelor_backend/models"
+	"bachelor_backend/pkg/money"
+	"bachelor_backend/services"
 
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// maxCartItemQuantity is the most of one product (or variant) a cart line can hold
+const maxCartItemQuantity = 100
+
 // AddToCartRequest represents the request to add item to cart
 type AddToCartRequest struct {
 	ProductID string `json:"product_id" validate:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	VariantID string `json:"variant_id,omitempty" validate:"omitempty,uuid" example:"5f0c2a9e-4b7d-4c1e-9a3f-2d8e6b1c7a40"` // Required for products with variants
 	Quantity  int    `json:"quantity" validate:"required,min=1,max=100" example:"2"`
 }
 
@@ -24,32 +34,106 @@ type UpdateCartItemRequest struct {
 	Quantity int `json:"quantity" validate:"required,min=0,max=100" example:"3"`
 }
 
+// MergeGuestCartRequest represents the request to merge a guest cart into the user's cart
+type MergeGuestCartRequest struct {
+	SessionID string `json:"session_id" validate:"omitempty,max=128" example:"b3f1c2d4-5e6f-4a7b-8c9d-0e1f2a3b4c5d"` // Defaults to the X-Session-ID header
+}
+
+// ApplyCouponRequest represents the request to apply a coupon code to the cart
+type ApplyCouponRequest struct {
+	Code string `json:"code" validate:"required,min=3,max=32" example:"SPRING20"`
+}
+
+// maxSessionIDLength bounds the X-Session-ID header accepted for guest carts
+const maxSessionIDLength = 128
+
+// cartOwner identifies whose cart a request works on: a signed-in user or a guest session
+type cartOwner struct {
+	UserID    *uuid.UUID
+	SessionID string
+}
+
+// resolveCartOwner returns the signed-in user, falling back to the guest session from the X-Session-ID header
+func resolveCartOwner(c *fiber.Ctx) (cartOwner, bool) {
+	if userID, ok := middleware.GetUserID(c); ok {
+		return cartOwner{UserID: &userID}, true
+	}
+
+	sessionID := strings.TrimSpace(c.Get("X-Session-ID"))
+	if sessionID == "" || len(sessionID) > maxSessionIDLength {
+		return cartOwner{}, false
+	}
+	return cartOwner{SessionID: sessionID}, true
+}
+
+// scope restricts a query on shopping_carts, or joined with it, to the owner's cart
+func (o cartOwner) scope(db *gorm.DB) *gorm.DB {
+	if o.UserID != nil {
+		return db.Where("shopping_carts.user_id = ?", *o.UserID)
+	}
+	return db.Where("shopping_carts.user_id IS NULL AND shopping_carts.session_id = ?", o.SessionID)
+}
+
+// newCart returns an empty cart belonging to the owner
+func (o cartOwner) newCart() models.ShoppingCart {
+	if o.UserID != nil {
+		return models.ShoppingCart{UserID: o.UserID}
+	}
+	sessionID := o.SessionID
+	return models.ShoppingCart{SessionID: &sessionID}
+}
+
+// findOrCreateCart returns the owner's cart, creating an empty one if needed
+func findOrCreateCart(tx *gorm.DB, owner cartOwner) (models.ShoppingCart, error) {
+	var cart models.ShoppingCart
+	err := tx.Scopes(owner.scope).First(&cart).Error
+	if err == nil {
+		return cart, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return cart, err
+	}
+
+	// A concurrent request may create the cart first; use theirs rather than failing
+	// on the unique owner index
+	cart = owner.newCart()
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&cart)
+	if result.Error != nil || result.RowsAffected > 0 {
+		return cart, result.Error
+	}
+	cart = models.ShoppingCart{}
+	return cart, tx.Scopes(owner.scope).First(&cart).Error
+}
+
 // GetCart returns the user's shopping cart
 // @Summary Get shopping cart
-// @Description Get the current user's shopping cart with all items and total
+// @Description Get the current user's shopping cart with all items and total. Guests without a token get the cart for their X-Session-ID header.
 // @Tags Cart
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param X-Session-ID header string false "Guest session ID, used when not signed in"
 // @Success 200 {object} map[string]interface{} "Cart retrieved successfully"
-// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 401 {object} map[string]interface{} "Neither signed in nor a session ID provided"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /cart [get]
 func GetCart(c *fiber.Ctx) error {
-	userID, ok := middleware.GetUserID(c)
+	owner, ok := resolveCartOwner(c)
 	if !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "User not authenticated",
+			"error": "Sign in or provide an X-Session-ID header",
 		})
 	}
 
 	// Get or create cart
 	var cart models.ShoppingCart
-	if err := database.DB.Where("user_id = ?", userID).
+	if err := database.DB.Scopes(owner.scope).
 		Preload("CartItems.Product").
+		Preload("CartItems.Variant").
+		Preload("Coupon").
 		First(&cart).Error; err != nil {
 		// Create cart if it doesn't exist
-		cart = models.ShoppingCart{UserID: userID}
+		cart = owner.newCart()
 		if err := database.DB.Create(&cart).Error; err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to create cart",
@@ -57,39 +141,205 @@ func GetCart(c *fiber.Ctx) error {
 		}
 	}
 
-	// Calculate total
-	var total float64
+	// Separate items whose product has been removed from the catalog
+	availableItems := make([]models.CartItem, 0, len(cart.CartItems))
+	unavailableItems := make([]fiber.Map, 0)
 	for _, item := range cart.CartItems {
-		total += item.Product.Price * float64(item.Quantity)
+		if item.Product.ID == uuid.Nil {
+			unavailableItems = append(unavailableItems, fiber.Map{
+				"cart_item_id": item.ID,
+				"product_id":   item.ProductID,
+				"quantity":     item.Quantity,
+				"reason":       "Product is no longer available",
+			})
+			continue
+		}
+		availableItems = append(availableItems, item)
+	}
+	cart.CartItems = availableItems
+
+	// Calculate total at each line's variant price
+	pricedItems := withVariantPrices(cart.CartItems)
+	var total money.Cents
+	for _, item := range pricedItems {
+		total += item.Product.Price.Mul(item.Quantity)
+	}
+
+	response := fiber.Map{
+		"cart":              cart,
+		"total":             total,
+		"item_count":        len(cart.CartItems),
+		"unavailable_items": unavailableItems,
+	}
+
+	// Show what the applied coupon is currently worth; it is re-validated at checkout
+	if cart.Coupon != nil {
+		quote, reason := services.QuoteCoupon(*cart.Coupon, pricedItems, time.Now())
+		if quote != nil {
+			response["coupon"] = quote
+			response["total_after_discount"] = total - quote.Amount
+		} else {
+			response["coupon_error"] = reason
+		}
+	}
+
+	return c.JSON(response)
+}
+
+// ApplyCoupon applies a coupon code to the user's cart
+// @Summary Apply coupon to cart
+// @Description Validate a coupon code against its active dates, usage limit and minimum order amount using the current cart, and store it on the cart. The coupon is redeemed when an order is placed.
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body ApplyCouponRequest true "Coupon code"
+// @Success 200 {object} map[string]interface{} "Coupon applied successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request or coupon cannot be applied"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 404 {object} map[string]interface{} "Cart or coupon not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /cart/apply-coupon [post]
+func ApplyCoupon(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+	}
+
+	var req ApplyCouponRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	var cart models.ShoppingCart
+	if err := database.DB.Where("user_id = ?", userID).
+		Preload("CartItems.Product").
+		Preload("CartItems.Variant").
+		First(&cart).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Cart not found",
+		})
+	}
+
+	if len(cart.CartItems) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Cart is empty",
+		})
+	}
+
+	coupon, err := services.FindCouponByCode(database.DB, req.Code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"success": false,
+				"error":   "Coupon not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to look up coupon",
+		})
+	}
+
+	pricedItems := withVariantPrices(cart.CartItems)
+	quote, reason := services.QuoteCoupon(coupon, pricedItems, time.Now())
+	if quote == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Coupon cannot be applied: " + reason,
+		})
+	}
+
+	if err := database.DB.Model(&cart).Update("coupon_id", coupon.ID).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to apply coupon",
+		})
+	}
+
+	var total money.Cents
+	for _, item := range pricedItems {
+		total += item.Product.Price.Mul(item.Quantity)
+	}
+
+	return c.JSON(fiber.Map{
+		"success":              true,
+		"message":              "Coupon applied successfully",
+		"coupon":               quote,
+		"discount_amount":      quote.Amount,
+		"total":                total,
+		"total_after_discount": total - quote.Amount,
+	})
+}
+
+// RemoveCoupon removes the applied coupon from the user's cart
+// @Summary Remove coupon from cart
+// @Description Remove the coupon currently applied to the user's cart
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Coupon removed successfully"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /cart/coupon [delete]
+func RemoveCoupon(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	if err := database.DB.Model(&models.ShoppingCart{}).
+		Where("user_id = ?", userID).
+		Update("coupon_id", nil).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to remove coupon",
+		})
 	}
 
 	return c.JSON(fiber.Map{
-		"cart":       cart,
-		"total":      total,
-		"item_count": len(cart.CartItems),
+		"message": "Coupon removed successfully",
 	})
 }
 
 // AddToCart adds an item to the user's cart
 // @Summary Add item to cart
-// @Description Add a product to the user's shopping cart with specified quantity
+// @Description Add a product to the user's shopping cart with specified quantity. Products with variants require a variant_id, and each variant is a separate cart line. Guests without a token add to the cart for their X-Session-ID header.
 // @Tags Cart
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param X-Session-ID header string false "Guest session ID, used when not signed in"
 // @Param request body AddToCartRequest true "Item to add to cart"
 // @Success 200 {object} map[string]interface{} "Item added to cart successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid request or insufficient stock"
-// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 401 {object} map[string]interface{} "Neither signed in nor a session ID provided"
 // @Failure 404 {object} map[string]interface{} "Product not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /cart/add [post]
 func AddToCart(c *fiber.Ctx) error {
-	userID, ok := middleware.GetUserID(c)
+	owner, ok := resolveCartOwner(c)
 	if !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"success": false,
-			"error":   "User not authenticated",
+			"error":   "Sign in or provide an X-Session-ID header",
 		})
 	}
 
@@ -127,53 +377,23 @@ func AddToCart(c *fiber.Ctx) error {
 		})
 	}
 
+	variant, err := resolveItemVariant(database.DB, product, req.VariantID)
+	if err != nil {
+		return fiberErrorResponse(c, err)
+	}
+
 	// Check stock availability
-	if product.Stock < req.Quantity {
+	available := cartItemStock(models.CartItem{Product: product, Variant: variant})
+	if available < req.Quantity {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
-			"error":   "Insufficient stock (Available: " + strconv.Itoa(product.Stock) + ", Requested: " + strconv.Itoa(req.Quantity) + ")",
+			"error":   "Insufficient stock (Available: " + strconv.Itoa(available) + ", Requested: " + strconv.Itoa(req.Quantity) + ")",
 		})
 	}
 
 	// Get or create cart using transaction
-	var cart models.ShoppingCart
 	err = database.DB.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("user_id = ?", userID).First(&cart).Error; err != nil {
-			cart = models.ShoppingCart{UserID: userID}
-			if err := tx.Create(&cart).Error; err != nil {
-				return err
-			}
-		}
-
-		// Check if item already exists in cart
-		var existingItem models.CartItem
-		if err := tx.Where("cart_id = ? AND product_id = ?", cart.ID, productID).
-			First(&existingItem).Error; err == nil {
-			// Update quantity with stock validation
-			newQuantity := existingItem.Quantity + req.Quantity
-			if newQuantity > product.Stock {
-				return fmt.Errorf("total quantity would exceed available stock (Available: %d, Total requested: %d)", product.Stock, newQuantity)
-			}
-			if newQuantity > 100 { // Maximum quantity per item
-				return fmt.Errorf("maximum quantity per item is 100")
-			}
-
-			existingItem.Quantity = newQuantity
-			if err := tx.Save(&existingItem).Error; err != nil {
-				return err
-			}
-		} else {
-			// Create new cart item
-			cartItem := models.CartItem{
-				CartID:    cart.ID,
-				ProductID: productID,
-				Quantity:  req.Quantity,
-			}
-			if err := tx.Create(&cartItem).Error; err != nil {
-				return err
-			}
-		}
-		return nil
+		return addItemToCart(tx, owner, product, variant, req.Quantity)
 	})
 
 	if err != nil {
@@ -183,8 +403,10 @@ func AddToCart(c *fiber.Ctx) error {
 		})
 	}
 
-	// Track user interaction
-	go trackUserInteraction(userID, productID, "cart_add", c.Get("X-Session-ID"))
+	// Track user interaction; guest activity is not attributed to anyone
+	if owner.UserID != nil {
+		go trackUserInteraction(*owner.UserID, productID, "cart_add", c.Get("X-Session-ID"))
+	}
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -192,6 +414,68 @@ func AddToCart(c *fiber.Ctx) error {
 	})
 }
 
+// addItemToCart adds a quantity of a product, or of one of its variants, to the owner's
+// cart, creating the cart if needed and merging with an existing line while respecting
+// stock and the per-item limit
+func addItemToCart(tx *gorm.DB, owner cartOwner, product models.Product, variant *models.ProductVariant, quantity int) error {
+	cart, err := findOrCreateCart(tx, owner)
+	if err != nil {
+		return err
+	}
+
+	var variantID *uuid.UUID
+	if variant != nil {
+		variantID = &variant.ID
+	}
+	stock := cartItemStock(models.CartItem{Product: product, Variant: variant})
+
+	if quantity > stock {
+		return fmt.Errorf("insufficient stock (Available: %d, Requested: %d)", stock, quantity)
+	}
+
+	// Insert the line, or add to the existing one if the new total stays within stock
+	// and the per-item maximum. Doing this in one statement against the unique index
+	// keeps concurrent adds from creating duplicate lines or losing quantity.
+	var quantities []int
+	if err := tx.Raw(`
+		INSERT INTO cart_items (cart_id, product_id, variant_id, quantity, created_at, updated_at)
+		VALUES (?, ?, ?, ?, NOW(), NOW())
+		ON CONFLICT (cart_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'::uuid))
+		DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity, updated_at = NOW()
+		WHERE cart_items.quantity + EXCLUDED.quantity <= ?
+		RETURNING quantity
+	`, cart.ID, product.ID, variantID, quantity, min(stock, maxCartItemQuantity)).Scan(&quantities).Error; err != nil {
+		return err
+	}
+	if len(quantities) > 0 {
+		return nil
+	}
+
+	// The line exists and the new total is over a limit; report which one
+	var existingItem models.CartItem
+	if err := tx.Where("cart_id = ? AND product_id = ?", cart.ID, product.ID).
+		Scopes(matchVariant(variantID)).
+		First(&existingItem).Error; err != nil {
+		return err
+	}
+	newQuantity := existingItem.Quantity + quantity
+	if newQuantity > stock {
+		return fmt.Errorf("total quantity would exceed available stock (Available: %d, Total requested: %d)", stock, newQuantity)
+	}
+	return fmt.Errorf("maximum quantity per item is %d", maxCartItemQuantity)
+}
+
+// matchVariant limits cart item lookups to the line for a variant, or to the
+// variant-less line when variantID is nil
+func matchVariant(variantID *uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if variantID == nil {
+			return db.Where("variant_id IS NULL")
+		}
+		return db.Where("variant_id = ?", *variantID)
+	}
+}
+
 // UpdateCartItem updates the quantity of an item in the cart
 // @Summary Update cart item quantity
 // @Description Update the quantity of a specific item in the user's cart
@@ -199,20 +483,21 @@ func AddToCart(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param X-Session-ID header string false "Guest session ID, used when not signed in"
 // @Param id path string true "Cart Item ID (UUID)"
 // @Param request body UpdateCartItemRequest true "New quantity (0 to remove item)"
 // @Success 200 {object} map[string]interface{} "Cart item updated successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid request or insufficient stock"
-// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 401 {object} map[string]interface{} "Neither signed in nor a session ID provided"
 // @Failure 404 {object} map[string]interface{} "Cart item not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /cart/item/{id} [put]
 func UpdateCartItem(c *fiber.Ctx) error {
-	userID, ok := middleware.GetUserID(c)
+	owner, ok := resolveCartOwner(c)
 	if !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"success": false,
-			"error":   "User not authenticated",
+			"error":   "Sign in or provide an X-Session-ID header",
 		})
 	}
 
@@ -244,8 +529,10 @@ func UpdateCartItem(c *fiber.Ctx) error {
 	// Get cart item with product information
 	var cartItem models.CartItem
 	if err := database.DB.Joins("JOIN shopping_carts ON cart_items.cart_id = shopping_carts.id").
-		Where("cart_items.id = ? AND shopping_carts.user_id = ?", id, userID).
+		Scopes(owner.scope).
+		Where("cart_items.id = ?", id).
 		Preload("Product").
+		Preload("Variant").
 		First(&cartItem).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"success": false,
@@ -263,10 +550,10 @@ func UpdateCartItem(c *fiber.Ctx) error {
 		}
 	} else {
 		// Validate stock availability for new quantity
-		if req.Quantity > cartItem.Product.Stock {
+		if available := cartItemStock(cartItem); req.Quantity > available {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"success": false,
-				"error":   "Insufficient stock (Available: " + strconv.Itoa(cartItem.Product.Stock) + ", Requested: " + strconv.Itoa(req.Quantity) + ")",
+				"error":   "Insufficient stock (Available: " + strconv.Itoa(available) + ", Requested: " + strconv.Itoa(req.Quantity) + ")",
 			})
 		}
 
@@ -293,18 +580,19 @@ func UpdateCartItem(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param X-Session-ID header string false "Guest session ID, used when not signed in"
 // @Param id path string true "Cart Item ID (UUID)"
 // @Success 200 {object} map[string]interface{} "Item removed from cart successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid item ID"
-// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 401 {object} map[string]interface{} "Neither signed in nor a session ID provided"
 // @Failure 404 {object} map[string]interface{} "Cart item not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /cart/item/{id} [delete]
 func RemoveFromCart(c *fiber.Ctx) error {
-	userID, ok := middleware.GetUserID(c)
+	owner, ok := resolveCartOwner(c)
 	if !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "User not authenticated",
+			"error": "Sign in or provide an X-Session-ID header",
 		})
 	}
 
@@ -319,7 +607,8 @@ func RemoveFromCart(c *fiber.Ctx) error {
 	// Get and delete cart item
 	var cartItem models.CartItem
 	if err := database.DB.Joins("JOIN shopping_carts ON cart_items.cart_id = shopping_carts.id").
-		Where("cart_items.id = ? AND shopping_carts.user_id = ?", id, userID).
+		Scopes(owner.scope).
+		Where("cart_items.id = ?", id).
 		First(&cartItem).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Cart item not found",
@@ -344,22 +633,23 @@ func RemoveFromCart(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param X-Session-ID header string false "Guest session ID, used when not signed in"
 // @Success 200 {object} map[string]interface{} "Cart cleared successfully"
-// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 401 {object} map[string]interface{} "Neither signed in nor a session ID provided"
 // @Failure 404 {object} map[string]interface{} "Cart not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /cart/clear [delete]
 func ClearCart(c *fiber.Ctx) error {
-	userID, ok := middleware.GetUserID(c)
+	owner, ok := resolveCartOwner(c)
 	if !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "User not authenticated",
+			"error": "Sign in or provide an X-Session-ID header",
 		})
 	}
 
 	// Get cart
 	var cart models.ShoppingCart
-	if err := database.DB.Where("user_id = ?", userID).First(&cart).Error; err != nil {
+	if err := database.DB.Scopes(owner.scope).First(&cart).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Cart not found",
 		})
@@ -376,3 +666,393 @@ func ClearCart(c *fiber.Ctx) error {
 		"message": "Cart cleared successfully",
 	})
 }
+
+// MergeGuestCart merges a guest cart into the signed-in user's cart
+// @Summary Merge guest cart
+// @Description Merge the cart built as a guest into the signed-in user's cart, typically right after login. Quantities of products in both carts are summed and capped at the per-item maximum of 100 and at available stock. The guest cart is removed afterwards.
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Session-ID header string false "Guest session ID, used when not given in the body"
+// @Param request body MergeGuestCartRequest false "Guest session to merge"
+// @Success 200 {object} map[string]interface{} "Guest cart merged successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request or no session ID provided"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /cart/merge [post]
+func MergeGuestCart(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+	}
+
+	var req MergeGuestCartRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid request body",
+			})
+		}
+
+		if err := middleware.ValidateStruct(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	sessionID := strings.TrimSpace(req.SessionID)
+	if sessionID == "" {
+		sessionID = strings.TrimSpace(c.Get("X-Session-ID"))
+	}
+	if sessionID == "" || len(sessionID) > maxSessionIDLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "A guest session ID is required",
+		})
+	}
+
+	guest := cartOwner{SessionID: sessionID}
+	mergedItems := 0
+	adjustedItems := make([]fiber.Map, 0)
+	skippedItems := make([]fiber.Map, 0)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var guestCart models.ShoppingCart
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Scopes(guest.scope).
+			Preload("CartItems.Product").
+			Preload("CartItems.Variant").
+			First(&guestCart).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil // Nothing to merge
+			}
+			return err
+		}
+
+		userCart, err := findOrCreateCart(tx, cartOwner{UserID: &userID})
+		if err != nil {
+			return err
+		}
+
+		for _, guestItem := range guestCart.CartItems {
+			// Skip items whose product has been removed from the catalog
+			if guestItem.Product.ID == uuid.Nil {
+				skippedItems = append(skippedItems, fiber.Map{
+					"product_id": guestItem.ProductID,
+					"quantity":   guestItem.Quantity,
+					"reason":     "Product is no longer available",
+				})
+				continue
+			}
+
+			var existingItem models.CartItem
+			err := tx.Where("cart_id = ? AND product_id = ?", userCart.ID, guestItem.ProductID).
+				Scopes(matchVariant(guestItem.VariantID)).
+				First(&existingItem).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			exists := err == nil
+
+			// Sum both quantities, capped at the per-item maximum and available stock
+			requested := existingItem.Quantity + guestItem.Quantity
+			limit := min(maxCartItemQuantity, cartItemStock(guestItem))
+			quantity := requested
+			if quantity > limit {
+				quantity = limit
+			}
+			if quantity < existingItem.Quantity {
+				quantity = existingItem.Quantity // Never reduce what the user already had
+			}
+
+			if quantity != requested {
+				adjustedItems = append(adjustedItems, fiber.Map{
+					"product_id":         guestItem.ProductID,
+					"requested_quantity": requested,
+					"quantity":           quantity,
+					"available_stock":    cartItemStock(guestItem),
+				})
+			}
+
+			switch {
+			case exists:
+				if quantity == existingItem.Quantity {
+					continue
+				}
+				existingItem.Quantity = quantity
+				if err := tx.Save(&existingItem).Error; err != nil {
+					return err
+				}
+			case quantity > 0:
+				if err := tx.Create(&models.CartItem{
+					CartID:    userCart.ID,
+					ProductID: guestItem.ProductID,
+					VariantID: guestItem.VariantID,
+					Quantity:  quantity,
+				}).Error; err != nil {
+					return err
+				}
+			default:
+				continue // Out of stock
+			}
+			mergedItems++
+		}
+
+		if err := tx.Where("cart_id = ?", guestCart.ID).Delete(&models.CartItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&guestCart).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to merge guest cart",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":        true,
+		"message":        "Guest cart merged successfully",
+		"merged_items":   mergedItems,
+		"adjusted_items": adjustedItems,
+		"skipped_items":  skippedItems,
+	})
+}
+
+// SaveCartItemForLater moves a cart item to the user's saved-for-later list
+// @Summary Save cart item for later
+// @Description Move an item out of the cart into the saved-for-later list, keeping its quantity and variant. Saving a product that is already saved replaces the saved quantity and variant.
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Cart Item ID (UUID)"
+// @Success 200 {object} map[string]interface{} "Item saved for later successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid item ID"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 404 {object} map[string]interface{} "Cart item not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /cart/item/{id}/save-for-later [post]
+func SaveCartItemForLater(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid item ID",
+		})
+	}
+
+	var cartItem models.CartItem
+	if err := database.DB.Joins("JOIN shopping_carts ON cart_items.cart_id = shopping_carts.id").
+		Where("cart_items.id = ? AND shopping_carts.user_id = ?", id, userID).
+		First(&cartItem).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Cart item not found",
+		})
+	}
+
+	savedItem := models.SavedItem{
+		UserID:    userID,
+		ProductID: cartItem.ProductID,
+		VariantID: cartItem.VariantID,
+		Quantity:  cartItem.Quantity,
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "product_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"variant_id", "quantity"}),
+		}).Create(&savedItem).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&cartItem).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to save item for later",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Item saved for later successfully",
+	})
+}
+
+// GetSavedItems returns the user's saved-for-later list
+// @Summary Get saved items
+// @Description Get the products the user saved for later, most recent first. Products no longer in the catalog are left out.
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Saved items retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /saved-items [get]
+func GetSavedItems(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var savedItems []models.SavedItem
+	if err := database.DB.Where("user_id = ?", userID).
+		Preload("Product").
+		Preload("Variant").
+		Order("created_at DESC").
+		Find(&savedItems).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch saved items",
+		})
+	}
+
+	// Skip items whose product has been removed from the catalog
+	available := make([]models.SavedItem, 0, len(savedItems))
+	for _, item := range savedItems {
+		if item.Product.ID != uuid.Nil {
+			available = append(available, item)
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"saved_items": available,
+		"count":       len(available),
+	})
+}
+
+// MoveSavedItemToCart moves a saved product back into the cart
+// @Summary Move saved item to cart
+// @Description Move a saved-for-later product back into the cart with its saved quantity, subject to the same stock checks as adding to the cart
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param productId path string true "Product ID (UUID)"
+// @Success 200 {object} map[string]interface{} "Item moved to cart successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid product ID or insufficient stock"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 404 {object} map[string]interface{} "Saved item or product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /saved-items/{productId}/move-to-cart [post]
+func MoveSavedItemToCart(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+	}
+
+	productID, err := uuid.Parse(c.Params("productId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid product ID",
+		})
+	}
+
+	var savedItem models.SavedItem
+	if err := database.DB.Where("user_id = ? AND product_id = ?", userID, productID).
+		First(&savedItem).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Saved item not found",
+		})
+	}
+
+	var product models.Product
+	if err := database.DB.First(&product, productID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Product not found",
+		})
+	}
+
+	var rawVariantID string
+	if savedItem.VariantID != nil {
+		rawVariantID = savedItem.VariantID.String()
+	}
+	variant, err := resolveItemVariant(database.DB, product, rawVariantID)
+	if err != nil {
+		return fiberErrorResponse(c, err)
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := addItemToCart(tx, cartOwner{UserID: &userID}, product, variant, savedItem.Quantity); err != nil {
+			return err
+		}
+		return tx.Delete(&savedItem).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	go trackUserInteraction(userID, productID, "cart_add", c.Get("X-Session-ID"))
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Item moved to cart successfully",
+	})
+}
+
+// RemoveSavedItem removes a product from the saved-for-later list
+// @Summary Remove saved item
+// @Description Remove a product from the user's saved-for-later list
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param productId path string true "Product ID (UUID)"
+// @Success 200 {object} map[string]interface{} "Saved item removed successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid product ID"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 404 {object} map[string]interface{} "Saved item not found"
+// @Router /saved-items/{productId} [delete]
+func RemoveSavedItem(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	productID, err := uuid.Parse(c.Params("productId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+
+	result := database.DB.Where("user_id = ? AND product_id = ?", userID, productID).Delete(&models.SavedItem{})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Saved item not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Saved item removed successfully",
+	})
+}
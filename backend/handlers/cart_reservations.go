@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/middleware"
+	"bachelor_backend/models"
+	"bachelor_backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errReservationUnavailable aborts a reservation when some cart quantities cannot be held
+var errReservationUnavailable = errors.New("not enough stock to reserve the cart")
+
+// releaseReservation deletes a reservation and returns its quantity to the product's available stock
+func releaseReservation(tx *gorm.DB, reservation models.StockReservation) error {
+	if err := tx.Model(&models.Product{}).Where("id = ?", reservation.ProductID).
+		UpdateColumn("reserved", gorm.Expr("GREATEST(reserved - ?, 0)", reservation.Quantity)).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&reservation).Error
+}
+
+// ReserveCart holds the current cart quantities for checkout
+// @Summary Reserve cart stock
+// @Description Reserve the quantities currently in the cart so they cannot be sold to anyone else while the user checks out. Reservations expire after a short window (STOCK_RESERVATION_MINUTES, 10 minutes by default) and calling this again renews them. Either every item is reserved or none is; reservations for products no longer in the cart are released. Placing an order consumes the reservations.
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Cart reserved successfully"
+// @Failure 400 {object} map[string]interface{} "Cart is empty"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 404 {object} map[string]interface{} "Cart not found"
+// @Failure 409 {object} map[string]interface{} "Not enough stock to reserve some items"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /cart/reserve [post]
+func ReserveCart(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+	}
+
+	expiresAt := time.Now().Add(services.ReservationDuration())
+	reservations := make([]models.StockReservation, 0)
+	unavailableItems := make([]fiber.Map, 0)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var cart models.ShoppingCart
+		if err := tx.Where("user_id = ?", userID).Preload("CartItems").First(&cart).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, "Cart not found")
+			}
+			return err
+		}
+
+		if len(cart.CartItems) == 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "Cart is empty")
+		}
+
+		// Lock the user's reservations before the products, the same order checkout
+		// and the expiry sweep use
+		var existing []models.StockReservation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", userID).
+			Find(&existing).Error; err != nil {
+			return err
+		}
+		held := make(map[uuid.UUID]models.StockReservation, len(existing))
+		for _, reservation := range existing {
+			held[reservation.ProductID] = reservation
+		}
+
+		// A product can be in the cart once per variant; the reservation covers all of
+		// its lines, while each variant's own stock is checked per line
+		quantities := make(map[uuid.UUID]int, len(cart.CartItems))
+		productIDs := make([]uuid.UUID, 0, len(cart.CartItems))
+		for _, item := range cart.CartItems {
+			if _, seen := quantities[item.ProductID]; !seen {
+				productIDs = append(productIDs, item.ProductID)
+			}
+			quantities[item.ProductID] += item.Quantity
+
+			if item.VariantID == nil {
+				continue
+			}
+			var variant models.ProductVariant
+			if err := tx.Where("id = ? AND product_id = ?", *item.VariantID, item.ProductID).
+				First(&variant).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					unavailableItems = append(unavailableItems, fiber.Map{
+						"product_id": item.ProductID,
+						"variant_id": item.VariantID,
+						"requested":  item.Quantity,
+						"available":  0,
+						"reason":     "Product variant is no longer available",
+					})
+					continue
+				}
+				return err
+			}
+			if item.Quantity > variant.Stock {
+				unavailableItems = append(unavailableItems, fiber.Map{
+					"product_id":  item.ProductID,
+					"variant_id":  item.VariantID,
+					"variant_sku": variant.SKU,
+					"requested":   item.Quantity,
+					"available":   max(variant.Stock, 0),
+					"reason":      "Insufficient stock",
+				})
+			}
+		}
+
+		for _, productID := range productIDs {
+			quantity := quantities[productID]
+
+			var product models.Product
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				First(&product, "id = ?", productID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					unavailableItems = append(unavailableItems, fiber.Map{
+						"product_id": productID,
+						"requested":  quantity,
+						"available":  0,
+						"reason":     "Product is no longer available",
+					})
+					continue
+				}
+				return err
+			}
+
+			// Units this user already holds count as available to them
+			reservation := held[productID]
+			available := product.Stock - product.Reserved + reservation.Quantity
+			if quantity > available {
+				unavailableItems = append(unavailableItems, fiber.Map{
+					"product_id":   productID,
+					"product_name": product.Name,
+					"requested":    quantity,
+					"available":    max(available, 0),
+					"reason":       "Insufficient stock",
+				})
+				continue
+			}
+
+			if delta := quantity - reservation.Quantity; delta != 0 {
+				if err := tx.Model(&product).
+					UpdateColumn("reserved", gorm.Expr("reserved + ?", delta)).Error; err != nil {
+					return err
+				}
+			}
+
+			reservation.UserID = userID
+			reservation.ProductID = productID
+			reservation.Quantity = quantity
+			reservation.ExpiresAt = expiresAt
+			if err := tx.Save(&reservation).Error; err != nil {
+				return err
+			}
+			reservations = append(reservations, reservation)
+		}
+
+		if len(unavailableItems) > 0 {
+			return errReservationUnavailable
+		}
+
+		// Stop holding products that have left the cart
+		for productID, reservation := range held {
+			if _, inCart := quantities[productID]; !inCart {
+				if err := releaseReservation(tx, reservation); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, errReservationUnavailable) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"success":           false,
+				"error":             "Not enough stock to reserve some items in the cart",
+				"unavailable_items": unavailableItems,
+			})
+		}
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			return c.Status(fiberErr.Code).JSON(fiber.Map{
+				"success": false,
+				"error":   fiberErr.Message,
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to reserve cart",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":      true,
+		"message":      "Cart reserved successfully",
+		"reservations": reservations,
+		"expires_at":   expiresAt,
+	})
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"testing"
+
+	"bachelor_backend/database/dbtest"
+	"bachelor_backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// createVariant adds a variant to the product; it is deleted with the product
+func createVariant(t *testing.T, db *gorm.DB, product models.Product, size string, stock int) models.ProductVariant {
+	t.Helper()
+	variant := models.ProductVariant{
+		ProductID:  product.ID,
+		SKU:        "TEST-" + uuid.NewString(),
+		Attributes: map[string]string{"size": size},
+		Stock:      stock,
+	}
+	if err := db.Create(&variant).Error; err != nil {
+		t.Fatalf("failed to create variant: %v", err)
+	}
+	return variant
+}
+
+// cartWithVariants creates a user whose cart holds the given quantity of each variant
+func cartWithVariants(t *testing.T, db *gorm.DB, product models.Product, quantities map[*models.ProductVariant]int) models.User {
+	t.Helper()
+	user := dbtest.CreateUser(t, db)
+	for variant, quantity := range quantities {
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return addItemToCart(tx, cartOwner{UserID: &user.ID}, product, variant, quantity)
+		}); err != nil {
+			t.Fatalf("addItemToCart: %v", err)
+		}
+	}
+	return user
+}
+
+func TestReserveCartTwoVariantsOfOneProduct(t *testing.T) {
+	db := dbtest.Open(t)
+	product := dbtest.CreateProduct(t, db, 1500, 10)
+	small := createVariant(t, db, product, "S", 5)
+	large := createVariant(t, db, product, "L", 5)
+	user := cartWithVariants(t, db, product, map[*models.ProductVariant]int{&small: 2, &large: 3})
+
+	// Reserving again renews the reservation without changing what is held
+	for attempt := 1; attempt <= 2; attempt++ {
+		var response map[string]interface{}
+		if status := callAsUser(t, fiber.MethodPost, ReserveCart, user.ID, &response); status != fiber.StatusOK {
+			t.Fatalf("attempt %d: status = %d, want 200: %v", attempt, status, response)
+		}
+
+		var reservations []models.StockReservation
+		if err := db.Where("user_id = ?", user.ID).Find(&reservations).Error; err != nil {
+			t.Fatalf("failed to load reservations: %v", err)
+		}
+		if len(reservations) != 1 || reservations[0].ProductID != product.ID || reservations[0].Quantity != 5 {
+			t.Fatalf("attempt %d: reservations = %+v, want one of 5 units", attempt, reservations)
+		}
+
+		var reserved models.Product
+		if err := db.First(&reserved, "id = ?", product.ID).Error; err != nil {
+			t.Fatalf("failed to load product: %v", err)
+		}
+		if reserved.Reserved != 5 {
+			t.Errorf("attempt %d: product reserved = %d, want 5", attempt, reserved.Reserved)
+		}
+	}
+}
+
+func TestReserveCartChecksVariantStock(t *testing.T) {
+	db := dbtest.Open(t)
+	product := dbtest.CreateProduct(t, db, 1500, 10)
+	small := createVariant(t, db, product, "S", 5)
+	large := createVariant(t, db, product, "L", 5)
+	user := cartWithVariants(t, db, product, map[*models.ProductVariant]int{&small: 2, &large: 4})
+
+	// The large size sells out elsewhere after it was added to the cart
+	if err := db.Model(&large).Update("stock", 1).Error; err != nil {
+		t.Fatalf("failed to update variant stock: %v", err)
+	}
+
+	var response struct {
+		UnavailableItems []struct {
+			VariantID uuid.UUID `json:"variant_id"`
+			Available int       `json:"available"`
+		} `json:"unavailable_items"`
+	}
+	if status := callAsUser(t, fiber.MethodPost, ReserveCart, user.ID, &response); status != fiber.StatusConflict {
+		t.Fatalf("status = %d, want 409", status)
+	}
+	if len(response.UnavailableItems) != 1 || response.UnavailableItems[0].VariantID != large.ID ||
+		response.UnavailableItems[0].Available != 1 {
+		t.Errorf("unavailable items = %+v, want the large variant with 1 available", response.UnavailableItems)
+	}
+
+	var count int64
+	if err := db.Model(&models.StockReservation{}).Where("user_id = ?", user.ID).Count(&count).Error; err != nil {
+		t.Fatalf("failed to count reservations: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("%d reservations kept, want none", count)
+	}
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/models"
+	"bachelor_backend/pkg/money"
+	"bachelor_backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CartTotalsLine is the price breakdown of a single cart item
+type CartTotalsLine struct {
+	CartItemID     uuid.UUID   `json:"cart_item_id"`
+	ProductID      uuid.UUID   `json:"product_id"`
+	VariantID      *uuid.UUID  `json:"variant_id,omitempty"`
+	ProductName    string      `json:"product_name"`
+	Quantity       int         `json:"quantity"`
+	UnitPrice      money.Cents `json:"unit_price"`
+	Subtotal       money.Cents `json:"subtotal"`
+	Discount       money.Cents `json:"discount"`        // Automatic discounts for the line under the stacking policy
+	CouponDiscount money.Cents `json:"coupon_discount"` // Line's share of the coupon
+	Tax            money.Cents `json:"tax"`
+	Total          money.Cents `json:"total"`
+
+	AppliedDiscounts []services.AppliedDiscount `json:"applied_discounts,omitempty"`
+	SkippedDiscounts []services.SkippedDiscount `json:"skipped_discounts,omitempty"`
+}
+
+// CartTotals is the price breakdown of a set of cart items as shown at checkout
+type CartTotals struct {
+	Lines          []CartTotalsLine      `json:"lines"`
+	ItemCount      int                   `json:"item_count"` // Total number of units
+	Subtotal       money.Cents           `json:"subtotal"`
+	DiscountPolicy string                `json:"discount_policy"` // How overlapping automatic discounts are combined
+	DiscountTotal  money.Cents           `json:"discount_total"`  // Automatic discounts and the coupon
+	Coupon         *services.CouponQuote `json:"coupon,omitempty"`
+	CouponError    string                `json:"coupon_error,omitempty"`
+	CouponDiscount money.Cents           `json:"coupon_discount"`
+	TaxRate        float64               `json:"tax_rate"`
+	Tax            money.Cents           `json:"tax"`
+	Shipping       money.Cents           `json:"shipping"`
+	Total          money.Cents           `json:"total"`
+
+	// Automatic discounts applied to at least one line, redeemed when an order is placed
+	AppliedDiscountIDs []uuid.UUID `json:"-"`
+}
+
+// computeCartTotals prices cart items the way checkout does: automatic discounts
+// per line combined under the stacking policy, the coupon spread across lines in proportion to their
+// subtotals, tax per discounted line and a shipping estimate. Items must have
+// their Product and, for variant lines, their Variant loaded. An inapplicable
+// coupon is reported in CouponError.
+func computeCartTotals(db *gorm.DB, items []models.CartItem, coupon *models.Discount, at time.Time) (CartTotals, error) {
+	items = withVariantPrices(items)
+	rates := services.LoadCheckoutRates()
+	totals := CartTotals{
+		Lines:          make([]CartTotalsLine, 0, len(items)),
+		DiscountPolicy: services.LoadDiscountStacking().Policy,
+		TaxRate:        rates.TaxRate,
+	}
+
+	for _, item := range items {
+		line := CartTotalsLine{
+			CartItemID:  item.ID,
+			ProductID:   item.ProductID,
+			VariantID:   item.VariantID,
+			ProductName: item.Product.Name,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.Product.Price,
+			Subtotal:    item.Product.Price.Mul(item.Quantity),
+		}
+		totals.Subtotal += line.Subtotal
+		totals.ItemCount += item.Quantity
+		totals.Lines = append(totals.Lines, line)
+	}
+
+	// Minimum order amounts are checked against the subtotal of all items
+	var automaticDiscount money.Cents
+	for i, item := range items {
+		quote, err := services.QuoteOrderLine(db, item.Product, item.Quantity, totals.Subtotal)
+		if err != nil {
+			return totals, err
+		}
+		totals.Lines[i].Discount = quote.DiscountTotal
+		totals.Lines[i].AppliedDiscounts = quote.AppliedDiscounts
+		totals.Lines[i].SkippedDiscounts = quote.SkippedDiscounts
+		automaticDiscount += quote.DiscountTotal
+		for _, applied := range quote.AppliedDiscounts {
+			if !slices.Contains(totals.AppliedDiscountIDs, applied.DiscountID) {
+				totals.AppliedDiscountIDs = append(totals.AppliedDiscountIDs, applied.DiscountID)
+			}
+		}
+	}
+
+	if coupon != nil {
+		quote, reason := services.QuoteCoupon(*coupon, items, at)
+		if quote != nil {
+			totals.Coupon = quote
+			totals.CouponDiscount = quote.Amount
+		} else {
+			totals.CouponError = reason
+		}
+	}
+
+	// Spread the coupon over the lines; the last line absorbs rounding
+	remaining := totals.CouponDiscount
+	for i := range totals.Lines {
+		line := &totals.Lines[i]
+		if i == len(totals.Lines)-1 {
+			line.CouponDiscount = remaining
+		} else if totals.Subtotal > 0 {
+			line.CouponDiscount = totals.CouponDiscount.MulRate(float64(line.Subtotal) / float64(totals.Subtotal))
+		}
+		line.CouponDiscount = max(0, money.Min(line.CouponDiscount, line.Subtotal-line.Discount))
+		remaining -= line.CouponDiscount
+
+		net := line.Subtotal - line.Discount - line.CouponDiscount
+		line.Tax = rates.LineTax(net)
+		line.Total = net + line.Tax
+		totals.Tax += line.Tax
+	}
+
+	totals.DiscountTotal = money.Min(automaticDiscount+totals.CouponDiscount, totals.Subtotal)
+	merchandiseTotal := totals.Subtotal - totals.DiscountTotal
+	totals.Shipping = rates.EstimateShipping(totals.ItemCount, merchandiseTotal)
+	totals.Total = merchandiseTotal + totals.Tax + totals.Shipping
+
+	return totals, nil
+}
+
+// GetCartSummary returns the checkout price breakdown of the cart
+// @Summary Get cart summary
+// @Description Get the cart's subtotal, automatic and coupon discounts, estimated tax (applied per line), estimated shipping and the final total, as charged at checkout. Guests without a token get the summary for their X-Session-ID header.
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Session-ID header string false "Guest session ID, used when not signed in"
+// @Success 200 {object} CartTotals "Cart summary retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Neither signed in nor a session ID provided"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /cart/summary [get]
+func GetCartSummary(c *fiber.Ctx) error {
+	owner, ok := resolveCartOwner(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Sign in or provide an X-Session-ID header",
+		})
+	}
+
+	var cart models.ShoppingCart
+	if err := database.DB.Scopes(owner.scope).
+		Preload("CartItems.Product").
+		Preload("CartItems.Variant").
+		Preload("Coupon").
+		First(&cart).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch cart",
+		})
+	}
+
+	// Items whose product has been removed from the catalog cannot be ordered
+	availableItems := make([]models.CartItem, 0, len(cart.CartItems))
+	for _, item := range cart.CartItems {
+		if item.Product.ID != uuid.Nil {
+			availableItems = append(availableItems, item)
+		}
+	}
+
+	totals, err := computeCartTotals(database.DB, availableItems, cart.Coupon, time.Now())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to calculate cart totals",
+		})
+	}
+
+	return c.JSON(totals)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"bachelor_backend/database/dbtest"
+	"bachelor_backend/models"
+	"bachelor_backend/pkg/money"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// callAsUser calls the handler as the signed-in user and decodes the JSON response
+func callAsUser(t *testing.T, method string, handler fiber.Handler, userID uuid.UUID, response interface{}) int {
+	t.Helper()
+
+	app := fiber.New()
+	app.Add(method, "/", func(c *fiber.Ctx) error {
+		c.Locals("user_id", userID)
+		return c.Next()
+	}, handler)
+
+	resp, err := app.Test(httptest.NewRequest(method, "/", nil), -1)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return resp.StatusCode
+}
+
+func TestAddItemToCartConcurrent(t *testing.T) {
+	db := dbtest.Open(t)
+	product := dbtest.CreateProduct(t, db, 1999, 1000)
+	user := dbtest.CreateUser(t, db)
+	owner := cartOwner{UserID: &user.ID}
+
+	// The user has no cart yet, so the adds also race to create it
+	const adds = 50
+	start := make(chan struct{})
+	errs := make(chan error, adds)
+	var wg sync.WaitGroup
+	for i := 0; i < adds; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs <- db.Transaction(func(tx *gorm.DB) error {
+				return addItemToCart(tx, owner, product, nil, 1)
+			})
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("addItemToCart: %v", err)
+		}
+	}
+
+	var carts []models.ShoppingCart
+	if err := db.Preload("CartItems").Where("user_id = ?", user.ID).Find(&carts).Error; err != nil {
+		t.Fatalf("failed to load carts: %v", err)
+	}
+	if len(carts) != 1 {
+		t.Fatalf("user has %d carts, want 1", len(carts))
+	}
+	items := carts[0].CartItems
+	if len(items) != 1 {
+		t.Fatalf("cart has %d lines, want 1", len(items))
+	}
+	if items[0].Quantity != adds {
+		t.Errorf("cart line quantity = %d, want %d", items[0].Quantity, adds)
+	}
+}
+
+func TestGetCartSoftDeletedProduct(t *testing.T) {
+	db := dbtest.Open(t)
+	removed := dbtest.CreateProduct(t, db, 1000, 5)
+	kept := dbtest.CreateProduct(t, db, 2000, 5)
+	user := dbtest.CreateUser(t, db)
+	owner := cartOwner{UserID: &user.ID}
+
+	for _, product := range []models.Product{removed, kept} {
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return addItemToCart(tx, owner, product, nil, 2)
+		}); err != nil {
+			t.Fatalf("addItemToCart: %v", err)
+		}
+	}
+	if err := db.Delete(&models.Product{}, "id = ?", removed.ID).Error; err != nil {
+		t.Fatalf("failed to soft-delete product: %v", err)
+	}
+
+	var response struct {
+		Cart             models.ShoppingCart `json:"cart"`
+		Total            money.Cents         `json:"total"`
+		ItemCount        int                 `json:"item_count"`
+		UnavailableItems []struct {
+			ProductID uuid.UUID `json:"product_id"`
+			Quantity  int       `json:"quantity"`
+		} `json:"unavailable_items"`
+	}
+	if status := callAsUser(t, fiber.MethodGet, GetCart, user.ID, &response); status != fiber.StatusOK {
+		t.Fatalf("status = %d, want 200", status)
+	}
+
+	if response.ItemCount != 1 || len(response.Cart.CartItems) != 1 || response.Cart.CartItems[0].ProductID != kept.ID {
+		t.Errorf("cart items = %+v, want only the available product", response.Cart.CartItems)
+	}
+	if want := kept.Price.Mul(2); response.Total != want {
+		t.Errorf("total = %s, want %s", response.Total, want)
+	}
+	if len(response.UnavailableItems) != 1 || response.UnavailableItems[0].ProductID != removed.ID ||
+		response.UnavailableItems[0].Quantity != 2 {
+		t.Errorf("unavailable items = %+v, want the soft-deleted product", response.UnavailableItems)
+	}
+}
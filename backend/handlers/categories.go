@@ -0,0 +1,400 @@
+package handlers
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"bachelor_backend/cache"
+	"bachelor_backend/database"
+	"bachelor_backend/middleware"
+	"bachelor_backend/models"
+	"bachelor_backend/pkg/pagination"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreateCategoryRequest represents the request to create a category
+type CreateCategoryRequest struct {
+	Name     string `json:"name" validate:"required,min=1,max=100" example:"Smartphones"`
+	ParentID string `json:"parent_id,omitempty" validate:"omitempty,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+}
+
+// UpdateCategoryRequest represents the request to rename or move a category
+type UpdateCategoryRequest struct {
+	Name     string  `json:"name" validate:"omitempty,min=1,max=100" example:"Mobile Phones"`
+	ParentID *string `json:"parent_id,omitempty" validate:"omitempty,max=36" example:"123e4567-e89b-12d3-a456-426614174000"` // Empty string moves the category to the top level
+}
+
+// CategoryNode is a category with its subcategories, as returned by the category tree
+type CategoryNode struct {
+	ID           uuid.UUID       `json:"id"`
+	Name         string          `json:"name"`
+	Slug         string          `json:"slug"`
+	ParentID     *uuid.UUID      `json:"parent_id"`
+	ProductCount int64           `json:"product_count"` // Products directly in this category
+	Children     []*CategoryNode `json:"children"`
+}
+
+var slugInvalidChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// categoryTree is the GetCategoryTree response, cached as a whole
+type categoryTree struct {
+	Categories []*CategoryNode `json:"categories"`
+	Total      int             `json:"total"`
+}
+
+// slugify turns a name into a lowercase, dash-separated URL-safe slug
+func slugify(name string) string {
+	return strings.Trim(slugInvalidChars.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), "-"), "-")
+}
+
+// findOrCreateCategory returns the category a free-text category name belongs to,
+// creating a top-level category when none has the same slug
+func findOrCreateCategory(tx *gorm.DB, name string) (models.Category, error) {
+	category := models.Category{
+		Name: strings.TrimSpace(name),
+		Slug: slugify(name),
+	}
+	if category.Slug == "" {
+		return category, fiber.NewError(fiber.StatusBadRequest, "Category name must contain letters or digits")
+	}
+
+	err := tx.Where("slug = ?", category.Slug).FirstOrCreate(&category).Error
+	return category, err
+}
+
+// resolveProductCategory picks the category for a product from an explicit category ID,
+// falling back to the free-text category name
+func resolveProductCategory(tx *gorm.DB, categoryID, name string) (models.Category, error) {
+	if categoryID == "" {
+		return findOrCreateCategory(tx, name)
+	}
+
+	var category models.Category
+	if err := tx.First(&category, "id = ?", categoryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return category, fiber.NewError(fiber.StatusBadRequest, "Category not found")
+		}
+		return category, err
+	}
+	return category, nil
+}
+
+// categoryDescendantIDs returns the IDs of a category and all categories below it
+func categoryDescendantIDs(db *gorm.DB, categoryID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := db.Raw(`
+		WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE id = ?
+			UNION
+			SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+		)
+		SELECT id FROM tree
+	`, categoryID).Scan(&ids).Error
+	return ids, err
+}
+
+// GetCategoryTree returns all categories arranged as a tree
+// @Summary Get category tree
+// @Description Get the product categories as a tree of top-level categories and their subcategories, sorted by name, with the number of products directly in each
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Category tree retrieved successfully"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /categories [get]
+func GetCategoryTree(c *fiber.Ctx) error {
+	var cached categoryTree
+	if cache.Get(cache.KeyCategoryTree, &cached) {
+		return c.JSON(cached)
+	}
+
+	var categories []models.Category
+	if err := database.DB.Order("name ASC").Find(&categories).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch categories",
+		})
+	}
+
+	var counts []struct {
+		CategoryID uuid.UUID
+		Count      int64
+	}
+	if err := database.DB.Model(&models.Product{}).
+		Select("category_id, COUNT(*) AS count").
+		Where("category_id IS NOT NULL").
+		Group("category_id").
+		Scan(&counts).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to count products",
+		})
+	}
+	productCounts := make(map[uuid.UUID]int64, len(counts))
+	for _, row := range counts {
+		productCounts[row.CategoryID] = row.Count
+	}
+
+	nodes := make(map[uuid.UUID]*CategoryNode, len(categories))
+	for _, category := range categories {
+		nodes[category.ID] = &CategoryNode{
+			ID:           category.ID,
+			Name:         category.Name,
+			Slug:         category.Slug,
+			ParentID:     category.ParentID,
+			ProductCount: productCounts[category.ID],
+			Children:     []*CategoryNode{},
+		}
+	}
+
+	// Categories are sorted by name, so children end up sorted too
+	roots := make([]*CategoryNode, 0)
+	for _, category := range categories {
+		node := nodes[category.ID]
+		if category.ParentID != nil {
+			if parent, ok := nodes[*category.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	tree := categoryTree{
+		Categories: roots,
+		Total:      len(categories),
+	}
+	cache.Set(cache.KeyCategoryTree, tree, cache.TTL())
+
+	return c.JSON(tree)
+}
+
+// GetCategoryProducts returns the products in a category and its subcategories
+// @Summary Get category products
+// @Description Get a paginated list of products in a category, including the products of all its subcategories
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param slug path string true "Category slug"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{} "Products retrieved successfully"
+// @Failure 404 {object} map[string]interface{} "Category not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /categories/{slug}/products [get]
+func GetCategoryProducts(c *fiber.Ctx) error {
+	var category models.Category
+	if err := database.DB.Where("slug = ?", strings.ToLower(c.Params("slug"))).First(&category).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Category not found",
+		})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	categoryIDs, err := categoryDescendantIDs(database.DB, category.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch subcategories",
+		})
+	}
+
+	query := database.DB.Where("category_id IN ?", categoryIDs).Order("name ASC")
+	products, meta, err := pagination.Paginate[models.Product](query, page, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch products",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"category":     category,
+		"category_ids": categoryIDs,
+		"products":     products,
+		"pagination":   meta,
+	})
+}
+
+// CreateCategory creates a category (admin only)
+// @Summary Create category
+// @Description Create a category, optionally below a parent category (admin access required). The slug is derived from the name and must be unique.
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateCategoryRequest true "Category details"
+// @Success 201 {object} models.Category "Category created successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request or parent not found"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 409 {object} map[string]interface{} "Category with this slug already exists"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /categories [post]
+func CreateCategory(c *fiber.Ctx) error {
+	var req CreateCategoryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	category := models.Category{
+		Name: strings.TrimSpace(req.Name),
+		Slug: slugify(req.Name),
+	}
+	if category.Slug == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Category name must contain letters or digits",
+		})
+	}
+
+	if req.ParentID != "" {
+		var parent models.Category
+		if err := database.DB.First(&parent, "id = ?", req.ParentID).Error; err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Parent category not found",
+			})
+		}
+		category.ParentID = &parent.ID
+	}
+
+	if err := database.DB.Create(&category).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "A category with slug " + category.Slug + " already exists",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create category",
+		})
+	}
+
+	cache.Invalidate(cache.KeyCategoryNames, cache.KeyCategoryTree)
+
+	return c.Status(fiber.StatusCreated).JSON(category)
+}
+
+// UpdateCategory renames or moves a category (admin only)
+// @Summary Update category
+// @Description Rename a category or move it below another parent (admin access required). Renaming updates the category name stored on its products. A category cannot be moved below itself or one of its subcategories.
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Category ID (UUID)"
+// @Param request body UpdateCategoryRequest true "Fields to change"
+// @Success 200 {object} models.Category "Category updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request, parent not found or move would create a cycle"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Category not found"
+// @Failure 409 {object} map[string]interface{} "Category with this slug already exists"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /categories/{id} [put]
+func UpdateCategory(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid category ID",
+		})
+	}
+
+	var req UpdateCategoryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var category models.Category
+	renamed := false
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&category, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, "Category not found")
+			}
+			return err
+		}
+
+		if name := strings.TrimSpace(req.Name); name != "" && name != category.Name {
+			category.Name = name
+			category.Slug = slugify(name)
+			if category.Slug == "" {
+				return fiber.NewError(fiber.StatusBadRequest, "Category name must contain letters or digits")
+			}
+			renamed = true
+		}
+
+		if req.ParentID != nil {
+			if *req.ParentID == "" {
+				category.ParentID = nil
+			} else {
+				parentID, err := uuid.Parse(*req.ParentID)
+				if err != nil {
+					return fiber.NewError(fiber.StatusBadRequest, "Invalid parent ID")
+				}
+
+				// The new parent must not be the category itself or one of its descendants
+				descendants, err := categoryDescendantIDs(tx, category.ID)
+				if err != nil {
+					return err
+				}
+				for _, descendantID := range descendants {
+					if descendantID == parentID {
+						return fiber.NewError(fiber.StatusBadRequest, "A category cannot be moved below itself or its subcategories")
+					}
+				}
+
+				var parent models.Category
+				if err := tx.First(&parent, "id = ?", parentID).Error; err != nil {
+					return fiber.NewError(fiber.StatusBadRequest, "Parent category not found")
+				}
+				category.ParentID = &parent.ID
+			}
+		}
+
+		if err := tx.Save(&category).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return fiber.NewError(fiber.StatusConflict, "A category with slug "+category.Slug+" already exists")
+			}
+			return err
+		}
+
+		// Keep the category name stored on products in sync
+		if renamed {
+			return tx.Unscoped().Model(&models.Product{}).
+				Where("category_id = ?", category.ID).
+				UpdateColumn("category", category.Name).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return fiberErrorResponse(c, err)
+	}
+
+	cache.Invalidate(cache.KeyCategoryNames, cache.KeyCategoryTree)
+	if renamed {
+		// Cached products still carry the old category name
+		var productIDs []uuid.UUID
+		database.DB.Model(&models.Product{}).Where("category_id = ?", category.ID).Pluck("id", &productIDs)
+		invalidateCatalogCache(productIDs...)
+	}
+
+	return c.JSON(category)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+
+	"bachelor_backend/models"
+	"bachelor_backend/pkg/money"
+	"bachelor_backend/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ConvertedProduct is a product with its price converted to a display currency
+type ConvertedProduct struct {
+	models.Product
+	ConvertedPrice    money.Cents `json:"converted_price"`
+	ConvertedCurrency string      `json:"converted_currency"`
+	ExchangeRate      float64     `json:"exchange_rate"` // Rate from the product's currency to ConvertedCurrency
+}
+
+// convertProducts converts product prices to a display currency. Stored prices are
+// left untouched.
+func convertProducts(products []models.Product, currency string) ([]ConvertedProduct, error) {
+	converted := make([]ConvertedProduct, len(products))
+	for i, product := range products {
+		from := product.Currency
+		if from == "" {
+			from = services.BaseCurrency()
+		}
+		rate, _, err := services.CurrencyConverterInstance.Rate(from, currency)
+		if err != nil {
+			return nil, err
+		}
+		converted[i] = ConvertedProduct{
+			Product:           product,
+			ConvertedPrice:    services.ConvertPrice(product.Price, rate),
+			ConvertedCurrency: currency,
+			ExchangeRate:      rate,
+		}
+	}
+	return converted, nil
+}
+
+// currencyErrorResponse reports a failed currency conversion
+func currencyErrorResponse(c *fiber.Ctx, err error) error {
+	if errors.Is(err, services.ErrUnsupportedCurrency) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+		"error": "Exchange rates are unavailable",
+	})
+}
+
+// respondWithCurrency sends a product listing response, converting its prices to the
+// currency query parameter when one is given
+func respondWithCurrency(c *fiber.Ctx, response fiber.Map) error {
+	if c.Query("currency") == "" {
+		return c.JSON(response)
+	}
+
+	currency, err := services.NormalizeCurrency(c.Query("currency"))
+	if err != nil {
+		return currencyErrorResponse(c, err)
+	}
+	base := services.BaseCurrency()
+	rate, table, err := services.CurrencyConverterInstance.Rate(base, currency)
+	if err != nil {
+		return currencyErrorResponse(c, err)
+	}
+	products, _ := response["products"].([]models.Product)
+	converted, err := convertProducts(products, currency)
+	if err != nil {
+		return currencyErrorResponse(c, err)
+	}
+
+	// Copy the response, which may be shared with the listing cache
+	result := make(fiber.Map, len(response)+1)
+	for key, value := range response {
+		result[key] = value
+	}
+	result["products"] = converted
+	result["currency"] = fiber.Map{
+		"code":        currency,
+		"base":        base,
+		"rate":        rate,
+		"rates_as_of": table.FetchedAt,
+	}
+	return c.JSON(result)
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// errInvalidCursor is returned when a pagination cursor cannot be decoded
+var errInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor is the position after the last row of a page in (created_at, id) order
+type pageCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+}
+
+// usesCursor reports whether the caller opted into cursor pagination. An empty
+// cursor parameter requests the first page.
+func usesCursor(c *fiber.Ctx) bool {
+	return c.Context().QueryArgs().Has("cursor")
+}
+
+// encodeCursor returns the opaque cursor for a row
+func encodeCursor(createdAt time.Time, id uuid.UUID) string {
+	data, _ := json.Marshal(pageCursor{CreatedAt: createdAt, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses an opaque cursor; an empty cursor yields nil
+func decodeCursor(raw string) (*pageCursor, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == uuid.Nil {
+		return nil, errInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// applyCursor orders the query by (created_at, id) and continues after the cursor.
+// The row-value comparison keeps pages stable while rows are inserted or deleted.
+func applyCursor(query *gorm.DB, cursor *pageCursor, sortOrder string, limit int) *gorm.DB {
+	if cursor != nil {
+		if sortOrder == "asc" {
+			query = query.Where("(created_at, id) > (?, ?)", cursor.CreatedAt, cursor.ID)
+		} else {
+			query = query.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+		}
+	}
+
+	// Fetch one extra row to learn whether another page exists
+	return query.Order("created_at " + sortOrder).Order("id " + sortOrder).Limit(limit + 1)
+}
+
+// cursorPage trims the extra row fetched by applyCursor and returns the cursor of
+// the next page, or nil on the last page
+func cursorPage[T any](items []T, limit int, key func(T) (time.Time, uuid.UUID)) ([]T, *string) {
+	if len(items) <= limit {
+		return items, nil
+	}
+
+	items = items[:limit]
+	next := encodeCursor(key(items[limit-1]))
+	return items, &next
+}
+
+// cursorLimit parses the limit used with cursor pagination
+func cursorLimit(c *fiber.Ctx, fallback int) int {
+	limit := c.QueryInt("limit", fallback)
+	if limit < 1 || limit > 100 {
+		return fallback
+	}
+	return limit
+}
@@ -1,15 +1,27 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"bachelor_backend/database"
 	"bachelor_backend/middleware"
 	"bachelor_backend/models"
+	"bachelor_backend/pkg/money"
+	"bachelor_backend/pkg/pagination"
+	"bachelor_backend/services"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Favorite-related request/response types
@@ -22,11 +34,25 @@ type AddUpvoteRequest struct {
 	ProductID string `json:"product_id" validate:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
 }
 
+// Social count request/response types
+type SocialCountsRequest struct {
+	ProductIDs []string `json:"product_ids" validate:"required,min=1,max=100,dive,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+}
+
+type ProductSocialCounts struct {
+	ProductID     uuid.UUID `json:"product_id"`
+	UpvoteCount   int64     `json:"upvote_count"`
+	FavoriteCount int64     `json:"favorite_count"`
+	UserUpvoted   bool      `json:"user_upvoted"`
+	UserFavorited bool      `json:"user_favorited"`
+}
+
 // Comment-related request/response types
 type AddCommentRequest struct {
 	ProductID string `json:"product_id" validate:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	ParentID  string `json:"parent_id,omitempty" validate:"omitempty,uuid" example:"123e4567-e89b-12d3-a456-426614174001"` // Comment being replied to
 	Content   string `json:"content" validate:"required,min=1,max=1000" example:"Great product!"`
-	Rating    int    `json:"rating" validate:"required,min=1,max=5" example:"5"`
+	Rating    int    `json:"rating,omitempty" validate:"omitempty,min=1,max=5" example:"5"` // Required for top-level comments, not allowed on replies
 }
 
 type UpdateCommentRequest struct {
@@ -38,9 +64,32 @@ type UpdateCommentRequest struct {
 type CreateTagRequest struct {
 	Name        string `json:"name" validate:"required,min=1,max=50" example:"Electronics"`
 	Description string `json:"description" validate:"omitempty,max=255" example:"Electronic devices and gadgets"`
-	Color       string `json:"color" validate:"omitempty,len=7" example:"#FF5733"`
+	Color       string `json:"color" validate:"omitempty,len=7" example:"#FF5733"` // #RRGGBB
+}
+
+type BulkAssignTagRequest struct {
+	TagID      string   `json:"tag_id" validate:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Category   string   `json:"category" validate:"required_without=ProductIDs,excluded_with=ProductIDs,omitempty,min=1,max=100" example:"electronics"` // Category name or slug; its subcategories are included
+	ProductIDs []string `json:"product_ids" validate:"omitempty,min=1,max=1000,dive,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
 }
 
+// bulkTagBatchSize is the number of product tags inserted per statement
+const bulkTagBatchSize = 500
+
+// How a tag came to be attached to a product
+const (
+	productTagSourceManual = "manual"
+	productTagSourceML     = "ml"
+)
+
+// TagSummary is a tag with the number of products carrying it
+type TagSummary struct {
+	models.Tag
+	ProductCount int64 `json:"product_count"`
+}
+
+var tagColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
 type AddProductTagRequest struct {
 	ProductID string `json:"product_id" validate:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
 	TagID     string `json:"tag_id" validate:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
@@ -48,15 +97,17 @@ type AddProductTagRequest struct {
 
 // Discount-related request/response types
 type CreateDiscountRequest struct {
-	ProductID         *string   `json:"product_id" validate:"omitempty,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
-	Category          *string   `json:"category" validate:"omitempty,min=1,max=100" example:"Electronics"`
-	DiscountType      string    `json:"discount_type" validate:"required,oneof=percentage fixed_amount" example:"percentage"`
-	DiscountValue     float64   `json:"discount_value" validate:"required,min=0" example:"20.0"`
-	MinOrderAmount    float64   `json:"min_order_amount" validate:"omitempty,min=0" example:"100.0"`
-	MaxDiscountAmount float64   `json:"max_discount_amount" validate:"omitempty,min=0" example:"50.0"`
-	StartDate         time.Time `json:"start_date" validate:"required" example:"2024-01-01T00:00:00Z"`
-	EndDate           time.Time `json:"end_date" validate:"required" example:"2024-12-31T23:59:59Z"`
-	UsageLimit        int       `json:"usage_limit" validate:"omitempty,min=0" example:"100"`
+	ProductID         *string     `json:"product_id" validate:"omitempty,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Category          *string     `json:"category" validate:"omitempty,min=1,max=100" example:"Electronics"`
+	Code              *string     `json:"code" validate:"omitempty,alphanum,min=3,max=32" example:"SPRING20"` // Coupon code; coupons may omit product_id and category to apply to the whole cart
+	DiscountType      string      `json:"discount_type" validate:"required,oneof=percentage fixed_amount" example:"percentage"`
+	DiscountValue     float64     `json:"discount_value" validate:"omitempty,gt=0,max=100" example:"20.0"`            // Percentage off, required for percentage discounts
+	AmountOff         money.Cents `json:"amount_off" validate:"omitempty,min=0" swaggertype:"string" example:"15.00"` // Amount off, required for fixed_amount discounts
+	MinOrderAmount    money.Cents `json:"min_order_amount" validate:"omitempty,min=0" swaggertype:"string" example:"100.00"`
+	MaxDiscountAmount money.Cents `json:"max_discount_amount" validate:"omitempty,min=0" swaggertype:"string" example:"50.00"`
+	StartDate         time.Time   `json:"start_date" validate:"required" example:"2024-01-01T00:00:00Z"`
+	EndDate           time.Time   `json:"end_date" validate:"required" example:"2024-12-31T23:59:59Z"`
+	UsageLimit        int         `json:"usage_limit" validate:"omitempty,min=0" example:"100"`
 }
 
 // FAVORITES HANDLERS
@@ -70,9 +121,10 @@ type CreateDiscountRequest struct {
 // @Security BearerAuth
 // @Param request body AddFavoriteRequest true "Product to add to favorites"
 // @Success 201 {object} map[string]interface{} "Product added to favorites successfully"
-// @Failure 400 {object} map[string]interface{} "Invalid request or product already in favorites"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
 // @Failure 401 {object} map[string]interface{} "User not authenticated"
 // @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 409 {object} map[string]interface{} "Product already in favorites"
 // @Router /favorites [post]
 func AddFavorite(c *fiber.Ctx) error {
 	userID, ok := middleware.GetUserID(c)
@@ -110,22 +162,18 @@ func AddFavorite(c *fiber.Ctx) error {
 		})
 	}
 
-	// Check if already favorited
-	var existingFavorite models.Favorite
-	if err := database.DB.Where("user_id = ? AND product_id = ?", userID, productID).
-		First(&existingFavorite).Error; err == nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Product already in favorites",
-		})
-	}
-
-	// Create favorite
+	// Create favorite; the unique index on user and product rejects duplicates
 	favorite := models.Favorite{
 		UserID:    userID,
 		ProductID: productID,
 	}
 
 	if err := database.DB.Create(&favorite).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Product already in favorites",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to add favorite",
 		})
@@ -134,6 +182,16 @@ func AddFavorite(c *fiber.Ctx) error {
 	// Track interaction
 	go trackUserInteraction(userID, productID, "favorite", c.Get("X-Session-ID"))
 
+	// Feed the favorite back into the recommender
+	feedback := models.RecommendationFeedback{
+		UserID:       userID,
+		ProductID:    productID,
+		FeedbackType: "liked",
+	}
+	if err := database.DB.Create(&feedback).Error; err != nil {
+		log.Printf("Failed to record favorite feedback: %v", err)
+	}
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"message":  "Product added to favorites successfully",
 		"favorite": favorite,
@@ -210,31 +268,99 @@ func GetFavorites(c *fiber.Ctx) error {
 
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	limit, _ := strconv.Atoi(c.Query("limit", "20"))
-	offset := (page - 1) * limit
 
-	var favorites []models.Favorite
-	var total int64
-
-	database.DB.Model(&models.Favorite{}).Where("user_id = ?", userID).Count(&total)
-
-	if err := database.DB.Where("user_id = ?", userID).
+	query := database.DB.Where("user_id = ?", userID).
 		Preload("Product").
-		Order("created_at DESC").
-		Offset(offset).Limit(limit).
-		Find(&favorites).Error; err != nil {
+		Order("created_at DESC")
+	favorites, meta, err := pagination.Paginate[models.Favorite](query, page, limit)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch favorites",
 		})
 	}
 
+	// Skip favorites whose product has been removed from the catalog
+	availableFavorites := make([]models.Favorite, 0, len(favorites))
+	unavailableCount := 0
+	for _, favorite := range favorites {
+		if favorite.Product.ID == uuid.Nil {
+			unavailableCount++
+			continue
+		}
+		availableFavorites = append(availableFavorites, favorite)
+	}
+
 	return c.JSON(fiber.Map{
-		"favorites": favorites,
-		"pagination": fiber.Map{
-			"page":        page,
-			"limit":       limit,
-			"total":       total,
-			"total_pages": (total + int64(limit) - 1) / int64(limit),
-		},
+		"favorites":         availableFavorites,
+		"unavailable_count": unavailableCount,
+		"pagination":        meta,
+	})
+}
+
+// FavoriteRecommendation is a product suggested from the user's favorites
+type FavoriteRecommendation struct {
+	models.Product
+	UpvoteCount  int64 `json:"upvote_count"`
+	CommentCount int64 `json:"comment_count"`
+}
+
+// GetFavoriteRecommendations returns products similar to the user's favorites
+// @Summary Get recommendations based on favorites
+// @Description Get products in the same categories as the user's favorites that are not favorited yet, ordered by upvotes and comments
+// @Tags Favorites
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Number of products" default(10)
+// @Success 200 {object} map[string]interface{} "Recommendations retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /favorites/recommendations [get]
+func GetFavoriteRecommendations(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+
+	favorited := favoritedCategories(userID)
+	recommendations := make([]FavoriteRecommendation, 0)
+	if len(favorited) == 0 {
+		return c.JSON(fiber.Map{
+			"recommendations": recommendations,
+			"categories":      []string{},
+		})
+	}
+
+	categories := make([]string, 0, len(favorited))
+	for category := range favorited {
+		categories = append(categories, category)
+	}
+
+	favoriteIDs := database.DB.Model(&models.Favorite{}).Select("product_id").Where("user_id = ?", userID)
+	if err := preferenceFilteredProducts(loadUserPreference(userID)).
+		Select(`products.*,
+			(SELECT COUNT(*) FROM upvotes WHERE upvotes.product_id = products.id) AS upvote_count,
+			(SELECT COUNT(*) FROM comments WHERE comments.product_id = products.id) AS comment_count`).
+		Where("LOWER(products.category) IN ?", categories).
+		Where("products.id NOT IN (?)", favoriteIDs).
+		Order("upvote_count + comment_count DESC, upvote_count DESC, products.created_at DESC").
+		Limit(limit).
+		Scan(&recommendations).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch recommendations",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"recommendations": recommendations,
+		"categories":      categories,
 	})
 }
 
@@ -249,9 +375,10 @@ func GetFavorites(c *fiber.Ctx) error {
 // @Security BearerAuth
 // @Param request body AddUpvoteRequest true "Product to upvote"
 // @Success 201 {object} map[string]interface{} "Product upvoted successfully"
-// @Failure 400 {object} map[string]interface{} "Invalid request or already upvoted"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
 // @Failure 401 {object} map[string]interface{} "User not authenticated"
 // @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 409 {object} map[string]interface{} "Product already upvoted"
 // @Router /upvotes [post]
 func AddUpvote(c *fiber.Ctx) error {
 	userID, ok := middleware.GetUserID(c)
@@ -289,22 +416,18 @@ func AddUpvote(c *fiber.Ctx) error {
 		})
 	}
 
-	// Check if already upvoted
-	var existingUpvote models.Upvote
-	if err := database.DB.Where("user_id = ? AND product_id = ?", userID, productID).
-		First(&existingUpvote).Error; err == nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Product already upvoted",
-		})
-	}
-
-	// Create upvote
+	// Create upvote; the unique index on user and product rejects duplicates
 	upvote := models.Upvote{
 		UserID:    userID,
 		ProductID: productID,
 	}
 
 	if err := database.DB.Create(&upvote).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Product already upvoted",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to add upvote",
 		})
@@ -406,20 +529,149 @@ func GetProductUpvotes(c *fiber.Ctx) error {
 	})
 }
 
+// GetSocialCounts returns upvote and favorite counts for many products at once
+// @Summary Get batch social counts
+// @Description Get upvote and favorite counts for up to 100 products in one request, plus the current user's upvote/favorite status when authenticated
+// @Tags Upvotes
+// @Accept json
+// @Produce json
+// @Param request body SocialCountsRequest true "Product IDs"
+// @Success 200 {object} map[string]interface{} "Social counts retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /products/social-counts [post]
+func GetSocialCounts(c *fiber.Ctx) error {
+	var req SocialCountsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Preserve request order while de-duplicating
+	productIDs := make([]uuid.UUID, 0, len(req.ProductIDs))
+	counts := make(map[uuid.UUID]*ProductSocialCounts, len(req.ProductIDs))
+	for _, idStr := range req.ProductIDs {
+		id, _ := uuid.Parse(idStr)
+		if _, exists := counts[id]; exists {
+			continue
+		}
+		productIDs = append(productIDs, id)
+		counts[id] = &ProductSocialCounts{ProductID: id}
+	}
+
+	type productCount struct {
+		ProductID uuid.UUID
+		Count     int64
+	}
+
+	var upvoteCounts []productCount
+	if err := database.DB.Model(&models.Upvote{}).
+		Select("product_id, COUNT(*) AS count").
+		Where("product_id IN ?", productIDs).
+		Group("product_id").
+		Scan(&upvoteCounts).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch upvote counts",
+		})
+	}
+	for _, row := range upvoteCounts {
+		counts[row.ProductID].UpvoteCount = row.Count
+	}
+
+	var favoriteCounts []productCount
+	if err := database.DB.Model(&models.Favorite{}).
+		Select("product_id, COUNT(*) AS count").
+		Where("product_id IN ?", productIDs).
+		Group("product_id").
+		Scan(&favoriteCounts).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch favorite counts",
+		})
+	}
+	for _, row := range favoriteCounts {
+		counts[row.ProductID].FavoriteCount = row.Count
+	}
+
+	// Include the current user's status if authenticated
+	if userID, ok := middleware.GetUserID(c); ok {
+		var upvoted []uuid.UUID
+		database.DB.Model(&models.Upvote{}).
+			Where("user_id = ? AND product_id IN ?", userID, productIDs).
+			Pluck("product_id", &upvoted)
+		for _, id := range upvoted {
+			counts[id].UserUpvoted = true
+		}
+
+		var favorited []uuid.UUID
+		database.DB.Model(&models.Favorite{}).
+			Where("user_id = ? AND product_id IN ?", userID, productIDs).
+			Pluck("product_id", &favorited)
+		for _, id := range favorited {
+			counts[id].UserFavorited = true
+		}
+	}
+
+	results := make([]ProductSocialCounts, 0, len(productIDs))
+	for _, id := range productIDs {
+		results = append(results, *counts[id])
+	}
+
+	return c.JSON(fiber.Map{
+		"counts": results,
+	})
+}
+
 // COMMENTS HANDLERS
 
+// hasPurchasedProduct reports whether the user has a delivered or completed order containing the product
+func hasPurchasedProduct(userID, productID uuid.UUID) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.OrderItem{}).
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.user_id = ? AND order_items.product_id = ? AND orders.status IN ?",
+			userID, productID, []string{"delivered", "completed"}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// RatingStats summarizes the ratings of a group of comments
+type RatingStats struct {
+	Average float64 `json:"average"`
+	Count   int64   `json:"count"`
+}
+
+// productRatingStats returns rating statistics for a product, optionally limited to verified purchases
+func productRatingStats(productID uuid.UUID, verified *bool) RatingStats {
+	var stats RatingStats
+	query := database.DB.Model(&models.Comment{}).
+		Where("product_id = ? AND rating IS NOT NULL", productID)
+	if verified != nil {
+		query = query.Where("verified_purchase = ?", *verified)
+	}
+	query.Select("COALESCE(AVG(rating), 0) AS average, COUNT(*) AS count").Scan(&stats)
+	stats.Average = math.Round(stats.Average*100) / 100
+	return stats
+}
+
 // AddComment adds a comment to a product
 // @Summary Add product comment
-// @Description Add a comment and rating to a product
+// @Description Add a comment and rating to a product, or reply to a top-level comment with parent_id. Replies carry no rating and cannot themselves be replied to.
 // @Tags Comments
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param request body AddCommentRequest true "Comment to add"
 // @Success 201 {object} map[string]interface{} "Comment added successfully"
-// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 400 {object} map[string]interface{} "Invalid request, rating rules violated or reply to a reply"
 // @Failure 401 {object} map[string]interface{} "User not authenticated"
-// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 404 {object} map[string]interface{} "Product or parent comment not found"
 // @Router /comments [post]
 func AddComment(c *fiber.Ctx) error {
 	userID, ok := middleware.GetUserID(c)
@@ -462,9 +714,49 @@ func AddComment(c *fiber.Ctx) error {
 		UserID:    userID,
 		ProductID: productID,
 		Content:   req.Content,
-		Rating:    req.Rating,
 	}
 
+	if req.ParentID != "" {
+		// Only top-level comments can be replied to, and only on the same product
+		var parent models.Comment
+		if err := database.DB.First(&parent, "id = ?", req.ParentID).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Parent comment not found",
+			})
+		}
+		if parent.ProductID != productID {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Parent comment belongs to a different product",
+			})
+		}
+		if parent.ParentID != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Replies cannot be replied to",
+			})
+		}
+		if req.Rating != 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Replies cannot carry a rating",
+			})
+		}
+		comment.ParentID = &parent.ID
+	} else {
+		if req.Rating == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Rating is required for top-level comments",
+			})
+		}
+		comment.Rating = &req.Rating
+	}
+
+	verified, err := hasPurchasedProduct(userID, productID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to add comment",
+		})
+	}
+	comment.VerifiedPurchase = verified
+
 	if err := database.DB.Create(&comment).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to add comment",
@@ -476,6 +768,7 @@ func AddComment(c *fiber.Ctx) error {
 
 	// Track interaction
 	go trackUserInteraction(userID, productID, "comment", c.Get("X-Session-ID"))
+	services.RefreshSentimentSnapshotAsync(productID)
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"message": "Comment added successfully",
@@ -485,13 +778,14 @@ func AddComment(c *fiber.Ctx) error {
 
 // GetProductComments returns comments for a product
 // @Summary Get product comments
-// @Description Get paginated list of comments for a product
+// @Description Get paginated list of top-level comments for a product, each with its replies nested oldest first. Comments by users who received the product are flagged as verified purchases, and ratings are summarized separately for verified and unverified reviewers.
 // @Tags Comments
 // @Accept json
 // @Produce json
 // @Param product_id path string true "Product ID (UUID)"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(20)
+// @Param verified_only query bool false "Only return comments from verified purchases" default(false)
 // @Success 200 {object} map[string]interface{} "Comments retrieved successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid product ID"
 // @Router /comments/{product_id} [get]
@@ -505,39 +799,50 @@ func GetProductComments(c *fiber.Ctx) error {
 
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	limit, _ := strconv.Atoi(c.Query("limit", "20"))
-	offset := (page - 1) * limit
-
-	var comments []models.Comment
-	var total int64
 
-	database.DB.Model(&models.Comment{}).Where("product_id = ?", productID).Count(&total)
+	verifiedOnly := c.QueryBool("verified_only", false)
+	topLevel := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("product_id = ? AND parent_id IS NULL", productID)
+		if verifiedOnly {
+			db = db.Where("verified_purchase = ?", true)
+		}
+		return db
+	}
 
-	if err := database.DB.Where("product_id = ?", productID).
+	query := database.DB.Scopes(topLevel).
 		Preload("User").
-		Order("created_at DESC").
-		Offset(offset).Limit(limit).
-		Find(&comments).Error; err != nil {
+		Preload("Replies", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		Preload("Replies.User").
+		Order("created_at DESC")
+	comments, meta, err := pagination.Paginate[models.Comment](query, page, limit)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch comments",
 		})
 	}
 
-	// Calculate average rating
-	var avgRating float64
-	database.DB.Model(&models.Comment{}).
-		Where("product_id = ?", productID).
-		Select("AVG(rating)").
-		Scan(&avgRating)
+	// Summarize ratings overall and by verified purchase
+	verified, unverified := true, false
+	verifiedStats := productRatingStats(productID, &verified)
+	unverifiedStats := productRatingStats(productID, &unverified)
+	allStats := productRatingStats(productID, nil)
+
+	avgRating := allStats.Average
+	if verifiedOnly {
+		avgRating = verifiedStats.Average
+	}
 
 	return c.JSON(fiber.Map{
-		"comments": comments,
-		"pagination": fiber.Map{
-			"page":        page,
-			"limit":       limit,
-			"total":       total,
-			"total_pages": (total + int64(limit) - 1) / int64(limit),
-		},
+		"comments":       comments,
+		"pagination":     meta,
 		"average_rating": avgRating,
+		"rating_summary": fiber.Map{
+			"all":        allStats,
+			"verified":   verifiedStats,
+			"unverified": unverifiedStats,
+		},
 	})
 }
 
@@ -551,7 +856,7 @@ func GetProductComments(c *fiber.Ctx) error {
 // @Param comment_id path string true "Comment ID (UUID)"
 // @Param request body UpdateCommentRequest true "Updated comment data"
 // @Success 200 {object} map[string]interface{} "Comment updated successfully"
-// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 400 {object} map[string]interface{} "Invalid request or rating on a reply"
 // @Failure 401 {object} map[string]interface{} "User not authenticated"
 // @Failure 403 {object} map[string]interface{} "Not authorized to update this comment"
 // @Failure 404 {object} map[string]interface{} "Comment not found"
@@ -598,7 +903,12 @@ func UpdateComment(c *fiber.Ctx) error {
 		comment.Content = req.Content
 	}
 	if req.Rating > 0 {
-		comment.Rating = req.Rating
+		if comment.ParentID != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Replies cannot carry a rating",
+			})
+		}
+		comment.Rating = &req.Rating
 	}
 
 	if err := database.DB.Save(&comment).Error; err != nil {
@@ -615,7 +925,7 @@ func UpdateComment(c *fiber.Ctx) error {
 
 // DeleteComment deletes a user's comment
 // @Summary Delete comment
-// @Description Delete a user's own comment
+// @Description Delete a user's own comment together with its replies
 // @Tags Comments
 // @Accept json
 // @Produce json
@@ -674,6 +984,7 @@ func DeleteComment(c *fiber.Ctx) error {
 // @Success 201 {object} map[string]interface{} "Tag created successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid request or tag already exists"
 // @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
 // @Router /tags [post]
 func CreateTag(c *fiber.Ctx) error {
 	var req CreateTagRequest
@@ -689,6 +1000,12 @@ func CreateTag(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.Color != "" && !tagColorPattern.MatchString(req.Color) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Color must be a hex color code of the form #RRGGBB",
+		})
+	}
+
 	// Check if tag already exists
 	var existingTag models.Tag
 	if err := database.DB.Where("name = ?", req.Name).First(&existingTag).Error; err == nil {
@@ -697,14 +1014,32 @@ func CreateTag(c *fiber.Ctx) error {
 		})
 	}
 
+	slug, err := uniqueTagSlug(database.DB, req.Name)
+	if err != nil {
+		if errors.Is(err, errEmptySlug) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Tag name must contain letters or digits",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create tag",
+		})
+	}
+
 	// Create tag
 	tag := models.Tag{
 		Name:        req.Name,
+		Slug:        slug,
 		Description: req.Description,
-		Color:       req.Color,
+		Color:       strings.ToUpper(req.Color),
 	}
 
 	if err := database.DB.Create(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Tag already exists",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to create tag",
 		})
@@ -716,9 +1051,36 @@ func CreateTag(c *fiber.Ctx) error {
 	})
 }
 
+var (
+	errEmptySlug = errors.New("name has no letters or digits")
+	errTagInUse  = errors.New("tag is attached to products")
+)
+
+// uniqueTagSlug derives a slug from a tag name, numbering it when another tag already
+// has the slug
+func uniqueTagSlug(db *gorm.DB, name string) (string, error) {
+	base := slugify(name)
+	if base == "" {
+		return "", errEmptySlug
+	}
+
+	var taken []string
+	if err := db.Model(&models.Tag{}).
+		Where("slug = ? OR slug LIKE ?", base, base+"-%").
+		Pluck("slug", &taken).Error; err != nil {
+		return "", err
+	}
+
+	slug := base
+	for n := 2; slices.Contains(taken, slug); n++ {
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+	return slug, nil
+}
+
 // GetTags returns all available tags
 // @Summary Get all tags
-// @Description Get list of all available product tags
+// @Description Get list of all available product tags, each with the number of products carrying it
 // @Tags Tags
 // @Accept json
 // @Produce json
@@ -732,8 +1094,82 @@ func GetTags(c *fiber.Ctx) error {
 		})
 	}
 
+	// Products removed from the catalog no longer count
+	var counts []struct {
+		TagID        uuid.UUID
+		ProductCount int64
+	}
+	if err := database.DB.Model(&models.ProductTag{}).
+		Select("product_tags.tag_id, COUNT(*) AS product_count").
+		Joins("JOIN products ON products.id = product_tags.product_id AND products.deleted_at IS NULL").
+		Group("product_tags.tag_id").
+		Scan(&counts).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to count tagged products",
+		})
+	}
+
+	countByTag := make(map[uuid.UUID]int64, len(counts))
+	for _, count := range counts {
+		countByTag[count.TagID] = count.ProductCount
+	}
+
+	summaries := make([]TagSummary, 0, len(tags))
+	for _, tag := range tags {
+		summaries = append(summaries, TagSummary{Tag: tag, ProductCount: countByTag[tag.ID]})
+	}
+
+	return c.JSON(fiber.Map{
+		"tags": summaries,
+	})
+}
+
+// GetTagProducts returns the products carrying a tag
+// @Summary Get tag products
+// @Description Get a paginated list of the products carrying a tag, looked up by ID or slug
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Param id path string true "Tag ID (UUID) or slug"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{} "Products retrieved successfully"
+// @Failure 404 {object} map[string]interface{} "Tag not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /tags/{id}/products [get]
+func GetTagProducts(c *fiber.Ctx) error {
+	var tag models.Tag
+	query := database.DB.Where("slug = ?", strings.ToLower(c.Params("id")))
+	if tagID, err := uuid.Parse(c.Params("id")); err == nil {
+		query = database.DB.Where("id = ?", tagID)
+	}
+	if err := query.First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Tag not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch tag",
+		})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	taggedProducts := database.DB.Model(&models.ProductTag{}).Select("product_id").Where("tag_id = ?", tag.ID)
+	products, meta, err := pagination.Paginate[models.Product](
+		database.DB.Where("id IN (?)", taggedProducts).Order("name ASC"), page, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch products",
+		})
+	}
+
 	return c.JSON(fiber.Map{
-		"tags": tags,
+		"tag":        tag,
+		"products":   products,
+		"pagination": meta,
 	})
 }
 
@@ -748,6 +1184,7 @@ func GetTags(c *fiber.Ctx) error {
 // @Success 201 {object} map[string]interface{} "Tag added to product successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid request or tag already added"
 // @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
 // @Failure 404 {object} map[string]interface{} "Product or tag not found"
 // @Router /tags/products [post]
 func AddProductTag(c *fiber.Ctx) error {
@@ -806,6 +1243,7 @@ func AddProductTag(c *fiber.Ctx) error {
 	productTag := models.ProductTag{
 		ProductID: productID,
 		TagID:     tagID,
+		Source:    productTagSourceManual,
 	}
 
 	if err := database.DB.Create(&productTag).Error; err != nil {
@@ -820,6 +1258,116 @@ func AddProductTag(c *fiber.Ctx) error {
 	})
 }
 
+// BulkAssignTag attaches a tag to every product in a category or in a list of products
+// @Summary Bulk assign tag
+// @Description Attach a tag to all products in a category (including its subcategories) or to a list of products, in one transaction (admin only). Products that already have the tag are skipped. Provide either category or product_ids.
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body BulkAssignTagRequest true "Tag and the products to attach it to"
+// @Success 200 {object} map[string]interface{} "Tag assigned"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Tag or category not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /tags/bulk-assign [post]
+func BulkAssignTag(c *fiber.Ctx) error {
+	var req BulkAssignTagRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var tag models.Tag
+	if err := database.DB.First(&tag, "id = ?", req.TagID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Tag not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch tag",
+		})
+	}
+
+	query := database.DB.Model(&models.Product{})
+	if req.Category != "" {
+		var category models.Category
+		if err := database.DB.Where("slug = ?", slugify(req.Category)).First(&category).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Category not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch category",
+			})
+		}
+
+		categoryIDs, err := categoryDescendantIDs(database.DB, category.ID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch subcategories",
+			})
+		}
+		query = query.Where("category_id IN ?", categoryIDs)
+	} else {
+		query = query.Where("id IN ?", req.ProductIDs)
+	}
+
+	var productIDs []uuid.UUID
+	if err := query.Pluck("id", &productIDs).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch products",
+		})
+	}
+
+	// Requested products that do not exist or were removed from the catalog
+	notFound := []string{}
+	for _, id := range req.ProductIDs {
+		parsed, _ := uuid.Parse(id)
+		if !slices.Contains(productIDs, parsed) && !slices.Contains(notFound, id) {
+			notFound = append(notFound, id)
+		}
+	}
+
+	var created int64
+	if len(productIDs) > 0 {
+		productTags := make([]models.ProductTag, 0, len(productIDs))
+		for _, productID := range productIDs {
+			productTags = append(productTags, models.ProductTag{ProductID: productID, TagID: tag.ID, Source: productTagSourceManual})
+		}
+
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&productTags, bulkTagBatchSize)
+			created = result.RowsAffected
+			return result.Error
+		})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to assign tag",
+			})
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"tag_id":         tag.ID,
+		"matched":        len(productIDs),
+		"created":        created,
+		"already_tagged": int64(len(productIDs)) - created,
+		"not_found":      notFound,
+	})
+}
+
 // GetProductTags returns tags for a product
 // @Summary Get product tags
 // @Description Get all tags associated with a product
@@ -851,11 +1399,127 @@ func GetProductTags(c *fiber.Ctx) error {
 	})
 }
 
+// RemoveProductTag removes a tag from a product
+// @Summary Remove tag from product
+// @Description Remove a tag from a product (admin only)
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param product_id query string true "Product ID (UUID)"
+// @Param tag_id query string true "Tag ID (UUID)"
+// @Success 200 {object} map[string]interface{} "Tag removed from product successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid product or tag ID"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Product does not have the tag"
+// @Router /tags/products [delete]
+func RemoveProductTag(c *fiber.Ctx) error {
+	productID, err := uuid.Parse(c.Query("product_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+
+	tagID, err := uuid.Parse(c.Query("tag_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid tag ID",
+		})
+	}
+
+	result := database.DB.Where("product_id = ? AND tag_id = ?", productID, tagID).Delete(&models.ProductTag{})
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to remove tag from product",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Product does not have the tag",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Tag removed from product successfully",
+	})
+}
+
+// DeleteTag deletes a tag
+// @Summary Delete tag
+// @Description Delete a tag (admin only). A tag still attached to products is only deleted with force=true, which also removes it from those products.
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Tag ID (UUID)"
+// @Param force query bool false "Remove the tag from its products as well" default(false)
+// @Success 200 {object} map[string]interface{} "Tag deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid tag ID"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Tag not found"
+// @Failure 409 {object} map[string]interface{} "Tag is still attached to products"
+// @Router /tags/{id} [delete]
+func DeleteTag(c *fiber.Ctx) error {
+	tagID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid tag ID",
+		})
+	}
+	force := c.QueryBool("force", false)
+
+	var productCount int64
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var tag models.Tag
+		if err := tx.First(&tag, "id = ?", tagID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.ProductTag{}).Where("tag_id = ?", tagID).Count(&productCount).Error; err != nil {
+			return err
+		}
+		if productCount > 0 {
+			if !force {
+				return errTagInUse
+			}
+			if err := tx.Where("tag_id = ?", tagID).Delete(&models.ProductTag{}).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(&tag).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Tag not found",
+			})
+		case errors.Is(err, errTagInUse):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":         "Tag is still attached to products; use force=true to remove it from them",
+				"product_count": productCount,
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete tag",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":           "Tag deleted successfully",
+		"products_untagged": productCount,
+	})
+}
+
 // DISCOUNTS HANDLERS
 
 // CreateDiscount creates a new discount
 // @Summary Create discount
-// @Description Create a new product or category discount (admin only)
+// @Description Create a new product or category discount (admin only). Discounts with a code are coupons that customers apply to their cart; a coupon without product_id or category applies to the whole cart. Percentage discounts take discount_value, fixed_amount discounts take amount_off.
 // @Tags Discounts
 // @Accept json
 // @Produce json
@@ -864,6 +1528,8 @@ func GetProductTags(c *fiber.Ctx) error {
 // @Success 201 {object} map[string]interface{} "Discount created successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid request"
 // @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 409 {object} map[string]interface{} "Coupon code already exists"
 // @Router /discounts [post]
 func CreateDiscount(c *fiber.Ctx) error {
 	var req CreateDiscountRequest
@@ -879,12 +1545,30 @@ func CreateDiscount(c *fiber.Ctx) error {
 		})
 	}
 
-	// Validate that either ProductID or Category is provided, but not both
-	if (req.ProductID == nil && req.Category == nil) || (req.ProductID != nil && req.Category != nil) {
+	// Validate that either ProductID or Category is provided, but not both.
+	// Coupons may omit both to apply to the whole cart.
+	if req.ProductID != nil && req.Category != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Either product_id or category must be provided, but not both",
 		})
 	}
+	if req.ProductID == nil && req.Category == nil && req.Code == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Either product_id or category must be provided for discounts without a code",
+		})
+	}
+
+	// Percentages and fixed amounts are stored separately
+	if req.DiscountType == "percentage" && (req.DiscountValue == 0 || req.AmountOff != 0) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Percentage discounts require discount_value and no amount_off",
+		})
+	}
+	if req.DiscountType == "fixed_amount" && (req.AmountOff <= 0 || req.DiscountValue != 0) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Fixed amount discounts require amount_off and no discount_value",
+		})
+	}
 
 	// Validate date range
 	if req.EndDate.Before(req.StartDate) {
@@ -897,6 +1581,7 @@ func CreateDiscount(c *fiber.Ctx) error {
 	discount := models.Discount{
 		DiscountType:      req.DiscountType,
 		DiscountValue:     req.DiscountValue,
+		AmountOff:         req.AmountOff,
 		MinOrderAmount:    req.MinOrderAmount,
 		MaxDiscountAmount: req.MaxDiscountAmount,
 		StartDate:         req.StartDate,
@@ -918,12 +1603,32 @@ func CreateDiscount(c *fiber.Ctx) error {
 		discount.Category = req.Category
 	}
 
+	if req.Code != nil {
+		code := services.NormalizeCouponCode(*req.Code)
+		discount.Code = &code
+	}
+
 	if err := database.DB.Create(&discount).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Coupon code already exists",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to create discount",
 		})
 	}
 
+	// is_active defaults to true on insert; scheduled discounts start switched off
+	// and the discount scheduler turns them on when their window opens
+	if !services.DiscountInWindow(discount, time.Now()) {
+		if err := database.DB.Model(&discount).UpdateColumn("is_active", false).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to create discount",
+			})
+		}
+	}
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"message":  "Discount created successfully",
 		"discount": discount,
@@ -932,7 +1637,7 @@ func CreateDiscount(c *fiber.Ctx) error {
 
 // GetActiveDiscounts returns currently active discounts
 // @Summary Get active discounts
-// @Description Get list of currently active discounts
+// @Description Get list of automatic discounts whose date window contains the current time
 // @Tags Discounts
 // @Accept json
 // @Produce json
@@ -942,7 +1647,9 @@ func CreateDiscount(c *fiber.Ctx) error {
 // @Router /discounts/active [get]
 func GetActiveDiscounts(c *fiber.Ctx) error {
 	now := time.Now()
-	query := database.DB.Where("is_active = ? AND start_date <= ? AND end_date >= ?", true, now, now)
+	// Coupon codes are only revealed to whoever holds them
+	// The date window decides, so results stay correct even if the discount scheduler lags
+	query := database.DB.Where("start_date <= ? AND end_date >= ? AND code IS NULL", now, now)
 
 	// Apply filters
 	if productID := c.Query("product_id"); productID != "" {
@@ -966,3 +1673,59 @@ func GetActiveDiscounts(c *fiber.Ctx) error {
 		"discounts": discounts,
 	})
 }
+
+// GetUpcomingDiscounts returns discounts that have not started yet
+// @Summary Get upcoming discounts
+// @Description Get discounts and coupons whose start date is still in the future, soonest first (admin only)
+// @Tags Discounts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{} "Upcoming discounts retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /discounts/upcoming [get]
+func GetUpcomingDiscounts(c *fiber.Ctx) error {
+	query := database.DB.Where("start_date > ?", time.Now()).Order("start_date ASC")
+	return listDiscounts(c, query)
+}
+
+// GetExpiredDiscounts returns discounts whose end date has passed
+// @Summary Get expired discounts
+// @Description Get discounts and coupons whose end date has passed, most recently ended first (admin only)
+// @Tags Discounts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{} "Expired discounts retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /discounts/expired [get]
+func GetExpiredDiscounts(c *fiber.Ctx) error {
+	query := database.DB.Where("end_date < ?", time.Now()).Order("end_date DESC")
+	return listDiscounts(c, query)
+}
+
+// listDiscounts responds with one page of the discounts matched by query
+func listDiscounts(c *fiber.Ctx, query *gorm.DB) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	discounts, meta, err := pagination.Paginate[models.Discount](query.Preload("Product"), page, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch discounts",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"discounts":  discounts,
+		"pagination": meta,
+	})
+}
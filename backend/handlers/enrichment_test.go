@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"testing"
+
+	"bachelor_backend/database/dbtest"
+	"bachelor_backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func TestGetFavoritesSoftDeletedProduct(t *testing.T) {
+	db := dbtest.Open(t)
+	removed := dbtest.CreateProduct(t, db, 1000, 5)
+	kept := dbtest.CreateProduct(t, db, 2000, 5)
+	user := dbtest.CreateUser(t, db)
+
+	for _, product := range []models.Product{removed, kept} {
+		if err := db.Create(&models.Favorite{UserID: user.ID, ProductID: product.ID}).Error; err != nil {
+			t.Fatalf("failed to create favorite: %v", err)
+		}
+	}
+	if err := db.Delete(&models.Product{}, "id = ?", removed.ID).Error; err != nil {
+		t.Fatalf("failed to soft-delete product: %v", err)
+	}
+
+	var response struct {
+		Favorites []struct {
+			ProductID uuid.UUID `json:"product_id"`
+		} `json:"favorites"`
+		UnavailableCount int `json:"unavailable_count"`
+	}
+	if status := callAsUser(t, fiber.MethodGet, GetFavorites, user.ID, &response); status != fiber.StatusOK {
+		t.Fatalf("status = %d, want 200", status)
+	}
+
+	if len(response.Favorites) != 1 || response.Favorites[0].ProductID != kept.ID {
+		t.Errorf("favorites = %+v, want only the available product", response.Favorites)
+	}
+	if response.UnavailableCount != 1 {
+		t.Errorf("unavailable_count = %d, want 1", response.UnavailableCount)
+	}
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/middleware"
+	"bachelor_backend/models"
+	"bachelor_backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreateExperimentRequest represents the request to start a recommendation algorithm experiment
+type CreateExperimentRequest struct {
+	Name        string   `json:"name" validate:"required,min=3,max=100" example:"collab-vs-hybrid"`
+	Description string   `json:"description" validate:"max=1000" example:"Does collaborative filtering beat hybrid on CTR?"`
+	Variants    []string `json:"variants" validate:"omitempty,min=2,dive,oneof=collaborative content_based hybrid" example:"collaborative,hybrid"`
+}
+
+// UpdateExperimentStatusRequest represents the request to pause, resume or complete an experiment
+type UpdateExperimentStatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=active paused completed" example:"completed"`
+}
+
+// loadExperiment fetches an experiment from the route parameters
+func loadExperiment(c *fiber.Ctx) (models.Experiment, error) {
+	var experiment models.Experiment
+
+	experimentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return experiment, fiber.NewError(fiber.StatusBadRequest, "Invalid experiment ID")
+	}
+
+	if err := database.DB.First(&experiment, "id = ?", experimentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return experiment, fiber.NewError(fiber.StatusNotFound, "Experiment not found")
+		}
+		return experiment, err
+	}
+
+	return experiment, nil
+}
+
+// activeExperimentConflict reports whether an experiment other than the given one is active
+func activeExperimentConflict(exceptID uuid.UUID) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.Experiment{}).
+		Where("status = ? AND id <> ?", services.ExperimentActive, exceptID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetExperiments returns all recommendation experiments
+// @Summary Get experiments
+// @Description Get all recommendation algorithm experiments, newest first. Admin only.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Experiments retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /analytics/experiments [get]
+func GetExperiments(c *fiber.Ctx) error {
+	var experiments []models.Experiment
+	if err := database.DB.Order("created_at DESC").Find(&experiments).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch experiments",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"experiments": experiments,
+	})
+}
+
+// CreateExperiment starts an A/B test of recommendation algorithms
+// @Summary Create experiment
+// @Description Start an experiment that buckets users into recommendation algorithm variants (collaborative, content_based, hybrid; all three by default). Users are assigned deterministically from a hash of their ID the first time they request recommendations, and keep their variant for the life of the experiment. Only one experiment can be active at a time. Admin only.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateExperimentRequest true "Experiment details"
+// @Success 201 {object} map[string]interface{} "Experiment created successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 409 {object} map[string]interface{} "Another experiment is active or the name is taken"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /analytics/experiments [post]
+func CreateExperiment(c *fiber.Ctx) error {
+	var req CreateExperimentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	variants := services.ExperimentAlgorithms
+	if len(req.Variants) > 0 {
+		seen := make(map[string]bool, len(req.Variants))
+		variants = make([]string, 0, len(req.Variants))
+		for _, variant := range req.Variants {
+			if seen[variant] {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Variants must be distinct",
+				})
+			}
+			seen[variant] = true
+			variants = append(variants, variant)
+		}
+	}
+
+	conflict, err := activeExperimentConflict(uuid.Nil)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create experiment",
+		})
+	}
+	if conflict {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Another experiment is already active; pause or complete it first",
+		})
+	}
+
+	experiment := models.Experiment{
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		Variants:    variants,
+		Status:      services.ExperimentActive,
+		StartedAt:   time.Now(),
+	}
+	if err := database.DB.Create(&experiment).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "An experiment with this name already exists",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create experiment",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":    "Experiment created successfully",
+		"experiment": experiment,
+	})
+}
+
+// GetExperiment returns an experiment with its per-variant results
+// @Summary Get experiment results
+// @Description Get an experiment with, for each variant, the number of users assigned, the recommendations generated for them and the impressions actually shown, clicks and purchases recorded as recommendation feedback, and the resulting click-through and conversion rates per impression. Only activity after each user's assignment and before the experiment ended counts. Admin only.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Experiment ID (UUID)"
+// @Success 200 {object} map[string]interface{} "Experiment results"
+// @Failure 400 {object} map[string]interface{} "Invalid experiment ID"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Experiment not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /analytics/experiments/{id} [get]
+func GetExperiment(c *fiber.Ctx) error {
+	experiment, err := loadExperiment(c)
+	if err != nil {
+		return fiberErrorResponse(c, err)
+	}
+
+	variants, err := services.ExperimentReport(experiment)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to compute experiment results",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"experiment":   experiment,
+		"variants":     variants,
+		"generated_at": time.Now(),
+	})
+}
+
+// UpdateExperimentStatus pauses, resumes or completes an experiment
+// @Summary Update experiment status
+// @Description Pause, resume or complete an experiment. While no experiment is active every user gets the default hybrid recommendations; assignments are kept, so resuming restores each user's variant. Completing an experiment is final and freezes its results window. Admin only.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Experiment ID (UUID)"
+// @Param request body UpdateExperimentStatusRequest true "New status"
+// @Success 200 {object} map[string]interface{} "Experiment updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Experiment not found"
+// @Failure 409 {object} map[string]interface{} "Another experiment is active or the experiment is completed"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /analytics/experiments/{id}/status [put]
+func UpdateExperimentStatus(c *fiber.Ctx) error {
+	experiment, err := loadExperiment(c)
+	if err != nil {
+		return fiberErrorResponse(c, err)
+	}
+
+	var req UpdateExperimentStatusRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if experiment.Status == services.ExperimentCompleted {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Experiment is already completed",
+		})
+	}
+
+	updates := map[string]interface{}{"status": req.Status}
+	switch req.Status {
+	case services.ExperimentActive:
+		conflict, err := activeExperimentConflict(experiment.ID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update experiment",
+			})
+		}
+		if conflict {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Another experiment is already active; pause or complete it first",
+			})
+		}
+	case services.ExperimentCompleted:
+		updates["ended_at"] = time.Now()
+	}
+
+	if err := database.DB.Model(&experiment).Updates(updates).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update experiment",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Experiment updated successfully",
+		"experiment": experiment,
+	})
+}
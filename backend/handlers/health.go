@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// readinessCheckTimeout bounds each dependency check of the readiness probe
+const readinessCheckTimeout = 3 * time.Second
+
+// DependencyStatus reports whether a downstream dependency is reachable
+type DependencyStatus struct {
+	Status    string `json:"status" example:"up"`
+	LatencyMs int64  `json:"latency_ms" example:"4"`
+	Error     string `json:"error,omitempty"`
+}
+
+// HealthReady checks that the service's dependencies are reachable
+// @Summary Readiness check
+// @Description Ping the database and the ML service status endpoint, concurrently and with a 3 second timeout each. Returns 200 when both are up and 503 with the status of each dependency otherwise. Use /health for liveness; it does not touch dependencies.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} map[string]interface{} "All dependencies are up"
+// @Failure 503 {object} map[string]interface{} "At least one dependency is down"
+// @Router /health/ready [get]
+func HealthReady(c *fiber.Ctx) error {
+	checks := map[string]func(context.Context) error{
+		"database":   database.Ping,
+		"ml_service": services.MLService.Ping,
+	}
+
+	var (
+		mu           sync.Mutex
+		wg           sync.WaitGroup
+		dependencies = make(map[string]DependencyStatus, len(checks))
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) error) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), readinessCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			status := DependencyStatus{Status: "up"}
+			if err := check(ctx); err != nil {
+				status.Status = "down"
+				status.Error = err.Error()
+			}
+			status.LatencyMs = time.Since(start).Milliseconds()
+
+			mu.Lock()
+			dependencies[name] = status
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	overall, code := "ok", fiber.StatusOK
+	for _, status := range dependencies {
+		if status.Status != "up" {
+			overall, code = "unavailable", fiber.StatusServiceUnavailable
+			break
+		}
+	}
+
+	return c.Status(code).JSON(fiber.Map{
+		"status":       overall,
+		"dependencies": dependencies,
+		"timestamp":    time.Now().UTC(),
+	})
+}
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/middleware"
+	"bachelor_backend/models"
+	"bachelor_backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LowStockProduct is a product at or below the low-stock threshold with its recent sales
+type LowStockProduct struct {
+	models.Product
+	UnitsSold     int      `json:"units_sold"`     // Units ordered in the sales window
+	DailyVelocity float64  `json:"daily_velocity"` // Average units sold per day in the window
+	DaysRemaining *float64 `json:"days_remaining"` // Days until stock runs out at the current velocity; nil without sales
+}
+
+// GetLowStockProducts returns products that are running out (admin only)
+// @Summary Get low-stock products
+// @Description Get products at or below the stock threshold, lowest stock first, with units sold over the last days and the estimated days until each runs out. Cancelled orders do not count as sales.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param threshold query int false "Stock threshold (default LOW_STOCK_THRESHOLD, 10)"
+// @Param days query int false "Sales window in days" default(30) minimum(1) maximum(365)
+// @Param limit query int false "Maximum number of products" default(100) minimum(1) maximum(500)
+// @Success 200 {object} map[string]interface{} "Low-stock products"
+// @Failure 400 {object} map[string]interface{} "Invalid threshold or window"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /products/low-stock [get]
+func GetLowStockProducts(c *fiber.Ctx) error {
+	threshold := c.QueryInt("threshold", services.LowStockThreshold())
+	if threshold < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "threshold cannot be negative",
+		})
+	}
+
+	days := c.QueryInt("days", 30)
+	if days < 1 || days > 365 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "days must be between 1 and 365",
+		})
+	}
+
+	limit := c.QueryInt("limit", 100)
+	if limit < 1 || limit > 500 {
+		limit = 100
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	sales := database.DB.Table("order_items oi").
+		Select("oi.product_id, SUM(oi.quantity) AS units_sold").
+		Joins("JOIN orders o ON o.id = oi.order_id").
+		Where("o.created_at >= ? AND o.status <> ?", since, "cancelled").
+		Group("oi.product_id")
+
+	var products []LowStockProduct
+	if err := database.DB.Model(&models.Product{}).
+		Select("products.*, COALESCE(sales.units_sold, 0) AS units_sold").
+		Joins("LEFT JOIN (?) sales ON sales.product_id = products.id", sales).
+		Where("products.stock <= ?", threshold).
+		Order("products.stock ASC").
+		Order("units_sold DESC").
+		Limit(limit).
+		Scan(&products).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to fetch low-stock products",
+		})
+	}
+
+	for i := range products {
+		products[i].DailyVelocity = float64(products[i].UnitsSold) / float64(days)
+		if products[i].DailyVelocity > 0 {
+			remaining := float64(max(products[i].Stock, 0)) / products[i].DailyVelocity
+			products[i].DaysRemaining = &remaining
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"success":   true,
+		"products":  products,
+		"threshold": threshold,
+		"days":      days,
+		"total":     len(products),
+	})
+}
+
+// StockAdjustment is a relative change to one product's stock
+type StockAdjustment struct {
+	ProductID string `json:"product_id" validate:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Delta     int    `json:"delta" validate:"required,min=-100000,max=100000" example:"-3"`
+	Reason    string `json:"reason,omitempty" validate:"omitempty,max=50" example:"damaged"` // Defaults to the batch reason
+}
+
+// StockAdjustRequest represents a batch of stock adjustments applied together
+type StockAdjustRequest struct {
+	Adjustments []StockAdjustment `json:"adjustments" validate:"required,min=1,max=500,dive"`
+	Reason      string            `json:"reason,omitempty" validate:"omitempty,max=50" example:"stock_count"` // Defaults to 'adjustment'
+	Reference   string            `json:"reference,omitempty" validate:"omitempty,max=100" example:"COUNT-2024-03"`
+}
+
+// StockAdjustResult is the stock of a product after a batch adjustment
+type StockAdjustResult struct {
+	ProductID     uuid.UUID `json:"product_id"`
+	Delta         int       `json:"delta"`
+	PreviousStock int       `json:"previous_stock"`
+	Stock         int       `json:"stock"`
+}
+
+// AdjustStock applies a batch of relative stock changes (admin only)
+// @Summary Adjust stock in bulk
+// @Description Apply relative stock changes to several products in one transaction. The whole batch is rejected if any product is missing, listed twice, or would end up with negative stock. Each change is recorded as a stock movement.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body StockAdjustRequest true "Stock adjustments"
+// @Success 200 {object} map[string]interface{} "Stock adjusted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 409 {object} map[string]interface{} "Adjustment would make stock negative"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /products/stock-adjust [post]
+func AdjustStock(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "Authentication required",
+		})
+	}
+
+	var req StockAdjustRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	batchReason := req.Reason
+	if batchReason == "" {
+		batchReason = "adjustment"
+	}
+
+	productIDs := make([]uuid.UUID, 0, len(req.Adjustments))
+	seen := make(map[uuid.UUID]bool, len(req.Adjustments))
+	for _, adjustment := range req.Adjustments {
+		productID := uuid.MustParse(adjustment.ProductID)
+		if seen[productID] {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Product listed more than once: " + adjustment.ProductID,
+			})
+		}
+		seen[productID] = true
+		productIDs = append(productIDs, productID)
+	}
+
+	results := make([]StockAdjustResult, 0, len(req.Adjustments))
+	var backInStock, lowStock []models.Product
+	threshold := services.LowStockThreshold()
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for i, adjustment := range req.Adjustments {
+			productID := productIDs[i]
+
+			// Relative, guarded update so concurrent stock changes are neither
+			// overwritten nor allowed to push stock below zero
+			result := tx.Model(&models.Product{}).
+				Where("id = ? AND stock + ? >= 0", productID, adjustment.Delta).
+				UpdateColumn("stock", gorm.Expr("stock + ?", adjustment.Delta))
+			if result.Error != nil {
+				return result.Error
+			}
+
+			var product models.Product
+			if err := tx.First(&product, productID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fiber.NewError(fiber.StatusNotFound, "Product not found: "+adjustment.ProductID)
+				}
+				return err
+			}
+			if result.RowsAffected == 0 {
+				return fiber.NewError(fiber.StatusConflict, fmt.Sprintf(
+					"Adjustment would make stock negative for product: %s (Stock: %d, Delta: %d)",
+					product.Name, product.Stock, adjustment.Delta))
+			}
+
+			reason := adjustment.Reason
+			if reason == "" {
+				reason = batchReason
+			}
+			if err := tx.Create(&models.StockMovement{
+				ProductID:  productID,
+				Delta:      adjustment.Delta,
+				StockAfter: product.Stock,
+				Reason:     reason,
+				Reference:  req.Reference,
+				CreatedBy:  &userID,
+			}).Error; err != nil {
+				return err
+			}
+
+			previousStock := product.Stock - adjustment.Delta
+			results = append(results, StockAdjustResult{
+				ProductID:     productID,
+				Delta:         adjustment.Delta,
+				PreviousStock: previousStock,
+				Stock:         product.Stock,
+			})
+
+			if previousStock <= 0 && product.Stock > 0 {
+				backInStock = append(backInStock, product)
+			}
+			if previousStock > threshold && product.Stock <= threshold {
+				lowStock = append(lowStock, product)
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			return fiberErrorResponse(c, err)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to adjust stock",
+		})
+	}
+
+	// Stock levels changed, so cached product listings and products are stale
+	invalidateCatalogCache(productIDs...)
+
+	for _, product := range backInStock {
+		go services.NotifyBackInStock(product)
+	}
+	go services.NotifyLowStock(lowStock)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Stock adjusted successfully",
+		"results": results,
+	})
+}
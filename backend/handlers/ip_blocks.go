@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/middleware"
+	"bachelor_backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// CreateIPBlockRequest represents the request to block an IP address
+type CreateIPBlockRequest struct {
+	IPAddress     string `json:"ip_address" example:"203.0.113.7"`
+	Reason        string `json:"reason" example:"Credential stuffing"`
+	DurationHours int    `json:"duration_hours" example:"24"` // 0 blocks permanently
+}
+
+// GetIPBlocks godoc
+// @Summary List IP blocks
+// @Description List blocked IP addresses (admin only). Expired blocks are omitted unless include_expired is set.
+// @Tags Security
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param include_expired query bool false "Include expired blocks"
+// @Success 200 {object} map[string]interface{} "IP blocks"
+// @Failure 401 {object} StandardErrorResponse "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} StandardErrorResponse "Internal server error"
+// @Router /security/blocks [get]
+func GetIPBlocks(c *fiber.Ctx) error {
+	query := database.DB.Order("created_at DESC")
+	if !c.QueryBool("include_expired", false) {
+		query = query.Where("expires_at IS NULL OR expires_at > ?", time.Now())
+	}
+
+	var blocks []models.IPBlock
+	if err := query.Find(&blocks).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to get IP blocks",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"blocks": blocks,
+			"total":  len(blocks),
+		},
+	})
+}
+
+// CreateIPBlock godoc
+// @Summary Block an IP address
+// @Description Deny an IP address access to the API (admin only). Blocking an already blocked IP replaces its reason and expiry.
+// @Tags Security
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateIPBlockRequest true "Block details"
+// @Success 201 {object} map[string]interface{} "IP blocked successfully"
+// @Failure 400 {object} StandardErrorResponse "Invalid IP address or duration"
+// @Failure 401 {object} StandardErrorResponse "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} StandardErrorResponse "Internal server error"
+// @Router /security/blocks [post]
+func CreateIPBlock(c *fiber.Ctx) error {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "User authentication required",
+		})
+	}
+
+	var req CreateIPBlockRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(req.IPAddress))
+	if ip == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "ip_address must be a valid IP address",
+		})
+	}
+	if req.DurationHours < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "duration_hours cannot be negative",
+		})
+	}
+	if ip.Equal(net.ParseIP(middleware.ClientIP(c))) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Cannot block your own IP address",
+		})
+	}
+
+	block, err := blockIP(ip.String(), req.Reason, req.DurationHours, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to block IP address",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "IP blocked successfully",
+		"data":    block,
+	})
+}
+
+// DeleteIPBlock godoc
+// @Summary Unblock an IP address
+// @Description Remove an IP block (admin only). The IP regains access immediately.
+// @Tags Security
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Block ID" format(uuid)
+// @Success 200 {object} map[string]interface{} "IP unblocked successfully"
+// @Failure 400 {object} StandardErrorResponse "Invalid block ID"
+// @Failure 401 {object} StandardErrorResponse "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} StandardErrorResponse "Block not found"
+// @Failure 500 {object} StandardErrorResponse "Internal server error"
+// @Router /security/blocks/{id} [delete]
+func DeleteIPBlock(c *fiber.Ctx) error {
+	blockID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid block ID format",
+		})
+	}
+
+	result := database.DB.Where("id = ?", blockID).Delete(&models.IPBlock{})
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to remove IP block",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Block not found",
+		})
+	}
+
+	refreshIPBlocklist()
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "IP unblocked successfully",
+	})
+}
+
+// blockIP creates or replaces the block for ip and applies it right away.
+// A durationHours of 0 blocks permanently.
+func blockIP(ip, reason string, durationHours int, createdBy uuid.UUID) (models.IPBlock, error) {
+	block := models.IPBlock{
+		IPAddress: ip,
+		Reason:    reason,
+		CreatedBy: &createdBy,
+	}
+	if durationHours > 0 {
+		expiresAt := time.Now().Add(time.Duration(durationHours) * time.Hour)
+		block.ExpiresAt = &expiresAt
+	}
+
+	if err := database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "ip_address"}},
+		DoUpdates: clause.AssignmentColumns([]string{"reason", "expires_at", "created_by", "updated_at"}),
+	}).Create(&block).Error; err != nil {
+		return models.IPBlock{}, err
+	}
+
+	refreshIPBlocklist()
+	return block, nil
+}
+
+// refreshIPBlocklist reloads the blocklist cache after a change so it applies
+// without waiting for the next periodic reload
+func refreshIPBlocklist() {
+	if err := middleware.IPBlocklistInstance.Refresh(); err != nil {
+		log.Printf("Failed to refresh IP blocklist: %v", err)
+	}
+}
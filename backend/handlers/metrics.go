@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"bytes"
+	"log"
+
+	"bachelor_backend/database"
+	"bachelor_backend/models"
+	"bachelor_backend/pkg/metrics"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// activeOrderStatuses are the order statuses reported by the orders_active gauge
+var activeOrderStatuses = []string{"pending", "processing", "shipped"}
+
+// refreshActiveOrders sets the orders_active gauge from the database
+func refreshActiveOrders() error {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	if err := database.DB.Model(&models.Order{}).
+		Select("status, COUNT(*) AS count").
+		Where("status IN ?", activeOrderStatuses).
+		Group("status").
+		Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	for _, status := range activeOrderStatuses {
+		metrics.OrdersActive.Set(float64(counts[status]), status)
+	}
+	return nil
+}
+
+// GetMetrics exposes service metrics for Prometheus
+// @Summary Prometheus metrics
+// @Description Metrics in the Prometheus text exposition format: request counts by method, route and status (http_requests_total), request duration (http_request_duration_seconds), database statements (db_queries_total), ML service call latency (ml_request_duration_seconds) and orders not yet delivered or cancelled by status (orders_active).
+// @Tags Health
+// @Produce plain
+// @Success 200 {string} string "Metrics in Prometheus text format"
+// @Router /metrics [get]
+func GetMetrics(c *fiber.Ctx) error {
+	if err := refreshActiveOrders(); err != nil {
+		// Serve the last known value rather than failing the scrape
+		log.Printf("Failed to refresh active orders metric: %v", err)
+	}
+
+	var buf bytes.Buffer
+	metrics.WriteAll(&buf)
+
+	c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	return c.Send(buf.Bytes())
+}
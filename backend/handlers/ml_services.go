@@ -1,16 +1,30 @@
 package handlers
 
 import (
+	"errors"
+	"log"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"bachelor_backend/database"
 	"bachelor_backend/middleware"
+	"bachelor_backend/models"
+	"bachelor_backend/pkg/money"
 	"bachelor_backend/services"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GetProductSentiment analyzes sentiment for a specific product
 // @Summary Analyze product sentiment
-// @Description Get sentiment analysis for a specific product based on user comments
+// @Description Get the sentiment of a product's user comments. The stored snapshot is served while it is younger than SENTIMENT_SNAPSHOT_TTL_MINUTES and recomputed by the ML service otherwise; if that fails, the expired snapshot is served with stale set.
 // @Tags ML
 // @Accept json
 // @Produce json
@@ -39,7 +53,7 @@ func GetProductSentiment(c *fiber.Ctx) error {
 		})
 	}
 
-	sentiment, err := services.MLService.AnalyzeProductSentiment(productID)
+	sentiment, stale, err := services.GetSentimentSnapshot(productID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
@@ -50,6 +64,75 @@ func GetProductSentiment(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{
 		"success": true,
 		"data":    sentiment,
+		"stale":   stale,
+	})
+}
+
+// BatchSentimentRequest lists the products to get the sentiment of
+type BatchSentimentRequest struct {
+	ProductIDs []string `json:"product_ids" validate:"required,min=1,max=100,dive,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+}
+
+// GetBatchSentiment returns the sentiment of several products
+// @Summary Analyze sentiment of several products
+// @Description Get the sentiment of up to 100 products at once, keyed by product ID. Stored snapshots are served while fresh and the rest are analyzed by the ML service in one batch. Products that cannot be analyzed and have no stored snapshot are listed in unavailable.
+// @Tags ML
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body BatchSentimentRequest true "Product IDs"
+// @Success 200 {object} map[string]interface{} "Product sentiments retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /ml/sentiment/batch [post]
+func GetBatchSentiment(c *fiber.Ctx) error {
+	var req BatchSentimentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	var productIDs []uuid.UUID
+	if err := database.DB.Model(&models.Product{}).Where("id IN ?", req.ProductIDs).Pluck("id", &productIDs).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to fetch products",
+		})
+	}
+
+	sentiments, err := services.GetSentimentSnapshots(productIDs)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to analyze product sentiment: " + err.Error(),
+		})
+	}
+
+	notFound, unavailable := []string{}, []string{}
+	for _, id := range req.ProductIDs {
+		productID, _ := uuid.Parse(id)
+		if !slices.Contains(productIDs, productID) {
+			notFound = append(notFound, id)
+		} else if _, ok := sentiments[productID]; !ok {
+			unavailable = append(unavailable, id)
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"success":     true,
+		"data":        sentiments,
+		"not_found":   notFound,
+		"unavailable": unavailable,
 	})
 }
 
@@ -175,6 +258,134 @@ func SuggestProductTags(c *fiber.Ctx) error {
 	})
 }
 
+// ApplySuggestedTagsRequest lists the suggested tags an admin accepted for a product
+type ApplySuggestedTagsRequest struct {
+	Tags []string `json:"tags" validate:"required,min=1,max=20,dive,min=1,max=50" example:"wireless"`
+}
+
+// ApplySuggestedTags attaches accepted tag suggestions to a product
+// @Summary Apply suggested tags
+// @Description Attach the accepted tags from an auto-tagging suggestion to a product (admin only). Tags that do not exist yet are created. Tags the product already has are left as they are; the rest are recorded with source 'ml'.
+// @Tags ML
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Product ID (UUID)"
+// @Param request body ApplySuggestedTagsRequest true "Accepted tag names"
+// @Success 200 {object} map[string]interface{} "Suggested tags applied successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid product ID or tag names"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /ml/auto-tagging/apply/{id} [post]
+func ApplySuggestedTags(c *fiber.Ctx) error {
+	productID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid product ID",
+		})
+	}
+
+	var req ApplySuggestedTagsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	// Suggestions may repeat a tag in different case
+	var names []string
+	for _, name := range req.Tags {
+		name = strings.TrimSpace(name)
+		if slugify(name) == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Tag name must contain letters or digits: " + strconv.Quote(name),
+			})
+		}
+		if !slices.ContainsFunc(names, func(existing string) bool { return strings.EqualFold(existing, name) }) {
+			names = append(names, name)
+		}
+	}
+
+	var product models.Product
+	if err := database.DB.First(&product, "id = ?", productID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"success": false,
+				"error":   "Product not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to fetch product",
+		})
+	}
+
+	createdTags, appliedTags, existingTags := []models.Tag{}, []models.Tag{}, []models.Tag{}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, name := range names {
+			var tag models.Tag
+			err := tx.Where("LOWER(name) = LOWER(?)", name).First(&tag).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				slug, err := uniqueTagSlug(tx, name)
+				if err != nil {
+					return err
+				}
+				tag = models.Tag{Name: name, Slug: slug}
+				if err := tx.Create(&tag).Error; err != nil {
+					return err
+				}
+				createdTags = append(createdTags, tag)
+			} else if err != nil {
+				return err
+			}
+
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ProductTag{
+				ProductID: product.ID,
+				TagID:     tag.ID,
+				Source:    productTagSourceML,
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				appliedTags = append(appliedTags, tag)
+			} else {
+				existingTags = append(existingTags, tag)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to apply suggested tags to product %s: %v", productID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to apply suggested tags",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"product_id":     product.ID,
+			"applied_tags":   appliedTags,
+			"created_tags":   createdTags,
+			"already_tagged": existingTags,
+		},
+	})
+}
+
 // AutoTagProducts automatically tags products that need tags
 // @Summary Auto-tag products
 // @Description Automatically assign tags to products that don't have sufficient tags
@@ -185,6 +396,7 @@ func SuggestProductTags(c *fiber.Ctx) error {
 // @Param limit query int false "Maximum number of products to tag" default(50)
 // @Success 200 {object} map[string]interface{} "Products auto-tagged successfully"
 // @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /ml/auto-tagging/auto-tag [post]
 func AutoTagProducts(c *fiber.Ctx) error {
@@ -380,6 +592,7 @@ func GetDiscountInsights(c *fiber.Ctx) error {
 // @Security BearerAuth
 // @Success 200 {object} map[string]interface{} "ML services initialized successfully"
 // @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /ml/initialize-services [post]
 func InitializeMLServices(c *fiber.Ctx) error {
@@ -404,3 +617,573 @@ func InitializeMLServices(c *fiber.Ctx) error {
 		"message": "All ML services initialized successfully",
 	})
 }
+
+// PreviewRecommendations previews ML recommendations for a user without persisting them
+// @Summary Preview recommendations
+// @Description Call the ML service directly to preview recommendations for any user and algorithm without storing the results (admin only)
+// @Tags ML
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user_id query string false "Target user ID (UUID), defaults to the caller"
+// @Param algorithm query string false "Algorithm (collaborative, content_based, hybrid, popular)" default(hybrid)
+// @Param limit query int false "Number of recommendations" default(10)
+// @Success 200 {object} map[string]interface{} "Recommendation preview generated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request parameters"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin access required"
+// @Failure 502 {object} map[string]interface{} "ML service unavailable"
+// @Router /ml/recommendations/preview [get]
+func PreviewRecommendations(c *fiber.Ctx) error {
+	callerID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+	}
+
+	targetUserID := callerID
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		parsed, err := uuid.Parse(userIDStr)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid user ID",
+			})
+		}
+		targetUserID = parsed
+	}
+
+	algorithm := c.Query("algorithm", "hybrid")
+	validAlgorithms := map[string]bool{
+		"collaborative": true,
+		"content_based": true,
+		"hybrid":        true,
+		"popular":       true,
+	}
+	if !validAlgorithms[algorithm] {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid algorithm. Valid algorithms: collaborative, content_based, hybrid, popular",
+		})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "10"))
+	if err != nil || limit < 1 || limit > 50 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Limit must be between 1 and 50",
+		})
+	}
+
+	preview, err := services.MLService.PreviewRecommendations(targetUserID, algorithm, limit)
+	if err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to preview recommendations: " + err.Error(),
+		})
+	}
+
+	// Attach product details so the preview can be inspected directly
+	productIDs := make([]uuid.UUID, 0, len(preview.Recommendations))
+	for _, rec := range preview.Recommendations {
+		if id, err := uuid.Parse(rec.ProductID); err == nil {
+			productIDs = append(productIDs, id)
+		}
+	}
+
+	productsByID := make(map[string]models.Product)
+	if len(productIDs) > 0 {
+		var products []models.Product
+		database.DB.Where("id IN ?", productIDs).Find(&products)
+		for _, product := range products {
+			productsByID[product.ID.String()] = product
+		}
+	}
+
+	items := make([]fiber.Map, 0, len(preview.Recommendations))
+	for _, rec := range preview.Recommendations {
+		item := fiber.Map{
+			"product_id": rec.ProductID,
+			"score":      rec.Score,
+			"algorithm":  rec.Algorithm,
+		}
+		if product, exists := productsByID[rec.ProductID]; exists {
+			item["product"] = product
+		}
+		items = append(items, item)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"user_id":         targetUserID,
+			"algorithm":       algorithm,
+			"recommendations": items,
+			"total":           len(items),
+			"persisted":       false,
+		},
+	})
+}
+
+// categorySentimentResult holds the outcome of one category sentiment lookup
+type categorySentimentResult struct {
+	Category         string                 `json:"category"`
+	Rank             int                    `json:"rank,omitempty"`
+	AverageSentiment float64                `json:"average_sentiment"`
+	TotalComments    int                    `json:"total_comments"`
+	Data             map[string]interface{} `json:"data,omitempty"`
+	Error            string                 `json:"error,omitempty"`
+}
+
+// maxConcurrentSentimentRequests bounds the fan-out to the ML service
+const maxConcurrentSentimentRequests = 4
+
+// CompareCategorySentiment compares sentiment across several categories
+// @Summary Compare category sentiment
+// @Description Analyze sentiment for several categories in parallel and rank them from most to least positive. Categories that fail are reported separately.
+// @Tags ML
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param categories query string true "Comma-separated list of categories (max 10)"
+// @Success 200 {object} map[string]interface{} "Category sentiment comparison retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid categories parameter"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 502 {object} map[string]interface{} "All category lookups failed"
+// @Router /ml/sentiment/compare [get]
+func CompareCategorySentiment(c *fiber.Ctx) error {
+	_, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+	}
+
+	// Parse and de-duplicate categories
+	seen := make(map[string]bool)
+	var categories []string
+	for _, category := range strings.Split(c.Query("categories"), ",") {
+		category = strings.TrimSpace(category)
+		if category == "" || seen[strings.ToLower(category)] {
+			continue
+		}
+		seen[strings.ToLower(category)] = true
+		categories = append(categories, category)
+	}
+
+	if len(categories) < 2 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "At least two distinct categories are required",
+		})
+	}
+	if len(categories) > 10 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "At most 10 categories can be compared at once",
+		})
+	}
+
+	results := make([]categorySentimentResult, len(categories))
+	semaphore := make(chan struct{}, maxConcurrentSentimentRequests)
+	var wg sync.WaitGroup
+
+	for i, category := range categories {
+		wg.Add(1)
+		go func(i int, category string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			result := categorySentimentResult{Category: category}
+			data, err := services.MLService.AnalyzeCategorySentiment(url.PathEscape(category))
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Data = data
+				if avg, ok := data["average_sentiment"].(float64); ok {
+					result.AverageSentiment = avg
+				}
+				if total, ok := data["total_comments"].(float64); ok {
+					result.TotalComments = int(total)
+				}
+			}
+			results[i] = result
+		}(i, category)
+	}
+	wg.Wait()
+
+	ranked := make([]categorySentimentResult, 0, len(results))
+	failed := make([]categorySentimentResult, 0)
+	for _, result := range results {
+		if result.Error != "" {
+			failed = append(failed, result)
+			continue
+		}
+		ranked = append(ranked, result)
+	}
+
+	if len(ranked) == 0 {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to analyze sentiment for all requested categories",
+			"failed":  failed,
+		})
+	}
+
+	// Most positive first; more comments break ties since they are more reliable
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].AverageSentiment != ranked[j].AverageSentiment {
+			return ranked[i].AverageSentiment > ranked[j].AverageSentiment
+		}
+		return ranked[i].TotalComments > ranked[j].TotalComments
+	})
+	for i := range ranked {
+		ranked[i].Rank = i + 1
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"ranking": ranked,
+			"failed":  failed,
+			"partial": len(failed) > 0,
+		},
+	})
+}
+
+// TasteProfileCategory represents a category the user gravitates towards
+type TasteProfileCategory struct {
+	Category     string  `json:"category"`
+	Score        float64 `json:"score"`
+	Interactions int64   `json:"interactions"`
+	Share        float64 `json:"share"`
+}
+
+// TasteProfileAffinity represents a tag or brand the user gravitates towards
+type TasteProfileAffinity struct {
+	Name         string  `json:"name"`
+	Score        float64 `json:"score"`
+	Interactions int64   `json:"interactions"`
+}
+
+// TasteProfilePriceSensitivity describes how price-conscious the user is
+type TasteProfilePriceSensitivity struct {
+	Level                string      `json:"level"` // 'high', 'medium', 'low', 'unknown'
+	AverageEngagedPrice  money.Cents `json:"average_engaged_price"`
+	AveragePurchasePrice money.Cents `json:"average_purchase_price"`
+	CategoryAveragePrice money.Cents `json:"category_average_price"`
+	PriceRatio           float64     `json:"price_ratio"`
+}
+
+// TasteProfile summarizes a user's shopping preferences
+type TasteProfile struct {
+	UserID            uuid.UUID                    `json:"user_id"`
+	TotalInteractions int64                        `json:"total_interactions"`
+	TopCategories     []TasteProfileCategory       `json:"top_categories"`
+	PriceSensitivity  TasteProfilePriceSensitivity `json:"price_sensitivity"`
+	PreferredTags     []TasteProfileAffinity       `json:"preferred_tags"`
+	BrandAffinity     []TasteProfileAffinity       `json:"brand_affinity"`
+}
+
+// interactionWeightSQL weights interactions by how strong a signal they are
+const interactionWeightSQL = `CASE ui.interaction_type
+	WHEN 'purchase' THEN 5
+	WHEN 'cart_add' THEN 3
+	WHEN 'like' THEN 2
+	WHEN 'wishlist' THEN 2
+	ELSE 1 END`
+
+// GetTasteProfile returns the authenticated user's taste profile
+// @Summary Get taste profile
+// @Description Summarize the user's preferences from their interaction history: top categories, price sensitivity, preferred tags and brand affinity
+// @Tags ML
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Taste profile retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /ml/taste-profile [get]
+func GetTasteProfile(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+	}
+
+	profile, err := buildTasteProfile(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to build taste profile: " + err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    profile,
+	})
+}
+
+// buildTasteProfile computes the taste profile from interactions and purchases
+func buildTasteProfile(userID uuid.UUID) (*TasteProfile, error) {
+	profile := &TasteProfile{
+		UserID:        userID,
+		TopCategories: []TasteProfileCategory{},
+		PreferredTags: []TasteProfileAffinity{},
+		BrandAffinity: []TasteProfileAffinity{},
+	}
+
+	if err := database.DB.Model(&models.UserInteraction{}).
+		Where("user_id = ?", userID).
+		Count(&profile.TotalInteractions).Error; err != nil {
+		return nil, err
+	}
+
+	// Top categories by weighted interactions
+	if err := database.DB.Raw(`
+		SELECT p.category AS category,
+			SUM(`+interactionWeightSQL+`) AS score,
+			COUNT(*) AS interactions
+		FROM user_interactions ui
+		JOIN products p ON p.id = ui.product_id
+		WHERE ui.user_id = ?
+		GROUP BY p.category
+		ORDER BY score DESC
+		LIMIT 5
+	`, userID).Scan(&profile.TopCategories).Error; err != nil {
+		return nil, err
+	}
+
+	var totalScore float64
+	for _, category := range profile.TopCategories {
+		totalScore += category.Score
+	}
+	if totalScore > 0 {
+		for i := range profile.TopCategories {
+			profile.TopCategories[i].Share = profile.TopCategories[i].Score / totalScore * 100
+		}
+	}
+
+	// Preferred tags from products the user engaged with
+	if err := database.DB.Raw(`
+		SELECT t.name AS name,
+			SUM(`+interactionWeightSQL+`) AS score,
+			COUNT(*) AS interactions
+		FROM user_interactions ui
+		JOIN product_tags pt ON pt.product_id = ui.product_id
+		JOIN tags t ON t.id = pt.tag_id
+		WHERE ui.user_id = ?
+		GROUP BY t.name
+		ORDER BY score DESC
+		LIMIT 10
+	`, userID).Scan(&profile.PreferredTags).Error; err != nil {
+		return nil, err
+	}
+
+	// Brand affinity from products with a known brand
+	if err := database.DB.Raw(`
+		SELECT p.brand AS name,
+			SUM(`+interactionWeightSQL+`) AS score,
+			COUNT(*) AS interactions
+		FROM user_interactions ui
+		JOIN products p ON p.id = ui.product_id
+		WHERE ui.user_id = ? AND p.brand IS NOT NULL AND p.brand <> ''
+		GROUP BY p.brand
+		ORDER BY score DESC
+		LIMIT 5
+	`, userID).Scan(&profile.BrandAffinity).Error; err != nil {
+		return nil, err
+	}
+
+	profile.PriceSensitivity = calculatePriceSensitivity(userID)
+
+	return profile, nil
+}
+
+// calculatePriceSensitivity compares what the user engages with to the average
+// price of the categories they browse
+func calculatePriceSensitivity(userID uuid.UUID) TasteProfilePriceSensitivity {
+	sensitivity := TasteProfilePriceSensitivity{Level: "unknown"}
+
+	database.DB.Raw(`
+		SELECT COALESCE(ROUND(AVG(p.price)), 0)
+		FROM user_interactions ui
+		JOIN products p ON p.id = ui.product_id
+		WHERE ui.user_id = ?
+	`, userID).Scan(&sensitivity.AverageEngagedPrice)
+
+	database.DB.Raw(`
+		SELECT COALESCE(ROUND(SUM(oi.price * oi.quantity) / NULLIF(SUM(oi.quantity), 0)), 0)
+		FROM order_items oi
+		JOIN orders o ON o.id = oi.order_id
+		WHERE o.user_id = ? AND o.status <> 'cancelled'
+	`, userID).Scan(&sensitivity.AveragePurchasePrice)
+
+	database.DB.Raw(`
+		SELECT COALESCE(ROUND(AVG(p.price)), 0)
+		FROM products p
+		WHERE p.category IN (
+			SELECT DISTINCT p2.category
+			FROM user_interactions ui
+			JOIN products p2 ON p2.id = ui.product_id
+			WHERE ui.user_id = ?
+		)
+	`, userID).Scan(&sensitivity.CategoryAveragePrice)
+
+	// Purchases are the stronger signal, fall back to browsing behaviour
+	userPrice := sensitivity.AveragePurchasePrice
+	if userPrice == 0 {
+		userPrice = sensitivity.AverageEngagedPrice
+	}
+	if userPrice == 0 || sensitivity.CategoryAveragePrice == 0 {
+		return sensitivity
+	}
+
+	sensitivity.PriceRatio = float64(userPrice) / float64(sensitivity.CategoryAveragePrice)
+	switch {
+	case sensitivity.PriceRatio < 0.8:
+		sensitivity.Level = "high"
+	case sensitivity.PriceRatio > 1.2:
+		sensitivity.Level = "low"
+	default:
+		sensitivity.Level = "medium"
+	}
+
+	return sensitivity
+}
+
+// UpdateUserPreferencesRequest represents the recommendation preferences payload
+type UpdateUserPreferencesRequest struct {
+	PreferredCategories []string     `json:"preferred_categories" validate:"omitempty,max=20,dive,min=1,max=100" example:"Electronics,Books"`
+	AvoidedCategories   []string     `json:"avoided_categories" validate:"omitempty,max=20,dive,min=1,max=100" example:"Clothing"`
+	MinPrice            *money.Cents `json:"min_price,omitempty" validate:"omitempty,min=0" swaggertype:"string" example:"10.00"`
+	MaxPrice            *money.Cents `json:"max_price,omitempty" validate:"omitempty,min=0" swaggertype:"string" example:"500.00"`
+}
+
+// GetUserPreferences returns the user's recommendation preferences
+// @Summary Get recommendation preferences
+// @Description Get the authenticated user's explicit recommendation preferences
+// @Tags ML
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Preferences retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Router /ml/preferences [get]
+func GetUserPreferences(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    loadUserPreference(userID),
+	})
+}
+
+// UpdateUserPreferences replaces the user's recommendation preferences
+// @Summary Update recommendation preferences
+// @Description Set preferred and avoided categories and a price range that recommendations must respect
+// @Tags ML
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body UpdateUserPreferencesRequest true "Recommendation preferences"
+// @Success 200 {object} map[string]interface{} "Preferences updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /ml/preferences [put]
+func UpdateUserPreferences(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+	}
+
+	var req UpdateUserPreferencesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	if req.MinPrice != nil && req.MaxPrice != nil && *req.MinPrice > *req.MaxPrice {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "min_price cannot be greater than max_price",
+		})
+	}
+
+	avoided := make(map[string]bool)
+	for _, category := range req.AvoidedCategories {
+		avoided[strings.ToLower(category)] = true
+	}
+	for _, category := range req.PreferredCategories {
+		if avoided[strings.ToLower(category)] {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Category cannot be both preferred and avoided: " + category,
+			})
+		}
+	}
+
+	preference := loadUserPreference(userID)
+	preference.PreferredCategories = req.PreferredCategories
+	preference.AvoidedCategories = req.AvoidedCategories
+	preference.MinPrice = req.MinPrice
+	preference.MaxPrice = req.MaxPrice
+
+	if err := database.DB.Save(&preference).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to save preferences",
+		})
+	}
+
+	// Drop stored recommendations that now conflict and regenerate so the new
+	// preferences take effect right away
+	if err := pruneRecommendationsForPreference(preference); err != nil {
+		log.Printf("Failed to prune recommendations for user %s: %v", userID, err)
+	}
+	generateMLRecommendations(userID, 10)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    preference,
+	})
+}
+
+// loadUserPreference returns the user's stored preferences or an empty set
+func loadUserPreference(userID uuid.UUID) models.UserPreference {
+	var preference models.UserPreference
+	if err := database.DB.Where("user_id = ?", userID).First(&preference).Error; err != nil {
+		return models.UserPreference{
+			UserID:              userID,
+			PreferredCategories: []string{},
+			AvoidedCategories:   []string{},
+		}
+	}
+	return preference
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"strconv"
+
+	"bachelor_backend/database"
+	"bachelor_backend/middleware"
+	"bachelor_backend/models"
+	"bachelor_backend/pkg/pagination"
+	"bachelor_backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// NotificationSuppressionRequest represents the request to stop notifying a user
+type NotificationSuppressionRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Reason string `json:"reason" validate:"required,oneof=bounce unsubscribe complaint manual" example:"bounce"`
+}
+
+// GetNotifications returns the authenticated user's notifications
+// @Summary Get notifications
+// @Description Get the authenticated user's in-app notifications, newest first. The unread count is returned in the body and the X-Unread-Count header for badge display.
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param unread query bool false "Only return unread notifications"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{} "Notifications retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /notifications [get]
+func GetNotifications(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	query := database.DB.Where("user_id = ?", userID).Order("created_at DESC")
+	if c.QueryBool("unread") {
+		query = query.Where("read = ?", false)
+	}
+
+	var unreadCount int64
+	database.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read = ?", userID, false).
+		Count(&unreadCount)
+
+	notifications, meta, err := pagination.Paginate[models.Notification](query, page, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch notifications",
+		})
+	}
+
+	c.Set("X-Unread-Count", strconv.FormatInt(unreadCount, 10))
+	return c.JSON(fiber.Map{
+		"notifications": notifications,
+		"unread_count":  unreadCount,
+		"pagination":    meta,
+	})
+}
+
+// MarkNotificationRead marks one of the user's notifications as read
+// @Summary Mark notification as read
+// @Description Mark one of the authenticated user's notifications as read
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Notification ID (UUID)"
+// @Success 200 {object} map[string]interface{} "Notification marked as read"
+// @Failure 400 {object} map[string]interface{} "Invalid notification ID"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 404 {object} map[string]interface{} "Notification not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /notifications/{id}/read [post]
+func MarkNotificationRead(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	notificationID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid notification ID",
+		})
+	}
+
+	result := database.DB.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", notificationID, userID).
+		Update("read", true)
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update notification",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Notification not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Notification marked as read",
+	})
+}
+
+// MarkAllNotificationsRead marks all of the user's notifications as read
+// @Summary Mark all notifications as read
+// @Description Mark every unread notification of the authenticated user as read
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Notifications marked as read"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /notifications/read-all [post]
+func MarkAllNotificationsRead(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	result := database.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read = ?", userID, false).
+		Update("read", true)
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update notifications",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":      "Notifications marked as read",
+		"marked_count": result.RowsAffected,
+	})
+}
+
+// GetNotificationDispatchStats returns notification dispatcher statistics
+// @Summary Get notification dispatch stats
+// @Description Get queue length, delivery counters and configuration of the notification dispatcher
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Dispatch statistics"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Router /notifications/dispatch/stats [get]
+func GetNotificationDispatchStats(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"stats": services.NotificationDispatcherInstance.GetStats(),
+	})
+}
+
+// AddNotificationSuppression adds a user to the notification suppression list
+// @Summary Suppress notifications for a user
+// @Description Stop delivering notifications to a user, e.g. after a bounce or unsubscribe
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body NotificationSuppressionRequest true "Suppression details"
+// @Success 201 {object} map[string]interface{} "User suppressed"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /notifications/suppressions [post]
+func AddNotificationSuppression(c *fiber.Ctx) error {
+	var req NotificationSuppressionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userID, _ := uuid.Parse(req.UserID)
+	suppression, err := services.SuppressNotifications(userID, req.Reason)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to suppress notifications",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":     "Notifications suppressed",
+		"suppression": suppression,
+	})
+}
+
+// RemoveNotificationSuppression removes a user from the notification suppression list
+// @Summary Remove notification suppression
+// @Description Resume delivering notifications to a previously suppressed user
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path string true "User ID (UUID)"
+// @Success 200 {object} map[string]interface{} "Suppression removed"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "User is not suppressed"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /notifications/suppressions/{user_id} [delete]
+func RemoveNotificationSuppression(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("user_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	removed, err := services.UnsuppressNotifications(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to remove suppression",
+		})
+	}
+
+	if !removed {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User is not suppressed",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Suppression removed",
+	})
+}
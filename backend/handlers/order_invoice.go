@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"bachelor_backend/database"
+	"bachelor_backend/middleware"
+	"bachelor_backend/models"
+	"bachelor_backend/pkg/money"
+	"bachelor_backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetOrderInvoice returns the invoice for an order
+// @Summary Get order invoice
+// @Description Get the invoice for an order with line items, discounts, grand total and the shipping address. Returns JSON by default, or a PDF with format=pdf. Customers can fetch their own orders; admins can fetch any order.
+// @Tags Orders
+// @Accept json
+// @Produce json,application/pdf
+// @Security BearerAuth
+// @Param id path string true "Order ID (UUID)"
+// @Param format query string false "Invoice format" Enums(json, pdf) default(json)
+// @Success 200 {object} services.Invoice "Invoice"
+// @Failure 400 {object} map[string]interface{} "Invalid order ID or format"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 404 {object} map[string]interface{} "Order not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /orders/{id}/invoice [get]
+func GetOrderInvoice(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid order ID",
+		})
+	}
+
+	format := c.Query("format", "json")
+	if format != "json" && format != "pdf" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid format, must be json or pdf",
+		})
+	}
+
+	var order models.Order
+	query := database.DB.Where("id = ?", id).
+		Preload("OrderItems.Product", includeDeletedProducts).
+		Preload("User")
+	if !middleware.IsAdmin(c) {
+		query = query.Where("user_id = ?", userID)
+	}
+	if err := query.First(&order).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Order not found",
+		})
+	}
+
+	var couponCode string
+	if order.CouponID != nil {
+		var coupon models.Discount
+		if err := database.DB.Select("code").First(&coupon, "id = ?", *order.CouponID).Error; err == nil && coupon.Code != nil {
+			couponCode = *coupon.Code
+		}
+	}
+
+	var refunded money.Cents
+	database.DB.Model(&models.Refund{}).
+		Where("order_id = ?", order.ID).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&refunded)
+
+	invoice := services.BuildInvoice(order, order.User, couponCode, refunded)
+
+	if format == "json" {
+		return c.JSON(invoice)
+	}
+
+	pdf, err := services.RenderInvoicePDF(invoice)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to render invoice",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+invoice.InvoiceNumber+`.pdf"`)
+	return c.Send(pdf)
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"bachelor_backend/database"
+	"bachelor_backend/middleware"
+	"bachelor_backend/models"
+	"bachelor_backend/pkg/money"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RefundOrderRequest represents the request to refund a delivered order.
+// Without items every remaining quantity is refunded.
+type RefundOrderRequest struct {
+	Reason string                   `json:"reason" validate:"required,min=3,max=500" example:"Item arrived damaged"`
+	Items  []RefundOrderItemRequest `json:"items,omitempty" validate:"omitempty,dive"`
+	Amount *money.Cents             `json:"amount,omitempty" validate:"omitempty,gt=0" swaggertype:"string" example:"19.99"` // Overrides the amount calculated from the items
+}
+
+// RefundOrderItemRequest represents a quantity of an order item to refund
+type RefundOrderItemRequest struct {
+	OrderItemID string `json:"order_item_id" validate:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Quantity    int    `json:"quantity" validate:"required,min=1" example:"1"`
+}
+
+// RefundOrder refunds a delivered order in full or in part (admin only)
+// @Summary Refund order
+// @Description Refund a delivered order, in full or by order item (admin access required). Stock is restored for the refunded quantities and the order moves to refunded or partially_refunded. The refunded amount defaults to the items' share of the paid total and can never exceed what remains of it.
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Order ID (UUID)"
+// @Param request body RefundOrderRequest true "Refund details"
+// @Success 201 {object} map[string]interface{} "Order refunded successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request, order not refundable or amount exceeds paid total"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Order not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /orders/{id}/refund [post]
+func RefundOrder(c *fiber.Ctx) error {
+	adminID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid order ID",
+		})
+	}
+
+	var req RefundOrderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var order models.Order
+	var refund models.Refund
+	var refundedTotal money.Cents
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the order so concurrent refunds cannot exceed the paid total
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("OrderItems").
+			First(&order, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, "Order not found")
+			}
+			return err
+		}
+
+		if !isRefundableStatus(order.Status) {
+			return fiber.NewError(fiber.StatusBadRequest, "Only delivered orders can be refunded, order is "+order.Status)
+		}
+
+		// Quantities and money already refunded
+		var alreadyRefunded []struct {
+			OrderItemID uuid.UUID
+			Quantity    int
+		}
+		if err := tx.Model(&models.RefundItem{}).
+			Select("refund_items.order_item_id, SUM(refund_items.quantity) AS quantity").
+			Joins("JOIN refunds ON refunds.id = refund_items.refund_id").
+			Where("refunds.order_id = ?", order.ID).
+			Group("refund_items.order_item_id").
+			Scan(&alreadyRefunded).Error; err != nil {
+			return err
+		}
+		refundedQuantities := make(map[uuid.UUID]int, len(alreadyRefunded))
+		for _, row := range alreadyRefunded {
+			refundedQuantities[row.OrderItemID] = row.Quantity
+		}
+
+		if err := tx.Model(&models.Refund{}).
+			Where("order_id = ?", order.ID).
+			Select("COALESCE(SUM(amount), 0)").
+			Scan(&refundedTotal).Error; err != nil {
+			return err
+		}
+
+		// Work out how much of each item to refund
+		itemsByID := make(map[uuid.UUID]models.OrderItem, len(order.OrderItems))
+		for _, item := range order.OrderItems {
+			itemsByID[item.ID] = item
+		}
+
+		refundQuantities := make(map[uuid.UUID]int)
+		if len(req.Items) == 0 {
+			for _, item := range order.OrderItems {
+				if remaining := item.Quantity - refundedQuantities[item.ID]; remaining > 0 {
+					refundQuantities[item.ID] = remaining
+				}
+			}
+		} else {
+			for _, reqItem := range req.Items {
+				itemID, _ := uuid.Parse(reqItem.OrderItemID)
+				item, exists := itemsByID[itemID]
+				if !exists {
+					return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Order item %s does not belong to this order", reqItem.OrderItemID))
+				}
+				if _, duplicate := refundQuantities[itemID]; duplicate {
+					return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Order item %s is listed more than once", reqItem.OrderItemID))
+				}
+				if remaining := item.Quantity - refundedQuantities[itemID]; reqItem.Quantity > remaining {
+					return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Cannot refund %d of order item %s, only %d left to refund", reqItem.Quantity, reqItem.OrderItemID, remaining))
+				}
+				refundQuantities[itemID] = reqItem.Quantity
+			}
+		}
+
+		// Default to the items' share of the paid total, so discounts and tax are refunded
+		// proportionally; shipping is only refunded with an explicit amount
+		var itemsSubtotal money.Cents
+		for itemID, quantity := range refundQuantities {
+			itemsSubtotal += itemsByID[itemID].Price.Mul(quantity)
+		}
+		amount := itemsSubtotal
+		if order.Subtotal > 0 {
+			amount = itemsSubtotal.MulRate(float64(order.Total-order.ShippingCost) / float64(order.Subtotal))
+		}
+		if req.Amount != nil {
+			amount = *req.Amount
+		}
+
+		refundable := order.Total - refundedTotal
+		if amount <= 0 && len(refundQuantities) == 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "Nothing left to refund")
+		}
+		if amount > refundable {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Refund amount %s exceeds the remaining paid total %s", amount, refundable))
+		}
+
+		refund = models.Refund{
+			OrderID:   order.ID,
+			Amount:    amount,
+			Reason:    req.Reason,
+			CreatedBy: &adminID,
+		}
+		for itemID, quantity := range refundQuantities {
+			refund.Items = append(refund.Items, models.RefundItem{
+				OrderItemID: itemID,
+				Quantity:    quantity,
+			})
+		}
+		if err := tx.Create(&refund).Error; err != nil {
+			return err
+		}
+
+		// Return refunded quantities to stock, including for products since removed from the catalog
+		for itemID, quantity := range refundQuantities {
+			var product models.Product
+			productID := itemsByID[itemID].ProductID
+			if err := tx.Unscoped().Model(&models.Product{}).Where("id = ?", productID).
+				UpdateColumn("stock", gorm.Expr("stock + ?", quantity)).Error; err != nil {
+				return err
+			}
+			if variantID := itemsByID[itemID].VariantID; variantID != nil {
+				if err := tx.Model(&models.ProductVariant{}).Where("id = ?", *variantID).
+					UpdateColumn("stock", gorm.Expr("stock + ?", quantity)).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Unscoped().First(&product, "id = ?", productID).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&models.StockMovement{
+				ProductID:  productID,
+				Delta:      quantity,
+				StockAfter: product.Stock,
+				Reason:     "refund",
+				Reference:  refund.ID.String(),
+				CreatedBy:  &adminID,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		// Fully refunded once every item has been returned or the whole payment refunded
+		refundedTotal += amount
+		fullyRefunded := refundedTotal >= order.Total
+		if !fullyRefunded {
+			fullyRefunded = true
+			for _, item := range order.OrderItems {
+				if refundedQuantities[item.ID]+refundQuantities[item.ID] < item.Quantity {
+					fullyRefunded = false
+					break
+				}
+			}
+		}
+
+		newStatus := "partially_refunded"
+		if fullyRefunded {
+			newStatus = "refunded"
+			if err := releaseOrderDiscounts(tx, order); err != nil {
+				return err
+			}
+		}
+		if err := recordStatusChange(tx, order.ID, order.Status, newStatus, &adminID, req.Reason); err != nil {
+			return err
+		}
+		order.Status = newStatus
+		return tx.Model(&order).Update("status", newStatus).Error
+	})
+
+	if err != nil {
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			return c.Status(fiberErr.Code).JSON(fiber.Map{
+				"error": fiberErr.Message,
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to refund order",
+		})
+	}
+
+	// Stock levels changed, so cached product listings and products are stale
+	invalidateCatalogCache(orderProductIDs(order)...)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":        "Order refunded successfully",
+		"refund":         refund,
+		"order_status":   order.Status,
+		"total_refunded": refundedTotal,
+		"paid_total":     order.Total,
+	})
+}
@@ -1,39 +1,68 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"log"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"bachelor_backend/database"
 	"bachelor_backend/middleware"
 	"bachelor_backend/models"
+	"bachelor_backend/pkg/money"
+	"bachelor_backend/pkg/pagination"
+	"bachelor_backend/services"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // CreateOrderRequest represents the request to create an order
 type CreateOrderRequest struct {
 	PaymentMethod   string   `json:"payment_method" validate:"required,oneof=credit_card debit_card paypal bank_transfer" example:"credit_card"`
-	ShippingAddress string   `json:"shipping_address" validate:"required,min=10,max=500" example:"123 Main St, City, State 12345"`
+	ShippingAddress string   `json:"shipping_address,omitempty" validate:"omitempty,min=10,max=500" example:"123 Main St, City, State 12345"`
+	AddressID       string   `json:"address_id,omitempty" validate:"omitempty,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`               // Saved address; defaults to the user's default address when neither is given
 	CartItemIDs     []string `json:"cart_item_ids,omitempty" example:"123e4567-e89b-12d3-a456-426614174000,456e7890-e89b-12d3-a456-426614174001"` // Optional: specific cart items to order (as string UUIDs)
+	Currency        string   `json:"currency,omitempty" validate:"omitempty,len=3,alpha" example:"EUR"`                                           // Display currency shown at checkout; amounts are charged in the base currency
 }
 
 // UpdateOrderStatusRequest represents the request to update order status
 type UpdateOrderStatusRequest struct {
 	Status string `json:"status" validate:"required,oneof=pending processing shipped delivered cancelled" example:"processing"`
+	Note   string `json:"note,omitempty" validate:"omitempty,max=500" example:"Handed over to the courier"`
+}
+
+// CancelOrderRequest represents the request to cancel an order or some of its items
+type CancelOrderRequest struct {
+	Items []CancelOrderItemRequest `json:"items,omitempty" validate:"omitempty,dive"`
+}
+
+// CancelOrderItemRequest identifies an order item and how many units of it to cancel
+type CancelOrderItemRequest struct {
+	OrderItemID string `json:"order_item_id" validate:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Quantity    int    `json:"quantity,omitempty" validate:"omitempty,min=1" example:"1"` // Defaults to the full item quantity
 }
 
 // GetOrders returns the user's order history
 // @Summary Get user orders
-// @Description Get paginated list of user's order history with order items
+// @Description Get paginated list of user's order history with order items. Pass cursor (empty for the first page) to use cursor pagination instead of page numbers; it requires sort=date and returns next_cursor instead of totals.
 // @Tags Orders
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param page query int false "Page number" default(1)
+// @Param cursor query string false "Opaque cursor from pagination.next_cursor; empty for the first page"
 // @Param limit query int false "Items per page" default(10)
+// @Param sort query string false "Sort field (date, total)" default(date)
+// @Param order query string false "Sort order (asc, desc)" default(desc)
+// @Param expand query string false "Related data to include (items.product, items, none)" default(items.product)
 // @Success 200 {object} map[string]interface{} "Orders retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid sort, order, expand or cursor parameter"
 // @Failure 401 {object} map[string]interface{} "User not authenticated"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /orders [get]
@@ -47,40 +76,100 @@ func GetOrders(c *fiber.Ctx) error {
 
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	limit, _ := strconv.Atoi(c.Query("limit", "10"))
-	offset := (page - 1) * limit
 
-	var orders []models.Order
-	var total int64
+	useCursor := usesCursor(c)
+	cursor, err := decodeCursor(c.Query("cursor"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid cursor",
+		})
+	}
 
-	// Count total orders first (without preload to avoid issues)
-	if err := database.DB.Model(&models.Order{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to count orders",
+	// Map public sort names to columns
+	sortColumns := map[string]string{
+		"date":  "created_at",
+		"total": "total",
+	}
+	sortColumn, validSort := sortColumns[c.Query("sort", "date")]
+	if !validSort {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid sort field. Valid fields: date, total",
+		})
+	}
+
+	sortOrder := strings.ToLower(c.Query("order", "desc"))
+	if sortOrder != "asc" && sortOrder != "desc" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid sort order. Valid orders: asc, desc",
+		})
+	}
+
+	if useCursor && sortColumn != "created_at" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Cursor pagination requires sort=date",
+		})
+	}
+
+	expand := c.Query("expand", "items.product")
+	if expand != "items.product" && expand != "items" && expand != "none" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid expand value. Valid values: items.product, items, none",
+		})
+	}
+
+	if useCursor {
+		var orders []models.Order
+		limit = cursorLimit(c, 10)
+		if err := applyCursor(preloadOrderExpansion(database.DB.Where("user_id = ?", userID), expand), cursor, sortOrder, limit).
+			Find(&orders).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch orders",
+			})
+		}
+
+		var nextCursor *string
+		orders, nextCursor = cursorPage(orders, limit, func(o models.Order) (time.Time, uuid.UUID) {
+			return o.CreatedAt, o.ID
+		})
+		return c.JSON(fiber.Map{
+			"orders": orders,
+			"pagination": fiber.Map{
+				"limit":       limit,
+				"next_cursor": nextCursor,
+				"has_more":    nextCursor != nil,
+			},
 		})
 	}
 
-	// Get orders with preload
-	if err := database.DB.Where("user_id = ?", userID).
-		Preload("OrderItems.Product").
-		Order("created_at DESC").
-		Offset(offset).Limit(limit).
-		Find(&orders).Error; err != nil {
+	// Get orders, preloading only what the caller asked for
+	query := preloadOrderExpansion(database.DB.Where("user_id = ?", userID), expand).
+		Order(sortColumn + " " + sortOrder).
+		Order("id " + sortOrder)
+
+	orders, meta, err := pagination.Paginate[models.Order](query, page, limit)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch orders",
 		})
 	}
 
 	return c.JSON(fiber.Map{
-		"orders": orders,
-		"pagination": fiber.Map{
-			"page":        page,
-			"limit":       limit,
-			"total":       total,
-			"total_pages": (total + int64(limit) - 1) / int64(limit),
-		},
+		"orders":     orders,
+		"pagination": meta,
 	})
 }
 
+// preloadOrderExpansion preloads the order relations selected by the expand parameter
+func preloadOrderExpansion(query *gorm.DB, expand string) *gorm.DB {
+	switch expand {
+	case "items.product":
+		return query.Preload("OrderItems.Product", includeDeletedProducts)
+	case "items":
+		return query.Preload("OrderItems")
+	}
+	return query
+}
+
 // GetOrder returns a specific order by ID
 // @Summary Get order by ID
 // @Description Get detailed information about a specific order
@@ -112,7 +201,8 @@ func GetOrder(c *fiber.Ctx) error {
 
 	var order models.Order
 	if err := database.DB.Where("id = ? AND user_id = ?", id, userID).
-		Preload("OrderItems.Product").
+		Preload("OrderItems.Product", includeDeletedProducts).
+		Preload("Refunds.Items").
 		First(&order).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Order not found",
@@ -124,7 +214,7 @@ func GetOrder(c *fiber.Ctx) error {
 
 // CreateOrder creates a new order from the user's cart
 // @Summary Create order from cart
-// @Description Create a new order from the user's current cart items or specific cart items with atomic stock management
+// @Description Create a new order from the user's current cart items or specific cart items with atomic stock management. Active discounts are applied to each item under the discount stacking policy, and a coupon applied to the cart is re-validated and redeemed. Estimated tax and shipping are added as in the cart summary. Units reserved with /cart/reserve are consumed; units reserved by other users are not available. The order ships to the inline shipping_address or the saved address_id (the default address otherwise), stored on the order as a snapshot. The display currency (the base currency unless currency is given) and its exchange rate at the time of purchase are stored on the order too.
 // @Tags Orders
 // @Accept json
 // @Produce json
@@ -135,6 +225,7 @@ func GetOrder(c *fiber.Ctx) error {
 // @Failure 401 {object} map[string]interface{} "User not authenticated"
 // @Failure 404 {object} map[string]interface{} "Cart not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 503 {object} map[string]interface{} "Exchange rates are unavailable"
 // @Router /orders [post]
 func CreateOrder(c *fiber.Ctx) error {
 	userID, ok := middleware.GetUserID(c)
@@ -160,6 +251,51 @@ func CreateOrder(c *fiber.Ctx) error {
 		})
 	}
 
+	// Resolve the shipping address: inline text, a saved address, or the default address
+	if req.ShippingAddress != "" && req.AddressID != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Provide either shipping_address or address_id, but not both",
+		})
+	}
+
+	shippingAddress := strings.TrimSpace(req.ShippingAddress)
+	var shippingAddressID *uuid.UUID
+	if shippingAddress == "" {
+		var address models.Address
+		query := database.DB.Where("user_id = ?", userID)
+		if req.AddressID != "" {
+			query = query.Where("id = ?", req.AddressID)
+		} else {
+			query = query.Where("is_default = ?", true)
+		}
+		if err := query.First(&address).Error; err != nil {
+			message := "Address not found"
+			if req.AddressID == "" {
+				message = "A shipping address is required: provide shipping_address or address_id, or save a default address"
+			}
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   message,
+			})
+		}
+		shippingAddress = formatAddress(address)
+		shippingAddressID = &address.ID
+	}
+
+	// Snapshot the display currency's exchange rate at the time of purchase
+	currency := services.BaseCurrency()
+	exchangeRate := 1.0
+	if req.Currency != "" {
+		var err error
+		if currency, err = services.NormalizeCurrency(req.Currency); err == nil {
+			exchangeRate, _, err = services.CurrencyConverterInstance.Rate(services.BaseCurrency(), currency)
+		}
+		if err != nil {
+			return currencyErrorResponse(c, err)
+		}
+	}
+
 	// Parse and validate cart item IDs if provided
 	var cartItemIDs []uuid.UUID
 	if len(req.CartItemIDs) > 0 {
@@ -186,9 +322,10 @@ func CreateOrder(c *fiber.Ctx) error {
 
 	// Get user's cart with row-level locking to prevent concurrent modifications
 	var cart models.ShoppingCart
-	if err := tx.Set("gorm:query_option", "FOR UPDATE").
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
 		Where("user_id = ?", userID).
 		Preload("CartItems.Product").
+		Preload("CartItems.Variant").
 		First(&cart).Error; err != nil {
 		tx.Rollback()
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
@@ -234,41 +371,191 @@ func CreateOrder(c *fiber.Ctx) error {
 		})
 	}
 
+	// Lock the user's stock reservations before the products, the same order the
+	// expiry sweep uses; reserved units are consumed by this order
+	var reservations []models.StockReservation
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).
+		Find(&reservations).Error; err != nil {
+		tx.Rollback()
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to verify product availability",
+		})
+	}
+	reservedQuantities := make(map[uuid.UUID]int, len(reservations))
+	for _, reservation := range reservations {
+		reservedQuantities[reservation.ProductID] = reservation.Quantity
+	}
+
 	// Validate stock availability with row-level locking and atomic updates
-	var total float64
 	stockUpdates := make(map[uuid.UUID]int) // Track stock updates for rollback if needed
+	lockedProducts := make(map[uuid.UUID]models.Product, len(itemsToOrder))
+
+	for i, item := range itemsToOrder {
+		// Lock the product row to prevent concurrent stock modifications; a product
+		// can appear on several lines, one per variant
+		product, locked := lockedProducts[item.ProductID]
+		if !locked {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				First(&product, item.ProductID).Error; err != nil {
+				tx.Rollback()
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"error": "Product is no longer available: " + item.ProductID.String(),
+					})
+				}
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to verify product availability",
+				})
+			}
+		}
 
-	for _, item := range itemsToOrder {
-		// Lock the product row to prevent concurrent stock modifications
-		var product models.Product
-		if err := tx.Set("gorm:query_option", "FOR UPDATE").
-			First(&product, item.ProductID).Error; err != nil {
-			tx.Rollback()
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to verify product availability",
-			})
+		// Check the variant's own stock, re-read inside the transaction
+		if item.VariantID != nil {
+			var variant models.ProductVariant
+			if err := tx.Where("id = ? AND product_id = ?", *item.VariantID, item.ProductID).
+				First(&variant).Error; err != nil {
+				tx.Rollback()
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"error": "Product variant is no longer available for product: " + product.Name,
+					})
+				}
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to verify product availability",
+				})
+			}
+			if variant.Stock < item.Quantity {
+				tx.Rollback()
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Insufficient stock for product: " + product.Name + " (" + variant.SKU + ")" +
+						" (Available: " + strconv.Itoa(max(variant.Stock, 0)) +
+						", Requested: " + strconv.Itoa(item.Quantity) + ")",
+				})
+			}
+			itemsToOrder[i].Variant = &variant
+		} else {
+			var variantCount int64
+			if err := tx.Model(&models.ProductVariant{}).
+				Where("product_id = ?", item.ProductID).
+				Count(&variantCount).Error; err != nil {
+				tx.Rollback()
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to verify product availability",
+				})
+			}
+			if variantCount > 0 {
+				tx.Rollback()
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Select a variant for product: " + product.Name,
+				})
+			}
 		}
 
-		// Check stock availability
-		if product.Stock < item.Quantity {
+		// Check stock availability, counting earlier lines for the same product;
+		// units reserved by other users are not available, units reserved by this user are
+		remaining := product.Stock
+		if stockAfter, ok := stockUpdates[product.ID]; ok {
+			remaining = stockAfter
+		}
+		available := remaining - product.Reserved + reservedQuantities[product.ID]
+		if available < item.Quantity {
 			tx.Rollback()
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"error": "Insufficient stock for product: " + product.Name +
-					" (Available: " + strconv.Itoa(product.Stock) +
+					" (Available: " + strconv.Itoa(max(available, 0)) +
 					", Requested: " + strconv.Itoa(item.Quantity) + ")",
 			})
 		}
 
-		// Calculate total using current product price (not cart price which might be outdated)
-		total += product.Price * float64(item.Quantity)
-		stockUpdates[product.ID] = product.Stock - item.Quantity
+		stockUpdates[product.ID] = remaining - item.Quantity
+		lockedProducts[product.ID] = product
+	}
+
+	// Price the items using current product prices (not cart prices which might be outdated)
+	pricedItems := make([]models.CartItem, len(itemsToOrder))
+	for i, item := range itemsToOrder {
+		item.Product = lockedProducts[item.ProductID]
+		pricedItems[i] = item
+	}
+
+	var coupon *models.Discount
+	if cart.CouponID != nil {
+		coupon = &models.Discount{}
+		if err := tx.First(coupon, "id = ?", *cart.CouponID).Error; err != nil {
+			tx.Rollback()
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "The coupon applied to the cart no longer exists, remove it to continue",
+			})
+		}
+	}
+
+	// Same breakdown as the cart summary: line discounts, coupon, tax and shipping
+	totals, err := computeCartTotals(tx, pricedItems, coupon, time.Now())
+	if err != nil {
+		tx.Rollback()
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to calculate order totals",
+		})
+	}
+	if coupon != nil && totals.Coupon == nil {
+		tx.Rollback()
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Coupon cannot be applied: " + totals.CouponError,
+		})
+	}
+
+	// Count one use per applied discount; the conditional update keeps usage limits
+	// intact when orders are placed concurrently
+	for _, discountID := range totals.AppliedDiscountIDs {
+		if err := services.RedeemDiscount(tx, discountID); err != nil {
+			tx.Rollback()
+			if errors.Is(err, services.ErrDiscountUsageLimitReached) {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": "A discount on your order has just run out, please review the prices and try again",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to apply discounts",
+			})
+		}
+	}
+
+	// Redeem the coupon applied to the cart, which was re-validated against the ordered items
+	if coupon != nil {
+		if err := services.RedeemDiscount(tx, coupon.ID); err != nil {
+			tx.Rollback()
+			if errors.Is(err, services.ErrDiscountUsageLimitReached) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Coupon cannot be applied: usage limit reached",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to redeem coupon",
+			})
+		}
 	}
 
 	// Create order
 	order := models.Order{
-		UserID: userID,
-		Total:  total,
-		Status: "pending",
+		UserID:            userID,
+		Subtotal:          totals.Subtotal,
+		DiscountTotal:     totals.DiscountTotal,
+		TaxTotal:          totals.Tax,
+		ShippingCost:      totals.Shipping,
+		Total:             totals.Total,
+		Status:            "pending",
+		ShippingAddress:   shippingAddress,
+		ShippingAddressID: shippingAddressID,
+		Currency:          currency,
+		ExchangeRate:      exchangeRate,
+	}
+	if totals.Coupon != nil {
+		order.CouponID = &totals.Coupon.DiscountID
+		order.CouponDiscount = totals.Coupon.Amount
+	}
+	if len(totals.AppliedDiscountIDs) > 0 {
+		order.DiscountIDs = totals.AppliedDiscountIDs
 	}
 
 	if err := tx.Create(&order).Error; err != nil {
@@ -278,6 +565,13 @@ func CreateOrder(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := recordStatusChange(tx, order.ID, "", order.Status, &userID, ""); err != nil {
+		tx.Rollback()
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create order",
+		})
+	}
+
 	// Create order items and update stock atomically
 	var orderedCartItemIDs []uuid.UUID
 	for _, cartItem := range itemsToOrder {
@@ -290,11 +584,18 @@ func CreateOrder(c *fiber.Ctx) error {
 			})
 		}
 
+		// Use current price, not cart price
+		price := currentProduct.Price
+		if cartItem.Variant != nil {
+			price = cartItem.Variant.Price(currentProduct)
+		}
+
 		orderItem := models.OrderItem{
 			OrderID:   order.ID,
 			ProductID: cartItem.ProductID,
+			VariantID: cartItem.VariantID,
 			Quantity:  cartItem.Quantity,
-			Price:     currentProduct.Price, // Use current price, not cart price
+			Price:     price,
 		}
 
 		if err := tx.Create(&orderItem).Error; err != nil {
@@ -304,11 +605,15 @@ func CreateOrder(c *fiber.Ctx) error {
 			})
 		}
 
-		// Update product stock atomically
-		newStock := stockUpdates[cartItem.ProductID]
+		// Update product stock atomically, releasing this user's reservation once
+		updates := map[string]interface{}{"stock": gorm.Expr("stock - ?", cartItem.Quantity)}
+		if held := reservedQuantities[cartItem.ProductID]; held > 0 {
+			updates["reserved"] = gorm.Expr("GREATEST(reserved - ?, 0)", held)
+			delete(reservedQuantities, cartItem.ProductID)
+		}
 		result := tx.Model(&models.Product{}).
 			Where("id = ? AND stock >= ?", cartItem.ProductID, cartItem.Quantity).
-			Update("stock", newStock)
+			Updates(updates)
 
 		if result.Error != nil {
 			tx.Rollback()
@@ -325,6 +630,24 @@ func CreateOrder(c *fiber.Ctx) error {
 			})
 		}
 
+		if cartItem.VariantID != nil {
+			result := tx.Model(&models.ProductVariant{}).
+				Where("id = ? AND stock >= ?", *cartItem.VariantID, cartItem.Quantity).
+				UpdateColumn("stock", gorm.Expr("stock - ?", cartItem.Quantity))
+			if result.Error != nil {
+				tx.Rollback()
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to update product stock",
+				})
+			}
+			if result.RowsAffected == 0 {
+				tx.Rollback()
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Insufficient stock for product: " + currentProduct.Name + " (concurrent modification detected)",
+				})
+			}
+		}
+
 		// Track purchase interaction safely
 		go trackUserInteraction(userID, cartItem.ProductID, "purchase", c.Get("X-Session-ID"))
 
@@ -332,6 +655,19 @@ func CreateOrder(c *fiber.Ctx) error {
 		orderedCartItemIDs = append(orderedCartItemIDs, cartItem.ID)
 	}
 
+	// The consumed reservations were released with the stock update
+	orderedProductIDs := make([]uuid.UUID, 0, len(itemsToOrder))
+	for _, item := range itemsToOrder {
+		orderedProductIDs = append(orderedProductIDs, item.ProductID)
+	}
+	if err := tx.Where("user_id = ? AND product_id IN ?", userID, orderedProductIDs).
+		Delete(&models.StockReservation{}).Error; err != nil {
+		tx.Rollback()
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to release stock reservations",
+		})
+	}
+
 	// Remove only the ordered cart items (not the entire cart)
 	if err := tx.Where("id IN ?", orderedCartItemIDs).Delete(&models.CartItem{}).Error; err != nil {
 		tx.Rollback()
@@ -340,6 +676,16 @@ func CreateOrder(c *fiber.Ctx) error {
 		})
 	}
 
+	// A coupon is used up once redeemed
+	if coupon != nil {
+		if err := tx.Model(&cart).Update("coupon_id", nil).Error; err != nil {
+			tx.Rollback()
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to clear applied coupon",
+			})
+		}
+	}
+
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -347,9 +693,36 @@ func CreateOrder(c *fiber.Ctx) error {
 		})
 	}
 
+	// Stock levels changed, so cached product listings and products are stale
+	invalidateCatalogCache(orderedProductIDs...)
+
+	// The user's dashboard and other analytics now miss this order
+	services.AnalyticsCacheInstance.InvalidateUser(userID)
+
+	// Alert admins about products this order took down to the low-stock threshold
+	threshold := services.LowStockThreshold()
+	var lowStock []models.Product
+	for productID, stockAfter := range stockUpdates {
+		product := lockedProducts[productID]
+		if product.Stock > threshold && stockAfter <= threshold {
+			product.Stock = stockAfter
+			lowStock = append(lowStock, product)
+		}
+	}
+	go services.NotifyLowStock(lowStock)
+
+	go services.PublishWebhookEvent(services.WebhookEventOrderCreated, fiber.Map{
+		"order_id":    order.ID,
+		"user_id":     order.UserID,
+		"status":      order.Status,
+		"total":       order.Total,
+		"items_count": len(orderedCartItemIDs),
+		"created_at":  order.CreatedAt,
+	})
+
 	// Load order with items for response (using fresh connection)
 	if err := database.DB.Where("id = ?", order.ID).
-		Preload("OrderItems.Product").
+		Preload("OrderItems.Product", includeDeletedProducts).
 		First(&order).Error; err != nil {
 		// Order was created successfully, but we can't load it for response
 		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -377,6 +750,7 @@ func CreateOrder(c *fiber.Ctx) error {
 // @Param request body UpdateOrderStatusRequest true "New order status"
 // @Success 200 {object} map[string]interface{} "Order status updated successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid request or status transition"
+// @Failure 403 {object} map[string]interface{} "Admin access required"
 // @Failure 404 {object} map[string]interface{} "Order not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /orders/{id}/status [put]
@@ -404,6 +778,13 @@ func UpdateOrderStatus(c *fiber.Ctx) error {
 		})
 	}
 
+	// Refunds must go through the refund endpoint, which records them and restores stock
+	if isRefundStatus(req.Status) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Use the refund endpoint to refund an order",
+		})
+	}
+
 	// Get current order to validate status transition
 	var order models.Order
 	if err := database.DB.First(&order, id).Error; err != nil {
@@ -419,20 +800,147 @@ func UpdateOrderStatus(c *fiber.Ctx) error {
 		})
 	}
 
-	// Update order status
+	// Update order status and record the transition together
+	changedBy, _ := middleware.GetUserID(c)
+	fromStatus := order.Status
 	order.Status = req.Status
-	if err := database.DB.Save(&order).Error; err != nil {
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&order).Error; err != nil {
+			return err
+		}
+		if req.Status == "delivered" {
+			// Reviews the customer already left for these products now count as verified
+			if err := tx.Model(&models.Comment{}).
+				Where("user_id = ? AND verified_purchase = ?", order.UserID, false).
+				Where("product_id IN (?)", tx.Model(&models.OrderItem{}).Select("product_id").Where("order_id = ?", order.ID)).
+				Update("verified_purchase", true).Error; err != nil {
+				return err
+			}
+		}
+		return recordStatusChange(tx, order.ID, fromStatus, req.Status, &changedBy, req.Note)
+	}); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to update order status",
 		})
 	}
 
+	notifyOrderStatusChange(order, fromStatus)
+
 	return c.JSON(fiber.Map{
 		"message": "Order status updated successfully",
 		"order":   order,
 	})
 }
 
+// notifyOrderStatusChange queues an in-app notification for the order owner about a status transition
+func notifyOrderStatusChange(order models.Order, fromStatus string) {
+	shortID := order.ID.String()[:8]
+	title := fmt.Sprintf("Order %s is now %s", shortID, order.Status)
+	body := fmt.Sprintf("Your order %s changed from %s to %s.", shortID, fromStatus, order.Status)
+	metadata := map[string]interface{}{
+		"order_id":    order.ID,
+		"from_status": fromStatus,
+		"to_status":   order.Status,
+	}
+	if err := services.NotificationDispatcherInstance.Enqueue(order.UserID, services.NotificationOrderStatus, title, body, metadata); err != nil {
+		log.Printf("Failed to queue order status notification for order %s: %v", order.ID, err)
+	}
+}
+
+// releaseOrderDiscounts gives back the uses of the coupon and automatic discounts
+// redeemed by an order that has been cancelled or refunded in full
+func releaseOrderDiscounts(tx *gorm.DB, order models.Order) error {
+	discountIDs := order.DiscountIDs
+	if order.CouponID != nil {
+		discountIDs = append(slices.Clone(discountIDs), *order.CouponID)
+	}
+	for _, discountID := range discountIDs {
+		if err := services.ReleaseDiscount(tx, discountID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orderProductIDs returns the IDs of the products in an order
+func orderProductIDs(order models.Order) []uuid.UUID {
+	productIDs := make([]uuid.UUID, 0, len(order.OrderItems))
+	for _, item := range order.OrderItems {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	return productIDs
+}
+
+// recordStatusChange writes an order status transition to the order's history
+func recordStatusChange(tx *gorm.DB, orderID uuid.UUID, fromStatus, toStatus string, changedBy *uuid.UUID, note string) error {
+	if changedBy != nil && *changedBy == uuid.Nil {
+		changedBy = nil
+	}
+	return tx.Create(&models.OrderStatusHistory{
+		OrderID:    orderID,
+		FromStatus: fromStatus,
+		ToStatus:   toStatus,
+		ChangedBy:  changedBy,
+		Note:       note,
+	}).Error
+}
+
+// GetOrderHistory returns the status timeline of an order
+// @Summary Get order status history
+// @Description Get the status transitions of an order, oldest first. Customers can see their own orders; admins can see any order.
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Order ID (UUID)"
+// @Success 200 {object} map[string]interface{} "Order history retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid order ID"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 404 {object} map[string]interface{} "Order not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /orders/{id}/history [get]
+func GetOrderHistory(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid order ID",
+		})
+	}
+
+	var order models.Order
+	query := database.DB.Where("id = ?", id)
+	if !middleware.IsAdmin(c) {
+		query = query.Where("user_id = ?", userID)
+	}
+	if err := query.First(&order).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Order not found",
+		})
+	}
+
+	var history []models.OrderStatusHistory
+	if err := database.DB.Where("order_id = ?", order.ID).
+		Order("created_at ASC").
+		Find(&history).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch order history",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"order_id":       order.ID,
+		"current_status": order.Status,
+		"history":        history,
+	})
+}
+
 // isValidStatusTransition validates if a status transition is allowed
 func isValidStatusTransition(currentStatus, newStatus string) bool {
 	// Define valid status transitions
@@ -440,8 +948,11 @@ func isValidStatusTransition(currentStatus, newStatus string) bool {
 		"pending":    {"processing", "cancelled"},
 		"processing": {"shipped", "cancelled"},
 		"shipped":    {"delivered"},
-		"delivered":  {}, // Final state
+		"delivered":  {}, // Further changes only through the refund endpoint
 		"cancelled":  {}, // Final state
+
+		"partially_refunded": {}, // Further changes only through the refund endpoint
+		"refunded":           {}, // Final state
 	}
 
 	allowedTransitions, exists := validTransitions[currentStatus]
@@ -458,18 +969,30 @@ func isValidStatusTransition(currentStatus, newStatus string) bool {
 	return false
 }
 
-// CancelOrder cancels an order (only if status is pending)
+// isRefundStatus reports whether a status is set by refunds only
+func isRefundStatus(status string) bool {
+	return status == "refunded" || status == "partially_refunded"
+}
+
+// isRefundableStatus reports whether an order in the given status can be refunded
+func isRefundableStatus(status string) bool {
+	return status == "delivered" || status == "partially_refunded"
+}
+
+// CancelOrder cancels an order, or only some of its items
 // @Summary Cancel order
-// @Description Cancel a pending order and restore product stock
+// @Description Cancel a pending order, or a processing order within the cancellation grace period (ORDER_CANCEL_GRACE_MINUTES, default 30). Pass items to cancel only part of the order; stock is restored for the cancelled quantities and the order total is adjusted.
 // @Tags Orders
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Order ID (UUID)"
+// @Param request body CancelOrderRequest false "Items to cancel (omit to cancel the whole order)"
 // @Success 200 {object} map[string]interface{} "Order cancelled successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid order ID or order cannot be cancelled"
 // @Failure 401 {object} map[string]interface{} "User not authenticated"
 // @Failure 404 {object} map[string]interface{} "Order not found"
+// @Failure 409 {object} map[string]interface{} "Order changed by a concurrent request"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /orders/{id}/cancel [put]
 func CancelOrder(c *fiber.Ctx) error {
@@ -488,52 +1011,357 @@ func CancelOrder(c *fiber.Ctx) error {
 		})
 	}
 
+	// The body is optional; without it the whole order is cancelled
+	var req CancelOrderRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		if err := middleware.ValidateStruct(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	}
+
 	// Get order
 	var order models.Order
 	if err := database.DB.Where("id = ? AND user_id = ?", id, userID).
-		Preload("OrderItems.Product").
+		Preload("OrderItems.Product", includeDeletedProducts).
 		First(&order).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Order not found",
 		})
 	}
 
-	if order.Status != "pending" {
+	if allowed, reason := services.CanCancelOrder(order, time.Now()); !allowed {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Order cannot be cancelled",
+			"error":  "Order cannot be cancelled",
+			"reason": reason,
 		})
 	}
 
+	// Work out how much of each item to cancel
+	cancelQuantities := make(map[uuid.UUID]int)
+	if len(req.Items) == 0 {
+		for _, item := range order.OrderItems {
+			cancelQuantities[item.ID] = item.Quantity
+		}
+	} else {
+		itemsByID := make(map[uuid.UUID]models.OrderItem, len(order.OrderItems))
+		for _, item := range order.OrderItems {
+			itemsByID[item.ID] = item
+		}
+
+		for _, reqItem := range req.Items {
+			itemID, _ := uuid.Parse(reqItem.OrderItemID)
+			item, exists := itemsByID[itemID]
+			if !exists {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": fmt.Sprintf("Order item %s does not belong to this order", reqItem.OrderItemID),
+				})
+			}
+			if _, duplicate := cancelQuantities[itemID]; duplicate {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": fmt.Sprintf("Order item %s is listed more than once", reqItem.OrderItemID),
+				})
+			}
+
+			quantity := reqItem.Quantity
+			if quantity == 0 {
+				quantity = item.Quantity
+			}
+			if quantity > item.Quantity {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": fmt.Sprintf("Cannot cancel %d of order item %s, only %d ordered", quantity, reqItem.OrderItemID, item.Quantity),
+				})
+			}
+			cancelQuantities[itemID] = quantity
+		}
+	}
+
+	return applyOrderCancellation(c, order, cancelQuantities, userID)
+}
+
+// restoreStock adds quantities back to the stock of the given products or variants in a
+// single statement. The increment is relative to the current stock, so changes made
+// since the order was loaded are kept.
+func restoreStock(tx *gorm.DB, table string, quantities map[uuid.UUID]int) error {
+	if len(quantities) == 0 {
+		return nil
+	}
+
+	values := make([]string, 0, len(quantities))
+	args := make([]interface{}, 0, 2*len(quantities))
+	for id, quantity := range quantities {
+		values = append(values, "(?::uuid, ?::int)")
+		args = append(args, id, quantity)
+	}
+
+	return tx.Exec(`
+		UPDATE `+table+` AS t SET stock = t.stock + v.quantity, updated_at = NOW()
+		FROM (VALUES `+strings.Join(values, ", ")+`) AS v(id, quantity)
+		WHERE t.id = v.id
+	`, args...).Error
+}
+
+// applyOrderCancellation cancels the given quantities of an order's items in one transaction:
+// stock is restored, the totals are recomputed and the order is cancelled once no items remain.
+// The order is locked and re-checked first, so concurrent cancellations apply one after another.
+func applyOrderCancellation(c *fiber.Ctx, order models.Order, cancelQuantities map[uuid.UUID]int, userID uuid.UUID) error {
 	// Start transaction
 	tx := database.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
 
-	// Restore product stock
+	// Lock the order and reload its items; the order passed in may already be stale
+	orderID := order.ID
+	order = models.Order{}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, "id = ?", orderID).Error; err != nil {
+		tx.Rollback()
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to cancel order",
+		})
+	}
+	if err := tx.Where("order_id = ?", order.ID).Find(&order.OrderItems).Error; err != nil {
+		tx.Rollback()
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to cancel order",
+		})
+	}
+
+	if allowed, reason := services.CanCancelOrder(order, time.Now()); !allowed {
+		tx.Rollback()
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":  "Order cannot be cancelled",
+			"reason": reason,
+		})
+	}
+
+	// Another cancellation may have removed or reduced the items in the meantime
+	lockedItems := make(map[uuid.UUID]models.OrderItem, len(order.OrderItems))
 	for _, item := range order.OrderItems {
-		if err := tx.Model(&item.Product).Update("stock", item.Product.Stock+item.Quantity).Error; err != nil {
+		lockedItems[item.ID] = item
+	}
+	for itemID, quantity := range cancelQuantities {
+		if item, exists := lockedItems[itemID]; !exists || quantity > item.Quantity {
 			tx.Rollback()
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to restore product stock",
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Order was changed by another request, please try again",
 			})
 		}
 	}
 
-	// Update order status
-	order.Status = "cancelled"
-	if err := tx.Save(&order).Error; err != nil {
+	var cancelledSubtotal money.Cents
+	remainingItems := 0
+	productStock := make(map[uuid.UUID]int)
+	variantStock := make(map[uuid.UUID]int)
+	for _, item := range order.OrderItems {
+		quantity, cancelled := cancelQuantities[item.ID]
+		if !cancelled {
+			remainingItems++
+			continue
+		}
+
+		productStock[item.ProductID] += quantity
+		if item.VariantID != nil {
+			variantStock[*item.VariantID] += quantity
+		}
+
+		if quantity == item.Quantity {
+			if err := tx.Delete(&models.OrderItem{}, "id = ?", item.ID).Error; err != nil {
+				tx.Rollback()
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to cancel order item",
+				})
+			}
+		} else {
+			if err := tx.Model(&models.OrderItem{}).Where("id = ?", item.ID).
+				Update("quantity", item.Quantity-quantity).Error; err != nil {
+				tx.Rollback()
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to cancel order item",
+				})
+			}
+			remainingItems++
+		}
+
+		cancelledSubtotal += item.Price.Mul(quantity)
+	}
+
+	// Restore stock for the cancelled quantities
+	if err := restoreStock(tx, "products", productStock); err != nil {
+		tx.Rollback()
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to restore product stock",
+		})
+	}
+	if err := restoreStock(tx, "product_variants", variantStock); err != nil {
+		tx.Rollback()
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to restore product stock",
+		})
+	}
+
+	// Discounts and tax are shared proportionally across the items of the order;
+	// shipping is only refunded once the whole order is cancelled
+	refundAmount := cancelledSubtotal
+	if order.Subtotal > 0 {
+		refundAmount = cancelledSubtotal.MulRate(float64(order.Total-order.ShippingCost) / float64(order.Subtotal))
+	}
+	if remainingItems == 0 || refundAmount > order.Total {
+		refundAmount = order.Total
+	}
+
+	// Cancelling every item cancels the order itself
+	remainingSubtotal := max(0, order.Subtotal-cancelledSubtotal)
+	remainingTotal := order.Total - refundAmount
+	var remainingTax, remainingShipping money.Cents
+	if remainingItems > 0 {
+		remainingShipping = order.ShippingCost
+		if order.Subtotal > 0 {
+			remainingTax = order.TaxTotal.MulRate(float64(remainingSubtotal) / float64(order.Subtotal))
+		}
+	}
+	updates := map[string]interface{}{
+		"subtotal":       remainingSubtotal,
+		"discount_total": max(0, remainingSubtotal+remainingTax+remainingShipping-remainingTotal),
+		"tax_total":      remainingTax,
+		"shipping_cost":  remainingShipping,
+		"total":          remainingTotal,
+	}
+	if remainingItems == 0 {
+		updates["status"] = "cancelled"
+	}
+
+	if err := tx.Model(&order).Updates(updates).Error; err != nil {
 		tx.Rollback()
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to cancel order",
 		})
 	}
 
-	tx.Commit()
+	if remainingItems == 0 {
+		if err := recordStatusChange(tx, order.ID, order.Status, "cancelled", &userID, "Cancelled by customer"); err != nil {
+			tx.Rollback()
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to cancel order",
+			})
+		}
+		if err := releaseOrderDiscounts(tx, order); err != nil {
+			tx.Rollback()
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to cancel order",
+			})
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to cancel order",
+		})
+	}
+
+	// Stock levels changed, so cached product listings and products are stale
+	invalidateCatalogCache(orderProductIDs(order)...)
+
+	// Reload the revised order
+	var revisedOrder models.Order
+	if err := database.DB.Preload("OrderItems.Product", includeDeletedProducts).First(&revisedOrder, "id = ?", order.ID).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch revised order",
+		})
+	}
+
+	message := "Order cancelled successfully"
+	if remainingItems > 0 {
+		message = "Order items cancelled successfully"
+	}
 
 	return c.JSON(fiber.Map{
-		"message": "Order cancelled successfully",
-		"order":   order,
+		"message":       message,
+		"order":         revisedOrder,
+		"refund_amount": refundAmount,
 	})
 }
 
+// CancelOrderItem cancels a single line item of an order
+// @Summary Cancel order item
+// @Description Cancel one line item of a pending order, or of a processing order within the cancellation grace period. The item's stock is restored and the order total recomputed; the order is cancelled once all of its items are.
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Order ID (UUID)"
+// @Param itemId path string true "Order item ID (UUID)"
+// @Success 200 {object} map[string]interface{} "Order item cancelled successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid ID or order cannot be cancelled"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 404 {object} map[string]interface{} "Order or order item not found"
+// @Failure 409 {object} map[string]interface{} "Order changed by a concurrent request"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /orders/{id}/items/{itemId}/cancel [put]
+func CancelOrderItem(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid order ID",
+		})
+	}
+
+	itemID, err := uuid.Parse(c.Params("itemId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid order item ID",
+		})
+	}
+
+	var order models.Order
+	if err := database.DB.Where("id = ? AND user_id = ?", id, userID).
+		Preload("OrderItems.Product", includeDeletedProducts).
+		First(&order).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Order not found",
+		})
+	}
+
+	if allowed, reason := services.CanCancelOrder(order, time.Now()); !allowed {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":  "Order cannot be cancelled",
+			"reason": reason,
+		})
+	}
+
+	for _, item := range order.OrderItems {
+		if item.ID == itemID {
+			return applyOrderCancellation(c, order, map[uuid.UUID]int{item.ID: item.Quantity}, userID)
+		}
+	}
+
+	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		"error": "Order item not found",
+	})
+}
+
+// includeDeletedProducts lets order history show products that were since removed from the catalog
+func includeDeletedProducts(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
 // GetOrderStats returns order statistics for analytics
 // @Summary Get order statistics
 // @Description Get comprehensive order statistics for the authenticated user
@@ -553,10 +1381,10 @@ func GetOrderStats(c *fiber.Ctx) error {
 	}
 
 	var stats struct {
-		TotalOrders     int64   `json:"total_orders"`
-		TotalSpent      float64 `json:"total_spent"`
-		PendingOrders   int64   `json:"pending_orders"`
-		CompletedOrders int64   `json:"completed_orders"`
+		TotalOrders     int64       `json:"total_orders"`
+		TotalSpent      money.Cents `json:"total_spent"`
+		PendingOrders   int64       `json:"pending_orders"`
+		CompletedOrders int64       `json:"completed_orders"`
 	}
 
 	// Get total orders and spent
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bachelor_backend/database/dbtest"
+	"bachelor_backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// runCancellation calls applyOrderCancellation with the given order through a fiber app
+// and returns the response status and body
+func runCancellation(t *testing.T, order models.Order, quantities map[uuid.UUID]int) (int, string) {
+	t.Helper()
+
+	app := fiber.New()
+	app.Put("/cancel", func(c *fiber.Ctx) error {
+		return applyOrderCancellation(c, order, quantities, order.UserID)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodPut, "/cancel", nil), -1)
+	if err != nil {
+		t.Fatalf("cancel request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func productStock(t *testing.T, db *gorm.DB, productID uuid.UUID) int {
+	t.Helper()
+	var product models.Product
+	if err := db.Unscoped().First(&product, "id = ?", productID).Error; err != nil {
+		t.Fatalf("failed to load product: %v", err)
+	}
+	return product.Stock
+}
+
+func TestCancelOrderKeepsConcurrentStockChanges(t *testing.T) {
+	db := dbtest.Open(t)
+	product := dbtest.CreateProduct(t, db, 2500, 7)
+	user := dbtest.CreateUser(t, db)
+	order := dbtest.CreateOrder(t, db, user, product, 3)
+
+	// A restock lands after the order was loaded for cancellation
+	if err := db.Model(&models.Product{}).Where("id = ?", product.ID).
+		Update("stock", gorm.Expr("stock + ?", 5)).Error; err != nil {
+		t.Fatalf("failed to restock product: %v", err)
+	}
+
+	status, body := runCancellation(t, order, map[uuid.UUID]int{order.OrderItems[0].ID: 3})
+	if status != fiber.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", status, body)
+	}
+
+	// 7 left after the order, 5 restocked, 3 returned by the cancellation
+	if got := productStock(t, db, product.ID); got != 15 {
+		t.Errorf("stock = %d, want 15", got)
+	}
+
+	var cancelled models.Order
+	if err := db.First(&cancelled, "id = ?", order.ID).Error; err != nil {
+		t.Fatalf("failed to load order: %v", err)
+	}
+	if cancelled.Status != "cancelled" {
+		t.Errorf("order status = %q, want cancelled", cancelled.Status)
+	}
+}
+
+func TestCancelOrderItemPartially(t *testing.T) {
+	db := dbtest.Open(t)
+	product := dbtest.CreateProduct(t, db, 2500, 10)
+	user := dbtest.CreateUser(t, db)
+	order := dbtest.CreateOrder(t, db, user, product, 4)
+
+	if err := db.Model(&models.Product{}).Where("id = ?", product.ID).
+		Update("stock", gorm.Expr("stock - ?", 2)).Error; err != nil {
+		t.Fatalf("failed to sell product: %v", err)
+	}
+
+	status, body := runCancellation(t, order, map[uuid.UUID]int{order.OrderItems[0].ID: 1})
+	if status != fiber.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", status, body)
+	}
+
+	// 10 before, 2 sold elsewhere, 1 returned by the cancellation
+	if got := productStock(t, db, product.ID); got != 9 {
+		t.Errorf("stock = %d, want 9", got)
+	}
+
+	var item models.OrderItem
+	if err := db.First(&item, "id = ?", order.OrderItems[0].ID).Error; err != nil {
+		t.Fatalf("failed to load order item: %v", err)
+	}
+	if item.Quantity != 3 {
+		t.Errorf("item quantity = %d, want 3", item.Quantity)
+	}
+}
+
+// failCommitsFor makes every commit that updated the order fail, using a constraint
+// trigger that only runs when the transaction commits
+func failCommitsFor(t *testing.T, db *gorm.DB, orderID uuid.UUID) {
+	t.Helper()
+
+	name := "fail_commit_" + strings.ReplaceAll(orderID.String(), "-", "")
+	statements := []string{
+		`CREATE FUNCTION ` + name + `() RETURNS trigger AS $$
+		BEGIN
+			IF NEW.id = '` + orderID.String() + `' THEN
+				RAISE EXCEPTION 'simulated commit failure';
+			END IF;
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql`,
+		`CREATE CONSTRAINT TRIGGER ` + name + ` AFTER UPDATE ON orders
+		DEFERRABLE INITIALLY DEFERRED FOR EACH ROW EXECUTE FUNCTION ` + name + `()`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("failed to install commit failure trigger: %v", err)
+		}
+	}
+	t.Cleanup(func() {
+		if err := db.Exec(`DROP TRIGGER IF EXISTS ` + name + ` ON orders`).Error; err != nil {
+			t.Errorf("failed to drop trigger %s: %v", name, err)
+		}
+		if err := db.Exec(`DROP FUNCTION IF EXISTS ` + name + `()`).Error; err != nil {
+			t.Errorf("failed to drop function %s: %v", name, err)
+		}
+	})
+}
+
+func TestCancelOrderCommitFailure(t *testing.T) {
+	db := dbtest.Open(t)
+	product := dbtest.CreateProduct(t, db, 2500, 7)
+	user := dbtest.CreateUser(t, db)
+	order := dbtest.CreateOrder(t, db, user, product, 3)
+	failCommitsFor(t, db, order.ID)
+
+	status, body := runCancellation(t, order, map[uuid.UUID]int{order.OrderItems[0].ID: 3})
+	if status != fiber.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500: %s", status, body)
+	}
+	if !strings.Contains(body, "Failed to cancel order") {
+		t.Errorf("body = %s, want the cancellation error", body)
+	}
+
+	// Nothing from the rolled back transaction is kept
+	if got := productStock(t, db, product.ID); got != 7 {
+		t.Errorf("stock = %d, want 7", got)
+	}
+	var unchanged models.Order
+	if err := db.Preload("OrderItems").First(&unchanged, "id = ?", order.ID).Error; err != nil {
+		t.Fatalf("failed to load order: %v", err)
+	}
+	if unchanged.Status != "pending" {
+		t.Errorf("order status = %q, want pending", unchanged.Status)
+	}
+	if len(unchanged.OrderItems) != 1 || unchanged.OrderItems[0].Quantity != 3 {
+		t.Errorf("order items = %+v, want the original line", unchanged.OrderItems)
+	}
+}
+
+// createUsedDiscount creates a discount for the product that has been used once
+func createUsedDiscount(t *testing.T, db *gorm.DB, product models.Product, code *string) models.Discount {
+	t.Helper()
+	discount := models.Discount{
+		ProductID:     &product.ID,
+		Code:          code,
+		DiscountType:  "percentage",
+		DiscountValue: 10,
+		StartDate:     time.Now().Add(-time.Hour),
+		EndDate:       time.Now().Add(time.Hour),
+		IsActive:      true,
+		UsageLimit:    1,
+		UsageCount:    1,
+	}
+	if err := db.Create(&discount).Error; err != nil {
+		t.Fatalf("failed to create discount: %v", err)
+	}
+	return discount
+}
+
+func TestCancelOrderReleasesDiscounts(t *testing.T) {
+	db := dbtest.Open(t)
+	product := dbtest.CreateProduct(t, db, 2500, 7)
+	code := "TEST-" + uuid.NewString()
+	coupon := createUsedDiscount(t, db, product, &code)
+	automatic := createUsedDiscount(t, db, product, nil)
+	user := dbtest.CreateUser(t, db)
+	order := dbtest.CreateOrder(t, db, user, product, 2)
+
+	order.CouponID = &coupon.ID
+	order.DiscountIDs = []uuid.UUID{automatic.ID}
+	if err := db.Model(&order).Select("coupon_id", "discount_ids").Updates(&order).Error; err != nil {
+		t.Fatalf("failed to attach discounts to the order: %v", err)
+	}
+
+	// Cancelling part of the order keeps the discounts used
+	item := order.OrderItems[0]
+	if status, body := runCancellation(t, order, map[uuid.UUID]int{item.ID: 1}); status != fiber.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", status, body)
+	}
+	assertUsageCounts(t, db, 1, coupon, automatic)
+
+	if status, body := runCancellation(t, order, map[uuid.UUID]int{item.ID: 1}); status != fiber.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", status, body)
+	}
+	assertUsageCounts(t, db, 0, coupon, automatic)
+}
+
+func assertUsageCounts(t *testing.T, db *gorm.DB, want int, discounts ...models.Discount) {
+	t.Helper()
+	for _, discount := range discounts {
+		var reloaded models.Discount
+		if err := db.First(&reloaded, "id = ?", discount.ID).Error; err != nil {
+			t.Fatalf("failed to load discount: %v", err)
+		}
+		if reloaded.UsageCount != want {
+			t.Errorf("discount %s usage_count = %d, want %d", discount.ID, reloaded.UsageCount, want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/models"
+	"bachelor_backend/pkg/money"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PriceDrop is a product whose current price is below an earlier price
+type PriceDrop struct {
+	ProductID     uuid.UUID   `json:"product_id"`
+	ProductName   string      `json:"product_name"`
+	CurrentPrice  money.Cents `json:"current_price"`
+	PreviousPrice money.Cents `json:"previous_price"`
+	ChangedAt     time.Time   `json:"changed_at"`
+}
+
+// recordPriceChange stores a price history entry when the price actually changed
+func recordPriceChange(tx *gorm.DB, productID uuid.UUID, oldPrice, newPrice money.Cents) error {
+	if oldPrice == newPrice {
+		return nil
+	}
+	return tx.Create(&models.PriceHistory{
+		ProductID: productID,
+		OldPrice:  oldPrice,
+		NewPrice:  newPrice,
+		ChangedAt: time.Now(),
+	}).Error
+}
+
+// viewedPriceDrops returns products the user viewed since the given time whose current
+// price is below the most recent higher price in their history
+func viewedPriceDrops(userID uuid.UUID, since time.Time) []PriceDrop {
+	viewed := database.DB.Model(&models.UserInteraction{}).
+		Select("product_id").
+		Where("user_id = ? AND interaction_type = ? AND created_at >= ?", userID, "view", since)
+
+	var drops []PriceDrop
+	if err := database.DB.Table("products p").
+		Select("DISTINCT ON (p.id) p.id AS product_id, p.name AS product_name, p.price AS current_price, ph.old_price AS previous_price, ph.changed_at").
+		Joins("JOIN price_histories ph ON ph.product_id = p.id AND ph.old_price > p.price").
+		Where("p.deleted_at IS NULL AND p.id IN (?)", viewed).
+		Order("p.id, ph.changed_at DESC").
+		Limit(5).
+		Scan(&drops).Error; err != nil {
+		log.Printf("Failed to load price drops for viewed products: %v", err)
+		return nil
+	}
+	return drops
+}
+
+// GetProductPriceHistory returns the price changes of a product
+// @Summary Get product price history
+// @Description Get the price changes of a product, newest first
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID (UUID)"
+// @Param limit query int false "Maximum number of entries" default(50)
+// @Success 200 {object} map[string]interface{} "Price history retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid product ID"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /products/{id}/price-history [get]
+func GetProductPriceHistory(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid product ID",
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	var product models.Product
+	if err := database.DB.First(&product, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"success": false,
+				"error":   "Product not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to fetch product",
+		})
+	}
+
+	var history []models.PriceHistory
+	if err := database.DB.Where("product_id = ?", id).
+		Order("changed_at DESC").
+		Limit(limit).
+		Find(&history).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to fetch price history",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":       true,
+		"product_id":    product.ID,
+		"current_price": product.Price,
+		"history":       history,
+	})
+}
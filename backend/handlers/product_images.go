@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"errors"
+
+	"bachelor_backend/cache"
+	"bachelor_backend/database"
+	"bachelor_backend/middleware"
+	"bachelor_backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AddProductImageRequest represents the request to add an image to a product gallery
+type AddProductImageRequest struct {
+	URL       string `json:"url" validate:"required,url,max=2048" example:"https://example.com/image-2.jpg"`
+	Position  *int   `json:"position,omitempty" validate:"omitempty,min=0" example:"1"` // Defaults to the end of the gallery
+	IsPrimary bool   `json:"is_primary" example:"false"`
+}
+
+// orderedImages preloads product images in gallery order
+func orderedImages(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC, created_at ASC")
+}
+
+// setPrimaryImage makes the given image the product's only primary image and
+// mirrors its URL into Product.ImageURL for listings
+func setPrimaryImage(tx *gorm.DB, productID uuid.UUID, image models.ProductImage) error {
+	if err := tx.Model(&models.ProductImage{}).
+		Where("product_id = ? AND id <> ? AND is_primary = ?", productID, image.ID, true).
+		Update("is_primary", false).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&models.ProductImage{}).
+		Where("id = ?", image.ID).
+		Update("is_primary", true).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&models.Product{}).
+		Where("id = ?", productID).
+		Update("image_url", image.URL).Error
+}
+
+// loadProductImage fetches an image of a product from the route parameters
+func loadProductImage(c *fiber.Ctx) (models.ProductImage, error) {
+	var image models.ProductImage
+
+	productID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return image, fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+
+	imageID, err := uuid.Parse(c.Params("imageId"))
+	if err != nil {
+		return image, fiber.NewError(fiber.StatusBadRequest, "Invalid image ID")
+	}
+
+	if err := database.DB.Where("id = ? AND product_id = ?", imageID, productID).First(&image).Error; err != nil {
+		return image, fiber.NewError(fiber.StatusNotFound, "Image not found")
+	}
+
+	return image, nil
+}
+
+// fiberErrorResponse writes a fiber.Error returned by a loader helper as a JSON error
+func fiberErrorResponse(c *fiber.Ctx, err error) error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return c.Status(fiberErr.Code).JSON(fiber.Map{
+			"success":    false,
+			"error":      fiberErr.Message,
+			"request_id": middleware.GetRequestID(c),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"success":    false,
+		"error":      err.Error(),
+		"request_id": middleware.GetRequestID(c),
+	})
+}
+
+// AddProductImage adds an image to a product's gallery
+// @Summary Add product image
+// @Description Add an image to a product's gallery (admin access required). The first image becomes primary automatically.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Product ID (UUID)"
+// @Param request body AddProductImageRequest true "Image details"
+// @Success 201 {object} map[string]interface{} "Image added successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /products/{id}/images [post]
+func AddProductImage(c *fiber.Ctx) error {
+	productID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid product ID",
+		})
+	}
+
+	var req AddProductImageRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	var product models.Product
+	if err := database.DB.First(&product, "id = ?", productID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Product not found",
+		})
+	}
+
+	image := models.ProductImage{
+		ProductID: productID,
+		URL:       req.URL,
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var stats struct {
+			Count       int64
+			MaxPosition int
+		}
+		if err := tx.Model(&models.ProductImage{}).
+			Select("COUNT(*) AS count, COALESCE(MAX(position), -1) AS max_position").
+			Where("product_id = ?", productID).
+			Scan(&stats).Error; err != nil {
+			return err
+		}
+
+		if req.Position != nil {
+			image.Position = *req.Position
+		} else {
+			image.Position = stats.MaxPosition + 1
+		}
+
+		if err := tx.Create(&image).Error; err != nil {
+			return err
+		}
+
+		// The first image is always primary
+		if req.IsPrimary || stats.Count == 0 {
+			image.IsPrimary = true
+			return setPrimaryImage(tx, productID, image)
+		}
+		return nil
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to add image",
+		})
+	}
+
+	// The cached product includes its gallery; listings only show the primary image
+	if image.IsPrimary {
+		invalidateCatalogCache(image.ProductID)
+	} else {
+		cache.Invalidate(cache.ProductKey(image.ProductID.String()))
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Image added successfully",
+		"image":   image,
+	})
+}
+
+// DeleteProductImage removes an image from a product's gallery
+// @Summary Delete product image
+// @Description Remove an image from a product's gallery (admin access required). Deleting the primary image promotes the next image by position.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Product ID (UUID)"
+// @Param imageId path string true "Image ID (UUID)"
+// @Success 200 {object} map[string]interface{} "Image deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid product or image ID"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Image not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /products/{id}/images/{imageId} [delete]
+func DeleteProductImage(c *fiber.Ctx) error {
+	image, err := loadProductImage(c)
+	if err != nil {
+		return fiberErrorResponse(c, err)
+	}
+
+	var promoted *models.ProductImage
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&image).Error; err != nil {
+			return err
+		}
+
+		if !image.IsPrimary {
+			return nil
+		}
+
+		// Promote the next image in gallery order, or clear the listing image
+		var next models.ProductImage
+		err := orderedImages(tx.Where("product_id = ?", image.ProductID)).First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Model(&models.Product{}).Where("id = ?", image.ProductID).Update("image_url", "").Error
+		}
+		if err != nil {
+			return err
+		}
+
+		next.IsPrimary = true
+		promoted = &next
+		return setPrimaryImage(tx, image.ProductID, next)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to delete image",
+		})
+	}
+
+	// The cached product includes its gallery; listings only show the primary image
+	if image.IsPrimary {
+		invalidateCatalogCache(image.ProductID)
+	} else {
+		cache.Invalidate(cache.ProductKey(image.ProductID.String()))
+	}
+
+	return c.JSON(fiber.Map{
+		"success":        true,
+		"message":        "Image deleted successfully",
+		"promoted_image": promoted,
+	})
+}
+
+// SetPrimaryProductImage makes an image the product's primary image
+// @Summary Set primary product image
+// @Description Make an image the product's primary image (admin access required). Any previous primary image is demoted.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Product ID (UUID)"
+// @Param imageId path string true "Image ID (UUID)"
+// @Success 200 {object} map[string]interface{} "Primary image updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid product or image ID"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Image not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /products/{id}/images/{imageId}/primary [put]
+func SetPrimaryProductImage(c *fiber.Ctx) error {
+	image, err := loadProductImage(c)
+	if err != nil {
+		return fiberErrorResponse(c, err)
+	}
+
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return setPrimaryImage(tx, image.ProductID, image)
+	}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to update primary image",
+		})
+	}
+
+	invalidateCatalogCache(image.ProductID)
+
+	var images []models.ProductImage
+	orderedImages(database.DB.Where("product_id = ?", image.ProductID)).Find(&images)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Primary image updated successfully",
+		"images":  images,
+	})
+}
@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"bachelor_backend/database"
+	"bachelor_backend/middleware"
+	"bachelor_backend/models"
+	"bachelor_backend/pkg/money"
+	"bachelor_backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Limits for bulk product imports
+const (
+	maxBulkImportRows   = 1000
+	bulkImportBatchSize = 100
+)
+
+// BulkImportRowResult reports the outcome of importing a single row
+type BulkImportRowResult struct {
+	Row       int        `json:"row"` // 1-based position in the submitted data (excluding the CSV header)
+	Success   bool       `json:"success"`
+	ProductID *uuid.UUID `json:"product_id,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// BulkImportProducts creates many products in one request
+// @Summary Bulk import products
+// @Description Create products from a JSON array of CreateProductRequest objects, or from a CSV file (multipart field "file" or a text/csv body) with a header row using the same field names. Every row is validated and reported individually. With atomic=true nothing is inserted if any row fails.
+// @Tags Products
+// @Accept json,mpfd,text/csv
+// @Produce json
+// @Security BearerAuth
+// @Param atomic query bool false "Roll back the whole import if any row fails" default(false)
+// @Param request body []CreateProductRequest false "Products to import (JSON)"
+// @Param file formData file false "CSV file with products"
+// @Success 200 {object} map[string]interface{} "Import finished; see per-row results"
+// @Failure 400 {object} map[string]interface{} "Invalid input, or atomic import with failing rows"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /products/bulk [post]
+func BulkImportProducts(c *fiber.Ctx) error {
+	if _, ok := middleware.GetUserID(c); !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "Authentication required",
+		})
+	}
+
+	atomic := c.QueryBool("atomic", false)
+
+	rows, rowErrors, err := parseBulkImportRows(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	if len(rows) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "No products to import",
+		})
+	}
+
+	if len(rows) > maxBulkImportRows {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   fmt.Sprintf("Too many rows, at most %d products can be imported at once", maxBulkImportRows),
+		})
+	}
+
+	// Validate every row up front
+	results := make([]BulkImportRowResult, len(rows))
+	products := make([]models.Product, 0, len(rows))
+	productRows := make([]int, 0, len(rows)) // index into results for each product
+	for i, req := range rows {
+		results[i].Row = i + 1
+
+		if rowErrors[i] != nil {
+			results[i].Error = rowErrors[i].Error()
+			continue
+		}
+		if err := middleware.ValidateStruct(&req); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+
+		category, err := resolveProductCategory(database.DB, req.CategoryID, req.Category)
+		if err != nil {
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				results[i].Error = fiberErr.Message
+			} else {
+				results[i].Error = "Failed to resolve category"
+			}
+			continue
+		}
+
+		sku := normalizeSKU(req.SKU)
+		products = append(products, models.Product{
+			Name:        req.Name,
+			Description: req.Description,
+			Price:       req.Price,
+			Currency:    services.BaseCurrency(),
+			Category:    category.Name,
+			CategoryID:  &category.ID,
+			Brand:       req.Brand,
+			SKU:         &sku,
+			Barcode:     req.Barcode,
+			Stock:       req.Stock,
+			ImageURL:    req.ImageURL,
+		})
+		productRows = append(productRows, i)
+	}
+
+	invalid := len(rows) - len(products)
+	if atomic && invalid > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   fmt.Sprintf("%d rows failed validation, nothing was imported", invalid),
+			"created": 0,
+			"failed":  len(rows),
+			"results": results,
+		})
+	}
+
+	if atomic {
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			return tx.CreateInBatches(&products, bulkImportBatchSize).Error
+		})
+		if err != nil {
+			for _, idx := range productRows {
+				results[idx].Error = "Import rolled back: " + err.Error()
+			}
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to import products, nothing was imported",
+				"created": 0,
+				"failed":  len(rows),
+				"results": results,
+			})
+		}
+		for i, idx := range productRows {
+			results[idx].Success = true
+			results[idx].ProductID = &products[i].ID
+		}
+	} else {
+		// Insert batch by batch so one bad batch does not discard the others
+		for start := 0; start < len(products); start += bulkImportBatchSize {
+			end := start + bulkImportBatchSize
+			if end > len(products) {
+				end = len(products)
+			}
+			batch := products[start:end]
+
+			err := database.DB.Transaction(func(tx *gorm.DB) error {
+				return tx.Create(&batch).Error
+			})
+			for i, idx := range productRows[start:end] {
+				if err != nil {
+					results[idx].Error = "Failed to insert: " + err.Error()
+					continue
+				}
+				results[idx].Success = true
+				results[idx].ProductID = &batch[i].ID
+			}
+		}
+	}
+
+	created := 0
+	for _, result := range results {
+		if result.Success {
+			created++
+		}
+	}
+
+	if created > 0 {
+		// Product data changed, so cached listings and category counts are stale
+		invalidateCatalogCache()
+		invalidateCategoryCache()
+	}
+
+	return c.JSON(fiber.Map{
+		"success": created == len(rows),
+		"created": created,
+		"failed":  len(rows) - created,
+		"results": results,
+	})
+}
+
+// parseBulkImportRows reads import rows from a JSON array, a multipart CSV upload or a CSV body.
+// Row-level parse problems are returned per row so they can be reported alongside validation errors.
+func parseBulkImportRows(c *fiber.Ctx) ([]CreateProductRequest, []error, error) {
+	contentType := strings.ToLower(c.Get(fiber.HeaderContentType))
+
+	switch {
+	case strings.HasPrefix(contentType, fiber.MIMEMultipartForm):
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return nil, nil, errors.New("CSV file is required in the 'file' field")
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return nil, nil, errors.New("Failed to read uploaded file")
+		}
+		defer file.Close()
+		return parseProductCSV(file)
+
+	case strings.HasPrefix(contentType, "text/csv"):
+		return parseProductCSV(strings.NewReader(string(c.Body())))
+
+	default:
+		var rows []CreateProductRequest
+		if err := json.Unmarshal(c.Body(), &rows); err != nil {
+			return nil, nil, errors.New("Invalid request body, expected a JSON array of products")
+		}
+		return rows, make([]error, len(rows)), nil
+	}
+}
+
+// parseProductCSV parses a CSV document whose header row names CreateProductRequest fields
+func parseProductCSV(r io.Reader) ([]CreateProductRequest, []error, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("Invalid CSV header: %v", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"name", "price", "category", "sku"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("CSV header is missing the '%s' column", required)
+		}
+	}
+
+	var rows []CreateProductRequest
+	var rowErrors []error
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rows = append(rows, CreateProductRequest{})
+			rowErrors = append(rowErrors, fmt.Errorf("Invalid CSV row: %v", err))
+			continue
+		}
+
+		field := func(name string) string {
+			if idx, ok := columns[name]; ok && idx < len(record) {
+				return strings.TrimSpace(record[idx])
+			}
+			return ""
+		}
+
+		req := CreateProductRequest{
+			Name:        field("name"),
+			Description: field("description"),
+			Category:    field("category"),
+			Brand:       field("brand"),
+			SKU:         field("sku"),
+			Barcode:     field("barcode"),
+			ImageURL:    field("image_url"),
+		}
+
+		var rowErr error
+		if value := field("price"); value != "" {
+			if req.Price, err = money.Parse(value); err != nil {
+				rowErr = fmt.Errorf("Invalid price '%s'", value)
+			}
+		}
+		if value := field("stock"); value != "" && rowErr == nil {
+			if req.Stock, err = strconv.Atoi(value); err != nil {
+				rowErr = fmt.Errorf("Invalid stock '%s'", value)
+			}
+		}
+
+		rows = append(rows, req)
+		rowErrors = append(rowErrors, rowErr)
+	}
+
+	return rows, rowErrors, nil
+}
@@ -0,0 +1,411 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bachelor_backend/cache"
+	"bachelor_backend/database"
+	"bachelor_backend/models"
+	"bachelor_backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Co-purchase rankings change slowly, so they are cached longer than catalog entries.
+// More candidates than any request asks for are cached, leaving room for the ones
+// filtered out at read time for being out of stock.
+const (
+	coPurchaseCacheTTL   = time.Hour
+	coPurchaseCandidates = 50
+	maxRelatedProducts   = 20
+)
+
+// Weights of the similarity score components; each component is between 0 and 1
+const (
+	similarTagWeight   = 0.6
+	similarPriceWeight = 0.4
+)
+
+// SimilarProduct is a product with how similar it is to the requested one
+type SimilarProduct struct {
+	Product        models.Product `json:"product"`
+	Score          float64        `json:"score"`           // 0-1, higher is more similar
+	SharedTags     int64          `json:"shared_tags"`     // Tags in common with the requested product
+	PriceProximity float64        `json:"price_proximity"` // 1 for the same price, 0 at double or free
+}
+
+// TrendingProduct is a product with its time-decayed interaction score
+type TrendingProduct struct {
+	Product      models.Product `json:"product"`
+	Score        float64        `json:"score"`
+	Interactions int64          `json:"interactions"` // Weighted interactions in the window, before decay
+}
+
+// coPurchase is a product bought in the same orders as another, with how many orders
+type coPurchase struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Orders    int64     `json:"orders"`
+}
+
+// BoughtTogetherProduct is a product with how many orders it shared with the requested one
+type BoughtTogetherProduct struct {
+	Product      models.Product `json:"product"`
+	SharedOrders int64          `json:"shared_orders"`
+}
+
+// loadCoPurchases ranks the products that appear in the same orders as the given
+// product, by number of shared orders. Cancelled and refunded orders do not count.
+func loadCoPurchases(productID uuid.UUID) ([]coPurchase, error) {
+	key := cache.BoughtTogetherKey(productID.String())
+
+	var ranked []coPurchase
+	if cache.Get(key, &ranked) {
+		return ranked, nil
+	}
+
+	if err := database.DB.Table("order_items oi").
+		Select("other.product_id, COUNT(DISTINCT other.order_id) AS orders").
+		Joins("JOIN order_items other ON other.order_id = oi.order_id AND other.product_id <> oi.product_id").
+		Joins("JOIN orders o ON o.id = oi.order_id").
+		Where("oi.product_id = ? AND o.status NOT IN ?", productID, []string{"cancelled", "refunded"}).
+		Group("other.product_id").
+		Order("orders DESC, other.product_id").
+		Limit(coPurchaseCandidates).
+		Scan(&ranked).Error; err != nil {
+		return nil, err
+	}
+
+	cache.Set(key, ranked, coPurchaseCacheTTL)
+	return ranked, nil
+}
+
+// GetFrequentlyBoughtTogether returns products often ordered with a product
+// @Summary Get frequently bought together products
+// @Description Get the products that most often appear in the same orders as the given product, ranked by the number of shared orders. The product itself, out-of-stock products and products removed from the catalog are left out; cancelled and refunded orders do not count. Rankings are cached for an hour.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID (UUID)"
+// @Param limit query int false "Number of products (max 20)" default(5)
+// @Success 200 {object} map[string]interface{} "Frequently bought together products retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid product ID"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /products/{id}/frequently-bought-together [get]
+func GetFrequentlyBoughtTogether(c *fiber.Ctx) error {
+	productID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "5"))
+	if limit < 1 || limit > maxRelatedProducts {
+		limit = 5
+	}
+
+	var product models.Product
+	if err := database.DB.Select("id").First(&product, "id = ?", productID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Product not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch frequently bought together products",
+		})
+	}
+
+	ranked, err := loadCoPurchases(productID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch frequently bought together products",
+		})
+	}
+
+	candidateIDs := make([]uuid.UUID, len(ranked))
+	for i, entry := range ranked {
+		candidateIDs[i] = entry.ProductID
+	}
+
+	// Stock changes too often to cache, so availability is checked on every request
+	var candidates []models.Product
+	if len(candidateIDs) > 0 {
+		if err := database.DB.Preload("Images", orderedImages).
+			Where("id IN ? AND stock > 0", candidateIDs).
+			Find(&candidates).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch frequently bought together products",
+			})
+		}
+	}
+	available := make(map[uuid.UUID]models.Product, len(candidates))
+	for _, candidate := range candidates {
+		available[candidate.ID] = candidate
+	}
+
+	result := make([]BoughtTogetherProduct, 0, limit)
+	for _, entry := range ranked {
+		if len(result) == limit {
+			break
+		}
+		if candidate, ok := available[entry.ProductID]; ok {
+			result = append(result, BoughtTogetherProduct{Product: candidate, SharedOrders: entry.Orders})
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"product_id": productID,
+		"products":   result,
+		"count":      len(result),
+	})
+}
+
+// GetSimilarProducts returns products like a product, by category, tags and price
+// @Summary Get similar products
+// @Description Get products in the same category as the given product, scored by the share of its tags they have (weight 0.6) and how close their price is (weight 0.4; 1 at the same price, 0 at double or free). Returns the top matches with their score, highest first.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID (UUID)"
+// @Param limit query int false "Number of products (max 20)" default(5)
+// @Success 200 {object} map[string]interface{} "Similar products retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid product ID"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /products/{id}/similar [get]
+func GetSimilarProducts(c *fiber.Ctx) error {
+	productID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "5"))
+	if limit < 1 || limit > maxRelatedProducts {
+		limit = 5
+	}
+
+	var product models.Product
+	if err := database.DB.First(&product, "id = ?", productID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Product not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch similar products",
+		})
+	}
+
+	var tagCount int64
+	if err := database.DB.Model(&models.ProductTag{}).Where("product_id = ?", productID).Count(&tagCount).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch similar products",
+		})
+	}
+
+	// Share of the product's tags each candidate has, and 1 - relative price difference
+	sourceTags := database.DB.Model(&models.ProductTag{}).Select("tag_id").Where("product_id = ?", productID)
+	proximity := "(1 - LEAST(ABS(p.price - @price)::float / GREATEST(@price, 1), 1))"
+	query := database.DB.Table("products p").
+		Select("p.id AS product_id, COUNT(pt.tag_id) AS shared_tags, "+
+			proximity+" AS price_proximity, "+
+			"COUNT(pt.tag_id)::float / @tags * @tagWeight + "+proximity+" * @priceWeight AS score",
+			map[string]interface{}{
+				"price":       product.Price,
+				"tags":        max(tagCount, 1),
+				"tagWeight":   similarTagWeight,
+				"priceWeight": similarPriceWeight,
+			}).
+		Joins("LEFT JOIN product_tags pt ON pt.product_id = p.id AND pt.tag_id IN (?)", sourceTags).
+		Where("p.deleted_at IS NULL AND p.id <> ?", productID)
+	if product.CategoryID != nil {
+		query = query.Where("p.category_id = ?", *product.CategoryID)
+	} else {
+		query = query.Where("p.category = ?", product.Category)
+	}
+
+	var scored []struct {
+		ProductID      uuid.UUID
+		SharedTags     int64
+		PriceProximity float64
+		Score          float64
+	}
+	if err := query.Group("p.id, p.price").
+		Order("score DESC, p.id").
+		Limit(limit).
+		Scan(&scored).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch similar products",
+		})
+	}
+
+	candidateIDs := make([]uuid.UUID, len(scored))
+	for i, entry := range scored {
+		candidateIDs[i] = entry.ProductID
+	}
+
+	var candidates []models.Product
+	if len(candidateIDs) > 0 {
+		if err := database.DB.Preload("Images", orderedImages).Where("id IN ?", candidateIDs).Find(&candidates).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch similar products",
+			})
+		}
+	}
+	byID := make(map[uuid.UUID]models.Product, len(candidates))
+	for _, candidate := range candidates {
+		byID[candidate.ID] = candidate
+	}
+
+	result := make([]SimilarProduct, 0, len(scored))
+	for _, entry := range scored {
+		candidate, ok := byID[entry.ProductID]
+		if !ok {
+			continue
+		}
+		result = append(result, SimilarProduct{
+			Product:        candidate,
+			Score:          math.Round(entry.Score*10000) / 10000,
+			SharedTags:     entry.SharedTags,
+			PriceProximity: math.Round(entry.PriceProximity*10000) / 10000,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"product_id": productID,
+		"products":   result,
+		"count":      len(result),
+	})
+}
+
+// trendingScoreSQL returns the SQL summing each interaction's type weight, halved for
+// every half-life that has passed since it happened, with its arguments
+func trendingScoreSQL() (string, []interface{}) {
+	types := make([]string, 0, len(services.TrendingInteractionWeights))
+	for interactionType := range services.TrendingInteractionWeights {
+		types = append(types, interactionType)
+	}
+	sort.Strings(types)
+
+	var weight strings.Builder
+	args := make([]interface{}, 0, 2*len(types)+1)
+	weight.WriteString("CASE ui.interaction_type")
+	for _, interactionType := range types {
+		weight.WriteString(" WHEN ? THEN ?::float")
+		args = append(args, interactionType, services.TrendingInteractionWeights[interactionType])
+	}
+	weight.WriteString(" ELSE 0 END")
+
+	args = append(args, services.TrendingHalfLife().Seconds())
+	return "SUM(" + weight.String() + " * EXP(-LN(2) * EXTRACT(EPOCH FROM (NOW() - ui.created_at)) / ?))", args
+}
+
+// GetTrendingProducts returns the products with the most recent interest
+// @Summary Get trending products
+// @Description Get the products trending now. Each interaction in the window counts by type (purchase 5, cart_add 3, favorite and wishlist 2, upvote and comment 1.5, view 1) and halves in weight every TRENDING_HALF_LIFE_HOURS (default 48), so products with rising interest outrank ones that were popular weeks ago. Results are cached for CACHE_TTL_SECONDS when Redis is configured.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param days query int false "Window of interactions considered, in days (max 90)" default(30)
+// @Param limit query int false "Number of products (max 50)" default(10)
+// @Param category query string false "Only products in this category"
+// @Success 200 {object} map[string]interface{} "Trending products retrieved successfully"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /products/trending [get]
+func GetTrendingProducts(c *fiber.Ctx) error {
+	days, _ := strconv.Atoi(c.Query("days", "30"))
+	if days < 1 || days > 90 {
+		days = 30
+	}
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+	category := strings.TrimSpace(c.Query("category"))
+
+	key := cache.TrendingKey(days, limit, category)
+	var result []TrendingProduct
+	if cache.Get(key, &result) {
+		return c.JSON(fiber.Map{
+			"products":  result,
+			"count":     len(result),
+			"half_life": services.TrendingHalfLife().String(),
+		})
+	}
+
+	types := make([]string, 0, len(services.TrendingInteractionWeights))
+	for interactionType := range services.TrendingInteractionWeights {
+		types = append(types, interactionType)
+	}
+
+	scoreSQL, scoreArgs := trendingScoreSQL()
+	query := database.DB.Table("user_interactions ui").
+		Select("ui.product_id, COUNT(*) AS interactions, "+scoreSQL+" AS score", scoreArgs...).
+		Joins("JOIN products p ON p.id = ui.product_id AND p.deleted_at IS NULL").
+		Where("ui.created_at >= ? AND ui.interaction_type IN ?", time.Now().AddDate(0, 0, -days), types)
+	if category != "" {
+		query = query.Where("LOWER(p.category) = LOWER(?)", category)
+	}
+
+	var scored []struct {
+		ProductID    uuid.UUID
+		Interactions int64
+		Score        float64
+	}
+	if err := query.Group("ui.product_id").
+		Order("score DESC, ui.product_id").
+		Limit(limit).
+		Scan(&scored).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch trending products",
+		})
+	}
+
+	productIDs := make([]uuid.UUID, len(scored))
+	for i, entry := range scored {
+		productIDs[i] = entry.ProductID
+	}
+
+	var products []models.Product
+	if len(productIDs) > 0 {
+		if err := database.DB.Preload("Images", orderedImages).Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch trending products",
+			})
+		}
+	}
+	byID := make(map[uuid.UUID]models.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+
+	result = make([]TrendingProduct, 0, len(scored))
+	for _, entry := range scored {
+		if product, ok := byID[entry.ProductID]; ok {
+			result = append(result, TrendingProduct{
+				Product:      product,
+				Score:        math.Round(entry.Score*10000) / 10000,
+				Interactions: entry.Interactions,
+			})
+		}
+	}
+
+	cache.Set(key, result, cache.TTL())
+
+	return c.JSON(fiber.Map{
+		"products":  result,
+		"count":     len(result),
+		"half_life": services.TrendingHalfLife().String(),
+	})
+}
@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"errors"
+
+	"bachelor_backend/database"
+	"bachelor_backend/middleware"
+	"bachelor_backend/models"
+	"bachelor_backend/pkg/money"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreateProductVariantRequest represents the request to add a variant to a product
+type CreateProductVariantRequest struct {
+	SKU           string            `json:"sku" validate:"required,alphanum,max=64" example:"TSHIRTMRED"`
+	Attributes    map[string]string `json:"attributes" validate:"required,min=1,max=10" example:"size:M,color:red"`
+	PriceOverride *money.Cents      `json:"price_override,omitempty" validate:"omitempty,gt=0" swaggertype:"string" example:"24.99"` // Defaults to the product price
+	Stock         int               `json:"stock" validate:"min=0" example:"25"`
+}
+
+// UpdateProductVariantRequest represents a partial update of a product variant
+type UpdateProductVariantRequest struct {
+	SKU           *string            `json:"sku,omitempty" validate:"omitempty,alphanum,max=64"`
+	Attributes    *map[string]string `json:"attributes,omitempty" validate:"omitempty,min=1,max=10"`
+	PriceOverride *money.Cents       `json:"price_override,omitempty" validate:"omitempty,gt=0" swaggertype:"string"`
+	ClearPrice    bool               `json:"clear_price_override,omitempty"` // Sell at the product price again
+	Stock         *int               `json:"stock,omitempty" validate:"omitempty,min=0"`
+}
+
+// orderedVariants preloads product variants in a stable order
+func orderedVariants(db *gorm.DB) *gorm.DB {
+	return db.Order("created_at ASC, sku ASC")
+}
+
+// syncVariantStock sets a product's stock to the total of its variants' stock
+func syncVariantStock(tx *gorm.DB, productID uuid.UUID) error {
+	return tx.Model(&models.Product{}).
+		Where("id = ?", productID).
+		UpdateColumn("stock", gorm.Expr(
+			"(SELECT COALESCE(SUM(stock), 0) FROM product_variants WHERE product_id = ?)", productID)).Error
+}
+
+// loadProductVariant fetches a variant of a product from the route parameters
+func loadProductVariant(c *fiber.Ctx) (models.ProductVariant, error) {
+	var variant models.ProductVariant
+
+	productID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return variant, fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+
+	variantID, err := uuid.Parse(c.Params("variantId"))
+	if err != nil {
+		return variant, fiber.NewError(fiber.StatusBadRequest, "Invalid variant ID")
+	}
+
+	if err := database.DB.Where("id = ? AND product_id = ?", variantID, productID).First(&variant).Error; err != nil {
+		return variant, fiber.NewError(fiber.StatusNotFound, "Variant not found")
+	}
+
+	return variant, nil
+}
+
+// resolveItemVariant finds the variant a cart line refers to. Products with variants
+// require one; products without variants must not be given one.
+func resolveItemVariant(db *gorm.DB, product models.Product, rawVariantID string) (*models.ProductVariant, error) {
+	if rawVariantID == "" {
+		var count int64
+		if err := db.Model(&models.ProductVariant{}).Where("product_id = ?", product.ID).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "This product has variants, choose one with variant_id")
+		}
+		return nil, nil
+	}
+
+	variantID, err := uuid.Parse(rawVariantID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid variant ID")
+	}
+
+	var variant models.ProductVariant
+	if err := db.Where("id = ? AND product_id = ?", variantID, product.ID).First(&variant).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Variant not found")
+		}
+		return nil, err
+	}
+	return &variant, nil
+}
+
+// cartItemStock returns the stock available to a cart line: the variant's for
+// variant lines, the product's otherwise. Variant and Product must be loaded.
+func cartItemStock(item models.CartItem) int {
+	if item.Variant != nil {
+		return item.Variant.Stock
+	}
+	return item.Product.Stock
+}
+
+// withVariantPrices returns copies of the items whose Product.Price is the price
+// of the line's variant, so pricing code can keep reading the product price
+func withVariantPrices(items []models.CartItem) []models.CartItem {
+	priced := make([]models.CartItem, len(items))
+	for i, item := range items {
+		if item.Variant != nil {
+			item.Product.Price = item.Variant.Price(item.Product)
+		}
+		priced[i] = item
+	}
+	return priced
+}
+
+// CreateProductVariant adds a variant to a product
+// @Summary Add product variant
+// @Description Add a purchasable variant such as a size or color to a product (admin access required). Once a product has variants, customers must pick one and its stock becomes the total of its variants' stock.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Product ID (UUID)"
+// @Param request body CreateProductVariantRequest true "Variant details"
+// @Success 201 {object} map[string]interface{} "Variant added successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 409 {object} map[string]interface{} "SKU already in use"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /products/{id}/variants [post]
+func CreateProductVariant(c *fiber.Ctx) error {
+	productID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid product ID",
+		})
+	}
+
+	var req CreateProductVariantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	var product models.Product
+	if err := database.DB.First(&product, "id = ?", productID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Product not found",
+		})
+	}
+
+	variant := models.ProductVariant{
+		ProductID:     productID,
+		SKU:           normalizeSKU(req.SKU),
+		Attributes:    req.Attributes,
+		PriceOverride: req.PriceOverride,
+		Stock:         req.Stock,
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&variant).Error; err != nil {
+			return err
+		}
+		return syncVariantStock(tx, productID)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"success": false,
+				"error":   "SKU already in use",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to add variant",
+		})
+	}
+
+	// The product's stock now includes the variant
+	invalidateCatalogCache(productID)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Variant added successfully",
+		"variant": variant,
+	})
+}
+
+// UpdateProductVariant changes a product variant
+// @Summary Update product variant
+// @Description Change a variant's SKU, attributes, price override or stock (admin access required). The product's stock is recalculated from its variants.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Product ID (UUID)"
+// @Param variantId path string true "Variant ID (UUID)"
+// @Param request body UpdateProductVariantRequest true "Fields to update"
+// @Success 200 {object} map[string]interface{} "Variant updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Variant not found"
+// @Failure 409 {object} map[string]interface{} "SKU already in use"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /products/{id}/variants/{variantId} [put]
+func UpdateProductVariant(c *fiber.Ctx) error {
+	variant, err := loadProductVariant(c)
+	if err != nil {
+		return fiberErrorResponse(c, err)
+	}
+
+	var req UpdateProductVariantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	if req.SKU != nil {
+		variant.SKU = normalizeSKU(*req.SKU)
+	}
+	if req.Attributes != nil {
+		variant.Attributes = *req.Attributes
+	}
+	if req.ClearPrice {
+		variant.PriceOverride = nil
+	} else if req.PriceOverride != nil {
+		variant.PriceOverride = req.PriceOverride
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Stock is written separately so a concurrent order's decrement is not lost
+		// between reading the variant and saving it
+		if err := tx.Model(&variant).
+			Select("sku", "attributes", "price_override").
+			Updates(&variant).Error; err != nil {
+			return err
+		}
+		if req.Stock == nil {
+			return nil
+		}
+
+		if err := tx.Model(&variant).UpdateColumn("stock", *req.Stock).Error; err != nil {
+			return err
+		}
+		variant.Stock = *req.Stock
+		return syncVariantStock(tx, variant.ProductID)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"success": false,
+				"error":   "SKU already in use",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to update variant",
+		})
+	}
+
+	invalidateCatalogCache(variant.ProductID)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Variant updated successfully",
+		"variant": variant,
+	})
+}
+
+// DeleteProductVariant removes a variant from a product
+// @Summary Delete product variant
+// @Description Remove a variant from a product (admin access required). Variants that have been ordered cannot be deleted; set their stock to 0 instead.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Product ID (UUID)"
+// @Param variantId path string true "Variant ID (UUID)"
+// @Success 200 {object} map[string]interface{} "Variant deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid product or variant ID"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Variant not found"
+// @Failure 409 {object} map[string]interface{} "Variant has been ordered"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /products/{id}/variants/{variantId} [delete]
+func DeleteProductVariant(c *fiber.Ctx) error {
+	variant, err := loadProductVariant(c)
+	if err != nil {
+		return fiberErrorResponse(c, err)
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&variant).Error; err != nil {
+			return err
+		}
+		return syncVariantStock(tx, variant.ProductID)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"success": false,
+				"error":   "Variant has been ordered and cannot be deleted, set its stock to 0 instead",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to delete variant",
+		})
+	}
+
+	invalidateCatalogCache(variant.ProductID)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Variant deleted successfully",
+	})
+}
@@ -1,21 +1,40 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
+	"bachelor_backend/cache"
 	"bachelor_backend/database"
 	"bachelor_backend/middleware"
 	"bachelor_backend/models"
+	"bachelor_backend/pkg/money"
+	"bachelor_backend/pkg/pagination"
 	"bachelor_backend/services"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ReceiveShipmentRequest represents an inbound shipment for a single product
+type ReceiveShipmentRequest struct {
+	ProductID string `json:"product_id,omitempty" validate:"omitempty,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	SKU       string `json:"sku,omitempty" validate:"omitempty,alphanum,max=64" example:"IPH15PRO256"`
+	Quantity  int    `json:"quantity" validate:"required,min=1,max=100000" example:"50"`
+	Reference string `json:"reference" validate:"required,min=1,max=100" example:"SHIP-2024-0042"`
+}
+
 // ProductResponse represents a product with additional metadata
 type ProductResponse struct {
 	models.Product
@@ -24,38 +43,51 @@ type ProductResponse struct {
 
 // CreateProductRequest represents the request to create a new product
 type CreateProductRequest struct {
-	Name        string  `json:"name" validate:"required,min=1,max=255" example:"iPhone 15 Pro"`
-	Description string  `json:"description" validate:"required,min=1,max=1000" example:"Latest iPhone with A17 Pro chip"`
-	Price       float64 `json:"price" validate:"required,min=0.01" example:"999.99"`
-	Category    string  `json:"category" validate:"required,min=1,max=100" example:"Electronics"`
-	Stock       int     `json:"stock" validate:"required,min=0" example:"50"`
-	ImageURL    string  `json:"image_url" validate:"omitempty,url" example:"https://example.com/image.jpg"`
+	Name        string      `json:"name" validate:"required,min=1,max=255" example:"iPhone 15 Pro"`
+	Description string      `json:"description" validate:"required,min=1,max=1000" example:"Latest iPhone with A17 Pro chip"`
+	Price       money.Cents `json:"price" validate:"required,min=1" swaggertype:"string" example:"999.99"`
+	Category    string      `json:"category" validate:"required_without=CategoryID,omitempty,min=1,max=100" example:"Electronics"` // Matched to a category by slug, created if missing
+	CategoryID  string      `json:"category_id" validate:"omitempty,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`          // Takes precedence over category
+	Brand       string      `json:"brand" validate:"omitempty,max=100" example:"Apple"`
+	SKU         string      `json:"sku" validate:"required,alphanum,max=64" example:"IPH15PRO256"`
+	Barcode     string      `json:"barcode" validate:"omitempty,numeric,min=8,max=14" example:"0194253401234"`
+	Stock       int         `json:"stock" validate:"required,min=0" example:"50"`
+	ImageURL    string      `json:"image_url" validate:"omitempty,url" example:"https://example.com/image.jpg"`
 }
 
 // UpdateProductRequest represents the request to update a product
 type UpdateProductRequest struct {
-	Name        string  `json:"name" validate:"omitempty,min=1,max=255" example:"iPhone 15 Pro"`
-	Description string  `json:"description" validate:"omitempty,min=1,max=1000" example:"Latest iPhone with A17 Pro chip"`
-	Price       float64 `json:"price" validate:"omitempty,min=0.01" example:"999.99"`
-	Category    string  `json:"category" validate:"omitempty,min=1,max=100" example:"Electronics"`
-	Stock       int     `json:"stock" validate:"omitempty,min=0" example:"50"`
-	ImageURL    string  `json:"image_url" validate:"omitempty,url" example:"https://example.com/image.jpg"`
+	Name        string      `json:"name" validate:"omitempty,min=1,max=255" example:"iPhone 15 Pro"`
+	Description string      `json:"description" validate:"omitempty,min=1,max=1000" example:"Latest iPhone with A17 Pro chip"`
+	Price       money.Cents `json:"price" validate:"omitempty,min=1" swaggertype:"string" example:"999.99"`
+	Category    string      `json:"category" validate:"omitempty,min=1,max=100" example:"Electronics"`
+	CategoryID  string      `json:"category_id" validate:"omitempty,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Brand       string      `json:"brand" validate:"omitempty,max=100" example:"Apple"`
+	SKU         string      `json:"sku" validate:"omitempty,alphanum,max=64" example:"IPH15PRO256"`
+	Barcode     string      `json:"barcode" validate:"omitempty,numeric,min=8,max=14" example:"0194253401234"`
+	Stock       int         `json:"stock" validate:"omitempty,min=0" example:"50"`
+	ImageURL    string      `json:"image_url" validate:"omitempty,url" example:"https://example.com/image.jpg"`
 }
 
 // GetProducts returns a paginated list of products
 // @Summary Get products
-// @Description Get a paginated list of products with optional filtering and sorting
+// @Description Get a paginated list of products with optional filtering and sorting. Pass cursor (empty for the first page) to use cursor pagination instead of page numbers; it requires sorting by created_at and returns next_cursor instead of totals. Pass currency to also get each price converted at the current exchange rate (converted_price) and the rate used; stored prices stay in the base currency.
 // @Tags Products
 // @Accept json
 // @Produce json
 // @Param page query int false "Page number" default(1)
+// @Param cursor query string false "Opaque cursor from pagination.next_cursor; empty for the first page"
 // @Param limit query int false "Items per page" default(20)
 // @Param category query string false "Filter by category"
 // @Param search query string false "Search in name and description"
 // @Param sort query string false "Sort field (price, name, created_at)" default("created_at")
 // @Param order query string false "Sort order (asc, desc)" default("desc")
+// @Param currency query string false "ISO 4217 code to convert prices to for display, e.g. EUR"
+// @Param X-Cache-Bypass header string false "Set to true to skip the listing cache (admins only)"
 // @Success 200 {object} map[string]interface{} "Products retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid cursor or sort for cursor pagination, or unsupported currency"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 503 {object} map[string]interface{} "Exchange rates are unavailable"
 // @Router /products [get]
 func GetProducts(c *fiber.Ctx) error {
 	// Parse query parameters
@@ -66,8 +98,43 @@ func GetProducts(c *fiber.Ctx) error {
 	sortBy := c.Query("sort", "created_at")
 	sortOrder := c.Query("order", "desc")
 
-	// Calculate offset
-	offset := (page - 1) * limit
+	page, limit = pagination.Normalize(page, limit)
+
+	useCursor := usesCursor(c)
+	var cursor *pageCursor
+	if useCursor {
+		if sortBy != "created_at" || (sortOrder != "asc" && sortOrder != "desc") {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Cursor pagination requires sort=created_at and order asc or desc",
+			})
+		}
+		var err error
+		if cursor, err = decodeCursor(c.Query("cursor")); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid cursor",
+			})
+		}
+		limit = cursorLimit(c, 20)
+		page = 0
+	}
+
+	// Serve from the short-lived listing cache unless an authenticated caller asks to bypass it
+	cacheKey := fmt.Sprintf("products:page=%d:limit=%d:category=%s:search=%s:sort=%s:order=%s",
+		page, limit, category, strings.ToLower(search), sortBy, sortOrder)
+	if useCursor {
+		cacheKey += ":cursor=" + c.Query("cursor")
+	}
+	bypassCache := middleware.IsAdmin(c) && c.Get("X-Cache-Bypass") == "true"
+
+	if !bypassCache {
+		if cached, found := services.ProductListCacheInstance.Get(cacheKey); found {
+			if search != "" {
+				go trackSearchQuery(c, search)
+			}
+			c.Set("X-Cache", "HIT")
+			return respondWithCurrency(c, cached.(fiber.Map))
+		}
+	}
 
 	// Build query
 	query := database.DB.Model(&models.Product{})
@@ -85,6 +152,33 @@ func GetProducts(c *fiber.Ctx) error {
 		go trackSearchQuery(c, search)
 	}
 
+	if useCursor {
+		var products []models.Product
+		if err := applyCursor(query, cursor, sortOrder, limit).Find(&products).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch products",
+			})
+		}
+
+		products, nextCursor := cursorPage(products, limit, func(p models.Product) (time.Time, uuid.UUID) {
+			return p.CreatedAt, p.ID
+		})
+
+		go trackProductViews(c, products)
+
+		response := fiber.Map{
+			"products": products,
+			"pagination": fiber.Map{
+				"limit":       limit,
+				"next_cursor": nextCursor,
+				"has_more":    nextCursor != nil,
+			},
+		}
+		services.ProductListCacheInstance.Set(cacheKey, response)
+		c.Set("X-Cache", "MISS")
+		return respondWithCurrency(c, response)
+	}
+
 	// Apply sorting
 	if sortBy == "price" || sortBy == "name" || sortBy == "created_at" {
 		if sortOrder == "asc" || sortOrder == "desc" {
@@ -92,17 +186,8 @@ func GetProducts(c *fiber.Ctx) error {
 		}
 	}
 
-	// Get total count
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to count products",
-		})
-	}
-
-	// Get products
-	var products []models.Product
-	if err := query.Offset(offset).Limit(limit).Find(&products).Error; err != nil {
+	products, meta, err := pagination.Paginate[models.Product](query, page, limit)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch products",
 		})
@@ -111,20 +196,60 @@ func GetProducts(c *fiber.Ctx) error {
 	// Track product views for each product (for analytics)
 	go trackProductViews(c, products)
 
+	response := fiber.Map{
+		"products":   products,
+		"pagination": meta,
+	}
+
+	services.ProductListCacheInstance.Set(cacheKey, response)
+	if bypassCache {
+		c.Set("X-Cache", "BYPASS")
+	} else {
+		c.Set("X-Cache", "MISS")
+	}
+
+	return respondWithCurrency(c, response)
+}
+
+// invalidateCatalogCache drops cached product listings and the cached details of the
+// given products. Called whenever product data or stock changes.
+func invalidateCatalogCache(productIDs ...uuid.UUID) {
+	services.ProductListCacheInstance.InvalidateAll()
+
+	keys := make([]string, 0, len(productIDs))
+	for _, id := range productIDs {
+		keys = append(keys, cache.ProductKey(id.String()))
+	}
+	cache.Invalidate(keys...)
+}
+
+// invalidateCategoryCache drops the cached category names and tree
+func invalidateCategoryCache() {
+	cache.Invalidate(cache.KeyCategoryNames, cache.KeyCategoryTree)
+}
+
+// GetProductCacheStats returns hit-rate statistics for the product listing cache
+// @Summary Get product cache statistics
+// @Description Get hit, miss and hit-rate statistics for the in-memory product listing cache and the Redis catalog cache
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Cache statistics retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Router /products/cache/stats [get]
+func GetProductCacheStats(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{
-		"products": products,
-		"pagination": fiber.Map{
-			"page":        page,
-			"limit":       limit,
-			"total":       total,
-			"total_pages": (total + int64(limit) - 1) / int64(limit),
-		},
+		"success": true,
+		"data":    services.ProductListCacheInstance.Stats(),
+		"redis":   cache.Stats(),
 	})
 }
 
 // GetProduct returns a single product by ID
 // @Summary Get product by ID
-// @Description Get detailed information about a specific product
+// @Description Get detailed information about a specific product, including its ordered image gallery, its variants and the last computed sentiment of its comments
 // @Tags Products
 // @Accept json
 // @Produce json
@@ -132,6 +257,7 @@ func GetProducts(c *fiber.Ctx) error {
 // @Success 200 {object} models.Product "Product retrieved successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid product ID"
 // @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 410 {object} map[string]interface{} "Product has been removed from the catalog"
 // @Router /products/{id} [get]
 func GetProduct(c *fiber.Ctx) error {
 	productID := c.Params("id")
@@ -145,71 +271,162 @@ func GetProduct(c *fiber.Ctx) error {
 	}
 
 	var product models.Product
-	if err := database.DB.First(&product, id).Error; err != nil {
+	if cache.Get(cache.ProductKey(id.String()), &product) {
+		trackProductView(c, product.ID)
+		return c.JSON(product)
+	}
+
+	if err := database.DB.Preload("Images", orderedImages).Preload("Variants", orderedVariants).Preload("Sentiment").First(&product, id).Error; err != nil {
+		// Distinguish products removed from the catalog from ones that never existed
+		var deleted models.Product
+		if database.DB.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&deleted).Error == nil {
+			return c.Status(fiber.StatusGone).JSON(fiber.Map{
+				"error":      "Product has been removed",
+				"deleted_at": deleted.DeletedAt.Time,
+			})
+		}
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Product not found",
 		})
 	}
 
-	// Track product view
-	go trackSingleProductView(c, product.ID)
+	cache.Set(cache.ProductKey(id.String()), product, cache.TTL())
+
+	trackProductView(c, product.ID)
+	return c.JSON(product)
+}
+
+// trackProductView records a product detail view and, for signed-in users, a view interaction
+func trackProductView(c *fiber.Ctx, productID uuid.UUID) {
+	trackSingleProductView(c, productID)
 
-	// Track user interaction if user is authenticated
 	if userID, ok := middleware.GetUserID(c); ok {
-		go trackUserInteraction(userID, product.ID, "view", c.Get("X-Session-ID"))
+		go trackUserInteraction(userID, productID, "view", c.Get("X-Session-ID"))
 	}
-
-	return c.JSON(product)
 }
 
-// GetProductsByCategory returns products filtered by category
-func GetProductsByCategory(c *fiber.Ctx) error {
-	category := c.Params("category")
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+// GetProductQuote returns the effective price of a product for a quantity
+// @Summary Get product price quote
+// @Description Get the effective unit and total price for a quantity, including any applied discounts and the reasons they apply. Overlapping discounts are combined under the stacking policy reported in stacking_policy (DISCOUNT_STACKING_POLICY: best, stack or exclusive). For signed-in users the minimum order amounts of discounts are checked against their cart as it would be with this quantity of the product, the way checkout prices it; order_subtotal reports that amount.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Product ID (UUID)"
+// @Param quantity query int false "Quantity" default(1)
+// @Success 200 {object} services.PriceQuote "Price quote calculated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid product ID or quantity"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /products/{id}/quote [get]
+func GetProductQuote(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
 
-	offset := (page - 1) * limit
+	quantity, err := strconv.Atoi(c.Query("quantity", "1"))
+	if err != nil || quantity < 1 || quantity > 100 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Quantity must be between 1 and 100",
+		})
+	}
 
-	var products []models.Product
-	var total int64
+	var product models.Product
+	if err := database.DB.First(&product, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Product not found",
+		})
+	}
 
-	query := database.DB.Where("category = ?", category)
+	orderSubtotal, err := quoteOrderSubtotal(c, product, quantity)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to calculate price quote",
+		})
+	}
 
-	if err := query.Count(&total).Error; err != nil {
+	quote, err := services.QuoteOrderLine(database.DB, product, quantity, orderSubtotal)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to count products",
+			"error": "Failed to calculate price quote",
 		})
 	}
 
-	if err := query.Offset(offset).Limit(limit).Find(&products).Error; err != nil {
+	return c.JSON(fiber.Map{
+		"quote":          quote,
+		"order_subtotal": orderSubtotal,
+		"in_stock":       product.Stock >= quantity,
+		"available":      product.Stock,
+	})
+}
+
+// quoteOrderSubtotal returns the order subtotal a product quote is priced against: the
+// signed-in user's cart with the quoted quantity in place of any lines of the product,
+// or just the quoted quantity for anonymous callers
+func quoteOrderSubtotal(c *fiber.Ctx, product models.Product, quantity int) (money.Cents, error) {
+	subtotal := product.Price.Mul(quantity)
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return subtotal, nil
+	}
+
+	var items []models.CartItem
+	if err := database.DB.Joins("JOIN shopping_carts ON cart_items.cart_id = shopping_carts.id").
+		Scopes(cartOwner{UserID: &userID}.scope).
+		Where("cart_items.product_id <> ?", product.ID).
+		Preload("Product").
+		Preload("Variant").
+		Find(&items).Error; err != nil {
+		return 0, err
+	}
+
+	for _, item := range withVariantPrices(items) {
+		subtotal += item.Product.Price.Mul(item.Quantity)
+	}
+	return subtotal, nil
+}
+
+// GetProductsByCategory returns products filtered by category
+func GetProductsByCategory(c *fiber.Ctx) error {
+	category := c.Params("category")
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	products, meta, err := pagination.Paginate[models.Product](database.DB.Where("category = ?", category), page, limit)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch products",
 		})
 	}
 
 	return c.JSON(fiber.Map{
-		"products": products,
-		"category": category,
-		"pagination": fiber.Map{
-			"page":        page,
-			"limit":       limit,
-			"total":       total,
-			"total_pages": (total + int64(limit) - 1) / int64(limit),
-		},
+		"products":   products,
+		"category":   category,
+		"pagination": meta,
 	})
 }
 
-// GetCategories returns all available product categories
+// GetCategories returns the names of all product categories; see GetCategoryTree for the hierarchy
 func GetCategories(c *fiber.Ctx) error {
 	var categories []string
+	if cache.Get(cache.KeyCategoryNames, &categories) {
+		return c.JSON(fiber.Map{
+			"categories": categories,
+		})
+	}
 
-	if err := database.DB.Model(&models.Product{}).
-		Distinct("category").
-		Pluck("category", &categories).Error; err != nil {
+	if err := database.DB.Model(&models.Category{}).
+		Order("name ASC").
+		Pluck("name", &categories).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch categories",
 		})
 	}
+	cache.Set(cache.KeyCategoryNames, categories, cache.TTL())
 
 	return c.JSON(fiber.Map{
 		"categories": categories,
@@ -218,7 +435,7 @@ func GetCategories(c *fiber.Ctx) error {
 
 // SearchProducts performs enhanced search with relevance scoring
 // @Summary Search products
-// @Description Search products using enhanced search with relevance scoring and intelligent filtering
+// @Description Search products with PostgreSQL full-text search (stemming and prefix matching) over name, category and description, ordered by ts_rank relevance with the ranking weights from /admin/search-config. When nothing matches, suggestions lists similar product names and popular past queries ("did you mean").
 // @Tags Products
 // @Accept json
 // @Produce json
@@ -228,6 +445,7 @@ func GetCategories(c *fiber.Ctx) error {
 // @Param category query string false "Filter by category"
 // @Param min_price query number false "Minimum price filter"
 // @Param max_price query number false "Maximum price filter"
+// @Param facets query bool false "Include category, price and tag facets for the result set" default(false)
 // @Success 200 {object} map[string]interface{} "Search results retrieved successfully"
 // @Failure 400 {object} map[string]interface{} "Search query is required"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
@@ -242,145 +460,122 @@ func SearchProducts(c *fiber.Ctx) error {
 
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	page, limit = pagination.Normalize(page, limit)
 	offset := (page - 1) * limit
 
 	// Additional filters
 	category := c.Query("category")
-	minPrice, _ := strconv.ParseFloat(c.Query("min_price", "0"), 64)
-	maxPrice, _ := strconv.ParseFloat(c.Query("max_price", "999999"), 64)
+	minPrice, _ := money.Parse(c.Query("min_price", "0"))
+	maxPrice, err := money.Parse(c.Query("max_price"))
+	if err != nil || maxPrice <= 0 {
+		maxPrice = searchNoMaxPrice
+	}
 
 	// Enhanced search with relevance scoring
-	searchResults, total, err := performEnhancedSearch(query, category, minPrice, maxPrice, offset, limit)
+	searchResults, total, err := performEnhancedSearch(query, category, minPrice, maxPrice, time.Time{}, offset, limit)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to search products",
 		})
 	}
 
-	// Track search query with results count
-	go trackSearchQueryWithResults(c, query, int(total))
+	// Track search query with results count; clicks on the results are reported against its ID
+	searchQueryID := trackSearchQueryWithResults(c, query, int(total))
 
-	return c.JSON(fiber.Map{
-		"products": searchResults,
-		"query":    query,
+	// Offer close matches when nothing was found, e.g. for a typo
+	suggestions := []DidYouMeanSuggestion{}
+	if total == 0 {
+		suggestions = findDidYouMeanSuggestions(c.UserContext(), query)
+	}
+
+	response := fiber.Map{
+		"products":        searchResults,
+		"query":           query,
+		"search_query_id": searchQueryID,
+		"suggestions":     suggestions,
 		"filters": fiber.Map{
 			"category":  category,
 			"min_price": minPrice,
 			"max_price": maxPrice,
 		},
-		"pagination": fiber.Map{
-			"page":        page,
-			"limit":       limit,
-			"total":       total,
-			"total_pages": (total + int64(limit) - 1) / int64(limit),
-		},
-	})
-}
-
-// Enhanced search function with relevance scoring
-func performEnhancedSearch(query, category string, minPrice, maxPrice float64, offset, limit int) ([]ProductSearchResult, int64, error) {
-	// Normalize and prepare search terms
-	normalizedQuery := strings.ToLower(strings.TrimSpace(query))
-	searchTerms := strings.Fields(normalizedQuery)
-
-	if len(searchTerms) == 0 {
-		return nil, 0, fmt.Errorf("invalid search query")
+		"pagination": pagination.NewMeta(page, limit, total),
 	}
 
-	// Build the search query with relevance scoring
-	var searchResults []ProductSearchResult
-	var total int64
-
-	// Create a complex query that scores relevance
-	baseQuery := database.DB.Model(&models.Product{}).
-		Select(`
-			products.*,
-			(
-				CASE 
-					WHEN LOWER(name) = ? THEN 100
-					WHEN LOWER(name) LIKE ? THEN 80
-					WHEN LOWER(name) LIKE ? THEN 60
-					ELSE 0
-				END +
-				CASE 
-					WHEN LOWER(description) LIKE ? THEN 40
-					WHEN LOWER(description) LIKE ? THEN 20
-					ELSE 0
-				END +
-				CASE 
-					WHEN LOWER(category) = ? THEN 50
-					WHEN LOWER(category) LIKE ? THEN 30
-					ELSE 0
-				END
-			) as relevance_score
-		`,
-			normalizedQuery,                        // Exact name match
-			normalizedQuery+"%",                    // Name starts with query
-			"%"+normalizedQuery+"%",                // Name contains query
-			"%"+normalizedQuery+"%",                // Description contains query
-			"%"+strings.Join(searchTerms, "%")+"%", // Description contains all terms
-			normalizedQuery,                        // Exact category match
-			"%"+normalizedQuery+"%",                // Category contains query
-		)
-
-	// Apply filters
-	whereConditions := []string{}
-	whereArgs := []interface{}{}
-
-	// Search condition - must match at least one field
-	searchCondition := `(
-		LOWER(name) LIKE ? OR 
-		LOWER(description) LIKE ? OR 
-		LOWER(category) LIKE ?
-	)`
-	whereConditions = append(whereConditions, searchCondition)
-	whereArgs = append(whereArgs, "%"+normalizedQuery+"%", "%"+normalizedQuery+"%", "%"+normalizedQuery+"%")
-
-	// Additional term matching for better relevance
-	for _, term := range searchTerms {
-		if len(term) > 2 { // Only consider terms longer than 2 characters
-			termCondition := `(
-				LOWER(name) LIKE ? OR 
-				LOWER(description) LIKE ? OR 
-				LOWER(category) LIKE ?
-			)`
-			whereConditions = append(whereConditions, termCondition)
-			whereArgs = append(whereArgs, "%"+term+"%", "%"+term+"%", "%"+term+"%")
+	if c.QueryBool("facets", false) {
+		facets, err := computeSearchFacets(query, minPrice, maxPrice)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to compute search facets",
+			})
 		}
+		response["facets"] = facets
 	}
 
+	return c.JSON(response)
+}
+
+// buildSearchConditions returns the search predicate shared by results and facets:
+// full-text match, price range and availability. The category filter is left to the caller.
+func buildSearchConditions(tsQuery string, minPrice, maxPrice money.Cents) ([]string, []interface{}) {
+	whereConditions := []string{"products.search_vector @@ to_tsquery('english', ?)"}
+	whereArgs := []interface{}{tsQuery}
+
 	// Price filters
 	if minPrice > 0 {
 		whereConditions = append(whereConditions, "price >= ?")
 		whereArgs = append(whereArgs, minPrice)
 	}
-	if maxPrice < 999999 {
+	if maxPrice < searchNoMaxPrice {
 		whereConditions = append(whereConditions, "price <= ?")
 		whereArgs = append(whereArgs, maxPrice)
 	}
 
+	// Stock filter - only show available products
+	whereConditions = append(whereConditions, "stock > 0")
+
+	return whereConditions, whereArgs
+}
+
+// performEnhancedSearch runs a full-text search over the products.search_vector
+// column (name, category and description), ranked by ts_rank with the configured
+// weight for each part. A non-zero createdAfter restricts it to products created
+// after that time.
+func performEnhancedSearch(query, category string, minPrice, maxPrice money.Cents, createdAfter time.Time, offset, limit int) ([]ProductSearchResult, int64, error) {
+	tsQuery := buildPrefixTSQuery(query)
+	if tsQuery == "" {
+		return nil, 0, fmt.Errorf("invalid search query")
+	}
+
+	var searchResults []ProductSearchResult
+	var total int64
+
+	// Apply filters
+	whereConditions, whereArgs := buildSearchConditions(tsQuery, minPrice, maxPrice)
+
 	// Category filter
 	if category != "" {
 		whereConditions = append(whereConditions, "LOWER(category) = ?")
 		whereArgs = append(whereArgs, strings.ToLower(category))
 	}
 
-	// Stock filter - only show available products
-	whereConditions = append(whereConditions, "stock > 0")
-
-	// Combine all conditions
-	finalQuery := baseQuery.Where(strings.Join(whereConditions, " AND "), whereArgs...)
+	// Only products added since a point in time, e.g. new matches of a saved search
+	if !createdAfter.IsZero() {
+		whereConditions = append(whereConditions, "products.created_at > ?")
+		whereArgs = append(whereArgs, createdAfter)
+	}
 
-	// Create a separate query for counting (without the SELECT with calculated fields)
-	countQuery := database.DB.Model(&models.Product{}).Where(strings.Join(whereConditions, " AND "), whereArgs...)
+	condition := strings.Join(whereConditions, " AND ")
 
 	// Count total results
-	if err := countQuery.Count(&total).Error; err != nil {
+	if err := database.DB.Model(&models.Product{}).Where(condition, whereArgs...).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	// Get results with relevance scoring and ordering
-	if err := finalQuery.
+	// Get results ordered by relevance
+	if err := database.DB.Model(&models.Product{}).
+		Select("products.*, ts_rank(?::float4[], products.search_vector, to_tsquery('english', ?)) AS relevance_score",
+			services.LoadSearchWeights().RankArray(), tsQuery).
+		Where(condition, whereArgs...).
 		Order("relevance_score DESC, name ASC").
 		Offset(offset).
 		Limit(limit).
@@ -388,52 +583,513 @@ func performEnhancedSearch(query, category string, minPrice, maxPrice float64, o
 		return nil, 0, err
 	}
 
-	// Filter out results with zero relevance score in application logic
-	filteredResults := make([]ProductSearchResult, 0, len(searchResults))
-	for _, result := range searchResults {
-		if result.RelevanceScore > 0 {
-			filteredResults = append(filteredResults, result)
+	return searchResults, total, nil
+}
+
+// SearchFacetCount is the number of matching products for a facet value
+type SearchFacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// PriceRangeBucket is the number of matching products in a price range.
+// Max is nil for the open-ended top bucket.
+type PriceRangeBucket struct {
+	Min   money.Cents  `json:"min"`
+	Max   *money.Cents `json:"max"`
+	Count int64        `json:"count"`
+}
+
+// SearchFacets summarizes a search result set for building filter sidebars
+type SearchFacets struct {
+	Categories []SearchFacetCount `json:"categories"`
+	Price      struct {
+		Min    money.Cents        `json:"min"`
+		Max    money.Cents        `json:"max"`
+		Ranges []PriceRangeBucket `json:"ranges"`
+	} `json:"price"`
+	Tags []SearchFacetCount `json:"tags"`
+}
+
+// searchPriceBuckets are the lower bounds of the price range facet buckets
+var searchPriceBuckets = []money.Cents{0, 2500, 5000, 10000, 25000, 50000}
+
+// searchNoMaxPrice is the max_price used when none is given (999999.00)
+const searchNoMaxPrice money.Cents = 99999900
+
+// maxSearchTagFacets caps the number of tags returned in the tag facet
+const maxSearchTagFacets = 20
+
+// computeSearchFacets aggregates category, price and tag counts for a search.
+// The category filter is deliberately not applied so users can switch categories.
+func computeSearchFacets(query string, minPrice, maxPrice money.Cents) (*SearchFacets, error) {
+	tsQuery := buildPrefixTSQuery(query)
+	if tsQuery == "" {
+		return nil, fmt.Errorf("invalid search query")
+	}
+
+	whereConditions, whereArgs := buildSearchConditions(tsQuery, minPrice, maxPrice)
+	condition := strings.Join(whereConditions, " AND ")
+
+	facets := &SearchFacets{}
+
+	// Category counts
+	if err := database.DB.Model(&models.Product{}).
+		Select("category AS value, COUNT(*) AS count").
+		Where(condition, whereArgs...).
+		Group("category").
+		Order("count DESC, category ASC").
+		Scan(&facets.Categories).Error; err != nil {
+		return nil, err
+	}
+
+	// Price bounds
+	var priceBounds struct {
+		Min money.Cents
+		Max money.Cents
+	}
+	if err := database.DB.Model(&models.Product{}).
+		Select("COALESCE(MIN(price), 0) AS min, COALESCE(MAX(price), 0) AS max").
+		Where(condition, whereArgs...).
+		Scan(&priceBounds).Error; err != nil {
+		return nil, err
+	}
+	facets.Price.Min = priceBounds.Min
+	facets.Price.Max = priceBounds.Max
+
+	// Price range buckets, grouped by the index of the bucket each price falls into
+	bucketCase := "CASE"
+	for i := len(searchPriceBuckets) - 1; i >= 0; i-- {
+		bucketCase += fmt.Sprintf(" WHEN price >= %d THEN %d", searchPriceBuckets[i], i)
+	}
+	bucketCase += " ELSE 0 END"
+
+	var bucketCounts []struct {
+		Bucket int
+		Count  int64
+	}
+	if err := database.DB.Model(&models.Product{}).
+		Select(bucketCase+" AS bucket, COUNT(*) AS count").
+		Where(condition, whereArgs...).
+		Group("bucket").
+		Scan(&bucketCounts).Error; err != nil {
+		return nil, err
+	}
+
+	facets.Price.Ranges = make([]PriceRangeBucket, len(searchPriceBuckets))
+	for i, lower := range searchPriceBuckets {
+		facets.Price.Ranges[i].Min = lower
+		if i+1 < len(searchPriceBuckets) {
+			upper := searchPriceBuckets[i+1]
+			facets.Price.Ranges[i].Max = &upper
+		}
+	}
+	for _, bc := range bucketCounts {
+		if bc.Bucket >= 0 && bc.Bucket < len(facets.Price.Ranges) {
+			facets.Price.Ranges[bc.Bucket].Count = bc.Count
+		}
+	}
+
+	// Tag counts
+	if err := database.DB.Model(&models.Product{}).
+		Select("tags.name AS value, COUNT(DISTINCT products.id) AS count").
+		Joins("JOIN product_tags ON product_tags.product_id = products.id").
+		Joins("JOIN tags ON tags.id = product_tags.tag_id").
+		Where(condition, whereArgs...).
+		Group("tags.name").
+		Order("count DESC, tags.name ASC").
+		Limit(maxSearchTagFacets).
+		Scan(&facets.Tags).Error; err != nil {
+		return nil, err
+	}
+
+	if facets.Categories == nil {
+		facets.Categories = []SearchFacetCount{}
+	}
+	if facets.Tags == nil {
+		facets.Tags = []SearchFacetCount{}
+	}
+
+	return facets, nil
+}
+
+// buildPrefixTSQuery turns free text into a to_tsquery expression that requires every
+// term and matches word prefixes, e.g. "wireless head" -> "wireless:* & head:*".
+// Characters with special meaning in tsquery syntax are dropped.
+func buildPrefixTSQuery(query string) string {
+	var terms []string
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		cleaned := strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, word)
+		if cleaned != "" {
+			terms = append(terms, cleaned+":*")
+		}
+	}
+	return strings.Join(terms, " & ")
+}
+
+// Search suggestion limits
+const (
+	maxSearchSuggestions    = 10
+	searchSuggestionTimeout = 300 * time.Millisecond
+	searchSuggestionHistory = 90 * 24 * time.Hour
+)
+
+// SearchSuggestion is a single type-ahead completion
+type SearchSuggestion struct {
+	Text      string `json:"text"`
+	Frequency int64  `json:"frequency"` // How often the text was searched recently
+	Source    string `json:"source"`    // 'query' or 'product'
+}
+
+// GetSearchSuggestions returns type-ahead completions for a search prefix
+// @Summary Get search suggestions
+// @Description Get up to 10 completions for a search prefix, combining popular past queries and product names, ranked by query frequency
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param q query string true "Search prefix"
+// @Success 200 {object} map[string]interface{} "Suggestions retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Search prefix is required"
+// @Router /products/search/suggestions [get]
+func GetSearchSuggestions(c *fiber.Ctx) error {
+	prefix := strings.ToLower(strings.TrimSpace(c.Query("q")))
+	if prefix == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Search prefix is required",
+		})
+	}
+	if len(prefix) > 100 {
+		prefix = prefix[:100]
+	}
+
+	// Keep the lookups within a tight latency budget
+	ctx, cancel := context.WithTimeout(c.UserContext(), searchSuggestionTimeout)
+	defer cancel()
+	db := database.DB.WithContext(ctx)
+
+	pattern := escapeLikePattern(prefix) + "%"
+
+	type querySuggestion struct {
+		Suggestion string
+		Frequency  int64
+	}
+	var popularQueries []querySuggestion
+	if err := db.Model(&models.SearchQuery{}).
+		Select("LOWER(TRIM(query)) AS suggestion, COUNT(*) AS frequency").
+		Where("LOWER(query) LIKE ? AND results_count > 0 AND created_at > ?", pattern, time.Now().Add(-searchSuggestionHistory)).
+		Group("LOWER(TRIM(query))").
+		Order("frequency DESC").
+		Limit(maxSearchSuggestions).
+		Scan(&popularQueries).Error; err != nil {
+		log.Printf("Search suggestions: query history lookup failed: %v", err)
+	}
+
+	var productNames []string
+	if err := db.Model(&models.Product{}).
+		Where("LOWER(name) LIKE ?", pattern).
+		Order("name ASC").
+		Limit(maxSearchSuggestions).
+		Pluck("name", &productNames).Error; err != nil {
+		log.Printf("Search suggestions: product name lookup failed: %v", err)
+	}
+
+	// Merge and de-duplicate case-insensitively
+	suggestions := make([]SearchSuggestion, 0, len(popularQueries)+len(productNames))
+	seen := make(map[string]int)
+	for _, q := range popularQueries {
+		if q.Suggestion == "" {
+			continue
+		}
+		seen[q.Suggestion] = len(suggestions)
+		suggestions = append(suggestions, SearchSuggestion{Text: q.Suggestion, Frequency: q.Frequency, Source: "query"})
+	}
+	for _, name := range productNames {
+		key := strings.ToLower(name)
+		if idx, exists := seen[key]; exists {
+			// Prefer the product's own capitalization
+			suggestions[idx].Text = name
+			suggestions[idx].Source = "product"
+			continue
+		}
+		seen[key] = len(suggestions)
+		suggestions = append(suggestions, SearchSuggestion{Text: name, Source: "product"})
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Frequency > suggestions[j].Frequency
+	})
+	if len(suggestions) > maxSearchSuggestions {
+		suggestions = suggestions[:maxSearchSuggestions]
+	}
+
+	return c.JSON(fiber.Map{
+		"query":       prefix,
+		"suggestions": suggestions,
+	})
+}
+
+// maxDidYouMeanSuggestions is the number of alternatives offered for a search without results
+const maxDidYouMeanSuggestions = 5
+
+// DidYouMeanSuggestion is an alternative search text for a search without results
+type DidYouMeanSuggestion struct {
+	Text       string  `json:"text"`
+	Similarity float64 `json:"similarity"` // Trigram similarity to the search text, from 0 to 1
+	Source     string  `json:"source"`     // 'query' or 'product'
+}
+
+// findDidYouMeanSuggestions looks for product names and popular past queries that are
+// spelled like the search text, using pg_trgm trigram similarity. Failures, such as
+// a database without pg_trgm, yield no suggestions.
+func findDidYouMeanSuggestions(ctx context.Context, query string) []DidYouMeanSuggestion {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if len(query) > 100 {
+		query = query[:100]
+	}
+
+	// Keep the lookups within a tight latency budget
+	ctx, cancel := context.WithTimeout(ctx, searchSuggestionTimeout)
+	defer cancel()
+	db := database.DB.WithContext(ctx)
+
+	var candidates []DidYouMeanSuggestion
+
+	// Past queries that found something, compared as a whole
+	var pastQueries []DidYouMeanSuggestion
+	if err := db.Model(&models.SearchQuery{}).
+		Select("LOWER(TRIM(query)) AS text, similarity(LOWER(TRIM(query)), ?) AS similarity, 'query' AS source", query).
+		Where("LOWER(TRIM(query)) % ? AND results_count > 0 AND created_at > ?", query, time.Now().Add(-searchSuggestionHistory)).
+		Group("LOWER(TRIM(query))").
+		Order("similarity DESC, COUNT(*) DESC").
+		Limit(maxDidYouMeanSuggestions).
+		Scan(&pastQueries).Error; err != nil {
+		log.Printf("Did you mean: query history lookup failed: %v", err)
+	}
+	candidates = append(candidates, pastQueries...)
+
+	// Product names, where the search text only has to match part of the name
+	var productNames []DidYouMeanSuggestion
+	if err := db.Model(&models.Product{}).
+		Select("name AS text, word_similarity(?, LOWER(name)) AS similarity, 'product' AS source", query).
+		Where("? <% LOWER(name) AND stock > 0", query).
+		Order("similarity DESC, name ASC").
+		Limit(maxDidYouMeanSuggestions).
+		Scan(&productNames).Error; err != nil {
+		log.Printf("Did you mean: product name lookup failed: %v", err)
+	}
+	candidates = append(candidates, productNames...)
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Similarity > candidates[j].Similarity
+	})
+
+	// De-duplicate case-insensitively, never suggesting the search itself
+	suggestions := make([]DidYouMeanSuggestion, 0, maxDidYouMeanSuggestions)
+	seen := map[string]bool{query: true}
+	for _, candidate := range candidates {
+		key := strings.ToLower(candidate.Text)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		suggestions = append(suggestions, candidate)
+		if len(suggestions) == maxDidYouMeanSuggestions {
+			break
 		}
 	}
+	return suggestions
+}
 
-	return filteredResults, total, nil
+// escapeLikePattern escapes LIKE wildcards so user input matches literally
+func escapeLikePattern(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
 }
 
 // ProductSearchResult represents a search result with relevance score
 type ProductSearchResult struct {
 	models.Product
-	RelevanceScore int `json:"relevance_score"`
+	RelevanceScore float64 `json:"relevance_score"` // ts_rank of the full-text match
+}
+
+// Enhanced search query tracking. The search query is stored in the background under
+// the returned ID.
+func trackSearchQueryWithResults(c *fiber.Ctx, query string, resultsCount int) uuid.UUID {
+	// Read the request now; the context is reused once the handler returns
+	searchQuery := models.SearchQuery{
+		ID:           uuid.New(),
+		Query:        query,
+		ResultsCount: resultsCount,
+	}
+	if owner, ok := resolveCartOwner(c); ok {
+		searchQuery.UserID = owner.UserID
+		if owner.UserID == nil {
+			searchQuery.SessionID = &owner.SessionID
+		}
+	}
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Error in trackSearchQueryWithResults: %v", r)
+			}
+		}()
+
+		if err := database.DB.Create(&searchQuery).Error; err != nil {
+			log.Printf("Failed to track search query: %v", err)
+		}
+	}()
+
+	return searchQuery.ID
+}
+
+// RecordSearchClickRequest reports a search result that was opened
+type RecordSearchClickRequest struct {
+	SearchQueryID string `json:"search_query_id" validate:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174000"` // search_query_id from the search response
+	ProductID     string `json:"product_id" validate:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174001"`
+	Position      *int   `json:"position" validate:"omitempty,min=1" example:"3"` // 1-based rank of the result on the page
+}
+
+// RecordSearchClick records that a search result was opened
+// @Summary Record search result click
+// @Description Record that a product was opened from a search, using the search_query_id returned by /products/search. Each product counts once per search towards the search's results_clicked and the query's click-through rate. Only the user or guest session (X-Session-ID) that made the search can record clicks on it.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Session-ID header string false "Guest session ID, used when not signed in"
+// @Param request body RecordSearchClickRequest true "Search and clicked product"
+// @Success 200 {object} map[string]interface{} "Click recorded"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 404 {object} map[string]interface{} "Search query or product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /products/search/click [post]
+func RecordSearchClick(c *fiber.Ctx) error {
+	var req RecordSearchClickRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var searchQuery models.SearchQuery
+	if err := database.DB.First(&searchQuery, "id = ?", req.SearchQueryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Search query not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch search query",
+		})
+	}
+	if !madeSearchQuery(c, searchQuery) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Search query not found",
+		})
+	}
+
+	var product models.Product
+	if err := database.DB.Select("id").First(&product, "id = ?", req.ProductID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Product not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch product",
+		})
+	}
+
+	var clickThroughRate float64
+	recorded := false
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.SearchClick{
+			SearchQueryID: searchQuery.ID,
+			ProductID:     product.ID,
+			Position:      req.Position,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		recorded = result.RowsAffected > 0
+
+		if recorded {
+			if err := tx.Model(&searchQuery).
+				UpdateColumn("results_clicked", gorm.Expr("results_clicked + 1")).Error; err != nil {
+				return err
+			}
+		}
+
+		var err error
+		clickThroughRate, err = updateSearchClickThroughRate(tx, searchQuery)
+		return err
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to record search click",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":            "Search click recorded",
+		"recorded":           recorded, // False when the product was already clicked from this search
+		"click_through_rate": clickThroughRate,
+	})
 }
 
-// Enhanced search query tracking
-func trackSearchQueryWithResults(c *fiber.Ctx, query string, resultsCount int) {
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Printf("Error in trackSearchQueryWithResults: %v", r)
-			}
-		}()
-
-		var userID *uuid.UUID
-		if id, ok := middleware.GetUserID(c); ok {
-			userID = &id
-		}
+// madeSearchQuery reports whether the caller made the search: the signed-in user for
+// their searches, the guest session for its own. Searches tracked before sessions were
+// recorded have neither and stay open.
+func madeSearchQuery(c *fiber.Ctx, searchQuery models.SearchQuery) bool {
+	if searchQuery.UserID != nil {
+		userID, ok := middleware.GetUserID(c)
+		return ok && userID == *searchQuery.UserID
+	}
+	if searchQuery.SessionID != nil {
+		return strings.TrimSpace(c.Get("X-Session-ID")) == *searchQuery.SessionID
+	}
+	return true
+}
 
-		searchQuery := models.SearchQuery{
-			UserID:       userID,
-			Query:        query,
-			ResultsCount: resultsCount,
-		}
+// updateSearchClickThroughRate recomputes the share of searches for a query that had
+// a result opened and stores it in the query's search analytics
+func updateSearchClickThroughRate(tx *gorm.DB, searchQuery models.SearchQuery) (float64, error) {
+	query := strings.ToLower(strings.TrimSpace(searchQuery.Query))
+
+	var clickThroughRate float64
+	if err := tx.Model(&models.SearchQuery{}).
+		Select("COALESCE(AVG(CASE WHEN results_clicked > 0 THEN 1.0 ELSE 0 END), 0)").
+		Where("LOWER(TRIM(query)) = ?", query).
+		Scan(&clickThroughRate).Error; err != nil {
+		return 0, err
+	}
 
-		if err := database.DB.Create(&searchQuery).Error; err != nil {
-			log.Printf("Failed to track search query: %v", err)
-		}
-	}()
+	analytics := models.SearchAnalytics{
+		Query:            query,
+		ResultsCount:     searchQuery.ResultsCount,
+		ClickThroughRate: clickThroughRate,
+	}
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "query"}},
+		DoUpdates: clause.AssignmentColumns([]string{"results_count", "click_through_rate"}),
+	}).Create(&analytics).Error
+	return clickThroughRate, err
 }
 
 // GetRecommendations returns ML-generated product recommendations
 // @Summary Get product recommendations
-// @Description Get personalized product recommendations using ML algorithms with reasoning
+// @Description Get personalized product recommendations using ML algorithms with reasoning. Products the user dismissed within RECOMMENDATION_DISMISS_DAYS (default 30) days are left out. Stored recommendations are regenerated in the background once older than RECOMMENDATION_STALE_HOURS (default 6).
 // @Tags Products
 // @Accept json
 // @Produce json
@@ -453,12 +1109,21 @@ func GetRecommendations(c *fiber.Ctx) error {
 
 	limit, _ := strconv.Atoi(c.Query("limit", "10"))
 
-	// Try to get fresh recommendations from ML service
-	go generateMLRecommendations(userID, limit)
+	// Users in a running experiment only see their variant's recommendations
+	algorithm, inExperiment := services.RecommendationAlgorithmFor(userID)
+
+	// Regenerate in the background only once the stored recommendations have gone stale
+	refreshRecommendationsIfStale(userID, algorithm, limit)
 
 	// Get recommendations from database with reasoning
+	query := database.DB.Where("user_id = ?", userID).
+		Scopes(recentlyDismissedProducts(userID, "product_id"))
+	if inExperiment {
+		query = query.Where("algorithm_type = ?", algorithm)
+	}
+
 	var recommendations []models.Recommendation
-	if err := database.DB.Where("user_id = ?", userID).
+	if err := query.
 		Preload("Product").
 		Order("score DESC").
 		Limit(limit).
@@ -485,7 +1150,8 @@ func GetRecommendations(c *fiber.Ctx) error {
 	// If no recommendations found, return popular products with reasoning
 	if len(products) == 0 {
 		var popularProducts []models.Product
-		if err := database.DB.Order("created_at DESC").Limit(limit).Find(&popularProducts).Error; err != nil {
+		if err := database.DB.Scopes(recentlyDismissedProducts(userID, "id")).
+			Order("created_at DESC").Limit(limit).Find(&popularProducts).Error; err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to fetch popular products",
 			})
@@ -508,6 +1174,9 @@ func GetRecommendations(c *fiber.Ctx) error {
 		}
 	}
 
+	// Record what was actually shown so click-through rates are measured against impressions
+	recordRecommendationImpressions(userID, products)
+
 	// Get user insights for context
 	userInsights := getUserRecommendationInsights(userID)
 
@@ -526,6 +1195,35 @@ type ProductRecommendationResponse struct {
 	Algorithm     string                  `json:"algorithm"`
 	Reasoning     RecommendationReasoning `json:"reasoning"`
 	IsRecommended bool                    `json:"is_recommended"`
+	ImpressionID  *uuid.UUID              `json:"impression_id,omitempty"` // Pass back when reporting a click
+}
+
+// recordRecommendationImpressions stores an impression for each returned item and sets
+// its impression ID. Failures are logged; the recommendations are still returned.
+func recordRecommendationImpressions(userID uuid.UUID, products []ProductRecommendationResponse) {
+	if len(products) == 0 {
+		return
+	}
+
+	impressions := make([]models.RecommendationImpression, len(products))
+	for i, product := range products {
+		impressions[i] = models.RecommendationImpression{
+			UserID:    userID,
+			ProductID: product.ID,
+			Algorithm: product.Algorithm,
+			Position:  i,
+			Score:     product.Score,
+		}
+	}
+
+	if err := database.DB.Create(&impressions).Error; err != nil {
+		log.Printf("Failed to record recommendation impressions for user %s: %v", userID, err)
+		return
+	}
+
+	for i := range products {
+		products[i].ImpressionID = &impressions[i].ID
+	}
 }
 
 // RecommendationReasoning explains why an item is recommended
@@ -604,7 +1302,7 @@ func generateRecommendationReasoning(userID uuid.UUID, rec models.Recommendation
 	}
 
 	// Check price range preference
-	var avgSpent float64
+	var avgSpent float64 // In cents, like the prices it is compared to
 	database.DB.Table("order_items oi").
 		Joins("JOIN orders o ON oi.order_id = o.id").
 		Joins("JOIN products p ON oi.product_id = p.id").
@@ -613,7 +1311,7 @@ func generateRecommendationReasoning(userID uuid.UUID, rec models.Recommendation
 		Scan(&avgSpent)
 
 	if avgSpent > 0 {
-		priceDiff := (rec.Product.Price - avgSpent) / avgSpent
+		priceDiff := (float64(rec.Product.Price) - avgSpent) / avgSpent
 		if priceDiff < 0.2 && priceDiff > -0.2 {
 			factors = append(factors, "Within your usual price range")
 		}
@@ -745,6 +1443,7 @@ func trackProductViews(c *fiber.Ctx, products []models.Product) {
 				UserID:    userID,
 				ProductID: product.ID,
 				SessionID: sessionID,
+				Source:    productViewList,
 			})
 		}
 
@@ -761,39 +1460,113 @@ func GetMLStatus(c *fiber.Ctx) error {
 	status, err := services.MLService.GetMLStatus()
 	if err != nil {
 		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-			"error":   "ML service unavailable",
-			"details": err.Error(),
+			"error":           "ML service unavailable",
+			"details":         err.Error(),
+			"circuit_breaker": services.MLCircuitBreaker.Status(),
 		})
 	}
 
+	if status == nil {
+		status = map[string]interface{}{}
+	}
+	status["circuit_breaker"] = services.MLCircuitBreaker.Status()
 	return c.JSON(status)
 }
 
 // TrainMLModels triggers training of ML models
+// @Summary Train ML models
+// @Description Start training the ML models in the background (admin only). Returns the training job, whose status is available at /ml/training/{jobId}; the admin is notified when training finishes. Only one job runs at a time.
+// @Tags ML
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 202 {object} map[string]interface{} "Training started"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 409 {object} map[string]interface{} "Training is already running"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /ml/train [post]
 func TrainMLModels(c *fiber.Ctx) error {
-	// Check if user is authenticated (you might want to add admin check here)
-	_, ok := middleware.GetUserID(c)
+	userID, ok := middleware.GetUserID(c)
 	if !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "Authentication required",
 		})
 	}
 
-	err := services.MLService.TrainModels()
+	job, err := services.StartTrainingJob(userID)
 	if err != nil {
+		if errors.Is(err, services.ErrTrainingInProgress) {
+			var running models.TrainingJob
+			database.DB.Where("status = ?", services.TrainingJobRunning).First(&running)
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "ML training is already running",
+				"job":   running,
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Failed to train ML models",
+			"error":   "Failed to start ML training",
 			"details": err.Error(),
 		})
 	}
 
-	return c.JSON(fiber.Map{
-		"message": "ML model training initiated successfully",
-		"status":  "success",
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "ML model training started",
+		"status":  job.Status,
+		"job":     job,
 	})
 }
 
+// GetTrainingJob returns the status of an ML training job
+// @Summary Get training job
+// @Description Get the status of an ML training job (admin only): running, completed or failed, with the error of a failed job
+// @Tags ML
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param jobId path string true "Training job ID (UUID)"
+// @Success 200 {object} models.TrainingJob "Training job retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid job ID"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Training job not found"
+// @Router /ml/training/{jobId} [get]
+func GetTrainingJob(c *fiber.Ctx) error {
+	jobID, err := uuid.Parse(c.Params("jobId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid job ID",
+		})
+	}
+
+	var job models.TrainingJob
+	if err := database.DB.First(&job, "id = ?", jobID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Training job not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch training job",
+		})
+	}
+
+	return c.JSON(job)
+}
+
 func trackSingleProductView(c *fiber.Ctx, productID uuid.UUID) {
+	// Read the request now; the context is reused once the handler returns
+	var userID *uuid.UUID
+	if id, ok := middleware.GetUserID(c); ok {
+		userID = &id
+	}
+	view := models.ProductView{
+		UserID:    userID,
+		ProductID: productID,
+		SessionID: c.Get("X-Session-ID"),
+		Source:    productViewDetail,
+	}
+
 	// Use a separate goroutine with proper error handling
 	go func() {
 		defer func() {
@@ -802,17 +1575,6 @@ func trackSingleProductView(c *fiber.Ctx, productID uuid.UUID) {
 			}
 		}()
 
-		var userID *uuid.UUID
-		if id, ok := middleware.GetUserID(c); ok {
-			userID = &id
-		}
-
-		view := models.ProductView{
-			UserID:    userID,
-			ProductID: productID,
-			SessionID: c.Get("X-Session-ID"),
-		}
-
 		if err := database.DB.Create(&view).Error; err != nil {
 			log.Printf("Failed to track single product view: %v", err)
 		}
@@ -841,6 +1603,89 @@ func trackUserInteraction(userID, productID uuid.UUID, interactionType, sessionI
 	}()
 }
 
+// recommendationRetryInterval is the minimum time between background regenerations
+// for one user, so a failing or slow ML service isn't asked again on every request
+const recommendationRetryInterval = 10 * time.Minute
+
+var (
+	recommendationRefreshMu sync.Mutex
+	recommendationRefreshes = make(map[uuid.UUID]time.Time) // Last regeneration started per user
+)
+
+// recommendationsStale reports whether the user's newest stored recommendations for
+// an algorithm are older than the staleness window, or missing
+func recommendationsStale(userID uuid.UUID, algorithm string) bool {
+	var latest *time.Time
+	if err := database.DB.Model(&models.Recommendation{}).
+		Select("MAX(generated_at)").
+		Where("user_id = ? AND algorithm_type = ?", userID, algorithm).
+		Scan(&latest).Error; err != nil {
+		return false
+	}
+	return latest == nil || time.Since(*latest) > services.RecommendationStaleness()
+}
+
+// markRecommendationRefresh records that a regeneration starts now, reporting false if
+// one was started for the user within the retry interval
+func markRecommendationRefresh(userID uuid.UUID) bool {
+	recommendationRefreshMu.Lock()
+	defer recommendationRefreshMu.Unlock()
+
+	now := time.Now()
+	for id, started := range recommendationRefreshes {
+		if now.Sub(started) >= recommendationRetryInterval {
+			delete(recommendationRefreshes, id)
+		}
+	}
+
+	if _, exists := recommendationRefreshes[userID]; exists {
+		return false
+	}
+	recommendationRefreshes[userID] = now
+	return true
+}
+
+// refreshRecommendationsIfStale regenerates a user's recommendations in the background
+// when the stored ones are stale and no regeneration was started recently
+func refreshRecommendationsIfStale(userID uuid.UUID, algorithm string, limit int) {
+	if !recommendationsStale(userID, algorithm) || !markRecommendationRefresh(userID) {
+		return
+	}
+	generateMLRecommendations(userID, limit)
+}
+
+// RefreshRecommendations forces regeneration of the user's recommendations
+// @Summary Refresh recommendations
+// @Description Regenerate the current user's recommendations from the ML service now, regardless of how fresh the stored ones are. Regeneration runs in the background; fetch GET /products/recommendations afterwards. Recommendations are otherwise regenerated automatically once older than RECOMMENDATION_STALE_HOURS (default 6).
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Number of recommendations to generate" default(10)
+// @Success 202 {object} map[string]interface{} "Regeneration started"
+// @Failure 401 {object} map[string]interface{} "Authentication required for recommendations"
+// @Router /products/recommendations/refresh [post]
+func RefreshRecommendations(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required for recommendations",
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+
+	recommendationRefreshMu.Lock()
+	recommendationRefreshes[userID] = time.Now()
+	recommendationRefreshMu.Unlock()
+
+	generateMLRecommendations(userID, limit)
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Recommendations are being regenerated",
+	})
+}
+
 // generateMLRecommendations calls the ML service to generate recommendations
 func generateMLRecommendations(userID uuid.UUID, limit int) {
 	// Use a separate goroutine with proper error handling
@@ -851,10 +1696,16 @@ func generateMLRecommendations(userID uuid.UUID, limit int) {
 			}
 		}()
 
-		// Try to call ML service first
-		mlRecommendations, err := services.MLService.GenerateRecommendations(userID, "hybrid", limit)
+		preference := loadUserPreference(userID)
+		favorited := favoritedCategories(userID)
+		algorithm, _ := services.RecommendationAlgorithmFor(userID)
+
+		// Try to call ML service first. Over-fetch so items filtered out by the
+		// user's preferences can be replaced, and store only the filtered list.
+		mlRecommendations, err := services.MLService.PreviewRecommendations(userID, algorithm, limit*2)
 		if err == nil && mlRecommendations != nil {
 			// Save ML recommendations to database with batch insert
+			generatedAt := time.Now()
 			recommendations := make([]models.Recommendation, 0, len(mlRecommendations.Recommendations))
 
 			for _, mlRec := range mlRecommendations.Recommendations {
@@ -867,13 +1718,16 @@ func generateMLRecommendations(userID uuid.UUID, limit int) {
 				recommendations = append(recommendations, models.Recommendation{
 					UserID:        userID,
 					ProductID:     productUUID,
-					AlgorithmType: mlRec.Algorithm,
+					AlgorithmType: algorithm, // The requested algorithm, which experiment reports and filters rely on
 					Score:         mlRec.Score,
+					GeneratedAt:   generatedAt,
 				})
 			}
 
+			recommendations = applyUserPreference(preference, favorited, recommendations, limit)
+
 			if len(recommendations) > 0 {
-				if err := database.DB.CreateInBatches(recommendations, 100).Error; err != nil {
+				if err := saveRecommendations(recommendations); err != nil {
 					log.Printf("Failed to save ML recommendations: %v", err)
 				}
 			}
@@ -882,31 +1736,51 @@ func generateMLRecommendations(userID uuid.UUID, limit int) {
 
 		// Fallback: Check if user already has recent recommendations
 		var count int64
-		if err := database.DB.Model(&models.Recommendation{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		if err := database.DB.Model(&models.Recommendation{}).Where("user_id = ? AND algorithm_type = ?", userID, algorithm).Count(&count).Error; err != nil {
 			log.Printf("Failed to count existing recommendations: %v", err)
 			return
 		}
 
 		if count == 0 {
-			// Create some sample recommendations for demo purposes
+			// Create some sample recommendations for demo purposes, honoring preferences
 			var products []models.Product
-			if err := database.DB.Limit(limit).Find(&products).Error; err != nil {
+			if err := preferenceFilteredProducts(preference).Limit(limit * 3).Find(&products).Error; err != nil {
 				log.Printf("Failed to fetch products for fallback recommendations: %v", err)
 				return
 			}
 
+			// Preferred categories first, then categories the user has favorited
+			preferred := categorySet(preference.PreferredCategories)
+			rank := func(product models.Product) int {
+				category := strings.ToLower(product.Category)
+				switch {
+				case preferred[category]:
+					return 2
+				case favorited[category]:
+					return 1
+				}
+				return 0
+			}
+			sort.SliceStable(products, func(i, j int) bool {
+				return rank(products[i]) > rank(products[j])
+			})
+			if len(products) > limit {
+				products = products[:limit]
+			}
+
 			recommendations := make([]models.Recommendation, 0, len(products))
 			for i, product := range products {
 				recommendations = append(recommendations, models.Recommendation{
 					UserID:        userID,
 					ProductID:     product.ID,
-					AlgorithmType: "hybrid",
+					AlgorithmType: algorithm,
 					Score:         float64(limit-i) / float64(limit), // Decreasing scores
+					GeneratedAt:   time.Now(),
 				})
 			}
 
 			if len(recommendations) > 0 {
-				if err := database.DB.CreateInBatches(recommendations, 100).Error; err != nil {
+				if err := saveRecommendations(recommendations); err != nil {
 					log.Printf("Failed to create fallback recommendations: %v", err)
 				}
 			}
@@ -914,6 +1788,151 @@ func generateMLRecommendations(userID uuid.UUID, limit int) {
 	}()
 }
 
+// saveRecommendations upserts recommendations so regenerating refreshes existing scores
+func saveRecommendations(recommendations []models.Recommendation) error {
+	return database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "product_id"}, {Name: "algorithm_type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"score", "generated_at", "created_at"}),
+	}).CreateInBatches(recommendations, 100).Error
+}
+
+// preferredCategoryBoost is applied to the score of recommendations in preferred categories
+const preferredCategoryBoost = 1.25
+
+// favoritedCategoryBoost is applied to the score of recommendations in categories
+// the user has favorited products from
+const favoritedCategoryBoost = 1.15
+
+// applyUserPreference drops recommendations that violate the user's preferences,
+// boosts preferred and favorited categories and returns at most limit items ordered by score
+func applyUserPreference(preference models.UserPreference, favorited map[string]bool, recommendations []models.Recommendation, limit int) []models.Recommendation {
+	if len(recommendations) == 0 {
+		return recommendations
+	}
+
+	productIDs := make([]uuid.UUID, 0, len(recommendations))
+	for _, rec := range recommendations {
+		productIDs = append(productIDs, rec.ProductID)
+	}
+
+	var products []models.Product
+	if err := preferenceFilteredProducts(preference).Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+		log.Printf("Failed to apply user preferences to recommendations: %v", err)
+		return recommendations
+	}
+
+	allowed := make(map[uuid.UUID]models.Product, len(products))
+	for _, product := range products {
+		allowed[product.ID] = product
+	}
+
+	preferred := categorySet(preference.PreferredCategories)
+	filtered := make([]models.Recommendation, 0, len(recommendations))
+	for _, rec := range recommendations {
+		product, ok := allowed[rec.ProductID]
+		if !ok {
+			continue
+		}
+		if preferred[strings.ToLower(product.Category)] {
+			rec.Score = math.Min(1, rec.Score*preferredCategoryBoost)
+		}
+		if favorited[strings.ToLower(product.Category)] {
+			rec.Score = math.Min(1, rec.Score*favoritedCategoryBoost)
+		}
+		filtered = append(filtered, rec)
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].Score > filtered[j].Score
+	})
+	if len(filtered) > limit {
+		filtered = filtered[:limit]
+	}
+
+	return filtered
+}
+
+// preferenceFilteredProducts returns a product query excluding avoided categories
+// and products outside the preferred price range
+func preferenceFilteredProducts(preference models.UserPreference) *gorm.DB {
+	query := database.DB.Model(&models.Product{})
+
+	if len(preference.AvoidedCategories) > 0 {
+		avoided := make([]string, 0, len(preference.AvoidedCategories))
+		for _, category := range preference.AvoidedCategories {
+			avoided = append(avoided, strings.ToLower(category))
+		}
+		query = query.Where("LOWER(category) NOT IN ?", avoided)
+	}
+	if preference.MinPrice != nil {
+		query = query.Where("price >= ?", *preference.MinPrice)
+	}
+	if preference.MaxPrice != nil {
+		query = query.Where("price <= ?", *preference.MaxPrice)
+	}
+
+	return query
+}
+
+// pruneRecommendationsForPreference removes stored recommendations that no longer
+// satisfy the user's preferences
+func pruneRecommendationsForPreference(preference models.UserPreference) error {
+	allowedProducts := preferenceFilteredProducts(preference).Select("id")
+	return database.DB.
+		Where("user_id = ? AND product_id NOT IN (?)", preference.UserID, allowedProducts).
+		Delete(&models.Recommendation{}).Error
+}
+
+// favoritedCategories returns the lower-cased categories of products the user has favorited
+func favoritedCategories(userID uuid.UUID) map[string]bool {
+	var categories []string
+	if err := database.DB.Model(&models.Product{}).
+		Joins("JOIN favorites ON favorites.product_id = products.id").
+		Where("favorites.user_id = ?", userID).
+		Distinct().
+		Pluck("products.category", &categories).Error; err != nil {
+		log.Printf("Failed to load favorited categories: %v", err)
+	}
+	return categorySet(categories)
+}
+
+// categorySet builds a lower-cased lookup set of categories
+func categorySet(categories []string) map[string]bool {
+	set := make(map[string]bool, len(categories))
+	for _, category := range categories {
+		set[strings.ToLower(category)] = true
+	}
+	return set
+}
+
+// GetProductBySKU returns a single product by its SKU
+// @Summary Get product by SKU
+// @Description Look up a product by SKU, e.g. from a barcode scanner
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param sku path string true "Product SKU"
+// @Success 200 {object} models.Product "Product retrieved successfully"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Router /products/sku/{sku} [get]
+func GetProductBySKU(c *fiber.Ctx) error {
+	sku := normalizeSKU(c.Params("sku"))
+
+	var product models.Product
+	if err := database.DB.Preload("Images", orderedImages).Where("sku = ?", sku).First(&product).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Product not found",
+		})
+	}
+
+	return c.JSON(product)
+}
+
+// normalizeSKU trims and upper-cases a SKU so lookups are case-insensitive
+func normalizeSKU(sku string) string {
+	return strings.ToUpper(strings.TrimSpace(sku))
+}
+
 // CreateProduct creates a new product (admin only)
 // @Summary Create a new product
 // @Description Create a new product in the catalog (admin access required)
@@ -925,7 +1944,9 @@ func generateMLRecommendations(userID uuid.UUID, limit int) {
 // @Success 201 {object} map[string]interface{} "Product created successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
 // @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 409 {object} map[string]interface{} "Product with this SKU already exists"
 // @Router /products [post]
 func CreateProduct(c *fiber.Ctx) error {
 	userID, ok := middleware.GetUserID(c)
@@ -952,23 +1973,44 @@ func CreateProduct(c *fiber.Ctx) error {
 		})
 	}
 
+	category, err := resolveProductCategory(database.DB, req.CategoryID, req.Category)
+	if err != nil {
+		return fiberErrorResponse(c, err)
+	}
+
 	// Create product
+	sku := normalizeSKU(req.SKU)
 	product := models.Product{
 		Name:        req.Name,
 		Description: req.Description,
 		Price:       req.Price,
-		Category:    req.Category,
+		Currency:    services.BaseCurrency(),
+		Category:    category.Name,
+		CategoryID:  &category.ID,
+		Brand:       req.Brand,
+		SKU:         &sku,
+		Barcode:     req.Barcode,
 		Stock:       req.Stock,
 		ImageURL:    req.ImageURL,
 	}
 
 	if err := database.DB.Create(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"success": false,
+				"error":   "A product with SKU " + sku + " already exists",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"error":   "Failed to create product",
 		})
 	}
 
+	// Product data changed, so cached listings, the product and category counts are stale
+	invalidateCatalogCache(product.ID)
+	invalidateCategoryCache()
+
 	// Track admin action
 	go trackUserInteraction(userID, product.ID, "admin_create", c.Get("X-Session-ID"))
 
@@ -991,8 +2033,10 @@ func CreateProduct(c *fiber.Ctx) error {
 // @Success 200 {object} map[string]interface{} "Product updated successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid request body or product ID"
 // @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
 // @Failure 404 {object} map[string]interface{} "Product not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 409 {object} map[string]interface{} "Product with this SKU already exists"
 // @Router /products/{id} [put]
 func UpdateProduct(c *fiber.Ctx) error {
 	userID, ok := middleware.GetUserID(c)
@@ -1038,6 +2082,7 @@ func UpdateProduct(c *fiber.Ctx) error {
 	}
 
 	// Update fields if provided
+	oldPrice := product.Price
 	if req.Name != "" {
 		product.Name = req.Name
 	}
@@ -1047,8 +2092,23 @@ func UpdateProduct(c *fiber.Ctx) error {
 	if req.Price > 0 {
 		product.Price = req.Price
 	}
-	if req.Category != "" {
-		product.Category = req.Category
+	if req.CategoryID != "" || req.Category != "" {
+		category, err := resolveProductCategory(database.DB, req.CategoryID, req.Category)
+		if err != nil {
+			return fiberErrorResponse(c, err)
+		}
+		product.Category = category.Name
+		product.CategoryID = &category.ID
+	}
+	if req.Brand != "" {
+		product.Brand = req.Brand
+	}
+	if req.SKU != "" {
+		sku := normalizeSKU(req.SKU)
+		product.SKU = &sku
+	}
+	if req.Barcode != "" {
+		product.Barcode = req.Barcode
 	}
 	if req.Stock >= 0 {
 		product.Stock = req.Stock
@@ -1057,13 +2117,28 @@ func UpdateProduct(c *fiber.Ctx) error {
 		product.ImageURL = req.ImageURL
 	}
 
-	if err := database.DB.Save(&product).Error; err != nil {
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&product).Error; err != nil {
+			return err
+		}
+		return recordPriceChange(tx, product.ID, oldPrice, product.Price)
+	}); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"success": false,
+				"error":   "A product with SKU " + *product.SKU + " already exists",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"error":   "Failed to update product",
 		})
 	}
 
+	// Product data changed, so cached listings, the product and category counts are stale
+	invalidateCatalogCache(product.ID)
+	invalidateCategoryCache()
+
 	// Track admin action
 	go trackUserInteraction(userID, product.ID, "admin_update", c.Get("X-Session-ID"))
 
@@ -1076,15 +2151,16 @@ func UpdateProduct(c *fiber.Ctx) error {
 
 // DeleteProduct deletes a product (admin only)
 // @Summary Delete a product
-// @Description Delete a product from the catalog (admin access required)
+// @Description Soft-delete a product from the catalog (admin access required). Order history keeps referencing it and GET /products/{id} returns 410 afterwards.
 // @Tags Products
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Product ID (UUID)"
 // @Success 200 {object} map[string]interface{} "Product deleted successfully"
-// @Failure 400 {object} map[string]interface{} "Invalid product ID or product has order history"
+// @Failure 400 {object} map[string]interface{} "Invalid product ID"
 // @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
 // @Failure 404 {object} map[string]interface{} "Product not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /products/{id} [delete]
@@ -1115,35 +2191,143 @@ func DeleteProduct(c *fiber.Ctx) error {
 		})
 	}
 
-	// Check if product has any orders (prevent deletion if it has order history)
-	var orderCount int64
-	if err := database.DB.Model(&models.OrderItem{}).Where("product_id = ?", id).Count(&orderCount).Error; err != nil {
+	// Soft-delete the product so order history keeps referencing it, and drop
+	// cart items and favorites that point at it
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := services.RemoveProductReferences(tx, product.ID); err != nil {
+			return err
+		}
+		return tx.Delete(&product).Error
+	}); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
-			"error":   "Failed to check product dependencies",
+			"error":   "Failed to delete product",
+		})
+	}
+
+	// Product data changed, so cached listings, the product and category counts are stale
+	invalidateCatalogCache(product.ID)
+	invalidateCategoryCache()
+
+	// Track admin action
+	go trackUserInteraction(userID, product.ID, "admin_delete", c.Get("X-Session-ID"))
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Product deleted successfully",
+	})
+}
+
+// ReceiveShipment records an inbound shipment and increases product stock (admin only)
+// @Summary Receive shipment
+// @Description Increase a product's stock from an inbound shipment, identified by product ID or SKU. Records a stock movement with the shipment reference and notifies users waiting for the product to come back in stock.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body ReceiveShipmentRequest true "Shipment data"
+// @Success 200 {object} map[string]interface{} "Shipment received successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /products/receive-shipment [post]
+func ReceiveShipment(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "Authentication required",
+		})
+	}
+
+	var req ReceiveShipmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
 		})
 	}
 
-	if orderCount > 0 {
+	if (req.ProductID == "") == (req.SKU == "") {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
-			"error":   "Cannot delete product with existing order history",
+			"error":   "Either product_id or sku must be provided, but not both",
 		})
 	}
 
-	// Delete the product
-	if err := database.DB.Delete(&product).Error; err != nil {
+	var product models.Product
+	var movement models.StockMovement
+	var previousStock int
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		lookup := tx.Model(&models.Product{})
+		if req.ProductID != "" {
+			lookup = lookup.Where("id = ?", req.ProductID)
+		} else {
+			lookup = lookup.Where("sku = ?", normalizeSKU(req.SKU))
+		}
+		if err := lookup.First(&product).Error; err != nil {
+			return err
+		}
+
+		// Relative update so concurrent stock changes are not overwritten
+		if err := tx.Model(&product).
+			UpdateColumn("stock", gorm.Expr("stock + ?", req.Quantity)).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&product, product.ID).Error; err != nil {
+			return err
+		}
+		previousStock = product.Stock - req.Quantity
+
+		movement = models.StockMovement{
+			ProductID:  product.ID,
+			Delta:      req.Quantity,
+			StockAfter: product.Stock,
+			Reason:     "shipment_received",
+			Reference:  req.Reference,
+			CreatedBy:  &userID,
+		}
+		return tx.Create(&movement).Error
+	})
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"success": false,
+				"error":   "Product not found",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
-			"error":   "Failed to delete product",
+			"error":   "Failed to receive shipment",
 		})
 	}
 
-	// Track admin action
-	go trackUserInteraction(userID, product.ID, "admin_delete", c.Get("X-Session-ID"))
+	// Stock levels changed, so cached product listings and the product are stale
+	invalidateCatalogCache(product.ID)
+
+	// Let users waiting on this product know it is available again
+	backInStock := previousStock <= 0 && product.Stock > 0
+	if backInStock {
+		go services.NotifyBackInStock(product)
+	}
 
 	return c.JSON(fiber.Map{
-		"success": true,
-		"message": "Product deleted successfully",
+		"success":        true,
+		"message":        "Shipment received successfully",
+		"product":        product,
+		"previous_stock": previousStock,
+		"movement":       movement,
+		"back_in_stock":  backInStock,
 	})
 }
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bachelor_backend/database/dbtest"
+	"bachelor_backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func TestRecordSearchClickOwnership(t *testing.T) {
+	db := dbtest.Open(t)
+	product := dbtest.CreateProduct(t, db, 1000, 5)
+	searcher := dbtest.CreateUser(t, db)
+	other := dbtest.CreateUser(t, db)
+	session := "session-" + uuid.NewString()
+
+	userSearch := models.SearchQuery{ID: uuid.New(), UserID: &searcher.ID, Query: "lamp"}
+	guestSearch := models.SearchQuery{ID: uuid.New(), SessionID: &session, Query: "lamp"}
+	for _, searchQuery := range []*models.SearchQuery{&userSearch, &guestSearch} {
+		if err := db.Create(searchQuery).Error; err != nil {
+			t.Fatalf("failed to create search query: %v", err)
+		}
+		t.Cleanup(func() {
+			if err := db.Delete(&models.SearchQuery{}, "id = ?", searchQuery.ID).Error; err != nil {
+				t.Errorf("failed to delete search query: %v", err)
+			}
+		})
+	}
+
+	tests := []struct {
+		name        string
+		searchQuery models.SearchQuery
+		userID      *uuid.UUID
+		sessionID   string
+		want        int
+	}{
+		{"another user", userSearch, &other.ID, "", fiber.StatusNotFound},
+		{"anonymous caller on a user's search", userSearch, nil, session, fiber.StatusNotFound},
+		{"the searching user", userSearch, &searcher.ID, "", fiber.StatusOK},
+		{"another session", guestSearch, nil, "session-" + uuid.NewString(), fiber.StatusNotFound},
+		{"the searching session", guestSearch, nil, session, fiber.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Post("/", func(c *fiber.Ctx) error {
+				if tt.userID != nil {
+					c.Locals("user_id", *tt.userID)
+				}
+				return c.Next()
+			}, RecordSearchClick)
+
+			body := `{"search_query_id":"` + tt.searchQuery.ID.String() + `","product_id":"` + product.ID.String() + `"}`
+			req := httptest.NewRequest(fiber.MethodPost, "/", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.sessionID != "" {
+				req.Header.Set("X-Session-ID", tt.sessionID)
+			}
+
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Product view sources
+const (
+	productViewDetail = "detail" // The product page was opened
+	productViewList   = "list"   // The product was shown in a listing
+)
+
+// RecentlyViewedProduct is a product with when the viewer last opened it
+type RecentlyViewedProduct struct {
+	Product  models.Product `json:"product"`
+	ViewedAt time.Time      `json:"viewed_at"`
+}
+
+// GetRecentlyViewedProducts returns the products the viewer opened most recently
+// @Summary Get recently viewed products
+// @Description Get the distinct products the current user (or, for guests, the X-Session-ID session) most recently opened, newest first. Each product appears once, at its latest view. Only product page views count, not listings, and products already in the cart or removed from the catalog are left out.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Session-ID header string false "Guest session ID, used when not signed in"
+// @Param limit query int false "Number of products (max 50)" default(10)
+// @Success 200 {object} map[string]interface{} "Recently viewed products retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Neither signed in nor a session ID provided"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /products/recently-viewed [get]
+func GetRecentlyViewedProducts(c *fiber.Ctx) error {
+	owner, ok := resolveCartOwner(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Sign in or provide an X-Session-ID header",
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	inCart := database.DB.Table("cart_items").
+		Select("cart_items.product_id").
+		Joins("JOIN shopping_carts ON cart_items.cart_id = shopping_carts.id").
+		Scopes(owner.scope)
+
+	query := database.DB.Model(&models.ProductView{}).
+		Select("product_views.product_id, MAX(product_views.created_at) AS viewed_at").
+		Joins("JOIN products ON products.id = product_views.product_id AND products.deleted_at IS NULL").
+		Where("product_views.source = ?", productViewDetail).
+		Where("product_views.product_id NOT IN (?)", inCart)
+	if owner.UserID != nil {
+		query = query.Where("product_views.user_id = ?", *owner.UserID)
+	} else {
+		query = query.Where("product_views.user_id IS NULL AND product_views.session_id = ?", owner.SessionID)
+	}
+
+	var views []struct {
+		ProductID uuid.UUID
+		ViewedAt  time.Time
+	}
+	if err := query.Group("product_views.product_id").
+		Order("viewed_at DESC").
+		Limit(limit).
+		Scan(&views).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch recently viewed products",
+		})
+	}
+
+	productIDs := make([]uuid.UUID, len(views))
+	for i, view := range views {
+		productIDs[i] = view.ProductID
+	}
+
+	var products []models.Product
+	if len(productIDs) > 0 {
+		if err := database.DB.Preload("Images", orderedImages).Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch recently viewed products",
+			})
+		}
+	}
+	byID := make(map[uuid.UUID]models.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+
+	result := make([]RecentlyViewedProduct, 0, len(views))
+	for _, view := range views {
+		if product, exists := byID[view.ProductID]; exists {
+			result = append(result, RecentlyViewedProduct{Product: product, ViewedAt: view.ViewedAt})
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"products": result,
+		"count":    len(result),
+	})
+}
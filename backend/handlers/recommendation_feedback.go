@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/middleware"
+	"bachelor_backend/models"
+	"bachelor_backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// recommendationClickWindow is how long after an impression a click without an
+// explicit impression ID is attributed to it
+const recommendationClickWindow = 24 * time.Hour
+
+// RecommendationClickRequest represents an optional body for reporting a recommendation click
+type RecommendationClickRequest struct {
+	ImpressionID *uuid.UUID `json:"impression_id,omitempty" example:"123e4567-e89b-12d3-a456-426614174000"`
+}
+
+// RecordRecommendationClick records a click on a recommended product
+// @Summary Record recommendation click
+// @Description Record that the current user clicked a product they were recommended. The click is tied to an impression: the one given by impression_id (as returned by GET /products/recommendations), or otherwise the user's latest impression of the product from the last 24 hours. Each impression counts at most one click; repeated clicks return the existing feedback.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param productId path string true "Product ID (UUID)"
+// @Param request body RecommendationClickRequest false "Impression the click came from"
+// @Success 201 {object} map[string]interface{} "Click recorded"
+// @Success 200 {object} map[string]interface{} "Click already recorded for this impression"
+// @Failure 400 {object} map[string]interface{} "Invalid product or impression ID"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 404 {object} map[string]interface{} "No recommendation impression found for this product"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /recommendations/{productId}/click [post]
+func RecordRecommendationClick(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	productID, err := uuid.Parse(c.Params("productId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+
+	var req RecommendationClickRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	query := database.DB.Where("user_id = ? AND product_id = ?", userID, productID)
+	if req.ImpressionID != nil {
+		query = query.Where("id = ?", *req.ImpressionID)
+	} else {
+		query = query.Where("created_at >= ?", time.Now().Add(-recommendationClickWindow))
+	}
+
+	var impression models.RecommendationImpression
+	if err := query.Order("created_at DESC").First(&impression).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "No recommendation impression found for this product",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to record click",
+		})
+	}
+
+	var feedback models.RecommendationFeedback
+	err = database.DB.Where("impression_id = ? AND feedback_type = ?", impression.ID, "clicked").First(&feedback).Error
+	if err == nil {
+		return c.JSON(fiber.Map{
+			"message":  "Click already recorded",
+			"feedback": feedback,
+		})
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to record click",
+		})
+	}
+
+	feedback = models.RecommendationFeedback{
+		UserID:       userID,
+		ProductID:    productID,
+		FeedbackType: "clicked",
+		ImpressionID: &impression.ID,
+	}
+	if err := database.DB.Create(&feedback).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to record click",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":  "Click recorded",
+		"feedback": feedback,
+	})
+}
+
+// recentlyDismissedProducts scopes a query to skip products the user dismissed within
+// the dismissal period
+func recentlyDismissedProducts(userID uuid.UUID, column string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		dismissed := database.DB.Model(&models.RecommendationFeedback{}).
+			Select("product_id").
+			Where("user_id = ? AND feedback_type = ? AND created_at >= ?",
+				userID, "dismissed", time.Now().Add(-services.RecommendationDismissalPeriod()))
+		return db.Where(column+" NOT IN (?)", dismissed)
+	}
+}
+
+// DismissRecommendation hides a recommended product from the user for a while
+// @Summary Dismiss recommendation
+// @Description Record that the current user is not interested in a recommended product. The product is left out of their recommendations for RECOMMENDATION_DISMISS_DAYS (default 30) days and the dismissal is kept as negative feedback for the recommender. Dismissing again restarts the period.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param productId path string true "Product ID (UUID)"
+// @Success 201 {object} map[string]interface{} "Recommendation dismissed"
+// @Failure 400 {object} map[string]interface{} "Invalid product ID"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /recommendations/{productId}/dismiss [post]
+func DismissRecommendation(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	productID, err := uuid.Parse(c.Params("productId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+
+	var product models.Product
+	if err := database.DB.Select("id").First(&product, "id = ?", productID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Product not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to dismiss recommendation",
+		})
+	}
+
+	feedback := models.RecommendationFeedback{
+		UserID:       userID,
+		ProductID:    productID,
+		FeedbackType: "dismissed",
+	}
+
+	// Tie the dismissal to the impression it was made from, when there is one
+	var impression models.RecommendationImpression
+	if err := database.DB.Where("user_id = ? AND product_id = ? AND created_at >= ?", userID, productID, time.Now().Add(-recommendationClickWindow)).
+		Order("created_at DESC").
+		First(&impression).Error; err == nil {
+		feedback.ImpressionID = &impression.ID
+	}
+
+	if err := database.DB.Create(&feedback).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to dismiss recommendation",
+		})
+	}
+
+	period := services.RecommendationDismissalPeriod()
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":      "Recommendation dismissed",
+		"feedback":     feedback,
+		"hidden_until": feedback.CreatedAt.Add(period),
+	})
+}
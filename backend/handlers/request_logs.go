@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"net"
+	"strconv"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/models"
+	"bachelor_backend/pkg/pagination"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// defaultRequestLogWindow is how far back request logs are searched without a from parameter
+const defaultRequestLogWindow = 24 * time.Hour
+
+// StatusClassCount is the number of requests in a status class such as 4xx
+type StatusClassCount struct {
+	Class string `json:"class"`
+	Count int64  `json:"count"`
+}
+
+// SlowPath is an endpoint ranked by its average response time
+type SlowPath struct {
+	Method          string  `json:"method"`
+	Path            string  `json:"path"`
+	Requests        int64   `json:"requests"`
+	AvgResponseTime float64 `json:"avg_response_time"`
+	MaxResponseTime float64 `json:"max_response_time"`
+}
+
+// GetRequestLogs godoc
+// @Summary Get request logs
+// @Description Search logged requests for incident investigation (admin only). Without from, only the last 24 hours are searched. Aggregates cover every matching request, not just the current page.
+// @Tags Security
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param ip query string false "Client IP address"
+// @Param path_prefix query string false "Request path prefix" example(/api/orders)
+// @Param method query string false "HTTP method"
+// @Param status_min query int false "Minimum status code" minimum(100) maximum(599)
+// @Param status_max query int false "Maximum status code" minimum(100) maximum(599)
+// @Param user_id query string false "User ID" format(uuid)
+// @Param from query string false "Start of the time window (RFC 3339)"
+// @Param to query string false "End of the time window (RFC 3339)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(50)
+// @Success 200 {object} map[string]interface{} "Request logs with aggregates"
+// @Failure 400 {object} StandardErrorResponse "Invalid filter"
+// @Failure 401 {object} StandardErrorResponse "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} StandardErrorResponse "Internal server error"
+// @Router /security/requests [get]
+func GetRequestLogs(c *fiber.Ctx) error {
+	filtered, window, err := requestLogFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+
+	logs, meta, err := pagination.Paginate[models.RequestLog](
+		filtered().Order("timestamp DESC"), page, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to get request logs",
+		})
+	}
+
+	var statusClasses []StatusClassCount
+	if err := filtered().
+		Select("(status_code / 100)::text || 'xx' AS class, COUNT(*) AS count").
+		Group("class").
+		Order("class").
+		Scan(&statusClasses).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to aggregate request logs",
+		})
+	}
+
+	var slowestPaths []SlowPath
+	if err := filtered().
+		Select("method, path, COUNT(*) AS requests, AVG(response_time) AS avg_response_time, MAX(response_time) AS max_response_time").
+		Group("method, path").
+		Order("avg_response_time DESC").
+		Limit(10).
+		Scan(&slowestPaths).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to aggregate request logs",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"requests": logs,
+			"aggregates": fiber.Map{
+				"status_classes": statusClasses,
+				"slowest_paths":  slowestPaths,
+			},
+			"window": window,
+		},
+		"pagination": meta,
+	})
+}
+
+// requestLogFilter validates the request log filters and returns a builder for
+// fresh queries with them applied, along with the effective time window
+func requestLogFilter(c *fiber.Ctx) (func() *gorm.DB, fiber.Map, error) {
+	to := time.Now()
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, nil, fiber.NewError(fiber.StatusBadRequest, "to must be an RFC 3339 timestamp")
+		}
+		to = parsed
+	}
+
+	from := to.Add(-defaultRequestLogWindow)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, nil, fiber.NewError(fiber.StatusBadRequest, "from must be an RFC 3339 timestamp")
+		}
+		from = parsed
+	}
+	if !from.Before(to) {
+		return nil, nil, fiber.NewError(fiber.StatusBadRequest, "from must be before to")
+	}
+
+	ip := c.Query("ip")
+	if ip != "" && net.ParseIP(ip) == nil {
+		return nil, nil, fiber.NewError(fiber.StatusBadRequest, "ip must be a valid IP address")
+	}
+
+	var userID *uuid.UUID
+	if value := c.Query("user_id"); value != "" {
+		parsed, err := uuid.Parse(value)
+		if err != nil {
+			return nil, nil, fiber.NewError(fiber.StatusBadRequest, "Invalid user ID format")
+		}
+		userID = &parsed
+	}
+
+	statusMin := c.QueryInt("status_min", 0)
+	statusMax := c.QueryInt("status_max", 0)
+	if statusMin < 0 || statusMax < 0 || (statusMax > 0 && statusMin > statusMax) {
+		return nil, nil, fiber.NewError(fiber.StatusBadRequest, "Invalid status code range")
+	}
+
+	pathPrefix := c.Query("path_prefix")
+	method := c.Query("method")
+
+	build := func() *gorm.DB {
+		query := database.DB.Model(&models.RequestLog{}).
+			Where("timestamp >= ? AND timestamp < ?", from, to)
+		if ip != "" {
+			query = query.Where("ip_address = ?", ip)
+		}
+		if pathPrefix != "" {
+			query = query.Where("path LIKE ?", escapeLikePattern(pathPrefix)+"%")
+		}
+		if method != "" {
+			query = query.Where("method = ?", method)
+		}
+		if statusMin > 0 {
+			query = query.Where("status_code >= ?", statusMin)
+		}
+		if statusMax > 0 {
+			query = query.Where("status_code <= ?", statusMax)
+		}
+		if userID != nil {
+			query = query.Where("user_id = ?", *userID)
+		}
+		return query
+	}
+
+	return build, fiber.Map{"from": from, "to": to}, nil
+}
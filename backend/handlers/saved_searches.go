@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/middleware"
+	"bachelor_backend/models"
+	"bachelor_backend/pkg/money"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxSavedSearchesPerUser caps how many searches a user can save
+const maxSavedSearchesPerUser = 20
+
+// SavedSearchRequest represents the request to save a search or replace a saved one
+type SavedSearchRequest struct {
+	Query    string      `json:"query" validate:"required,min=1,max=200" example:"mechanical keyboard"`
+	Category string      `json:"category,omitempty" validate:"omitempty,max=100" example:"Electronics"`
+	MinPrice money.Cents `json:"min_price,omitempty" validate:"omitempty,min=0" swaggertype:"string" example:"50.00"`
+	MaxPrice money.Cents `json:"max_price,omitempty" validate:"omitempty,min=0" swaggertype:"string" example:"150.00"` // Omit for no upper bound
+}
+
+// validateSavedSearchRequest checks what the struct tags cannot express
+func validateSavedSearchRequest(req *SavedSearchRequest) string {
+	req.Query = strings.TrimSpace(req.Query)
+	req.Category = strings.TrimSpace(req.Category)
+	if buildPrefixTSQuery(req.Query) == "" {
+		return "Search query must contain at least one word"
+	}
+	if req.MaxPrice > 0 && req.MinPrice > req.MaxPrice {
+		return "min_price cannot be greater than max_price"
+	}
+	return ""
+}
+
+// FindSavedSearchMatches runs a saved search over the products created after since.
+// It is registered with the saved search scanner.
+func FindSavedSearchMatches(search models.SavedSearch, since time.Time, limit int) ([]models.Product, int64, error) {
+	maxPrice := search.Filters.MaxPrice
+	if maxPrice <= 0 {
+		maxPrice = searchNoMaxPrice
+	}
+
+	results, total, err := performEnhancedSearch(search.Query, search.Filters.Category, search.Filters.MinPrice, maxPrice, since, 0, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	products := make([]models.Product, 0, len(results))
+	for _, result := range results {
+		products = append(products, result.Product)
+	}
+	return products, total, nil
+}
+
+// GetSavedSearches returns the current user's saved searches
+// @Summary Get saved searches
+// @Description Get the searches the current user saved, oldest first
+// @Tags Saved Searches
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Saved searches retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /saved-searches [get]
+func GetSavedSearches(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var searches []models.SavedSearch
+	if err := database.DB.Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&searches).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch saved searches",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"saved_searches": searches,
+	})
+}
+
+// GetSavedSearch returns one of the current user's saved searches
+// @Summary Get saved search
+// @Description Get a saved search of the current user
+// @Tags Saved Searches
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Saved search ID (UUID)"
+// @Success 200 {object} map[string]interface{} "Saved search retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid saved search ID"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 404 {object} map[string]interface{} "Saved search not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /saved-searches/{id} [get]
+func GetSavedSearch(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	searchID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid saved search ID",
+		})
+	}
+
+	var search models.SavedSearch
+	if err := database.DB.Where("id = ? AND user_id = ?", searchID, userID).First(&search).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Saved search not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch saved search",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"saved_search": search,
+	})
+}
+
+// CreateSavedSearch saves a search for the current user
+// @Summary Save search
+// @Description Save a search query with optional category and price filters. The saved search is re-run periodically and a saved_search notification is sent when products added since the last check match it. Only products created after saving are reported.
+// @Tags Saved Searches
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body SavedSearchRequest true "Search to save"
+// @Success 201 {object} map[string]interface{} "Search saved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request or saved search limit reached"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /saved-searches [post]
+func CreateSavedSearch(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var req SavedSearchRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if msg := validateSavedSearchRequest(&req); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	var existing int64
+	if err := database.DB.Model(&models.SavedSearch{}).Where("user_id = ?", userID).Count(&existing).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save search",
+		})
+	}
+	if existing >= maxSavedSearchesPerUser {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Saved search limit reached",
+			"limit": maxSavedSearchesPerUser,
+		})
+	}
+
+	search := models.SavedSearch{
+		UserID: userID,
+		Query:  req.Query,
+		Filters: models.SavedSearchFilters{
+			Category: req.Category,
+			MinPrice: req.MinPrice,
+			MaxPrice: req.MaxPrice,
+		},
+		LastChecked: time.Now(),
+	}
+	if err := database.DB.Create(&search).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save search",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":      "Search saved successfully",
+		"saved_search": search,
+	})
+}
+
+// UpdateSavedSearch replaces the query and filters of one of the current user's saved searches
+// @Summary Update saved search
+// @Description Replace the query and filters of a saved search. Products added before the update are not reported for the new criteria.
+// @Tags Saved Searches
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Saved search ID (UUID)"
+// @Param request body SavedSearchRequest true "New search criteria"
+// @Success 200 {object} map[string]interface{} "Saved search updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 404 {object} map[string]interface{} "Saved search not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /saved-searches/{id} [put]
+func UpdateSavedSearch(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	searchID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid saved search ID",
+		})
+	}
+
+	var search models.SavedSearch
+	if err := database.DB.Where("id = ? AND user_id = ?", searchID, userID).First(&search).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Saved search not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch saved search",
+		})
+	}
+
+	var req SavedSearchRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if msg := validateSavedSearchRequest(&req); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	search.Query = req.Query
+	search.Filters = models.SavedSearchFilters{
+		Category: req.Category,
+		MinPrice: req.MinPrice,
+		MaxPrice: req.MaxPrice,
+	}
+	search.LastChecked = time.Now()
+	if err := database.DB.Save(&search).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update saved search",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":      "Saved search updated successfully",
+		"saved_search": search,
+	})
+}
+
+// DeleteSavedSearch deletes one of the current user's saved searches
+// @Summary Delete saved search
+// @Description Delete a saved search and stop its alerts. Notifications already sent remain.
+// @Tags Saved Searches
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Saved search ID (UUID)"
+// @Success 200 {object} map[string]interface{} "Saved search deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid saved search ID"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 404 {object} map[string]interface{} "Saved search not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /saved-searches/{id} [delete]
+func DeleteSavedSearch(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	searchID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid saved search ID",
+		})
+	}
+
+	result := database.DB.Where("id = ? AND user_id = ?", searchID, userID).Delete(&models.SavedSearch{})
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete saved search",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Saved search not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Saved search deleted successfully",
+	})
+}
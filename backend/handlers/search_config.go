@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"bachelor_backend/middleware"
+	"bachelor_backend/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetSearchConfig returns the search ranking weights
+// @Summary Get search configuration
+// @Description Get the ranking weights of product search (admin only): how much a match in the name, category or description counts, each between 0 and 1. Source is 'database' once an admin has set the weights and 'environment' before that.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Search configuration retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/search-config [get]
+func GetSearchConfig(c *fiber.Ctx) error {
+	config, err := services.GetSearchConfig()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch search configuration",
+		})
+	}
+
+	response := fiber.Map{
+		"weights":  services.DefaultSearchWeights(),
+		"defaults": services.DefaultSearchWeights(),
+		"source":   "environment",
+	}
+	if config != nil {
+		response["weights"] = services.SearchWeights{
+			Name:        config.NameWeight,
+			Category:    config.CategoryWeight,
+			Description: config.DescriptionWeight,
+		}
+		response["source"] = "database"
+		response["updated_by"] = config.UpdatedBy
+		response["updated_at"] = config.UpdatedAt
+	}
+
+	return c.JSON(response)
+}
+
+// UpdateSearchConfig sets the search ranking weights
+// @Summary Update search configuration
+// @Description Set the ranking weights of product search (admin only). Each weight is between 0 and 1; searches use the new weights right away.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.SearchWeights true "Ranking weights"
+// @Success 200 {object} map[string]interface{} "Search configuration updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid weights"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/search-config [put]
+func UpdateSearchConfig(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	// Weights left out of the request keep their current value
+	weights := services.LoadSearchWeights()
+	if err := c.BodyParser(&weights); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := middleware.ValidateStruct(&weights); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if weights.Name+weights.Category+weights.Description == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "At least one weight must be above zero",
+		})
+	}
+
+	config, err := services.SaveSearchWeights(weights, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update search configuration",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Search configuration updated successfully",
+		"weights":    weights,
+		"updated_by": config.UpdatedBy,
+		"updated_at": config.UpdatedAt,
+	})
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"log"
 	"strconv"
+	"strings"
 	"time"
 
+	"bachelor_backend/database"
 	"bachelor_backend/middleware"
 	"bachelor_backend/models"
 	"bachelor_backend/services"
@@ -103,7 +106,7 @@ func GetAnomalyInsights(c *fiber.Ctx) error {
 
 // GetAttackPatterns godoc
 // @Summary Get Attack Patterns
-// @Description Get information about detectable attack patterns and security threats
+// @Description Get information about detectable attack patterns and security threats. Falls back to a built-in catalog (source: local) when the ML service is unavailable.
 // @Tags Security
 // @Accept json
 // @Produce json
@@ -115,14 +118,18 @@ func GetAnomalyInsights(c *fiber.Ctx) error {
 func GetAttackPatterns(c *fiber.Ctx) error {
 	patterns, err := services.AnomalyServiceInstance.GetAttackPatterns()
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error":   "Failed to get attack patterns: " + err.Error(),
+		// Keep the endpoint usable during ML outages with the built-in catalog
+		log.Printf("ML service unavailable for attack patterns, using local catalog: %v", err)
+		return c.JSON(fiber.Map{
+			"success": true,
+			"source":  "local",
+			"data":    services.LocalAttackPatterns(),
 		})
 	}
 
 	return c.JSON(fiber.Map{
 		"success": true,
+		"source":  "ml_service",
 		"data":    patterns,
 	})
 }
@@ -150,7 +157,7 @@ func SimulateAttack(c *fiber.Ctx) error {
 	}
 
 	// Validate attack type
-	validTypes := []string{"sql_injection", "xss", "brute_force", "ddos"}
+	validTypes := services.AttackPatternTypes()
 	isValid := false
 	for _, validType := range validTypes {
 		if attackType == validType {
@@ -162,7 +169,7 @@ func SimulateAttack(c *fiber.Ctx) error {
 	if !isValid {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
-			"error":   "Invalid attack type. Valid types: sql_injection, xss, brute_force, ddos",
+			"error":   "Invalid attack type. Valid types: " + strings.Join(validTypes, ", "),
 		})
 	}
 
@@ -229,12 +236,14 @@ func GetSecurityAlerts(c *fiber.Ctx) error {
 
 // ResolveAlertRequest represents the request to resolve an alert
 type ResolveAlertRequest struct {
-	Notes string `json:"notes" example:"False positive - legitimate admin access"`
+	Notes              string `json:"notes" example:"False positive - legitimate admin access"`
+	BlockIP            bool   `json:"block_ip" example:"false"`          // Also block the alert's IP address
+	BlockDurationHours int    `json:"block_duration_hours" example:"24"` // 0 blocks permanently
 }
 
 // ResolveSecurityAlert godoc
 // @Summary Resolve Security Alert
-// @Description Mark a security alert as resolved with optional notes
+// @Description Mark a security alert as resolved with optional notes. Set block_ip to also block the IP address that raised the alert.
 // @Tags Security
 // @Accept json
 // @Produce json
@@ -244,6 +253,7 @@ type ResolveAlertRequest struct {
 // @Success 200 {object} map[string]interface{} "Alert resolved successfully"
 // @Failure 400 {object} StandardErrorResponse "Bad request - invalid alert ID or request body"
 // @Failure 401 {object} StandardErrorResponse "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
 // @Failure 404 {object} StandardErrorResponse "Alert not found"
 // @Failure 500 {object} StandardErrorResponse "Internal server error"
 // @Router /security/alerts/{alert_id}/resolve [post]
@@ -276,6 +286,13 @@ func ResolveSecurityAlert(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.BlockDurationHours < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "block_duration_hours cannot be negative",
+		})
+	}
+
 	// Resolve the alert
 	err = services.AnomalyServiceInstance.ResolveAlert(alertID, userID, req.Notes)
 	if err != nil {
@@ -291,9 +308,36 @@ func ResolveSecurityAlert(c *fiber.Ctx) error {
 		})
 	}
 
+	if !req.BlockIP {
+		return c.JSON(fiber.Map{
+			"success": true,
+			"message": "Alert resolved successfully",
+		})
+	}
+
+	// Block the offending IP address
+	var alert models.AnomalyAlert
+	if err := database.DB.Select("id", "ip_address").Where("id = ?", alertID).First(&alert).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Alert resolved but failed to block IP address",
+		})
+	}
+
+	block, err := blockIP(alert.IPAddress, "Blocked when resolving security alert "+alertID.String(), req.BlockDurationHours, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Alert resolved but failed to block IP address",
+		})
+	}
+
 	return c.JSON(fiber.Map{
 		"success": true,
-		"message": "Alert resolved successfully",
+		"message": "Alert resolved and IP blocked successfully",
+		"data": fiber.Map{
+			"block": block,
+		},
 	})
 }
 
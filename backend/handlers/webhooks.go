@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"errors"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"bachelor_backend/database"
+	"bachelor_backend/middleware"
+	"bachelor_backend/models"
+	"bachelor_backend/pkg/pagination"
+	"bachelor_backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// minWebhookSecretLength is the shortest signing secret accepted from callers
+const minWebhookSecretLength = 16
+
+// CreateWebhookRequest represents the request to register a webhook
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" example:"https://ops.example.com/hooks/shop"`
+	Secret     string   `json:"secret,omitempty"` // Generated when omitted
+	EventTypes []string `json:"event_types" example:"anomaly.critical,order.created"`
+}
+
+// UpdateWebhookRequest represents a partial update of a webhook
+type UpdateWebhookRequest struct {
+	URL        *string   `json:"url,omitempty"`
+	Secret     *string   `json:"secret,omitempty"`
+	EventTypes *[]string `json:"event_types,omitempty"`
+	Active     *bool     `json:"active,omitempty"`
+}
+
+// GetWebhooks godoc
+// @Summary List webhooks
+// @Description List registered webhooks (admin only). Secrets are never returned.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Webhooks"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /webhooks [get]
+func GetWebhooks(c *fiber.Ctx) error {
+	var webhooks []models.Webhook
+	if err := database.DB.Order("created_at DESC").Find(&webhooks).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch webhooks",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"webhooks":    webhooks,
+		"event_types": services.WebhookEventTypes,
+	})
+}
+
+// CreateWebhook godoc
+// @Summary Register a webhook
+// @Description Register an endpoint to receive signed event payloads (admin only). Each POST carries an X-Signature header with the hex HMAC-SHA256 of the body, prefixed with "sha256=". The secret is only returned in this response.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateWebhookRequest true "Webhook details"
+// @Success 201 {object} map[string]interface{} "Webhook created successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid URL, secret or event types"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /webhooks [post]
+func CreateWebhook(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var req CreateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := validateWebhookURL(req.URL); err != nil {
+		return fiberErrorResponse(c, err)
+	}
+	eventTypes, err := normalizeWebhookEventTypes(req.EventTypes)
+	if err != nil {
+		return fiberErrorResponse(c, err)
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = generateRandomToken(); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to create webhook",
+			})
+		}
+	} else if len(secret) < minWebhookSecretLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "secret must be at least " + strconv.Itoa(minWebhookSecretLength) + " characters",
+		})
+	}
+
+	webhook := models.Webhook{
+		URL:        req.URL,
+		Secret:     secret,
+		EventTypes: eventTypes,
+		Active:     true,
+		CreatedBy:  &userID,
+	}
+	if err := database.DB.Create(&webhook).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create webhook",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Webhook created successfully",
+		"webhook": webhook,
+		"secret":  secret,
+	})
+}
+
+// UpdateWebhook godoc
+// @Summary Update a webhook
+// @Description Change a webhook's URL, secret, event types or active flag (admin only). Pending deliveries to an inactive webhook fail on their next attempt.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Param request body UpdateWebhookRequest true "Fields to update"
+// @Success 200 {object} map[string]interface{} "Webhook updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Webhook not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /webhooks/{id} [put]
+func UpdateWebhook(c *fiber.Ctx) error {
+	webhook, err := findWebhook(c.Params("id"))
+	if err != nil {
+		return fiberErrorResponse(c, err)
+	}
+
+	var req UpdateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL); err != nil {
+			return fiberErrorResponse(c, err)
+		}
+		webhook.URL = *req.URL
+	}
+	if req.Secret != nil {
+		if len(*req.Secret) < minWebhookSecretLength {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "secret must be at least " + strconv.Itoa(minWebhookSecretLength) + " characters",
+			})
+		}
+		webhook.Secret = *req.Secret
+	}
+	if req.EventTypes != nil {
+		eventTypes, err := normalizeWebhookEventTypes(*req.EventTypes)
+		if err != nil {
+			return fiberErrorResponse(c, err)
+		}
+		webhook.EventTypes = eventTypes
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+
+	if err := database.DB.Save(&webhook).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update webhook",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Webhook updated successfully",
+		"webhook": webhook,
+	})
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook
+// @Description Remove a webhook and its delivery history (admin only)
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} map[string]interface{} "Webhook deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid webhook ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Webhook not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /webhooks/{id} [delete]
+func DeleteWebhook(c *fiber.Ctx) error {
+	webhookID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid webhook ID",
+		})
+	}
+
+	result := database.DB.Where("id = ?", webhookID).Delete(&models.Webhook{})
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete webhook",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Webhook not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Webhook deleted successfully",
+	})
+}
+
+// GetWebhookDeliveries godoc
+// @Summary List webhook deliveries
+// @Description List the delivery attempts for a webhook, newest first (admin only)
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Param status query string false "Filter by delivery status" Enums(pending, succeeded, failed)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{} "Webhook deliveries"
+// @Failure 400 {object} map[string]interface{} "Invalid webhook ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Admin role required"
+// @Failure 404 {object} map[string]interface{} "Webhook not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /webhooks/{id}/deliveries [get]
+func GetWebhookDeliveries(c *fiber.Ctx) error {
+	webhook, err := findWebhook(c.Params("id"))
+	if err != nil {
+		return fiberErrorResponse(c, err)
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	query := database.DB.Where("webhook_id = ?", webhook.ID).Order("created_at DESC")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	deliveries, meta, err := pagination.Paginate[models.WebhookDelivery](query, page, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch webhook deliveries",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"deliveries": deliveries,
+		"pagination": meta,
+	})
+}
+
+// findWebhook loads a webhook by its ID parameter
+func findWebhook(rawID string) (models.Webhook, error) {
+	webhookID, err := uuid.Parse(rawID)
+	if err != nil {
+		return models.Webhook{}, fiber.NewError(fiber.StatusBadRequest, "Invalid webhook ID")
+	}
+
+	var webhook models.Webhook
+	if err := database.DB.Where("id = ?", webhookID).First(&webhook).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Webhook{}, fiber.NewError(fiber.StatusNotFound, "Webhook not found")
+		}
+		return models.Webhook{}, fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch webhook")
+	}
+	return webhook, nil
+}
+
+// validateWebhookURL requires an absolute http or https URL
+func validateWebhookURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "url must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// normalizeWebhookEventTypes checks that every event type is known and removes duplicates
+func normalizeWebhookEventTypes(eventTypes []string) ([]string, error) {
+	if len(eventTypes) == 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "event_types must list at least one event")
+	}
+
+	normalized := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		if !slices.Contains(services.WebhookEventTypes, eventType) {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Unknown event type: "+eventType)
+		}
+		if !slices.Contains(normalized, eventType) {
+			normalized = append(normalized, eventType)
+		}
+	}
+	return normalized, nil
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/middleware"
+	"bachelor_backend/models"
+	"bachelor_backend/pkg/pagination"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SharedWishlistItem is a favorited product as shown on a public wishlist
+type SharedWishlistItem struct {
+	Product     models.Product `json:"product"`
+	FavoritedAt time.Time      `json:"favorited_at"`
+}
+
+// ShareWishlist creates a public link to the user's favorites
+// @Summary Share favorites
+// @Description Generate a public read-only link to the user's favorites. Returns the existing link if the favorites are already shared.
+// @Tags Favorites
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Wishlist already shared"
+// @Success 201 {object} map[string]interface{} "Wishlist shared successfully"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /favorites/share [post]
+func ShareWishlist(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var share models.WishlistShare
+	if err := database.DB.Where("user_id = ?", userID).First(&share).Error; err == nil {
+		return c.JSON(fiber.Map{
+			"message": "Wishlist already shared",
+			"share":   share,
+		})
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to share wishlist",
+		})
+	}
+
+	slug, err := generateRandomToken()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to share wishlist",
+		})
+	}
+
+	share = models.WishlistShare{
+		UserID: userID,
+		Slug:   slug,
+	}
+	if err := database.DB.Create(&share).Error; err != nil {
+		// A concurrent request may have shared the list first
+		if errors.Is(err, gorm.ErrDuplicatedKey) &&
+			database.DB.Where("user_id = ?", userID).First(&share).Error == nil {
+			return c.JSON(fiber.Map{
+				"message": "Wishlist already shared",
+				"share":   share,
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to share wishlist",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Wishlist shared successfully",
+		"share":   share,
+	})
+}
+
+// RevokeWishlistShare removes the public link to the user's favorites
+// @Summary Revoke favorites share
+// @Description Revoke the public link to the user's favorites. The old link stops working immediately.
+// @Tags Favorites
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Wishlist share revoked successfully"
+// @Failure 401 {object} map[string]interface{} "User not authenticated"
+// @Failure 404 {object} map[string]interface{} "Wishlist is not shared"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /favorites/share [delete]
+func RevokeWishlistShare(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	result := database.DB.Where("user_id = ?", userID).Delete(&models.WishlistShare{})
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to revoke wishlist share",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Wishlist is not shared",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Wishlist share revoked successfully",
+	})
+}
+
+// GetSharedWishlist returns the favorites behind a public share link
+// @Summary Get shared wishlist
+// @Description Get a user's favorite products through a public share link. The response is read-only and does not include the owner's email or ID.
+// @Tags Favorites
+// @Accept json
+// @Produce json
+// @Param slug path string true "Share slug"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{} "Shared wishlist retrieved successfully"
+// @Failure 404 {object} map[string]interface{} "Wishlist not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /wishlists/{slug} [get]
+func GetSharedWishlist(c *fiber.Ctx) error {
+	var share models.WishlistShare
+	if err := database.DB.Preload("User").Where("slug = ?", c.Params("slug")).First(&share).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Wishlist not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch wishlist",
+		})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	// Only count favorites whose product is still in the catalog
+	available := database.DB.Model(&models.Product{}).Select("id")
+	query := database.DB.Where("user_id = ? AND product_id IN (?)", share.UserID, available).
+		Preload("Product").
+		Order("created_at DESC")
+
+	favorites, meta, err := pagination.Paginate[models.Favorite](query, page, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch wishlist",
+		})
+	}
+
+	items := make([]SharedWishlistItem, 0, len(favorites))
+	for _, favorite := range favorites {
+		if favorite.Product.ID == uuid.Nil {
+			continue
+		}
+		items = append(items, SharedWishlistItem{
+			Product:     favorite.Product,
+			FavoritedAt: favorite.CreatedAt,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"owner_name": share.User.Name,
+		"items":      items,
+		"pagination": meta,
+	})
+}
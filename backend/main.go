@@ -3,9 +3,11 @@ package main
 import (
 	"log"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"bachelor_backend/cache"
 	"bachelor_backend/database"
 	_ "bachelor_backend/docs"
 	"bachelor_backend/handlers"
@@ -49,12 +51,18 @@ import (
 // @tag.name Products
 // @tag.description Product catalog management and search
 
+// @tag.name Categories
+// @tag.description Product category tree
+
 // @tag.name Cart
 // @tag.description Shopping cart operations
 
 // @tag.name Orders
 // @tag.description Order management and tracking
 
+// @tag.name Addresses
+// @tag.description Saved shipping addresses
+
 // @tag.name Analytics
 // @tag.description Business analytics and reporting
 
@@ -69,14 +77,86 @@ func main() {
 
 	log.Println("Database migration completed successfully")
 
+	// Run a maintenance command instead of the server, e.g. `main backfill-daily-stats -days 365`
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
+	// Connect to the Redis catalog cache (optional; falls back to the database)
+	cache.Init()
+	defer cache.Close()
+
 	// Initialize services
 	services.InitializeAnomalyService()
 
+	// Training jobs still marked running were cut off when the previous process stopped
+	services.FailInterruptedTrainingJobs()
+
 	// Start background anomaly analyzer (analyze every 5 minutes)
 	services.BackgroundAnalyzerInstance.Start(5)
 
+	// Start catalog cleaner (remove cart items/favorites for deleted products every hour)
+	services.CatalogCleanerInstance.Start(60)
+
+	// Start token cleaner (remove expired revoked, refresh and password reset tokens every hour)
+	services.TokenCleanerInstance.Start(60)
+
+	// Start reservation sweeper (release expired checkout stock reservations every minute)
+	services.ReservationSweeperInstance.Start(1)
+
+	// Start price alert scanner (notify users when favorited products get cheaper every 5 minutes)
+	services.PriceAlertScannerInstance.Start(5)
+
+	// Start notification dispatcher (rate-limited delivery of queued notifications)
+	services.NotificationDispatcherInstance.Start()
+
+	// Start webhook dispatcher (deliver new events immediately, check for due retries every 30 seconds)
+	services.WebhookDispatcherInstance.Start(30)
+
+	// Start live anomaly analysis of sampled traffic (enabled with LIVE_ANOMALY_ANALYSIS)
+	services.LiveAnomalyAnalyzerInstance.Start()
+	middleware.OnRequestLogged(services.LiveAnomalyAnalyzerInstance.Submit)
+
+	// Start discount scheduler (switch discounts on and off at their start and end dates every minute)
+	services.DiscountSchedulerInstance.Start(1)
+
+	// Start daily rollup job (roll up the previous days into daily user stats, checked every hour)
+	services.DailyRollupJobInstance.Start(60)
+
+	// Start analytics report scheduler (generate due weekly and monthly reports, checked every hour)
+	services.AnalyticsReportSchedulerInstance.SetGenerator(handlers.GenerateAnalyticsReport)
+	services.AnalyticsReportSchedulerInstance.Start(60)
+
+	// Start saved search scanner (notify users about new products matching their saved searches every 30 minutes)
+	services.SavedSearchScannerInstance.SetMatcher(handlers.FindSavedSearchMatches)
+	services.SavedSearchScannerInstance.Start(30)
+
 	// Defer cleanup
 	defer services.BackgroundAnalyzerInstance.Stop()
+	defer services.CatalogCleanerInstance.Stop()
+	defer services.NotificationDispatcherInstance.Stop()
+	defer services.TokenCleanerInstance.Stop()
+	defer services.ReservationSweeperInstance.Stop()
+	defer services.PriceAlertScannerInstance.Stop()
+	defer services.LiveAnomalyAnalyzerInstance.Stop()
+	defer services.WebhookDispatcherInstance.Stop()
+	defer services.DiscountSchedulerInstance.Stop()
+	defer services.DailyRollupJobInstance.Stop()
+	defer services.AnalyticsReportSchedulerInstance.Stop()
+	defer services.SavedSearchScannerInstance.Stop()
+
+	// Start IP blocklist refresher (reload active IP blocks every minute)
+	middleware.IPBlocklistInstance.Start(60)
+	defer middleware.IPBlocklistInstance.Stop()
+
+	// Request body limits: small for auth, large for bulk imports, 4MB elsewhere
+	bodyLimitConfig := middleware.BodyLimitConfig{
+		Limit: getEnvInt("BODY_LIMIT_BYTES", 4*1024*1024),
+		Routes: map[string]int{
+			"/api/v1/auth":          getEnvInt("AUTH_BODY_LIMIT_BYTES", 64*1024),
+			"/api/v1/products/bulk": getEnvInt("BULK_BODY_LIMIT_BYTES", 32*1024*1024),
+		},
+	}
 
 	// Create Fiber app with enhanced configuration
 	app := fiber.New(fiber.Config{
@@ -85,16 +165,31 @@ func main() {
 			if e, ok := err.(*fiber.Error); ok {
 				code = e.Code
 			}
+			if code == fiber.StatusRequestEntityTooLarge {
+				return middleware.BodyTooLarge(c, bodyLimitConfig.MaxLimit())
+			}
 			return c.Status(code).JSON(fiber.Map{
-				"success": false,
-				"error":   err.Error(),
+				"success":    false,
+				"error":      err.Error(),
+				"request_id": middleware.GetRequestID(c),
 			})
 		},
+		// Only trust the client IP header when the request comes from a known proxy;
+		// c.IP() falls back to the connection address otherwise. The proxy should
+		// overwrite the header (e.g. PROXY_HEADER=X-Real-IP) rather than append to it.
+		ProxyHeader:             getEnv("PROXY_HEADER", ""),
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          getEnvList("TRUSTED_PROXIES"),
+		EnableIPValidation:      true,
 		// Security configurations
-		BodyLimit:    4 * 1024 * 1024, // 4MB body limit
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  120 * time.Second,
+		// Bodies are streamed: the server reads at most the smallest limit up front and
+		// middleware.BodyLimit reads the rest only up to the limit of the route
+		BodyLimit:                    bodyLimitConfig.MinLimit(),
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
+		ReadTimeout:                  10 * time.Second,
+		WriteTimeout:                 10 * time.Second,
+		IdleTimeout:                  120 * time.Second,
 		// Disable server header for security
 		DisableStartupMessage: false,
 		ServerHeader:          "",
@@ -113,6 +208,12 @@ func main() {
 		OAuth2RedirectUrl: "http://localhost:8081/swagger/oauth2-redirect.html",
 	}))
 
+	// Request ID middleware (first, so every log line and response carries the ID)
+	app.Use(middleware.RequestID())
+
+	// Per-route request body limits
+	app.Use(middleware.BodyLimit(bodyLimitConfig))
+
 	// Security middleware
 	app.Use(helmet.New(helmet.Config{
 		XSSProtection:         "1; mode=block",
@@ -128,9 +229,14 @@ func main() {
 	// Recovery middleware
 	app.Use(recover.New())
 
+	// Response compression middleware (brotli/gzip via Accept-Encoding)
+	compressionConfig := middleware.DefaultCompressionConfig
+	compressionConfig.MinSize = getEnvInt("COMPRESSION_MIN_SIZE", compressionConfig.MinSize)
+	app.Use(middleware.Compression(compressionConfig))
+
 	// Structured logging middleware
 	app.Use(logger.New(logger.Config{
-		Format:     "[${time}] ${status} - ${method} ${path} - ${ip} - ${latency}\n",
+		Format:     "[${time}] ${locals:request_id} ${status} - ${method} ${path} - ${ip} - ${latency}\n",
 		TimeFormat: "2006-01-02 15:04:05",
 		TimeZone:   "UTC",
 	}))
@@ -144,8 +250,9 @@ func main() {
 		},
 		LimitReached: func(c *fiber.Ctx) error {
 			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"success": false,
-				"error":   "Rate limit exceeded. Please try again later.",
+				"success":    false,
+				"error":      "Rate limit exceeded. Please try again later.",
+				"request_id": middleware.GetRequestID(c),
 			})
 		},
 	}))
@@ -153,7 +260,8 @@ func main() {
 	// CORS middleware with enhanced security and Docker support
 	app.Use(cors.New(cors.Config{
 		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders:     "Origin,Content-Type,Accept,Authorization,X-Session-ID,X-Requested-With",
+		AllowHeaders:     "Origin,Content-Type,Accept,Authorization,X-Session-ID,X-Requested-With,X-Request-ID",
+		ExposeHeaders:    "X-Request-ID",
 		AllowCredentials: true,
 		MaxAge:           86400, // 24 hours
 		// Dynamic origin validation for Docker environments
@@ -204,7 +312,13 @@ func main() {
 	// Request metrics middleware
 	app.Use(middleware.RequestMetrics())
 
-	// Health check endpoint with enhanced information
+	// Prometheus request metrics
+	app.Use(middleware.PrometheusMetrics())
+
+	// Reject blocked IP addresses (after logging so blocked traffic stays visible)
+	app.Use(middleware.BlockIPs())
+
+	// Liveness probe; does not touch dependencies
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{
 			"status":    "ok",
@@ -214,6 +328,12 @@ func main() {
 		})
 	})
 
+	// Readiness probe; checks the database and ML service
+	app.Get("/health/ready", handlers.HealthReady)
+
+	// Prometheus metrics
+	app.Get("/metrics", handlers.GetMetrics)
+
 	// CORS test endpoint for debugging
 	app.All("/cors-test", func(c *fiber.Ctx) error {
 		origin := c.Get("Origin")
@@ -249,39 +369,100 @@ func main() {
 
 	auth.Post("/register", handlers.Register)
 	auth.Post("/login", handlers.Login)
+	auth.Post("/refresh", handlers.RefreshAccessToken)
+	auth.Post("/logout", middleware.AuthRequired(), handlers.Logout)
+	auth.Post("/forgot-password", handlers.ForgotPassword)
+	auth.Post("/reset-password", handlers.ResetPassword)
+	auth.Post("/change-password", middleware.AuthRequired(), handlers.ChangePassword)
 	auth.Get("/profile", middleware.AuthRequired(), handlers.GetProfile)
 	auth.Put("/profile", middleware.AuthRequired(), handlers.UpdateProfile)
+	auth.Get("/notifications", middleware.AuthRequired(), handlers.GetNotificationPreferences)
+	auth.Put("/notifications", middleware.AuthRequired(), handlers.UpdateNotificationPreferences)
 
 	// Product routes
 	products := api.Group("/products")
 	products.Get("/", middleware.OptionalAuth(), handlers.GetProducts)
 	products.Get("/categories", handlers.GetCategories)
 	products.Get("/search", middleware.OptionalAuth(), handlers.SearchProducts)
+	products.Get("/search/suggestions", handlers.GetSearchSuggestions)
+	products.Post("/search/click", middleware.OptionalAuth(), handlers.RecordSearchClick)
+	products.Get("/recently-viewed", middleware.OptionalAuth(), handlers.GetRecentlyViewedProducts)
+	products.Get("/trending", handlers.GetTrendingProducts)
 	products.Get("/recommendations", middleware.AuthRequired(), handlers.GetRecommendations)
+	products.Post("/recommendations/refresh", middleware.AuthRequired(), handlers.RefreshRecommendations)
+	products.Get("/cache/stats", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.GetProductCacheStats)
+	products.Get("/low-stock", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.GetLowStockProducts)
 	products.Get("/category/:category", middleware.OptionalAuth(), handlers.GetProductsByCategory)
+	products.Get("/sku/:sku", handlers.GetProductBySKU)
+	products.Post("/social-counts", middleware.OptionalAuth(), handlers.GetSocialCounts)
 	products.Get("/:id", middleware.OptionalAuth(), handlers.GetProduct)
+	products.Get("/:id/quote", middleware.OptionalAuth(), handlers.GetProductQuote)
+	products.Get("/:id/price-history", handlers.GetProductPriceHistory)
+	products.Get("/:id/frequently-bought-together", handlers.GetFrequentlyBoughtTogether)
+	products.Get("/:id/similar", handlers.GetSimilarProducts)
 
 	// Admin product management routes
-	products.Post("/", middleware.AuthRequired(), handlers.CreateProduct)
-	products.Put("/:id", middleware.AuthRequired(), handlers.UpdateProduct)
-	products.Delete("/:id", middleware.AuthRequired(), handlers.DeleteProduct)
+	products.Post("/", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.CreateProduct)
+	products.Post("/bulk", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.BulkImportProducts)
+	products.Post("/receive-shipment", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.ReceiveShipment)
+	products.Post("/stock-adjust", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.AdjustStock)
+	products.Put("/:id", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.UpdateProduct)
+	products.Post("/:id/images", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.AddProductImage)
+	products.Delete("/:id/images/:imageId", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.DeleteProductImage)
+	products.Put("/:id/images/:imageId/primary", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.SetPrimaryProductImage)
+	products.Post("/:id/variants", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.CreateProductVariant)
+	products.Put("/:id/variants/:variantId", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.UpdateProductVariant)
+	products.Delete("/:id/variants/:variantId", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.DeleteProductVariant)
+	products.Delete("/:id", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.DeleteProduct)
 
 	// Shopping cart routes
-	cart := api.Group("/cart", middleware.AuthRequired())
-	cart.Get("/", handlers.GetCart)
-	cart.Post("/add", handlers.AddToCart)
-	cart.Put("/item/:id", handlers.UpdateCartItem)
-	cart.Delete("/item/:id", handlers.RemoveFromCart)
-	cart.Delete("/clear", handlers.ClearCart)
+	// Guests without a token use the cart for their X-Session-ID header
+	cart := api.Group("/cart")
+	cart.Get("/", middleware.OptionalAuth(), handlers.GetCart)
+	cart.Get("/summary", middleware.OptionalAuth(), handlers.GetCartSummary)
+	cart.Post("/add", middleware.OptionalAuth(), handlers.AddToCart)
+	cart.Put("/item/:id", middleware.OptionalAuth(), handlers.UpdateCartItem)
+	cart.Delete("/item/:id", middleware.OptionalAuth(), handlers.RemoveFromCart)
+	cart.Delete("/clear", middleware.OptionalAuth(), handlers.ClearCart)
+	cart.Post("/merge", middleware.AuthRequired(), handlers.MergeGuestCart)
+	cart.Post("/reserve", middleware.AuthRequired(), handlers.ReserveCart)
+	cart.Post("/apply-coupon", middleware.AuthRequired(), handlers.ApplyCoupon)
+	cart.Delete("/coupon", middleware.AuthRequired(), handlers.RemoveCoupon)
+	cart.Post("/item/:id/save-for-later", middleware.AuthRequired(), handlers.SaveCartItemForLater)
+
+	// Saved-for-later routes
+	savedItems := api.Group("/saved-items", middleware.AuthRequired())
+	savedItems.Get("/", handlers.GetSavedItems)
+	savedItems.Post("/:productId/move-to-cart", handlers.MoveSavedItemToCart)
+	savedItems.Delete("/:productId", handlers.RemoveSavedItem)
 
 	// Order routes
 	orders := api.Group("/orders", middleware.AuthRequired())
 	orders.Get("/", handlers.GetOrders)
 	orders.Get("/stats", handlers.GetOrderStats)
 	orders.Get("/:id", handlers.GetOrder)
+	orders.Get("/:id/history", handlers.GetOrderHistory)
+	orders.Get("/:id/invoice", handlers.GetOrderInvoice)
 	orders.Post("/", handlers.CreateOrder)
-	orders.Put("/:id/status", handlers.UpdateOrderStatus)
+	orders.Put("/:id/status", middleware.RequireRole("admin"), handlers.UpdateOrderStatus)
 	orders.Put("/:id/cancel", handlers.CancelOrder)
+	orders.Put("/:id/items/:itemId/cancel", handlers.CancelOrderItem)
+	orders.Post("/:id/refund", middleware.RequireRole("admin"), handlers.RefundOrder)
+
+	// Category tree routes
+	categories := api.Group("/categories")
+	categories.Get("/", handlers.GetCategoryTree)
+	categories.Get("/:slug/products", handlers.GetCategoryProducts)
+	categories.Post("/", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.CreateCategory)
+	categories.Put("/:id", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.UpdateCategory)
+
+	// Address book routes
+	addresses := api.Group("/addresses", middleware.AuthRequired())
+	addresses.Get("/", handlers.GetAddresses)
+	addresses.Post("/", handlers.CreateAddress)
+	addresses.Get("/:id", handlers.GetAddress)
+	addresses.Put("/:id", handlers.UpdateAddress)
+	addresses.Delete("/:id", handlers.DeleteAddress)
 
 	// Security routes - Anomaly Detection
 	security := api.Group("/security", middleware.AuthRequired())
@@ -290,33 +471,65 @@ func main() {
 	security.Get("/patterns", handlers.GetAttackPatterns)
 	security.Get("/simulate", handlers.SimulateAttack)
 	security.Get("/alerts", handlers.GetSecurityAlerts)
-	security.Post("/alerts/:alert_id/resolve", handlers.ResolveSecurityAlert)
+	security.Post("/alerts/:alert_id/resolve", middleware.RequireRole("admin"), handlers.ResolveSecurityAlert)
 	security.Get("/metrics", handlers.GetSecurityMetrics)
+	security.Get("/requests", middleware.RequireRole("admin"), handlers.GetRequestLogs)
+	security.Get("/blocks", middleware.RequireRole("admin"), handlers.GetIPBlocks)
+	security.Post("/blocks", middleware.RequireRole("admin"), handlers.CreateIPBlock)
+	security.Delete("/blocks/:id", middleware.RequireRole("admin"), handlers.DeleteIPBlock)
+
+	// Webhook routes
+	webhooks := api.Group("/webhooks", middleware.AuthRequired(), middleware.RequireRole("admin"))
+	webhooks.Get("/", handlers.GetWebhooks)
+	webhooks.Post("/", handlers.CreateWebhook)
+	webhooks.Put("/:id", handlers.UpdateWebhook)
+	webhooks.Delete("/:id", handlers.DeleteWebhook)
+	webhooks.Get("/:id/deliveries", handlers.GetWebhookDeliveries)
 
 	// Analytics routes
 	analytics := api.Group("/analytics", middleware.AuthRequired())
 	analytics.Get("/dashboard", handlers.GetDashboard)
 	analytics.Get("/user", handlers.GetUserAnalytics)
 	analytics.Get("/products", handlers.GetProductAnalytics)
+	analytics.Get("/products/export", middleware.RequireRole("admin"), handlers.ExportProductAnalytics)
+	analytics.Get("/funnel", middleware.RequireRole("admin"), handlers.GetFunnelAnalytics)
 	analytics.Get("/trends", handlers.GetMLTrends)
+	analytics.Get("/forecast", middleware.RequireRole("admin"), handlers.GetRevenueForecast)
 	analytics.Get("/search", handlers.GetSearchAnalytics)
 	analytics.Get("/recommendations/metrics", handlers.GetRecommendationMetrics)
+	analytics.Get("/experiments", middleware.RequireRole("admin"), handlers.GetExperiments)
+	analytics.Post("/experiments", middleware.RequireRole("admin"), handlers.CreateExperiment)
+	analytics.Get("/experiments/:id", middleware.RequireRole("admin"), handlers.GetExperiment)
+	analytics.Put("/experiments/:id/status", middleware.RequireRole("admin"), handlers.UpdateExperimentStatus)
 	analytics.Get("/export", handlers.ExportAnalytics)
+	analytics.Get("/schedules", handlers.GetAnalyticsSchedules)
+	analytics.Post("/schedules", handlers.CreateAnalyticsSchedule)
+	analytics.Delete("/schedules/:id", handlers.DeleteAnalyticsSchedule)
+	analytics.Get("/reports", handlers.GetGeneratedReports)
+	analytics.Get("/reports/:id/download", handlers.DownloadGeneratedReport)
 
 	// ML routes
 	ml := api.Group("/ml")
 	ml.Get("/status", handlers.GetMLStatus)
-	ml.Post("/train", middleware.AuthRequired(), handlers.TrainMLModels)
+	ml.Post("/train", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.TrainMLModels)
+	ml.Get("/training/:jobId", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.GetTrainingJob)
+	ml.Get("/recommendations/preview", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.PreviewRecommendations)
+	ml.Get("/taste-profile", middleware.AuthRequired(), handlers.GetTasteProfile)
+	ml.Get("/preferences", middleware.AuthRequired(), handlers.GetUserPreferences)
+	ml.Put("/preferences", middleware.AuthRequired(), handlers.UpdateUserPreferences)
 
 	// New ML service routes
 	// Sentiment Analysis
 	ml.Get("/sentiment/product/:id", middleware.AuthRequired(), handlers.GetProductSentiment)
+	ml.Post("/sentiment/batch", middleware.AuthRequired(), handlers.GetBatchSentiment)
 	ml.Get("/sentiment/category/:category", middleware.AuthRequired(), handlers.GetCategorySentiment)
 	ml.Get("/sentiment/insights", middleware.AuthRequired(), handlers.GetSentimentInsights)
+	ml.Get("/sentiment/compare", middleware.AuthRequired(), handlers.CompareCategorySentiment)
 
 	// Auto-Tagging
 	ml.Get("/auto-tagging/suggest/:id", middleware.AuthRequired(), handlers.SuggestProductTags)
-	ml.Post("/auto-tagging/auto-tag", middleware.AuthRequired(), handlers.AutoTagProducts)
+	ml.Post("/auto-tagging/apply/:id", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.ApplySuggestedTags)
+	ml.Post("/auto-tagging/auto-tag", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.AutoTagProducts)
 	ml.Get("/auto-tagging/insights", middleware.AuthRequired(), handlers.GetTaggingInsights)
 
 	// Smart Discounts
@@ -325,15 +538,27 @@ func main() {
 	ml.Get("/smart-discounts/insights", middleware.AuthRequired(), handlers.GetDiscountInsights)
 
 	// ML Services Management
-	ml.Post("/initialize-services", middleware.AuthRequired(), handlers.InitializeMLServices)
+	ml.Post("/initialize-services", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.InitializeMLServices)
+
+	// Recommendation feedback
+	recommendations := api.Group("/recommendations", middleware.AuthRequired())
+	recommendations.Post("/:productId/click", handlers.RecordRecommendationClick)
+	recommendations.Post("/:productId/dismiss", handlers.DismissRecommendation)
 
 	// Data enrichment routes
 	// Favorites
 	favorites := api.Group("/favorites", middleware.AuthRequired())
 	favorites.Get("/", handlers.GetFavorites)
+	favorites.Get("/recommendations", handlers.GetFavoriteRecommendations)
 	favorites.Post("/", handlers.AddFavorite)
+	favorites.Post("/share", handlers.ShareWishlist)
+	favorites.Delete("/share", handlers.RevokeWishlistShare)
 	favorites.Delete("/:product_id", handlers.RemoveFavorite)
 
+	// Shared wishlists (public)
+	wishlists := api.Group("/wishlists")
+	wishlists.Get("/:slug", handlers.GetSharedWishlist)
+
 	// Upvotes
 	upvotes := api.Group("/upvotes")
 	upvotes.Get("/:product_id", handlers.GetProductUpvotes)
@@ -347,17 +572,45 @@ func main() {
 	comments.Put("/:comment_id", middleware.AuthRequired(), handlers.UpdateComment)
 	comments.Delete("/:comment_id", middleware.AuthRequired(), handlers.DeleteComment)
 
+	// Admin settings
+	admin := api.Group("/admin", middleware.AuthRequired(), middleware.RequireRole("admin"))
+	admin.Get("/search-config", handlers.GetSearchConfig)
+	admin.Put("/search-config", handlers.UpdateSearchConfig)
+
 	// Tags
 	tags := api.Group("/tags")
 	tags.Get("/", handlers.GetTags)
-	tags.Post("/", middleware.AuthRequired(), handlers.CreateTag)
+	tags.Post("/", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.CreateTag)
 	tags.Get("/products/:product_id", handlers.GetProductTags)
-	tags.Post("/products", middleware.AuthRequired(), handlers.AddProductTag)
+	tags.Get("/:id/products", handlers.GetTagProducts)
+	tags.Delete("/products", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.RemoveProductTag)
+	tags.Delete("/:id", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.DeleteTag)
+	tags.Post("/products", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.AddProductTag)
+	tags.Post("/bulk-assign", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.BulkAssignTag)
+
+	// Notifications
+	notifications := api.Group("/notifications", middleware.AuthRequired())
+	notifications.Get("/", handlers.GetNotifications)
+	notifications.Post("/read-all", handlers.MarkAllNotificationsRead)
+	notifications.Post("/:id/read", handlers.MarkNotificationRead)
+	notifications.Get("/dispatch/stats", middleware.RequireRole("admin"), handlers.GetNotificationDispatchStats)
+	notifications.Post("/suppressions", middleware.RequireRole("admin"), handlers.AddNotificationSuppression)
+	notifications.Delete("/suppressions/:user_id", middleware.RequireRole("admin"), handlers.RemoveNotificationSuppression)
+
+	// Saved searches
+	savedSearches := api.Group("/saved-searches", middleware.AuthRequired())
+	savedSearches.Get("/", handlers.GetSavedSearches)
+	savedSearches.Post("/", handlers.CreateSavedSearch)
+	savedSearches.Get("/:id", handlers.GetSavedSearch)
+	savedSearches.Put("/:id", handlers.UpdateSavedSearch)
+	savedSearches.Delete("/:id", handlers.DeleteSavedSearch)
 
 	// Discounts
 	discounts := api.Group("/discounts")
 	discounts.Get("/active", handlers.GetActiveDiscounts)
-	discounts.Post("/", middleware.AuthRequired(), handlers.CreateDiscount)
+	discounts.Get("/upcoming", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.GetUpcomingDiscounts)
+	discounts.Get("/expired", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.GetExpiredDiscounts)
+	discounts.Post("/", middleware.AuthRequired(), middleware.RequireRole("admin"), handlers.CreateDiscount)
 
 	// 404 handler
 	app.Use(func(c *fiber.Ctx) error {
@@ -383,3 +636,25 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// getEnvList gets a comma-separated environment variable as a list, skipping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvInt gets integer environment variable with fallback
+func getEnvInt(key string, fallback int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+		log.Printf("Warning: Invalid integer value for %s: %s, using fallback: %d", key, value, fallback)
+	}
+	return fallback
+}
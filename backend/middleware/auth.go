@@ -4,6 +4,10 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/models"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
@@ -15,6 +19,7 @@ type JWTClaims struct {
 	UserID uuid.UUID `json:"user_id"`
 	Email  string    `json:"email"`
 	Name   string    `json:"name"`
+	Role   string    `json:"role"`
 	jwt.RegisteredClaims
 }
 
@@ -46,7 +51,7 @@ func AuthRequired() fiber.Handler {
 				return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid signing method")
 			}
 			return []byte(getJWTSecret()), nil
-		})
+		}, jwtParserOptions()...)
 
 		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
@@ -69,10 +74,15 @@ func AuthRequired() fiber.Handler {
 			})
 		}
 
+		// Reject tokens that were revoked, e.g. by logging out
+		if isTokenRevoked(claims.ID) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Token has been revoked",
+			})
+		}
+
 		// Store user information in context using Locals
-		c.Locals("user_id", claims.UserID)
-		c.Locals("user_email", claims.Email)
-		c.Locals("user_name", claims.Name)
+		setClaimsLocals(c, claims)
 
 		return c.Next()
 	}
@@ -103,7 +113,7 @@ func OptionalAuth() fiber.Handler {
 				return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid signing method")
 			}
 			return []byte(getJWTSecret()), nil
-		})
+		}, jwtParserOptions()...)
 
 		if err != nil || !token.Valid {
 			// Invalid token, continue without user info
@@ -117,15 +127,70 @@ func OptionalAuth() fiber.Handler {
 			return c.Next()
 		}
 
+		if isTokenRevoked(claims.ID) {
+			// Revoked token, continue without user info
+			return c.Next()
+		}
+
 		// Store user information in context
-		c.Locals("user_id", claims.UserID)
-		c.Locals("user_email", claims.Email)
-		c.Locals("user_name", claims.Name)
+		setClaimsLocals(c, claims)
 
 		return c.Next()
 	}
 }
 
+// RequireRole middleware restricts a route to users with one of the given roles.
+// It must run after AuthRequired.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := GetUserRole(c)
+		for _, allowed := range roles {
+			if role == allowed {
+				return c.Next()
+			}
+		}
+
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Insufficient permissions",
+		})
+	}
+}
+
+// setClaimsLocals stores the token's user and identity information in the context
+func setClaimsLocals(c *fiber.Ctx, claims *JWTClaims) {
+	c.Locals("user_id", claims.UserID)
+	c.Locals("user_email", claims.Email)
+	c.Locals("user_name", claims.Name)
+	c.Locals("user_role", claims.Role)
+	c.Locals("token_jti", claims.ID)
+	if claims.ExpiresAt != nil {
+		c.Locals("token_expires_at", claims.ExpiresAt.Time)
+	}
+}
+
+// isTokenRevoked reports whether a token ID is on the revocation denylist.
+// Tokens issued without an ID cannot be revoked.
+func isTokenRevoked(jti string) bool {
+	if jti == "" {
+		return false
+	}
+
+	var count int64
+	database.DB.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count)
+	return count > 0
+}
+
+// GetTokenID extracts the current token's ID (jti) and expiry from context
+func GetTokenID(c *fiber.Ctx) (string, time.Time, bool) {
+	jti, ok := c.Locals("token_jti").(string)
+	if !ok || jti == "" {
+		return "", time.Time{}, false
+	}
+
+	expiresAt, _ := c.Locals("token_expires_at").(time.Time)
+	return jti, expiresAt, true
+}
+
 // GetUserID extracts user ID from context
 func GetUserID(c *fiber.Ctx) (uuid.UUID, bool) {
 	userID := c.Locals("user_id")
@@ -162,6 +227,69 @@ func GetUserName(c *fiber.Ctx) (string, bool) {
 	return nameStr, ok
 }
 
+// GetUserRole extracts user role from context
+func GetUserRole(c *fiber.Ctx) (string, bool) {
+	role := c.Locals("user_role")
+	if role == nil {
+		return "", false
+	}
+
+	roleStr, ok := role.(string)
+	return roleStr, ok && roleStr != ""
+}
+
+// IsAdmin reports whether the authenticated user has the admin role
+func IsAdmin(c *fiber.Ctx) bool {
+	role, _ := GetUserRole(c)
+	return role == "admin"
+}
+
+// Defaults for the access token settings
+const (
+	defaultAccessTokenTTL = 15 * time.Minute
+	defaultJWTIssuer      = "bachelor_backend"
+	defaultJWTAudience    = "bachelor_api"
+)
+
+// AccessTokenTTL returns the access token lifetime from JWT_ACCESS_TTL, a duration
+// such as "15m" or "1h" (default 15 minutes)
+func AccessTokenTTL() time.Duration {
+	if value := os.Getenv("JWT_ACCESS_TTL"); value != "" {
+		if ttl, err := time.ParseDuration(value); err == nil && ttl > 0 {
+			return ttl
+		}
+		log.Printf("Warning: Invalid duration for JWT_ACCESS_TTL: %s, using fallback: %s", value, defaultAccessTokenTTL)
+	}
+	return defaultAccessTokenTTL
+}
+
+// JWTIssuer returns the issuer put in and required of access tokens (JWT_ISSUER)
+func JWTIssuer() string {
+	if issuer := os.Getenv("JWT_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return defaultJWTIssuer
+}
+
+// JWTAudience returns the audience put in and required of access tokens (JWT_AUDIENCE).
+// Environments sharing a secret should use different audiences so their tokens are not
+// accepted by each other.
+func JWTAudience() string {
+	if audience := os.Getenv("JWT_AUDIENCE"); audience != "" {
+		return audience
+	}
+	return defaultJWTAudience
+}
+
+// jwtParserOptions returns the claim checks applied to access tokens
+func jwtParserOptions() []jwt.ParserOption {
+	return []jwt.ParserOption{
+		jwt.WithIssuer(JWTIssuer()),
+		jwt.WithAudience(JWTAudience()),
+		jwt.WithExpirationRequired(),
+	}
+}
+
 // getJWTSecret gets JWT secret from environment
 func getJWTSecret() string {
 	secret := os.Getenv("JWT_SECRET")
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLimitConfig defines the configuration for the request body limit middleware
+type BodyLimitConfig struct {
+	// Limit is the largest request body (in bytes) accepted by default
+	Limit int
+	// Routes overrides the limit for paths starting with a prefix; the longest
+	// matching prefix wins
+	Routes map[string]int
+}
+
+// DefaultBodyLimitConfig is the default configuration
+var DefaultBodyLimitConfig = BodyLimitConfig{
+	Limit: 4 * 1024 * 1024, // 4MB
+}
+
+// MaxLimit returns the largest limit in the configuration
+func (cfg BodyLimitConfig) MaxLimit() int {
+	limit := cfg.Limit
+	for _, routeLimit := range cfg.Routes {
+		limit = max(limit, routeLimit)
+	}
+	return limit
+}
+
+// MinLimit returns the smallest limit in the configuration. With request body streaming
+// enabled, the server only reads this much of a body before the middleware runs; the
+// rest is read by the middleware up to the limit of the route.
+func (cfg BodyLimitConfig) MinLimit() int {
+	limit := cfg.Limit
+	for _, routeLimit := range cfg.Routes {
+		limit = min(limit, routeLimit)
+	}
+	return limit
+}
+
+// limitFor returns the body limit for a request path
+func (cfg BodyLimitConfig) limitFor(path string) int {
+	limit, matched := cfg.Limit, ""
+	for prefix, routeLimit := range cfg.Routes {
+		if strings.HasPrefix(path, prefix) && len(prefix) > len(matched) {
+			limit, matched = routeLimit, prefix
+		}
+	}
+	return limit
+}
+
+// BodyLimit creates a middleware that rejects request bodies over the limit for their
+// route with a 413 naming the limit. The app must enable StreamRequestBody, so bodies
+// are checked before they are read in full.
+func BodyLimit(config ...BodyLimitConfig) fiber.Handler {
+	cfg := DefaultBodyLimitConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	return func(c *fiber.Ctx) error {
+		limit := cfg.limitFor(c.Path())
+
+		// Reject on the declared length without reading the body. The unread rest of
+		// the body is still on the connection, so it cannot be reused.
+		if c.Request().Header.ContentLength() > limit {
+			c.Context().SetConnectionClose()
+			return BodyTooLarge(c, limit)
+		}
+
+		// Read the rest of a streamed body, but never more than the limit; chunked
+		// bodies have no declared length
+		if stream := c.Request().BodyStream(); stream != nil {
+			body, err := io.ReadAll(io.LimitReader(stream, int64(limit)+1))
+			if err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "Failed to read request body")
+			}
+			if len(body) > limit {
+				c.Context().SetConnectionClose()
+				return BodyTooLarge(c, limit)
+			}
+			c.Request().SetBody(body)
+		} else if len(c.Body()) > limit {
+			return BodyTooLarge(c, limit)
+		}
+
+		return c.Next()
+	}
+}
+
+// BodyTooLarge responds with a 413 naming the body limit
+func BodyTooLarge(c *fiber.Ctx, limit int) error {
+	return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+		"success":     false,
+		"error":       "Request body too large; the limit for this endpoint is " + formatByteSize(limit),
+		"limit_bytes": limit,
+		"request_id":  GetRequestID(c),
+	})
+}
+
+// formatByteSize formats a size in bytes as B, KB or MB
+func formatByteSize(size int) string {
+	switch {
+	case size >= 1024*1024 && size%(1024*1024) == 0:
+		return fmt.Sprintf("%d MB", size/(1024*1024))
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+	case size >= 1024 && size%1024 == 0:
+		return fmt.Sprintf("%d KB", size/1024)
+	case size >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(size)/1024)
+	default:
+		return fmt.Sprintf("%d bytes", size)
+	}
+}
@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// CompressionConfig defines the configuration for response compression middleware
+type CompressionConfig struct {
+	// Skip defines a function to skip middleware
+	Skip func(c *fiber.Ctx) bool
+	// MinSize is the smallest response body (in bytes) that will be compressed
+	MinSize int
+	// GzipLevel is the gzip compression level
+	GzipLevel int
+	// BrotliLevel is the brotli compression level
+	BrotliLevel int
+}
+
+// DefaultCompressionConfig is the default configuration
+var DefaultCompressionConfig = CompressionConfig{
+	Skip: func(c *fiber.Ctx) bool {
+		return strings.HasPrefix(c.Path(), "/swagger")
+	},
+	MinSize:     1024, // 1KB
+	GzipLevel:   fasthttp.CompressDefaultCompression,
+	BrotliLevel: fasthttp.CompressBrotliDefaultCompression,
+}
+
+// Compression creates a middleware that compresses response bodies with
+// brotli or gzip depending on the client's Accept-Encoding header
+func Compression(config ...CompressionConfig) fiber.Handler {
+	cfg := DefaultCompressionConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Skip != nil && cfg.Skip(c) {
+			return c.Next()
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		c.Vary(fiber.HeaderAcceptEncoding)
+
+		resp := c.Response()
+		if resp.IsBodyStream() || len(resp.Header.Peek(fiber.HeaderContentEncoding)) > 0 {
+			return nil
+		}
+
+		body := resp.Body()
+		if len(body) < cfg.MinSize || !isCompressibleContentType(string(resp.Header.ContentType())) {
+			return nil
+		}
+
+		switch negotiateEncoding(c.Get(fiber.HeaderAcceptEncoding)) {
+		case "br":
+			resp.SetBodyRaw(fasthttp.AppendBrotliBytesLevel(nil, body, cfg.BrotliLevel))
+			c.Set(fiber.HeaderContentEncoding, "br")
+		case "gzip":
+			resp.SetBodyRaw(fasthttp.AppendGzipBytesLevel(nil, body, cfg.GzipLevel))
+			c.Set(fiber.HeaderContentEncoding, "gzip")
+		}
+
+		return nil
+	}
+}
+
+// negotiateEncoding picks the preferred supported encoding from an
+// Accept-Encoding header, honoring q-values. Brotli wins ties.
+func negotiateEncoding(acceptEncoding string) string {
+	best := ""
+	bestQ := 0.0
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		encoding := strings.ToLower(strings.TrimSpace(fields[0]))
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if parsed, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		if q <= 0 {
+			continue
+		}
+
+		switch encoding {
+		case "br":
+			if q >= bestQ {
+				best, bestQ = "br", q
+			}
+		case "gzip", "*":
+			if q > bestQ {
+				best, bestQ = "gzip", q
+			}
+		}
+	}
+
+	return best
+}
+
+// isCompressibleContentType reports whether a response of this type benefits from compression
+func isCompressibleContentType(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	return strings.HasPrefix(contentType, "text/") ||
+		strings.Contains(contentType, "json") ||
+		strings.Contains(contentType, "xml") ||
+		strings.Contains(contentType, "javascript") ||
+		strings.Contains(contentType, "csv")
+}
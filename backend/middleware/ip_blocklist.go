@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// IPBlocklist keeps the active IP blocks in memory so requests can be checked
+// without a database round trip. The cache is reloaded periodically so blocks
+// added by other instances apply, and each entry's expiry is checked per request
+// so expired blocks stop applying between reloads.
+type IPBlocklist struct {
+	blocks    map[string]*time.Time // IP -> expiry, nil for permanent blocks
+	mu        sync.RWMutex
+	ticker    *time.Ticker
+	stopChan  chan bool
+	isRunning bool
+}
+
+// NewIPBlocklist creates an empty IP blocklist
+func NewIPBlocklist() *IPBlocklist {
+	return &IPBlocklist{
+		blocks:    make(map[string]*time.Time),
+		stopChan:  make(chan bool),
+		isRunning: false,
+	}
+}
+
+// Start loads the active blocks and reloads them on the given interval
+func (bl *IPBlocklist) Start(intervalSeconds int) {
+	if bl.isRunning {
+		log.Println("IP blocklist refresher is already running")
+		return
+	}
+
+	bl.ticker = time.NewTicker(time.Duration(intervalSeconds) * time.Second)
+	bl.isRunning = true
+
+	log.Printf("Starting IP blocklist refresher with %d second intervals", intervalSeconds)
+
+	go func() {
+		if err := bl.Refresh(); err != nil {
+			log.Printf("Failed to load IP blocklist: %v", err)
+		}
+
+		for {
+			select {
+			case <-bl.ticker.C:
+				if err := bl.Refresh(); err != nil {
+					log.Printf("Failed to refresh IP blocklist: %v", err)
+				}
+			case <-bl.stopChan:
+				bl.ticker.Stop()
+				bl.isRunning = false
+				log.Println("IP blocklist refresher stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic reload
+func (bl *IPBlocklist) Stop() {
+	if !bl.isRunning {
+		return
+	}
+
+	bl.stopChan <- true
+}
+
+// Refresh reloads the active blocks from the database. On failure the previous
+// blocks stay in effect.
+func (bl *IPBlocklist) Refresh() error {
+	var active []models.IPBlock
+	if err := database.DB.
+		Select("ip_address", "expires_at").
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Find(&active).Error; err != nil {
+		return err
+	}
+
+	blocks := make(map[string]*time.Time, len(active))
+	for _, block := range active {
+		blocks[normalizeIP(block.IPAddress)] = block.ExpiresAt
+	}
+
+	bl.mu.Lock()
+	bl.blocks = blocks
+	bl.mu.Unlock()
+	return nil
+}
+
+// IsBlocked reports whether ip has an active block
+func (bl *IPBlocklist) IsBlocked(ip string) bool {
+	bl.mu.RLock()
+	expiresAt, ok := bl.blocks[normalizeIP(ip)]
+	bl.mu.RUnlock()
+
+	return ok && (expiresAt == nil || time.Now().Before(*expiresAt))
+}
+
+// Count returns the number of cached blocks, including any that expired since the last reload
+func (bl *IPBlocklist) Count() int {
+	bl.mu.RLock()
+	defer bl.mu.RUnlock()
+	return len(bl.blocks)
+}
+
+// normalizeIP returns the canonical form of an IP so equivalent spellings match
+func normalizeIP(ip string) string {
+	if parsed := net.ParseIP(ip); parsed != nil {
+		return parsed.String()
+	}
+	return ip
+}
+
+// ClientIP returns the client address used for request logging and IP blocks.
+// Forwarding headers are only honoured for requests from the trusted proxies
+// configured on the app, so clients cannot pick the address they are judged by.
+func ClientIP(c *fiber.Ctx) string {
+	return c.IP()
+}
+
+// BlockIPs rejects requests from blocked IP addresses with 403 Forbidden
+func BlockIPs() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !IPBlocklistInstance.IsBlocked(ClientIP(c)) {
+			return c.Next()
+		}
+
+		go countBlockedRequest()
+
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Access denied",
+		})
+	}
+}
+
+// countBlockedRequest adds a blocked request to today's security metrics
+func countBlockedRequest() {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if err := database.DB.Model(&models.SecurityMetrics{}).
+		Where("date = ?", today).
+		UpdateColumn("blocked_requests", gorm.Expr("blocked_requests + 1")).Error; err != nil {
+		log.Printf("Failed to count blocked request: %v", err)
+	}
+}
+
+// Global IP blocklist instance
+var IPBlocklistInstance = NewIPBlocklist()
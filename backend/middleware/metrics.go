@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"bachelor_backend/pkg/metrics"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PrometheusMetrics records the count and duration of requests by route template
+// (e.g. /api/products/:id), so label cardinality stays bounded
+func PrometheusMetrics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			// The error handler sets the final status after this middleware returns
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+		}
+
+		method := c.Method()
+		path := c.Route().Path
+		metrics.HTTPRequestsTotal.Inc(method, path, strconv.Itoa(status))
+		metrics.HTTPRequestDuration.Observe(time.Since(start).Seconds(), method, path)
+
+		return err
+	}
+}
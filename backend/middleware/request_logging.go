@@ -4,8 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"net"
 	"strings"
+	"sync"
 	"time"
 
 	"bachelor_backend/database"
@@ -15,6 +15,30 @@ import (
 	"github.com/google/uuid"
 )
 
+// RequestLogHandler receives each request log after it has been saved. Handlers run
+// on the logging goroutine and must not block; slow work belongs on a queue.
+type RequestLogHandler func(models.RequestLog)
+
+var (
+	logHandlersMu sync.RWMutex
+	logHandlers   []RequestLogHandler
+)
+
+// OnRequestLogged registers a handler for saved request logs. It lets services
+// consume live traffic without the middleware importing them.
+func OnRequestLogged(handler RequestLogHandler) {
+	logHandlersMu.Lock()
+	defer logHandlersMu.Unlock()
+	logHandlers = append(logHandlers, handler)
+}
+
+// requestLogHandlers returns the registered handlers
+func requestLogHandlers() []RequestLogHandler {
+	logHandlersMu.RLock()
+	defer logHandlersMu.RUnlock()
+	return logHandlers
+}
+
 // RequestLoggingConfig defines the configuration for request logging middleware
 type RequestLoggingConfig struct {
 	// Skip defines a function to skip middleware
@@ -69,7 +93,7 @@ func RequestLogging(config ...RequestLoggingConfig) fiber.Handler {
 		// Get request details
 		method := c.Method()
 		path := c.Path()
-		ipAddress := getRealIP(c)
+		ipAddress := ClientIP(c)
 		userAgent := c.Get("User-Agent")
 
 		// Get user ID if available
@@ -80,6 +104,7 @@ func RequestLogging(config ...RequestLoggingConfig) fiber.Handler {
 
 		// Get session ID
 		sessionID := getSessionID(c)
+		requestID := GetRequestID(c)
 
 		// Capture query parameters
 		queryParams := captureQueryParams(c, cfg.LogSensitiveData)
@@ -109,6 +134,7 @@ func RequestLogging(config ...RequestLoggingConfig) fiber.Handler {
 				RequestSize:  requestSize,
 				ResponseSize: responseSize,
 				SessionID:    sessionID,
+				RequestID:    requestID,
 				Timestamp:    startTime,
 			}); err != nil {
 				log.Printf("Failed to log request: %v", err)
@@ -119,48 +145,6 @@ func RequestLogging(config ...RequestLoggingConfig) fiber.Handler {
 	}
 }
 
-// getRealIP extracts the real IP address from the request
-func getRealIP(c *fiber.Ctx) string {
-	// Check X-Forwarded-For header first (for load balancers/proxies)
-	xForwardedFor := c.Get("X-Forwarded-For")
-	if xForwardedFor != "" {
-		// X-Forwarded-For can contain multiple IPs, take the first one
-		ips := strings.Split(xForwardedFor, ",")
-		if len(ips) > 0 {
-			ip := strings.TrimSpace(ips[0])
-			if net.ParseIP(ip) != nil {
-				return ip
-			}
-		}
-	}
-
-	// Check X-Real-IP header
-	xRealIP := c.Get("X-Real-IP")
-	if xRealIP != "" && net.ParseIP(xRealIP) != nil {
-		return xRealIP
-	}
-
-	// Check X-Forwarded header
-	xForwarded := c.Get("X-Forwarded")
-	if xForwarded != "" {
-		// Extract IP from X-Forwarded header
-		parts := strings.Split(xForwarded, ";")
-		for _, part := range parts {
-			if strings.HasPrefix(strings.TrimSpace(part), "for=") {
-				ip := strings.TrimSpace(strings.TrimPrefix(part, "for="))
-				// Remove quotes if present
-				ip = strings.Trim(ip, "\"")
-				if net.ParseIP(ip) != nil {
-					return ip
-				}
-			}
-		}
-	}
-
-	// Fall back to remote IP
-	return c.IP()
-}
-
 // getSessionID extracts or generates a session ID
 func getSessionID(c *fiber.Ctx) string {
 	// Try to get session ID from header
@@ -258,15 +242,10 @@ func logRequest(requestLog models.RequestLog) error {
 		return fmt.Errorf("failed to save request log: %w", result.Error)
 	}
 
-	// Trigger anomaly analysis asynchronously
-	go func() {
-		// Import the services package to access AnomalyServiceInstance
-		// Note: This creates a circular import, so we'll handle it differently
-		// For now, we'll skip the automatic analysis and handle it separately
-
-		// In a production system, you might use a message queue or event system
-		// to decouple the request logging from anomaly analysis
-	}()
+	// Hand the saved log to subscribers such as live anomaly analysis
+	for _, handler := range requestLogHandlers() {
+		handler(requestLog)
+	}
 
 	return nil
 }
@@ -282,80 +261,38 @@ func RequestMetrics() fiber.Handler {
 		// Calculate response time
 		responseTime := time.Since(start).Milliseconds()
 
+		// The context is reused once the handler returns, so read the status now
+		statusCode := c.Response().StatusCode()
+
 		// Track metrics asynchronously to avoid blocking the request
-		go func() {
-			updateDailyMetrics(c, responseTime)
-		}()
+		go updateDailyMetrics(statusCode, responseTime)
 
 		return err
 	}
 }
 
-// updateDailyMetrics updates daily security metrics
-func updateDailyMetrics(c *fiber.Ctx, responseTime int64) {
-	// Add safety checks to prevent nil pointer dereference
-	if c == nil {
-		log.Printf("Warning: Context is nil in updateDailyMetrics")
-		return
-	}
-
-	// Check if response is available
-	response := c.Response()
-	if response == nil {
-		log.Printf("Warning: Response is nil in updateDailyMetrics")
-		return
-	}
-
+// updateDailyMetrics adds a request to today's security metrics. It is a single
+// upsert so concurrent requests neither lose increments nor race to create the row.
+func updateDailyMetrics(statusCode int, responseTime int64) {
 	today := time.Now().UTC().Truncate(24 * time.Hour)
 
-	// Get or create today's security metrics
-	var metrics models.SecurityMetrics
-	result := database.DB.Where("date = ?", today).First(&metrics)
-
-	statusCode := response.StatusCode()
-
-	if result.Error != nil {
-		// Create new metrics record for today
-		metrics = models.SecurityMetrics{
-			Date:            today,
-			TotalRequests:   1,
-			UniqueIPs:       1,
-			AvgResponseTime: float64(responseTime),
-			ErrorRate:       0.0,
-		}
-
-		// Calculate error rate for new record
-		if statusCode >= 400 {
-			metrics.ErrorRate = 1.0
-		}
-
-		if err := database.DB.Create(&metrics).Error; err != nil {
-			log.Printf("Failed to create security metrics: %v", err)
-			return
-		}
-	} else {
-		// Update existing metrics
-		oldTotal := metrics.TotalRequests
-		metrics.TotalRequests++
-
-		// Update average response time
-		metrics.AvgResponseTime = (metrics.AvgResponseTime*float64(oldTotal) + float64(responseTime)) / float64(metrics.TotalRequests)
-
-		// Update error rate
-		if statusCode >= 400 {
-			// Recalculate error rate
-			errorCount := int(metrics.ErrorRate * float64(oldTotal))
-			errorCount++
-			metrics.ErrorRate = float64(errorCount) / float64(metrics.TotalRequests)
-		} else {
-			// Recalculate error rate without adding an error
-			errorCount := int(metrics.ErrorRate * float64(oldTotal))
-			metrics.ErrorRate = float64(errorCount) / float64(metrics.TotalRequests)
-		}
+	isError := 0
+	if statusCode >= 400 {
+		isError = 1
+	}
 
-		if err := database.DB.Save(&metrics).Error; err != nil {
-			log.Printf("Failed to update security metrics: %v", err)
-			return
-		}
+	if err := database.DB.Exec(`
+		INSERT INTO security_metrics (date, total_requests, error_requests, unique_ips, avg_response_time, error_rate, created_at, updated_at)
+		VALUES (?, 1, ?, 1, ?, ?, NOW(), NOW())
+		ON CONFLICT (date) DO UPDATE SET
+			total_requests = security_metrics.total_requests + 1,
+			error_requests = security_metrics.error_requests + EXCLUDED.error_requests,
+			avg_response_time = (security_metrics.avg_response_time * security_metrics.total_requests + EXCLUDED.avg_response_time)
+				/ (security_metrics.total_requests + 1),
+			error_rate = (security_metrics.error_requests + EXCLUDED.error_requests)::decimal
+				/ (security_metrics.total_requests + 1),
+			updated_at = NOW()
+	`, today, isError, float64(responseTime), isError).Error; err != nil {
+		log.Printf("Failed to update security metrics: %v", err)
 	}
 }
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"bachelor_backend/database/dbtest"
+	"bachelor_backend/models"
+)
+
+func TestUpdateDailyMetricsConcurrent(t *testing.T) {
+	db := dbtest.Open(t)
+
+	readToday := func() models.SecurityMetrics {
+		t.Helper()
+		var metrics models.SecurityMetrics
+		err := db.Where("date = ?", time.Now().UTC().Truncate(24*time.Hour)).
+			Limit(1).Find(&metrics).Error
+		if err != nil {
+			t.Fatalf("failed to read security metrics: %v", err)
+		}
+		return metrics
+	}
+
+	before := readToday()
+
+	const requests = 200
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	failed := 0
+	for i := 0; i < requests; i++ {
+		statusCode := 200
+		if i%4 == 0 {
+			statusCode = 500
+			failed++
+		}
+		wg.Add(1)
+		go func(statusCode int) {
+			defer wg.Done()
+			<-start
+			updateDailyMetrics(statusCode, 10)
+		}(statusCode)
+	}
+	close(start)
+	wg.Wait()
+
+	after := readToday()
+	if got := after.TotalRequests - before.TotalRequests; got != requests {
+		t.Errorf("total_requests grew by %d, want %d", got, requests)
+	}
+	if got := after.ErrorRequests - before.ErrorRequests; got != failed {
+		t.Errorf("error_requests grew by %d, want %d", got, failed)
+	}
+}
@@ -3,6 +3,8 @@ package models
 import (
 	"time"
 
+	"bachelor_backend/pkg/money"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -12,7 +14,9 @@ type User struct {
 	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
 	Email        string    `json:"email" gorm:"unique;not null;index"`
 	Name         string    `json:"name" gorm:"not null;index"`
+	Phone        string    `json:"phone" gorm:"type:varchar(20)"`
 	PasswordHash string    `json:"-" gorm:"not null"`
+	Role         string    `json:"role" gorm:"not null;default:'customer';index"` // 'customer', 'admin'
 	CreatedAt    time.Time `json:"created_at" gorm:"index"`
 	UpdatedAt    time.Time `json:"updated_at" gorm:"index"`
 
@@ -28,88 +32,187 @@ type User struct {
 
 // Product represents a product in the e-commerce platform
 type Product struct {
-	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
-	Name        string    `json:"name" gorm:"not null;index"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price" gorm:"type:decimal(10,2);not null;index"`
-	Category    string    `json:"category" gorm:"not null;index"`
-	Stock       int       `json:"stock" gorm:"default:0;index"`
-	ImageURL    string    `json:"image_url"`
-	CreatedAt   time.Time `json:"created_at" gorm:"index"`
-	UpdatedAt   time.Time `json:"updated_at" gorm:"index"`
+	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Name        string         `json:"name" gorm:"not null;index"`
+	Description string         `json:"description"`
+	Price       money.Cents    `json:"price" gorm:"type:bigint;not null;index" swaggertype:"string" example:"999.99"`
+	Currency    string         `json:"currency" gorm:"type:varchar(3);not null;default:'USD'"` // Prices are stored in this currency; others are converted for display only
+	Category    string         `json:"category" gorm:"not null;index"`                         // Category name, kept in sync with CategoryID for older clients
+	CategoryID  *uuid.UUID     `json:"category_id" gorm:"type:uuid;index"`                     // Node in the category tree
+	SKU         *string        `json:"sku" gorm:"uniqueIndex"`                                 // NULL only for products created before SKUs were required
+	Barcode     string         `json:"barcode,omitempty" gorm:"index"`
+	Brand       string         `json:"brand" gorm:"index"`
+	Stock       int            `json:"stock" gorm:"default:0;index"`       // Total of the variants' stock for products with variants
+	Reserved    int            `json:"reserved" gorm:"not null;default:0"` // Units held by checkout reservations; Stock - Reserved is available
+	ImageURL    string         `json:"image_url"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"index"`
+	UpdatedAt   time.Time      `json:"updated_at" gorm:"index"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index" swaggertype:"string"`
 
 	// Relationships
-	OrderItems       []OrderItem       `json:"order_items,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
-	CartItems        []CartItem        `json:"cart_items,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	UserInteractions []UserInteraction `json:"user_interactions,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Recommendations  []Recommendation  `json:"recommendations,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	ProductViews     []ProductView     `json:"product_views,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Favorites        []Favorite        `json:"favorites,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Upvotes          []Upvote          `json:"upvotes,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Comments         []Comment         `json:"comments,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Discounts        []Discount        `json:"discounts,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Tags             []Tag             `json:"tags,omitempty" gorm:"many2many:product_tags;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Images           []ProductImage     `json:"images,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Variants         []ProductVariant   `json:"variants,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	OrderItems       []OrderItem        `json:"order_items,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	CartItems        []CartItem         `json:"cart_items,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserInteractions []UserInteraction  `json:"user_interactions,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Recommendations  []Recommendation   `json:"recommendations,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ProductViews     []ProductView      `json:"product_views,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Favorites        []Favorite         `json:"favorites,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Upvotes          []Upvote           `json:"upvotes,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Comments         []Comment          `json:"comments,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Discounts        []Discount         `json:"discounts,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Tags             []Tag              `json:"tags,omitempty" gorm:"many2many:product_tags;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	CategoryNode     *Category          `json:"category_node,omitempty" gorm:"foreignKey:CategoryID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	Sentiment        *SentimentSnapshot `json:"sentiment,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 }
 
-// Order represents an order placed by a user
-type Order struct {
+// Category represents a node in the product category tree
+type Category struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Name      string     `json:"name" gorm:"not null;index"`
+	Slug      string     `json:"slug" gorm:"not null;uniqueIndex"` // URL-safe form of the name, unique across the tree
+	ParentID  *uuid.UUID `json:"parent_id" gorm:"type:uuid;index"` // Nil for top-level categories
+	CreatedAt time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"index"`
+
+	// Relationships
+	Children []Category `json:"children,omitempty" gorm:"foreignKey:ParentID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+}
+
+// ProductImage represents one image in a product's gallery
+type ProductImage struct {
 	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
-	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
-	Total     float64   `json:"total" gorm:"type:decimal(10,2);not null;index"`
-	Status    string    `json:"status" gorm:"default:'pending';index"`
+	ProductID uuid.UUID `json:"product_id" gorm:"type:uuid;not null;index"`
+	URL       string    `json:"url" gorm:"not null"`
+	Position  int       `json:"position" gorm:"not null;index"`
+	IsPrimary bool      `json:"is_primary" gorm:"not null"`
 	CreatedAt time.Time `json:"created_at" gorm:"index"`
-	UpdatedAt time.Time `json:"updated_at" gorm:"index"`
+}
+
+// ProductVariant represents one purchasable option of a product, such as a size and color
+type ProductVariant struct {
+	ID            uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	ProductID     uuid.UUID         `json:"product_id" gorm:"type:uuid;not null;index"`
+	SKU           string            `json:"sku" gorm:"size:64;not null;uniqueIndex"`
+	Attributes    map[string]string `json:"attributes" gorm:"serializer:json;type:jsonb;not null"`  // e.g. {"size": "M", "color": "red"}
+	PriceOverride *money.Cents      `json:"price_override" gorm:"type:bigint" swaggertype:"string"` // Nil sells at the product price
+	Stock         int               `json:"stock" gorm:"not null;default:0"`
+	CreatedAt     time.Time         `json:"created_at" gorm:"index"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+
+	// Relationships
+	Product Product `json:"-" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// Price returns the price the variant sells at
+func (v ProductVariant) Price(product Product) money.Cents {
+	if v.PriceOverride != nil {
+		return *v.PriceOverride
+	}
+	return product.Price
+}
+
+// Order represents an order placed by a user
+type Order struct {
+	ID        uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID    uuid.UUID   `json:"user_id" gorm:"type:uuid;not null;index"`
+	Total     money.Cents `json:"total" gorm:"type:bigint;not null;index" swaggertype:"string"` // Subtotal - DiscountTotal + TaxTotal + ShippingCost
+	Status    string      `json:"status" gorm:"default:'pending';index"`
+	CreatedAt time.Time   `json:"created_at" gorm:"index"`
+	UpdatedAt time.Time   `json:"updated_at" gorm:"index"`
+
+	// Pricing breakdown; DiscountTotal includes automatic discounts and the coupon
+	Subtotal       money.Cents `json:"subtotal" gorm:"type:bigint;not null;default:0" swaggertype:"string"`
+	DiscountTotal  money.Cents `json:"discount_total" gorm:"type:bigint;not null;default:0" swaggertype:"string"`
+	CouponID       *uuid.UUID  `json:"coupon_id,omitempty" gorm:"type:uuid;index"`
+	CouponDiscount money.Cents `json:"coupon_discount" gorm:"type:bigint;not null;default:0" swaggertype:"string"`
+	DiscountIDs    []uuid.UUID `json:"discount_ids,omitempty" gorm:"serializer:json;type:jsonb"` // Automatic discounts redeemed at checkout
+	TaxTotal       money.Cents `json:"tax_total" gorm:"type:bigint;not null;default:0" swaggertype:"string"`
+	ShippingCost   money.Cents `json:"shipping_cost" gorm:"type:bigint;not null;default:0" swaggertype:"string"`
+
+	// Currency the customer saw at checkout and the rate from the base currency at that
+	// time; the amounts above stay in the base currency
+	Currency     string  `json:"currency" gorm:"type:varchar(3);not null;default:'USD'"`
+	ExchangeRate float64 `json:"exchange_rate" gorm:"type:decimal(18,8);not null;default:1"`
+
+	// Snapshot of the shipping address at checkout; editing the saved address does not change it
+	ShippingAddress   string     `json:"shipping_address" gorm:"type:text"`
+	ShippingAddressID *uuid.UUID `json:"shipping_address_id,omitempty" gorm:"type:uuid;index"` // Saved address the snapshot was taken from
 
 	// Relationships
 	User       User        `json:"user" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
 	OrderItems []OrderItem `json:"order_items" gorm:"foreignKey:OrderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Refunds    []Refund    `json:"refunds,omitempty" gorm:"foreignKey:OrderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 }
 
 // OrderItem represents an item within an order
 type OrderItem struct {
-	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
-	OrderID   uuid.UUID `json:"order_id" gorm:"type:uuid;not null;index"`
-	ProductID uuid.UUID `json:"product_id" gorm:"type:uuid;not null;index"`
-	Quantity  int       `json:"quantity" gorm:"not null;check:quantity > 0"`
-	Price     float64   `json:"price" gorm:"type:decimal(10,2);not null"`
-	CreatedAt time.Time `json:"created_at" gorm:"index"`
+	ID        uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrderID   uuid.UUID   `json:"order_id" gorm:"type:uuid;not null;index"`
+	ProductID uuid.UUID   `json:"product_id" gorm:"type:uuid;not null;index"`
+	VariantID *uuid.UUID  `json:"variant_id,omitempty" gorm:"type:uuid;index"` // Set for products with variants
+	Quantity  int         `json:"quantity" gorm:"not null;check:quantity > 0"`
+	Price     money.Cents `json:"price" gorm:"type:bigint;not null" swaggertype:"string"`
+	CreatedAt time.Time   `json:"created_at" gorm:"index"`
 
 	// Relationships
-	Order   Order   `json:"order" gorm:"foreignKey:OrderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Product Product `json:"product" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	Order   Order           `json:"order" gorm:"foreignKey:OrderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Product Product         `json:"product" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	Variant *ProductVariant `json:"variant,omitempty" gorm:"foreignKey:VariantID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
 }
 
-// ShoppingCart represents a user's shopping cart
+// ShoppingCart represents a user's shopping cart, or a guest's cart keyed by session ID
 type ShoppingCart struct {
-	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
-	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex"`
-	CreatedAt time.Time `json:"created_at" gorm:"index"`
-	UpdatedAt time.Time `json:"updated_at" gorm:"index"`
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID    *uuid.UUID `json:"user_id" gorm:"type:uuid;uniqueIndex"`             // Nil for guest carts
+	SessionID *string    `json:"session_id,omitempty" gorm:"size:128;uniqueIndex"` // Set for guest carts only
+	CouponID  *uuid.UUID `json:"coupon_id" gorm:"type:uuid;index"`                 // Applied coupon, redeemed when the order is placed
+	CreatedAt time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"index"`
 
 	// Relationships
-	User      User       `json:"user" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	User      *User      `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	CartItems []CartItem `json:"cart_items" gorm:"foreignKey:CartID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Coupon    *Discount  `json:"coupon,omitempty" gorm:"foreignKey:CouponID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
 }
 
 // CartItem represents an item in a shopping cart
 type CartItem struct {
-	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
-	CartID    uuid.UUID `json:"cart_id" gorm:"type:uuid;not null;index"`
-	ProductID uuid.UUID `json:"product_id" gorm:"type:uuid;not null;index"`
-	Quantity  int       `json:"quantity" gorm:"not null;check:quantity > 0"`
-	CreatedAt time.Time `json:"created_at" gorm:"index"`
-	UpdatedAt time.Time `json:"updated_at" gorm:"index"`
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	CartID    uuid.UUID  `json:"cart_id" gorm:"type:uuid;not null;index"`
+	ProductID uuid.UUID  `json:"product_id" gorm:"type:uuid;not null;index"`
+	VariantID *uuid.UUID `json:"variant_id,omitempty" gorm:"type:uuid;index"` // Required for products with variants
+	Quantity  int        `json:"quantity" gorm:"not null;check:quantity > 0"`
+	CreatedAt time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"index"`
 
 	// Relationships
-	Cart    ShoppingCart `json:"cart" gorm:"foreignKey:CartID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Product Product      `json:"product" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	Cart    ShoppingCart    `json:"cart" gorm:"foreignKey:CartID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Product Product         `json:"product" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	Variant *ProductVariant `json:"variant,omitempty" gorm:"foreignKey:VariantID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 }
 
-// Add unique constraint for cart_id + product_id combination
+// TableName names the cart items table; its unique index on cart_id, product_id and
+// variant_id is created in database.addCustomConstraints
 func (CartItem) TableName() string {
 	return "cart_items"
 }
 
+// SavedItem represents a product a user moved out of their cart to buy later
+type SavedItem struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_saved_items_user_product"`
+	ProductID uuid.UUID  `json:"product_id" gorm:"type:uuid;not null;uniqueIndex:idx_saved_items_user_product;index"`
+	VariantID *uuid.UUID `json:"variant_id,omitempty" gorm:"type:uuid;index"`           // Variant restored when moved back to the cart
+	Quantity  int        `json:"quantity" gorm:"not null;default:1;check:quantity > 0"` // Quantity restored when moved back to the cart
+	CreatedAt time.Time  `json:"created_at" gorm:"index"`
+
+	// Relationships
+	User    User            `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Product Product         `json:"product" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Variant *ProductVariant `json:"variant,omitempty" gorm:"foreignKey:VariantID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+}
+
 // UserInteraction represents user interactions with products for ML
 type UserInteraction struct {
 	ID              uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
@@ -128,15 +231,51 @@ type UserInteraction struct {
 type SearchQuery struct {
 	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
 	UserID         *uuid.UUID `json:"user_id" gorm:"type:uuid;index"`
+	SessionID      *string    `json:"-" gorm:"size:128;index"` // Guest session the search was made from
 	Query          string     `json:"query" gorm:"not null;index"`
 	ResultsCount   int        `json:"results_count" gorm:"default:0"`
-	ResultsClicked int        `json:"results_clicked" gorm:"default:0"`
-	CreatedAt      time.Time  `json:"created_at" gorm:"index"` // Changed from Timestamp to CreatedAt for consistency
+	ResultsClicked int        `json:"results_clicked" gorm:"default:0"` // Distinct results opened from this search
+	CreatedAt      time.Time  `json:"created_at" gorm:"index"`          // Changed from Timestamp to CreatedAt for consistency
 
 	// Relationships
 	User *User `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
 }
 
+// SearchClick represents a search result that was opened from a search
+type SearchClick struct {
+	ID            uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	SearchQueryID uuid.UUID `json:"search_query_id" gorm:"type:uuid;not null;uniqueIndex:idx_search_clicks_query_product,priority:1"`
+	ProductID     uuid.UUID `json:"product_id" gorm:"type:uuid;not null;uniqueIndex:idx_search_clicks_query_product,priority:2;index"`
+	Position      *int      `json:"position,omitempty"` // 1-based rank of the result on the page, when reported
+	CreatedAt     time.Time `json:"created_at" gorm:"index"`
+
+	// Relationships
+	SearchQuery SearchQuery `json:"-" gorm:"foreignKey:SearchQueryID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Product     Product     `json:"-" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// SavedSearch represents a search a user saved to be alerted about new matching products
+type SavedSearch struct {
+	ID          uuid.UUID          `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID      uuid.UUID          `json:"user_id" gorm:"type:uuid;not null;index"`
+	Query       string             `json:"query" gorm:"size:200;not null"`
+	Filters     SavedSearchFilters `json:"filters" gorm:"serializer:json;type:jsonb"`
+	LastChecked time.Time          `json:"last_checked" gorm:"not null;index"` // Products created after this are new matches
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// SavedSearchFilters are the optional search filters stored with a saved search.
+// A zero max price means no upper bound.
+type SavedSearchFilters struct {
+	Category string      `json:"category,omitempty"`
+	MinPrice money.Cents `json:"min_price,omitempty" swaggertype:"string"`
+	MaxPrice money.Cents `json:"max_price,omitempty" swaggertype:"string"`
+}
+
 // Recommendation represents ML-generated recommendations
 type Recommendation struct {
 	ID            uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
@@ -144,6 +283,7 @@ type Recommendation struct {
 	ProductID     uuid.UUID `json:"product_id" gorm:"type:uuid;not null;index"`
 	AlgorithmType string    `json:"algorithm_type" gorm:"not null;index"` // 'collaborative', 'content_based', 'hybrid'
 	Score         float64   `json:"score" gorm:"type:decimal(5,4);not null;index"`
+	GeneratedAt   time.Time `json:"generated_at" gorm:"index"` // When the recommendation was last (re)generated
 	CreatedAt     time.Time `json:"created_at" gorm:"index"`
 
 	// Relationships
@@ -153,15 +293,32 @@ type Recommendation struct {
 
 // RecommendationFeedback represents user feedback on recommendations
 type RecommendationFeedback struct {
-	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
-	UserID       uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
-	ProductID    uuid.UUID `json:"product_id" gorm:"type:uuid;not null;index"`
-	FeedbackType string    `json:"feedback_type" gorm:"not null;index"` // 'clicked', 'purchased', 'dismissed'
-	CreatedAt    time.Time `json:"created_at" gorm:"index"`             // Changed from Timestamp to CreatedAt for consistency
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID       uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	ProductID    uuid.UUID  `json:"product_id" gorm:"type:uuid;not null;index"`
+	FeedbackType string     `json:"feedback_type" gorm:"not null;index"`            // 'clicked', 'purchased', 'dismissed', 'liked'
+	ImpressionID *uuid.UUID `json:"impression_id,omitempty" gorm:"type:uuid;index"` // Impression a click came from
+	CreatedAt    time.Time  `json:"created_at" gorm:"index"`                        // Changed from Timestamp to CreatedAt for consistency
 
 	// Relationships
-	User    User    `json:"user" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Product Product `json:"product" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	User       User                      `json:"user" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Product    Product                   `json:"product" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Impression *RecommendationImpression `json:"-" gorm:"foreignKey:ImpressionID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+}
+
+// RecommendationImpression records a recommended product actually returned to a user
+type RecommendationImpression struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index:idx_recommendation_impressions_user_product,priority:1"`
+	ProductID uuid.UUID `json:"product_id" gorm:"type:uuid;not null;index:idx_recommendation_impressions_user_product,priority:2"`
+	Algorithm string    `json:"algorithm" gorm:"size:50;not null;index"` // Algorithm of the recommendation, 'popular' for fallback items
+	Position  int       `json:"position" gorm:"not null"`                // Zero-based rank in the returned list
+	Score     float64   `json:"score"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+
+	// Relationships
+	User    User    `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Product Product `json:"-" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 }
 
 // UserSession represents user sessions for analytics
@@ -184,7 +341,8 @@ type ProductView struct {
 	UserID    *uuid.UUID `json:"user_id" gorm:"type:uuid;index"`
 	ProductID uuid.UUID  `json:"product_id" gorm:"type:uuid;not null;index"`
 	SessionID string     `json:"session_id" gorm:"index"`
-	CreatedAt time.Time  `json:"created_at" gorm:"index"` // Changed from Timestamp to CreatedAt for consistency
+	Source    string     `json:"source" gorm:"size:16;not null;default:'list';index"` // 'detail' for the product page, 'list' for listings
+	CreatedAt time.Time  `json:"created_at" gorm:"index"`                             // Changed from Timestamp to CreatedAt for consistency
 
 	// Relationships
 	User    *User   `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
@@ -194,10 +352,10 @@ type ProductView struct {
 // SearchAnalytics represents search analytics data
 type SearchAnalytics struct {
 	ID               uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
-	Query            string    `json:"query" gorm:"not null;index"`
-	ResultsCount     int       `json:"results_count" gorm:"default:0"`
-	ClickThroughRate float64   `json:"click_through_rate" gorm:"type:decimal(5,4);default:0"`
-	CreatedAt        time.Time `json:"created_at" gorm:"index"` // Changed from Timestamp to CreatedAt for consistency
+	Query            string    `json:"query" gorm:"not null;index"`                           // Lowercased search text
+	ResultsCount     int       `json:"results_count" gorm:"default:0"`                        // Results of the latest search
+	ClickThroughRate float64   `json:"click_through_rate" gorm:"type:decimal(5,4);default:0"` // Share of searches for the query that had a result opened
+	CreatedAt        time.Time `json:"created_at" gorm:"index"`                               // Changed from Timestamp to CreatedAt for consistency
 }
 
 // MLModelPerformance represents ML model performance metrics
@@ -237,35 +395,40 @@ type Upvote struct {
 
 // Comment represents user comments on products
 type Comment struct {
-	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
-	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
-	ProductID uuid.UUID `json:"product_id" gorm:"type:uuid;not null;index"`
-	Content   string    `json:"content" gorm:"type:text;not null"`
-	Rating    int       `json:"rating" gorm:"check:rating >= 1 AND rating <= 5"` // 1-5 star rating
-	CreatedAt time.Time `json:"created_at" gorm:"index"`
-	UpdatedAt time.Time `json:"updated_at" gorm:"index"`
+	ID               uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID           uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	ProductID        uuid.UUID  `json:"product_id" gorm:"type:uuid;not null;index"`
+	ParentID         *uuid.UUID `json:"parent_id" gorm:"type:uuid;index"` // Set for replies; replies cannot be replied to
+	Content          string     `json:"content" gorm:"type:text;not null"`
+	Rating           *int       `json:"rating" gorm:"check:rating >= 1 AND rating <= 5"`       // 1-5 star rating; nil for replies
+	VerifiedPurchase bool       `json:"verified_purchase" gorm:"not null;default:false;index"` // Author received an order containing the product
+	CreatedAt        time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt        time.Time  `json:"updated_at" gorm:"index"`
 
 	// Relationships
-	User    User    `json:"user" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Product Product `json:"product" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	User    User      `json:"user" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Product Product   `json:"product" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Replies []Comment `json:"replies,omitempty" gorm:"foreignKey:ParentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 }
 
 // Discount represents product discounts
 type Discount struct {
-	ID                uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
-	ProductID         *uuid.UUID `json:"product_id" gorm:"type:uuid;index"`    // Nullable for category-wide discounts
-	Category          *string    `json:"category" gorm:"index"`                // Nullable for product-specific discounts
-	DiscountType      string     `json:"discount_type" gorm:"not null;index"`  // 'percentage', 'fixed_amount'
-	DiscountValue     float64    `json:"discount_value" gorm:"not null"`       // Percentage (0-100) or fixed amount
-	MinOrderAmount    float64    `json:"min_order_amount" gorm:"default:0"`    // Minimum order amount to apply discount
-	MaxDiscountAmount float64    `json:"max_discount_amount" gorm:"default:0"` // Maximum discount amount (for percentage)
-	StartDate         time.Time  `json:"start_date" gorm:"not null;index"`
-	EndDate           time.Time  `json:"end_date" gorm:"not null;index"`
-	IsActive          bool       `json:"is_active" gorm:"default:true;index"`
-	UsageLimit        int        `json:"usage_limit" gorm:"default:0"` // 0 = unlimited
-	UsageCount        int        `json:"usage_count" gorm:"default:0"`
-	CreatedAt         time.Time  `json:"created_at" gorm:"index"`
-	UpdatedAt         time.Time  `json:"updated_at" gorm:"index"`
+	ID                uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	ProductID         *uuid.UUID  `json:"product_id" gorm:"type:uuid;index"`                                     // Nullable for category-wide discounts
+	Category          *string     `json:"category" gorm:"index"`                                                 // Nullable for product-specific discounts
+	Code              *string     `json:"code,omitempty" gorm:"uniqueIndex"`                                     // Coupon code; nil for automatic discounts
+	DiscountType      string      `json:"discount_type" gorm:"not null;index"`                                   // 'percentage', 'fixed_amount'
+	DiscountValue     float64     `json:"discount_value" gorm:"not null"`                                        // Percentage (0-100); 0 for fixed amounts
+	AmountOff         money.Cents `json:"amount_off" gorm:"type:bigint;not null;default:0" swaggertype:"string"` // Fixed amount taken off; 0 for percentages
+	MinOrderAmount    money.Cents `json:"min_order_amount" gorm:"type:bigint;default:0" swaggertype:"string"`    // Minimum order amount to apply discount
+	MaxDiscountAmount money.Cents `json:"max_discount_amount" gorm:"type:bigint;default:0" swaggertype:"string"` // Maximum discount amount (for percentage)
+	StartDate         time.Time   `json:"start_date" gorm:"not null;index"`
+	EndDate           time.Time   `json:"end_date" gorm:"not null;index"`
+	IsActive          bool        `json:"is_active" gorm:"default:true;index"` // Kept in step with the date window by the discount scheduler
+	UsageLimit        int         `json:"usage_limit" gorm:"default:0"`        // 0 = unlimited
+	UsageCount        int         `json:"usage_count" gorm:"default:0"`
+	CreatedAt         time.Time   `json:"created_at" gorm:"index"`
+	UpdatedAt         time.Time   `json:"updated_at" gorm:"index"`
 
 	// Relationships
 	Product *Product `json:"product,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
@@ -275,8 +438,9 @@ type Discount struct {
 type Tag struct {
 	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
 	Name        string    `json:"name" gorm:"uniqueIndex;not null;max:50"`
+	Slug        string    `json:"slug" gorm:"not null;default:''"` // URL-safe form of the name, unique across tags
 	Description string    `json:"description" gorm:"max:255"`
-	Color       string    `json:"color" gorm:"max:7"` // Hex color code
+	Color       string    `json:"color" gorm:"max:7"` // Hex color code, #RRGGBB
 	CreatedAt   time.Time `json:"created_at" gorm:"index"`
 
 	// Relationships
@@ -287,6 +451,7 @@ type Tag struct {
 type ProductTag struct {
 	ProductID uuid.UUID `json:"product_id" gorm:"type:uuid;not null;index"`
 	TagID     uuid.UUID `json:"tag_id" gorm:"type:uuid;not null;index"`
+	Source    string    `json:"source" gorm:"size:16;not null;default:'manual';index"` // 'manual' when added by an admin, 'ml' for accepted suggestions
 	CreatedAt time.Time `json:"created_at" gorm:"index"`
 
 	// Relationships
@@ -308,6 +473,7 @@ type RequestLog struct {
 	RequestSize  int        `json:"request_size" gorm:"default:0"`
 	ResponseSize int        `json:"response_size" gorm:"default:0"`
 	SessionID    string     `json:"session_id" gorm:"index"`
+	RequestID    string     `json:"request_id" gorm:"index"` // X-Request-ID of the request
 	Timestamp    time.Time  `json:"timestamp" gorm:"not null;index"`
 
 	// Relationships
@@ -318,6 +484,7 @@ type RequestLog struct {
 type AnomalyAlert struct {
 	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
 	RequestLogID   *uuid.UUID `json:"request_log_id" gorm:"type:uuid;index"`
+	RequestID      string     `json:"request_id" gorm:"index"` // X-Request-ID of the originating request
 	UserID         *uuid.UUID `json:"user_id" gorm:"type:uuid;index"`
 	IPAddress      string     `json:"ip_address" gorm:"type:inet;not null;index"`
 	AnomalyScore   float64    `json:"anomaly_score" gorm:"type:decimal(5,4);not null;index"`
@@ -341,6 +508,7 @@ type SecurityMetrics struct {
 	ID                uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
 	Date              time.Time `json:"date" gorm:"not null;uniqueIndex"`
 	TotalRequests     int       `json:"total_requests" gorm:"default:0"`
+	ErrorRequests     int       `json:"error_requests" gorm:"default:0"` // Requests answered with a 4xx or 5xx status
 	AnomalousRequests int       `json:"anomalous_requests" gorm:"default:0"`
 	HighRiskRequests  int       `json:"high_risk_requests" gorm:"default:0"`
 	BlockedRequests   int       `json:"blocked_requests" gorm:"default:0"`
@@ -351,6 +519,387 @@ type SecurityMetrics struct {
 	UpdatedAt         time.Time `json:"updated_at" gorm:"index"`
 }
 
+// NotificationPreference represents a user's notification opt-ins
+type NotificationPreference struct {
+	ID              uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID          uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex"`
+	PriceDrop       bool      `json:"price_drop" gorm:"not null"`
+	BackInStock     bool      `json:"back_in_stock" gorm:"not null"`
+	OrderStatus     bool      `json:"order_status" gorm:"not null"`
+	DigestFrequency string    `json:"digest_frequency" gorm:"not null;size:10"` // 'none', 'daily', 'weekly'
+	CreatedAt       time.Time `json:"created_at" gorm:"index"`
+	UpdatedAt       time.Time `json:"updated_at" gorm:"index"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// UserPreference represents explicit recommendation preferences set by a user
+type UserPreference struct {
+	ID                  uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID              uuid.UUID    `json:"user_id" gorm:"type:uuid;not null;uniqueIndex"`
+	PreferredCategories []string     `json:"preferred_categories" gorm:"serializer:json;type:jsonb"`
+	AvoidedCategories   []string     `json:"avoided_categories" gorm:"serializer:json;type:jsonb"`
+	MinPrice            *money.Cents `json:"min_price" gorm:"type:bigint" swaggertype:"string"`
+	MaxPrice            *money.Cents `json:"max_price" gorm:"type:bigint" swaggertype:"string"`
+	CreatedAt           time.Time    `json:"created_at" gorm:"index"`
+	UpdatedAt           time.Time    `json:"updated_at" gorm:"index"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// OrderStatusHistory represents one status transition of an order
+type OrderStatusHistory struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrderID    uuid.UUID  `json:"order_id" gorm:"type:uuid;not null;index"`
+	FromStatus string     `json:"from_status"` // Empty for the initial status
+	ToStatus   string     `json:"to_status" gorm:"not null"`
+	ChangedBy  *uuid.UUID `json:"changed_by" gorm:"type:uuid;index"`
+	Note       string     `json:"note" gorm:"type:text"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"index"`
+
+	// Relationships
+	Order Order `json:"-" gorm:"foreignKey:OrderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// Refund represents money returned to a customer for a delivered order
+type Refund struct {
+	ID        uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	OrderID   uuid.UUID   `json:"order_id" gorm:"type:uuid;not null;index"`
+	Amount    money.Cents `json:"amount" gorm:"type:bigint;not null" swaggertype:"string"`
+	Reason    string      `json:"reason" gorm:"type:text;not null"`
+	CreatedBy *uuid.UUID  `json:"created_by" gorm:"type:uuid;index"`
+	CreatedAt time.Time   `json:"created_at" gorm:"index"`
+
+	// Relationships
+	Items   []RefundItem `json:"items" gorm:"foreignKey:RefundID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Creator *User        `json:"-" gorm:"foreignKey:CreatedBy;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+}
+
+// RefundItem represents the quantity of an order item covered by a refund
+type RefundItem struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	RefundID    uuid.UUID `json:"refund_id" gorm:"type:uuid;not null;index"`
+	OrderItemID uuid.UUID `json:"order_item_id" gorm:"type:uuid;not null;index"`
+	Quantity    int       `json:"quantity" gorm:"not null;check:quantity > 0"`
+
+	// Relationships
+	OrderItem OrderItem `json:"-" gorm:"foreignKey:OrderItemID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// StockMovement represents an auditable change to a product's stock level
+type StockMovement struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	ProductID  uuid.UUID  `json:"product_id" gorm:"type:uuid;not null;index"`
+	Delta      int        `json:"delta" gorm:"not null"`
+	StockAfter int        `json:"stock_after" gorm:"not null"`
+	Reason     string     `json:"reason" gorm:"not null;index"` // 'shipment_received', 'adjustment', ...
+	Reference  string     `json:"reference" gorm:"index"`       // External reference such as a shipment number
+	CreatedBy  *uuid.UUID `json:"created_by" gorm:"type:uuid;index"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"index"`
+
+	// Relationships
+	Product Product `json:"product,omitempty" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Creator *User   `json:"-" gorm:"foreignKey:CreatedBy;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+}
+
+// Notification represents an in-app notification for a user
+type Notification struct {
+	ID        uuid.UUID              `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID    uuid.UUID              `json:"user_id" gorm:"type:uuid;not null;index"`
+	Type      string                 `json:"type" gorm:"not null;index"` // 'back_in_stock', 'price_drop', 'order_status', ...
+	Title     string                 `json:"title" gorm:"not null"`
+	Body      string                 `json:"body" gorm:"type:text"`
+	Read      bool                   `json:"read" gorm:"default:false;index"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty" gorm:"serializer:json;type:jsonb"` // Related IDs such as order_id or product_id
+	CreatedAt time.Time              `json:"created_at" gorm:"index"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// NotificationSuppression represents a user who must not receive notifications,
+// e.g. after a bounce or an unsubscribe
+type NotificationSuppression struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex"`
+	Reason    string    `json:"reason" gorm:"not null"` // 'bounce', 'unsubscribe', 'complaint', 'manual'
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// RefreshToken represents a long-lived token used to obtain new access tokens
+type RefreshToken struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	TokenHash string    `json:"-" gorm:"not null;uniqueIndex"` // SHA-256 of the token; the token itself is never stored
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	Revoked   bool      `json:"revoked" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// PasswordResetToken represents a single-use token for resetting a forgotten password
+type PasswordResetToken struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	TokenHash string     `json:"-" gorm:"not null;uniqueIndex"` // SHA-256 of the token; the token itself is never stored
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null;index"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"index"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// RevokedToken represents an access token that was invalidated before it expired
+type RevokedToken struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	JTI       string    `json:"jti" gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"` // Entry can be removed once the token would have expired
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// Address represents a saved shipping address in a user's address book
+type Address struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	Label     string    `json:"label" gorm:"type:varchar(50)"` // e.g. 'Home', 'Work'
+	Line1     string    `json:"line1" gorm:"not null"`
+	Line2     string    `json:"line2"`
+	City      string    `json:"city" gorm:"not null"`
+	State     string    `json:"state"`
+	Zip       string    `json:"zip" gorm:"type:varchar(20);not null"`
+	Country   string    `json:"country" gorm:"not null"`
+	IsDefault bool      `json:"is_default" gorm:"not null;index"` // At most one per user
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"index"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// StockReservation represents units of a product held for a user's checkout until it expires
+type StockReservation struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	ProductID uuid.UUID `json:"product_id" gorm:"type:uuid;not null;uniqueIndex:idx_stock_reservations_user_product,priority:2"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_stock_reservations_user_product,priority:1"`
+	Quantity  int       `json:"quantity" gorm:"not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relationships
+	Product Product `json:"-" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	User    User    `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// PriceHistory represents a change to a product's price
+type PriceHistory struct {
+	ID        uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	ProductID uuid.UUID   `json:"product_id" gorm:"type:uuid;not null;index:idx_price_histories_product_changed,priority:1"`
+	OldPrice  money.Cents `json:"old_price" gorm:"type:bigint;not null" swaggertype:"string"`
+	NewPrice  money.Cents `json:"new_price" gorm:"type:bigint;not null" swaggertype:"string"`
+	ChangedAt time.Time   `json:"changed_at" gorm:"not null;index:idx_price_histories_product_changed,priority:2"`
+
+	// Relationships
+	Product Product `json:"-" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// PriceAlert represents a price drop a user was alerted about for one of their favorites
+type PriceAlert struct {
+	ID             uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID         uuid.UUID   `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_price_alerts_user_change,priority:1"`
+	ProductID      uuid.UUID   `json:"product_id" gorm:"type:uuid;not null;index"`
+	PriceHistoryID uuid.UUID   `json:"price_history_id" gorm:"type:uuid;not null;uniqueIndex:idx_price_alerts_user_change,priority:2"`
+	OldPrice       money.Cents `json:"old_price" gorm:"type:bigint;not null" swaggertype:"string"`
+	NewPrice       money.Cents `json:"new_price" gorm:"type:bigint;not null" swaggertype:"string"`
+	CreatedAt      time.Time   `json:"created_at" gorm:"index"`
+
+	// Relationships
+	User         User         `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Product      Product      `json:"-" gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	PriceHistory PriceHistory `json:"-" gorm:"foreignKey:PriceHistoryID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// SentimentSnapshot is the last sentiment analysis of a product's comments, kept so
+// product pages do not wait on the ML service
+type SentimentSnapshot struct {
+	ProductID    uuid.UUID `json:"product_id" gorm:"type:uuid;primary_key"`
+	Score        float64   `json:"score" gorm:"type:decimal(5,4);not null;default:0"` // Average comment sentiment, from -1 to 1
+	Label        string    `json:"label" gorm:"size:16;not null"`                     // 'positive', 'negative', 'neutral' or 'no_data'
+	CommentCount int       `json:"comment_count" gorm:"not null;default:0"`
+	ComputedAt   time.Time `json:"computed_at" gorm:"not null;index"`
+}
+
+// TrainingJob represents a run of ML model training started by an admin
+type TrainingJob struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Status     string     `json:"status" gorm:"size:16;not null;default:'running';index"` // 'running', 'completed' or 'failed'
+	StartedBy  uuid.UUID  `json:"started_by" gorm:"type:uuid;not null;index"`
+	StartedAt  time.Time  `json:"started_at" gorm:"not null;index"`
+	FinishedAt *time.Time `json:"finished_at"`
+	Error      string     `json:"error,omitempty"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:StartedBy;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// SearchConfig holds the search ranking weights set by an admin. There is at most one
+// row; without it the weights come from the environment.
+type SearchConfig struct {
+	ID                int        `json:"-" gorm:"primary_key"` // Always 1
+	NameWeight        float64    `json:"name_weight" gorm:"not null"`
+	CategoryWeight    float64    `json:"category_weight" gorm:"not null"`
+	DescriptionWeight float64    `json:"description_weight" gorm:"not null"`
+	UpdatedBy         *uuid.UUID `json:"updated_by" gorm:"type:uuid"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// WishlistShare represents a public read-only link to a user's favorites
+type WishlistShare struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID    uuid.UUID `json:"-" gorm:"type:uuid;not null;uniqueIndex"`
+	Slug      string    `json:"slug" gorm:"size:64;not null;uniqueIndex"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// IPBlock represents a client IP address denied access to the API
+type IPBlock struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	IPAddress string     `json:"ip_address" gorm:"type:inet;not null;uniqueIndex"`
+	Reason    string     `json:"reason" gorm:"type:text"`
+	ExpiresAt *time.Time `json:"expires_at" gorm:"index"` // nil blocks permanently
+	CreatedBy *uuid.UUID `json:"created_by" gorm:"type:uuid;index"`
+	CreatedAt time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	// Relationships
+	CreatedByUser *User `json:"created_by_user,omitempty" gorm:"foreignKey:CreatedBy;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+}
+
+// Webhook represents an external endpoint notified about subscribed events
+type Webhook struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	URL        string     `json:"url" gorm:"type:text;not null"`
+	Secret     string     `json:"-" gorm:"size:128;not null"`                             // HMAC-SHA256 signing key
+	EventTypes []string   `json:"event_types" gorm:"serializer:json;type:jsonb;not null"` // e.g. 'anomaly.critical', 'order.created'
+	Active     bool       `json:"active" gorm:"not null;default:true;index"`
+	CreatedBy  *uuid.UUID `json:"created_by" gorm:"type:uuid;index"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	// Relationships
+	CreatedByUser *User `json:"created_by_user,omitempty" gorm:"foreignKey:CreatedBy;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+}
+
+// WebhookDelivery represents one event sent to a webhook and the outcome of its attempts
+type WebhookDelivery struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	WebhookID      uuid.UUID  `json:"webhook_id" gorm:"type:uuid;not null;index"`
+	EventType      string     `json:"event_type" gorm:"size:50;not null;index"`
+	Payload        string     `json:"payload" gorm:"type:text;not null"`                                                                    // Exact signed body, resent unchanged on retries
+	Status         string     `json:"status" gorm:"size:20;not null;default:'pending';index:idx_webhook_deliveries_status_next,priority:1"` // 'pending', 'succeeded', 'failed'
+	Attempts       int        `json:"attempts" gorm:"not null;default:0"`
+	LastStatusCode int        `json:"last_status_code"`
+	LastError      string     `json:"last_error" gorm:"type:text"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"not null;index:idx_webhook_deliveries_status_next,priority:2"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relationships
+	Webhook Webhook `json:"-" gorm:"foreignKey:WebhookID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// DailyUserStats represents one user's activity on one day, rolled up from the raw
+// order, interaction and search tables so analytics need not scan them
+type DailyUserStats struct {
+	ID           uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID       uuid.UUID   `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_daily_user_stats_user_date,priority:1"`
+	Date         time.Time   `json:"date" gorm:"type:date;not null;uniqueIndex:idx_daily_user_stats_user_date,priority:2;index"` // UTC day
+	Orders       int64       `json:"orders" gorm:"not null;default:0"`                                                           // Orders placed that day, in any status
+	Spend        money.Cents `json:"spend" gorm:"type:bigint;not null;default:0" swaggertype:"string"`                           // Total of that day's delivered and completed orders
+	Interactions int64       `json:"interactions" gorm:"not null;default:0"`
+	Searches     int64       `json:"searches" gorm:"not null;default:0"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// AnalyticsSchedule represents a user's subscription to a recurring analytics export
+type AnalyticsSchedule struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID     uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	ReportType string     `json:"report_type" gorm:"size:20;not null"` // 'user', 'products'
+	Frequency  string     `json:"frequency" gorm:"size:20;not null"`   // 'weekly', 'monthly'
+	Format     string     `json:"format" gorm:"size:10;not null"`      // 'csv', 'xlsx'
+	LastSentAt *time.Time `json:"last_sent_at"`
+	NextRunAt  time.Time  `json:"next_run_at" gorm:"not null;index"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// GeneratedReport represents an analytics export produced for a user, kept for download
+type GeneratedReport struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID      uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	ScheduleID  *uuid.UUID `json:"schedule_id" gorm:"type:uuid;index"`
+	ReportType  string     `json:"report_type" gorm:"size:20;not null"`
+	Format      string     `json:"format" gorm:"size:10;not null"`
+	Filename    string     `json:"filename" gorm:"size:255;not null"`
+	ContentType string     `json:"content_type" gorm:"size:100;not null"`
+	Content     []byte     `json:"-" gorm:"type:bytea;not null"`
+	Size        int        `json:"size"`
+	PeriodStart time.Time  `json:"period_start"`
+	PeriodEnd   time.Time  `json:"period_end"`
+	URL         string     `json:"url" gorm:"size:255"` // Download link
+	CreatedAt   time.Time  `json:"created_at" gorm:"index"`
+
+	// Relationships
+	User     User               `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Schedule *AnalyticsSchedule `json:"-" gorm:"foreignKey:ScheduleID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+}
+
+// Experiment represents an A/B test of recommendation algorithms
+type Experiment struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Name        string     `json:"name" gorm:"size:100;not null;uniqueIndex"`
+	Description string     `json:"description" gorm:"type:text"`
+	Variants    []string   `json:"variants" gorm:"serializer:json;type:jsonb;not null"`   // Algorithms users are bucketed into, e.g. 'collaborative', 'content_based', 'hybrid'
+	Status      string     `json:"status" gorm:"size:20;not null;default:'active';index"` // 'active', 'paused', 'completed'
+	StartedAt   time.Time  `json:"started_at"`
+	EndedAt     *time.Time `json:"ended_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ExperimentAssignment records the variant a user was bucketed into, keeping it sticky across sessions
+type ExperimentAssignment struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	ExperimentID uuid.UUID `json:"experiment_id" gorm:"type:uuid;not null;uniqueIndex:idx_experiment_assignments_experiment_user,priority:1"`
+	UserID       uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_experiment_assignments_experiment_user,priority:2;index"`
+	Variant      string    `json:"variant" gorm:"size:50;not null;index"`
+	CreatedAt    time.Time `json:"created_at"` // When the user was assigned
+
+	// Relationships
+	Experiment Experiment `json:"-" gorm:"foreignKey:ExperimentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	User       User       `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
 // BeforeCreate hook for User model
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.ID == uuid.Nil {
@@ -0,0 +1,32 @@
+package metrics
+
+// Metrics recorded by the backend
+var (
+	HTTPRequestsTotal = NewCounterVec(
+		"http_requests_total",
+		"HTTP requests handled, by method, route and status code.",
+		"method", "path", "status",
+	)
+	HTTPRequestDuration = NewHistogramVec(
+		"http_request_duration_seconds",
+		"Time spent handling HTTP requests, by method and route.",
+		DefaultBuckets,
+		"method", "path",
+	)
+	DBQueriesTotal = NewCounterVec(
+		"db_queries_total",
+		"Database statements executed, by operation and outcome.",
+		"operation", "outcome",
+	)
+	MLRequestDuration = NewHistogramVec(
+		"ml_request_duration_seconds",
+		"Latency of calls to the ML service, by endpoint and outcome.",
+		DefaultBuckets,
+		"endpoint", "outcome",
+	)
+	OrdersActive = NewGaugeVec(
+		"orders_active",
+		"Orders not yet delivered or cancelled, by status.",
+		"status",
+	)
+)
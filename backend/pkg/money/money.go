@@ -0,0 +1,143 @@
+// Package money provides an integer amount of cents for storing and adding up prices
+// without floating point rounding errors.
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidAmount is returned when parsing an amount that is not a decimal number
+// with at most two fractional digits
+var ErrInvalidAmount = errors.New("invalid monetary amount")
+
+// Cents is an amount of money in hundredths of the currency unit. It is stored as a
+// bigint and marshals to JSON as a decimal string such as "19.99"; it unmarshals from
+// either a string or a number.
+type Cents int64
+
+// FromFloat converts an amount in currency units to cents, rounding to the nearest cent
+func FromFloat(amount float64) Cents {
+	return Cents(math.Round(amount * 100))
+}
+
+// Parse reads a decimal amount such as "19.99", "-5" or "0.5" exactly
+func Parse(text string) (Cents, error) {
+	text = strings.TrimSpace(text)
+	negative := strings.HasPrefix(text, "-")
+	digits := strings.TrimPrefix(text, "-")
+
+	whole, fraction, _ := strings.Cut(digits, ".")
+	if whole == "" && fraction == "" || len(fraction) > 2 || !isDigits(whole) || !isDigits(fraction) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, text)
+	}
+	fraction += strings.Repeat("0", 2-len(fraction))
+	if whole == "" {
+		whole = "0"
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > math.MaxInt64/100-1 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, text)
+	}
+	cents, _ := strconv.ParseInt(fraction, 10, 64)
+
+	amount := Cents(units*100 + cents)
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+func isDigits(text string) bool {
+	for _, r := range text {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Float64 returns the amount in currency units, for display and statistics only
+func (c Cents) Float64() float64 {
+	return float64(c) / 100
+}
+
+// String formats the amount as a decimal with two fractional digits
+func (c Cents) String() string {
+	sign := ""
+	value := int64(c)
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, value/100, value%100)
+}
+
+// Mul returns the amount multiplied by a quantity
+func (c Cents) Mul(quantity int) Cents {
+	return c * Cents(quantity)
+}
+
+// Div returns the amount split evenly over a quantity, rounded to the nearest cent
+func (c Cents) Div(quantity int) Cents {
+	return Cents(math.Round(float64(c) / float64(quantity)))
+}
+
+// MulRate returns the amount multiplied by a rate such as a tax rate or exchange rate,
+// rounded to the nearest cent
+func (c Cents) MulRate(rate float64) Cents {
+	return Cents(math.Round(float64(c) * rate))
+}
+
+// Percent returns percent of the amount, rounded to the nearest cent
+func (c Cents) Percent(percent float64) Cents {
+	return c.MulRate(percent / 100)
+}
+
+// Min returns the smaller of two amounts
+func Min(a, b Cents) Cents {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// MarshalJSON implements json.Marshaler
+func (c Cents) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + c.String() + `"`), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (c *Cents) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	text := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	} else if strings.ContainsAny(text, "eE") {
+		// Numbers in exponent form such as 1e2
+		amount, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidAmount, text)
+		}
+		*c = FromFloat(amount)
+		return nil
+	}
+
+	amount, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	*c = amount
+	return nil
+}
@@ -0,0 +1,71 @@
+// Package pagination provides offset pagination with a response shape shared by every list endpoint.
+package pagination
+
+import (
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultLimit is used when the requested limit is missing or not positive
+	DefaultLimit = 20
+	// MaxLimit caps the number of items returned per page
+	MaxLimit = 100
+)
+
+// PaginationMeta describes a page of results
+type PaginationMeta struct {
+	Page       int   `json:"page"`
+	Limit      int   `json:"limit"`
+	Total      int64 `json:"total"`
+	TotalPages int64 `json:"total_pages"`
+}
+
+// NewMeta builds the metadata for a page of a result set with total items
+func NewMeta(page, limit int, total int64) PaginationMeta {
+	return PaginationMeta{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: (total + int64(limit) - 1) / int64(limit),
+	}
+}
+
+// Normalize clamps page to at least 1 and limit to 1..MaxLimit, using DefaultLimit
+// for a missing limit
+func Normalize(page, limit int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+	return page, limit
+}
+
+// Paginate counts the rows matched by query and loads the requested page into a slice.
+// Ordering and preloads on query apply to the page; the count ignores them.
+func Paginate[T any](query *gorm.DB, page, limit int) ([]T, PaginationMeta, error) {
+	page, limit = Normalize(page, limit)
+
+	// Count on a copy without preloads, which would otherwise run against the count
+	countQuery := query.Session(&gorm.Session{}).Model(new(T))
+	countQuery.Statement.Preloads = nil
+
+	var total int64
+	if err := countQuery.Count(&total).Error; err != nil {
+		return nil, PaginationMeta{}, err
+	}
+
+	items := make([]T, 0, limit)
+	if err := query.Session(&gorm.Session{}).
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&items).Error; err != nil {
+		return nil, PaginationMeta{}, err
+	}
+
+	return items, NewMeta(page, limit, total), nil
+}
@@ -0,0 +1,200 @@
+// Package xlsx writes simple Office Open XML spreadsheets: one or more sheets of
+// plain rows holding text and numbers, without styles or formulas.
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContentType is the MIME type of an XLSX workbook
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// maxSheetNameLength is the longest sheet name spreadsheet applications accept
+const maxSheetNameLength = 31
+
+// Workbook is a spreadsheet built sheet by sheet in memory
+type Workbook struct {
+	sheets []sheet
+}
+
+type sheet struct {
+	name string
+	rows [][]interface{}
+}
+
+// AddSheet appends a sheet. Cells may be strings, integers, floats, booleans,
+// times or nil; anything else is written as its fmt representation.
+func (wb *Workbook) AddSheet(name string, rows [][]interface{}) {
+	wb.sheets = append(wb.sheets, sheet{name: sanitizeSheetName(name, len(wb.sheets)+1), rows: rows})
+}
+
+// Write encodes the workbook as an XLSX file
+func (wb *Workbook) Write(w io.Writer) error {
+	if len(wb.sheets) == 0 {
+		wb.AddSheet("Sheet1", nil)
+	}
+
+	zw := zip.NewWriter(w)
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", wb.contentTypes()},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", wb.workbook()},
+		{"xl/_rels/workbook.xml.rels", wb.workbookRels()},
+	}
+	for _, file := range files {
+		if err := writeZipFile(zw, file.name, file.content); err != nil {
+			return err
+		}
+	}
+	for i, s := range wb.sheets {
+		if err := writeZipFile(zw, fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), s.xml()); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+const rootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+func (wb *Workbook) contentTypes() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	for i := range wb.sheets {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+func (wb *Workbook) workbook() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, s := range wb.sheets {
+		fmt.Fprintf(&b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escape(s.name), i+1, i+1)
+	}
+	b.WriteString(`</sheets></workbook>`)
+	return b.String()
+}
+
+func (wb *Workbook) workbookRels() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := range wb.sheets {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+func (s sheet) xml() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for r, row := range s.rows {
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		for c, value := range row {
+			writeCell(&b, cellRef(c, r), value)
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
+
+// writeCell writes one cell; numbers are stored as numbers, everything else as inline text
+func writeCell(b *strings.Builder, ref string, value interface{}) {
+	var number string
+	switch v := value.(type) {
+	case nil:
+		return
+	case int:
+		number = strconv.Itoa(v)
+	case int64:
+		number = strconv.FormatInt(v, 10)
+	case float64:
+		number = strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		flag := "0"
+		if v {
+			flag = "1"
+		}
+		fmt.Fprintf(b, `<c r="%s" t="b"><v>%s</v></c>`, ref, flag)
+		return
+	case time.Time:
+		writeText(b, ref, v.Format(time.RFC3339))
+		return
+	case string:
+		writeText(b, ref, v)
+		return
+	default:
+		writeText(b, ref, fmt.Sprint(v))
+		return
+	}
+	fmt.Fprintf(b, `<c r="%s"><v>%s</v></c>`, ref, number)
+}
+
+func writeText(b *strings.Builder, ref, text string) {
+	fmt.Fprintf(b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, escape(text))
+}
+
+// cellRef returns the A1-style reference of a zero-based column and row
+func cellRef(col, row int) string {
+	name := ""
+	for col >= 0 {
+		name = string(rune('A'+col%26)) + name
+		col = col/26 - 1
+	}
+	return name + strconv.Itoa(row+1)
+}
+
+// sanitizeSheetName drops characters sheet names may not contain and enforces the length limit
+func sanitizeSheetName(name string, position int) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return -1
+		}
+		return r
+	}, name)
+	if runes := []rune(name); len(runes) > maxSheetNameLength {
+		name = string(runes[:maxSheetNameLength])
+	}
+	if strings.TrimSpace(name) == "" {
+		name = "Sheet" + strconv.Itoa(position)
+	}
+	return name
+}
+
+// escape escapes text for XML, replacing characters XML cannot represent
+func escape(text string) string {
+	var b strings.Builder
+	if err := xml.EscapeText(&b, []byte(text)); err != nil {
+		return ""
+	}
+	return b.String()
+}
+
+func writeZipFile(zw *zip.Writer, name, content string) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, content)
+	return err
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AnalyticsCache is a short-lived in-memory cache for encoded analytics responses.
+// Entries are keyed per user so a user's entries can be dropped together.
+type AnalyticsCache struct {
+	mu      sync.RWMutex
+	entries map[string]analyticsCacheEntry
+	ttl     time.Duration
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+	evicted atomic.Uint64
+}
+
+type analyticsCacheEntry struct {
+	body      []byte
+	expiresAt time.Time
+}
+
+// NewAnalyticsCache creates a new analytics cache with the given TTL
+func NewAnalyticsCache(ttl time.Duration) *AnalyticsCache {
+	return &AnalyticsCache{
+		entries: make(map[string]analyticsCacheEntry),
+		ttl:     ttl,
+	}
+}
+
+// AnalyticsCacheKey builds the cache key of an analytics response for a user, endpoint
+// and canonical query string. The day is part of the key so no entry outlives the day
+// its date ranges were computed for.
+func AnalyticsCacheKey(userID uuid.UUID, endpoint, params string, at time.Time) string {
+	return userID.String() + ":" + endpoint + ":" + at.Format("2006-01-02") + ":" + params
+}
+
+// Get returns a cached response body if present and not expired
+func (ac *AnalyticsCache) Get(key string) ([]byte, bool) {
+	ac.mu.RLock()
+	entry, exists := ac.entries[key]
+	ac.mu.RUnlock()
+
+	if !exists || time.Now().After(entry.expiresAt) {
+		ac.misses.Add(1)
+		return nil, false
+	}
+
+	ac.hits.Add(1)
+	return entry.body, true
+}
+
+// Set stores a response body under the given key for the cache TTL
+func (ac *AnalyticsCache) Set(key string, body []byte) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	// Drop expired entries opportunistically so the map doesn't grow unbounded
+	now := time.Now()
+	for k, entry := range ac.entries {
+		if now.After(entry.expiresAt) {
+			delete(ac.entries, k)
+		}
+	}
+
+	ac.entries[key] = analyticsCacheEntry{
+		body:      body,
+		expiresAt: now.Add(ac.ttl),
+	}
+}
+
+// InvalidateUser drops every cached response of a user, e.g. after they place an order
+func (ac *AnalyticsCache) InvalidateUser(userID uuid.UUID) {
+	prefix := userID.String() + ":"
+
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	for key := range ac.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(ac.entries, key)
+			ac.evicted.Add(1)
+		}
+	}
+}
+
+// TTL returns how long responses are cached
+func (ac *AnalyticsCache) TTL() time.Duration {
+	return ac.ttl
+}
+
+// Stats returns cache hit/miss statistics
+func (ac *AnalyticsCache) Stats() map[string]interface{} {
+	ac.mu.RLock()
+	size := len(ac.entries)
+	ac.mu.RUnlock()
+
+	hits := ac.hits.Load()
+	misses := ac.misses.Load()
+
+	hitRate := 0.0
+	if total := hits + misses; total > 0 {
+		hitRate = float64(hits) / float64(total) * 100
+	}
+
+	return map[string]interface{}{
+		"hits":        hits,
+		"misses":      misses,
+		"hit_rate":    hitRate,
+		"entries":     size,
+		"invalidated": ac.evicted.Load(),
+		"ttl_seconds": ac.ttl.Seconds(),
+	}
+}
+
+// analyticsCacheTTL reads the cache TTL from the environment
+func analyticsCacheTTL() time.Duration {
+	if value := os.Getenv("ANALYTICS_CACHE_TTL_SECONDS"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return 5 * time.Minute
+}
+
+// Global analytics cache instance
+var AnalyticsCacheInstance = NewAnalyticsCache(analyticsCacheTTL())
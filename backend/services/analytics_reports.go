@@ -0,0 +1,202 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/models"
+)
+
+// NotificationAnalyticsReport is the notification sent when a scheduled report is ready
+const NotificationAnalyticsReport = "analytics_report"
+
+// Analytics report cadences
+const (
+	ReportFrequencyWeekly  = "weekly"
+	ReportFrequencyMonthly = "monthly"
+)
+
+// maxReportsPerRun bounds how many schedules a single run generates
+const maxReportsPerRun = 100
+
+// AnalyticsReportGenerator builds the export of a schedule for the period from to to.
+// It fills in the report's filename, content type and content.
+type AnalyticsReportGenerator func(schedule models.AnalyticsSchedule, from, to time.Time) (*models.GeneratedReport, error)
+
+// AnalyticsReportScheduler periodically generates the analytics reports users subscribed to
+type AnalyticsReportScheduler struct {
+	ticker    *time.Ticker
+	stopChan  chan bool
+	isRunning bool
+	lastRun   time.Time
+	generate  AnalyticsReportGenerator
+	generated int64
+	failed    int64
+}
+
+// NewAnalyticsReportScheduler creates a new analytics report scheduler
+func NewAnalyticsReportScheduler() *AnalyticsReportScheduler {
+	return &AnalyticsReportScheduler{
+		stopChan:  make(chan bool),
+		isRunning: false,
+	}
+}
+
+// SetGenerator registers the function that builds report exports. It lets the
+// scheduler reuse the analytics queries of the handlers without importing them.
+func (rs *AnalyticsReportScheduler) SetGenerator(generate AnalyticsReportGenerator) {
+	rs.generate = generate
+}
+
+// Start begins checking for due schedules every interval
+func (rs *AnalyticsReportScheduler) Start(intervalMinutes int) {
+	if rs.isRunning {
+		log.Println("Analytics report scheduler is already running")
+		return
+	}
+
+	rs.ticker = time.NewTicker(time.Duration(intervalMinutes) * time.Minute)
+	rs.isRunning = true
+
+	log.Printf("Starting analytics report scheduler with %d minute intervals", intervalMinutes)
+
+	go func() {
+		rs.runDueReports()
+
+		for {
+			select {
+			case <-rs.ticker.C:
+				rs.runDueReports()
+			case <-rs.stopChan:
+				rs.ticker.Stop()
+				rs.isRunning = false
+				log.Println("Analytics report scheduler stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the scheduler
+func (rs *AnalyticsReportScheduler) Stop() {
+	if !rs.isRunning {
+		return
+	}
+
+	rs.stopChan <- true
+}
+
+// runDueReports generates a report for every schedule whose next run has passed
+func (rs *AnalyticsReportScheduler) runDueReports() {
+	rs.lastRun = time.Now()
+	if rs.generate == nil {
+		log.Println("Analytics report scheduler has no generator registered")
+		return
+	}
+
+	var schedules []models.AnalyticsSchedule
+	if err := database.DB.Where("next_run_at <= ?", rs.lastRun).
+		Order("next_run_at ASC").
+		Limit(maxReportsPerRun).
+		Find(&schedules).Error; err != nil {
+		log.Printf("Failed to load due analytics schedules: %v", err)
+		return
+	}
+
+	for _, schedule := range schedules {
+		if _, err := rs.RunSchedule(schedule, rs.lastRun); err != nil {
+			rs.failed++
+			log.Printf("Failed to generate analytics report for schedule %s: %v", schedule.ID, err)
+			continue
+		}
+		rs.generated++
+	}
+
+	if len(schedules) > 0 {
+		log.Printf("Analytics report run completed: %d schedules processed", len(schedules))
+	}
+}
+
+// RunSchedule generates and stores the report of a schedule for the period ending at,
+// advances the schedule and notifies its owner that the report is ready
+func (rs *AnalyticsReportScheduler) RunSchedule(schedule models.AnalyticsSchedule, at time.Time) (*models.GeneratedReport, error) {
+	from := ReportPeriodStart(schedule.Frequency, at)
+	report, err := rs.generate(schedule, from, at)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduleID := schedule.ID
+	report.UserID = schedule.UserID
+	report.ScheduleID = &scheduleID
+	report.ReportType = schedule.ReportType
+	report.Format = schedule.Format
+	report.Size = len(report.Content)
+	report.PeriodStart = from
+	report.PeriodEnd = at
+
+	if err := database.DB.Create(report).Error; err != nil {
+		return nil, err
+	}
+
+	report.URL = fmt.Sprintf("/api/v1/analytics/reports/%s/download", report.ID)
+	database.DB.Model(report).Update("url", report.URL)
+
+	// Advance from the previous due time so the cadence doesn't drift with the check interval
+	next := NextReportRun(schedule.Frequency, schedule.NextRunAt)
+	for !next.After(at) {
+		next = NextReportRun(schedule.Frequency, next)
+	}
+	if err := database.DB.Model(&schedule).Updates(map[string]interface{}{
+		"last_sent_at": at,
+		"next_run_at":  next,
+	}).Error; err != nil {
+		return report, err
+	}
+
+	title := "Your analytics report is ready"
+	body := fmt.Sprintf("Your %s %s analytics report for %s to %s is ready to download.",
+		schedule.Frequency, schedule.ReportType, from.Format("2006-01-02"), at.Format("2006-01-02"))
+	metadata := map[string]interface{}{
+		"report_id":   report.ID,
+		"schedule_id": schedule.ID,
+		"url":         report.URL,
+	}
+	if err := NotificationDispatcherInstance.Enqueue(schedule.UserID, NotificationAnalyticsReport, title, body, metadata); err != nil {
+		log.Printf("Failed to queue analytics report notification for user %s: %v", schedule.UserID, err)
+	}
+
+	return report, nil
+}
+
+// GetStatus returns the current status of the scheduler
+func (rs *AnalyticsReportScheduler) GetStatus() map[string]interface{} {
+	return map[string]interface{}{
+		"is_running":   rs.isRunning,
+		"last_run":     rs.lastRun.Format(time.RFC3339),
+		"generated":    rs.generated,
+		"failed":       rs.failed,
+		"service_name": "analytics_report_scheduler",
+	}
+}
+
+// NextReportRun returns when a schedule of the given frequency runs after from
+func NextReportRun(frequency string, from time.Time) time.Time {
+	if frequency == ReportFrequencyMonthly {
+		return from.AddDate(0, 1, 0)
+	}
+	return from.AddDate(0, 0, 7)
+}
+
+// ReportPeriodStart returns the start of the period a report ending at covers
+func ReportPeriodStart(frequency string, at time.Time) time.Time {
+	if frequency == ReportFrequencyMonthly {
+		return at.AddDate(0, -1, 0)
+	}
+	return at.AddDate(0, 0, -7)
+}
+
+// Global analytics report scheduler instance
+var AnalyticsReportSchedulerInstance = NewAnalyticsReportScheduler()
@@ -31,14 +31,13 @@ func NewAnomalyService() *AnomalyService {
 
 	return &AnomalyService{
 		mlServiceURL: mlServiceURL,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		httpClient:   newMLHTTPClient(30 * time.Second),
 	}
 }
 
 // RequestAnalysisRequest represents the request structure for ML analysis
 type RequestAnalysisRequest struct {
+	ID           string  `json:"id,omitempty"` // Echoed back as the analysis request_id
 	IPAddress    string  `json:"ip_address"`
 	UserAgent    string  `json:"user_agent"`
 	Method       string  `json:"method"`
@@ -77,6 +76,7 @@ type MLServiceResponse struct {
 func (as *AnomalyService) AnalyzeRequest(requestLog models.RequestLog) (*AnomalyAnalysisResponse, error) {
 	// Prepare request data
 	analysisRequest := RequestAnalysisRequest{
+		ID:           requestLog.RequestID,
 		IPAddress:    requestLog.IPAddress,
 		UserAgent:    requestLog.UserAgent,
 		Method:       requestLog.Method,
@@ -149,6 +149,7 @@ func (as *AnomalyService) ProcessAnomalyAlert(requestLog models.RequestLog, anal
 	alert := models.AnomalyAlert{
 		ID:             uuid.New(),
 		RequestLogID:   &requestLog.ID,
+		RequestID:      requestLog.RequestID,
 		UserID:         requestLog.UserID,
 		IPAddress:      requestLog.IPAddress,
 		AnomalyScore:   analysis.Data.AnomalyScore,
@@ -166,12 +167,17 @@ func (as *AnomalyService) ProcessAnomalyAlert(requestLog models.RequestLog, anal
 	}
 
 	// Log the alert
-	log.Printf("Anomaly alert created: ID=%s, IP=%s, Score=%.3f, Risk=%s",
-		alert.ID, alert.IPAddress, alert.AnomalyScore, alert.RiskLevel)
+	log.Printf("Anomaly alert created: ID=%s, RequestID=%s, IP=%s, Score=%.3f, Risk=%s",
+		alert.ID, alert.RequestID, alert.IPAddress, alert.AnomalyScore, alert.RiskLevel)
 
 	// Update security metrics
 	as.updateAnomalyMetrics(analysis.Data.RiskLevel)
 
+	// Notify external operators about serious anomalies
+	if alert.RiskLevel == "high" || alert.RiskLevel == "critical" {
+		PublishWebhookEvent("anomaly."+alert.RiskLevel, alert)
+	}
+
 	return nil
 }
 
@@ -180,12 +186,12 @@ func (as *AnomalyService) AnalyzeRequestAsync(requestLog models.RequestLog) {
 	go func() {
 		analysis, err := as.AnalyzeRequest(requestLog)
 		if err != nil {
-			log.Printf("Failed to analyze request %s: %v", requestLog.ID, err)
+			log.Printf("Failed to analyze request %s (request ID %s): %v", requestLog.ID, requestLog.RequestID, err)
 			return
 		}
 
 		if err := as.ProcessAnomalyAlert(requestLog, analysis); err != nil {
-			log.Printf("Failed to process anomaly alert for request %s: %v", requestLog.ID, err)
+			log.Printf("Failed to process anomaly alert for request %s (request ID %s): %v", requestLog.ID, requestLog.RequestID, err)
 		}
 	}()
 }
@@ -330,39 +336,25 @@ func (as *AnomalyService) GetAttackPatterns() (map[string]interface{}, error) {
 	return patternsData, nil
 }
 
-// updateAnomalyMetrics updates security metrics with anomaly information
+// updateAnomalyMetrics counts an anomaly in today's security metrics with a single
+// upsert, so concurrent alerts do not lose increments
 func (as *AnomalyService) updateAnomalyMetrics(riskLevel string) {
-	today := time.Now().Truncate(24 * time.Hour)
-
-	// Get or create today's metrics
-	var metrics models.SecurityMetrics
-	result := database.DB.Where("date = ?", today).First(&metrics)
-
-	if result.Error != nil {
-		// Create new metrics record
-		metrics = models.SecurityMetrics{
-			ID:                uuid.New(),
-			Date:              today,
-			AnomalousRequests: 1,
-			CreatedAt:         time.Now(),
-			UpdatedAt:         time.Now(),
-		}
-
-		if riskLevel == "high" || riskLevel == "critical" {
-			metrics.HighRiskRequests = 1
-		}
-
-		database.DB.Create(&metrics)
-	} else {
-		// Update existing metrics
-		metrics.AnomalousRequests++
-
-		if riskLevel == "high" || riskLevel == "critical" {
-			metrics.HighRiskRequests++
-		}
-
-		metrics.UpdatedAt = time.Now()
-		database.DB.Save(&metrics)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	highRisk := 0
+	if riskLevel == "high" || riskLevel == "critical" {
+		highRisk = 1
+	}
+
+	if err := database.DB.Exec(`
+		INSERT INTO security_metrics (date, anomalous_requests, high_risk_requests, created_at, updated_at)
+		VALUES (?, 1, ?, NOW(), NOW())
+		ON CONFLICT (date) DO UPDATE SET
+			anomalous_requests = security_metrics.anomalous_requests + 1,
+			high_risk_requests = security_metrics.high_risk_requests + EXCLUDED.high_risk_requests,
+			updated_at = NOW()
+	`, today, highRisk).Error; err != nil {
+		log.Printf("Failed to update anomaly metrics: %v", err)
 	}
 }
 
@@ -0,0 +1,88 @@
+package services
+
+// AttackPattern describes an attack type the anomaly detector can recognise
+type AttackPattern struct {
+	Type        string   `json:"type"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Severity    string   `json:"severity"`
+	Indicators  []string `json:"indicators"`
+}
+
+// localAttackPatterns mirrors the attack types accepted by the attack simulator.
+// It is served when the ML service cannot be reached.
+var localAttackPatterns = []AttackPattern{
+	{
+		Type:        "sql_injection",
+		Name:        "SQL Injection",
+		Description: "Attempts to alter database queries through crafted input in paths, query strings or bodies",
+		Severity:    "critical",
+		Indicators: []string{
+			"union", "select", "drop", "insert", "update", "delete",
+			"or 1=1", "or 1=2", "--", ";--", "/*", "*/", "xp_",
+			"sp_", "exec", "execute", "cast", "convert",
+		},
+	},
+	{
+		Type:        "xss",
+		Name:        "Cross-Site Scripting (XSS)",
+		Description: "Attempts to inject scripts that run in other users' browsers",
+		Severity:    "high",
+		Indicators: []string{
+			"<script", "</script>", "javascript:", "onload=",
+			"onerror=", "onclick=", "alert(", "document.cookie",
+		},
+	},
+	{
+		Type:        "brute_force",
+		Name:        "Brute Force Attacks",
+		Description: "Repeated failed authentication attempts from the same source in a short time",
+		Severity:    "high",
+		Indicators: []string{
+			"repeated 401 responses on /api/v1/auth/login",
+			"high request rate from a single IP to authentication endpoints",
+		},
+	},
+	{
+		Type:        "ddos",
+		Name:        "DDoS/High Volume Attacks",
+		Description: "Unusually high request volume intended to exhaust server resources",
+		Severity:    "critical",
+		Indicators: []string{
+			"request rate far above the baseline",
+			"many distinct IPs hitting the same endpoint",
+			"rising response times and error rates",
+		},
+	},
+}
+
+// AttackPatternTypes returns the attack types present in the local catalog
+func AttackPatternTypes() []string {
+	types := make([]string, len(localAttackPatterns))
+	for i, pattern := range localAttackPatterns {
+		types[i] = pattern.Type
+	}
+	return types
+}
+
+// LocalAttackPatterns returns the built-in attack pattern catalog in the same
+// shape as the ML service's patterns response
+func LocalAttackPatterns() map[string]interface{} {
+	attackTypes := make([]string, len(localAttackPatterns))
+	for i, pattern := range localAttackPatterns {
+		attackTypes[i] = pattern.Name
+	}
+
+	patterns := make([]AttackPattern, len(localAttackPatterns))
+	copy(patterns, localAttackPatterns)
+
+	return map[string]interface{}{
+		"detection_methods": []string{
+			"Pattern-based detection (SQL injection, XSS)",
+			"Rate-based analysis (brute force, high volume traffic)",
+		},
+		"attack_types_detected": attackTypes,
+		"attack_patterns":       patterns,
+		"risk_levels":           []string{"low", "medium", "high", "critical"},
+	}
+}
@@ -120,14 +120,14 @@ func (ba *BackgroundAnalyzer) analyzeRecentRequests() {
 	for _, requestLog := range requestLogs {
 		analysis, err := anomalyService.AnalyzeRequest(requestLog)
 		if err != nil {
-			log.Printf("Failed to analyze request %s: %v", requestLog.ID, err)
+			log.Printf("Failed to analyze request %s (request ID %s): %v", requestLog.ID, requestLog.RequestID, err)
 			errors++
 			continue
 		}
 
 		// Process the analysis result
 		if err := anomalyService.ProcessAnomalyAlert(requestLog, analysis); err != nil {
-			log.Printf("Failed to process anomaly alert for request %s: %v", requestLog.ID, err)
+			log.Printf("Failed to process anomaly alert for request %s (request ID %s): %v", requestLog.ID, requestLog.RequestID, err)
 			errors++
 			continue
 		}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"bachelor_backend/database"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// unavailableProductCondition matches rows whose product no longer exists or was soft-deleted
+const unavailableProductCondition = "NOT EXISTS (SELECT 1 FROM products p WHERE p.id = product_id AND p.deleted_at IS NULL)"
+
+// CatalogCleaner periodically removes cart items, saved items and favorites that point at deleted products
+type CatalogCleaner struct {
+	ticker    *time.Ticker
+	stopChan  chan bool
+	isRunning bool
+	lastRun   time.Time
+}
+
+// CleanupResult summarizes a single cleanup run
+type CleanupResult struct {
+	CartItemsRemoved  int64 `json:"cart_items_removed"`
+	SavedItemsRemoved int64 `json:"saved_items_removed"`
+	FavoritesRemoved  int64 `json:"favorites_removed"`
+}
+
+// NewCatalogCleaner creates a new catalog cleaner
+func NewCatalogCleaner() *CatalogCleaner {
+	return &CatalogCleaner{
+		stopChan:  make(chan bool),
+		isRunning: false,
+	}
+}
+
+// Start begins the periodic cleanup process
+func (cc *CatalogCleaner) Start(intervalMinutes int) {
+	if cc.isRunning {
+		log.Println("Catalog cleaner is already running")
+		return
+	}
+
+	cc.ticker = time.NewTicker(time.Duration(intervalMinutes) * time.Minute)
+	cc.isRunning = true
+
+	log.Printf("Starting catalog cleaner with %d minute intervals", intervalMinutes)
+
+	go func() {
+		cc.runCleanup()
+
+		for {
+			select {
+			case <-cc.ticker.C:
+				cc.runCleanup()
+			case <-cc.stopChan:
+				cc.ticker.Stop()
+				cc.isRunning = false
+				log.Println("Catalog cleaner stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic cleanup process
+func (cc *CatalogCleaner) Stop() {
+	if !cc.isRunning {
+		return
+	}
+
+	cc.stopChan <- true
+}
+
+// runCleanup performs a cleanup pass and logs the outcome
+func (cc *CatalogCleaner) runCleanup() {
+	result, err := CleanupUnavailableProductReferences(database.DB)
+	cc.lastRun = time.Now()
+	if err != nil {
+		log.Printf("Catalog cleanup failed: %v", err)
+		return
+	}
+
+	if result.CartItemsRemoved > 0 || result.SavedItemsRemoved > 0 || result.FavoritesRemoved > 0 {
+		log.Printf("Catalog cleanup completed: %d cart items, %d saved items and %d favorites removed",
+			result.CartItemsRemoved, result.SavedItemsRemoved, result.FavoritesRemoved)
+	}
+}
+
+// GetStatus returns the current status of the catalog cleaner
+func (cc *CatalogCleaner) GetStatus() map[string]interface{} {
+	return map[string]interface{}{
+		"is_running":   cc.isRunning,
+		"last_run":     cc.lastRun.Format(time.RFC3339),
+		"service_name": "catalog_cleaner",
+	}
+}
+
+// CleanupUnavailableProductReferences deletes cart items, saved items and favorites whose product is gone
+func CleanupUnavailableProductReferences(db *gorm.DB) (CleanupResult, error) {
+	var result CleanupResult
+
+	cartItems := db.Exec("DELETE FROM cart_items WHERE " + unavailableProductCondition)
+	if cartItems.Error != nil {
+		return result, cartItems.Error
+	}
+	result.CartItemsRemoved = cartItems.RowsAffected
+
+	savedItems := db.Exec("DELETE FROM saved_items WHERE " + unavailableProductCondition)
+	if savedItems.Error != nil {
+		return result, savedItems.Error
+	}
+	result.SavedItemsRemoved = savedItems.RowsAffected
+
+	favorites := db.Exec("DELETE FROM favorites WHERE " + unavailableProductCondition)
+	if favorites.Error != nil {
+		return result, favorites.Error
+	}
+	result.FavoritesRemoved = favorites.RowsAffected
+
+	return result, nil
+}
+
+// RemoveProductReferences deletes cart items, saved items and favorites for a single product,
+// typically inside the transaction that removes the product itself
+func RemoveProductReferences(db *gorm.DB, productID uuid.UUID) error {
+	if err := db.Exec("DELETE FROM cart_items WHERE product_id = ?", productID).Error; err != nil {
+		return err
+	}
+	if err := db.Exec("DELETE FROM saved_items WHERE product_id = ?", productID).Error; err != nil {
+		return err
+	}
+	return db.Exec("DELETE FROM favorites WHERE product_id = ?", productID).Error
+}
+
+// Global catalog cleaner instance
+var CatalogCleanerInstance = NewCatalogCleaner()
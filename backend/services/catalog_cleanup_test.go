@@ -0,0 +1,110 @@
+package services
+
+import (
+	"testing"
+
+	"bachelor_backend/database/dbtest"
+	"bachelor_backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// addProductReferences puts the product in the user's cart, saved items and favorites
+func addProductReferences(t *testing.T, db *gorm.DB, cart models.ShoppingCart, product models.Product) {
+	t.Helper()
+
+	references := []interface{}{
+		&models.CartItem{CartID: cart.ID, ProductID: product.ID, Quantity: 1},
+		&models.SavedItem{UserID: *cart.UserID, ProductID: product.ID, Quantity: 1},
+		&models.Favorite{UserID: *cart.UserID, ProductID: product.ID},
+	}
+	for _, reference := range references {
+		if err := db.Create(reference).Error; err != nil {
+			t.Fatalf("failed to create %T: %v", reference, err)
+		}
+	}
+}
+
+// countProductReferences returns how many cart items, saved items and favorites point at the product
+func countProductReferences(t *testing.T, db *gorm.DB, productID uuid.UUID) int64 {
+	t.Helper()
+
+	var total int64
+	for _, model := range []interface{}{&models.CartItem{}, &models.SavedItem{}, &models.Favorite{}} {
+		var count int64
+		if err := db.Model(model).Where("product_id = ?", productID).Count(&count).Error; err != nil {
+			t.Fatalf("failed to count %T: %v", model, err)
+		}
+		total += count
+	}
+	return total
+}
+
+func createCart(t *testing.T, db *gorm.DB, user models.User) models.ShoppingCart {
+	t.Helper()
+	cart := models.ShoppingCart{UserID: &user.ID}
+	if err := db.Create(&cart).Error; err != nil {
+		t.Fatalf("failed to create cart: %v", err)
+	}
+	return cart
+}
+
+func TestRemoveProductReferences(t *testing.T) {
+	db := dbtest.Open(t)
+	removed := dbtest.CreateProduct(t, db, 1000, 5)
+	kept := dbtest.CreateProduct(t, db, 2000, 5)
+	user := dbtest.CreateUser(t, db)
+	cart := createCart(t, db, user)
+	addProductReferences(t, db, cart, removed)
+	addProductReferences(t, db, cart, kept)
+
+	// Soft-delete the product together with its references, as DeleteProduct does
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.Product{}, "id = ?", removed.ID).Error; err != nil {
+			return err
+		}
+		return RemoveProductReferences(tx, removed.ID)
+	})
+	if err != nil {
+		t.Fatalf("failed to remove product: %v", err)
+	}
+
+	if got := countProductReferences(t, db, removed.ID); got != 0 {
+		t.Errorf("%d references to the removed product left, want 0", got)
+	}
+	if got := countProductReferences(t, db, kept.ID); got != 3 {
+		t.Errorf("%d references to the other product, want 3", got)
+	}
+}
+
+func TestCleanupUnavailableProductReferences(t *testing.T) {
+	db := dbtest.Open(t)
+	softDeleted := dbtest.CreateProduct(t, db, 1000, 5)
+	kept := dbtest.CreateProduct(t, db, 2000, 5)
+	user := dbtest.CreateUser(t, db)
+	cart := createCart(t, db, user)
+	addProductReferences(t, db, cart, softDeleted)
+	addProductReferences(t, db, cart, kept)
+
+	// Soft-deleted without removing its references, e.g. by an older version of the API
+	if err := db.Delete(&models.Product{}, "id = ?", softDeleted.ID).Error; err != nil {
+		t.Fatalf("failed to soft-delete product: %v", err)
+	}
+
+	result, err := CleanupUnavailableProductReferences(db)
+	if err != nil {
+		t.Fatalf("CleanupUnavailableProductReferences: %v", err)
+	}
+	// Other soft-deleted products in the database may be cleaned up too
+	if result.CartItemsRemoved < 1 || result.SavedItemsRemoved < 1 || result.FavoritesRemoved < 1 {
+		t.Errorf("result = %+v, want at least one of each removed", result)
+	}
+
+	if got := countProductReferences(t, db, softDeleted.ID); got != 0 {
+		t.Errorf("%d references to the soft-deleted product left, want 0", got)
+	}
+	if got := countProductReferences(t, db, kept.ID); got != 3 {
+		t.Errorf("%d references to the other product, want 3", got)
+	}
+}
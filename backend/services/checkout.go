@@ -0,0 +1,58 @@
+package services
+
+import (
+	"log"
+	"os"
+	"strconv"
+
+	"bachelor_backend/pkg/money"
+)
+
+// CheckoutRates holds the rates used to estimate tax and shipping for a cart
+type CheckoutRates struct {
+	TaxRate               float64     `json:"tax_rate"`                // Fraction of each discounted line, e.g. 0.08
+	ShippingBaseCost      money.Cents `json:"shipping_base_cost"`      // Charged once per order
+	ShippingPerItemCost   money.Cents `json:"shipping_per_item_cost"`  // Charged for every unit after the first
+	FreeShippingThreshold money.Cents `json:"free_shipping_threshold"` // Orders worth at least this ship free; 0 disables
+}
+
+// LoadCheckoutRates reads the checkout rates from the environment
+func LoadCheckoutRates() CheckoutRates {
+	return CheckoutRates{
+		TaxRate:               getEnvFloat("CHECKOUT_TAX_RATE", 0),
+		ShippingBaseCost:      money.FromFloat(getEnvFloat("SHIPPING_BASE_COST", 5)),
+		ShippingPerItemCost:   money.FromFloat(getEnvFloat("SHIPPING_PER_ITEM_COST", 0.5)),
+		FreeShippingThreshold: money.FromFloat(getEnvFloat("FREE_SHIPPING_THRESHOLD", 100)),
+	}
+}
+
+// LineTax returns the estimated tax on the discounted amount of a single line
+func (r CheckoutRates) LineTax(amount money.Cents) money.Cents {
+	if amount <= 0 {
+		return 0
+	}
+	return amount.MulRate(r.TaxRate)
+}
+
+// EstimateShipping returns the shipping cost for a number of units worth merchandiseTotal
+// after discounts. Products carry no weight, so the estimate is based on the unit count.
+func (r CheckoutRates) EstimateShipping(unitCount int, merchandiseTotal money.Cents) money.Cents {
+	if unitCount == 0 {
+		return 0
+	}
+	if r.FreeShippingThreshold > 0 && merchandiseTotal >= r.FreeShippingThreshold {
+		return 0
+	}
+	return r.ShippingBaseCost + r.ShippingPerItemCost.Mul(unitCount-1)
+}
+
+// getEnvFloat reads a non-negative number from the environment with a fallback
+func getEnvFloat(key string, fallback float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil && floatValue >= 0 {
+			return floatValue
+		}
+		log.Printf("Warning: Invalid number for %s: %s, using fallback: %g", key, value, fallback)
+	}
+	return fallback
+}
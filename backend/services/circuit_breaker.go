@@ -0,0 +1,198 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bachelor_backend/pkg/metrics"
+
+	"github.com/google/uuid"
+)
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// ErrCircuitOpen is returned instead of calling a service whose breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreaker stops calls to a failing dependency. After FailureThreshold
+// consecutive failures it opens and rejects calls immediately; once the cooldown has
+// passed it lets a single trial call through (half-open), closing again if the trial
+// succeeds and reopening if it fails.
+type CircuitBreaker struct {
+	mu               sync.Mutex
+	name             string
+	failureThreshold int
+	cooldown         time.Duration
+	state            string
+	failures         int
+	openedAt         time.Time
+	trialInFlight    bool
+	trips            int64
+	rejected         int64
+	lastError        string
+}
+
+// NewCircuitBreaker creates a closed circuit breaker
+func NewCircuitBreaker(name string, failureThreshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		name:             name,
+		failureThreshold: failureThreshold,
+		cooldown:         cooldown,
+		state:            CircuitClosed,
+	}
+}
+
+// Allow reports whether a call may proceed, returning ErrCircuitOpen if not
+func (cb *CircuitBreaker) Allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case CircuitOpen:
+		if time.Since(cb.openedAt) < cb.cooldown {
+			cb.rejected++
+			return fmt.Errorf("%s: %w", cb.name, ErrCircuitOpen)
+		}
+		cb.state = CircuitHalfOpen
+		cb.trialInFlight = true
+		return nil
+	case CircuitHalfOpen:
+		if cb.trialInFlight {
+			cb.rejected++
+			return fmt.Errorf("%s: %w", cb.name, ErrCircuitOpen)
+		}
+		cb.trialInFlight = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// RecordSuccess closes the breaker and resets the failure count
+func (cb *CircuitBreaker) RecordSuccess() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.state = CircuitClosed
+	cb.failures = 0
+	cb.trialInFlight = false
+}
+
+// RecordFailure counts a failed call, opening the breaker at the threshold or when a
+// half-open trial fails
+func (cb *CircuitBreaker) RecordFailure(err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures++
+	cb.trialInFlight = false
+	if err != nil {
+		cb.lastError = err.Error()
+	}
+
+	if cb.state == CircuitHalfOpen || (cb.state == CircuitClosed && cb.failures >= cb.failureThreshold) {
+		cb.state = CircuitOpen
+		cb.openedAt = time.Now()
+		cb.trips++
+	}
+}
+
+// State returns the current breaker state
+func (cb *CircuitBreaker) State() string {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// Status returns the breaker state and counters
+func (cb *CircuitBreaker) Status() map[string]interface{} {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	status := map[string]interface{}{
+		"name":                 cb.name,
+		"state":                cb.state,
+		"consecutive_failures": cb.failures,
+		"failure_threshold":    cb.failureThreshold,
+		"cooldown_seconds":     cb.cooldown.Seconds(),
+		"trips":                cb.trips,
+		"rejected_calls":       cb.rejected,
+		"last_error":           cb.lastError,
+	}
+	if cb.state == CircuitOpen {
+		status["retry_at"] = cb.openedAt.Add(cb.cooldown).Format(time.RFC3339)
+	}
+	return status
+}
+
+// breakerTransport guards an HTTP transport with a circuit breaker. Transport errors
+// and 5xx responses count as failures.
+type breakerTransport struct {
+	breaker *CircuitBreaker
+	next    http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (bt *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := bt.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := bt.next.RoundTrip(req)
+	outcome := "success"
+	switch {
+	case err != nil:
+		bt.breaker.RecordFailure(err)
+		outcome = "error"
+	case resp.StatusCode >= http.StatusInternalServerError:
+		bt.breaker.RecordFailure(fmt.Errorf("status %d", resp.StatusCode))
+		outcome = "error"
+	default:
+		bt.breaker.RecordSuccess()
+	}
+	metrics.MLRequestDuration.Observe(time.Since(start).Seconds(), metricsEndpoint(req.URL.Path), outcome)
+	return resp, err
+}
+
+// metricsEndpoint replaces IDs in a request path with :id so it can be used as a
+// metric label
+func metricsEndpoint(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if _, err := uuid.Parse(segment); err == nil {
+			segments[i] = ":id"
+		} else if _, err := strconv.Atoi(segment); err == nil {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// newMLHTTPClient creates an HTTP client for the ML service whose calls go through the
+// shared ML circuit breaker
+func newMLHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &breakerTransport{breaker: MLCircuitBreaker, next: http.DefaultTransport},
+	}
+}
+
+// MLCircuitBreaker is shared by every client of the ML service, so an outage seen by
+// one fails the others fast too. Configured with ML_BREAKER_FAILURE_THRESHOLD
+// (default 5) and ML_BREAKER_COOLDOWN_SECONDS (default 30).
+var MLCircuitBreaker = NewCircuitBreaker(
+	"ml_service",
+	getEnvInt("ML_BREAKER_FAILURE_THRESHOLD", 5),
+	time.Duration(getEnvInt("ML_BREAKER_COOLDOWN_SECONDS", 30))*time.Second,
+)
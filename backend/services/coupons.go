@@ -0,0 +1,95 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"bachelor_backend/models"
+	"bachelor_backend/pkg/money"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CouponQuote describes the effect of a coupon on a set of cart items
+type CouponQuote struct {
+	DiscountID       uuid.UUID   `json:"discount_id"`
+	Code             string      `json:"code"`
+	Scope            string      `json:"scope"`
+	EligibleSubtotal money.Cents `json:"eligible_subtotal"`
+	Amount           money.Cents `json:"amount"`
+	Reason           string      `json:"reason"`
+}
+
+// NormalizeCouponCode trims and upper-cases a coupon code so lookups are case-insensitive
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// FindCouponByCode looks up a discount by its coupon code
+func FindCouponByCode(db *gorm.DB, code string) (models.Discount, error) {
+	var discount models.Discount
+	err := db.Where("code = ?", NormalizeCouponCode(code)).First(&discount).Error
+	return discount, err
+}
+
+// CouponScope reports whether a coupon targets a product, a category or the whole cart
+func CouponScope(discount models.Discount) string {
+	if discount.ProductID == nil && discount.Category == nil {
+		return "cart"
+	}
+	return DiscountScope(discount)
+}
+
+// couponEligibleSubtotal sums the cart items a coupon applies to
+func couponEligibleSubtotal(discount models.Discount, items []models.CartItem) money.Cents {
+	var subtotal money.Cents
+	for _, item := range items {
+		if item.Product.ID == uuid.Nil {
+			continue // Product removed from the catalog
+		}
+		if discount.ProductID != nil && item.ProductID != *discount.ProductID {
+			continue
+		}
+		if discount.Category != nil && !strings.EqualFold(item.Product.Category, *discount.Category) {
+			continue
+		}
+		subtotal += item.Product.Price.Mul(item.Quantity)
+	}
+	return subtotal
+}
+
+// QuoteCoupon validates a coupon against cart items and returns the discount it gives,
+// or an explanation of why it cannot be applied. Items must have their Product loaded.
+func QuoteCoupon(discount models.Discount, items []models.CartItem, at time.Time) (*CouponQuote, string) {
+	if at.Before(discount.StartDate) {
+		return nil, "coupon is not valid yet"
+	}
+	if at.After(discount.EndDate) {
+		return nil, "coupon has expired"
+	}
+
+	subtotal := couponEligibleSubtotal(discount, items)
+	if subtotal == 0 {
+		return nil, "no items in the cart are eligible for this coupon"
+	}
+
+	amount, skipReason := CalculateDiscountAmount(discount, subtotal)
+	if skipReason != "" {
+		return nil, skipReason
+	}
+
+	code := ""
+	if discount.Code != nil {
+		code = *discount.Code
+	}
+
+	return &CouponQuote{
+		DiscountID:       discount.ID,
+		Code:             code,
+		Scope:            CouponScope(discount),
+		EligibleSubtotal: subtotal,
+		Amount:           amount,
+		Reason:           describeDiscount(discount, amount),
+	}, ""
+}
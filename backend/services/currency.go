@@ -0,0 +1,217 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bachelor_backend/pkg/money"
+)
+
+// ErrUnsupportedCurrency is returned for a currency with no known exchange rate
+var ErrUnsupportedCurrency = errors.New("unsupported currency")
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// BaseCurrency is the currency prices are stored in (BASE_CURRENCY, default USD).
+// Stored prices are authoritative; other currencies are only for display.
+func BaseCurrency() string {
+	if value := strings.ToUpper(strings.TrimSpace(os.Getenv("BASE_CURRENCY"))); currencyCodePattern.MatchString(value) {
+		return value
+	}
+	return "USD"
+}
+
+// NormalizeCurrency upper-cases a currency code and checks it is three letters
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !currencyCodePattern.MatchString(code) {
+		return "", fmt.Errorf("%w: %q is not a three-letter currency code", ErrUnsupportedCurrency, code)
+	}
+	return code, nil
+}
+
+// ExchangeRates is a table of how many units of each currency one unit of the base
+// currency buys
+type ExchangeRates struct {
+	Base      string             `json:"base"`
+	Rates     map[string]float64 `json:"rates"`
+	FetchedAt time.Time          `json:"fetched_at"`
+	Source    string             `json:"source"` // "url" or "static"
+}
+
+// rate returns the rate converting an amount in one currency to another
+func (er ExchangeRates) rate(from, to string) (float64, error) {
+	fromRate, ok := er.Rates[from]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, from)
+	}
+	toRate, ok := er.Rates[to]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, to)
+	}
+	return toRate / fromRate, nil
+}
+
+// CurrencyConverter converts prices using a cached exchange rate table. Rates are
+// fetched from EXCHANGE_RATES_URL, a JSON document of the form
+// {"base": "USD", "rates": {"EUR": 0.92}}, or otherwise read from EXCHANGE_RATES
+// ("EUR=0.92,GBP=0.79"). The table is refreshed after EXCHANGE_RATES_TTL_MINUTES
+// (default 60); if a refresh fails the previous table keeps being used.
+type CurrencyConverter struct {
+	mu     sync.Mutex
+	client *http.Client
+	url    string
+	static string
+	ttl    time.Duration
+	table  *ExchangeRates
+}
+
+// NewCurrencyConverter creates a converter reading rates from url, or from the static
+// table when url is empty
+func NewCurrencyConverter(url, static string, ttl time.Duration) *CurrencyConverter {
+	return &CurrencyConverter{
+		client: &http.Client{Timeout: 5 * time.Second},
+		url:    url,
+		static: static,
+		ttl:    ttl,
+	}
+}
+
+// Rates returns the current exchange rate table, refreshing it when it has expired
+func (cc *CurrencyConverter) Rates() (ExchangeRates, error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if cc.table != nil && time.Since(cc.table.FetchedAt) < cc.ttl {
+		return *cc.table, nil
+	}
+
+	table, err := cc.load()
+	if err != nil {
+		if cc.table != nil {
+			log.Printf("Warning: failed to refresh exchange rates, using rates from %s: %v", cc.table.FetchedAt.Format(time.RFC3339), err)
+			return *cc.table, nil
+		}
+		return ExchangeRates{}, err
+	}
+
+	cc.table = table
+	return *table, nil
+}
+
+// Rate returns the rate converting an amount in one currency to another, with the
+// table it came from
+func (cc *CurrencyConverter) Rate(from, to string) (float64, ExchangeRates, error) {
+	if from == to {
+		return 1, ExchangeRates{Base: BaseCurrency(), FetchedAt: time.Now()}, nil
+	}
+
+	table, err := cc.Rates()
+	if err != nil {
+		return 0, ExchangeRates{}, err
+	}
+	rate, err := table.rate(from, to)
+	return rate, table, err
+}
+
+// load reads a fresh rate table from the configured source, rebased on the base currency
+func (cc *CurrencyConverter) load() (*ExchangeRates, error) {
+	var table *ExchangeRates
+	var err error
+	if cc.url != "" {
+		table, err = cc.fetch()
+	} else {
+		table, err = parseStaticRates(cc.static)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	base := BaseCurrency()
+	table.Rates[table.Base] = 1
+	baseRate, ok := table.Rates[base]
+	if !ok {
+		return nil, fmt.Errorf("exchange rates have no rate for base currency %s", base)
+	}
+	for code, rate := range table.Rates {
+		table.Rates[code] = rate / baseRate
+	}
+	table.Base = base
+	table.FetchedAt = time.Now()
+	return table, nil
+}
+
+// fetch downloads the rate table from the configured URL
+func (cc *CurrencyConverter) fetch() (*ExchangeRates, error) {
+	resp, err := cc.client.Get(cc.url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch exchange rates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("exchange rate source returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Base  string             `json:"base"`
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode exchange rates: %w", err)
+	}
+
+	table := &ExchangeRates{Base: strings.ToUpper(body.Base), Rates: make(map[string]float64), Source: "url"}
+	if table.Base == "" {
+		table.Base = BaseCurrency()
+	}
+	for code, rate := range body.Rates {
+		if rate > 0 {
+			table.Rates[strings.ToUpper(code)] = rate
+		}
+	}
+	return table, nil
+}
+
+// parseStaticRates parses a "EUR=0.92,GBP=0.79" table of rates against the base currency
+func parseStaticRates(value string) (*ExchangeRates, error) {
+	table := &ExchangeRates{Base: BaseCurrency(), Rates: make(map[string]float64), Source: "static"}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		code, rateText, found := strings.Cut(entry, "=")
+		code, err := NormalizeCurrency(code)
+		if !found || err != nil {
+			return nil, fmt.Errorf("invalid exchange rate entry %q", entry)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(rateText), 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("invalid exchange rate entry %q", entry)
+		}
+		table.Rates[code] = rate
+	}
+	return table, nil
+}
+
+// ConvertPrice converts an amount at the given rate, rounded to cents
+func ConvertPrice(amount money.Cents, rate float64) money.Cents {
+	return amount.MulRate(rate)
+}
+
+// Global currency converter instance
+var CurrencyConverterInstance = NewCurrencyConverter(
+	os.Getenv("EXCHANGE_RATES_URL"),
+	os.Getenv("EXCHANGE_RATES"),
+	time.Duration(getEnvInt("EXCHANGE_RATES_TTL_MINUTES", 60))*time.Minute,
+)
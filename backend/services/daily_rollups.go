@@ -0,0 +1,170 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"bachelor_backend/database"
+
+	"gorm.io/gorm"
+)
+
+// rollupDailyUserStatsSQL aggregates one UTC day of orders, interactions and searches per user
+const rollupDailyUserStatsSQL = `
+INSERT INTO daily_user_stats (user_id, date, orders, spend, interactions, searches, created_at, updated_at)
+SELECT u.user_id, CAST(@day AS date), COALESCE(o.orders, 0), COALESCE(o.spend, 0), COALESCE(i.interactions, 0), COALESCE(s.searches, 0), NOW(), NOW()
+FROM (
+	SELECT user_id FROM orders WHERE created_at >= @start AND created_at < @end
+	UNION
+	SELECT user_id FROM user_interactions WHERE created_at >= @start AND created_at < @end
+	UNION
+	SELECT user_id FROM search_queries WHERE user_id IS NOT NULL AND created_at >= @start AND created_at < @end
+) u
+LEFT JOIN (
+	SELECT user_id, COUNT(*) AS orders,
+		COALESCE(SUM(total) FILTER (WHERE status IN ('delivered', 'completed')), 0) AS spend
+	FROM orders WHERE created_at >= @start AND created_at < @end GROUP BY user_id
+) o ON o.user_id = u.user_id
+LEFT JOIN (
+	SELECT user_id, COUNT(*) AS interactions
+	FROM user_interactions WHERE created_at >= @start AND created_at < @end GROUP BY user_id
+) i ON i.user_id = u.user_id
+LEFT JOIN (
+	SELECT user_id, COUNT(*) AS searches
+	FROM search_queries WHERE user_id IS NOT NULL AND created_at >= @start AND created_at < @end GROUP BY user_id
+) s ON s.user_id = u.user_id
+WHERE EXISTS (SELECT 1 FROM users WHERE users.id = u.user_id)`
+
+// DailyRollupJob rolls up each finished day into daily_user_stats once a day
+type DailyRollupJob struct {
+	ticker      *time.Ticker
+	stopChan    chan bool
+	isRunning   bool
+	lastRun     time.Time
+	lastRolled  time.Time // Most recent day rolled up
+	rowsWritten int64
+}
+
+// NewDailyRollupJob creates a new daily rollup job
+func NewDailyRollupJob() *DailyRollupJob {
+	return &DailyRollupJob{
+		stopChan:  make(chan bool),
+		isRunning: false,
+	}
+}
+
+// Start begins checking for a finished day to roll up every interval
+func (dr *DailyRollupJob) Start(intervalMinutes int) {
+	if dr.isRunning {
+		log.Println("Daily rollup job is already running")
+		return
+	}
+
+	dr.ticker = time.NewTicker(time.Duration(intervalMinutes) * time.Minute)
+	dr.isRunning = true
+
+	log.Printf("Starting daily rollup job with %d minute intervals", intervalMinutes)
+
+	go func() {
+		dr.runRollup()
+
+		for {
+			select {
+			case <-dr.ticker.C:
+				dr.runRollup()
+			case <-dr.stopChan:
+				dr.ticker.Stop()
+				dr.isRunning = false
+				log.Println("Daily rollup job stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the rollup job
+func (dr *DailyRollupJob) Stop() {
+	if !dr.isRunning {
+		return
+	}
+
+	dr.stopChan <- true
+}
+
+// runRollup rolls up the days before today once per day. Recent days are rolled up
+// again (DAILY_ROLLUP_LOOKBACK_DAYS, default 7) so orders delivered after the day
+// they were placed still count towards that day's spend.
+func (dr *DailyRollupJob) runRollup() {
+	yesterday := StartOfDayUTC(time.Now()).AddDate(0, 0, -1)
+	if !dr.lastRolled.Before(yesterday) {
+		return
+	}
+
+	from := yesterday.AddDate(0, 0, 1-getEnvInt("DAILY_ROLLUP_LOOKBACK_DAYS", 7))
+	rows, err := BackfillDailyUserStats(database.DB, from, yesterday)
+	dr.lastRun = time.Now()
+	if err != nil {
+		log.Printf("Daily rollup failed: %v", err)
+		return
+	}
+
+	dr.lastRolled = yesterday
+	dr.rowsWritten += rows
+	log.Printf("Daily rollup completed: %d user-days from %s to %s",
+		rows, from.Format("2006-01-02"), yesterday.Format("2006-01-02"))
+}
+
+// GetStatus returns the current status of the rollup job
+func (dr *DailyRollupJob) GetStatus() map[string]interface{} {
+	return map[string]interface{}{
+		"is_running":   dr.isRunning,
+		"last_run":     dr.lastRun.Format(time.RFC3339),
+		"last_rolled":  dr.lastRolled.Format("2006-01-02"),
+		"rows_written": dr.rowsWritten,
+		"service_name": "daily_rollup_job",
+	}
+}
+
+// StartOfDayUTC returns midnight UTC of the day containing t
+func StartOfDayUTC(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// RollupDailyUserStats replaces the daily_user_stats rows of one UTC day with a fresh
+// aggregate of that day, returning the number of user rows written
+func RollupDailyUserStats(db *gorm.DB, day time.Time) (int64, error) {
+	start := StartOfDayUTC(day)
+	params := map[string]interface{}{
+		"day":   start.Format("2006-01-02"),
+		"start": start,
+		"end":   start.AddDate(0, 0, 1),
+	}
+
+	var rows int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM daily_user_stats WHERE date = ?", params["day"]).Error; err != nil {
+			return err
+		}
+		result := tx.Exec(rollupDailyUserStatsSQL, params)
+		rows = result.RowsAffected
+		return result.Error
+	})
+	return rows, err
+}
+
+// BackfillDailyUserStats rolls up every UTC day from from to to, inclusive
+func BackfillDailyUserStats(db *gorm.DB, from, to time.Time) (int64, error) {
+	var total int64
+	for day := StartOfDayUTC(from); !day.After(StartOfDayUTC(to)); day = day.AddDate(0, 0, 1) {
+		rows, err := RollupDailyUserStats(db, day)
+		if err != nil {
+			return total, err
+		}
+		total += rows
+	}
+	return total, nil
+}
+
+// Global daily rollup job instance
+var DailyRollupJobInstance = NewDailyRollupJob()
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/models"
+
+	"gorm.io/gorm"
+)
+
+// DiscountScheduler periodically switches discounts on and off as their date windows open and close
+type DiscountScheduler struct {
+	ticker    *time.Ticker
+	stopChan  chan bool
+	isRunning bool
+	lastRun   time.Time
+}
+
+// DiscountActivationResult summarizes a single activation pass
+type DiscountActivationResult struct {
+	Activated   int64 `json:"activated"`
+	Deactivated int64 `json:"deactivated"`
+}
+
+// NewDiscountScheduler creates a new discount scheduler
+func NewDiscountScheduler() *DiscountScheduler {
+	return &DiscountScheduler{
+		stopChan:  make(chan bool),
+		isRunning: false,
+	}
+}
+
+// Start begins the periodic activation process
+func (ds *DiscountScheduler) Start(intervalMinutes int) {
+	if ds.isRunning {
+		log.Println("Discount scheduler is already running")
+		return
+	}
+
+	ds.ticker = time.NewTicker(time.Duration(intervalMinutes) * time.Minute)
+	ds.isRunning = true
+
+	log.Printf("Starting discount scheduler with %d minute intervals", intervalMinutes)
+
+	go func() {
+		ds.runActivation()
+
+		for {
+			select {
+			case <-ds.ticker.C:
+				ds.runActivation()
+			case <-ds.stopChan:
+				ds.ticker.Stop()
+				ds.isRunning = false
+				log.Println("Discount scheduler stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic activation process
+func (ds *DiscountScheduler) Stop() {
+	if !ds.isRunning {
+		return
+	}
+
+	ds.stopChan <- true
+}
+
+// runActivation performs an activation pass and logs the outcome
+func (ds *DiscountScheduler) runActivation() {
+	result, err := SyncDiscountActivation(database.DB, time.Now())
+	ds.lastRun = time.Now()
+	if err != nil {
+		log.Printf("Discount activation failed: %v", err)
+		return
+	}
+
+	if result.Activated > 0 || result.Deactivated > 0 {
+		log.Printf("Discount activation completed: %d activated, %d deactivated", result.Activated, result.Deactivated)
+	}
+}
+
+// GetStatus returns the current status of the discount scheduler
+func (ds *DiscountScheduler) GetStatus() map[string]interface{} {
+	return map[string]interface{}{
+		"is_running":   ds.isRunning,
+		"last_run":     ds.lastRun.Format(time.RFC3339),
+		"service_name": "discount_scheduler",
+	}
+}
+
+// DiscountInWindow reports whether a discount's date window contains the given time
+func DiscountInWindow(discount models.Discount, at time.Time) bool {
+	return !at.Before(discount.StartDate) && !at.After(discount.EndDate)
+}
+
+// SyncDiscountActivation sets is_active on every discount whose flag disagrees with its date window
+func SyncDiscountActivation(db *gorm.DB, at time.Time) (DiscountActivationResult, error) {
+	var result DiscountActivationResult
+
+	activated := db.Model(&models.Discount{}).
+		Where("is_active = ? AND start_date <= ? AND end_date >= ?", false, at, at).
+		Updates(map[string]interface{}{"is_active": true, "updated_at": at})
+	if activated.Error != nil {
+		return result, activated.Error
+	}
+	result.Activated = activated.RowsAffected
+
+	deactivated := db.Model(&models.Discount{}).
+		Where("is_active = ? AND (start_date > ? OR end_date < ?)", true, at, at).
+		Updates(map[string]interface{}{"is_active": false, "updated_at": at})
+	if deactivated.Error != nil {
+		return result, deactivated.Error
+	}
+	result.Deactivated = deactivated.RowsAffected
+
+	return result, nil
+}
+
+// Global discount scheduler instance
+var DiscountSchedulerInstance = NewDiscountScheduler()
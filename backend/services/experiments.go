@@ -0,0 +1,199 @@
+package services
+
+import (
+	"errors"
+	"hash/fnv"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultRecommendationAlgorithm is used for users outside any running experiment
+const DefaultRecommendationAlgorithm = "hybrid"
+
+// Experiment statuses
+const (
+	ExperimentActive    = "active"
+	ExperimentPaused    = "paused"
+	ExperimentCompleted = "completed"
+)
+
+// ExperimentAlgorithms lists the recommendation algorithms an experiment can compare
+var ExperimentAlgorithms = []string{"collaborative", "content_based", "hybrid"}
+
+// ExperimentVariantReport summarizes how one variant of an experiment performed
+type ExperimentVariantReport struct {
+	Variant          string  `json:"variant"`
+	Users            int64   `json:"users"`
+	Recommendations  int64   `json:"recommendations"`
+	Impressions      int64   `json:"impressions"`
+	Clicks           int64   `json:"clicks"`
+	Purchases        int64   `json:"purchases"`
+	ClickThroughRate float64 `json:"click_through_rate"` // Percent of impressions clicked
+	ConversionRate   float64 `json:"conversion_rate"`    // Purchases per impression, in percent
+}
+
+// BucketVariant deterministically picks a variant for a user from a hash of the
+// experiment and user IDs, so the same user always lands in the same bucket
+func BucketVariant(experimentID, userID uuid.UUID, variants []string) string {
+	h := fnv.New32a()
+	h.Write(experimentID[:])
+	h.Write(userID[:])
+	return variants[h.Sum32()%uint32(len(variants))]
+}
+
+// GetRunningExperiment returns the active experiment, or nil when none is running
+func GetRunningExperiment() (*models.Experiment, error) {
+	var experiment models.Experiment
+	err := database.DB.Where("status = ?", ExperimentActive).
+		Order("started_at DESC").
+		First(&experiment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+// AssignExperimentVariant returns the user's variant in an experiment, bucketing and
+// recording them on first sight. The stored assignment wins over the hash, so users
+// keep their variant even if the experiment's variants are edited later.
+func AssignExperimentVariant(experiment models.Experiment, userID uuid.UUID) (models.ExperimentAssignment, error) {
+	assignment := models.ExperimentAssignment{
+		ExperimentID: experiment.ID,
+		UserID:       userID,
+		Variant:      BucketVariant(experiment.ID, userID, experiment.Variants),
+	}
+
+	if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&assignment).Error; err != nil {
+		return assignment, err
+	}
+
+	err := database.DB.Where("experiment_id = ? AND user_id = ?", experiment.ID, userID).First(&assignment).Error
+	return assignment, err
+}
+
+// RecommendationAlgorithmFor returns the algorithm to generate a user's recommendations
+// with: their variant of the running experiment, or the default outside experiments.
+// Lookup failures fall back to the default so recommendations keep working.
+func RecommendationAlgorithmFor(userID uuid.UUID) (string, bool) {
+	experiment, err := GetRunningExperiment()
+	if err != nil || experiment == nil || len(experiment.Variants) == 0 {
+		return DefaultRecommendationAlgorithm, false
+	}
+
+	assignment, err := AssignExperimentVariant(*experiment, userID)
+	if err != nil {
+		return DefaultRecommendationAlgorithm, false
+	}
+	return assignment.Variant, true
+}
+
+// ExperimentReport computes per-variant recommendation and impression counts, clicks
+// and purchases of an experiment. Only activity after each user's assignment, and before the
+// experiment ended, is attributed to their variant.
+func ExperimentReport(experiment models.Experiment) ([]ExperimentVariantReport, error) {
+	end := time.Now()
+	if experiment.EndedAt != nil {
+		end = *experiment.EndedAt
+	}
+
+	reports := make(map[string]*ExperimentVariantReport, len(experiment.Variants))
+	order := append([]string{}, experiment.Variants...)
+	for _, variant := range experiment.Variants {
+		reports[variant] = &ExperimentVariantReport{Variant: variant}
+	}
+	report := func(variant string) *ExperimentVariantReport {
+		if _, exists := reports[variant]; !exists {
+			reports[variant] = &ExperimentVariantReport{Variant: variant}
+			order = append(order, variant)
+		}
+		return reports[variant]
+	}
+
+	var users []struct {
+		Variant string
+		Count   int64
+	}
+	if err := database.DB.Model(&models.ExperimentAssignment{}).
+		Select("variant, COUNT(*) AS count").
+		Where("experiment_id = ?", experiment.ID).
+		Group("variant").
+		Scan(&users).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range users {
+		report(row.Variant).Users = row.Count
+	}
+
+	var recommendations []struct {
+		Variant string
+		Count   int64
+	}
+	if err := database.DB.Table("experiment_assignments a").
+		Select("a.variant, COUNT(r.id) AS count").
+		Joins("JOIN recommendations r ON r.user_id = a.user_id AND r.created_at >= a.created_at AND r.created_at < ?", end).
+		Where("a.experiment_id = ?", experiment.ID).
+		Group("a.variant").
+		Scan(&recommendations).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range recommendations {
+		report(row.Variant).Recommendations = row.Count
+	}
+
+	var impressions []struct {
+		Variant string
+		Count   int64
+	}
+	if err := database.DB.Table("experiment_assignments a").
+		Select("a.variant, COUNT(i.id) AS count").
+		Joins("JOIN recommendation_impressions i ON i.user_id = a.user_id AND i.created_at >= a.created_at AND i.created_at < ?", end).
+		Where("a.experiment_id = ?", experiment.ID).
+		Group("a.variant").
+		Scan(&impressions).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range impressions {
+		report(row.Variant).Impressions = row.Count
+	}
+
+	var feedback []struct {
+		Variant   string
+		Clicks    int64
+		Purchases int64
+	}
+	if err := database.DB.Table("experiment_assignments a").
+		Select("a.variant, "+
+			"COUNT(*) FILTER (WHERE f.feedback_type = 'clicked' AND f.impression_id IS NOT NULL) AS clicks, "+
+			"COUNT(*) FILTER (WHERE f.feedback_type = 'purchased') AS purchases").
+		Joins("JOIN recommendation_feedbacks f ON f.user_id = a.user_id AND f.created_at >= a.created_at AND f.created_at < ?", end).
+		Where("a.experiment_id = ?", experiment.ID).
+		Group("a.variant").
+		Scan(&feedback).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range feedback {
+		entry := report(row.Variant)
+		entry.Clicks = row.Clicks
+		entry.Purchases = row.Purchases
+	}
+
+	result := make([]ExperimentVariantReport, 0, len(order))
+	for _, variant := range order {
+		entry := reports[variant]
+		if entry.Impressions > 0 {
+			entry.ClickThroughRate = float64(entry.Clicks) / float64(entry.Impressions) * 100
+			entry.ConversionRate = float64(entry.Purchases) / float64(entry.Impressions) * 100
+		}
+		result = append(result, *entry)
+	}
+	return result, nil
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"fmt"
+	"log"
+
+	"bachelor_backend/database"
+	"bachelor_backend/models"
+
+	"github.com/google/uuid"
+)
+
+// NotificationLowStock is sent to admins when an order takes a product down to
+// the low-stock threshold. Admins cannot opt out of it.
+const NotificationLowStock = "low_stock"
+
+// LowStockThreshold returns the stock level at or below which a product counts as
+// running low (LOW_STOCK_THRESHOLD, default 10)
+func LowStockThreshold() int {
+	return getEnvInt("LOW_STOCK_THRESHOLD", 10)
+}
+
+// NotifyLowStock alerts every admin about products whose stock just reached the
+// low-stock threshold
+func NotifyLowStock(products []models.Product) {
+	if len(products) == 0 {
+		return
+	}
+
+	var adminIDs []uuid.UUID
+	if err := database.DB.Model(&models.User{}).
+		Where("role = ?", "admin").
+		Pluck("id", &adminIDs).Error; err != nil {
+		log.Printf("Failed to load admins for low-stock notification: %v", err)
+		return
+	}
+
+	for _, product := range products {
+		title := fmt.Sprintf("%s is running low", product.Name)
+		body := fmt.Sprintf("Only %d units of %s are left in stock.", product.Stock, product.Name)
+		metadata := map[string]interface{}{
+			"product_id": product.ID,
+			"stock":      product.Stock,
+			"threshold":  LowStockThreshold(),
+		}
+
+		for _, adminID := range adminIDs {
+			if err := NotificationDispatcherInstance.Enqueue(adminID, NotificationLowStock, title, body, metadata); err != nil {
+				log.Printf("Failed to queue low-stock notification for admin %s: %v", adminID, err)
+			}
+		}
+	}
+
+	log.Printf("Queued low-stock notifications for %d products", len(products))
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"bachelor_backend/models"
+	"bachelor_backend/pkg/money"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/google/uuid"
+)
+
+// InvoiceLine is a single line item on an invoice
+type InvoiceLine struct {
+	ProductID   uuid.UUID   `json:"product_id"`
+	ProductName string      `json:"product_name"`
+	SKU         string      `json:"sku,omitempty"`
+	Quantity    int         `json:"quantity"`
+	UnitPrice   money.Cents `json:"unit_price"`
+	Subtotal    money.Cents `json:"subtotal"`
+	Discount    money.Cents `json:"discount"` // Share of the order's discounts
+	Total       money.Cents `json:"total"`
+}
+
+// Invoice is a customer-facing receipt for an order
+type Invoice struct {
+	InvoiceNumber   string        `json:"invoice_number"`
+	OrderID         uuid.UUID     `json:"order_id"`
+	OrderDate       time.Time     `json:"order_date"`
+	Status          string        `json:"status"`
+	CustomerName    string        `json:"customer_name"`
+	CustomerEmail   string        `json:"customer_email"`
+	ShippingAddress string        `json:"shipping_address"`
+	Items           []InvoiceLine `json:"items"`
+	Subtotal        money.Cents   `json:"subtotal"`
+	DiscountTotal   money.Cents   `json:"discount_total"`
+	CouponCode      string        `json:"coupon_code,omitempty"`
+	CouponDiscount  money.Cents   `json:"coupon_discount"`
+	Tax             money.Cents   `json:"tax"`
+	Shipping        money.Cents   `json:"shipping"`
+	GrandTotal      money.Cents   `json:"grand_total"`
+	Refunded        money.Cents   `json:"refunded"`
+}
+
+// BuildInvoice builds the invoice for an order. The order must have its
+// items and their products loaded. Order-level discounts are spread across
+// the lines in proportion to their subtotals.
+func BuildInvoice(order models.Order, customer models.User, couponCode string, refunded money.Cents) Invoice {
+	invoice := Invoice{
+		InvoiceNumber:   "INV-" + strings.ToUpper(order.ID.String()[:8]),
+		OrderID:         order.ID,
+		OrderDate:       order.CreatedAt,
+		Status:          order.Status,
+		CustomerName:    customer.Name,
+		CustomerEmail:   customer.Email,
+		ShippingAddress: order.ShippingAddress,
+		Items:           make([]InvoiceLine, 0, len(order.OrderItems)),
+		Subtotal:        order.Subtotal,
+		DiscountTotal:   order.DiscountTotal,
+		CouponCode:      couponCode,
+		CouponDiscount:  order.CouponDiscount,
+		Tax:             order.TaxTotal,
+		Shipping:        order.ShippingCost,
+		GrandTotal:      order.Total,
+		Refunded:        refunded,
+	}
+
+	var linesSubtotal money.Cents
+	for _, item := range order.OrderItems {
+		line := InvoiceLine{
+			ProductID:   item.ProductID,
+			ProductName: item.Product.Name,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.Price,
+			Subtotal:    item.Price.Mul(item.Quantity),
+		}
+		if item.Product.SKU != nil {
+			line.SKU = *item.Product.SKU
+		}
+		linesSubtotal += line.Subtotal
+		invoice.Items = append(invoice.Items, line)
+	}
+
+	// Orders placed before subtotals were stored only have a total
+	if invoice.Subtotal == 0 {
+		invoice.Subtotal = linesSubtotal
+	}
+
+	// Spread the discount over the lines; the last line absorbs rounding
+	remaining := invoice.DiscountTotal
+	for i := range invoice.Items {
+		line := &invoice.Items[i]
+		if i == len(invoice.Items)-1 {
+			line.Discount = remaining
+		} else if linesSubtotal > 0 {
+			line.Discount = invoice.DiscountTotal.MulRate(float64(line.Subtotal) / float64(linesSubtotal))
+		}
+		line.Discount = money.Min(line.Discount, line.Subtotal)
+		remaining -= line.Discount
+		line.Total = line.Subtotal - line.Discount
+	}
+
+	return invoice
+}
+
+// RenderInvoicePDF renders an invoice as a single-page A4 PDF
+func RenderInvoicePDF(invoice Invoice) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle("Invoice "+invoice.InvoiceNumber, true)
+	pdf.SetMargins(15, 15, 15)
+	pdf.AddPage()
+
+	// The core fonts only cover cp1252
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 10, "Invoice", "", 1, "L", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 5, "Invoice number: "+invoice.InvoiceNumber, "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 5, "Order ID: "+invoice.OrderID.String(), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 5, "Order date: "+invoice.OrderDate.Format("2006-01-02 15:04 MST"), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 5, "Status: "+invoice.Status, "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(0, 5, "Bill to", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 5, tr(invoice.CustomerName), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 5, tr(invoice.CustomerEmail), "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(0, 5, "Ship to", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	shippingAddress := invoice.ShippingAddress
+	if shippingAddress == "" {
+		shippingAddress = "-"
+	}
+	pdf.MultiCell(0, 5, tr(shippingAddress), "", "L", false)
+	pdf.Ln(4)
+
+	// Line items
+	widths := []float64{70, 15, 25, 25, 20, 25}
+	headers := []string{"Product", "Qty", "Unit price", "Subtotal", "Discount", "Total"}
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(235, 235, 235)
+	for i, header := range headers {
+		align := "R"
+		if i == 0 {
+			align = "L"
+		}
+		pdf.CellFormat(widths[i], 7, header, "B", 0, align, true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 10)
+	for _, line := range invoice.Items {
+		name := line.ProductName
+		if runes := []rune(name); len(runes) > 40 {
+			name = string(runes[:37]) + "..."
+		}
+		pdf.CellFormat(widths[0], 6, tr(name), "", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], 6, fmt.Sprintf("%d", line.Quantity), "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[2], 6, formatInvoiceAmount(line.UnitPrice), "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[3], 6, formatInvoiceAmount(line.Subtotal), "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[4], 6, formatInvoiceAmount(-line.Discount), "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[5], 6, formatInvoiceAmount(line.Total), "", 1, "R", false, 0, "")
+	}
+	pdf.Ln(2)
+
+	// Totals
+	labelWidth := widths[0] + widths[1] + widths[2] + widths[3] + widths[4]
+	totalRow := func(label string, amount money.Cents, bold bool) {
+		style := ""
+		if bold {
+			style = "B"
+		}
+		pdf.SetFont("Helvetica", style, 10)
+		pdf.CellFormat(labelWidth, 6, label, "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[5], 6, formatInvoiceAmount(amount), "", 1, "R", false, 0, "")
+	}
+	totalRow("Subtotal", invoice.Subtotal, false)
+	if invoice.CouponCode != "" {
+		totalRow("Coupon "+tr(invoice.CouponCode), -invoice.CouponDiscount, false)
+	}
+	if invoice.DiscountTotal > 0 {
+		totalRow("Total discounts", -invoice.DiscountTotal, false)
+	}
+	if invoice.Tax > 0 {
+		totalRow("Tax", invoice.Tax, false)
+	}
+	totalRow("Shipping", invoice.Shipping, false)
+	totalRow("Grand total", invoice.GrandTotal, true)
+	if invoice.Refunded > 0 {
+		totalRow("Refunded", -invoice.Refunded, false)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render invoice: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// formatInvoiceAmount formats a monetary amount with two decimals
+func formatInvoiceAmount(amount money.Cents) string {
+	return amount.String()
+}
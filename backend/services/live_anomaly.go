@@ -0,0 +1,177 @@
+package services
+
+import (
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"bachelor_backend/models"
+)
+
+// LiveAnalysisConfig controls anomaly analysis of live traffic
+type LiveAnalysisConfig struct {
+	Enabled    bool    // Analyze request logs as they are saved
+	SampleRate float64 // Fraction of requests analyzed (0.0 to 1.0)
+	QueueSize  int     // Maximum logs waiting for analysis; extra logs are dropped
+	Workers    int     // Concurrent calls to the ML service
+}
+
+// LiveAnomalyAnalyzer analyzes a sample of request logs as they are saved. Logs
+// are queued without blocking the caller and dropped when the queue is full; the
+// background analyzer still picks up anything missed here.
+type LiveAnomalyAnalyzer struct {
+	config    LiveAnalysisConfig
+	queue     chan models.RequestLog
+	wg        sync.WaitGroup
+	mu        sync.Mutex
+	isRunning bool
+
+	submitted atomic.Int64
+	skipped   atomic.Int64
+	dropped   atomic.Int64
+	analyzed  atomic.Int64
+	failed    atomic.Int64
+}
+
+// NewLiveAnomalyAnalyzer creates a new live anomaly analyzer
+func NewLiveAnomalyAnalyzer(config LiveAnalysisConfig) *LiveAnomalyAnalyzer {
+	return &LiveAnomalyAnalyzer{
+		config: config,
+	}
+}
+
+// Start launches the analysis workers if live analysis is enabled
+func (la *LiveAnomalyAnalyzer) Start() {
+	la.mu.Lock()
+	defer la.mu.Unlock()
+
+	if la.isRunning {
+		log.Println("Live anomaly analyzer is already running")
+		return
+	}
+	if !la.config.Enabled {
+		log.Println("Live anomaly analysis disabled")
+		return
+	}
+	if AnomalyServiceInstance == nil {
+		log.Println("Warning: Anomaly service not available, live anomaly analyzer will not start")
+		return
+	}
+
+	la.queue = make(chan models.RequestLog, la.config.QueueSize)
+	la.isRunning = true
+
+	for i := 0; i < la.config.Workers; i++ {
+		la.wg.Add(1)
+		go la.work(la.queue)
+	}
+
+	log.Printf("Starting live anomaly analyzer (sample rate %.2f, %d workers)", la.config.SampleRate, la.config.Workers)
+}
+
+// Stop closes the queue and waits for in-flight analyses to finish
+func (la *LiveAnomalyAnalyzer) Stop() {
+	la.mu.Lock()
+	if !la.isRunning {
+		la.mu.Unlock()
+		return
+	}
+	la.isRunning = false
+	close(la.queue)
+	la.mu.Unlock()
+
+	la.wg.Wait()
+	log.Println("Live anomaly analyzer stopped")
+}
+
+// Submit queues a saved request log for analysis. It never blocks: unsampled logs
+// are skipped and logs arriving while the queue is full are dropped.
+func (la *LiveAnomalyAnalyzer) Submit(requestLog models.RequestLog) {
+	la.mu.Lock()
+	defer la.mu.Unlock()
+
+	if !la.isRunning {
+		return
+	}
+	if la.config.SampleRate < 1.0 && rand.Float64() >= la.config.SampleRate {
+		la.skipped.Add(1)
+		return
+	}
+
+	select {
+	case la.queue <- requestLog:
+		la.submitted.Add(1)
+	default:
+		la.dropped.Add(1)
+	}
+}
+
+// work analyzes queued logs until the queue is closed
+func (la *LiveAnomalyAnalyzer) work(queue <-chan models.RequestLog) {
+	defer la.wg.Done()
+
+	for requestLog := range queue {
+		analysis, err := AnomalyServiceInstance.AnalyzeRequest(requestLog)
+		if err != nil {
+			la.failed.Add(1)
+			log.Printf("Failed to analyze request %s (request ID %s): %v", requestLog.ID, requestLog.RequestID, err)
+			continue
+		}
+
+		if err := AnomalyServiceInstance.ProcessAnomalyAlert(requestLog, analysis); err != nil {
+			la.failed.Add(1)
+			log.Printf("Failed to process anomaly alert for request %s (request ID %s): %v", requestLog.ID, requestLog.RequestID, err)
+			continue
+		}
+		la.analyzed.Add(1)
+	}
+}
+
+// GetStatus returns the current status of the live analyzer
+func (la *LiveAnomalyAnalyzer) GetStatus() map[string]interface{} {
+	la.mu.Lock()
+	defer la.mu.Unlock()
+
+	queued := 0
+	if la.isRunning {
+		queued = len(la.queue)
+	}
+
+	return map[string]interface{}{
+		"is_running":  la.isRunning,
+		"enabled":     la.config.Enabled,
+		"sample_rate": la.config.SampleRate,
+		"workers":     la.config.Workers,
+		"queued":      queued,
+		"submitted":   la.submitted.Load(),
+		"skipped":     la.skipped.Load(),
+		"dropped":     la.dropped.Load(),
+		"analyzed":    la.analyzed.Load(),
+		"failed":      la.failed.Load(),
+	}
+}
+
+// loadLiveAnalysisConfig reads the live analysis settings from the environment
+func loadLiveAnalysisConfig() LiveAnalysisConfig {
+	enabled := false
+	if value := os.Getenv("LIVE_ANOMALY_ANALYSIS"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Printf("Warning: Invalid boolean value for LIVE_ANOMALY_ANALYSIS: %s, using fallback: false", value)
+		}
+		enabled = parsed
+	}
+
+	return LiveAnalysisConfig{
+		Enabled:    enabled,
+		SampleRate: min(getEnvFloat("LIVE_ANOMALY_SAMPLE_RATE", 0.1), 1.0),
+		QueueSize:  getEnvInt("LIVE_ANOMALY_QUEUE_SIZE", 1000),
+		Workers:    getEnvInt("LIVE_ANOMALY_WORKERS", 2),
+	}
+}
+
+// Global live anomaly analyzer instance
+var LiveAnomalyAnalyzerInstance = NewLiveAnomalyAnalyzer(loadLiveAnalysisConfig())
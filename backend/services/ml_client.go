@@ -2,25 +2,33 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"math/rand"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type MLClient struct {
-	baseURL string
-	client  *http.Client
+	baseURL     string
+	client      *http.Client
+	trainClient *http.Client // Training runs for minutes and waits longer
+	retry       MLRetryPolicy
 }
 
 type RecommendationRequest struct {
 	UserID    string `json:"user_id"`
 	Algorithm string `json:"algorithm"`
 	Limit     int    `json:"limit"`
+	Persist   *bool  `json:"persist,omitempty"`
 }
 
 type RecommendationResponse struct {
@@ -42,6 +50,8 @@ type SentimentAnalysisResponse struct {
 	ProductName      string                   `json:"product_name"`
 	TotalComments    int                      `json:"total_comments"`
 	SentimentSummary map[string]interface{}   `json:"sentiment_summary"`
+	AverageSentiment float64                  `json:"average_sentiment"`
+	SentimentTrend   string                   `json:"sentiment_trend"` // 'positive', 'negative', 'neutral' or 'no_data'
 	Insights         []map[string]interface{} `json:"insights"`
 	GeneratedAt      string                   `json:"generated_at"`
 }
@@ -74,6 +84,76 @@ type SmartDiscountResponse struct {
 	GeneratedAt                 string                 `json:"generated_at"`
 }
 
+// Revenue Forecasting Types
+type DailyRevenue struct {
+	Date    string  `json:"date"` // YYYY-MM-DD
+	Revenue float64 `json:"revenue"`
+}
+
+type RevenueForecastRequest struct {
+	History     []DailyRevenue `json:"history"`
+	HorizonDays int            `json:"horizon_days"`
+}
+
+type RevenueForecastPoint struct {
+	Date       string  `json:"date"`
+	Revenue    float64 `json:"revenue"`
+	LowerBound float64 `json:"lower_bound"`
+	UpperBound float64 `json:"upper_bound"`
+}
+
+type RevenueForecastResponse struct {
+	Forecast        []RevenueForecastPoint `json:"forecast"`
+	Model           string                 `json:"model"`
+	ConfidenceLevel float64                `json:"confidence_level"`
+	GeneratedAt     string                 `json:"generated_at"`
+}
+
+// MLRetryPolicy controls how ML service calls are retried
+type MLRetryPolicy struct {
+	MaxAttempts int           // Total attempts, including the first
+	BaseDelay   time.Duration // Delay before the first retry, doubled for each further retry
+	MaxDelay    time.Duration // Upper bound on a single delay
+	Jitter      float64       // Fraction of each delay randomly added or removed, 0-1
+}
+
+// MLStatusError is returned when the ML service answers with a non-200 status
+type MLStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *MLStatusError) Error() string {
+	return fmt.Sprintf("ML service returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// loadMLRetryPolicy reads the retry policy from the environment
+func loadMLRetryPolicy() MLRetryPolicy {
+	jitter := getEnvFloat("ML_RETRY_JITTER", 0.2)
+	if jitter > 1 {
+		jitter = 1
+	}
+
+	return MLRetryPolicy{
+		MaxAttempts: getEnvInt("ML_RETRY_MAX_ATTEMPTS", 3),
+		BaseDelay:   time.Duration(getEnvInt("ML_RETRY_BASE_DELAY_MS", 200)) * time.Millisecond,
+		MaxDelay:    time.Duration(getEnvInt("ML_RETRY_MAX_DELAY_MS", 2000)) * time.Millisecond,
+		Jitter:      jitter,
+	}
+}
+
+// delay returns how long to wait before the given retry (1 for the first retry)
+func (p MLRetryPolicy) delay(retry int) time.Duration {
+	delay := p.BaseDelay << (retry - 1)
+	if delay > p.MaxDelay || delay <= 0 {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		delay += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(delay))
+	}
+	return delay
+}
+
 // NewMLClient creates a new ML service client
 func NewMLClient() *MLClient {
 	baseURL := os.Getenv("ML_SERVICE_URL")
@@ -82,281 +162,279 @@ func NewMLClient() *MLClient {
 	}
 
 	return &MLClient{
-		baseURL: baseURL,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		baseURL:     baseURL,
+		client:      newMLHTTPClient(30 * time.Second),
+		trainClient: newMLHTTPClient(time.Duration(getEnvInt("ML_TRAINING_TIMEOUT_MINUTES", 30)) * time.Minute),
+		retry:       loadMLRetryPolicy(),
 	}
 }
 
-// GenerateRecommendations calls the ML service to generate recommendations
-func (ml *MLClient) GenerateRecommendations(userID uuid.UUID, algorithm string, limit int) (*RecommendationsResponse, error) {
-	reqBody := RecommendationRequest{
-		UserID:    userID.String(),
-		Algorithm: algorithm,
-		Limit:     limit,
-	}
+// doRequest sends a request to the ML service and returns the body of a 200 response.
+// Network errors and 5xx responses are retried with exponential backoff; 4xx responses
+// and calls rejected by the circuit breaker are not.
+func (ml *MLClient) doRequest(method, path string, body []byte) ([]byte, error) {
+	url := ml.baseURL + path
 
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	var lastErr error
+	for attempt := 1; attempt <= ml.retry.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(ml.retry.delay(attempt - 1))
+		}
+
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		req, err := http.NewRequest(method, url, reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := ml.client.Do(req)
+		if err != nil {
+			if errors.Is(err, ErrCircuitOpen) {
+				return nil, fmt.Errorf("failed to call ML service: %w", err)
+			}
+			lastErr = fmt.Errorf("failed to call ML service: %w", err)
+			continue
+		}
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("failed to read response: %w", err)
+			continue
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			lastErr = &MLStatusError{StatusCode: resp.StatusCode, Body: string(respBody)}
+			if resp.StatusCode >= http.StatusInternalServerError {
+				continue
+			}
+			return nil, lastErr
+		}
+
+		return respBody, nil
 	}
 
-	url := fmt.Sprintf("%s/generate", ml.baseURL)
-	resp, err := ml.client.Post(url, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to call ML service: %w", err)
+	return nil, lastErr
+}
+
+// doJSON sends body (if not nil) as JSON to the ML service and decodes the response into T
+func doJSON[T any](ml *MLClient, method, path string, body any) (*T, error) {
+	var jsonData []byte
+	if body != nil {
+		var err error
+		jsonData, err = json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ML service returned status %d: %s", resp.StatusCode, string(body))
+	respBody, err := ml.doRequest(method, path, jsonData)
+	if err != nil {
+		return nil, err
 	}
 
-	var result RecommendationsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	var result T
+	if err := json.Unmarshal(respBody, &result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	return &result, nil
 }
 
-// TrainModels calls the ML service to train recommendation models
-func (ml *MLClient) TrainModels() error {
-	url := fmt.Sprintf("%s/train", ml.baseURL)
-	resp, err := ml.client.Post(url, "application/json", nil)
+// doMap calls an ML service endpoint that returns a JSON object
+func (ml *MLClient) doMap(method, path string) (map[string]interface{}, error) {
+	result, err := doJSON[map[string]interface{}](ml, method, path, nil)
 	if err != nil {
-		return fmt.Errorf("failed to call ML service: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return *result, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("ML service returned status %d: %s", resp.StatusCode, string(body))
-	}
+// GenerateRecommendations calls the ML service to generate recommendations
+func (ml *MLClient) GenerateRecommendations(userID uuid.UUID, algorithm string, limit int) (*RecommendationsResponse, error) {
+	return doJSON[RecommendationsResponse](ml, http.MethodPost, "/generate", RecommendationRequest{
+		UserID:    userID.String(),
+		Algorithm: algorithm,
+		Limit:     limit,
+	})
+}
 
-	return nil
+// PreviewRecommendations calls the ML service to generate recommendations
+// without persisting them
+func (ml *MLClient) PreviewRecommendations(userID uuid.UUID, algorithm string, limit int) (*RecommendationsResponse, error) {
+	persist := false
+	return doJSON[RecommendationsResponse](ml, http.MethodPost, "/generate", RecommendationRequest{
+		UserID:    userID.String(),
+		Algorithm: algorithm,
+		Limit:     limit,
+		Persist:   &persist,
+	})
 }
 
-// GetMLStatus checks the status of ML models
-func (ml *MLClient) GetMLStatus() (map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/status", ml.baseURL)
-	resp, err := ml.client.Get(url)
+// TrainModels calls the ML service to train recommendation models and waits until
+// training has finished (up to ML_TRAINING_TIMEOUT_MINUTES, default 30). Failures are
+// not retried, since training would start over.
+func (ml *MLClient) TrainModels() error {
+	req, err := http.NewRequest(http.MethodPost, ml.baseURL+"/train", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := ml.trainClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to call ML service: %w", err)
+		return fmt.Errorf("failed to call ML service: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ML service returned status %d: %s", resp.StatusCode, string(body))
+		return &MLStatusError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
+	return nil
+}
 
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// Ping makes a single call to the ML service status endpoint, without retries, to
+// check that the service is reachable
+func (ml *MLClient) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ml.baseURL+"/status", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	return result, nil
-}
-
-// AnalyzeProductSentiment calls the ML service to analyze product sentiment
-func (ml *MLClient) AnalyzeProductSentiment(productID uuid.UUID) (*SentimentAnalysisResponse, error) {
-	url := fmt.Sprintf("%s/sentiment/product/%s", ml.baseURL, productID.String())
-	resp, err := ml.client.Get(url)
+	resp, err := ml.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to call ML service: %w", err)
+		return fmt.Errorf("failed to call ML service: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ML service returned status %d: %s", resp.StatusCode, string(body))
+		return &MLStatusError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
+	return nil
+}
 
-	var result SentimentAnalysisResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
+// GetMLStatus checks the status of ML models
+func (ml *MLClient) GetMLStatus() (map[string]interface{}, error) {
+	return ml.doMap(http.MethodGet, "/status")
+}
 
-	return &result, nil
+// AnalyzeProductSentiment calls the ML service to analyze product sentiment
+func (ml *MLClient) AnalyzeProductSentiment(productID uuid.UUID) (*SentimentAnalysisResponse, error) {
+	return doJSON[SentimentAnalysisResponse](ml, http.MethodGet, fmt.Sprintf("/sentiment/product/%s", productID.String()), nil)
+}
+
+// sentimentFallbackWorkers is how many per-product sentiment requests run at once when
+// the ML service has no batch endpoint
+const sentimentFallbackWorkers = 4
+
+// AnalyzeProductSentimentBatch calls the ML service to analyze the sentiment of several
+// products at once, keyed by product ID. If the service has no batch endpoint, each
+// product is analyzed on its own; products whose analysis fails are left out.
+func (ml *MLClient) AnalyzeProductSentimentBatch(productIDs []uuid.UUID) (map[uuid.UUID]SentimentAnalysisResponse, error) {
+	ids := make([]string, 0, len(productIDs))
+	for _, productID := range productIDs {
+		ids = append(ids, productID.String())
+	}
+
+	batch, err := doJSON[struct {
+		Results map[string]SentimentAnalysisResponse `json:"results"`
+	}](ml, http.MethodPost, "/sentiment/batch", map[string][]string{"product_ids": ids})
+	if err == nil {
+		results := make(map[uuid.UUID]SentimentAnalysisResponse, len(batch.Results))
+		for id, result := range batch.Results {
+			if productID, err := uuid.Parse(id); err == nil {
+				results[productID] = result
+			}
+		}
+		return results, nil
+	}
+
+	var statusErr *MLStatusError
+	if !errors.As(err, &statusErr) || (statusErr.StatusCode != http.StatusNotFound && statusErr.StatusCode != http.StatusMethodNotAllowed) {
+		return nil, err
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[uuid.UUID]SentimentAnalysisResponse, len(productIDs))
+		slots   = make(chan struct{}, sentimentFallbackWorkers)
+	)
+	for _, productID := range productIDs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(productID uuid.UUID) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			result, err := ml.AnalyzeProductSentiment(productID)
+			if err != nil {
+				log.Printf("Warning: failed to analyze sentiment for product %s: %v", productID, err)
+				return
+			}
+			mu.Lock()
+			results[productID] = *result
+			mu.Unlock()
+		}(productID)
+	}
+	wg.Wait()
+
+	return results, nil
 }
 
 // AnalyzeCategorySentiment calls the ML service to analyze category sentiment
 func (ml *MLClient) AnalyzeCategorySentiment(category string) (map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/sentiment/category/%s", ml.baseURL, category)
-	resp, err := ml.client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to call ML service: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ML service returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return result, nil
+	return ml.doMap(http.MethodGet, fmt.Sprintf("/sentiment/category/%s", category))
 }
 
 // GetSentimentInsights calls the ML service to get sentiment insights
 func (ml *MLClient) GetSentimentInsights() (map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/sentiment/insights", ml.baseURL)
-	resp, err := ml.client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to call ML service: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ML service returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return result, nil
+	return ml.doMap(http.MethodGet, "/sentiment/insights")
 }
 
 // SuggestProductTags calls the ML service to suggest tags for a product
 func (ml *MLClient) SuggestProductTags(productID uuid.UUID) (*AutoTaggingResponse, error) {
-	url := fmt.Sprintf("%s/auto-tagging/suggest/%s", ml.baseURL, productID.String())
-	resp, err := ml.client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to call ML service: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ML service returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var result AutoTaggingResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return &result, nil
+	return doJSON[AutoTaggingResponse](ml, http.MethodGet, fmt.Sprintf("/auto-tagging/suggest/%s", productID.String()), nil)
 }
 
 // AutoTagProducts calls the ML service to auto-tag products
 func (ml *MLClient) AutoTagProducts(limit int) (map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/auto-tagging/auto-tag?limit=%d", ml.baseURL, limit)
-	resp, err := ml.client.Post(url, "application/json", nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to call ML service: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ML service returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return result, nil
+	return ml.doMap(http.MethodPost, fmt.Sprintf("/auto-tagging/auto-tag?limit=%d", limit))
 }
 
 // GetTaggingInsights calls the ML service to get tagging insights
 func (ml *MLClient) GetTaggingInsights() (map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/auto-tagging/insights", ml.baseURL)
-	resp, err := ml.client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to call ML service: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ML service returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return result, nil
+	return ml.doMap(http.MethodGet, "/auto-tagging/insights")
 }
 
 // SuggestProductDiscount calls the ML service to suggest discount for a product
 func (ml *MLClient) SuggestProductDiscount(productID uuid.UUID) (*SmartDiscountResponse, error) {
-	url := fmt.Sprintf("%s/smart-discounts/suggest/product/%s", ml.baseURL, productID.String())
-	resp, err := ml.client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to call ML service: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ML service returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var result SmartDiscountResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return &result, nil
+	return doJSON[SmartDiscountResponse](ml, http.MethodGet, fmt.Sprintf("/smart-discounts/suggest/product/%s", productID.String()), nil)
 }
 
 // SuggestCategoryDiscounts calls the ML service to suggest discounts for a category
 func (ml *MLClient) SuggestCategoryDiscounts(category string) (map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/smart-discounts/suggest/category/%s", ml.baseURL, category)
-	resp, err := ml.client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to call ML service: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ML service returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return result, nil
+	return ml.doMap(http.MethodGet, fmt.Sprintf("/smart-discounts/suggest/category/%s", category))
 }
 
 // GetDiscountInsights calls the ML service to get discount insights
 func (ml *MLClient) GetDiscountInsights() (map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/smart-discounts/insights", ml.baseURL)
-	resp, err := ml.client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to call ML service: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ML service returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
+	return ml.doMap(http.MethodGet, "/smart-discounts/insights")
+}
 
-	return result, nil
+// ForecastRevenue calls the ML service to project daily revenue forward from its history
+func (ml *MLClient) ForecastRevenue(history []DailyRevenue, horizonDays int) (*RevenueForecastResponse, error) {
+	return doJSON[RevenueForecastResponse](ml, http.MethodPost, "/forecast/revenue", RevenueForecastRequest{
+		History:     history,
+		HorizonDays: horizonDays,
+	})
 }
 
 // InitializeMLServices calls the ML service to initialize all new ML models
@@ -364,16 +442,13 @@ func (ml *MLClient) InitializeMLServices() error {
 	services := []string{"sentiment", "auto-tagging", "smart-discounts"}
 
 	for _, service := range services {
-		url := fmt.Sprintf("%s/%s/initialize", ml.baseURL, service)
-		resp, err := ml.client.Post(url, "application/json", nil)
-		if err != nil {
+		if _, err := ml.doRequest(http.MethodPost, fmt.Sprintf("/%s/initialize", service), nil); err != nil {
+			var statusErr *MLStatusError
+			if errors.As(err, &statusErr) {
+				return fmt.Errorf("%s service returned status %d", service, statusErr.StatusCode)
+			}
 			return fmt.Errorf("failed to initialize %s service: %w", service, err)
 		}
-		resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return fmt.Errorf("%s service returned status %d", service, resp.StatusCode)
-		}
 	}
 
 	return nil
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestMLClient returns a client for the test server with fast retries. It bypasses
+// the shared circuit breaker so failures here do not affect other tests.
+func newTestMLClient(url string) *MLClient {
+	return &MLClient{
+		baseURL: url,
+		client:  &http.Client{Timeout: 5 * time.Second},
+		retry:   MLRetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond},
+	}
+}
+
+func TestDoRequestRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"limit":5}` {
+			t.Errorf("attempt %d got body %q, want the original body resent", calls.Load()+1, body)
+		}
+		if calls.Add(1) < 3 {
+			http.Error(w, "model not loaded", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	body, err := newTestMLClient(server.URL).doRequest(http.MethodPost, "/recommendations", []byte(`{"limit":5}`))
+	if err != nil {
+		t.Fatalf("doRequest: %v", err)
+	}
+	if string(body) != `{"ok":true}` {
+		t.Errorf("body = %q, want the successful response", body)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("server called %d times, want 3", got)
+	}
+}
+
+func TestDoRequestGivesUpAfterMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	_, err := newTestMLClient(server.URL).doRequest(http.MethodGet, "/health", nil)
+
+	var statusErr *MLStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("err = %v, want an MLStatusError with status 500", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("server called %d times, want 3", got)
+	}
+}
+
+func TestDoRequestDoesNotRetryClientErrors(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				http.Error(w, "bad request", status)
+			}))
+			defer server.Close()
+
+			_, err := newTestMLClient(server.URL).doRequest(http.MethodGet, "/recommendations", nil)
+
+			var statusErr *MLStatusError
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != status {
+				t.Fatalf("err = %v, want an MLStatusError with status %d", err, status)
+			}
+			if got := calls.Load(); got != 1 {
+				t.Errorf("server called %d times, want 1", got)
+			}
+		})
+	}
+}
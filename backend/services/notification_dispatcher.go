@@ -0,0 +1,287 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// ErrDispatchQueueFull is returned when the dispatch queue cannot accept more messages
+var ErrDispatchQueueFull = errors.New("notification dispatch queue is full")
+
+// DispatchConfig controls how fast and how often notifications are delivered
+type DispatchConfig struct {
+	RatePerSecond    int           // Maximum messages delivered per second
+	BatchSize        int           // Maximum messages taken from the queue per tick
+	MaxAttempts      int           // Delivery attempts before a message is dropped
+	RetryBackoff     time.Duration // Base delay before a retry, doubled on each attempt
+	PerUserHourlyCap int           // Maximum notifications a user receives per hour
+	MaxQueueSize     int           // Maximum pending messages
+}
+
+// DispatchMessage is a notification waiting to be delivered
+type DispatchMessage struct {
+	UserID      uuid.UUID
+	Type        string
+	Title       string
+	Body        string
+	Metadata    map[string]interface{}
+	attempts    int
+	nextAttempt time.Time
+}
+
+// NotificationDispatcher queues notifications and delivers them in rate-limited batches,
+// retrying failures and enforcing the per-user frequency cap and suppression list.
+// The queue is held in memory, so pending messages are lost on shutdown.
+type NotificationDispatcher struct {
+	config    DispatchConfig
+	queue     []DispatchMessage
+	mu        sync.Mutex
+	ticker    *time.Ticker
+	stopChan  chan bool
+	isRunning bool
+
+	enqueued   atomic.Int64
+	sent       atomic.Int64
+	retried    atomic.Int64
+	failed     atomic.Int64
+	suppressed atomic.Int64
+	capped     atomic.Int64
+	optedOut   atomic.Int64
+	rejected   atomic.Int64
+}
+
+// NewNotificationDispatcher creates a new notification dispatcher
+func NewNotificationDispatcher(config DispatchConfig) *NotificationDispatcher {
+	return &NotificationDispatcher{
+		config:    config,
+		stopChan:  make(chan bool),
+		isRunning: false,
+	}
+}
+
+// Enqueue adds a notification to the dispatch queue
+func (nd *NotificationDispatcher) Enqueue(userID uuid.UUID, notificationType, title, body string, metadata map[string]interface{}) error {
+	nd.mu.Lock()
+	defer nd.mu.Unlock()
+
+	if len(nd.queue) >= nd.config.MaxQueueSize {
+		nd.rejected.Add(1)
+		return ErrDispatchQueueFull
+	}
+
+	nd.queue = append(nd.queue, DispatchMessage{
+		UserID:      userID,
+		Type:        notificationType,
+		Title:       title,
+		Body:        body,
+		Metadata:    metadata,
+		nextAttempt: time.Now(),
+	})
+	nd.enqueued.Add(1)
+	return nil
+}
+
+// Start begins delivering queued notifications once per second
+func (nd *NotificationDispatcher) Start() {
+	if nd.isRunning {
+		log.Println("Notification dispatcher is already running")
+		return
+	}
+
+	nd.ticker = time.NewTicker(time.Second)
+	nd.isRunning = true
+
+	log.Printf("Starting notification dispatcher (rate %d/s, batch %d, cap %d/user/hour)",
+		nd.config.RatePerSecond, nd.config.BatchSize, nd.config.PerUserHourlyCap)
+
+	go func() {
+		for {
+			select {
+			case <-nd.ticker.C:
+				nd.dispatchBatch()
+			case <-nd.stopChan:
+				nd.ticker.Stop()
+				nd.isRunning = false
+				log.Println("Notification dispatcher stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the dispatcher
+func (nd *NotificationDispatcher) Stop() {
+	if !nd.isRunning {
+		return
+	}
+
+	nd.stopChan <- true
+}
+
+// dispatchBatch delivers the messages that are due, up to the batch size and send rate
+func (nd *NotificationDispatcher) dispatchBatch() {
+	limit := nd.config.BatchSize
+	if nd.config.RatePerSecond < limit {
+		limit = nd.config.RatePerSecond
+	}
+
+	now := time.Now()
+	batch := make([]DispatchMessage, 0, limit)
+
+	nd.mu.Lock()
+	remaining := nd.queue[:0]
+	for _, msg := range nd.queue {
+		if len(batch) < limit && !msg.nextAttempt.After(now) {
+			batch = append(batch, msg)
+		} else {
+			remaining = append(remaining, msg)
+		}
+	}
+	nd.queue = remaining
+	nd.mu.Unlock()
+
+	for _, msg := range batch {
+		nd.deliver(msg)
+	}
+}
+
+// deliver sends a single message, requeueing it with backoff if delivery fails
+func (nd *NotificationDispatcher) deliver(msg DispatchMessage) {
+	if IsNotificationSuppressed(msg.UserID) {
+		nd.suppressed.Add(1)
+		return
+	}
+
+	if nd.config.PerUserHourlyCap > 0 {
+		var recent int64
+		database.DB.Model(&models.Notification{}).
+			Where("user_id = ? AND created_at > ?", msg.UserID, time.Now().Add(-time.Hour)).
+			Count(&recent)
+		if recent >= int64(nd.config.PerUserHourlyCap) {
+			nd.capped.Add(1)
+			return
+		}
+	}
+
+	created, err := CreateNotification(msg.UserID, msg.Type, msg.Title, msg.Body, msg.Metadata)
+	if err != nil {
+		msg.attempts++
+		if msg.attempts >= nd.config.MaxAttempts {
+			nd.failed.Add(1)
+			log.Printf("Dropping %s notification for user %s after %d attempts: %v", msg.Type, msg.UserID, msg.attempts, err)
+			return
+		}
+
+		msg.nextAttempt = time.Now().Add(nd.config.RetryBackoff * time.Duration(1<<(msg.attempts-1)))
+		nd.retried.Add(1)
+
+		nd.mu.Lock()
+		nd.queue = append(nd.queue, msg)
+		nd.mu.Unlock()
+		return
+	}
+
+	if !created {
+		nd.optedOut.Add(1)
+		return
+	}
+	nd.sent.Add(1)
+}
+
+// GetStats returns dispatch counters and the current queue length
+func (nd *NotificationDispatcher) GetStats() map[string]interface{} {
+	nd.mu.Lock()
+	queued := len(nd.queue)
+	nd.mu.Unlock()
+
+	return map[string]interface{}{
+		"is_running": nd.isRunning,
+		"queued":     queued,
+		"enqueued":   nd.enqueued.Load(),
+		"sent":       nd.sent.Load(),
+		"retried":    nd.retried.Load(),
+		"failed":     nd.failed.Load(),
+		"suppressed": nd.suppressed.Load(),
+		"capped":     nd.capped.Load(),
+		"opted_out":  nd.optedOut.Load(),
+		"rejected":   nd.rejected.Load(),
+		"config": map[string]interface{}{
+			"rate_per_second":     nd.config.RatePerSecond,
+			"batch_size":          nd.config.BatchSize,
+			"max_attempts":        nd.config.MaxAttempts,
+			"retry_backoff":       nd.config.RetryBackoff.String(),
+			"per_user_hourly_cap": nd.config.PerUserHourlyCap,
+			"max_queue_size":      nd.config.MaxQueueSize,
+		},
+	}
+}
+
+// IsNotificationSuppressed reports whether a user is on the suppression list.
+// Lookup failures err on the side of not notifying.
+func IsNotificationSuppressed(userID uuid.UUID) bool {
+	var count int64
+	if err := database.DB.Model(&models.NotificationSuppression{}).
+		Where("user_id = ?", userID).
+		Count(&count).Error; err != nil {
+		return true
+	}
+	return count > 0
+}
+
+// SuppressNotifications adds a user to the suppression list, updating the reason if already present
+func SuppressNotifications(userID uuid.UUID, reason string) (models.NotificationSuppression, error) {
+	suppression := models.NotificationSuppression{
+		UserID: userID,
+		Reason: reason,
+	}
+
+	err := database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"reason"}),
+	}).Create(&suppression).Error
+	return suppression, err
+}
+
+// UnsuppressNotifications removes a user from the suppression list.
+// It reports whether the user was suppressed.
+func UnsuppressNotifications(userID uuid.UUID) (bool, error) {
+	result := database.DB.Where("user_id = ?", userID).Delete(&models.NotificationSuppression{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// loadDispatchConfig reads the dispatcher configuration from the environment
+func loadDispatchConfig() DispatchConfig {
+	return DispatchConfig{
+		RatePerSecond:    getEnvInt("NOTIFICATION_SEND_RATE", 20),
+		BatchSize:        getEnvInt("NOTIFICATION_BATCH_SIZE", 50),
+		MaxAttempts:      getEnvInt("NOTIFICATION_MAX_ATTEMPTS", 3),
+		RetryBackoff:     time.Duration(getEnvInt("NOTIFICATION_RETRY_BACKOFF_SECONDS", 30)) * time.Second,
+		PerUserHourlyCap: getEnvInt("NOTIFICATION_USER_HOURLY_CAP", 10),
+		MaxQueueSize:     getEnvInt("NOTIFICATION_MAX_QUEUE_SIZE", 10000),
+	}
+}
+
+// getEnvInt reads a positive integer from the environment with a fallback
+func getEnvInt(key string, fallback int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil && intValue > 0 {
+			return intValue
+		}
+		log.Printf("Warning: Invalid integer value for %s: %s, using fallback: %d", key, value, fallback)
+	}
+	return fallback
+}
+
+// Global notification dispatcher instance
+var NotificationDispatcherInstance = NewNotificationDispatcher(loadDispatchConfig())
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"errors"
+
+	"bachelor_backend/database"
+	"bachelor_backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Notification categories users can opt in or out of
+const (
+	NotificationPriceDrop   = "price_drop"
+	NotificationBackInStock = "back_in_stock"
+	NotificationOrderStatus = "order_status"
+	NotificationDigest      = "digest"
+)
+
+// DefaultNotificationPreference returns the preferences used for users who never changed them
+func DefaultNotificationPreference(userID uuid.UUID) models.NotificationPreference {
+	return models.NotificationPreference{
+		UserID:          userID,
+		PriceDrop:       true,
+		BackInStock:     true,
+		OrderStatus:     true,
+		DigestFrequency: "none",
+	}
+}
+
+// GetNotificationPreference loads a user's preferences, falling back to the defaults
+func GetNotificationPreference(userID uuid.UUID) (models.NotificationPreference, error) {
+	var preference models.NotificationPreference
+	err := database.DB.Where("user_id = ?", userID).First(&preference).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return DefaultNotificationPreference(userID), nil
+	}
+	return preference, err
+}
+
+// IsNotificationEnabled reports whether a user wants notifications of the given category.
+// Lookup failures err on the side of not notifying.
+func IsNotificationEnabled(userID uuid.UUID, category string) bool {
+	preference, err := GetNotificationPreference(userID)
+	if err != nil {
+		return false
+	}
+
+	switch category {
+	case NotificationPriceDrop:
+		return preference.PriceDrop
+	case NotificationBackInStock:
+		return preference.BackInStock
+	case NotificationOrderStatus:
+		return preference.OrderStatus
+	case NotificationDigest:
+		return preference.DigestFrequency != "none"
+	default:
+		return true
+	}
+}
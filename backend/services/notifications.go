@@ -0,0 +1,63 @@
+package services
+
+import (
+	"fmt"
+	"log"
+
+	"bachelor_backend/database"
+	"bachelor_backend/models"
+
+	"github.com/google/uuid"
+)
+
+// CreateNotification stores an in-app notification for a user if their
+// preferences allow notifications of this type. It reports whether one was created.
+// Features should normally go through NotificationDispatcherInstance instead.
+func CreateNotification(userID uuid.UUID, notificationType, title, body string, metadata map[string]interface{}) (bool, error) {
+	if !IsNotificationEnabled(userID, notificationType) {
+		return false, nil
+	}
+
+	notification := models.Notification{
+		UserID:   userID,
+		Type:     notificationType,
+		Title:    title,
+		Body:     body,
+		Metadata: metadata,
+	}
+
+	if err := database.DB.Create(&notification).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// NotifyBackInStock notifies every user who favorited the product that it is available again
+func NotifyBackInStock(product models.Product) {
+	var userIDs []uuid.UUID
+	if err := database.DB.Model(&models.Favorite{}).
+		Where("product_id = ?", product.ID).
+		Distinct("user_id").
+		Pluck("user_id", &userIDs).Error; err != nil {
+		log.Printf("Failed to load users for back-in-stock notification: %v", err)
+		return
+	}
+
+	title := fmt.Sprintf("%s is back in stock", product.Name)
+	body := fmt.Sprintf("%s from your favorites is available again (%d in stock).", product.Name, product.Stock)
+	metadata := map[string]interface{}{"product_id": product.ID}
+
+	queued := 0
+	for _, userID := range userIDs {
+		if err := NotificationDispatcherInstance.Enqueue(userID, NotificationBackInStock, title, body, metadata); err != nil {
+			log.Printf("Failed to queue back-in-stock notification for user %s: %v", userID, err)
+			continue
+		}
+		queued++
+	}
+
+	if queued > 0 {
+		log.Printf("Queued %d back-in-stock notifications for product %s", queued, product.ID)
+	}
+}
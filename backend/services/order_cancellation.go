@@ -0,0 +1,40 @@
+package services
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"bachelor_backend/models"
+)
+
+// defaultCancellationGraceMinutes is used when ORDER_CANCEL_GRACE_MINUTES is not set
+const defaultCancellationGraceMinutes = 30
+
+// CancellationGracePeriod returns how long after creation a processing order may still be cancelled
+func CancellationGracePeriod() time.Duration {
+	if value := os.Getenv("ORDER_CANCEL_GRACE_MINUTES"); value != "" {
+		if minutes, err := strconv.Atoi(value); err == nil && minutes >= 0 {
+			return time.Duration(minutes) * time.Minute
+		}
+	}
+	return defaultCancellationGraceMinutes * time.Minute
+}
+
+// CanCancelOrder reports whether an order may be cancelled at the given time.
+// Pending orders can always be cancelled; processing orders only within the grace period.
+// When cancellation is not allowed the returned string explains why.
+func CanCancelOrder(order models.Order, at time.Time) (bool, string) {
+	switch order.Status {
+	case "pending":
+		return true, ""
+	case "processing":
+		grace := CancellationGracePeriod()
+		if at.Sub(order.CreatedAt) <= grace {
+			return true, ""
+		}
+		return false, "Cancellation grace period of " + grace.String() + " has expired"
+	default:
+		return false, "Orders with status '" + order.Status + "' cannot be cancelled"
+	}
+}
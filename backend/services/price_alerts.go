@@ -0,0 +1,141 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/pkg/money"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// priceAlertLookback bounds how far back the scanner looks for price drops
+const priceAlertLookback = 24 * time.Hour
+
+// PriceAlertScanner periodically alerts users when a favorited product's price drops
+type PriceAlertScanner struct {
+	ticker    *time.Ticker
+	stopChan  chan bool
+	isRunning bool
+	lastRun   time.Time
+}
+
+// PriceDropAlert is a newly recorded price alert together with the product name
+type PriceDropAlert struct {
+	UserID      uuid.UUID
+	ProductID   uuid.UUID
+	ProductName string
+	OldPrice    money.Cents
+	NewPrice    money.Cents
+}
+
+// NewPriceAlertScanner creates a new price alert scanner
+func NewPriceAlertScanner() *PriceAlertScanner {
+	return &PriceAlertScanner{
+		stopChan:  make(chan bool),
+		isRunning: false,
+	}
+}
+
+// Start begins the periodic scan
+func (ps *PriceAlertScanner) Start(intervalMinutes int) {
+	if ps.isRunning {
+		log.Println("Price alert scanner is already running")
+		return
+	}
+
+	ps.ticker = time.NewTicker(time.Duration(intervalMinutes) * time.Minute)
+	ps.isRunning = true
+
+	log.Printf("Starting price alert scanner with %d minute intervals", intervalMinutes)
+
+	go func() {
+		ps.runScan()
+
+		for {
+			select {
+			case <-ps.ticker.C:
+				ps.runScan()
+			case <-ps.stopChan:
+				ps.ticker.Stop()
+				ps.isRunning = false
+				log.Println("Price alert scanner stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic scan
+func (ps *PriceAlertScanner) Stop() {
+	if !ps.isRunning {
+		return
+	}
+
+	ps.stopChan <- true
+}
+
+// runScan records alerts for new price drops and queues their notifications
+func (ps *PriceAlertScanner) runScan() {
+	alerts, err := RecordPriceDropAlerts(database.DB, time.Now().Add(-priceAlertLookback))
+	ps.lastRun = time.Now()
+	if err != nil {
+		log.Printf("Price alert scan failed: %v", err)
+		return
+	}
+
+	for _, alert := range alerts {
+		title := fmt.Sprintf("%s is now cheaper", alert.ProductName)
+		body := fmt.Sprintf("%s from your favorites dropped from $%s to $%s.", alert.ProductName, alert.OldPrice, alert.NewPrice)
+		metadata := map[string]interface{}{
+			"product_id": alert.ProductID,
+			"old_price":  alert.OldPrice,
+			"new_price":  alert.NewPrice,
+		}
+		if err := NotificationDispatcherInstance.Enqueue(alert.UserID, NotificationPriceDrop, title, body, metadata); err != nil {
+			log.Printf("Failed to queue price drop notification for user %s: %v", alert.UserID, err)
+		}
+	}
+
+	if len(alerts) > 0 {
+		log.Printf("Price alert scan completed: %d alerts queued", len(alerts))
+	}
+}
+
+// RecordPriceDropAlerts creates a price alert for every user who had favorited a product
+// before its price dropped, for drops since the given time. Each user is alerted at most
+// once per price change; only alerts created by this call are returned.
+func RecordPriceDropAlerts(db *gorm.DB, since time.Time) ([]PriceDropAlert, error) {
+	var alerts []PriceDropAlert
+	err := db.Raw(`
+		WITH inserted AS (
+			INSERT INTO price_alerts (user_id, product_id, price_history_id, old_price, new_price, created_at)
+			SELECT f.user_id, ph.product_id, ph.id, ph.old_price, ph.new_price, NOW()
+			FROM price_histories ph
+			JOIN favorites f ON f.product_id = ph.product_id AND f.created_at <= ph.changed_at
+			JOIN products p ON p.id = ph.product_id AND p.deleted_at IS NULL
+			WHERE ph.new_price < ph.old_price AND ph.changed_at >= ?
+			ON CONFLICT (user_id, price_history_id) DO NOTHING
+			RETURNING user_id, product_id, old_price, new_price
+		)
+		SELECT inserted.user_id, inserted.product_id, p.name AS product_name, inserted.old_price, inserted.new_price
+		FROM inserted
+		JOIN products p ON p.id = inserted.product_id
+	`, since).Scan(&alerts).Error
+	return alerts, err
+}
+
+// GetStatus returns the current status of the price alert scanner
+func (ps *PriceAlertScanner) GetStatus() map[string]interface{} {
+	return map[string]interface{}{
+		"is_running":   ps.isRunning,
+		"last_run":     ps.lastRun.Format(time.RFC3339),
+		"service_name": "price_alert_scanner",
+	}
+}
+
+// Global price alert scanner instance
+var PriceAlertScannerInstance = NewPriceAlertScanner()
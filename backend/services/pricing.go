@@ -0,0 +1,320 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"bachelor_backend/models"
+	"bachelor_backend/pkg/money"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Discount stacking policies decide what happens when several automatic discounts match one line
+const (
+	StackingPolicyBest      = "best"      // Apply only the single largest discount
+	StackingPolicyStack     = "stack"     // Apply every discount, capped at a share of the line subtotal
+	StackingPolicyExclusive = "exclusive" // Product discounts beat category discounts, then the largest wins
+)
+
+// DiscountStacking is the configured discount stacking policy
+type DiscountStacking struct {
+	Policy     string  `json:"policy"`
+	MaxPercent float64 `json:"max_percent,omitempty"` // Cap on the combined discount for the stack policy
+}
+
+// LoadDiscountStacking reads the stacking policy from the environment
+// (DISCOUNT_STACKING_POLICY, default best; DISCOUNT_STACK_MAX_PERCENT, default 50)
+func LoadDiscountStacking() DiscountStacking {
+	stacking := DiscountStacking{Policy: StackingPolicyBest}
+
+	if value := os.Getenv("DISCOUNT_STACKING_POLICY"); value != "" {
+		switch policy := strings.ToLower(value); policy {
+		case StackingPolicyBest, StackingPolicyStack, StackingPolicyExclusive:
+			stacking.Policy = policy
+		default:
+			log.Printf("Warning: Invalid DISCOUNT_STACKING_POLICY: %s, using fallback: %s", value, StackingPolicyBest)
+		}
+	}
+
+	if stacking.Policy == StackingPolicyStack {
+		stacking.MaxPercent = math.Min(getEnvFloat("DISCOUNT_STACK_MAX_PERCENT", 50), 100)
+	}
+	return stacking
+}
+
+// AppliedDiscount describes a discount that was applied to a price calculation
+type AppliedDiscount struct {
+	DiscountID    uuid.UUID   `json:"discount_id"`
+	Scope         string      `json:"scope"`
+	DiscountType  string      `json:"discount_type"`
+	DiscountValue float64     `json:"discount_value"`       // Percentage, for percentage discounts
+	AmountOff     money.Cents `json:"amount_off,omitempty"` // Fixed amount, for fixed amount discounts
+	Amount        money.Cents `json:"amount"`
+	Reason        string      `json:"reason"`
+}
+
+// SkippedDiscount describes a matching discount that could not be applied
+type SkippedDiscount struct {
+	DiscountID uuid.UUID `json:"discount_id"`
+	Reason     string    `json:"reason"`
+}
+
+// PriceQuote represents the effective price of a product for a given quantity
+type PriceQuote struct {
+	ProductID        uuid.UUID         `json:"product_id"`
+	Quantity         int               `json:"quantity"`
+	BaseUnitPrice    money.Cents       `json:"base_unit_price"`
+	UnitPrice        money.Cents       `json:"unit_price"`
+	Subtotal         money.Cents       `json:"subtotal"`
+	DiscountTotal    money.Cents       `json:"discount_total"`
+	Total            money.Cents       `json:"total"`
+	StackingPolicy   string            `json:"stacking_policy"` // How overlapping discounts were combined
+	AppliedDiscounts []AppliedDiscount `json:"applied_discounts"`
+	SkippedDiscounts []SkippedDiscount `json:"skipped_discounts,omitempty"`
+}
+
+// FindActiveDiscounts returns the automatic discounts whose date window contains at for a
+// product, either targeting the product directly or its category. Coupon discounts are excluded.
+func FindActiveDiscounts(db *gorm.DB, product models.Product, at time.Time) ([]models.Discount, error) {
+	var discounts []models.Discount
+	err := db.Where("start_date <= ? AND end_date >= ? AND code IS NULL", at, at).
+		Where("product_id = ? OR category = ?", product.ID, product.Category).
+		Find(&discounts).Error
+	return discounts, err
+}
+
+// DiscountScope reports whether a discount targets a product or a category
+func DiscountScope(discount models.Discount) string {
+	if discount.ProductID != nil {
+		return "product"
+	}
+	return "category"
+}
+
+// ErrDiscountUsageLimitReached is returned when a discount has no uses left
+var ErrDiscountUsageLimitReached = errors.New("discount usage limit reached")
+
+// CalculateDiscountAmount returns how much a discount takes off the given
+// subtotal, or an explanation of why it does not apply
+func CalculateDiscountAmount(discount models.Discount, subtotal money.Cents) (money.Cents, string) {
+	return calculateDiscount(discount, subtotal, subtotal)
+}
+
+// calculateDiscount applies a discount to a line subtotal, checking the minimum
+// order amount against the subtotal of the whole order
+func calculateDiscount(discount models.Discount, subtotal, orderSubtotal money.Cents) (money.Cents, string) {
+	if discount.UsageLimit > 0 && discount.UsageCount >= discount.UsageLimit {
+		return 0, "usage limit reached"
+	}
+	if discount.MinOrderAmount > 0 && orderSubtotal < discount.MinOrderAmount {
+		return 0, fmt.Sprintf("requires a minimum order of %s", discount.MinOrderAmount)
+	}
+
+	var amount money.Cents
+	switch discount.DiscountType {
+	case "percentage":
+		amount = subtotal.Percent(discount.DiscountValue)
+	case "fixed_amount":
+		amount = discount.AmountOff
+	default:
+		return 0, "unsupported discount type"
+	}
+
+	if discount.MaxDiscountAmount > 0 && amount > discount.MaxDiscountAmount {
+		amount = discount.MaxDiscountAmount
+	}
+	if amount > subtotal {
+		amount = subtotal
+	}
+
+	return amount, ""
+}
+
+// describeDiscount builds a human readable reason for an applied discount
+func describeDiscount(discount models.Discount, amount money.Cents) string {
+	var reason string
+	if discount.DiscountType == "percentage" {
+		reason = fmt.Sprintf("%.0f%% off", discount.DiscountValue)
+	} else {
+		reason = fmt.Sprintf("%s off", discount.AmountOff)
+	}
+
+	if discount.ProductID != nil {
+		reason += " this product"
+	} else if discount.Category != nil {
+		reason += " in category " + *discount.Category
+	}
+
+	if discount.MaxDiscountAmount > 0 && amount >= discount.MaxDiscountAmount {
+		reason += fmt.Sprintf(" (capped at %s)", discount.MaxDiscountAmount)
+	}
+
+	return reason
+}
+
+// QuoteOrderLine prices one line of an order, combining its active discounts under the
+// configured stacking policy. Minimum order amounts are checked against orderSubtotal.
+func QuoteOrderLine(db *gorm.DB, product models.Product, quantity int, orderSubtotal money.Cents) (*PriceQuote, error) {
+	discounts, err := FindActiveDiscounts(db, product, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return PriceLine(product, quantity, orderSubtotal, discounts, LoadDiscountStacking()), nil
+}
+
+// PriceLine prices one line of an order from the discounts matching it, combining
+// them under the given stacking policy
+func PriceLine(product models.Product, quantity int, orderSubtotal money.Cents, discounts []models.Discount, stacking DiscountStacking) *PriceQuote {
+	subtotal := product.Price.Mul(quantity)
+	quote := &PriceQuote{
+		ProductID:        product.ID,
+		Quantity:         quantity,
+		BaseUnitPrice:    product.Price,
+		Subtotal:         subtotal,
+		StackingPolicy:   stacking.Policy,
+		AppliedDiscounts: []AppliedDiscount{},
+	}
+
+	var candidates []AppliedDiscount
+	for _, discount := range discounts {
+		amount, skipReason := calculateDiscount(discount, subtotal, orderSubtotal)
+		if skipReason != "" {
+			quote.SkippedDiscounts = append(quote.SkippedDiscounts, SkippedDiscount{
+				DiscountID: discount.ID,
+				Reason:     skipReason,
+			})
+			continue
+		}
+
+		candidates = append(candidates, AppliedDiscount{
+			DiscountID:    discount.ID,
+			Scope:         DiscountScope(discount),
+			DiscountType:  discount.DiscountType,
+			DiscountValue: discount.DiscountValue,
+			AmountOff:     discount.AmountOff,
+			Amount:        amount,
+			Reason:        describeDiscount(discount, amount),
+		})
+	}
+
+	applied, skipped := combineDiscounts(candidates, subtotal, stacking)
+	quote.AppliedDiscounts = append(quote.AppliedDiscounts, applied...)
+	quote.SkippedDiscounts = append(quote.SkippedDiscounts, skipped...)
+	for _, discount := range applied {
+		quote.DiscountTotal += discount.Amount
+	}
+	quote.DiscountTotal = money.Min(quote.DiscountTotal, subtotal)
+
+	quote.Total = subtotal - quote.DiscountTotal
+	if quantity > 0 {
+		quote.UnitPrice = quote.Total.Div(quantity)
+	}
+
+	return quote
+}
+
+// combineDiscounts resolves overlapping discounts on a line of the given subtotal
+// under a stacking policy, returning the discounts applied and those passed over
+func combineDiscounts(candidates []AppliedDiscount, subtotal money.Cents, stacking DiscountStacking) ([]AppliedDiscount, []SkippedDiscount) {
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	// Largest first; ties go to product discounts, which are more specific
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Amount != candidates[j].Amount {
+			return candidates[i].Amount > candidates[j].Amount
+		}
+		return candidates[i].Scope == "product" && candidates[j].Scope != "product"
+	})
+
+	var skipped []SkippedDiscount
+	switch stacking.Policy {
+	case StackingPolicyStack:
+		remaining := subtotal.Percent(stacking.MaxPercent)
+		var applied []AppliedDiscount
+		for _, candidate := range candidates {
+			if remaining <= 0 {
+				skipped = append(skipped, SkippedDiscount{
+					DiscountID: candidate.DiscountID,
+					Reason:     fmt.Sprintf("combined discounts are capped at %.0f%%", stacking.MaxPercent),
+				})
+				continue
+			}
+			if candidate.Amount > remaining {
+				candidate.Amount = remaining
+				candidate.Reason += fmt.Sprintf(" (reduced to stay within the %.0f%% stacking cap)", stacking.MaxPercent)
+			}
+			remaining -= candidate.Amount
+			applied = append(applied, candidate)
+		}
+		return applied, skipped
+
+	case StackingPolicyExclusive:
+		hasProductDiscount := slices.ContainsFunc(candidates, func(candidate AppliedDiscount) bool {
+			return candidate.Scope == "product"
+		})
+		var best *AppliedDiscount
+		for i, candidate := range candidates {
+			switch {
+			case hasProductDiscount && candidate.Scope != "product":
+				skipped = append(skipped, SkippedDiscount{
+					DiscountID: candidate.DiscountID,
+					Reason:     "a product discount takes precedence over category discounts",
+				})
+			case best == nil:
+				best = &candidates[i]
+			default:
+				skipped = append(skipped, SkippedDiscount{
+					DiscountID: candidate.DiscountID,
+					Reason:     "a larger discount applies",
+				})
+			}
+		}
+		return []AppliedDiscount{*best}, skipped
+
+	default:
+		for _, candidate := range candidates[1:] {
+			skipped = append(skipped, SkippedDiscount{
+				DiscountID: candidate.DiscountID,
+				Reason:     "a larger discount applies",
+			})
+		}
+		return candidates[:1], skipped
+	}
+}
+
+// RedeemDiscount atomically records one use of a discount, failing with
+// ErrDiscountUsageLimitReached once its usage limit has been used up
+func RedeemDiscount(tx *gorm.DB, discountID uuid.UUID) error {
+	result := tx.Model(&models.Discount{}).
+		Where("id = ? AND (usage_limit = 0 OR usage_count < usage_limit)", discountID).
+		Update("usage_count", gorm.Expr("usage_count + 1"))
+	if result.Error != nil {
+		return fmt.Errorf("failed to redeem discount: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrDiscountUsageLimitReached
+	}
+	return nil
+}
+
+// ReleaseDiscount gives back one use of a discount, for orders that are cancelled or
+// refunded after redeeming it
+func ReleaseDiscount(tx *gorm.DB, discountID uuid.UUID) error {
+	if err := tx.Model(&models.Discount{}).
+		Where("id = ?", discountID).
+		Update("usage_count", gorm.Expr("GREATEST(usage_count - 1, 0)")).Error; err != nil {
+		return fmt.Errorf("failed to release discount: %w", err)
+	}
+	return nil
+}
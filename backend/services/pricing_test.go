@@ -0,0 +1,147 @@
+package services
+
+import (
+	"testing"
+
+	"bachelor_backend/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+// Overlapping candidates on a 100.00 line, two per scope
+var (
+	categoryLarge = AppliedDiscount{DiscountID: uuid.MustParse("00000000-0000-0000-0000-000000000001"), Scope: "category", Amount: 3000}
+	categorySmall = AppliedDiscount{DiscountID: uuid.MustParse("00000000-0000-0000-0000-000000000002"), Scope: "category", Amount: 1000}
+	productSmall  = AppliedDiscount{DiscountID: uuid.MustParse("00000000-0000-0000-0000-000000000003"), Scope: "product", Amount: 2000}
+	productTie    = AppliedDiscount{DiscountID: uuid.MustParse("00000000-0000-0000-0000-000000000004"), Scope: "product", Amount: 3000}
+)
+
+const lineSubtotal money.Cents = 10000
+
+type combineCase struct {
+	name        string
+	candidates  []AppliedDiscount
+	wantApplied map[uuid.UUID]money.Cents
+	wantSkipped []uuid.UUID
+}
+
+func runCombineCases(t *testing.T, stacking DiscountStacking, tests []combineCase) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates := append([]AppliedDiscount(nil), tt.candidates...)
+			applied, skipped := combineDiscounts(candidates, lineSubtotal, stacking)
+
+			if len(applied) != len(tt.wantApplied) {
+				t.Fatalf("applied %d discounts, want %d: %+v", len(applied), len(tt.wantApplied), applied)
+			}
+			for _, discount := range applied {
+				want, ok := tt.wantApplied[discount.DiscountID]
+				if !ok {
+					t.Errorf("discount %s applied, want it skipped", discount.DiscountID)
+					continue
+				}
+				if discount.Amount != want {
+					t.Errorf("discount %s amount = %d, want %d", discount.DiscountID, discount.Amount, want)
+				}
+			}
+
+			if len(skipped) != len(tt.wantSkipped) {
+				t.Fatalf("skipped %d discounts, want %d: %+v", len(skipped), len(tt.wantSkipped), skipped)
+			}
+			skippedIDs := make(map[uuid.UUID]bool, len(skipped))
+			for _, discount := range skipped {
+				if discount.Reason == "" {
+					t.Errorf("discount %s skipped without a reason", discount.DiscountID)
+				}
+				skippedIDs[discount.DiscountID] = true
+			}
+			for _, id := range tt.wantSkipped {
+				if !skippedIDs[id] {
+					t.Errorf("discount %s not reported as skipped", id)
+				}
+			}
+		})
+	}
+}
+
+func TestCombineDiscountsBest(t *testing.T) {
+	runCombineCases(t, DiscountStacking{Policy: StackingPolicyBest}, []combineCase{
+		{
+			name:        "none",
+			candidates:  nil,
+			wantApplied: map[uuid.UUID]money.Cents{},
+		},
+		{
+			name:        "single",
+			candidates:  []AppliedDiscount{categorySmall},
+			wantApplied: map[uuid.UUID]money.Cents{categorySmall.DiscountID: 1000},
+		},
+		{
+			name:        "largest wins over product scope",
+			candidates:  []AppliedDiscount{categorySmall, productSmall, categoryLarge},
+			wantApplied: map[uuid.UUID]money.Cents{categoryLarge.DiscountID: 3000},
+			wantSkipped: []uuid.UUID{categorySmall.DiscountID, productSmall.DiscountID},
+		},
+		{
+			name:        "tie goes to the product discount",
+			candidates:  []AppliedDiscount{categoryLarge, productTie},
+			wantApplied: map[uuid.UUID]money.Cents{productTie.DiscountID: 3000},
+			wantSkipped: []uuid.UUID{categoryLarge.DiscountID},
+		},
+	})
+}
+
+func TestCombineDiscountsStack(t *testing.T) {
+	runCombineCases(t, DiscountStacking{Policy: StackingPolicyStack, MaxPercent: 50}, []combineCase{
+		{
+			name:        "within the cap",
+			candidates:  []AppliedDiscount{categorySmall, productSmall},
+			wantApplied: map[uuid.UUID]money.Cents{productSmall.DiscountID: 2000, categorySmall.DiscountID: 1000},
+		},
+		{
+			name:       "discount past the cap skipped",
+			candidates: []AppliedDiscount{categorySmall, productSmall, categoryLarge},
+			// 30.00 + 20.00 reaches the 50% cap, so the 10.00 discount no longer fits
+			wantApplied: map[uuid.UUID]money.Cents{categoryLarge.DiscountID: 3000, productSmall.DiscountID: 2000},
+			wantSkipped: []uuid.UUID{categorySmall.DiscountID},
+		},
+		{
+			name:        "last discount reduced to the cap",
+			candidates:  []AppliedDiscount{productTie, categoryLarge},
+			wantApplied: map[uuid.UUID]money.Cents{productTie.DiscountID: 3000, categoryLarge.DiscountID: 2000},
+		},
+	})
+
+	runCombineCases(t, DiscountStacking{Policy: StackingPolicyStack, MaxPercent: 20}, []combineCase{
+		{
+			name:        "largest discount capped on its own",
+			candidates:  []AppliedDiscount{categoryLarge, categorySmall},
+			wantApplied: map[uuid.UUID]money.Cents{categoryLarge.DiscountID: 2000},
+			wantSkipped: []uuid.UUID{categorySmall.DiscountID},
+		},
+	})
+}
+
+func TestCombineDiscountsExclusive(t *testing.T) {
+	runCombineCases(t, DiscountStacking{Policy: StackingPolicyExclusive}, []combineCase{
+		{
+			name:        "category discounts only",
+			candidates:  []AppliedDiscount{categorySmall, categoryLarge},
+			wantApplied: map[uuid.UUID]money.Cents{categoryLarge.DiscountID: 3000},
+			wantSkipped: []uuid.UUID{categorySmall.DiscountID},
+		},
+		{
+			name:        "product discount beats a larger category discount",
+			candidates:  []AppliedDiscount{categoryLarge, productSmall, categorySmall},
+			wantApplied: map[uuid.UUID]money.Cents{productSmall.DiscountID: 2000},
+			wantSkipped: []uuid.UUID{categoryLarge.DiscountID, categorySmall.DiscountID},
+		},
+		{
+			name:        "largest of several product discounts",
+			candidates:  []AppliedDiscount{productSmall, productTie, categoryLarge},
+			wantApplied: map[uuid.UUID]money.Cents{productTie.DiscountID: 3000},
+			wantSkipped: []uuid.UUID{productSmall.DiscountID, categoryLarge.DiscountID},
+		},
+	})
+}
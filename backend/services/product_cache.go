@@ -0,0 +1,112 @@
+package services
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ProductListCache is a short-lived in-memory cache for product listing responses
+type ProductListCache struct {
+	mu      sync.RWMutex
+	entries map[string]productListCacheEntry
+	ttl     time.Duration
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+	evicted atomic.Uint64
+}
+
+type productListCacheEntry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+// NewProductListCache creates a new product list cache with the given TTL
+func NewProductListCache(ttl time.Duration) *ProductListCache {
+	return &ProductListCache{
+		entries: make(map[string]productListCacheEntry),
+		ttl:     ttl,
+	}
+}
+
+// Get returns a cached value if present and not expired
+func (pc *ProductListCache) Get(key string) (interface{}, bool) {
+	pc.mu.RLock()
+	entry, exists := pc.entries[key]
+	pc.mu.RUnlock()
+
+	if !exists || time.Now().After(entry.expiresAt) {
+		pc.misses.Add(1)
+		return nil, false
+	}
+
+	pc.hits.Add(1)
+	return entry.value, true
+}
+
+// Set stores a value under the given key for the cache TTL
+func (pc *ProductListCache) Set(key string, value interface{}) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	// Drop expired entries opportunistically so the map doesn't grow unbounded
+	now := time.Now()
+	for k, entry := range pc.entries {
+		if now.After(entry.expiresAt) {
+			delete(pc.entries, k)
+		}
+	}
+
+	pc.entries[key] = productListCacheEntry{
+		value:     value,
+		expiresAt: now.Add(pc.ttl),
+	}
+}
+
+// InvalidateAll drops every cached entry. Called whenever product data or stock changes.
+func (pc *ProductListCache) InvalidateAll() {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.evicted.Add(uint64(len(pc.entries)))
+	pc.entries = make(map[string]productListCacheEntry)
+}
+
+// Stats returns cache hit/miss statistics
+func (pc *ProductListCache) Stats() map[string]interface{} {
+	pc.mu.RLock()
+	size := len(pc.entries)
+	pc.mu.RUnlock()
+
+	hits := pc.hits.Load()
+	misses := pc.misses.Load()
+
+	hitRate := 0.0
+	if total := hits + misses; total > 0 {
+		hitRate = float64(hits) / float64(total) * 100
+	}
+
+	return map[string]interface{}{
+		"hits":        hits,
+		"misses":      misses,
+		"hit_rate":    hitRate,
+		"entries":     size,
+		"invalidated": pc.evicted.Load(),
+		"ttl_seconds": pc.ttl.Seconds(),
+	}
+}
+
+// productListCacheTTL reads the cache TTL from the environment
+func productListCacheTTL() time.Duration {
+	if value := os.Getenv("PRODUCT_CACHE_TTL_SECONDS"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return 30 * time.Second
+}
+
+// Global product list cache instance
+var ProductListCacheInstance = NewProductListCache(productListCacheTTL())
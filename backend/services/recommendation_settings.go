@@ -0,0 +1,16 @@
+package services
+
+import "time"
+
+// RecommendationDismissalPeriod is how long a dismissed product is left out of a user's
+// recommendations (RECOMMENDATION_DISMISS_DAYS, default 30). Dismissals expire so a
+// product is never hidden for good.
+func RecommendationDismissalPeriod() time.Duration {
+	return time.Duration(getEnvInt("RECOMMENDATION_DISMISS_DAYS", 30)) * 24 * time.Hour
+}
+
+// RecommendationStaleness is how old a user's stored recommendations may get before
+// they are regenerated (RECOMMENDATION_STALE_HOURS, default 6)
+func RecommendationStaleness() time.Duration {
+	return time.Duration(getEnvInt("RECOMMENDATION_STALE_HOURS", 6)) * time.Hour
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/pkg/money"
+)
+
+// forecastZ95 is the z-score of a two-sided 95% interval
+const forecastZ95 = 1.96
+
+// RevenueForecast is a forward projection of daily revenue with confidence bounds
+type RevenueForecast struct {
+	History         []DailyRevenue         `json:"history"`
+	Forecast        []RevenueForecastPoint `json:"forecast"`
+	TotalForecast   float64                `json:"total_forecast"`
+	Model           string                 `json:"model"`
+	Source          string                 `json:"source"` // 'ml_service' or 'linear_trend'
+	ConfidenceLevel float64                `json:"confidence_level"`
+	GeneratedAt     time.Time              `json:"generated_at"`
+}
+
+type revenueForecastEntry struct {
+	forecast  *RevenueForecast
+	expiresAt time.Time
+}
+
+var (
+	revenueForecastMu    sync.Mutex
+	revenueForecastCache = make(map[string]revenueForecastEntry)
+)
+
+// ForecastRevenue projects store revenue horizonDays ahead from the daily revenue
+// history (FORECAST_HISTORY_DAYS, default 90). The ML service is asked first; when it
+// is unavailable a linear trend is fitted instead. ML forecasts are cached until the
+// end of the UTC day; fallback forecasts only for the analytics cache TTL, so the ML
+// projection is picked up again soon after the service recovers.
+func ForecastRevenue(horizonDays int) (*RevenueForecast, error) {
+	now := time.Now()
+	key := now.UTC().Format("2006-01-02") + ":" + strconv.Itoa(horizonDays)
+
+	revenueForecastMu.Lock()
+	entry, exists := revenueForecastCache[key]
+	revenueForecastMu.Unlock()
+	if exists && now.Before(entry.expiresAt) {
+		return entry.forecast, nil
+	}
+
+	history, err := loadDailyRevenue(getEnvInt("FORECAST_HISTORY_DAYS", 90))
+	if err != nil {
+		return nil, err
+	}
+
+	forecast := &RevenueForecast{History: history, GeneratedAt: now}
+	expiresAt := StartOfDayUTC(now).AddDate(0, 0, 1)
+
+	if result, err := MLService.ForecastRevenue(history, horizonDays); err == nil && len(result.Forecast) > 0 {
+		forecast.Forecast = result.Forecast
+		forecast.Model = result.Model
+		forecast.ConfidenceLevel = result.ConfidenceLevel
+		forecast.Source = "ml_service"
+	} else {
+		if err != nil {
+			log.Printf("ML revenue forecast unavailable, using linear trend: %v", err)
+		}
+		forecast.Forecast = linearRevenueForecast(history, horizonDays)
+		forecast.Model = "linear_regression"
+		forecast.ConfidenceLevel = 0.95
+		forecast.Source = "linear_trend"
+		if fallbackExpiry := now.Add(AnalyticsCacheInstance.TTL()); fallbackExpiry.Before(expiresAt) {
+			expiresAt = fallbackExpiry
+		}
+	}
+
+	for _, point := range forecast.Forecast {
+		forecast.TotalForecast += point.Revenue
+	}
+
+	revenueForecastMu.Lock()
+	for k, e := range revenueForecastCache {
+		if now.After(e.expiresAt) {
+			delete(revenueForecastCache, k)
+		}
+	}
+	revenueForecastCache[key] = revenueForecastEntry{forecast: forecast, expiresAt: expiresAt}
+	revenueForecastMu.Unlock()
+
+	return forecast, nil
+}
+
+// loadDailyRevenue returns the store's revenue for each of the given number of days
+// before today, from the daily rollups. Days without sales are included as zero.
+func loadDailyRevenue(days int) ([]DailyRevenue, error) {
+	today := StartOfDayUTC(time.Now())
+	from := today.AddDate(0, 0, -days)
+
+	var rows []struct {
+		Date    time.Time
+		Revenue money.Cents
+	}
+	if err := database.DB.Table("daily_user_stats").
+		Select("date, COALESCE(SUM(spend), 0) AS revenue").
+		Where("date >= ? AND date < ?", from, today).
+		Group("date").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	revenueByDay := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		revenueByDay[row.Date.Format("2006-01-02")] = row.Revenue.Float64()
+	}
+
+	history := make([]DailyRevenue, 0, days)
+	for day := from; day.Before(today); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		history = append(history, DailyRevenue{Date: date, Revenue: revenueByDay[date]})
+	}
+	return history, nil
+}
+
+// linearRevenueForecast fits a least-squares line through the history and extends it
+// horizonDays past the last day, with 95% prediction intervals. Revenue is never
+// projected below zero.
+func linearRevenueForecast(history []DailyRevenue, horizonDays int) []RevenueForecastPoint {
+	last := StartOfDayUTC(time.Now()).AddDate(0, 0, -1)
+	if len(history) > 0 {
+		if parsed, err := time.Parse("2006-01-02", history[len(history)-1].Date); err == nil {
+			last = parsed
+		}
+	}
+
+	n := float64(len(history))
+	var slope, intercept, residualStdDev, meanX, sxx float64
+	if n > 0 {
+		var sumX, sumY float64
+		for i, day := range history {
+			sumX += float64(i)
+			sumY += day.Revenue
+		}
+		meanX = sumX / n
+		meanY := sumY / n
+
+		var sxy float64
+		for i, day := range history {
+			dx := float64(i) - meanX
+			sxx += dx * dx
+			sxy += dx * (day.Revenue - meanY)
+		}
+		if sxx > 0 {
+			slope = sxy / sxx
+		}
+		intercept = meanY - slope*meanX
+
+		if n > 2 {
+			var sse float64
+			for i, day := range history {
+				residual := day.Revenue - (intercept + slope*float64(i))
+				sse += residual * residual
+			}
+			residualStdDev = math.Sqrt(sse / (n - 2))
+		}
+	}
+
+	forecast := make([]RevenueForecastPoint, 0, horizonDays)
+	for step := 1; step <= horizonDays; step++ {
+		x := n - 1 + float64(step)
+		predicted := intercept + slope*x
+
+		margin := 0.0
+		if n > 2 && sxx > 0 {
+			margin = forecastZ95 * residualStdDev * math.Sqrt(1+1/n+(x-meanX)*(x-meanX)/sxx)
+		}
+
+		forecast = append(forecast, RevenueForecastPoint{
+			Date:       last.AddDate(0, 0, step).Format("2006-01-02"),
+			Revenue:    math.Max(predicted, 0),
+			LowerBound: math.Max(predicted-margin, 0),
+			UpperBound: math.Max(predicted+margin, 0),
+		})
+	}
+	return forecast
+}
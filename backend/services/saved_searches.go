@@ -0,0 +1,179 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/models"
+)
+
+// NotificationSavedSearch is the notification sent when new products match a saved search
+const NotificationSavedSearch = "saved_search"
+
+// maxSavedSearchesPerRun bounds how many saved searches a single run re-checks
+const maxSavedSearchesPerRun = 500
+
+// savedSearchPreviewSize is how many matching products a notification links to
+const savedSearchPreviewSize = 3
+
+// SavedSearchMatcher returns the products created after since that match a saved search,
+// best matches first and at most limit of them, together with the total number of matches
+type SavedSearchMatcher func(search models.SavedSearch, since time.Time, limit int) ([]models.Product, int64, error)
+
+// SavedSearchScanner periodically re-runs saved searches and alerts their owners about new matches
+type SavedSearchScanner struct {
+	ticker    *time.Ticker
+	stopChan  chan bool
+	isRunning bool
+	lastRun   time.Time
+	match     SavedSearchMatcher
+	notified  int64
+	failed    int64
+}
+
+// NewSavedSearchScanner creates a new saved search scanner
+func NewSavedSearchScanner() *SavedSearchScanner {
+	return &SavedSearchScanner{
+		stopChan:  make(chan bool),
+		isRunning: false,
+	}
+}
+
+// SetMatcher registers the function that runs a saved search. It lets the scanner
+// reuse the product search of the handlers without importing them.
+func (ss *SavedSearchScanner) SetMatcher(match SavedSearchMatcher) {
+	ss.match = match
+}
+
+// Start begins the periodic scan
+func (ss *SavedSearchScanner) Start(intervalMinutes int) {
+	if ss.isRunning {
+		log.Println("Saved search scanner is already running")
+		return
+	}
+
+	ss.ticker = time.NewTicker(time.Duration(intervalMinutes) * time.Minute)
+	ss.isRunning = true
+
+	log.Printf("Starting saved search scanner with %d minute intervals", intervalMinutes)
+
+	go func() {
+		ss.runScan()
+
+		for {
+			select {
+			case <-ss.ticker.C:
+				ss.runScan()
+			case <-ss.stopChan:
+				ss.ticker.Stop()
+				ss.isRunning = false
+				log.Println("Saved search scanner stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic scan
+func (ss *SavedSearchScanner) Stop() {
+	if !ss.isRunning {
+		return
+	}
+
+	ss.stopChan <- true
+}
+
+// runScan checks the saved searches that were checked least recently
+func (ss *SavedSearchScanner) runScan() {
+	ss.lastRun = time.Now()
+	if ss.match == nil {
+		log.Println("Saved search scanner has no matcher registered")
+		return
+	}
+
+	var searches []models.SavedSearch
+	if err := database.DB.Where("last_checked < ?", ss.lastRun).
+		Order("last_checked ASC").
+		Limit(maxSavedSearchesPerRun).
+		Find(&searches).Error; err != nil {
+		log.Printf("Failed to load saved searches: %v", err)
+		return
+	}
+
+	notified := 0
+	for _, search := range searches {
+		found, err := ss.CheckSavedSearch(search, ss.lastRun)
+		if err != nil {
+			ss.failed++
+			log.Printf("Failed to check saved search %s: %v", search.ID, err)
+			continue
+		}
+		if found {
+			ss.notified++
+			notified++
+		}
+	}
+
+	if notified > 0 {
+		log.Printf("Saved search scan completed: %d of %d searches had new matches", notified, len(searches))
+	}
+}
+
+// CheckSavedSearch looks for products created since the search's last check, queues a
+// notification to its owner when there are any and advances last_checked to at, which
+// should be taken before the search runs so no product is missed.
+// It reports whether new matches were found.
+func (ss *SavedSearchScanner) CheckSavedSearch(search models.SavedSearch, at time.Time) (bool, error) {
+	products, total, err := ss.match(search, search.LastChecked, savedSearchPreviewSize)
+	if err != nil {
+		return false, err
+	}
+
+	if err := database.DB.Model(&models.SavedSearch{}).
+		Where("id = ?", search.ID).
+		Update("last_checked", at).Error; err != nil {
+		return false, err
+	}
+
+	if total == 0 || len(products) == 0 {
+		return false, nil
+	}
+
+	title := fmt.Sprintf("New results for \"%s\"", search.Query)
+	body := fmt.Sprintf("%s matches your saved search \"%s\".", products[0].Name, search.Query)
+	if total > 1 {
+		body = fmt.Sprintf("%d new products match your saved search \"%s\", including %s.", total, search.Query, products[0].Name)
+	}
+
+	productIDs := make([]string, 0, len(products))
+	for _, product := range products {
+		productIDs = append(productIDs, product.ID.String())
+	}
+	metadata := map[string]interface{}{
+		"saved_search_id": search.ID,
+		"query":           search.Query,
+		"match_count":     total,
+		"product_ids":     productIDs,
+	}
+	if err := NotificationDispatcherInstance.Enqueue(search.UserID, NotificationSavedSearch, title, body, metadata); err != nil {
+		log.Printf("Failed to queue saved search notification for user %s: %v", search.UserID, err)
+	}
+
+	return true, nil
+}
+
+// GetStatus returns the current status of the saved search scanner
+func (ss *SavedSearchScanner) GetStatus() map[string]interface{} {
+	return map[string]interface{}{
+		"is_running":   ss.isRunning,
+		"last_run":     ss.lastRun.Format(time.RFC3339),
+		"notified":     ss.notified,
+		"failed":       ss.failed,
+		"service_name": "saved_search_scanner",
+	}
+}
+
+// Global saved search scanner instance
+var SavedSearchScannerInstance = NewSavedSearchScanner()
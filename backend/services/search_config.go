@@ -0,0 +1,116 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// searchConfigID is the primary key of the single search configuration row
+const searchConfigID = 1
+
+// searchWeightsTTL is how long the ranking weights are kept in memory before they are
+// read again, so changes made through another instance are picked up
+const searchWeightsTTL = time.Minute
+
+// SearchWeights are the ts_rank weights of the parts of a product's search vector:
+// the name (weight A), the category (B) and the description (C). A match in a part
+// counts in proportion to its weight.
+type SearchWeights struct {
+	Name        float64 `json:"name" validate:"min=0,max=1" example:"1"`
+	Category    float64 `json:"category" validate:"min=0,max=1" example:"0.4"`
+	Description float64 `json:"description" validate:"min=0,max=1" example:"0.2"`
+}
+
+// DefaultSearchWeights reads the ranking weights from the environment
+// (SEARCH_WEIGHT_NAME, SEARCH_WEIGHT_CATEGORY and SEARCH_WEIGHT_DESCRIPTION). The
+// defaults are PostgreSQL's own ts_rank weights.
+func DefaultSearchWeights() SearchWeights {
+	return SearchWeights{
+		Name:        min(getEnvFloat("SEARCH_WEIGHT_NAME", 1), 1),
+		Category:    min(getEnvFloat("SEARCH_WEIGHT_CATEGORY", 0.4), 1),
+		Description: min(getEnvFloat("SEARCH_WEIGHT_DESCRIPTION", 0.2), 1),
+	}
+}
+
+// RankArray formats the weights as the {D, C, B, A} array ts_rank takes. Nothing is
+// indexed with weight D, which keeps PostgreSQL's default.
+func (w SearchWeights) RankArray() string {
+	return fmt.Sprintf("{0.1,%g,%g,%g}", w.Description, w.Category, w.Name)
+}
+
+var searchWeightsCache struct {
+	mu       sync.Mutex
+	weights  SearchWeights
+	loadedAt time.Time
+}
+
+// GetSearchConfig returns the admin-set search configuration, or nil when the
+// weights come from the environment
+func GetSearchConfig() (*models.SearchConfig, error) {
+	var config models.SearchConfig
+	if err := database.DB.First(&config, searchConfigID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &config, nil
+}
+
+// LoadSearchWeights returns the ranking weights used by product search: the
+// admin-set weights if there are any, the environment's otherwise
+func LoadSearchWeights() SearchWeights {
+	searchWeightsCache.mu.Lock()
+	defer searchWeightsCache.mu.Unlock()
+
+	if !searchWeightsCache.loadedAt.IsZero() && time.Since(searchWeightsCache.loadedAt) < searchWeightsTTL {
+		return searchWeightsCache.weights
+	}
+
+	weights := DefaultSearchWeights()
+	config, err := GetSearchConfig()
+	if err != nil {
+		log.Printf("Warning: failed to load search config, using default weights: %v", err)
+	} else if config != nil {
+		weights = SearchWeights{
+			Name:        config.NameWeight,
+			Category:    config.CategoryWeight,
+			Description: config.DescriptionWeight,
+		}
+	}
+
+	searchWeightsCache.weights = weights
+	searchWeightsCache.loadedAt = time.Now()
+	return weights
+}
+
+// SaveSearchWeights stores ranking weights set by an admin; searches use them right away
+func SaveSearchWeights(weights SearchWeights, updatedBy uuid.UUID) (models.SearchConfig, error) {
+	config := models.SearchConfig{
+		ID:                searchConfigID,
+		NameWeight:        weights.Name,
+		CategoryWeight:    weights.Category,
+		DescriptionWeight: weights.Description,
+		UpdatedBy:         &updatedBy,
+	}
+	if err := database.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&config).Error; err != nil {
+		return config, err
+	}
+
+	searchWeightsCache.mu.Lock()
+	searchWeightsCache.weights = weights
+	searchWeightsCache.loadedAt = time.Now()
+	searchWeightsCache.mu.Unlock()
+
+	return config, nil
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"bachelor_backend/cache"
+	"bachelor_backend/database"
+	"bachelor_backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SentimentSnapshotTTL is how long a product's stored sentiment is served before it is
+// analyzed again (SENTIMENT_SNAPSHOT_TTL_MINUTES, default 360)
+func SentimentSnapshotTTL() time.Duration {
+	return time.Duration(getEnvInt("SENTIMENT_SNAPSHOT_TTL_MINUTES", 360)) * time.Minute
+}
+
+// sentimentRefreshes holds the products with a background refresh in flight
+var sentimentRefreshes sync.Map
+
+// RefreshSentimentSnapshot analyzes a product's comments with the ML service and
+// stores the result as the product's sentiment snapshot
+func RefreshSentimentSnapshot(productID uuid.UUID) (models.SentimentSnapshot, error) {
+	analysis, err := MLService.AnalyzeProductSentiment(productID)
+	if err != nil {
+		return models.SentimentSnapshot{}, err
+	}
+	return storeSentimentSnapshot(productID, *analysis)
+}
+
+// storeSentimentSnapshot saves an ML sentiment analysis as the product's snapshot
+func storeSentimentSnapshot(productID uuid.UUID, analysis SentimentAnalysisResponse) (models.SentimentSnapshot, error) {
+	snapshot := models.SentimentSnapshot{
+		ProductID:    productID,
+		Score:        analysis.AverageSentiment,
+		Label:        analysis.SentimentTrend,
+		CommentCount: analysis.TotalComments,
+		ComputedAt:   time.Now(),
+	}
+	if snapshot.Label == "" {
+		snapshot.Label = "no_data"
+	}
+
+	if err := database.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&snapshot).Error; err != nil {
+		return models.SentimentSnapshot{}, err
+	}
+
+	// Product pages embed the snapshot
+	cache.Invalidate(cache.ProductKey(productID.String()))
+	return snapshot, nil
+}
+
+// GetSentimentSnapshot returns a product's sentiment snapshot, analyzing the product
+// again when there is none or it is older than SentimentSnapshotTTL. When the ML
+// service fails, an expired snapshot is returned with stale set.
+func GetSentimentSnapshot(productID uuid.UUID) (snapshot models.SentimentSnapshot, stale bool, err error) {
+	err = database.DB.First(&snapshot, "product_id = ?", productID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return snapshot, false, err
+	}
+	found := err == nil
+	if found && time.Since(snapshot.ComputedAt) < SentimentSnapshotTTL() {
+		return snapshot, false, nil
+	}
+
+	refreshed, err := RefreshSentimentSnapshot(productID)
+	if err != nil {
+		if found {
+			log.Printf("Warning: failed to refresh sentiment for product %s, serving snapshot from %s: %v",
+				productID, snapshot.ComputedAt.Format(time.RFC3339), err)
+			return snapshot, true, nil
+		}
+		return snapshot, false, err
+	}
+	return refreshed, false, nil
+}
+
+// GetSentimentSnapshots returns the sentiment snapshots of several products, keyed by
+// product ID. Products without a fresh snapshot are analyzed in one batch; if that
+// fails for a product, its expired snapshot is returned if it has one and it is
+// left out otherwise.
+func GetSentimentSnapshots(productIDs []uuid.UUID) (map[uuid.UUID]models.SentimentSnapshot, error) {
+	var stored []models.SentimentSnapshot
+	if err := database.DB.Where("product_id IN ?", productIDs).Find(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	snapshots := make(map[uuid.UUID]models.SentimentSnapshot, len(productIDs))
+	for _, snapshot := range stored {
+		snapshots[snapshot.ProductID] = snapshot
+	}
+
+	var outdated []uuid.UUID
+	for _, productID := range productIDs {
+		snapshot, ok := snapshots[productID]
+		if (!ok || time.Since(snapshot.ComputedAt) >= SentimentSnapshotTTL()) && !slices.Contains(outdated, productID) {
+			outdated = append(outdated, productID)
+		}
+	}
+	if len(outdated) == 0 {
+		return snapshots, nil
+	}
+
+	analyses, err := MLService.AnalyzeProductSentimentBatch(outdated)
+	if err != nil {
+		log.Printf("Warning: failed to analyze sentiment for %d products, serving stored snapshots: %v", len(outdated), err)
+		return snapshots, nil
+	}
+
+	for _, productID := range outdated {
+		analysis, ok := analyses[productID]
+		if !ok {
+			continue
+		}
+		snapshot, err := storeSentimentSnapshot(productID, analysis)
+		if err != nil {
+			return nil, err
+		}
+		snapshots[productID] = snapshot
+	}
+	return snapshots, nil
+}
+
+// RefreshSentimentSnapshotAsync refreshes a product's sentiment snapshot in the
+// background, for example after a new comment. Requests for a product whose refresh is
+// already running are dropped.
+func RefreshSentimentSnapshotAsync(productID uuid.UUID) {
+	if _, running := sentimentRefreshes.LoadOrStore(productID, struct{}{}); running {
+		return
+	}
+
+	go func() {
+		defer sentimentRefreshes.Delete(productID)
+		if _, err := RefreshSentimentSnapshot(productID); err != nil {
+			log.Printf("Warning: failed to refresh sentiment for product %s: %v", productID, err)
+		}
+	}()
+}
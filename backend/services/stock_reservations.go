@@ -0,0 +1,122 @@
+package services
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"bachelor_backend/database"
+
+	"gorm.io/gorm"
+)
+
+// defaultReservationMinutes is used when STOCK_RESERVATION_MINUTES is not set
+const defaultReservationMinutes = 10
+
+// ReservationDuration returns how long reserved cart quantities are held at checkout
+func ReservationDuration() time.Duration {
+	if value := os.Getenv("STOCK_RESERVATION_MINUTES"); value != "" {
+		if minutes, err := strconv.Atoi(value); err == nil && minutes > 0 {
+			return time.Duration(minutes) * time.Minute
+		}
+	}
+	return defaultReservationMinutes * time.Minute
+}
+
+// ReleaseExpiredReservations deletes expired stock reservations and returns their
+// quantities to the products' available stock. Deleting and releasing happen in a
+// single statement, so a reservation is never released twice.
+func ReleaseExpiredReservations(db *gorm.DB) (int64, error) {
+	result := db.Exec(`
+		WITH expired AS (
+			DELETE FROM stock_reservations WHERE expires_at <= ? RETURNING product_id, quantity
+		), released AS (
+			SELECT product_id, SUM(quantity) AS quantity FROM expired GROUP BY product_id
+		)
+		UPDATE products SET reserved = GREATEST(products.reserved - released.quantity, 0)
+		FROM released
+		WHERE products.id = released.product_id
+	`, time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// ReservationSweeper periodically releases expired stock reservations
+type ReservationSweeper struct {
+	ticker    *time.Ticker
+	stopChan  chan bool
+	isRunning bool
+	lastRun   time.Time
+}
+
+// NewReservationSweeper creates a new reservation sweeper
+func NewReservationSweeper() *ReservationSweeper {
+	return &ReservationSweeper{
+		stopChan:  make(chan bool),
+		isRunning: false,
+	}
+}
+
+// Start begins the periodic sweep
+func (rs *ReservationSweeper) Start(intervalMinutes int) {
+	if rs.isRunning {
+		log.Println("Reservation sweeper is already running")
+		return
+	}
+
+	rs.ticker = time.NewTicker(time.Duration(intervalMinutes) * time.Minute)
+	rs.isRunning = true
+
+	log.Printf("Starting reservation sweeper with %d minute intervals", intervalMinutes)
+
+	go func() {
+		rs.runSweep()
+
+		for {
+			select {
+			case <-rs.ticker.C:
+				rs.runSweep()
+			case <-rs.stopChan:
+				rs.ticker.Stop()
+				rs.isRunning = false
+				log.Println("Reservation sweeper stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic sweep
+func (rs *ReservationSweeper) Stop() {
+	if !rs.isRunning {
+		return
+	}
+
+	rs.stopChan <- true
+}
+
+// runSweep releases expired reservations and logs the outcome
+func (rs *ReservationSweeper) runSweep() {
+	released, err := ReleaseExpiredReservations(database.DB)
+	rs.lastRun = time.Now()
+	if err != nil {
+		log.Printf("Reservation sweep failed: %v", err)
+		return
+	}
+
+	if released > 0 {
+		log.Printf("Reservation sweep completed: reservations released for %d products", released)
+	}
+}
+
+// GetStatus returns the current status of the reservation sweeper
+func (rs *ReservationSweeper) GetStatus() map[string]interface{} {
+	return map[string]interface{}{
+		"is_running":   rs.isRunning,
+		"last_run":     rs.lastRun.Format(time.RFC3339),
+		"service_name": "reservation_sweeper",
+	}
+}
+
+// Global reservation sweeper instance
+var ReservationSweeperInstance = NewReservationSweeper()
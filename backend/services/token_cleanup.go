@@ -0,0 +1,102 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/models"
+)
+
+// TokenCleaner periodically removes revoked-token denylist entries, refresh tokens and
+// password reset tokens that have expired
+type TokenCleaner struct {
+	ticker    *time.Ticker
+	stopChan  chan bool
+	isRunning bool
+	lastRun   time.Time
+}
+
+// NewTokenCleaner creates a new token cleaner
+func NewTokenCleaner() *TokenCleaner {
+	return &TokenCleaner{
+		stopChan:  make(chan bool),
+		isRunning: false,
+	}
+}
+
+// Start begins the periodic cleanup process
+func (tc *TokenCleaner) Start(intervalMinutes int) {
+	if tc.isRunning {
+		log.Println("Token cleaner is already running")
+		return
+	}
+
+	tc.ticker = time.NewTicker(time.Duration(intervalMinutes) * time.Minute)
+	tc.isRunning = true
+
+	log.Printf("Starting token cleaner with %d minute intervals", intervalMinutes)
+
+	go func() {
+		tc.runCleanup()
+
+		for {
+			select {
+			case <-tc.ticker.C:
+				tc.runCleanup()
+			case <-tc.stopChan:
+				tc.ticker.Stop()
+				tc.isRunning = false
+				log.Println("Token cleaner stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic cleanup process
+func (tc *TokenCleaner) Stop() {
+	if !tc.isRunning {
+		return
+	}
+
+	tc.stopChan <- true
+}
+
+// runCleanup deletes expired entries and logs the outcome
+func (tc *TokenCleaner) runCleanup() {
+	now := time.Now()
+	tc.lastRun = now
+
+	revoked := database.DB.Where("expires_at < ?", now).Delete(&models.RevokedToken{})
+	if revoked.Error != nil {
+		log.Printf("Failed to clean up revoked tokens: %v", revoked.Error)
+	}
+
+	refresh := database.DB.Where("expires_at < ?", now).Delete(&models.RefreshToken{})
+	if refresh.Error != nil {
+		log.Printf("Failed to clean up refresh tokens: %v", refresh.Error)
+	}
+
+	reset := database.DB.Where("expires_at < ?", now).Delete(&models.PasswordResetToken{})
+	if reset.Error != nil {
+		log.Printf("Failed to clean up password reset tokens: %v", reset.Error)
+	}
+
+	if revoked.RowsAffected > 0 || refresh.RowsAffected > 0 || reset.RowsAffected > 0 {
+		log.Printf("Token cleanup completed: %d revoked tokens, %d refresh tokens and %d password reset tokens removed",
+			revoked.RowsAffected, refresh.RowsAffected, reset.RowsAffected)
+	}
+}
+
+// GetStatus returns the current status of the token cleaner
+func (tc *TokenCleaner) GetStatus() map[string]interface{} {
+	return map[string]interface{}{
+		"is_running":   tc.isRunning,
+		"last_run":     tc.lastRun.Format(time.RFC3339),
+		"service_name": "token_cleaner",
+	}
+}
+
+// Global token cleaner instance
+var TokenCleanerInstance = NewTokenCleaner()
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NotificationTrainingFinished is sent to the admin who started ML training once it
+// has completed or failed. Admins cannot opt out of it.
+const NotificationTrainingFinished = "training_finished"
+
+// Training job statuses
+const (
+	TrainingJobRunning   = "running"
+	TrainingJobCompleted = "completed"
+	TrainingJobFailed    = "failed"
+)
+
+// ErrTrainingInProgress is returned when training is started while another job runs
+var ErrTrainingInProgress = errors.New("ML training is already running")
+
+// StartTrainingJob records a training job and trains the ML models in the background.
+// The admin who started it is notified when it finishes.
+func StartTrainingJob(startedBy uuid.UUID) (models.TrainingJob, error) {
+	job := models.TrainingJob{
+		Status:    TrainingJobRunning,
+		StartedBy: startedBy,
+		StartedAt: time.Now(),
+	}
+	if err := database.DB.Create(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return job, ErrTrainingInProgress
+		}
+		return job, err
+	}
+
+	go runTrainingJob(job)
+	return job, nil
+}
+
+// runTrainingJob waits for the ML service to train, then records the outcome and
+// notifies the admin who started the job
+func runTrainingJob(job models.TrainingJob) {
+	trainErr := MLService.TrainModels()
+
+	finishedAt := time.Now()
+	job.FinishedAt = &finishedAt
+	job.Status = TrainingJobCompleted
+	if trainErr != nil {
+		job.Status = TrainingJobFailed
+		job.Error = trainErr.Error()
+	}
+
+	if err := database.DB.Model(&job).Updates(map[string]interface{}{
+		"status":      job.Status,
+		"finished_at": job.FinishedAt,
+		"error":       job.Error,
+	}).Error; err != nil {
+		log.Printf("Failed to record outcome of training job %s: %v", job.ID, err)
+	}
+
+	duration := finishedAt.Sub(job.StartedAt).Round(time.Second)
+	title := "ML training completed"
+	body := fmt.Sprintf("Model training finished in %s.", duration)
+	if trainErr != nil {
+		title = "ML training failed"
+		body = fmt.Sprintf("Model training failed after %s: %s", duration, job.Error)
+		log.Printf("Training job %s failed: %v", job.ID, trainErr)
+	} else {
+		log.Printf("Training job %s completed in %s", job.ID, duration)
+	}
+
+	metadata := map[string]interface{}{
+		"job_id": job.ID,
+		"status": job.Status,
+	}
+	if err := NotificationDispatcherInstance.Enqueue(job.StartedBy, NotificationTrainingFinished, title, body, metadata); err != nil {
+		log.Printf("Failed to queue training notification for admin %s: %v", job.StartedBy, err)
+	}
+}
+
+// FailInterruptedTrainingJobs marks jobs left running by a previous process as
+// failed, since their outcome will never be recorded
+func FailInterruptedTrainingJobs() {
+	result := database.DB.Model(&models.TrainingJob{}).
+		Where("status = ?", TrainingJobRunning).
+		Updates(map[string]interface{}{
+			"status":      TrainingJobFailed,
+			"finished_at": time.Now(),
+			"error":       "interrupted by a server restart",
+		})
+	if result.Error != nil {
+		log.Printf("Failed to mark interrupted training jobs: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("Marked %d interrupted training jobs as failed", result.RowsAffected)
+	}
+}
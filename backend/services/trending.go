@@ -0,0 +1,21 @@
+package services
+
+import "time"
+
+// TrendingInteractionWeights is how much each interaction type counts towards a
+// product's trending score. Types not listed, such as admin edits, do not count.
+var TrendingInteractionWeights = map[string]float64{
+	"purchase": 5,
+	"cart_add": 3,
+	"favorite": 2,
+	"wishlist": 2,
+	"upvote":   1.5,
+	"comment":  1.5,
+	"view":     1,
+}
+
+// TrendingHalfLife is how long it takes an interaction's weight in the trending score
+// to halve (TRENDING_HALF_LIFE_HOURS, default 48)
+func TrendingHalfLife() time.Duration {
+	return time.Duration(getEnvInt("TRENDING_HALF_LIFE_HOURS", 48)) * time.Hour
+}
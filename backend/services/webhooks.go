@@ -0,0 +1,265 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"bachelor_backend/database"
+	"bachelor_backend/models"
+
+	"github.com/google/uuid"
+)
+
+// Webhook event types
+const (
+	WebhookEventAnomalyHigh     = "anomaly.high"
+	WebhookEventAnomalyCritical = "anomaly.critical"
+	WebhookEventOrderCreated    = "order.created"
+)
+
+// WebhookEventTypes lists the events webhooks can subscribe to
+var WebhookEventTypes = []string{
+	WebhookEventAnomalyHigh,
+	WebhookEventAnomalyCritical,
+	WebhookEventOrderCreated,
+}
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookPayload is the JSON body posted to webhooks
+type WebhookPayload struct {
+	ID        uuid.UUID   `json:"id"` // Delivery ID, stable across retries so receivers can deduplicate
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookDispatcher delivers queued webhook events. Deliveries are stored before
+// they are sent, so retries survive restarts; failed attempts are retried with
+// exponential backoff until MaxAttempts is reached.
+type WebhookDispatcher struct {
+	httpClient   *http.Client
+	MaxAttempts  int
+	RetryBackoff time.Duration // Delay before the first retry, doubled on each attempt
+	BatchSize    int
+	ticker       *time.Ticker
+	wake         chan struct{}
+	stopChan     chan bool
+	isRunning    bool
+}
+
+// NewWebhookDispatcher creates a new webhook dispatcher
+func NewWebhookDispatcher() *WebhookDispatcher {
+	return &WebhookDispatcher{
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		MaxAttempts:  getEnvInt("WEBHOOK_MAX_ATTEMPTS", 6),
+		RetryBackoff: time.Duration(getEnvInt("WEBHOOK_RETRY_BACKOFF_SECONDS", 30)) * time.Second,
+		BatchSize:    50,
+		wake:         make(chan struct{}, 1),
+		stopChan:     make(chan bool),
+		isRunning:    false,
+	}
+}
+
+// Start begins delivering due webhook events, checking for retries on the given interval
+func (wd *WebhookDispatcher) Start(intervalSeconds int) {
+	if wd.isRunning {
+		log.Println("Webhook dispatcher is already running")
+		return
+	}
+
+	wd.ticker = time.NewTicker(time.Duration(intervalSeconds) * time.Second)
+	wd.isRunning = true
+
+	log.Printf("Starting webhook dispatcher with %d second intervals", intervalSeconds)
+
+	go func() {
+		wd.deliverDue()
+
+		for {
+			select {
+			case <-wd.ticker.C:
+				wd.deliverDue()
+			case <-wd.wake:
+				wd.deliverDue()
+			case <-wd.stopChan:
+				wd.ticker.Stop()
+				wd.isRunning = false
+				log.Println("Webhook dispatcher stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the webhook dispatcher
+func (wd *WebhookDispatcher) Stop() {
+	if !wd.isRunning {
+		return
+	}
+
+	wd.stopChan <- true
+}
+
+// Publish queues an event for every active webhook subscribed to it and wakes the
+// dispatcher. It returns once the deliveries are stored; sending happens in the background.
+func (wd *WebhookDispatcher) Publish(eventType string, data interface{}) error {
+	subscribed, err := json.Marshal([]string{eventType})
+	if err != nil {
+		return err
+	}
+
+	var webhooks []models.Webhook
+	if err := database.DB.Select("id").
+		Where("active AND event_types @> ?::jsonb", string(subscribed)).
+		Find(&webhooks).Error; err != nil {
+		return fmt.Errorf("failed to find subscribed webhooks: %w", err)
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	deliveries := make([]models.WebhookDelivery, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		delivery := models.WebhookDelivery{
+			ID:            uuid.New(),
+			WebhookID:     webhook.ID,
+			EventType:     eventType,
+			Status:        WebhookDeliveryPending,
+			NextAttemptAt: now,
+		}
+
+		payload, err := json.Marshal(WebhookPayload{
+			ID:        delivery.ID,
+			Event:     eventType,
+			CreatedAt: now,
+			Data:      data,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode webhook payload: %w", err)
+		}
+		delivery.Payload = string(payload)
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := database.DB.Create(&deliveries).Error; err != nil {
+		return fmt.Errorf("failed to queue webhook deliveries: %w", err)
+	}
+
+	select {
+	case wd.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// deliverDue sends every pending delivery whose next attempt is due
+func (wd *WebhookDispatcher) deliverDue() {
+	var deliveries []models.WebhookDelivery
+	if err := database.DB.Preload("Webhook").
+		Where("status = ? AND next_attempt_at <= ?", WebhookDeliveryPending, time.Now()).
+		Order("next_attempt_at").
+		Limit(wd.BatchSize).
+		Find(&deliveries).Error; err != nil {
+		log.Printf("Failed to load due webhook deliveries: %v", err)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		wd.attempt(delivery)
+	}
+}
+
+// attempt sends one delivery and records the outcome
+func (wd *WebhookDispatcher) attempt(delivery models.WebhookDelivery) {
+	updates := map[string]interface{}{
+		"attempts": delivery.Attempts + 1,
+	}
+
+	statusCode, err := wd.send(delivery)
+	updates["last_status_code"] = statusCode
+
+	switch {
+	case err == nil:
+		now := time.Now()
+		updates["status"] = WebhookDeliverySucceeded
+		updates["last_error"] = ""
+		updates["delivered_at"] = &now
+	case !delivery.Webhook.Active || delivery.Attempts+1 >= wd.MaxAttempts:
+		updates["status"] = WebhookDeliveryFailed
+		updates["last_error"] = err.Error()
+		log.Printf("Webhook delivery %s to %s failed permanently: %v", delivery.ID, delivery.Webhook.URL, err)
+	default:
+		updates["last_error"] = err.Error()
+		updates["next_attempt_at"] = time.Now().Add(wd.RetryBackoff << delivery.Attempts)
+	}
+
+	if err := database.DB.Model(&models.WebhookDelivery{}).
+		Where("id = ?", delivery.ID).
+		Updates(updates).Error; err != nil {
+		log.Printf("Failed to record webhook delivery %s: %v", delivery.ID, err)
+	}
+}
+
+// send posts the stored payload, signed with the webhook secret
+func (wd *WebhookDispatcher) send(delivery models.WebhookDelivery) (int, error) {
+	if !delivery.Webhook.Active {
+		return 0, fmt.Errorf("webhook is inactive")
+	}
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, delivery.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature", SignWebhookPayload(delivery.Webhook.Secret, body))
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Delivery", delivery.ID.String())
+
+	resp, err := wd.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload returns the X-Signature header value for body: the hex
+// HMAC-SHA256 of the body keyed with the webhook secret, prefixed with "sha256="
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// PublishWebhookEvent queues an event on the global dispatcher, logging failures
+func PublishWebhookEvent(eventType string, data interface{}) {
+	if err := WebhookDispatcherInstance.Publish(eventType, data); err != nil {
+		log.Printf("Failed to publish %s webhook event: %v", eventType, err)
+	}
+}
+
+// Global webhook dispatcher instance
+var WebhookDispatcherInstance = NewWebhookDispatcher()
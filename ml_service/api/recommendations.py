@@ -11,6 +11,7 @@ class RecommendationRequest(BaseModel):
     user_id: str
     algorithm: Optional[str] = "hybrid"  # collaborative, content_based, hybrid, popular
     limit: Optional[int] = 10
+    persist: Optional[bool] = True  # set to False to preview without saving
 
 class RecommendationResponse(BaseModel):
     product_id: str
@@ -55,8 +56,8 @@ async def generate_recommendations(request: RecommendationRequest):
         else:
             raise HTTPException(status_code=400, detail="Invalid algorithm type")
         
-        # Save recommendations to database
-        if recommendations:
+        # Save recommendations to database (skipped for previews)
+        if recommendations and request.persist:
             await recommendation_engine.save_recommendations_to_db(request.user_id, recommendations)
         
         # Convert to response format
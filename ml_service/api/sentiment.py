@@ -1,5 +1,6 @@
 from fastapi import APIRouter, HTTPException, Query
-from typing import Optional
+from pydantic import BaseModel, Field
+from typing import List, Optional
 import logging
 
 from models.sentiment import sentiment_analyzer
@@ -7,6 +8,9 @@ from models.sentiment import sentiment_analyzer
 logger = logging.getLogger(__name__)
 router = APIRouter()
 
+class BatchSentimentRequest(BaseModel):
+    product_ids: List[str] = Field(..., min_length=1, max_length=100)
+
 @router.get("/")
 async def sentiment_status():
     """Get sentiment analysis service status"""
@@ -40,6 +44,18 @@ async def analyze_product_sentiment(product_id: str):
         logger.error(f"Failed to analyze product sentiment: {e}")
         raise HTTPException(status_code=500, detail=str(e))
 
+@router.post("/batch")
+async def analyze_batch_sentiment(request: BatchSentimentRequest):
+    """Analyze sentiment for several products at once"""
+    try:
+        results = {}
+        for product_id in dict.fromkeys(request.product_ids):
+            results[product_id] = await sentiment_analyzer.analyze_product_sentiment(product_id)
+        return {"results": results}
+    except Exception as e:
+        logger.error(f"Failed to analyze batch sentiment: {e}")
+        raise HTTPException(status_code=500, detail=str(e))
+
 @router.get("/category/{category}")
 async def analyze_category_sentiment(category: str):
     """Analyze sentiment for a product category"""
@@ -37,6 +37,31 @@ async def get_sales_forecast(
         "category": category
     }
 
+class DailyRevenue(BaseModel):
+    date: str
+    revenue: float
+
+class RevenueForecastRequest(BaseModel):
+    history: List[DailyRevenue]
+    horizon_days: int = 30
+
+@router.post("/forecast/revenue")
+async def forecast_revenue(request: RevenueForecastRequest):
+    """Project daily revenue forward from its history, with confidence bounds"""
+    if not request.history:
+        raise HTTPException(status_code=400, detail="Revenue history is required")
+    if request.horizon_days < 1 or request.horizon_days > 365:
+        raise HTTPException(status_code=400, detail="horizon_days must be between 1 and 365")
+
+    try:
+        return trend_analyzer.forecast_revenue(
+            [point.model_dump() for point in request.history],
+            request.horizon_days
+        )
+    except Exception as e:
+        logger.error(f"Failed to forecast revenue: {e}")
+        raise HTTPException(status_code=500, detail=f"Failed to forecast revenue: {str(e)}")
+
 @router.get("/popular")
 async def get_trending_products(
     period: str = Query("7d", description="Time period for trending calculation"),
@@ -82,7 +82,7 @@ async def get_user_interactions() -> pd.DataFrame:
         ui.interaction_type,
         ui.timestamp,
         p.category,
-        p.price
+        p.price / 100.0 AS price
     FROM user_interactions ui
     JOIN products p ON ui.product_id = p.id
     ORDER BY ui.timestamp DESC
@@ -95,11 +95,12 @@ async def get_products_data() -> pd.DataFrame:
         id,
         name,
         description,
-        price,
+        price / 100.0 AS price,
         category,
         stock,
         created_at
     FROM products
+    WHERE deleted_at IS NULL
     """
     return await fetch_dataframe(query)
 
@@ -140,8 +141,8 @@ async def get_sales_data() -> pd.DataFrame:
         p.id as product_id,
         p.name as product_name,
         oi.quantity,
-        oi.price,
-        (oi.quantity * oi.price) as total_amount
+        oi.price / 100.0 AS price,
+        (oi.quantity * oi.price) / 100.0 as total_amount
     FROM orders o
     JOIN order_items oi ON o.id = oi.order_id
     JOIN products p ON oi.product_id = p.id
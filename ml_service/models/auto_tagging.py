@@ -46,11 +46,12 @@ class AutoTagger:
                 p.name,
                 p.description,
                 p.category,
-                p.price,
+                p.price / 100.0 AS price,
                 COALESCE(array_agg(t.name) FILTER (WHERE t.name IS NOT NULL), '{}') as existing_tags
             FROM products p
             LEFT JOIN product_tags pt ON p.id = pt.product_id
             LEFT JOIN tags t ON pt.tag_id = t.id
+            WHERE p.deleted_at IS NULL
             GROUP BY p.id, p.name, p.description, p.category, p.price
             """
             
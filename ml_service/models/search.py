@@ -42,10 +42,10 @@ class EnhancedSearchEngine:
         try:
             query = """
             SELECT 
-                id, name, description, category, price, stock,
+                id, name, description, category, price / 100.0 AS price, stock,
                 created_at
             FROM products 
-            WHERE stock > 0
+            WHERE stock > 0 AND deleted_at IS NULL
             ORDER BY created_at DESC
             """
             
@@ -41,12 +41,13 @@ class SmartDiscountEngine:
             SELECT 
                 oi.product_id,
                 oi.quantity,
-                oi.price,
+                oi.price / 100.0 AS price,
                 o.created_at,
                 p.name as product_name,
                 p.category,
-                p.price as current_price,
-                COALESCE(d.discount_value, 0) as discount_applied,
+                p.price / 100.0 as current_price,
+                CASE WHEN d.discount_type = 'fixed_amount' THEN d.amount_off / 100.0
+                     ELSE COALESCE(d.discount_value, 0) END as discount_applied,
                 COALESCE(d.discount_type, 'none') as discount_type
             FROM order_items oi
             JOIN orders o ON oi.order_id = o.id
@@ -79,7 +80,7 @@ class SmartDiscountEngine:
                 ui.interaction_type,
                 ui.created_at,
                 p.category,
-                p.price
+                p.price / 100.0 AS price
             FROM user_interactions ui
             JOIN products p ON ui.product_id = p.id
             WHERE ui.created_at >= $1
@@ -92,7 +93,7 @@ class SmartDiscountEngine:
                 ci.product_id,
                 ci.quantity,
                 ci.created_at,
-                p.price,
+                p.price / 100.0 AS price,
                 p.category,
                 CASE 
                     WHEN EXISTS (
@@ -38,10 +38,10 @@ class TrendAnalyzer:
                 p.id as product_id,
                 p.name as product_name,
                 p.category,
-                p.price,
+                p.price / 100.0 AS price,
                 DATE(o.created_at) as date,
                 SUM(oi.quantity) as units_sold,
-                SUM(oi.quantity * oi.price) as revenue
+                SUM(oi.quantity * oi.price) / 100.0 as revenue
             FROM orders o
             JOIN order_items oi ON o.id = oi.order_id
             JOIN products p ON oi.product_id = p.id
@@ -403,6 +403,54 @@ class TrendAnalyzer:
             logger.error(f"Forecasting failed for product {product_id}: {e}")
             return {'error': str(e)}
 
+    def forecast_revenue(self, history: List[Dict[str, Any]], horizon_days: int = 30) -> Dict[str, Any]:
+        """Project daily revenue with a linear trend plus day-of-week effects and 95% bounds"""
+        if not history:
+            raise ValueError("Revenue history is empty")
+
+        dates = pd.to_datetime([point['date'] for point in history])
+        revenue = np.array([float(point['revenue']) for point in history])
+
+        def features(index: np.ndarray, weekdays: np.ndarray) -> np.ndarray:
+            weekday_dummies = np.eye(7)[weekdays][:, 1:]
+            return np.column_stack([index, weekday_dummies])
+
+        index = np.arange(len(history))
+        use_weekdays = len(history) >= 14
+        X = features(index, dates.dayofweek.values) if use_weekdays else index.reshape(-1, 1)
+
+        model = LinearRegression()
+        model.fit(X, revenue)
+
+        residuals = revenue - model.predict(X)
+        dof = max(len(history) - X.shape[1] - 1, 1)
+        residual_std = float(np.sqrt(np.sum(residuals ** 2) / dof))
+
+        future_dates = pd.date_range(dates[-1] + timedelta(days=1), periods=horizon_days, freq='D')
+        future_index = np.arange(len(history), len(history) + horizon_days)
+        future_X = features(future_index, future_dates.dayofweek.values) if use_weekdays else future_index.reshape(-1, 1)
+        predictions = model.predict(future_X)
+
+        # Widen the interval the further the projection is from the observed data
+        spread = np.sqrt(1 + (future_index - index.mean()) ** 2 / max(np.sum((index - index.mean()) ** 2), 1))
+        margins = 1.96 * residual_std * spread
+
+        forecast = []
+        for date, predicted, margin in zip(future_dates, predictions, margins):
+            forecast.append({
+                'date': date.strftime('%Y-%m-%d'),
+                'revenue': max(float(predicted), 0.0),
+                'lower_bound': max(float(predicted - margin), 0.0),
+                'upper_bound': max(float(predicted + margin), 0.0)
+            })
+
+        return {
+            'forecast': forecast,
+            'model': 'linear_regression_weekly' if use_weekdays else 'linear_regression',
+            'confidence_level': 0.95,
+            'generated_at': datetime.now().isoformat()
+        }
+
     def _calculate_trend_score(self, metrics: Dict[str, Any]) -> float:
         if not metrics:
             return 0.0